@@ -1,11 +1,26 @@
 package oci
 
-import "strings"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
 
 // Klaus-specific OCI manifest annotation keys. All artifact types
 // (plugins, personalities, toolchains) use these annotations to carry
 // common metadata on the manifest.
 const (
+	// AnnotationType carries the artifact Kind ("plugin", "personality",
+	// or "toolchain").
+	AnnotationType        = "io.giantswarm.klaus.type"
 	AnnotationName        = "io.giantswarm.klaus.name"
 	AnnotationDescription = "io.giantswarm.klaus.description"
 	AnnotationHomepage    = "io.giantswarm.klaus.homepage"
@@ -15,12 +30,31 @@ const (
 	AnnotationAuthorName  = "io.giantswarm.klaus.author.name"
 	AnnotationAuthorEmail = "io.giantswarm.klaus.author.email"
 	AnnotationAuthorURL   = "io.giantswarm.klaus.author.url"
+
+	// AnnotationPrivate marks an artifact as private ("true"). Describe
+	// omits the private metadata of such artifacts unless the client was
+	// created WithIncludePrivate.
+	AnnotationPrivate = "io.giantswarm.klaus.private"
+
+	// AnnotationQuarantineReason carries the reason on quarantine markers
+	// (see Client.Quarantine).
+	AnnotationQuarantineReason = "io.giantswarm.klaus.quarantine.reason"
 )
 
+// privateAnnotations are the annotations withheld from describe results of
+// private artifacts: links to internal sites and author contact details.
+var privateAnnotations = []string{
+	AnnotationHomepage,
+	AnnotationRepository,
+	AnnotationAuthorEmail,
+	AnnotationAuthorURL,
+}
+
 // commonMetadata holds the shared metadata fields that all Klaus artifact
 // types (plugins, personalities, toolchains) carry via OCI manifest
 // annotations. Using a struct avoids error-prone positional parameters.
 type commonMetadata struct {
+	Kind        Kind
 	Name        string
 	Description string
 	Author      *Author
@@ -28,6 +62,7 @@ type commonMetadata struct {
 	SourceRepo  string
 	License     string
 	Keywords    []string
+	Private     bool
 }
 
 // buildKlausAnnotations builds an OCI manifest annotation map from common
@@ -36,6 +71,9 @@ type commonMetadata struct {
 func buildKlausAnnotations(m commonMetadata) map[string]string {
 	annotations := make(map[string]string)
 
+	if m.Kind != "" {
+		annotations[AnnotationType] = string(m.Kind)
+	}
 	if m.Name != "" {
 		annotations[AnnotationName] = m.Name
 	}
@@ -54,6 +92,9 @@ func buildKlausAnnotations(m commonMetadata) map[string]string {
 	if len(m.Keywords) > 0 {
 		annotations[AnnotationKeywords] = strings.Join(m.Keywords, ",")
 	}
+	if m.Private {
+		annotations[AnnotationPrivate] = "true"
+	}
 	if m.Author != nil {
 		if m.Author.Name != "" {
 			annotations[AnnotationAuthorName] = m.Author.Name
@@ -76,11 +117,13 @@ func buildKlausAnnotations(m commonMetadata) map[string]string {
 // common metadata fields. Missing annotation keys result in zero values.
 func metadataFromAnnotations(annotations map[string]string) commonMetadata {
 	m := commonMetadata{
+		Kind:        KindFromAnnotations(annotations),
 		Name:        annotations[AnnotationName],
 		Description: annotations[AnnotationDescription],
 		Homepage:    annotations[AnnotationHomepage],
 		SourceRepo:  annotations[AnnotationRepository],
 		License:     annotations[AnnotationLicense],
+		Private:     annotations[AnnotationPrivate] == "true",
 	}
 
 	if kw := annotations[AnnotationKeywords]; kw != "" {
@@ -101,6 +144,61 @@ func metadataFromAnnotations(annotations map[string]string) commonMetadata {
 	return m
 }
 
+// KindFromAnnotations returns the artifact kind recorded in the
+// AnnotationType manifest annotation, or "" when the annotation is missing
+// or holds an unknown kind. Kinds added with RegisterArtifactKind are
+// known.
+func KindFromAnnotations(annotations map[string]string) Kind {
+	switch k := Kind(annotations[AnnotationType]); k {
+	case KindPlugin, KindPersonality, KindToolchain:
+		return k
+	case "":
+		return ""
+	default:
+		if isRegisteredKind(k) {
+			return k
+		}
+	}
+	return ""
+}
+
+// redactAnnotations returns annotations without privateAnnotations when
+// they mark a private artifact, and whether any annotation was removed.
+// The input map is not modified.
+func redactAnnotations(annotations map[string]string) (map[string]string, bool) {
+	if annotations[AnnotationPrivate] != "true" {
+		return annotations, false
+	}
+	redacted := maps.Clone(annotations)
+	removed := false
+	for _, k := range privateAnnotations {
+		if _, ok := redacted[k]; ok {
+			delete(redacted, k)
+			removed = true
+		}
+	}
+	return redacted, removed
+}
+
+// Annotations returns the manifest annotations PushPlugin sets for p:
+// the artifact type and p's common metadata.
+func (p Plugin) Annotations() map[string]string {
+	return buildKlausAnnotations(p.klausMetadata())
+}
+
+// Annotations returns the manifest annotations PushPersonality sets for
+// p: the artifact type and p's common metadata.
+func (p Personality) Annotations() map[string]string {
+	return buildKlausAnnotations(p.klausMetadata())
+}
+
+// Annotations returns the manifest annotations a toolchain image should
+// carry so that DescribeToolchain and listings can read its metadata,
+// e.g. to pass to `docker buildx build --annotation`.
+func (t Toolchain) Annotations() map[string]string {
+	return buildKlausAnnotations(t.klausMetadata())
+}
+
 // pluginFromAnnotations assembles a Plugin from OCI manifest annotations
 // (common metadata) and a config blob (type-specific fields).
 func pluginFromAnnotations(annotations map[string]string, tag string, blob pluginConfigBlob) Plugin {
@@ -113,6 +211,7 @@ func pluginFromAnnotations(annotations map[string]string, tag string, blob plugi
 		SourceRepo:  m.SourceRepo,
 		License:     m.License,
 		Keywords:    m.Keywords,
+		Private:     m.Private,
 		Version:     tag,
 		Skills:      blob.Skills,
 		Commands:    blob.Commands,
@@ -120,6 +219,11 @@ func pluginFromAnnotations(annotations map[string]string, tag string, blob plugi
 		HasHooks:    blob.HasHooks,
 		MCPServers:  blob.MCPServers,
 		LSPServers:  blob.LSPServers,
+		ContentHash: blob.ContentHash,
+
+		Dependencies: blob.Dependencies,
+		Secrets:      blob.Secrets,
+		Permissions:  blob.Permissions,
 	}
 }
 
@@ -135,9 +239,14 @@ func personalityFromAnnotations(annotations map[string]string, tag string, blob
 		SourceRepo:  m.SourceRepo,
 		License:     m.License,
 		Keywords:    m.Keywords,
+		Private:     m.Private,
 		Version:     tag,
 		Toolchain:   blob.Toolchain,
 		Plugins:     blob.Plugins,
+		Extends:     blob.Extends,
+		ContentHash: blob.ContentHash,
+
+		Requirements: blob.Requirements,
 	}
 }
 
@@ -154,5 +263,63 @@ func toolchainFromAnnotations(annotations map[string]string) Toolchain {
 		SourceRepo:  m.SourceRepo,
 		License:     m.License,
 		Keywords:    m.Keywords,
+		Private:     m.Private,
+	}
+}
+
+// AnnotationsFileName is the optional file in an artifact source directory
+// whose annotations pushes and builds add to the manifest, so repository
+// owners can add annotations such as "org.opencontainers.image.source"
+// without code changes in CI. It is a flat YAML map of keys to string
+// values:
+//
+//	org.opencontainers.image.source: https://github.com/giantswarm/gs-base
+//	com.example.team: platform
+//
+// Annotations derived from the artifact's metadata take precedence: keys
+// the artifact already sets are kept, and keys in the io.giantswarm.klaus.
+// namespace are rejected, since they would contradict the metadata. The
+// file stays part of the content layer.
+const AnnotationsFileName = "annotations.yaml"
+
+// klausAnnotationPrefix starts the annotation keys the client derives
+// from artifact metadata.
+const klausAnnotationPrefix = "io.giantswarm.klaus."
+
+// mergeAnnotationsFile returns annotations with those of sourceDir's
+// AnnotationsFileName added, following its precedence rules. The input
+// map is not modified.
+func mergeAnnotationsFile(sourceDir string, annotations map[string]string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(sourceDir, AnnotationsFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return annotations, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", AnnotationsFileName, err)
+	}
+	var file map[string]string
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing %s: %w", AnnotationsFileName, err)
+	}
+	if len(file) == 0 {
+		return annotations, nil
+	}
+
+	merged := maps.Clone(annotations)
+	if merged == nil {
+		merged = make(map[string]string, len(file))
+	}
+	for k, v := range file {
+		if k == "" {
+			return nil, fmt.Errorf("parsing %s: empty annotation key", AnnotationsFileName)
+		}
+		if strings.HasPrefix(k, klausAnnotationPrefix) {
+			return nil, fmt.Errorf("parsing %s: annotation %s is derived from the artifact metadata and cannot be set", AnnotationsFileName, k)
+		}
+		if _, ok := merged[k]; !ok {
+			merged[k] = v
+		}
 	}
+	return merged, nil
 }
@@ -8,26 +8,171 @@ import (
 	"io"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"time"
 )
 
-// maxExtractFileSize is the per-file size limit during extraction (100 MB).
-const maxExtractFileSize = 100 << 20
+// Default extraction limits; see ExtractionPolicy.
+const (
+	// maxExtractFileSize is the default per-file size limit (100 MB).
+	maxExtractFileSize = 100 << 20
+	// maxExtractTotalSize is the default limit for all files combined (1 GB).
+	maxExtractTotalSize = 1 << 30
+	// maxExtractEntries is the default limit for the number of entries.
+	maxExtractEntries = 10000
+	// maxExtractPathLength is the default limit for entry names in bytes.
+	maxExtractPathLength = 1024
+	// maxExtractDepth is the default limit for directory nesting.
+	maxExtractDepth = 32
+)
+
+// ExtractionPolicy limits what pulling may write to disk. Content layers of
+// third-party artifacts are untrusted, so every limit has a conservative
+// default. A zero field uses the default; a negative field disables the
+// limit.
+//
+// Independent of the limits, extraction always rejects entries that escape
+// the destination (including hard links pointing outside it), device nodes
+// and FIFOs, and names that only differ in case, which would overwrite each
+// other on case-insensitive filesystems. Symbolic links are skipped.
+type ExtractionPolicy struct {
+	// MaxFileSize is the maximum size of a single file in bytes.
+	MaxFileSize int64
+	// MaxTotalSize is the maximum size of all files combined in bytes.
+	MaxTotalSize int64
+	// MaxEntries is the maximum number of archive entries.
+	MaxEntries int
+	// MaxPathLength is the maximum length of an entry name in bytes.
+	MaxPathLength int
+	// MaxDepth is the maximum number of path components of an entry.
+	MaxDepth int
+	// FileModes controls how permission bits from the archive are applied.
+	// Defaults to FileModesExact.
+	FileModes FileModePolicy
+}
+
+// FileModePolicy controls how extraction applies permission bits.
+type FileModePolicy string
+
+const (
+	// FileModesExact restores the permission bits (0777) recorded in the
+	// archive for files and directories, independent of the process umask.
+	// Directories always keep owner read, write and execute permission.
+	FileModesExact FileModePolicy = ""
+	// FileModesNormalize uses 0755 for directories and executable files
+	// and 0644 for everything else.
+	FileModesNormalize FileModePolicy = "normalize"
+	// FileModesUmask creates files with the archived permission bits
+	// filtered through the process umask, and directories with 0755 minus
+	// the umask.
+	FileModesUmask FileModePolicy = "umask"
+)
+
+// mode returns the permission bits to apply for an entry with the given
+// archived mode. Entries without permission bits get 0644 (files) or 0755
+// (directories).
+func (p FileModePolicy) mode(archived int64, dir bool) os.FileMode {
+	mode := os.FileMode(archived) & 0o777
+	if mode == 0 {
+		mode = 0o644
+		if dir {
+			mode = 0o755
+		}
+	}
+	if p == FileModesNormalize {
+		if dir || mode&0o111 != 0 {
+			return 0o755
+		}
+		return 0o644
+	}
+	if dir {
+		// The owner keeps full access so the tree can be removed again
+		// when the artifact is re-pulled.
+		mode |= 0o700
+	}
+	return mode
+}
+
+// DefaultExtractionPolicy returns the policy used when none is configured.
+func DefaultExtractionPolicy() ExtractionPolicy {
+	return ExtractionPolicy{
+		MaxFileSize:   maxExtractFileSize,
+		MaxTotalSize:  maxExtractTotalSize,
+		MaxEntries:    maxExtractEntries,
+		MaxPathLength: maxExtractPathLength,
+		MaxDepth:      maxExtractDepth,
+	}
+}
+
+// WithExtractionPolicy sets the limits applied when extracting pulled
+// content layers.
+func WithExtractionPolicy(p ExtractionPolicy) ClientOption {
+	return func(c *Client) { c.extraction = p }
+}
+
+// withDefaults fills zero fields from DefaultExtractionPolicy.
+func (p ExtractionPolicy) withDefaults() ExtractionPolicy {
+	d := DefaultExtractionPolicy()
+	if p.MaxFileSize == 0 {
+		p.MaxFileSize = d.MaxFileSize
+	}
+	if p.MaxTotalSize == 0 {
+		p.MaxTotalSize = d.MaxTotalSize
+	}
+	if p.MaxEntries == 0 {
+		p.MaxEntries = d.MaxEntries
+	}
+	if p.MaxPathLength == 0 {
+		p.MaxPathLength = d.MaxPathLength
+	}
+	if p.MaxDepth == 0 {
+		p.MaxDepth = d.MaxDepth
+	}
+	return p
+}
+
+// exceeds reports whether n is over limit, treating negative limits as
+// unlimited.
+func exceeds[T int | int64](n, limit T) bool {
+	return limit >= 0 && n > limit
+}
+
+// extractTarGz extracts a gzip-compressed tar archive to destDir, enforcing
+// policy. Zero policy fields use the defaults.
+func extractTarGz(r io.Reader, destDir string, policy ExtractionPolicy) error {
+	policy = policy.withDefaults()
 
-// extractTarGz extracts a gzip-compressed tar archive to destDir.
-// It validates paths to prevent directory traversal attacks and limits
-// individual file sizes.
-func extractTarGz(r io.Reader, destDir string) error {
 	gzr, err := gzip.NewReader(r)
 	if err != nil {
 		return fmt.Errorf("creating gzip reader: %w", err)
 	}
 	defer gzr.Close()
 
-	cleanDest := filepath.Clean(destDir)
+	// An absolute destination lets the os package handle paths beyond
+	// MAX_PATH on Windows.
+	cleanDest, err := filepath.Abs(destDir)
+	if err != nil {
+		return fmt.Errorf("resolving destination %s: %w", destDir, err)
+	}
 	tr := tar.NewReader(gzr)
 
+	var (
+		entries   int
+		totalSize int64
+		// names maps lower-cased entry names to their original spelling
+		// to catch case-only collisions.
+		names = make(map[string]string)
+		// files records extracted regular files as hard link targets.
+		files = make(map[string]bool)
+		// dirs records directory modes, applied once all entries are
+		// written so read-only directories do not block their contents.
+		dirs = make(map[string]os.FileMode)
+	)
+
 	for {
 		header, err := tr.Next()
 		if err == io.EOF {
@@ -37,63 +182,174 @@ func extractTarGz(r io.Reader, destDir string) error {
 			return fmt.Errorf("reading tar entry: %w", err)
 		}
 
-		name := filepath.Clean(header.Name)
-		if strings.HasPrefix(name, "..") || filepath.IsAbs(name) {
-			return fmt.Errorf("invalid path in archive: %s", header.Name)
+		entries++
+		if exceeds(entries, policy.MaxEntries) {
+			return fmt.Errorf("archive has more than %d entries", policy.MaxEntries)
+		}
+		if exceeds(len(header.Name), policy.MaxPathLength) {
+			return fmt.Errorf("entry name exceeds %d bytes: %.64s...", policy.MaxPathLength, header.Name)
 		}
 
-		target := filepath.Join(destDir, name)
+		name, err := cleanEntryName(header.Name)
+		if err != nil {
+			return err
+		}
+		if exceeds(len(strings.Split(name, "/")), policy.MaxDepth) {
+			return fmt.Errorf("entry %s is nested deeper than %d levels", header.Name, policy.MaxDepth)
+		}
+		if extractForWindows {
+			if err := checkPortablePath(name); err != nil {
+				return fmt.Errorf("entry %s cannot be extracted on Windows: %w", header.Name, err)
+			}
+		}
 
-		if !strings.HasPrefix(filepath.Clean(target), cleanDest) {
+		target := filepath.Join(cleanDest, filepath.FromSlash(name))
+		if !strings.HasPrefix(target, cleanDest+string(filepath.Separator)) {
 			return fmt.Errorf("path escapes destination: %s", header.Name)
 		}
 
+		switch header.Typeflag {
+		case tar.TypeDir, tar.TypeReg, tar.TypeLink:
+			lower := strings.ToLower(name)
+			if prev, ok := names[lower]; ok && prev != name {
+				return fmt.Errorf("entries %s and %s differ only in case", prev, name)
+			}
+			names[lower] = name
+		}
+
 		switch header.Typeflag {
 		case tar.TypeDir:
 			if err := os.MkdirAll(target, 0o755); err != nil {
 				return fmt.Errorf("creating directory %s: %w", target, err)
 			}
+			dirs[target] = policy.FileModes.mode(header.Mode, true)
 
 		case tar.TypeReg:
-			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
-				return fmt.Errorf("creating parent directory for %s: %w", target, err)
+			if exceeds(header.Size, policy.MaxFileSize) {
+				return fmt.Errorf("file %s exceeds max size (%d bytes)", header.Name, policy.MaxFileSize)
 			}
-
-			mode := os.FileMode(header.Mode) & 0o777
-			if mode == 0 {
-				mode = 0o644
+			n, err := writeExtractedFile(target, header.Mode, tr, policy.MaxFileSize, policy.FileModes)
+			if err != nil {
+				return fmt.Errorf("extracting file %s: %w", target, err)
+			}
+			if exceeds(n, policy.MaxFileSize) {
+				return fmt.Errorf("file %s exceeds max size (%d bytes)", header.Name, policy.MaxFileSize)
+			}
+			totalSize += n
+			if exceeds(totalSize, policy.MaxTotalSize) {
+				return fmt.Errorf("archive content exceeds %d bytes", policy.MaxTotalSize)
 			}
+			files[name] = true
 
-			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+		case tar.TypeLink:
+			// Hard links are materialized as copies of a file extracted
+			// earlier from the same archive; anything else is rejected.
+			linkName, err := cleanEntryName(header.Linkname)
 			if err != nil {
-				return fmt.Errorf("creating file %s: %w", target, err)
+				return fmt.Errorf("hard link %s: %w", header.Name, err)
 			}
-
-			n, err := io.Copy(f, io.LimitReader(tr, maxExtractFileSize+1))
-			if closeErr := f.Close(); closeErr != nil && err == nil {
-				err = closeErr
+			if !files[linkName] {
+				return fmt.Errorf("hard link %s points to %s, which is not a file in the archive", header.Name, header.Linkname)
 			}
+			src, err := os.Open(filepath.Join(cleanDest, filepath.FromSlash(linkName)))
 			if err != nil {
-				return fmt.Errorf("extracting file %s: %w", target, err)
+				return fmt.Errorf("opening hard link target %s: %w", header.Linkname, err)
 			}
-
-			if n > maxExtractFileSize {
-				return fmt.Errorf("file %s exceeds max size (%d bytes)", header.Name, maxExtractFileSize)
+			n, err := writeExtractedFile(target, header.Mode, src, -1, policy.FileModes)
+			src.Close()
+			if err != nil {
+				return fmt.Errorf("extracting hard link %s: %w", target, err)
+			}
+			totalSize += n
+			if exceeds(totalSize, policy.MaxTotalSize) {
+				return fmt.Errorf("archive content exceeds %d bytes", policy.MaxTotalSize)
 			}
+			files[name] = true
+
+		case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+			return fmt.Errorf("entry %s is a device node or FIFO", header.Name)
 
 		default:
 			// Skip symlinks and other types for security.
 		}
 	}
 
+	if policy.FileModes == FileModesUmask {
+		return nil
+	}
+	// Deepest directories first, so a parent's mode cannot prevent
+	// changing its children.
+	targets := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		targets = append(targets, dir)
+	}
+	slices.SortFunc(targets, func(a, b string) int { return len(b) - len(a) })
+	for _, dir := range targets {
+		if err := os.Chmod(dir, dirs[dir]); err != nil {
+			return fmt.Errorf("setting mode of %s: %w", dir, err)
+		}
+	}
 	return nil
 }
 
+// cleanEntryName returns the slash-separated clean form of an archive
+// entry name, rejecting absolute paths and parent directory references.
+func cleanEntryName(raw string) (string, error) {
+	name := path.Clean(strings.ReplaceAll(raw, "\\", "/"))
+	if name == "." || name == ".." || strings.HasPrefix(name, "../") || path.IsAbs(name) || filepath.IsAbs(raw) || filepath.VolumeName(raw) != "" {
+		return "", fmt.Errorf("invalid path in archive: %s", raw)
+	}
+	return name, nil
+}
+
+// writeExtractedFile writes r to target, creating parent directories, and
+// returns the number of bytes written. At most limit+1 bytes are copied so
+// callers can detect oversized files; a negative limit copies everything.
+// The archived mode is applied according to modes.
+func writeExtractedFile(target string, archivedMode int64, r io.Reader, limit int64, modes FileModePolicy) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return 0, fmt.Errorf("creating parent directory: %w", err)
+	}
+
+	mode := modes.mode(archivedMode, false)
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return 0, err
+	}
+	if modes != FileModesUmask {
+		// OpenFile applies the umask, and does not change the mode of an
+		// existing file.
+		if err := f.Chmod(mode); err != nil {
+			f.Close()
+			return 0, err
+		}
+	}
+	if limit >= 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+	n, err := io.Copy(f, r)
+	if closeErr := f.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return n, err
+}
+
 // createTarGz creates a gzip-compressed tar archive of the given directory.
 // Hidden files starting with ".oci-cache" (cache metadata) are excluded.
+// The archive is reproducible: entries are written in lexical order and
+// their headers are normalized (see normalizeHeader), so the same content
+// always produces the same bytes and therefore the same layer digest.
 func createTarGz(sourceDir string) ([]byte, error) {
 	var buf bytes.Buffer
-	gzw := gzip.NewWriter(&buf)
+	if err := writeTarGz(&buf, sourceDir); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeTarGz streams the archive createTarGz creates of sourceDir to w.
+func writeTarGz(w io.Writer, sourceDir string) error {
+	gzw := gzip.NewWriter(w)
 	tw := tar.NewWriter(gzw)
 
 	err := filepath.WalkDir(sourceDir, func(path string, d fs.DirEntry, err error) error {
@@ -130,6 +386,10 @@ func createTarGz(sourceDir string) ([]byte, error) {
 			return err
 		}
 		header.Name = filepath.ToSlash(relPath)
+		if err := checkPortablePath(header.Name); err != nil {
+			return fmt.Errorf("%s cannot be extracted on Windows: %w", header.Name, err)
+		}
+		normalizeHeader(header)
 
 		if err := tw.WriteHeader(header); err != nil {
 			return err
@@ -150,26 +410,115 @@ func createTarGz(sourceDir string) ([]byte, error) {
 	})
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	if err := tw.Close(); err != nil {
-		return nil, err
+		return err
 	}
-	if err := gzw.Close(); err != nil {
-		return nil, err
+	return gzw.Close()
+}
+
+// extractForWindows enables the Windows file name checks during
+// extraction. It is a variable so tests can exercise the checks anywhere.
+var extractForWindows = runtime.GOOS == "windows"
+
+// windowsReservedNames are device names Windows reserves in every
+// directory, with or without an extension.
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// checkPortablePath reports why the slash-separated path p cannot be
+// created on Windows: reserved device names, characters Windows forbids,
+// trailing dots or spaces, or components longer than 255 characters.
+// Packaging rejects such paths so every artifact can be pulled on Windows.
+func checkPortablePath(p string) error {
+	for _, elem := range strings.Split(p, "/") {
+		if len(elem) > 255 {
+			return fmt.Errorf("name %.32s... is longer than 255 characters", elem)
+		}
+		if i := strings.IndexFunc(elem, func(r rune) bool {
+			return r < 0x20 || strings.ContainsRune(`<>:"\|?*`, r)
+		}); i >= 0 {
+			return fmt.Errorf("name %q contains %q", elem, elem[i])
+		}
+		if strings.HasSuffix(elem, ".") || strings.HasSuffix(elem, " ") {
+			return fmt.Errorf("name %q ends with a dot or space", elem)
+		}
+		base, _, _ := strings.Cut(elem, ".")
+		if windowsReservedNames[strings.ToUpper(strings.TrimRight(base, " "))] {
+			return fmt.Errorf("name %q is reserved", elem)
+		}
 	}
+	return nil
+}
 
-	return buf.Bytes(), nil
+// normalizeHeader strips the parts of a tar header that depend on the
+// machine or checkout rather than the content: timestamps, ownership, and
+// permission bits other than the executable bit.
+func normalizeHeader(h *tar.Header) {
+	h.ModTime = time.Time{}
+	h.AccessTime = time.Time{}
+	h.ChangeTime = time.Time{}
+	h.Uid, h.Gid = 0, 0
+	h.Uname, h.Gname = "", ""
+	h.Format = tar.FormatPAX
+	switch {
+	case h.Typeflag == tar.TypeDir, h.Mode&0o111 != 0:
+		h.Mode = 0o755
+	default:
+		h.Mode = 0o644
+	}
 }
 
-// cleanAndCreate removes an existing directory and recreates it.
-func cleanAndCreate(dir string) error {
-	if err := os.RemoveAll(dir); err != nil {
-		return fmt.Errorf("cleaning destination %s: %w", dir, err)
+// stagingDir creates an empty directory next to dir to extract into, so
+// an interrupted extraction never touches dir. Pass it to replaceDir once
+// the extraction is complete, and remove it otherwise.
+func stagingDir(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("resolving destination %s: %w", dir, err)
 	}
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return fmt.Errorf("creating destination %s: %w", dir, err)
+	if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+		return "", fmt.Errorf("creating destination %s: %w", dir, err)
+	}
+	staged, err := os.MkdirTemp(filepath.Dir(abs), "."+filepath.Base(abs)+".staging-")
+	if err != nil {
+		return "", fmt.Errorf("creating staging directory for %s: %w", dir, err)
+	}
+	if err := os.Chmod(staged, 0o755); err != nil {
+		os.Remove(staged)
+		return "", fmt.Errorf("creating staging directory for %s: %w", dir, err)
+	}
+	return staged, nil
+}
+
+// replaceDir moves staged into place as dir. An existing dir is moved
+// aside first and restored if staged cannot take its place, so dir holds
+// either its previous or its new content, never a mix.
+func replaceDir(staged, dir string) error {
+	previous := ""
+	if _, err := os.Lstat(dir); err == nil {
+		previous = staged + ".previous"
+		if err := os.Rename(dir, previous); err != nil {
+			return fmt.Errorf("replacing destination %s: %w", dir, err)
+		}
+	}
+	if err := os.Rename(staged, dir); err != nil {
+		if previous != "" {
+			os.Rename(previous, dir)
+		}
+		return fmt.Errorf("replacing destination %s: %w", dir, err)
+	}
+	if previous != "" {
+		if err := os.RemoveAll(previous); err != nil {
+			return fmt.Errorf("removing previous content of %s: %w", dir, err)
+		}
 	}
 	return nil
 }
@@ -6,7 +6,9 @@ import (
 	"compress/gzip"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCreateAndExtractTarGz(t *testing.T) {
@@ -34,7 +36,7 @@ func TestCreateAndExtractTarGz(t *testing.T) {
 
 	// Extract to a new directory.
 	destDir := t.TempDir()
-	if err := extractTarGz(bytes.NewReader(data), destDir); err != nil {
+	if err := extractTarGz(bytes.NewReader(data), destDir, ExtractionPolicy{}); err != nil {
 		t.Fatalf("extractTarGz: %v", err)
 	}
 
@@ -106,7 +108,7 @@ func TestExtractTarGz_PathTraversal(t *testing.T) {
 	gzw.Close()
 
 	destDir := t.TempDir()
-	err := extractTarGz(&buf, destDir)
+	err := extractTarGz(&buf, destDir, ExtractionPolicy{})
 	if err == nil {
 		t.Error("expected error for path traversal attempt")
 	}
@@ -131,8 +133,306 @@ func TestExtractTarGz_FileSizeLimit(t *testing.T) {
 	gzw.Close()
 
 	destDir := t.TempDir()
-	err := extractTarGz(&buf, destDir)
+	err := extractTarGz(&buf, destDir, ExtractionPolicy{})
 	if err == nil {
 		t.Error("expected error for oversized file")
 	}
 }
+
+func TestCreateTarGz_Reproducible(t *testing.T) {
+	build := func(mtime time.Time, mode os.FileMode) []byte {
+		t.Helper()
+		srcDir := t.TempDir()
+		path := filepath.Join(srcDir, "SOUL.md")
+		if err := os.WriteFile(path, []byte("Be calm."), mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		data, err := createTarGz(srcDir)
+		if err != nil {
+			t.Fatalf("createTarGz: %v", err)
+		}
+		return data
+	}
+
+	a := build(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 0o600)
+	b := build(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC), 0o664)
+	if !bytes.Equal(a, b) {
+		t.Error("archives differ for identical content with different mtimes and modes")
+	}
+
+	gzr, err := gzip.NewReader(bytes.NewReader(a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hdr, err := tar.NewReader(gzr).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hdr.ModTime.Equal(time.Unix(0, 0)) || hdr.Mode != 0o644 || hdr.Uid != 0 || hdr.Uname != "" {
+		t.Errorf("header = %+v, want normalized", hdr)
+	}
+}
+
+// tarEntry is an archive entry for buildTarGz.
+type tarEntry struct {
+	header tar.Header
+	body   string
+}
+
+// buildTarGz writes entries into a gzip-compressed tar archive. Regular
+// file sizes are taken from the body.
+func buildTarGz(t testing.TB, entries ...tarEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for _, e := range entries {
+		h := e.header
+		if h.Typeflag == tar.TypeReg {
+			h.Size = int64(len(e.body))
+		}
+		if h.Mode == 0 {
+			h.Mode = 0o644
+		}
+		if err := tw.WriteHeader(&h); err != nil {
+			t.Fatalf("writing header %s: %v", h.Name, err)
+		}
+		if e.body != "" {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func file(name, body string) tarEntry {
+	return tarEntry{header: tar.Header{Name: name, Typeflag: tar.TypeReg}, body: body}
+}
+
+func TestExtractTarGz_Policy(t *testing.T) {
+	deep := strings.Repeat("d/", 40) + "f"
+
+	tests := []struct {
+		name    string
+		entries []tarEntry
+		policy  ExtractionPolicy
+		wantErr string
+	}{
+		{
+			name:    "long name",
+			entries: []tarEntry{file(strings.Repeat("a", 2000), "x")},
+			wantErr: "exceeds 1024 bytes",
+		},
+		{
+			name:    "deep nesting",
+			entries: []tarEntry{file(deep, "x")},
+			wantErr: "nested deeper than 32",
+		},
+		{
+			name:    "deep nesting allowed",
+			entries: []tarEntry{file(deep, "x")},
+			policy:  ExtractionPolicy{MaxDepth: -1},
+		},
+		{
+			name:    "too many entries",
+			entries: []tarEntry{file("a", "x"), file("b", "x"), file("c", "x")},
+			policy:  ExtractionPolicy{MaxEntries: 2},
+			wantErr: "more than 2 entries",
+		},
+		{
+			name:    "total size",
+			entries: []tarEntry{file("a", "12345"), file("b", "12345")},
+			policy:  ExtractionPolicy{MaxTotalSize: 8},
+			wantErr: "exceeds 8 bytes",
+		},
+		{
+			name:    "file size",
+			entries: []tarEntry{file("a", "12345")},
+			policy:  ExtractionPolicy{MaxFileSize: 4},
+			wantErr: "exceeds max size",
+		},
+		{
+			name:    "device node",
+			entries: []tarEntry{{header: tar.Header{Name: "dev/null", Typeflag: tar.TypeChar}}},
+			wantErr: "device node",
+		},
+		{
+			name:    "fifo",
+			entries: []tarEntry{{header: tar.Header{Name: "pipe", Typeflag: tar.TypeFifo}}},
+			wantErr: "device node or FIFO",
+		},
+		{
+			name:    "hard link outside",
+			entries: []tarEntry{{header: tar.Header{Name: "passwd", Typeflag: tar.TypeLink, Linkname: "../../etc/passwd"}}},
+			wantErr: "invalid path",
+		},
+		{
+			name:    "hard link to absolute path",
+			entries: []tarEntry{{header: tar.Header{Name: "passwd", Typeflag: tar.TypeLink, Linkname: "/etc/passwd"}}},
+			wantErr: "invalid path",
+		},
+		{
+			name:    "hard link to unknown file",
+			entries: []tarEntry{{header: tar.Header{Name: "link", Typeflag: tar.TypeLink, Linkname: "missing"}}},
+			wantErr: "not a file in the archive",
+		},
+		{
+			name:    "case collision",
+			entries: []tarEntry{file("skills/Deploy/SKILL.md", "a"), file("skills/deploy/SKILL.md", "b")},
+			wantErr: "differ only in case",
+		},
+		{
+			name:    "backslash traversal",
+			entries: []tarEntry{file(`..\escape.txt`, "x")},
+			wantErr: "invalid path",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := buildTarGz(t, tt.entries...)
+			err := extractTarGz(bytes.NewReader(data), t.TempDir(), tt.policy)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("extractTarGz() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("extractTarGz() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExtractTarGz_HardLinkInsideTree(t *testing.T) {
+	data := buildTarGz(t,
+		file("a.txt", "shared"),
+		tarEntry{header: tar.Header{Name: "sub/b.txt", Typeflag: tar.TypeLink, Linkname: "a.txt"}},
+	)
+	dest := t.TempDir()
+	if err := extractTarGz(bytes.NewReader(data), dest, ExtractionPolicy{}); err != nil {
+		t.Fatalf("extractTarGz() error = %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dest, "sub", "b.txt"))
+	if err != nil || string(got) != "shared" {
+		t.Errorf("sub/b.txt = %q, %v; want copy of a.txt", got, err)
+	}
+}
+
+func TestWithExtractionPolicy(t *testing.T) {
+	c := NewClient(WithExtractionPolicy(ExtractionPolicy{MaxEntries: 5}))
+	if got := c.extraction.withDefaults(); got.MaxEntries != 5 || got.MaxFileSize != maxExtractFileSize {
+		t.Errorf("policy = %+v, want MaxEntries 5 and default file size", got)
+	}
+}
+
+// FuzzExtractTarGz extracts single-entry archives with arbitrary names,
+// link targets and types, and checks that nothing is written outside the
+// destination directory.
+func FuzzExtractTarGz(f *testing.F) {
+	f.Add("a.txt", "", byte(tar.TypeReg))
+	f.Add("../x", "", byte(tar.TypeReg))
+	f.Add("/etc/x", "", byte(tar.TypeReg))
+	f.Add("link", "../../etc/passwd", byte(tar.TypeLink))
+	f.Add("sym", "/etc/passwd", byte(tar.TypeSymlink))
+	f.Add(`..\..\x`, "", byte(tar.TypeReg))
+	f.Add("a/./../../b", "", byte(tar.TypeDir))
+
+	f.Fuzz(func(t *testing.T, name, linkname string, typeflag byte) {
+		var buf bytes.Buffer
+		gzw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gzw)
+		h := &tar.Header{Name: name, Linkname: linkname, Typeflag: typeflag, Mode: 0o644}
+		if typeflag == tar.TypeReg {
+			h.Size = 1
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			return
+		}
+		if typeflag == tar.TypeReg {
+			tw.Write([]byte("x"))
+		}
+		tw.Close()
+		gzw.Close()
+
+		root := t.TempDir()
+		dest := filepath.Join(root, "dest")
+		if err := os.Mkdir(dest, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		_ = extractTarGz(&buf, dest, ExtractionPolicy{})
+
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].Name() != "dest" {
+			t.Fatalf("extraction of %q (type %q, link %q) wrote outside destination: %v", name, typeflag, linkname, entries)
+		}
+	})
+}
+
+func TestCheckPortablePath(t *testing.T) {
+	tests := []struct {
+		path string
+		ok   bool
+	}{
+		{"skills/deploy/SKILL.md", true},
+		{".claude-plugin/plugin.json", true},
+		{"docs/console.md", true},
+		{"aux.md", false},
+		{"commands/CON", false},
+		{"lpt1.txt", false},
+		{"nul .txt", false},
+		{"a:b.md", false},
+		{`a\b.md`, false},
+		{"what?.md", false},
+		{"trailing.", false},
+		{"trailing ", false},
+		{strings.Repeat("x", 256), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			err := checkPortablePath(tt.path)
+			if (err == nil) != tt.ok {
+				t.Errorf("checkPortablePath(%q) = %v, want ok=%v", tt.path, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestExtractTarGz_WindowsNames(t *testing.T) {
+	data := buildTarGz(t, file("commands/aux.md", "x"))
+
+	if err := extractTarGz(bytes.NewReader(data), t.TempDir(), ExtractionPolicy{}); err != nil && !extractForWindows {
+		t.Fatalf("extractTarGz() error = %v, want reserved names allowed off Windows", err)
+	}
+
+	defer func(v bool) { extractForWindows = v }(extractForWindows)
+	extractForWindows = true
+	err := extractTarGz(bytes.NewReader(data), t.TempDir(), ExtractionPolicy{})
+	if err == nil || !strings.Contains(err.Error(), "cannot be extracted on Windows") {
+		t.Errorf("extractTarGz() error = %v, want Windows name error", err)
+	}
+}
+
+func TestExtractTarGz_RelativeDestination(t *testing.T) {
+	t.Chdir(t.TempDir())
+	data := buildTarGz(t, file("a/b.txt", "x"))
+	if err := extractTarGz(bytes.NewReader(data), "dest", ExtractionPolicy{}); err != nil {
+		t.Fatalf("extractTarGz() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join("dest", "a", "b.txt")); err != nil {
+		t.Error(err)
+	}
+}
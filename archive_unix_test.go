@@ -0,0 +1,107 @@
+//go:build unix
+
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestExtractTarGz_FileModesIgnoreUmask(t *testing.T) {
+	old := syscall.Umask(0o077)
+	defer syscall.Umask(old)
+
+	data := buildTarGz(t, tarEntry{header: tar.Header{Name: "run.sh", Typeflag: tar.TypeReg, Mode: 0o755}, body: "x"})
+	for policy, want := range map[FileModePolicy]os.FileMode{FileModesExact: 0o755, FileModesUmask: 0o700} {
+		dest := t.TempDir()
+		if err := extractTarGz(bytes.NewReader(data), dest, ExtractionPolicy{FileModes: policy}); err != nil {
+			t.Fatalf("extractTarGz() error = %v", err)
+		}
+		info, err := os.Stat(filepath.Join(dest, "run.sh"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("policy %q: mode = %o, want %o", policy, got, want)
+		}
+	}
+}
+
+func TestExtractTarGz_FileModes(t *testing.T) {
+	data := buildTarGz(t,
+		tarEntry{header: tar.Header{Name: "bin/", Typeflag: tar.TypeDir, Mode: 0o750}},
+		tarEntry{header: tar.Header{Name: "bin/run.sh", Typeflag: tar.TypeReg, Mode: 0o755}, body: "#!/bin/sh\n"},
+		tarEntry{header: tar.Header{Name: "secret.txt", Typeflag: tar.TypeReg, Mode: 0o600}, body: "s"},
+		tarEntry{header: tar.Header{Name: "shared.txt", Typeflag: tar.TypeReg, Mode: 0o666}, body: "x"},
+		tarEntry{header: tar.Header{Name: "ro/", Typeflag: tar.TypeDir, Mode: 0o555}},
+		tarEntry{header: tar.Header{Name: "ro/file", Typeflag: tar.TypeReg, Mode: 0o444}, body: "r"},
+	)
+
+	tests := []struct {
+		policy FileModePolicy
+		want   map[string]os.FileMode
+	}{
+		{
+			policy: FileModesExact,
+			want: map[string]os.FileMode{
+				"bin":        0o750,
+				"bin/run.sh": 0o755,
+				"secret.txt": 0o600,
+				"shared.txt": 0o666,
+				"ro":         0o755,
+				"ro/file":    0o444,
+			},
+		},
+		{
+			policy: FileModesNormalize,
+			want: map[string]os.FileMode{
+				"bin":        0o755,
+				"bin/run.sh": 0o755,
+				"secret.txt": 0o644,
+				"shared.txt": 0o644,
+				"ro":         0o755,
+				"ro/file":    0o644,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run("policy="+string(tt.policy), func(t *testing.T) {
+			dest := t.TempDir()
+			if err := extractTarGz(bytes.NewReader(data), dest, ExtractionPolicy{FileModes: tt.policy}); err != nil {
+				t.Fatalf("extractTarGz() error = %v", err)
+			}
+			for name, want := range tt.want {
+				info, err := os.Stat(filepath.Join(dest, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := info.Mode().Perm(); got != want {
+					t.Errorf("%s mode = %o, want %o", name, got, want)
+				}
+			}
+			if err := replaceDir(t.TempDir(), dest); err != nil {
+				t.Errorf("replaceDir() after extraction error = %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateTarGz_RejectsNonPortableNames(t *testing.T) {
+	for _, name := range []string{"aux.md", "a:b.md", `a\b.md`} {
+		t.Run(name, func(t *testing.T) {
+			srcDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(srcDir, name), []byte("x"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := createTarGz(srcDir)
+			if err == nil || !strings.Contains(err.Error(), "cannot be extracted on Windows") {
+				t.Errorf("createTarGz() error = %v, want Windows name error", err)
+			}
+		})
+	}
+}
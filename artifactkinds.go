@@ -0,0 +1,269 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ArtifactKind describes the media types of an artifact type registered
+// with RegisterArtifactKind. Artifacts of the kind are packaged like
+// plugins and personalities: a JSON config blob, a tar+gzip content layer
+// of the source directory, and the common Klaus annotations.
+type ArtifactKind struct {
+	// ConfigMediaType is the media type of the config blob. Required.
+	ConfigMediaType string
+	// ContentMediaType is the media type of the content layer. Required.
+	ContentMediaType string
+	// ArtifactType is the OCI 1.1 manifest artifactType set on push.
+	// Optional; artifacts are also recognized by ConfigMediaType.
+	ArtifactType string
+	// DefaultRegistry is the registry base that short names are expanded
+	// against and that ListArtifacts lists by default (e.g.
+	// "gsoci.azurecr.io/giantswarm/klaus-datasets"). Optional; without
+	// it, references must be fully qualified and listings need
+	// WithRegistry.
+	DefaultRegistry string
+}
+
+// ArtifactParser decodes the config blob of a registered artifact kind
+// into the value returned as CustomArtifact.Config, e.g. a pointer to the
+// downstream project's own config struct.
+type ArtifactParser func(configJSON []byte) (any, error)
+
+// registeredKind is an artifact kind added with RegisterArtifactKind.
+type registeredKind struct {
+	artifactKind
+	defaultRegistry string
+	parser          ArtifactParser
+}
+
+// registeredKinds holds the registered kinds in registration order.
+var registeredKinds struct {
+	mu    sync.RWMutex
+	kinds []registeredKind
+}
+
+// RegisterArtifactKind adds an artifact type under name, so that
+// PushArtifact, PullArtifact, DescribeArtifact, and ListArtifacts handle it
+// and Describe and PullAny detect it. parser decodes its config blob; a
+// nil parser leaves the config as json.RawMessage.
+//
+// Registration is meant for package initialization of downstream
+// projects. It fails when name or any of the media types is already used
+// by a built-in or registered kind.
+func RegisterArtifactKind(name Kind, kind ArtifactKind, parser ArtifactParser) error {
+	if name == "" {
+		return fmt.Errorf("registering artifact kind: empty name")
+	}
+	if kind.ConfigMediaType == "" || kind.ContentMediaType == "" {
+		return fmt.Errorf("registering artifact kind %s: config and content media types are required", name)
+	}
+	if parser == nil {
+		parser = func(configJSON []byte) (any, error) { return json.RawMessage(configJSON), nil }
+	}
+
+	registeredKinds.mu.Lock()
+	defer registeredKinds.mu.Unlock()
+	for _, k := range artifactKindsLocked() {
+		if k.Kind == name {
+			return fmt.Errorf("registering artifact kind %s: kind already exists", name)
+		}
+		used := []string{k.ConfigMediaType, k.ContentMediaType, k.ArtifactType}
+		for _, mt := range []string{kind.ConfigMediaType, kind.ContentMediaType, kind.ArtifactType} {
+			if mt != "" && slices.Contains(used, mt) {
+				return fmt.Errorf("registering artifact kind %s: media type %s is used by %s", name, mt, k.Kind)
+			}
+		}
+	}
+	registeredKinds.kinds = append(registeredKinds.kinds, registeredKind{
+		artifactKind: artifactKind{
+			Kind:             name,
+			ConfigMediaType:  kind.ConfigMediaType,
+			ContentMediaType: kind.ContentMediaType,
+			ArtifactType:     kind.ArtifactType,
+		},
+		defaultRegistry: strings.TrimSuffix(kind.DefaultRegistry, "/"),
+		parser:          parser,
+	})
+	return nil
+}
+
+// artifactKinds returns the built-in kinds followed by the registered
+// ones, in detection order.
+func artifactKinds() []artifactKind {
+	registeredKinds.mu.RLock()
+	defer registeredKinds.mu.RUnlock()
+	return artifactKindsLocked()
+}
+
+func artifactKindsLocked() []artifactKind {
+	kinds := slices.Clone(knownArtifactKinds)
+	for _, k := range registeredKinds.kinds {
+		kinds = append(kinds, k.artifactKind)
+	}
+	return kinds
+}
+
+// lookupKind returns the registered kind called name.
+func lookupKind(name Kind) (registeredKind, error) {
+	registeredKinds.mu.RLock()
+	defer registeredKinds.mu.RUnlock()
+	for _, k := range registeredKinds.kinds {
+		if k.Kind == name {
+			return k, nil
+		}
+	}
+	if slices.ContainsFunc(knownArtifactKinds, func(k artifactKind) bool { return k.Kind == name }) {
+		return registeredKind{}, fmt.Errorf("%s is a built-in artifact kind; use its typed methods", name)
+	}
+	return registeredKind{}, fmt.Errorf("unknown artifact kind %q", name)
+}
+
+// isRegisteredKind reports whether name was added with
+// RegisterArtifactKind.
+func isRegisteredKind(name Kind) bool {
+	_, err := lookupKind(name)
+	return err == nil
+}
+
+// resolveRef resolves ref like ResolvePluginRef, expanding short names
+// against the kind's default registry.
+func (k registeredKind) resolveRef(ctx context.Context, c *Client, ref string) (string, error) {
+	if k.defaultRegistry == "" && !strings.Contains(strings.TrimSpace(ref), "/") {
+		return "", fmt.Errorf("reference %q must be a fully-qualified OCI reference: artifact kind %s has no default registry", ref, k.Kind)
+	}
+	return c.resolveWithSuggestions(ctx, c, ref, k.defaultRegistry)
+}
+
+// CustomArtifact holds the metadata of an artifact of a kind added with
+// RegisterArtifactKind.
+type CustomArtifact struct {
+	Kind        Kind
+	Name        string
+	Version     string // From the OCI tag; ignored on push.
+	Description string
+	Author      *Author
+	Homepage    string
+	SourceRepo  string
+	License     string
+	Keywords    []string
+	// Config is the config blob. On push it is marshaled to JSON; on pull
+	// and describe it holds the value returned by the kind's parser.
+	Config any
+}
+
+// annotations returns the Klaus manifest annotations for a.
+func (a CustomArtifact) annotations() map[string]string {
+	return buildKlausAnnotations(commonMetadata{
+		Kind:        a.Kind,
+		Name:        a.Name,
+		Description: a.Description,
+		Author:      a.Author,
+		Homepage:    a.Homepage,
+		SourceRepo:  a.SourceRepo,
+		License:     a.License,
+		Keywords:    a.Keywords,
+	})
+}
+
+// customArtifact assembles the CustomArtifact of kind k from manifest
+// annotations and the raw config blob, which is nil for cache entries
+// written without it.
+func (k registeredKind) customArtifact(annotations map[string]string, tag string, configJSON []byte) (CustomArtifact, error) {
+	m := metadataFromAnnotations(annotations)
+	a := CustomArtifact{
+		Kind:        k.Kind,
+		Name:        m.Name,
+		Version:     tag,
+		Description: m.Description,
+		Author:      m.Author,
+		Homepage:    m.Homepage,
+		SourceRepo:  m.SourceRepo,
+		License:     m.License,
+		Keywords:    m.Keywords,
+	}
+	if configJSON == nil {
+		return a, nil
+	}
+	config, err := k.parser(configJSON)
+	if err != nil {
+		return CustomArtifact{}, fmt.Errorf("parsing %s config: %w", k.Kind, err)
+	}
+	a.Config = config
+	return a, nil
+}
+
+// DescribedCustomArtifact is a CustomArtifact with its OCI metadata.
+type DescribedCustomArtifact struct {
+	ArtifactInfo
+	CustomArtifact
+}
+
+// PulledCustomArtifact is a CustomArtifact with OCI metadata and local
+// file state.
+type PulledCustomArtifact struct {
+	ArtifactInfo
+	CustomArtifact
+	Dir    string // Local directory where files were extracted
+	Cached bool   // True if pull was skipped (cache hit)
+}
+
+// PushArtifact pushes sourceDir as an artifact of the registered kind
+// a.Kind. Common metadata is stored as Klaus annotations on the manifest
+// and a.Config as the config blob, as PushPlugin does for plugins.
+func (c *Client) PushArtifact(ctx context.Context, sourceDir, ref string, a CustomArtifact, opts ...PushOption) (*PushResult, error) {
+	return Push(ctx, c, CustomKind(a.Kind), sourceDir, ref, a, opts...)
+}
+
+// PullArtifact downloads an artifact of the registered kind from an OCI
+// registry into destDir, like PullPlugin does for plugins.
+func (c *Client) PullArtifact(ctx context.Context, kind Kind, ref, destDir string, opts ...PullOption) (*PulledCustomArtifact, error) {
+	p, err := Pull(ctx, c, CustomKind(kind), ref, destDir, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &PulledCustomArtifact{ArtifactInfo: p.ArtifactInfo, CustomArtifact: p.Artifact, Dir: p.Dir, Cached: p.Cached}, nil
+}
+
+// DescribeArtifact fetches the config blob of an artifact of the
+// registered kind and returns its metadata without downloading the content
+// layer. Short names are expanded against the kind's default registry.
+func (c *Client) DescribeArtifact(ctx context.Context, kind Kind, ref string) (*DescribedCustomArtifact, error) {
+	d, err := Describe(ctx, c, CustomKind(kind), ref)
+	if err != nil {
+		return nil, err
+	}
+	return &DescribedCustomArtifact{ArtifactInfo: d.ArtifactInfo, CustomArtifact: d.Artifact}, nil
+}
+
+// customFromManifest fetches the config blob for an already fetched
+// manifest of kind k and assembles the CustomArtifact.
+func customFromManifest(ctx context.Context, k registeredKind, fm *fetchedManifest, resolved string) (CustomArtifact, error) {
+	configJSON, err := fetchConfigBlob(ctx, fm.repo, resolved, fm.manifest.Config)
+	if err != nil {
+		return CustomArtifact{}, err
+	}
+	a, err := k.customArtifact(fm.manifest.Annotations, fm.tag, configJSON)
+	if err != nil {
+		return CustomArtifact{}, fmt.Errorf("%s: %w", resolved, err)
+	}
+	return a, nil
+}
+
+// ListArtifacts discovers the artifacts of the registered kind under its
+// default registry (or a custom one via WithRegistry) and returns
+// ListEntry results, like ListPlugins does for plugins.
+func (c *Client) ListArtifacts(ctx context.Context, kind Kind, opts ...ListOption) ([]ListEntry, error) {
+	k, err := lookupKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	if k.defaultRegistry == "" && newListConfig(opts).base("") == "" {
+		return nil, fmt.Errorf("listing %s artifacts: the kind has no default registry; use WithRegistry", kind)
+	}
+	return c.listEntries(ctx, k.defaultRegistry, k.artifactKind, opts...)
+}
@@ -0,0 +1,162 @@
+package oci
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testKindDataset Kind = "dataset"
+
+type testDatasetConfig struct {
+	Rows int `json:"rows"`
+}
+
+// registerTestKind registers the dataset kind for the duration of the
+// test.
+func registerTestKind(t *testing.T, defaultRegistry string) {
+	t.Helper()
+	err := RegisterArtifactKind(testKindDataset, ArtifactKind{
+		ConfigMediaType:  "application/vnd.example.dataset.config.v1+json",
+		ContentMediaType: "application/vnd.example.dataset.content.v1.tar+gzip",
+		ArtifactType:     "application/vnd.example.dataset.v1",
+		DefaultRegistry:  defaultRegistry,
+	}, func(configJSON []byte) (any, error) {
+		var cfg testDatasetConfig
+		if err := json.Unmarshal(configJSON, &cfg); err != nil {
+			return nil, err
+		}
+		return &cfg, nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterArtifactKind() error = %v", err)
+	}
+	t.Cleanup(func() {
+		registeredKinds.mu.Lock()
+		defer registeredKinds.mu.Unlock()
+		registeredKinds.kinds = slices.DeleteFunc(registeredKinds.kinds, func(k registeredKind) bool { return k.Kind == testKindDataset })
+	})
+}
+
+func TestCustomArtifactKind(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	registerTestKind(t, host+"/klaus-datasets")
+	client := NewClient(WithPlainHTTP(true))
+
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "rows.jsonl"), "{}\n{}\n")
+	ref := host + "/klaus-datasets/evals:v1.0.0"
+	pushed, err := client.PushArtifact(t.Context(), src, ref, CustomArtifact{
+		Kind:        testKindDataset,
+		Name:        "evals",
+		Description: "Evaluation prompts",
+		Config:      testDatasetConfig{Rows: 2},
+	})
+	if err != nil {
+		t.Fatalf("PushArtifact() error = %v", err)
+	}
+
+	described, err := client.DescribeArtifact(t.Context(), testKindDataset, "evals")
+	if err != nil {
+		t.Fatalf("DescribeArtifact() error = %v", err)
+	}
+	if described.Ref != ref || described.Digest != pushed.Digest || described.Name != "evals" || described.Version != "v1.0.0" {
+		t.Errorf("DescribeArtifact() = %+v", described)
+	}
+	if cfg, ok := described.Config.(*testDatasetConfig); !ok || cfg.Rows != 2 {
+		t.Errorf("Config = %#v, want the parsed dataset config", described.Config)
+	}
+
+	dest := filepath.Join(t.TempDir(), "evals")
+	for _, cached := range []bool{false, true} {
+		pulled, err := client.PullArtifact(t.Context(), testKindDataset, ref, dest)
+		if err != nil {
+			t.Fatalf("PullArtifact() error = %v", err)
+		}
+		if pulled.Cached != cached || pulled.Kind != testKindDataset || pulled.Description != "Evaluation prompts" {
+			t.Errorf("PullArtifact() = %+v", pulled)
+		}
+		if cfg, ok := pulled.Config.(*testDatasetConfig); !ok || cfg.Rows != 2 {
+			t.Errorf("Config = %#v, want the parsed dataset config", pulled.Config)
+		}
+		assertFileContent(t, filepath.Join(dest, "rows.jsonl"), "{}\n{}\n")
+	}
+
+	detected, err := client.Describe(t.Context(), ref)
+	if err != nil || detected.Kind != testKindDataset || detected.Custom == nil || detected.Custom.Name != "evals" {
+		t.Errorf("Describe() = %+v, %v, want the dataset", detected, err)
+	}
+	pulledAny, err := client.PullAny(t.Context(), ref, t.TempDir())
+	if err != nil || pulledAny.Kind != testKindDataset || pulledAny.Custom == nil {
+		t.Errorf("PullAny() = %+v, %v, want the dataset", pulledAny, err)
+	}
+
+	entries, err := client.ListArtifacts(t.Context(), testKindDataset, WithVerifyArtifactType())
+	if err != nil {
+		t.Fatalf("ListArtifacts() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name != "evals" || entries[0].Version != "v1.0.0" {
+		t.Errorf("ListArtifacts() = %+v", entries)
+	}
+
+	var wrong *ErrWrongArtifactType
+	if _, err := client.DescribePlugin(t.Context(), ref); !errors.As(err, &wrong) || wrong.Got != testKindDataset {
+		t.Errorf("DescribePlugin() of a dataset error = %v, want *ErrWrongArtifactType naming the dataset kind", err)
+	}
+}
+
+func TestRegisterArtifactKind_Errors(t *testing.T) {
+	registerTestKind(t, "")
+	tests := map[string]struct {
+		name Kind
+		kind ArtifactKind
+		want string
+	}{
+		"empty name":            {"", ArtifactKind{ConfigMediaType: "a", ContentMediaType: "b"}, "empty name"},
+		"missing media type":    {"policy", ArtifactKind{ConfigMediaType: "a"}, "media types are required"},
+		"built-in name":         {KindPlugin, ArtifactKind{ConfigMediaType: "a", ContentMediaType: "b"}, "already exists"},
+		"registered name":       {testKindDataset, ArtifactKind{ConfigMediaType: "a", ContentMediaType: "b"}, "already exists"},
+		"built-in media type":   {"policy", ArtifactKind{ConfigMediaType: MediaTypePluginConfig, ContentMediaType: "b"}, "used by plugin"},
+		"registered media type": {"policy", ArtifactKind{ConfigMediaType: "a", ContentMediaType: "application/vnd.example.dataset.content.v1.tar+gzip"}, "used by dataset"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := RegisterArtifactKind(tt.name, tt.kind, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("RegisterArtifactKind() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomArtifactKind_Unregistered(t *testing.T) {
+	registerTestKind(t, "")
+	client := NewClient()
+	if _, err := client.PullArtifact(t.Context(), "policy", "example.com/p:v1", t.TempDir()); err == nil || !strings.Contains(err.Error(), "unknown artifact kind") {
+		t.Errorf("PullArtifact() of an unregistered kind error = %v", err)
+	}
+	if _, err := client.DescribeArtifact(t.Context(), KindPlugin, "example.com/p:v1"); err == nil || !strings.Contains(err.Error(), "built-in") {
+		t.Errorf("DescribeArtifact() of a built-in kind error = %v", err)
+	}
+	if _, err := client.DescribeArtifact(t.Context(), testKindDataset, "evals"); err == nil || !strings.Contains(err.Error(), "no default registry") {
+		t.Errorf("DescribeArtifact() of a short name without default registry error = %v", err)
+	}
+	if _, err := client.ListArtifacts(t.Context(), testKindDataset); err == nil || !strings.Contains(err.Error(), "WithRegistry") {
+		t.Errorf("ListArtifacts() without default registry error = %v", err)
+	}
+}
+
+func TestKindFromAnnotations_Registered(t *testing.T) {
+	annotations := map[string]string{AnnotationType: string(testKindDataset)}
+	if got := KindFromAnnotations(annotations); got != "" {
+		t.Errorf("KindFromAnnotations() before registration = %q, want empty", got)
+	}
+	registerTestKind(t, "")
+	if got := KindFromAnnotations(annotations); got != testKindDataset {
+		t.Errorf("KindFromAnnotations() = %q, want %q", got, testKindDataset)
+	}
+}
@@ -0,0 +1,207 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/errgroup"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// RepositoryAudit is a storage hygiene report for one repository.
+type RepositoryAudit struct {
+	// Repository is the audited repository.
+	Repository string
+	// Tags maps each tag to the manifest digest it points at.
+	Tags map[string]string
+	// Manifests lists the digests of all manifests reachable from a tag:
+	// tagged manifests, index children, and referrers such as signatures.
+	Manifests []string
+	// Blobs maps the digest of every blob referenced by a reachable
+	// manifest to its size in bytes.
+	Blobs map[string]int64
+	// Unreferenced lists manifests that no tag reaches. Garbage collection
+	// removes them. Only populated when UnreferencedListed is true.
+	Unreferenced []string
+	// UnreferencedListed reports whether the registry exposes an API to
+	// enumerate untagged manifests. The OCI distribution API does not;
+	// Harbor does when the client uses WithHarborAPI.
+	UnreferencedListed bool
+	// ReclaimableBytes estimates the storage garbage collection frees: the
+	// unreferenced manifests plus blobs that only they reference. Blobs
+	// shared with other repositories on the same registry are counted too,
+	// so this is an upper bound.
+	ReclaimableBytes int64
+}
+
+// AuditRepository reports the tags, reachable manifests, and referenced
+// blobs of repository (e.g. "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base").
+// On Harbor registries with WithHarborAPI, it also reports untagged
+// manifests and the space garbage collection would reclaim. The audit
+// bypasses the response cache.
+func (c *Client) AuditRepository(ctx context.Context, repository string) (*RepositoryAudit, error) {
+	repo, err := c.newRepositoryFromName(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	err = repo.Tags(ctx, "", func(t []string) error {
+		tags = append(tags, t...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing tags for %s: %w", repository, err)
+	}
+
+	w := &manifestWalker{repo: repo, manifests: map[string]bool{}, blobs: map[string]int64{}}
+	audit := &RepositoryAudit{Repository: repository, Tags: make(map[string]string, len(tags))}
+	var mu sync.Mutex
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+	for _, tag := range tags {
+		g.Go(func() error {
+			desc, err := repo.Resolve(gctx, tag)
+			if err != nil {
+				return fmt.Errorf("resolving %s:%s: %w", repository, tag, err)
+			}
+			mu.Lock()
+			audit.Tags[tag] = desc.Digest.String()
+			mu.Unlock()
+			return w.walk(gctx, desc)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	audit.Manifests = slices.Sorted(maps.Keys(w.manifests))
+	audit.Blobs = w.blobs
+
+	host, _ := splitHostPath(repository)
+	if c.harborAPI && c.isHarbor(ctx, host) {
+		if err := c.auditUnreferenced(ctx, c.newHarborClient(host), repository, w, audit); err != nil {
+			return nil, err
+		}
+	}
+	return audit, nil
+}
+
+// auditUnreferenced lists the repository's artifacts through the Harbor
+// API and records those the tag walk did not reach.
+func (c *Client) auditUnreferenced(ctx context.Context, h *harborClient, repository string, reachable *manifestWalker, audit *RepositoryAudit) error {
+	digests, err := h.artifactDigests(ctx, repository)
+	if err != nil {
+		return fmt.Errorf("listing artifacts of %s: %w", repository, err)
+	}
+	audit.UnreferencedListed = true
+
+	orphans := &manifestWalker{repo: reachable.repo, manifests: map[string]bool{}, blobs: map[string]int64{}}
+	for _, d := range digests {
+		if reachable.manifests[d] {
+			continue
+		}
+		desc, err := reachable.repo.Resolve(ctx, d)
+		if err != nil {
+			return fmt.Errorf("resolving %s@%s: %w", repository, d, err)
+		}
+		if err := orphans.walk(ctx, desc); err != nil {
+			return err
+		}
+		audit.ReclaimableBytes += desc.Size
+	}
+
+	for d := range orphans.manifests {
+		if !reachable.manifests[d] {
+			audit.Unreferenced = append(audit.Unreferenced, d)
+		}
+	}
+	slices.Sort(audit.Unreferenced)
+	for d, size := range orphans.blobs {
+		if _, ok := reachable.blobs[d]; !ok {
+			audit.ReclaimableBytes += size
+		}
+	}
+	return nil
+}
+
+// manifestWalker collects the manifests and blobs reachable from a set of
+// manifests, following index children and referrers. It is safe for
+// concurrent use.
+type manifestWalker struct {
+	repo *remote.Repository
+
+	mu        sync.Mutex
+	manifests map[string]bool
+	blobs     map[string]int64
+}
+
+// walk records desc and everything it references.
+func (w *manifestWalker) walk(ctx context.Context, desc ocispec.Descriptor) error {
+	d := desc.Digest.String()
+	w.mu.Lock()
+	seen := w.manifests[d]
+	w.manifests[d] = true
+	w.mu.Unlock()
+	if seen {
+		return nil
+	}
+
+	successors, err := content.Successors(ctx, w.repo, desc)
+	if err != nil {
+		return fmt.Errorf("fetching manifest %s: %w", d, err)
+	}
+	err = w.repo.Referrers(ctx, desc, "", func(referrers []ocispec.Descriptor) error {
+		successors = append(successors, referrers...)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("listing referrers of %s: %w", d, err)
+	}
+
+	for _, s := range successors {
+		if isManifestMediaType(s.MediaType) {
+			if err := w.walk(ctx, s); err != nil {
+				return err
+			}
+			continue
+		}
+		w.mu.Lock()
+		w.blobs[s.Digest.String()] = s.Size
+		w.mu.Unlock()
+	}
+	return nil
+}
+
+// artifactDigests returns the digests of all artifacts in repository (a
+// full "host/project/path" name), tagged or not.
+func (h *harborClient) artifactDigests(ctx context.Context, repository string) ([]string, error) {
+	_, name := splitHostPath(repository)
+	project, repoPath, _ := strings.Cut(name, "/")
+	path := "/api/v2.0/projects/" + url.PathEscape(project) +
+		"/repositories/" + url.PathEscape(url.PathEscape(repoPath)) + "/artifacts?page_size=100"
+
+	var digests []string
+	err := h.paginate(ctx, path, func(dec *json.Decoder) error {
+		var page []struct {
+			Digest string `json:"digest"`
+		}
+		if err := dec.Decode(&page); err != nil {
+			return err
+		}
+		for _, a := range page {
+			digests = append(digests, a.Digest)
+		}
+		return nil
+	})
+	return digests, err
+}
@@ -0,0 +1,117 @@
+package oci
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func pushSoul(t *testing.T, client *Client, ref, soul string, opts ...PushOption) *PushResult {
+	t.Helper()
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "SOUL.md"), soul)
+	result, err := client.PushPersonality(t.Context(), src, ref, Personality{Name: "sre"}, opts...)
+	if err != nil {
+		t.Fatalf("PushPersonality(%s) error = %v", ref, err)
+	}
+	return result
+}
+
+func TestAuditRepository(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	repository := host + "/klaus/sre"
+
+	v1 := pushSoul(t, client, repository+":v1.0.0", "one", WithAdditionalTags("v1"), WithChecksumReferrer())
+	v2 := pushSoul(t, client, repository+":v1.1.0", "two")
+
+	audit, err := client.AuditRepository(t.Context(), repository)
+	if err != nil {
+		t.Fatalf("AuditRepository() error = %v", err)
+	}
+
+	if audit.Tags["v1.0.0"] != v1.Digest || audit.Tags["v1"] != v1.Digest || audit.Tags["v1.1.0"] != v2.Digest {
+		t.Errorf("Tags = %v", audit.Tags)
+	}
+	for _, d := range []string{v1.Digest, v2.Digest, v1.ChecksumReferrer} {
+		if !slices.Contains(audit.Manifests, d) {
+			t.Errorf("Manifests = %v, missing %s", audit.Manifests, d)
+		}
+	}
+	for _, d := range []string{v1.Checksums.Config, v1.Checksums.Layer, v2.Checksums.Layer} {
+		if _, ok := audit.Blobs[d]; !ok {
+			t.Errorf("Blobs missing %s", d)
+		}
+	}
+	if audit.UnreferencedListed || len(audit.Unreferenced) != 0 {
+		t.Errorf("UnreferencedListed = %v, Unreferenced = %v; want neither without Harbor", audit.UnreferencedListed, audit.Unreferenced)
+	}
+}
+
+func TestAuditRepository_HarborUnreferenced(t *testing.T) {
+	reg := newCacheRegistry()
+	inner := reg.handler()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/version":
+			json.NewEncoder(w).Encode(map[string]string{"version": "v2.0"})
+		case r.URL.Path == "/api/v2.0/projects/klaus/repositories/sre/artifacts":
+			reg.mu.Lock()
+			var out []map[string]string
+			for d := range reg.manifests {
+				out = append(out, map[string]string{"digest": d})
+			}
+			reg.mu.Unlock()
+			json.NewEncoder(w).Encode(out)
+		default:
+			inner.ServeHTTP(w, r)
+		}
+	}))
+	defer ts.Close()
+	host := testRegistryHost(ts)
+	client := NewClient(WithPlainHTTP(true), WithHarborAPI(true))
+	repository := host + "/klaus/sre"
+
+	// Republishing v1.0.0 with new content leaves the old manifest untagged.
+	old := pushSoul(t, client, repository+":v1.0.0", "old")
+	current := pushSoul(t, client, repository+":v1.0.0", "new")
+
+	audit, err := client.AuditRepository(t.Context(), repository)
+	if err != nil {
+		t.Fatalf("AuditRepository() error = %v", err)
+	}
+	if !audit.UnreferencedListed {
+		t.Fatal("UnreferencedListed = false on Harbor")
+	}
+	if len(audit.Unreferenced) != 1 || audit.Unreferenced[0] != old.Digest {
+		t.Errorf("Unreferenced = %v, want [%s]", audit.Unreferenced, old.Digest)
+	}
+	if slices.Contains(audit.Manifests, old.Digest) || !slices.Contains(audit.Manifests, current.Digest) {
+		t.Errorf("Manifests = %v", audit.Manifests)
+	}
+
+	// The config blob records the content-tree hash, so the old
+	// manifest, its config, and its layer are reclaimable.
+	reg.mu.Lock()
+	want := int64(len(reg.manifests[old.Digest]) + len(reg.blobs[old.Checksums.Config]) + len(reg.blobs[old.Checksums.Layer]))
+	reg.mu.Unlock()
+	if audit.ReclaimableBytes != want {
+		t.Errorf("ReclaimableBytes = %d, want %d", audit.ReclaimableBytes, want)
+	}
+}
+
+func TestAuditRepository_NotFound(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+
+	_, err := client.AuditRepository(t.Context(), host+"/klaus/missing")
+	if err == nil || !strings.Contains(err.Error(), "listing tags") {
+		t.Errorf("AuditRepository() error = %v, want tag listing error", err)
+	}
+}
@@ -0,0 +1,158 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+)
+
+// AuditOperation names a client operation recorded in the audit log.
+type AuditOperation string
+
+const (
+	AuditPush    AuditOperation = "push"
+	AuditPull    AuditOperation = "pull"
+	AuditRetag   AuditOperation = "retag"
+	AuditArchive AuditOperation = "archive"
+
+	AuditQuarantine        AuditOperation = "quarantine"
+	AuditReleaseQuarantine AuditOperation = "release-quarantine"
+)
+
+// AuditRecord describes one artifact movement performed by the client.
+type AuditRecord struct {
+	// Time is when the operation finished, in UTC.
+	Time time.Time `json:"time"`
+	// Operation is the kind of operation.
+	Operation AuditOperation `json:"operation"`
+	// Actor identifies who performed the operation; see WithAuditActor.
+	Actor string `json:"actor,omitempty"`
+	// Ref is the reference the operation targeted, e.g.
+	// "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base:v1.0.0".
+	Ref string `json:"ref"`
+	// Digest is the manifest digest, when known.
+	Digest string `json:"digest,omitempty"`
+	// Tags lists all tags set by a push, primary tag first, or moved by
+	// an archive.
+	Tags []string `json:"tags,omitempty"`
+	// Cached is true for pulls served from the local cache.
+	Cached bool `json:"cached,omitempty"`
+	// Error is the error message of a failed operation, empty on success.
+	Error string `json:"error,omitempty"`
+}
+
+// AuditSink receives a record of every push, pull, retag, archive,
+// quarantine, and quarantine release performed by a client. Record is
+// called synchronously after the operation and must be safe for
+// concurrent use. Sinks handle their own failures, since the operation
+// has already taken place.
+type AuditSink interface {
+	Record(ctx context.Context, r AuditRecord)
+}
+
+// WithAuditSink records client operations to sink.
+func WithAuditSink(sink AuditSink) ClientOption {
+	return func(c *Client) { c.auditSink = sink }
+}
+
+// WithAuditActor sets the actor recorded in audit records. Defaults to the
+// name of the current operating system user.
+func WithAuditActor(actor string) ClientOption {
+	return func(c *Client) { c.auditActor = actor }
+}
+
+// recordAudit completes r with the time, actor, and outcome err and passes
+// it to the audit sink, if one is configured.
+func (c *Client) recordAudit(ctx context.Context, r AuditRecord, err error) {
+	if c.auditSink == nil {
+		return
+	}
+	r.Time = time.Now().UTC()
+	r.Actor = c.auditActor
+	if r.Actor == "" {
+		r.Actor = currentUser()
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	c.auditSink.Record(context.WithoutCancel(ctx), r)
+}
+
+// currentUser returns the name of the operating system user, or "" when
+// it cannot be determined.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// FileAuditSink appends audit records to a file as JSON lines.
+type FileAuditSink struct {
+	mu  sync.Mutex
+	f   *os.File
+	err error
+}
+
+// NewFileAuditSink opens path for appending, creating it with mode 0600 if
+// it does not exist. Close the sink to flush and release the file.
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	return &FileAuditSink{f: f}, nil
+}
+
+// Record implements AuditSink. Each record is written with a single
+// write call. The first write error is kept and returned by Err and Close.
+func (s *FileAuditSink) Record(_ context.Context, r AuditRecord) {
+	line, err := json.Marshal(r)
+	if err != nil {
+		s.setErr(err)
+		return
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.f.Write(line); err != nil && s.err == nil {
+		s.err = fmt.Errorf("writing audit log: %w", err)
+	}
+}
+
+// Err returns the first error encountered while writing records.
+func (s *FileAuditSink) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close syncs and closes the file. It returns the first write error, if
+// any, or the error of closing the file.
+func (s *FileAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.f.Sync(); err != nil && s.err == nil {
+		s.err = fmt.Errorf("syncing audit log: %w", err)
+	}
+	if err := s.f.Close(); err != nil && s.err == nil {
+		s.err = fmt.Errorf("closing audit log: %w", err)
+	}
+	return s.err
+}
+
+func (s *FileAuditSink) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
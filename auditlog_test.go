@@ -0,0 +1,134 @@
+package oci
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryAuditSink collects audit records in memory.
+type memoryAuditSink struct {
+	mu      sync.Mutex
+	records []AuditRecord
+}
+
+func (s *memoryAuditSink) Record(_ context.Context, r AuditRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, r)
+}
+
+func (s *memoryAuditSink) all() []AuditRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]AuditRecord(nil), s.records...)
+}
+
+func TestAuditSink(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	sink := &memoryAuditSink{}
+	client := NewClient(WithPlainHTTP(true), WithAuditSink(sink), WithAuditActor("release-bot"))
+	repository := host + "/klaus/sre"
+
+	digests := pushVersions(t, client, repository, "v1.0.0")
+	dest := filepath.Join(t.TempDir(), "sre")
+	for range 2 {
+		if _, err := client.PullPersonality(t.Context(), repository+":v1.0.0", dest); err != nil {
+			t.Fatalf("PullPersonality() error = %v", err)
+		}
+	}
+	if err := client.Retag(t.Context(), repository, digests["v1.0.0"], "stable"); err != nil {
+		t.Fatalf("Retag() error = %v", err)
+	}
+	if err := client.Retag(t.Context(), repository, "sha256:", "broken"); err == nil {
+		t.Fatal("Retag() with invalid digest succeeded")
+	}
+
+	want := []AuditRecord{
+		{Operation: AuditPush, Ref: repository + ":v1.0.0", Digest: digests["v1.0.0"], Tags: []string{"v1.0.0"}},
+		{Operation: AuditPull, Ref: repository + ":v1.0.0", Digest: digests["v1.0.0"]},
+		{Operation: AuditPull, Ref: repository + ":v1.0.0", Digest: digests["v1.0.0"], Cached: true},
+		{Operation: AuditRetag, Ref: repository + ":stable", Digest: digests["v1.0.0"]},
+		{Operation: AuditRetag, Ref: repository + ":broken", Digest: "sha256:"},
+	}
+	got := sink.all()
+	if len(got) != len(want) {
+		t.Fatalf("recorded %d operations, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Operation != w.Operation || g.Ref != w.Ref || g.Digest != w.Digest || g.Cached != w.Cached || len(g.Tags) != len(w.Tags) {
+			t.Errorf("record %d = %+v, want %+v", i, g, w)
+		}
+		if g.Actor != "release-bot" {
+			t.Errorf("record %d actor = %q, want release-bot", i, g.Actor)
+		}
+		if g.Time.IsZero() || g.Time.Location() != time.UTC {
+			t.Errorf("record %d time = %v, want UTC timestamp", i, g.Time)
+		}
+		if wantErr := i == len(want)-1; (g.Error != "") != wantErr {
+			t.Errorf("record %d error = %q, want error: %v", i, g.Error, wantErr)
+		}
+	}
+}
+
+func TestFileAuditSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileAuditSink(path)
+	if err != nil {
+		t.Fatalf("NewFileAuditSink() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Go(func() {
+			sink.Record(t.Context(), AuditRecord{Operation: AuditPull, Ref: "example.com/klaus/sre:v1.0.0"})
+		})
+	}
+	wg.Wait()
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("line %d: %v", lines+1, err)
+		}
+		if r.Operation != AuditPull {
+			t.Errorf("line %d operation = %q", lines+1, r.Operation)
+		}
+		lines++
+	}
+	if lines != 20 {
+		t.Errorf("wrote %d lines, want 20", lines)
+	}
+
+	// Reopening appends.
+	sink, err = NewFileAuditSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Record(t.Context(), AuditRecord{Operation: AuditPush})
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if n := bytes.Count(data, []byte("\n")); n != 21 {
+		t.Errorf("after reopen: %d lines, want 21", n)
+	}
+}
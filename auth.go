@@ -2,12 +2,17 @@ package oci
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"oras.land/oras-go/v2/registry/remote/auth"
 )
@@ -26,16 +31,183 @@ type dockerAuthEntry struct {
 // newAuthClient creates an auth.Client that resolves credentials from
 // Docker/Podman config files. If registryAuthEnv is non-empty, the named
 // environment variable is checked first for a base64-encoded Docker config JSON.
+// Credentials attached to the request context with WithContextCredentials
+// take precedence over both.
 func newAuthClient(registryAuthEnv string) *auth.Client {
 	return &auth.Client{
 		Client: http.DefaultClient,
-		Cache:  auth.NewCache(),
+		Cache:  &credentialCache{shared: auth.NewCache()},
 		Credential: func(ctx context.Context, hostport string) (auth.Credential, error) {
+			if cred, ok := ContextCredentials(ctx); ok {
+				return cred, nil
+			}
 			return resolveCredential(registryAuthEnv, hostport)
 		},
 	}
 }
 
+// credentialIdentity describes the credential the client uses for host
+// without revealing secrets; see Provenance.Identity.
+func (c *Client) credentialIdentity(ctx context.Context, host string) string {
+	cred, err := c.authClient.Credential(ctx, host)
+	switch {
+	case err != nil, cred == auth.EmptyCredential:
+		return IdentityAnonymous
+	case cred.Username != "":
+		return cred.Username
+	case cred.RefreshToken != "":
+		return IdentityToken
+	}
+	return IdentityAccessToken
+}
+
+// contextCredentialsKey is the context key of WithContextCredentials.
+type contextCredentialsKey struct{}
+
+// WithContextCredentials returns a copy of ctx carrying cred, which
+// clients use for all registry requests made with the context instead of
+// their configured credential sources. This allows per-request
+// credentials, e.g. one per tenant, on a client shared between
+// goroutines. Auth tokens obtained with context credentials are cached
+// separately per credential.
+//
+// Background revalidation of the on-disk cache (WithCache) does not carry
+// the context and uses the client's configured credentials, and cached
+// content is shared between all credentials.
+func WithContextCredentials(ctx context.Context, cred auth.Credential) context.Context {
+	return context.WithValue(ctx, contextCredentialsKey{}, cred)
+}
+
+// ContextCredentials returns the credentials attached to ctx with
+// WithContextCredentials.
+func ContextCredentials(ctx context.Context) (auth.Credential, bool) {
+	cred, ok := ctx.Value(contextCredentialsKey{}).(auth.Credential)
+	return cred, ok
+}
+
+// WithPullCredentials sets the credentials of registry requests to host
+// (e.g. "gsoci.azurecr.io") that only read, e.g. for listing, describing,
+// and pulling, in place of the environment variable and Docker/Podman
+// config files. Together with WithPushCredentials it lets one binary use
+// a low-privilege read token for its read paths while holding a write
+// token, e.g. in CI. Repeat the option for credentials to several hosts.
+// Once set, read requests to any other host, such as mirrors, are
+// anonymous, so the credentials are never sent elsewhere. Context
+// credentials (see WithContextCredentials) take precedence.
+func WithPullCredentials(host string, cred auth.Credential) ClientOption {
+	return func(c *Client) {
+		if c.pullCredentials == nil {
+			c.pullCredentials = map[string]auth.Credential{}
+		}
+		c.pullCredentials[host] = cred
+	}
+}
+
+// WithPushCredentials sets the credentials of registry requests to host
+// that modify repositories: those requesting push or delete access, as
+// made by pushing, tagging, and deleting. Reads that only need pull
+// access, such as checking a push for existing blobs, use the pull
+// credentials. Like those, push credentials are only sent to their host.
+// See WithPullCredentials.
+func WithPushCredentials(host string, cred auth.Credential) ClientOption {
+	return func(c *Client) {
+		if c.pushCredentials == nil {
+			c.pushCredentials = map[string]auth.Credential{}
+		}
+		c.pushCredentials[host] = cred
+	}
+}
+
+// configureAuth applies the credential options to the auth client. It
+// runs after all options so that it also covers auth clients replaced by
+// WithRegistryAuthEnv.
+func (c *Client) configureAuth() {
+	cache := &credentialCache{}
+	if c.pullCredentials != nil || c.pushCredentials != nil {
+		resolve := c.authClient.Credential
+		pull, push := c.pullCredentials, c.pushCredentials
+		c.authClient.Credential = func(ctx context.Context, hostport string) (auth.Credential, error) {
+			if _, ok := ContextCredentials(ctx); !ok {
+				creds := pull
+				if isWriteRequest(ctx, hostport) {
+					creds = push
+				}
+				if creds != nil {
+					// Credentials bound to other hosts are never sent here.
+					return creds[hostport], nil
+				}
+			}
+			return resolve(ctx, hostport)
+		}
+		cache.write = auth.NewCache()
+	}
+	if c.tokenCacheDir != "" {
+		cache.shared = newTokenFileCache(c.tokenCacheDir, c.authClient.Credential)
+	} else {
+		cache.shared = auth.NewCache()
+	}
+	c.authClient.Cache = cache
+}
+
+// isWriteRequest reports whether requests to host made with ctx modify
+// repositories, i.e. request push or delete access.
+func isWriteRequest(ctx context.Context, host string) bool {
+	for _, scope := range auth.GetAllScopesForHost(ctx, host) {
+		actions := scope[strings.LastIndex(scope, ":")+1:]
+		for _, action := range strings.Split(actions, ",") {
+			switch action {
+			case auth.ActionPush, auth.ActionDelete:
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// credentialCache is an auth.Cache that keeps the tokens of each context
+// credential apart, so a token obtained for one request's credentials is
+// never reused for another's. Requests without context credentials share
+// one cache, except for write requests when separate pull and push
+// credentials are configured.
+type credentialCache struct {
+	shared auth.Cache
+	// write caches the tokens of write requests; nil when they use the
+	// shared cache.
+	write auth.Cache
+	// perCredential maps auth.Credential -> auth.Cache.
+	perCredential sync.Map
+}
+
+func (c *credentialCache) cache(ctx context.Context, registry string) auth.Cache {
+	cred, ok := ContextCredentials(ctx)
+	if !ok {
+		if c.write != nil && isWriteRequest(ctx, registry) {
+			return c.write
+		}
+		return c.shared
+	}
+	if cache, ok := c.perCredential.Load(cred); ok {
+		return cache.(auth.Cache)
+	}
+	cache, _ := c.perCredential.LoadOrStore(cred, auth.NewCache())
+	return cache.(auth.Cache)
+}
+
+// GetScheme implements auth.Cache.
+func (c *credentialCache) GetScheme(ctx context.Context, registry string) (auth.Scheme, error) {
+	return c.cache(ctx, registry).GetScheme(ctx, registry)
+}
+
+// GetToken implements auth.Cache.
+func (c *credentialCache) GetToken(ctx context.Context, registry string, scheme auth.Scheme, key string) (string, error) {
+	return c.cache(ctx, registry).GetToken(ctx, registry, scheme, key)
+}
+
+// Set implements auth.Cache.
+func (c *credentialCache) Set(ctx context.Context, registry string, scheme auth.Scheme, key string, fetch func(context.Context) (string, error)) (string, error) {
+	return c.cache(ctx, registry).Set(ctx, registry, scheme, key, fetch)
+}
+
 // resolveCredential resolves registry credentials in priority order:
 //  1. Environment variable (if registryAuthEnv is non-empty): base64-encoded Docker config JSON
 //  2. Docker config at ~/.docker/config.json
@@ -128,3 +300,58 @@ func credentialFromJSON(data []byte, hostport string) (auth.Credential, bool) {
 		Password: parts[1],
 	}, true
 }
+
+// fingerprintKeyFile is the file holding the fingerprint key of a cache
+// directory.
+const fingerprintKeyFile = "fingerprint.key"
+
+// credentialFingerprint identifies cred for host in cache keys without
+// revealing it. It is an HMAC-SHA256 keyed with key, so fingerprints in
+// cache keys and files cannot be checked against guessed secrets without
+// the key.
+func credentialFingerprint(key []byte, host string, cred auth.Credential) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.Join([]string{
+		host, cred.Username, cred.Password, cred.RefreshToken, cred.AccessToken,
+	}, "\x00")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// processFingerprintKey is the fingerprint key of caches that are only
+// kept in memory.
+var processFingerprintKey = sync.OnceValue(func() []byte {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	return key
+})
+
+// fingerprintKey returns the fingerprint key of the cache in dir, so that
+// fingerprints persisted there match across processes. The key is created
+// on first use, readable only by the owner on Unix-like systems. If it
+// cannot be read or created, processFingerprintKey is used and persisted
+// entries are not reused by other processes.
+func fingerprintKey(dir string) []byte {
+	path := filepath.Join(dir, fingerprintKeyFile)
+	if key, err := os.ReadFile(path); err == nil && len(key) == 32 {
+		return key
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return processFingerprintKey()
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return processFingerprintKey()
+	}
+	// The key is linked into place so that concurrent processes agree on
+	// the first complete key.
+	tmp := path + ".tmp." + hex.EncodeToString(key[:8])
+	if err := writePrivateFile(tmp, key); err != nil {
+		return processFingerprintKey()
+	}
+	_ = os.Link(tmp, path)
+	_ = os.Remove(tmp)
+	if key, err := os.ReadFile(path); err == nil && len(key) == 32 {
+		return key
+	}
+	return processFingerprintKey()
+}
@@ -1,10 +1,15 @@
 package oci
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"sync"
 	"testing"
 
 	"oras.land/oras-go/v2/registry/remote/auth"
@@ -174,3 +179,202 @@ func TestResolveCredential_FallbackAnonymous(t *testing.T) {
 		t.Errorf("expected empty credential, got %+v", cred)
 	}
 }
+
+func TestWithContextCredentials(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_RUNTIME_DIR", "")
+
+	var (
+		mu    sync.Mutex
+		users []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _, ok := r.BasicAuth()
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		users = append(users, user)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	client := NewClient(WithPlainHTTP(true))
+	get := func(cred *auth.Credential) (int, error) {
+		t.Helper()
+		ctx := t.Context()
+		if cred != nil {
+			ctx = WithContextCredentials(ctx, *cred)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/v2/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.authClient.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	alice := &auth.Credential{Username: "alice", Password: "a"}
+	bob := &auth.Credential{Username: "bob", Password: "b"}
+	for _, cred := range []*auth.Credential{alice, bob, alice} {
+		if code, err := get(cred); err != nil || code != http.StatusOK {
+			t.Fatalf("GET as %s = %d, %v, want 200", cred.Username, code, err)
+		}
+	}
+	// Without context credentials the client falls back to its
+	// configured sources, which have none here.
+	if _, err := get(nil); err == nil {
+		t.Error("GET without credentials succeeded")
+	}
+
+	if want := []string{"alice", "bob", "alice"}; !slices.Equal(users, want) {
+		t.Errorf("server saw users %v, want %v", users, want)
+	}
+}
+
+func TestWithPullPushCredentials(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_RUNTIME_DIR", "")
+
+	reg := newCacheRegistry()
+	var (
+		mu          sync.Mutex
+		readUsers   = map[string]bool{}
+		writeUsers  = map[string]bool{}
+		credentials = map[string]string{"reader": "r", "writer": "w"}
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		write := r.Method != http.MethodGet && r.Method != http.MethodHead
+		if !ok || credentials[user] != pass || write && user != "writer" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		if write {
+			writeUsers[user] = true
+		} else {
+			readUsers[user] = true
+		}
+		mu.Unlock()
+		reg.handler().ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	repo := testRegistryHost(ts) + "/giantswarm/klaus-personalities/sre"
+
+	client := NewClient(WithPlainHTTP(true),
+		WithPullCredentials(testRegistryHost(ts), auth.Credential{Username: "reader", Password: "r"}),
+		WithPushCredentials(testRegistryHost(ts), auth.Credential{Username: "writer", Password: "w"}))
+	pushVersions(t, client, repo, "v1.0.0")
+	mu.Lock()
+	clear(readUsers)
+	mu.Unlock()
+	if _, err := client.DescribePersonality(t.Context(), repo+":v1.0.0"); err != nil {
+		t.Fatalf("DescribePersonality() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !writeUsers["writer"] {
+		t.Error("no write requests were made with the push credentials")
+	}
+	if !readUsers["reader"] || readUsers["writer"] {
+		t.Errorf("read requests were made as %v, want reader only", readUsers)
+	}
+}
+
+func TestWithPullPushCredentials_BoundToHost(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	reader := auth.Credential{Username: "reader", Password: "r"}
+	writer := auth.Credential{Username: "writer", Password: "w"}
+	client := NewClient(
+		WithPullCredentials("registry.example.com", reader),
+		WithPushCredentials("registry.example.com", writer))
+
+	write := auth.AppendScopesForHost(t.Context(), "registry.example.com", "repository:a:pull,push")
+	write = auth.AppendScopesForHost(write, "mirror.example.com", "repository:a:pull,push")
+	tests := []struct {
+		name string
+		ctx  context.Context
+		host string
+		want auth.Credential
+	}{
+		{"read", t.Context(), "registry.example.com", reader},
+		{"write", write, "registry.example.com", writer},
+		{"read from another host", t.Context(), "mirror.example.com", auth.EmptyCredential},
+		{"write to another host", write, "mirror.example.com", auth.EmptyCredential},
+	}
+	for _, tt := range tests {
+		got, err := client.authClient.Credential(tt.ctx, tt.host)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: Credential() = %+v, %v, want %+v", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestIsWriteRequest(t *testing.T) {
+	ctx := t.Context()
+	tests := []struct {
+		scopes []string
+		want   bool
+	}{
+		{nil, false},
+		{[]string{"repository:a:pull"}, false},
+		{[]string{"repository:a:pull", "repository:b:pull,push"}, true},
+		{[]string{"repository:a:delete"}, true},
+		{[]string{"registry:catalog:*"}, false},
+	}
+	for _, tt := range tests {
+		ctx := auth.AppendScopesForHost(ctx, "registry.example.com", tt.scopes...)
+		if got := isWriteRequest(ctx, "registry.example.com"); got != tt.want {
+			t.Errorf("isWriteRequest(%v) = %v, want %v", tt.scopes, got, tt.want)
+		}
+	}
+	ctx = auth.AppendScopesForHost(ctx, "other.example.com", "repository:a:push")
+	if isWriteRequest(ctx, "registry.example.com") {
+		t.Error("scopes of another host made the request a write")
+	}
+}
+
+func TestContextCredentials(t *testing.T) {
+	if _, ok := ContextCredentials(t.Context()); ok {
+		t.Error("ContextCredentials() found credentials in a plain context")
+	}
+	cred := auth.Credential{RefreshToken: "token"}
+	got, ok := ContextCredentials(WithContextCredentials(t.Context(), cred))
+	if !ok || got != cred {
+		t.Errorf("ContextCredentials() = %+v, %v", got, ok)
+	}
+}
+
+func TestCredentialIdentity(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	client := NewClient()
+	tests := []struct {
+		cred *auth.Credential
+		want string
+	}{
+		{nil, IdentityAnonymous},
+		{&auth.Credential{Username: "alice", Password: "a"}, "alice"},
+		{&auth.Credential{RefreshToken: "r"}, IdentityToken},
+		{&auth.Credential{AccessToken: "a"}, IdentityAccessToken},
+	}
+	for _, tt := range tests {
+		ctx := t.Context()
+		if tt.cred != nil {
+			ctx = WithContextCredentials(ctx, *tt.cred)
+		}
+		if got := client.credentialIdentity(ctx, "registry.example.com"); got != tt.want {
+			t.Errorf("credentialIdentity(%+v) = %q, want %q", tt.cred, got, tt.want)
+		}
+	}
+}
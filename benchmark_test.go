@@ -0,0 +1,156 @@
+package oci
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// The benchmarks cover the hot paths of packaging, extraction, listing,
+// and resolution. README.md documents their baselines; compare changes
+// with hack/benchcmp as described there.
+
+// benchmarkTrees are the source trees packaged and extracted: many small
+// text files, as in skills and commands, and a few large incompressible
+// ones, as in bundled binaries.
+var benchmarkTrees = []struct {
+	name  string
+	files int
+	size  int
+	text  bool
+}{
+	{name: "small", files: 200, size: 2 << 10, text: true},
+	{name: "large", files: 4, size: 4 << 20},
+}
+
+// writeBenchmarkTree writes files of size bytes to a new directory and
+// returns it with the total size.
+func writeBenchmarkTree(b *testing.B, files, size int, text bool) (string, int64) {
+	b.Helper()
+	dir := b.TempDir()
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := range files {
+		data := make([]byte, size)
+		for j := range data {
+			if text {
+				data[j] = "abcdefghijklmnopqrstuvwxyz \n"[rng.IntN(28)]
+			} else {
+				data[j] = byte(rng.Uint32())
+			}
+		}
+		path := filepath.Join(dir, fmt.Sprintf("dir%d", i%10), fmt.Sprintf("file%d.md", i))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			b.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return dir, int64(files * size)
+}
+
+func BenchmarkCreateTarGz(b *testing.B) {
+	for _, tree := range benchmarkTrees {
+		b.Run(tree.name, func(b *testing.B) {
+			dir, total := writeBenchmarkTree(b, tree.files, tree.size, tree.text)
+			b.SetBytes(total)
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := createTarGz(dir); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkExtractTarGz(b *testing.B) {
+	for _, tree := range benchmarkTrees {
+		b.Run(tree.name, func(b *testing.B) {
+			dir, total := writeBenchmarkTree(b, tree.files, tree.size, tree.text)
+			layer, err := createTarGz(dir)
+			if err != nil {
+				b.Fatal(err)
+			}
+			root := b.TempDir()
+			b.SetBytes(total)
+			b.ReportAllocs()
+			i := 0
+			for b.Loop() {
+				dest := filepath.Join(root, fmt.Sprint(i))
+				i++
+				if err := extractTarGz(bytes.NewReader(layer), dest, ExtractionPolicy{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// newBenchmarkRegistry returns a fake registry holding repos plugin
+// repositories below klaus-plugins, each with a single version. The
+// registry's maps are filled directly, as adding repositories one by one
+// sorts the catalog each time.
+func newBenchmarkRegistry(b *testing.B, repos int) (*Client, string) {
+	b.Helper()
+	reg := newCacheRegistry()
+	blob, _ := json.Marshal(pluginConfigBlob{})
+	digest := addPullableArtifact(b, reg, "klaus-plugins/template", "v1.0.0", pluginArtifact, blob, map[string]string{"SKILL.md": "# skill\n"})
+	reg.repos = map[string]map[string]string{}
+	reg.catalogRepos = nil
+	for i := range repos {
+		repo := fmt.Sprintf("klaus-plugins/plugin-%05d", i)
+		reg.repos[repo] = map[string]string{"v1.0.0": digest}
+		reg.catalogRepos = append(reg.catalogRepos, repo)
+	}
+	slices.Sort(reg.catalogRepos)
+
+	host := newPullTestRegistry(b, reg)
+	return NewClient(WithPlainHTTP(true)), host
+}
+
+func BenchmarkListPlugins(b *testing.B) {
+	for _, repos := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("repos=%d", repos), func(b *testing.B) {
+			client, host := newBenchmarkRegistry(b, repos)
+			b.ReportAllocs()
+			for b.Loop() {
+				entries, err := client.ListPlugins(b.Context(), WithRegistry(host+"/klaus-plugins"))
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(entries) != repos {
+					b.Fatalf("listed %d plugins, want %d", len(entries), repos)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkResolveLatestVersion(b *testing.B) {
+	for _, tags := range []int{10, 1000} {
+		b.Run(fmt.Sprintf("tags=%d", tags), func(b *testing.B) {
+			reg := newCacheRegistry()
+			versions := map[string]string{}
+			for i := range tags {
+				versions[fmt.Sprintf("v%d.%d.%d", i/100, i/10%10, i%10)] = "sha256:0"
+			}
+			versions["latest"] = "sha256:0"
+			versions["sha256-0.sig"] = "sha256:0"
+			reg.repos["klaus-plugins/gs-base"] = versions
+			host := newPullTestRegistry(b, reg)
+			client := NewClient(WithPlainHTTP(true))
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := client.ResolveLatestVersion(b.Context(), host+"/klaus-plugins/gs-base"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package oci
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	godigest "github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// BuiltArtifact is a Klaus artifact assembled in memory, exactly as
+// PushPlugin and PushPersonality would upload it. Building is
+// deterministic: the same source directory and metadata always produce
+// byte-identical blobs and therefore the same manifest digest.
+type BuiltArtifact struct {
+	// Manifest is the OCI image manifest.
+	Manifest ocispec.Manifest
+	// ManifestJSON is the serialized manifest as pushed to the registry.
+	ManifestJSON []byte
+	// Digest is the manifest digest, e.g. "sha256:...".
+	Digest string
+	// Config is the config blob.
+	Config []byte
+	// Layer is the gzip-compressed tar content layer.
+	Layer []byte
+	// ExtraLayers are the layers attached with WithExtraLayer, in
+	// manifest order. They follow the content layer in the manifest.
+	ExtraLayers [][]byte
+	// Changelog is the changelog layer attached with WithChangelog, if
+	// any. It is the last layer of the manifest.
+	Changelog []byte
+
+	// layerPath holds the content layer instead of Layer when it was
+	// spooled to disk while packaging for a push.
+	layerPath string
+	// extraPaths holds, by index, the extra layers streamed from disk
+	// instead of ExtraLayers.
+	extraPaths []string
+	// temps are the temporary files removed by release.
+	temps []string
+}
+
+// openLayer opens the content layer.
+func (a *BuiltArtifact) openLayer() (io.ReadCloser, error) {
+	if a.layerPath != "" {
+		return os.Open(a.layerPath)
+	}
+	return io.NopCloser(bytes.NewReader(a.Layer)), nil
+}
+
+// openExtraLayer opens the i-th extra layer.
+func (a *BuiltArtifact) openExtraLayer(i int) (io.ReadCloser, error) {
+	if i < len(a.extraPaths) && a.extraPaths[i] != "" {
+		return os.Open(a.extraPaths[i])
+	}
+	return io.NopCloser(bytes.NewReader(a.ExtraLayers[i])), nil
+}
+
+// release removes the temporary files of layers spooled to disk.
+func (a *BuiltArtifact) release() {
+	for _, path := range a.temps {
+		os.Remove(path)
+	}
+}
+
+// Blobs returns the config and layers keyed by digest.
+func (a *BuiltArtifact) Blobs() map[string][]byte {
+	blobs := map[string][]byte{
+		a.Manifest.Config.Digest.String():    a.Config,
+		a.Manifest.Layers[0].Digest.String(): a.Layer,
+	}
+	for _, extra := range a.ExtraLayers {
+		blobs[godigest.FromBytes(extra).String()] = extra
+	}
+	if a.Changelog != nil {
+		blobs[a.Manifest.Layers[len(a.Manifest.Layers)-1].Digest.String()] = a.Changelog
+	}
+	return blobs
+}
+
+// BuildPlugin assembles a plugin artifact from sourceDir without pushing
+// it. The result matches what PushPlugin uploads for the same arguments.
+// The config blob records the content-tree hash of sourceDir, replacing
+// p.ContentHash.
+func BuildPlugin(sourceDir string, p Plugin) (*BuiltArtifact, error) {
+	return buildPlugin(sourceDir, p, -1)
+}
+
+// buildPlugin builds a plugin artifact, spooling a content layer larger
+// than spillAt bytes to disk; a negative spillAt keeps it in memory.
+func buildPlugin(sourceDir string, p Plugin, spillAt int64) (*BuiltArtifact, error) {
+	if err := withContentHash(sourceDir, &p); err != nil {
+		return nil, err
+	}
+	configJSON, annotations, err := p.encode()
+	if err != nil {
+		return nil, err
+	}
+	return buildArtifact(sourceDir, configJSON, annotations, pluginArtifact, spillAt)
+}
+
+// encode returns the config blob and manifest annotations of p.
+func (p Plugin) encode() ([]byte, map[string]string, error) {
+	configJSON, err := json.Marshal(p.configBlob())
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshaling plugin config: %w", err)
+	}
+	return configJSON, p.Annotations(), nil
+}
+
+// BuildPersonality assembles a personality artifact from sourceDir without
+// pushing it. The result matches what PushPersonality uploads for the same
+// arguments.
+func BuildPersonality(sourceDir string, p Personality) (*BuiltArtifact, error) {
+	return buildPersonality(sourceDir, p, -1)
+}
+
+// buildPersonality is the personality counterpart of buildPlugin.
+func buildPersonality(sourceDir string, p Personality, spillAt int64) (*BuiltArtifact, error) {
+	if err := withContentHash(sourceDir, &p); err != nil {
+		return nil, err
+	}
+	configJSON, annotations, err := p.encode()
+	if err != nil {
+		return nil, err
+	}
+	return buildArtifact(sourceDir, configJSON, annotations, personalityArtifact, spillAt)
+}
+
+// encode returns the config blob and manifest annotations of p.
+func (p Personality) encode() ([]byte, map[string]string, error) {
+	configJSON, err := json.Marshal(p.configBlob())
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshaling personality config: %w", err)
+	}
+	return configJSON, p.Annotations(), nil
+}
+
+// buildArtifact packages sourceDir and assembles the manifest for a Klaus
+// artifact of the given kind. A content layer larger than spillAt bytes
+// is spooled to a temporary file; a negative spillAt never spools.
+func buildArtifact(sourceDir string, configJSON []byte, annotations map[string]string, kind artifactKind, spillAt int64) (*BuiltArtifact, error) {
+	annotations, err := mergeAnnotationsFile(sourceDir, annotations)
+	if err != nil {
+		return nil, err
+	}
+	spool := newBlobSpool(spillAt)
+	if err := writeTarGz(spool, sourceDir); err != nil {
+		spool.discard()
+		return nil, fmt.Errorf("creating archive: %w", err)
+	}
+	layerDesc, layerData, layerPath, err := spool.finish(kind.ContentMediaType)
+	if err != nil {
+		return nil, fmt.Errorf("creating archive: %w", err)
+	}
+
+	manifest := ocispec.Manifest{
+		Versioned:    specs.Versioned{SchemaVersion: 2},
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: kind.ArtifactType,
+		Config:       blobDescriptor(kind.ConfigMediaType, configJSON),
+		Layers:       []ocispec.Descriptor{layerDesc},
+		Annotations:  annotations,
+	}
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		if layerPath != "" {
+			os.Remove(layerPath)
+		}
+		return nil, fmt.Errorf("marshaling manifest: %w", err)
+	}
+
+	built := &BuiltArtifact{
+		Manifest:     manifest,
+		ManifestJSON: manifestJSON,
+		Digest:       godigest.FromBytes(manifestJSON).String(),
+		Config:       configJSON,
+		Layer:        layerData,
+		layerPath:    layerPath,
+	}
+	if layerPath != "" {
+		built.temps = []string{layerPath}
+	}
+	return built, nil
+}
+
+// blobDescriptor returns the descriptor of data with the given media type.
+func blobDescriptor(mediaType string, data []byte) ocispec.Descriptor {
+	return ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    godigest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+}
@@ -0,0 +1,111 @@
+package oci
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildPlugin_Deterministic(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, ".claude-plugin", "plugin.json"), `{"name":"gs-base"}`)
+	writeFile(t, filepath.Join(src, "skills", "kubectl", "SKILL.md"), "# kubectl")
+
+	p := Plugin{Name: "gs-base", Description: "Base plugin", Skills: []string{"kubectl"}}
+	a, err := BuildPlugin(src, p)
+	if err != nil {
+		t.Fatalf("BuildPlugin() error = %v", err)
+	}
+
+	// Touch the files; the artifact must not change.
+	for _, f := range []string{".claude-plugin/plugin.json", "skills/kubectl/SKILL.md"} {
+		if err := os.Chmod(filepath.Join(src, f), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, err := BuildPlugin(src, p)
+	if err != nil {
+		t.Fatalf("BuildPlugin() error = %v", err)
+	}
+
+	if a.Digest != b.Digest || !bytes.Equal(a.ManifestJSON, b.ManifestJSON) {
+		t.Errorf("digests differ: %s vs %s", a.Digest, b.Digest)
+	}
+	if a.Manifest.ArtifactType != ArtifactTypePlugin {
+		t.Errorf("ArtifactType = %q, want %q", a.Manifest.ArtifactType, ArtifactTypePlugin)
+	}
+	if a.Manifest.Annotations[AnnotationDescription] != "Base plugin" {
+		t.Errorf("annotations = %v", a.Manifest.Annotations)
+	}
+	blobs := a.Blobs()
+	if len(blobs) != 2 || !bytes.Equal(blobs[a.Manifest.Config.Digest.String()], a.Config) {
+		t.Errorf("Blobs() = %d entries, want config and layer", len(blobs))
+	}
+}
+
+func TestBuildPersonality_MatchesPush(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "SOUL.md"), "Be calm.")
+	p := Personality{Name: "sre", Plugins: []PluginReference{{Repository: "example.com/p", Tag: "v1.0.0"}}}
+
+	built, err := BuildPersonality(src, p)
+	if err != nil {
+		t.Fatalf("BuildPersonality() error = %v", err)
+	}
+	pushed, err := client.PushPersonality(t.Context(), src, host+"/klaus/sre:v1.0.0", p)
+	if err != nil {
+		t.Fatalf("PushPersonality() error = %v", err)
+	}
+	if pushed.Digest != built.Digest {
+		t.Errorf("pushed digest = %s, built digest = %s", pushed.Digest, built.Digest)
+	}
+}
+
+func TestBuildPlugin_AnnotationsFile(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, ".claude-plugin", "plugin.json"), `{"name":"gs-base"}`)
+	writeFile(t, filepath.Join(src, AnnotationsFileName), "org.opencontainers.image.source: https://github.com/giantswarm/gs-base\ncom.example.build: 42\n")
+
+	a, err := BuildPlugin(src, Plugin{Name: "gs-base"})
+	if err != nil {
+		t.Fatalf("BuildPlugin() error = %v", err)
+	}
+	want := map[string]string{
+		AnnotationType:                    "plugin",
+		AnnotationName:                    "gs-base",
+		"org.opencontainers.image.source": "https://github.com/giantswarm/gs-base",
+		"com.example.build":               "42",
+	}
+	if len(a.Manifest.Annotations) != len(want) {
+		t.Errorf("annotations = %v, want %v", a.Manifest.Annotations, want)
+	}
+	for k, v := range want {
+		if a.Manifest.Annotations[k] != v {
+			t.Errorf("annotation %s = %q, want %q", k, a.Manifest.Annotations[k], v)
+		}
+	}
+
+	// Annotations the artifact sets take precedence over the file.
+	merged, err := mergeAnnotationsFile(src, map[string]string{"com.example.build": "7"})
+	if err != nil || merged["com.example.build"] != "7" || merged["org.opencontainers.image.source"] == "" {
+		t.Errorf("mergeAnnotationsFile() = %v, %v, want the artifact's value kept", merged, err)
+	}
+
+	for name, content := range map[string]string{
+		"klaus namespace": "io.giantswarm.klaus.description: overridden\n",
+		"not a map":       "- a\n- b\n",
+		"nested value":    "com.example.team:\n  name: platform\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			writeFile(t, filepath.Join(src, AnnotationsFileName), content)
+			if _, err := BuildPlugin(src, Plugin{Name: "gs-base"}); err == nil {
+				t.Error("BuildPlugin() succeeded, want error")
+			}
+		})
+	}
+}
@@ -20,11 +20,53 @@ type CacheEntry struct {
 	// ConfigJSON is the raw OCI config blob, persisted so that metadata
 	// remains available on cache hits without re-fetching.
 	ConfigJSON json.RawMessage `json:"configJSON,omitempty"`
+	// ConfigMediaType is the media type of the config blob, persisted so
+	// that typed pulls can reject cache hits of a different artifact kind.
+	ConfigMediaType string `json:"configMediaType,omitempty"`
+	// ArtifactType is the OCI 1.1 manifest artifactType, if any.
+	ArtifactType string `json:"artifactType,omitempty"`
 	// Annotations are the OCI manifest annotations, persisted so that
 	// common metadata is available on cache hits.
 	Annotations map[string]string `json:"annotations,omitempty"`
+	// Provenance records where the artifact was pulled from. It is nil
+	// for entries written by older versions.
+	Provenance *Provenance `json:"provenance,omitempty"`
 }
 
+// Provenance records where a cached artifact came from and how it was
+// checked, so tools can explain the origin of a cache directory.
+type Provenance struct {
+	// Registry is the registry host the artifact was pulled from.
+	Registry string `json:"registry"`
+	// Repository is the repository path within Registry.
+	Repository string `json:"repository"`
+	// Identity describes the credential used for the pull without
+	// revealing secrets: the username, or one of IdentityAnonymous,
+	// IdentityToken, and IdentityAccessToken.
+	Identity string `json:"identity"`
+	// ClientVersion is the klaus-oci version that pulled the artifact;
+	// see Version.
+	ClientVersion string `json:"clientVersion"`
+	// Signature is the result of signature verification.
+	Signature SignatureStatus `json:"signature"`
+}
+
+// Identities of credentials without a username.
+const (
+	IdentityAnonymous   = "anonymous"
+	IdentityToken       = "identity-token"
+	IdentityAccessToken = "access-token"
+)
+
+// SignatureStatus is the result of verifying the signature of a pulled
+// artifact.
+type SignatureStatus string
+
+// SignatureNotVerified means the signature was not verified. The client
+// does not verify signatures, so pulls currently always record it;
+// content is still checked against its digest.
+const SignatureNotVerified SignatureStatus = "not-verified"
+
 // IsCached returns true if the directory has a cache entry matching the given
 // manifest digest.
 func IsCached(dir string, digest string) bool {
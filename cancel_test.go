@@ -0,0 +1,205 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// newHookedRegistry serves reg and calls hook before each request.
+func newHookedRegistry(t *testing.T, reg *cacheRegistry, hook func(*http.Request)) string {
+	t.Helper()
+	handler := reg.handler()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hook(r)
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return strings.TrimPrefix(ts.URL, "http://")
+}
+
+// assertNoStaging fails when dir holds leftover staging directories.
+func assertNoStaging(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".staging-") {
+			t.Errorf("leftover staging entry %s", e.Name())
+		}
+	}
+}
+
+func assertFileContent(t *testing.T, path, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil || string(got) != want {
+		t.Errorf("%s = %q, %v, want %q", filepath.Base(path), got, err, want)
+	}
+}
+
+func TestPullPlugin_CancelledKeepsPreviousVersion(t *testing.T) {
+	reg := newCacheRegistry()
+	blob, _ := json.Marshal(pluginConfigBlob{})
+	addPullableArtifact(t, reg, "klaus-plugins/p", "v1.0.0", pluginArtifact, blob, map[string]string{"SKILL.md": "v1"})
+	addPullableArtifact(t, reg, "klaus-plugins/p", "v2.0.0", pluginArtifact, blob, map[string]string{
+		"SKILL.md": "v2",
+		"data.bin": string(randomBytes(t, 256<<10)),
+	})
+	var layer string
+	for digest, data := range reg.blobs {
+		if len(data) > 1024 {
+			layer = digest
+		}
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	host := newHookedRegistry(t, reg, func(r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/blobs/"+layer) {
+			cancel()
+		}
+	})
+	client := NewClient(WithPlainHTTP(true))
+	root := t.TempDir()
+	dest := filepath.Join(root, "p")
+	if _, err := client.PullPlugin(ctx, host+"/klaus-plugins/p:v1.0.0", dest); err != nil {
+		t.Fatalf("PullPlugin(v1) error = %v", err)
+	}
+
+	_, err := client.PullPlugin(ctx, host+"/klaus-plugins/p:v2.0.0", dest)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("PullPlugin(v2) error = %v, want context.Canceled", err)
+	}
+	assertFileContent(t, filepath.Join(dest, "SKILL.md"), "v1")
+	if _, err := os.Stat(filepath.Join(dest, "data.bin")); !os.IsNotExist(err) {
+		t.Errorf("data.bin of the cancelled pull exists, stat error = %v", err)
+	}
+	assertNoStaging(t, root)
+}
+
+func TestPullPlugin_FailedExtractionKeepsPreviousVersion(t *testing.T) {
+	reg := newCacheRegistry()
+	blob, _ := json.Marshal(pluginConfigBlob{})
+	addPullableArtifact(t, reg, "klaus-plugins/p", "v1.0.0", pluginArtifact, blob, map[string]string{"SKILL.md": "v1"})
+	addPullableArtifact(t, reg, "klaus-plugins/p", "v2.0.0", pluginArtifact, blob, map[string]string{
+		"SKILL.md":  "v2",
+		"large.bin": string(randomBytes(t, 8<<10)),
+	})
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true), WithExtractionPolicy(ExtractionPolicy{MaxTotalSize: 4 << 10}))
+	root := t.TempDir()
+	dest := filepath.Join(root, "p")
+	if _, err := client.PullPlugin(t.Context(), host+"/klaus-plugins/p:v1.0.0", dest); err != nil {
+		t.Fatalf("PullPlugin(v1) error = %v", err)
+	}
+
+	if _, err := client.PullPlugin(t.Context(), host+"/klaus-plugins/p:v2.0.0", dest); err == nil {
+		t.Fatal("PullPlugin(v2) error = nil, want the size limit to fail extraction")
+	}
+	assertFileContent(t, filepath.Join(dest, "SKILL.md"), "v1")
+	if entry, err := ReadCacheEntry(dest); err != nil || entry.Ref != host+"/klaus-plugins/p:v1.0.0" {
+		t.Errorf("cache entry = %+v, %v, want v1", entry, err)
+	}
+	assertNoStaging(t, root)
+}
+
+func TestPullExtraLayers_CancelledKeepsPreviousFile(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "SOUL.md"), "You are an SRE.")
+	aux := t.TempDir()
+	writeFile(t, filepath.Join(aux, "eval.jsonl"), "v1\n")
+	ref := host + "/klaus/sre:v1.0.0"
+	if _, err := client.PushPersonality(t.Context(), src, ref, Personality{Name: "sre"}, WithExtraLayer(filepath.Join(aux, "eval.jsonl"), testDatasetMediaType)); err != nil {
+		t.Fatal(err)
+	}
+	dest := t.TempDir()
+	if _, err := client.PullExtraLayers(t.Context(), ref, dest); err != nil {
+		t.Fatal(err)
+	}
+
+	fm, err := client.fetchManifest(t.Context(), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	target := filepath.Join(dest, "eval.jsonl")
+	if err := client.pullExtraLayer(ctx, fm, fm.manifest.Layers[1], target, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("pullExtraLayer() error = %v, want context.Canceled", err)
+	}
+	assertFileContent(t, target, "v1\n")
+	assertNoStaging(t, dest)
+}
+
+func TestPushPlugin_CancelledLeavesNoTag(t *testing.T) {
+	reg := newCacheRegistry()
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	host := newHookedRegistry(t, reg, func(r *http.Request) {
+		if r.Method == http.MethodPut && strings.Contains(r.URL.Path, "/manifests/sha256:") {
+			cancel()
+		}
+	})
+	client := NewClient(WithPlainHTTP(true))
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "SKILL.md"), "# p\n")
+
+	_, err := client.PushPlugin(ctx, src, host+"/klaus-plugins/p:v1.0.0", Plugin{Name: "p"}, WithAdditionalTags("latest"))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("PushPlugin() error = %v, want context.Canceled", err)
+	}
+	reg.mu.Lock()
+	tags := reg.repos["klaus-plugins/p"]
+	reg.mu.Unlock()
+	if len(tags) != 0 {
+		t.Errorf("cancelled push created tags %v", tags)
+	}
+}
+
+func TestPushPlugin_CancelledWhileTaggingAppliesAllTags(t *testing.T) {
+	reg := newCacheRegistry()
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	host := newHookedRegistry(t, reg, func(r *http.Request) {
+		if r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/manifests/v1.0.0") {
+			cancel()
+		}
+	})
+	client := NewClient(WithPlainHTTP(true))
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "SKILL.md"), "# p\n")
+
+	result, err := client.PushPlugin(ctx, src, host+"/klaus-plugins/p:v1.0.0", Plugin{Name: "p"}, WithAdditionalTags("v1", "latest"))
+	if err != nil {
+		t.Fatalf("PushPlugin() error = %v, want tagging to complete despite the cancellation", err)
+	}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	for _, tag := range []string{"v1.0.0", "v1", "latest"} {
+		if got := reg.repos["klaus-plugins/p"][tag]; got != result.Digest {
+			t.Errorf("tag %s = %q, want %s", tag, got, result.Digest)
+		}
+	}
+}
+
+func TestApplyTags_CancelledBeforeTagging(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if err := applyTags(ctx, nil, ocispec.Descriptor{}, []string{"v1"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("applyTags() error = %v, want context.Canceled", err)
+	}
+}
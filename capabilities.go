@@ -0,0 +1,53 @@
+package oci
+
+import (
+	"slices"
+	"strings"
+)
+
+// Capability is a component available to a personality together with its
+// provenance: the plugins that provide it.
+type Capability struct {
+	Name string
+	// Plugins lists the names of the providing plugins in resolution
+	// order. More than one entry means a collision for every type except
+	// skills (see DetectConflicts).
+	Plugins []string
+}
+
+// MergedCapabilities is the union of the components of a personality's
+// plugins. Each list is sorted by name.
+type MergedCapabilities struct {
+	Skills     []Capability
+	Commands   []Capability
+	Agents     []Capability
+	MCPServers []Capability
+	LSPServers []Capability
+}
+
+// Merged returns the union of skills, commands, agents, MCP servers, and LSP
+// servers across all resolved plugins, with per-item provenance, so callers
+// can show what a personality can do without re-aggregating.
+func (r *ResolvedDependencies) Merged() MergedCapabilities {
+	merge := func(t ComponentType) []Capability {
+		items := indexComponents(r.Plugins, t)
+		if len(items) == 0 {
+			return nil
+		}
+		caps := make([]Capability, len(items))
+		for i, item := range items {
+			caps[i] = Capability{Name: item.name, Plugins: item.plugins}
+		}
+		slices.SortFunc(caps, func(a, b Capability) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+		return caps
+	}
+	return MergedCapabilities{
+		Skills:     merge(ComponentSkill),
+		Commands:   merge(ComponentCommand),
+		Agents:     merge(ComponentAgent),
+		MCPServers: merge(ComponentMCPServer),
+		LSPServers: merge(ComponentLSPServer),
+	}
+}
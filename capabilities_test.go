@@ -0,0 +1,63 @@
+package oci
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestResolvedDependencies_Merged(t *testing.T) {
+	deps := &ResolvedDependencies{
+		Plugins: []DescribedPlugin{
+			{Plugin: Plugin{
+				Name:       "gs-base",
+				Skills:     []string{"kubernetes", "fluxcd"},
+				Commands:   []string{"hello"},
+				MCPServers: []string{"github"},
+			}},
+			{Plugin: Plugin{
+				Name:       "gs-sre",
+				Skills:     []string{"kubernetes", "alerts"},
+				Agents:     []string{"oncall"},
+				LSPServers: []string{"yamlls"},
+			}},
+		},
+	}
+
+	merged := deps.Merged()
+
+	names := func(caps []Capability) []string {
+		var out []string
+		for _, c := range caps {
+			out = append(out, c.Name)
+		}
+		return out
+	}
+	if got, want := names(merged.Skills), []string{"alerts", "fluxcd", "kubernetes"}; !slices.Equal(got, want) {
+		t.Errorf("Skills = %v, want %v", got, want)
+	}
+	if got := merged.Skills[2].Plugins; !slices.Equal(got, []string{"gs-base", "gs-sre"}) {
+		t.Errorf("kubernetes provenance = %v, want [gs-base gs-sre]", got)
+	}
+	if got := merged.Skills[0].Plugins; !slices.Equal(got, []string{"gs-sre"}) {
+		t.Errorf("alerts provenance = %v, want [gs-sre]", got)
+	}
+	if got := names(merged.Commands); !slices.Equal(got, []string{"hello"}) {
+		t.Errorf("Commands = %v", got)
+	}
+	if got := names(merged.Agents); !slices.Equal(got, []string{"oncall"}) {
+		t.Errorf("Agents = %v", got)
+	}
+	if got := names(merged.MCPServers); !slices.Equal(got, []string{"github"}) {
+		t.Errorf("MCPServers = %v", got)
+	}
+	if got := names(merged.LSPServers); !slices.Equal(got, []string{"yamlls"}) {
+		t.Errorf("LSPServers = %v", got)
+	}
+}
+
+func TestResolvedDependencies_Merged_Empty(t *testing.T) {
+	merged := (&ResolvedDependencies{}).Merged()
+	if merged.Skills != nil || merged.Commands != nil || merged.Agents != nil || merged.MCPServers != nil || merged.LSPServers != nil {
+		t.Errorf("Merged() = %+v, want all nil", merged)
+	}
+}
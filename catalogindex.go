@@ -0,0 +1,254 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Catalog index media types. A catalog index lists the repositories of a
+// registry base with their tags, so listings read one document instead of
+// scanning the catalog and the tags of every repository. Indexes are
+// attached as OCI referrers, of artifact type MediaTypeCatalogIndex, to an
+// anchor manifest of artifact type MediaTypeCatalogAnchor tagged
+// CatalogAnchorTag in the repository named like the registry base.
+const (
+	MediaTypeCatalogAnchor = "application/vnd.giantswarm.klaus.catalog.v1+json"
+	MediaTypeCatalogIndex  = "application/vnd.giantswarm.klaus.catalog-index.v1+json"
+)
+
+// CatalogAnchorTag tags the anchor manifest catalog indexes refer to.
+const CatalogAnchorTag = "klaus-catalog"
+
+// maxCatalogIndexSize bounds catalog index documents.
+const maxCatalogIndexSize = 16 << 20
+
+// CatalogIndex lists the repositories of a registry base and their tags.
+type CatalogIndex struct {
+	// Base is the indexed registry base, e.g.
+	// "gsoci.azurecr.io/giantswarm/klaus-plugins".
+	Base string `json:"base"`
+	// Timestamp is when the index was built.
+	Timestamp time.Time `json:"timestamp"`
+	// Repositories maps full repository paths to their tags.
+	Repositories map[string][]string `json:"repositories"`
+
+	// Digest is the manifest digest of the referrer holding the index,
+	// set when it is fetched.
+	Digest string `json:"-"`
+}
+
+// PublishCatalogIndex scans the catalog and the tags of every repository
+// under registryBase and attaches the result as a catalog index, creating
+// the anchor manifest on first use. It returns the index referrer's
+// manifest digest. Listings of clients on registries with the referrers
+// API then read the tags of the indexed repositories from the index
+// instead of listing them; republish after pushes, e.g. at the end of the
+// CI pipeline, since listings only see the tags in the newest index.
+// Older indexes are deleted where the registry allows.
+func (c *Client) PublishCatalogIndex(ctx context.Context, registryBase string) (string, error) {
+	repos, err := c.listRepositories(ctx, registryBase)
+	if err != nil {
+		return "", err
+	}
+	index := CatalogIndex{
+		Base:         registryBase,
+		Timestamp:    time.Now().UTC(),
+		Repositories: make(map[string][]string, len(repos)),
+	}
+	for _, repo := range repos {
+		tags, err := c.List(ctx, repo)
+		if err != nil {
+			return "", fmt.Errorf("listing tags for %s: %w", repo, err)
+		}
+		index.Repositories[repo] = tags
+	}
+	doc, err := json.Marshal(index)
+	if err != nil {
+		return "", fmt.Errorf("marshaling catalog index: %w", err)
+	}
+	if len(doc) > maxCatalogIndexSize {
+		return "", fmt.Errorf("catalog index is %d bytes, exceeding the limit of %d", len(doc), maxCatalogIndexSize)
+	}
+
+	repo, err := c.newRepositoryFromName(registryBase)
+	if err != nil {
+		return "", err
+	}
+	anchor, err := repo.Resolve(ctx, CatalogAnchorTag)
+	if err != nil {
+		if _, err := pushBlob(ctx, repo, ocispec.MediaTypeEmptyJSON, ocispec.DescriptorEmptyJSON.Data); err != nil {
+			return "", fmt.Errorf("pushing empty config: %w", err)
+		}
+		anchor, err = pushManifest(ctx, repo, ocispec.Manifest{
+			Versioned:    specs.Versioned{SchemaVersion: 2},
+			MediaType:    ocispec.MediaTypeImageManifest,
+			ArtifactType: MediaTypeCatalogAnchor,
+			Config:       ocispec.DescriptorEmptyJSON,
+			Layers:       []ocispec.Descriptor{ocispec.DescriptorEmptyJSON},
+		}, CatalogAnchorTag)
+		if err != nil {
+			return "", fmt.Errorf("pushing catalog anchor for %s: %w", registryBase, err)
+		}
+	}
+
+	fm := &fetchedManifest{repo: repo, mediaType: anchor.MediaType, digest: anchor.Digest.String()}
+	previous, err := fm.referrers(ctx, MediaTypeCatalogIndex)
+	if err != nil {
+		return "", fmt.Errorf("listing catalog indexes of %s: %w", registryBase, err)
+	}
+	annotations := map[string]string{ocispec.AnnotationCreated: index.Timestamp.Format(time.RFC3339Nano)}
+	desc, err := pushReferrer(ctx, repo, anchor, MediaTypeCatalogIndex, doc, annotations)
+	if err != nil {
+		return "", fmt.Errorf("publishing catalog index of %s: %w", registryBase, err)
+	}
+	for _, old := range previous {
+		if old.Digest != desc.Digest {
+			_ = repo.Delete(ctx, old)
+		}
+	}
+	return desc.Digest.String(), nil
+}
+
+// DefaultCatalogIndexMaxAge is the default of WithCatalogIndexMaxAge.
+const DefaultCatalogIndexMaxAge = 24 * time.Hour
+
+// WithCatalogIndexMaxAge sets the age beyond which listings ignore a
+// catalog index and list the tags of every repository with the tags/list
+// API, as if none was published (default DefaultCatalogIndexMaxAge).
+// Zero or negative accepts indexes of any age.
+func WithCatalogIndexMaxAge(d time.Duration) ClientOption {
+	return func(c *Client) { c.catalogIndexMaxAge = d }
+}
+
+// discoverCatalogIndex returns the newest catalog index of base when the
+// registry serves one through the referrers API and it is not older than
+// the client's maximum age. Otherwise it returns nil and the reason, and
+// listing falls back to the tags/list API.
+func (c *Client) discoverCatalogIndex(ctx context.Context, base string) (*CatalogIndex, string) {
+	host, path := SplitRegistryBase(base)
+	if c.supports(ctx, host, strings.TrimSuffix(path, "/"), FeatureReferrers) != Supported {
+		return nil, host + " does not support the referrers API"
+	}
+	repo, err := c.newRepositoryFromName(base)
+	if err != nil {
+		return nil, err.Error()
+	}
+	anchor, err := repo.Resolve(ctx, CatalogAnchorTag)
+	if err != nil {
+		return nil, "no catalog index published for " + base
+	}
+
+	fm := &fetchedManifest{repo: repo, mediaType: anchor.MediaType, digest: anchor.Digest.String()}
+	referrers, err := fm.referrers(ctx, MediaTypeCatalogIndex)
+	if err != nil {
+		return nil, fmt.Sprintf("listing catalog indexes of %s: %v", base, err)
+	}
+	var newest *ocispec.Descriptor
+	var newestTime time.Time
+	for i, desc := range referrers {
+		created, _ := time.Parse(time.RFC3339Nano, desc.Annotations[ocispec.AnnotationCreated])
+		if newest == nil || created.After(newestTime) {
+			newest, newestTime = &referrers[i], created
+		}
+	}
+	if newest == nil {
+		return nil, "no catalog index published for " + base
+	}
+
+	data, err := fm.fetchReferrerDocument(ctx, *newest, MediaTypeCatalogIndex, maxCatalogIndexSize)
+	if err != nil {
+		return nil, fmt.Sprintf("fetching catalog index %s of %s: %v", newest.Digest, base, err)
+	}
+	var index CatalogIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Sprintf("parsing catalog index %s of %s: %v", newest.Digest, base, err)
+	}
+	if index.Base != base {
+		return nil, fmt.Sprintf("catalog index %s indexes %s, not %s", newest.Digest, index.Base, base)
+	}
+	if c.catalogIndexMaxAge > 0 && time.Since(index.Timestamp) > c.catalogIndexMaxAge {
+		return nil, fmt.Sprintf("catalog index %s of %s, built %s, is older than %s",
+			newest.Digest, base, index.Timestamp.Format(time.RFC3339), c.catalogIndexMaxAge)
+	}
+	index.Digest = newest.Digest.String()
+	return &index, ""
+}
+
+// discoveredTags lists tags from the catalog indexes of the bases of a
+// listing and falls back to the tags/list API for repositories they do
+// not cover, such as those created since an index was published.
+type discoveredTags struct {
+	client *Client
+	// bases are the registry bases whose indexes discover looks up.
+	bases   []string
+	indexes []*CatalogIndex
+	// fallbacks holds the reasons the bases of the listing have no
+	// usable index.
+	fallbacks map[string]string
+}
+
+// discover looks up the catalog index of each base that any of repos
+// belongs to. scopes holds the pre-warmed scopes of each base (see
+// prewarmScopes), so that the lookups share the token of a batch instead
+// of costing one of their own.
+func (d *discoveredTags) discover(ctx context.Context, repos []string, scopes [][]string) {
+	for i, base := range d.bases {
+		if !slices.ContainsFunc(repos, func(r string) bool { return strings.HasPrefix(r, base+"/") }) {
+			continue
+		}
+		index, reason := d.client.discoverCatalogIndex(withScopes(ctx, base, scopes[i]), base)
+		if index == nil {
+			if d.fallbacks == nil {
+				d.fallbacks = map[string]string{}
+			}
+			d.fallbacks[base] = reason
+			continue
+		}
+		d.indexes = append(d.indexes, index)
+	}
+}
+
+func (d *discoveredTags) List(ctx context.Context, repository string) ([]string, error) {
+	if index := d.index(repository); index != nil {
+		return index.Repositories[repository], nil
+	}
+	return d.client.List(ctx, repository)
+}
+
+// index returns the catalog index covering repository, or nil.
+func (d *discoveredTags) index(repository string) *CatalogIndex {
+	for _, index := range d.indexes {
+		if _, ok := index.Repositories[repository]; ok {
+			return index
+		}
+	}
+	return nil
+}
+
+// strategy describes how the tags of repository are discovered, for the
+// resolution trace.
+func (d *discoveredTags) strategy(repository string) string {
+	if index := d.index(repository); index != nil {
+		return fmt.Sprintf("tags read from catalog index %s of %s, built %s, found with the referrers API",
+			index.Digest, index.Base, index.Timestamp.Format(time.RFC3339))
+	}
+	for _, index := range d.indexes {
+		if strings.HasPrefix(repository, index.Base+"/") {
+			return fmt.Sprintf("tags listed with the tags/list API: catalog index %s of %s, built %s, does not list %s",
+				index.Digest, index.Base, index.Timestamp.Format(time.RFC3339), repository)
+		}
+	}
+	for base, reason := range d.fallbacks {
+		if strings.HasPrefix(repository, base+"/") {
+			return "tags listed with the tags/list API: " + reason
+		}
+	}
+	return "tags listed with the tags/list API"
+}
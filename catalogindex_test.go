@@ -0,0 +1,180 @@
+package oci
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// newReferrersTestRegistry serves reg with the referrers API, answering
+// from the subjects of the pushed manifests.
+func newReferrersTestRegistry(t *testing.T, reg *cacheRegistry) string {
+	t.Helper()
+	handler := reg.handler()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, subject, ok := strings.Cut(r.URL.Path, "/referrers/")
+		if !ok || r.Method != http.MethodGet {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		artifactType := r.URL.Query().Get("artifactType")
+		index := ocispec.Index{MediaType: ocispec.MediaTypeImageIndex, Manifests: []ocispec.Descriptor{}}
+		reg.mu.Lock()
+		for digest, body := range reg.manifests {
+			var m ocispec.Manifest
+			if json.Unmarshal(body, &m) != nil || m.Subject == nil || m.Subject.Digest.String() != subject {
+				continue
+			}
+			if artifactType != "" && m.ArtifactType != artifactType {
+				continue
+			}
+			index.Manifests = append(index.Manifests, ocispec.Descriptor{
+				MediaType:    m.MediaType,
+				Digest:       godigest.Digest(digest),
+				Size:         int64(len(body)),
+				ArtifactType: m.ArtifactType,
+				Annotations:  m.Annotations,
+			})
+		}
+		reg.mu.Unlock()
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+		_ = json.NewEncoder(w).Encode(index)
+	}))
+	t.Cleanup(ts.Close)
+	return strings.TrimPrefix(ts.URL, "http://")
+}
+
+func TestListPersonalities_CatalogIndex(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newReferrersTestRegistry(t, reg)
+	base := host + "/klaus/personalities"
+	client := NewClient(WithPlainHTTP(true))
+	pushVersions(t, client, base+"/sre", "v1.0.0", "v1.1.0")
+	pushVersions(t, client, base+"/dev", "v0.1.0")
+
+	if _, err := client.PublishCatalogIndex(t.Context(), base); err != nil {
+		t.Fatalf("PublishCatalogIndex() error = %v", err)
+	}
+	// Republishing replaces the index.
+	if _, err := client.PublishCatalogIndex(t.Context(), base); err != nil {
+		t.Fatalf("PublishCatalogIndex() error = %v", err)
+	}
+	// Tags pushed after publishing are only listed once republished.
+	pushVersions(t, client, base+"/sre", "v1.2.0")
+
+	tagsBefore := reg.tagsCount.Load()
+	var trace ResolutionTrace
+	ctx := WithResolutionTrace(t.Context(), &trace)
+	entries, err := NewClient(WithPlainHTTP(true)).ListPersonalities(ctx, WithRegistry(base))
+	if err != nil {
+		t.Fatalf("ListPersonalities() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Reference != base+"/dev:v0.1.0" || entries[1].Reference != base+"/sre:v1.1.0" {
+		t.Errorf("entries = %+v, want the indexed versions", entries)
+	}
+	if n := reg.tagsCount.Load() - tagsBefore; n != 0 {
+		t.Errorf("tags/list requests = %d, want 0", n)
+	}
+	resolutions := trace.Resolutions()
+	if len(resolutions) != 2 {
+		t.Fatalf("got %d resolutions, want 2", len(resolutions))
+	}
+	for _, r := range resolutions {
+		if len(r.Decisions) == 0 || !strings.HasPrefix(r.Decisions[0], "tags read from catalog index") {
+			t.Errorf("decisions of %s = %q, want the catalog index strategy", r.Input, r.Decisions)
+		}
+	}
+}
+
+func TestListPersonalities_CatalogIndexFallback(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	base := host + "/klaus/personalities"
+	client := NewClient(WithPlainHTTP(true))
+	pushVersions(t, client, base+"/sre", "v1.0.0")
+	if _, err := client.PublishCatalogIndex(t.Context(), base); err != nil {
+		t.Fatalf("PublishCatalogIndex() error = %v", err)
+	}
+	pushVersions(t, client, base+"/sre", "v1.1.0")
+
+	var trace ResolutionTrace
+	ctx := WithResolutionTrace(t.Context(), &trace)
+	entries, err := NewClient(WithPlainHTTP(true)).ListPersonalities(ctx, WithRegistry(base))
+	if err != nil {
+		t.Fatalf("ListPersonalities() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Reference != base+"/sre:v1.1.0" {
+		t.Errorf("entries = %+v, want the listed version", entries)
+	}
+	resolutions := trace.Resolutions()
+	if len(resolutions) != 1 || len(resolutions[0].Decisions) == 0 ||
+		resolutions[0].Decisions[0] != "tags listed with the tags/list API: "+host+" does not support the referrers API" {
+		t.Errorf("resolutions = %+v, want the tags/list fallback", resolutions)
+	}
+}
+
+func TestListPersonalities_CatalogIndexMissingRepository(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newReferrersTestRegistry(t, reg)
+	base := host + "/klaus/personalities"
+	client := NewClient(WithPlainHTTP(true))
+	pushVersions(t, client, base+"/sre", "v1.0.0")
+	if _, err := client.PublishCatalogIndex(t.Context(), base); err != nil {
+		t.Fatalf("PublishCatalogIndex() error = %v", err)
+	}
+	// Repositories created after publishing are listed from the catalog.
+	pushVersions(t, client, base+"/dev", "v0.1.0")
+
+	var trace ResolutionTrace
+	ctx := WithResolutionTrace(t.Context(), &trace)
+	entries, err := NewClient(WithPlainHTTP(true)).ListPersonalities(ctx, WithRegistry(base))
+	if err != nil {
+		t.Fatalf("ListPersonalities() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Reference != base+"/dev:v0.1.0" || entries[1].Reference != base+"/sre:v1.0.0" {
+		t.Errorf("entries = %+v, want both repositories", entries)
+	}
+	for _, r := range trace.Resolutions() {
+		want := "tags read from catalog index"
+		if r.Input == base+"/dev" {
+			want = "tags listed with the tags/list API: catalog index"
+		}
+		if len(r.Decisions) == 0 || !strings.HasPrefix(r.Decisions[0], want) {
+			t.Errorf("decisions of %s = %q, want prefix %q", r.Input, r.Decisions, want)
+		}
+	}
+}
+
+func TestListPersonalities_CatalogIndexMaxAge(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newReferrersTestRegistry(t, reg)
+	base := host + "/klaus/personalities"
+	client := NewClient(WithPlainHTTP(true))
+	pushVersions(t, client, base+"/sre", "v1.0.0")
+	if _, err := client.PublishCatalogIndex(t.Context(), base); err != nil {
+		t.Fatalf("PublishCatalogIndex() error = %v", err)
+	}
+	pushVersions(t, client, base+"/sre", "v1.1.0")
+
+	var trace ResolutionTrace
+	ctx := WithResolutionTrace(t.Context(), &trace)
+	lister := NewClient(WithPlainHTTP(true), WithCatalogIndexMaxAge(time.Nanosecond))
+	entries, err := lister.ListPersonalities(ctx, WithRegistry(base))
+	if err != nil {
+		t.Fatalf("ListPersonalities() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Reference != base+"/sre:v1.1.0" {
+		t.Errorf("entries = %+v, want the listed version", entries)
+	}
+	resolutions := trace.Resolutions()
+	if len(resolutions) != 1 || len(resolutions[0].Decisions) == 0 ||
+		!strings.HasSuffix(resolutions[0].Decisions[0], "is older than 1ns") {
+		t.Errorf("resolutions = %+v, want the tags/list fallback for the outdated index", resolutions)
+	}
+}
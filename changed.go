@@ -0,0 +1,43 @@
+package oci
+
+import (
+	"context"
+	"fmt"
+)
+
+// HasChanged reports whether ref no longer resolves to knownDigest,
+// together with the digest it resolves to now. It costs a single HEAD
+// request, which bypasses the response cache of WithCache so that a
+// change is seen as soon as the registry has it. Digest references are
+// answered without a request, and an empty knownDigest always counts as
+// changed.
+//
+// Reconcile loops should call HasChanged with the digest they last
+// acted on and skip describing, resolving, and pulling while it reports
+// no change:
+//
+//	changed, digest, err := client.HasChanged(ctx, ref, status.Digest)
+//	if err != nil || !changed {
+//		return err
+//	}
+//	// Pull ref@digest and record digest in status.
+func (c *Client) HasChanged(ctx context.Context, ref, knownDigest string) (bool, string, error) {
+	if hasDigest(ref) {
+		digest := digestFromRef(ref)
+		return digest != knownDigest, digest, nil
+	}
+
+	repo, tag, err := c.newRepository(ref)
+	if err != nil {
+		return false, "", err
+	}
+	if tag == "" {
+		return false, "", fmt.Errorf("reference %q must include a tag or digest", ref)
+	}
+	desc, err := repo.Resolve(ctx, tag)
+	if err != nil {
+		return false, "", fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	digest := desc.Digest.String()
+	return digest != knownDigest, digest, nil
+}
@@ -0,0 +1,62 @@
+package oci
+
+import "testing"
+
+func TestHasChanged(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true), WithCache(t.TempDir()))
+	repository := host + "/klaus/sre"
+	digests := pushVersions(t, client, repository, "v1.0.0", "v1.1.0")
+
+	if err := client.Retag(t.Context(), repository, digests["v1.0.0"], "stable"); err != nil {
+		t.Fatal(err)
+	}
+	// Warm the response cache, which HasChanged must not trust.
+	if _, err := client.Resolve(t.Context(), repository+":stable"); err != nil {
+		t.Fatal(err)
+	}
+
+	before := reg.headCount.Load() + reg.manifestCount.Load()
+	changed, digest, err := client.HasChanged(t.Context(), repository+":stable", digests["v1.0.0"])
+	if err != nil {
+		t.Fatalf("HasChanged() error = %v", err)
+	}
+	if changed || digest != digests["v1.0.0"] {
+		t.Errorf("HasChanged() = %v, %s, want false, %s", changed, digest, digests["v1.0.0"])
+	}
+	if n := reg.headCount.Load() + reg.manifestCount.Load() - before; n != 1 {
+		t.Errorf("HasChanged() made %d manifest requests, want 1", n)
+	}
+
+	if err := client.Retag(t.Context(), repository, digests["v1.1.0"], "stable"); err != nil {
+		t.Fatal(err)
+	}
+	changed, digest, err = client.HasChanged(t.Context(), repository+":stable", digests["v1.0.0"])
+	if err != nil {
+		t.Fatalf("HasChanged() error = %v", err)
+	}
+	if !changed || digest != digests["v1.1.0"] {
+		t.Errorf("HasChanged() after retag = %v, %s, want true, %s", changed, digest, digests["v1.1.0"])
+	}
+
+	if changed, _, _ := client.HasChanged(t.Context(), repository+":stable", ""); !changed {
+		t.Error("HasChanged() with empty known digest = false, want true")
+	}
+	if _, _, err := client.HasChanged(t.Context(), repository+":missing", digests["v1.0.0"]); err == nil {
+		t.Error("HasChanged() of a missing tag succeeded")
+	}
+}
+
+func TestHasChanged_Digest(t *testing.T) {
+	client := NewClient()
+	ref := "registry.invalid/klaus/sre@sha256:" + sum256Hex([]byte("a"))
+
+	changed, digest, err := client.HasChanged(t.Context(), ref, "sha256:"+sum256Hex([]byte("a")))
+	if err != nil || changed || digest != "sha256:"+sum256Hex([]byte("a")) {
+		t.Errorf("HasChanged() = %v, %s, %v, want unchanged without request", changed, digest, err)
+	}
+	if changed, _, _ := client.HasChanged(t.Context(), ref, "sha256:"+sum256Hex([]byte("b"))); !changed {
+		t.Error("HasChanged() with another digest = false, want true")
+	}
+}
@@ -0,0 +1,124 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+	godigest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/errgroup"
+	"oras.land/oras-go/v2/content"
+)
+
+// MediaTypeChangelog is the media type of the changelog layer attached with
+// WithChangelog.
+const MediaTypeChangelog = "application/vnd.giantswarm.klaus.changelog.v1+markdown"
+
+// maxChangelogSize bounds changelog layers, which are meant for
+// human-readable summaries.
+const maxChangelogSize = 1 << 20
+
+// WithChangelog attaches markdown, e.g. a CHANGELOG.md or the notes of
+// this version only, as a separate layer of media type MediaTypeChangelog,
+// so upgrade UIs can show it with FetchChangelog without downloading the
+// content. The layer is never encrypted. An empty changelog attaches no
+// layer.
+func WithChangelog(markdown string) PushOption {
+	return func(cfg *pushConfig) { cfg.changelog = []byte(markdown) }
+}
+
+// withChangelog returns a copy of built with a changelog layer appended.
+func withChangelog(built *BuiltArtifact, changelog []byte) (*BuiltArtifact, error) {
+	if len(changelog) > maxChangelogSize {
+		return nil, fmt.Errorf("changelog is %d bytes, exceeding the limit of %d", len(changelog), maxChangelogSize)
+	}
+	desc := blobDescriptor(MediaTypeChangelog, changelog)
+	desc.Annotations = map[string]string{ocispec.AnnotationTitle: "CHANGELOG.md"}
+
+	manifest := built.Manifest
+	manifest.Layers = append(append([]ocispec.Descriptor(nil), manifest.Layers...), desc)
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling manifest: %w", err)
+	}
+	withLog := *built
+	withLog.Manifest = manifest
+	withLog.ManifestJSON = manifestJSON
+	withLog.Digest = godigest.FromBytes(manifestJSON).String()
+	withLog.Changelog = changelog
+	return &withLog, nil
+}
+
+// FetchChangelog returns the changelog attached to the artifact at ref
+// with WithChangelog, or "" if it has none. ref must include a tag or
+// digest. Only the manifest and the changelog layer are downloaded.
+func (c *Client) FetchChangelog(ctx context.Context, ref string) (string, error) {
+	fm, err := c.fetchManifest(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	for _, layer := range fm.manifest.Layers {
+		if layer.MediaType != MediaTypeChangelog {
+			continue
+		}
+		if layer.Size > maxChangelogSize {
+			return "", fmt.Errorf("changelog of %s is %d bytes, exceeding the limit of %d", ref, layer.Size, maxChangelogSize)
+		}
+		rc, err := fm.repo.Fetch(ctx, layer)
+		if err != nil {
+			return "", fmt.Errorf("fetching changelog for %s: %w", ref, err)
+		}
+		defer rc.Close()
+		data, err := content.ReadAll(rc, layer)
+		if err != nil {
+			return "", fmt.Errorf("reading changelog for %s: %w", ref, err)
+		}
+		return string(data), nil
+	}
+	return "", nil
+}
+
+// VersionChangelog is the changelog of one version of an artifact.
+type VersionChangelog struct {
+	Version   string
+	Changelog string
+}
+
+// ChangelogsSince returns the changelogs of all semver versions of
+// repository newer than since, newest first, e.g. to show what an upgrade
+// from since brings. Versions without a changelog are included with an
+// empty Changelog so callers can still list them.
+func (c *Client) ChangelogsSince(ctx context.Context, repository, since string) ([]VersionChangelog, error) {
+	sinceVer, err := semver.NewVersion(since)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %q: %w", since, err)
+	}
+	tags, err := c.List(ctx, repository)
+	if err != nil {
+		return nil, fmt.Errorf("listing versions for %s: %w", repository, err)
+	}
+
+	var result []VersionChangelog
+	for _, tag := range sortedSemverTags(tags) {
+		if v, _ := semver.NewVersion(tag); !v.GreaterThan(sinceVer) {
+			break
+		}
+		result = append(result, VersionChangelog{Version: tag})
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+	for i := range result {
+		g.Go(func() error {
+			log, err := c.FetchChangelog(gctx, repository+":"+result[i].Version)
+			result[i].Changelog = log
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
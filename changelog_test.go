@@ -0,0 +1,101 @@
+package oci
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithChangelog(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	ref := host + "/klaus-plugins/gs-base:v1.1.0"
+
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, ".claude-plugin", "plugin.json"), `{"name":"gs-base"}`)
+	plain, err := BuildPlugin(src, Plugin{Name: "gs-base"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := client.PushPlugin(t.Context(), src, ref, Plugin{Name: "gs-base"}, WithChangelog("## v1.1.0\n\n- Add k8s skill\n"))
+	if err != nil {
+		t.Fatalf("PushPlugin() error = %v", err)
+	}
+	if result.Digest == plain.Digest {
+		t.Error("changelog layer did not change the manifest digest")
+	}
+
+	log, err := client.FetchChangelog(t.Context(), ref)
+	if err != nil {
+		t.Fatalf("FetchChangelog() error = %v", err)
+	}
+	if log != "## v1.1.0\n\n- Add k8s skill\n" {
+		t.Errorf("FetchChangelog() = %q", log)
+	}
+
+	// The changelog layer is not extracted on pull.
+	pulled, err := client.PullPlugin(t.Context(), ref, filepath.Join(t.TempDir(), "gs-base"))
+	if err != nil {
+		t.Fatalf("PullPlugin() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(pulled.Dir, "CHANGELOG.md")); !os.IsNotExist(err) {
+		t.Errorf("changelog was extracted: %v", err)
+	}
+
+	if _, err := client.PushPlugin(t.Context(), src, ref, Plugin{Name: "gs-base"}, WithChangelog(strings.Repeat("x", maxChangelogSize+1))); err == nil {
+		t.Error("PushPlugin() with oversized changelog succeeded")
+	}
+}
+
+func TestFetchChangelog_None(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	repository := host + "/klaus/sre"
+	pushVersions(t, client, repository, "v1.0.0")
+
+	log, err := client.FetchChangelog(t.Context(), repository+":v1.0.0")
+	if err != nil || log != "" {
+		t.Errorf("FetchChangelog() = %q, %v, want empty", log, err)
+	}
+}
+
+func TestChangelogsSince(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	repository := host + "/klaus/sre"
+
+	pushVersions(t, client, repository, "v1.0.0", "v1.2.0")
+	for _, v := range []string{"v1.1.0", "v2.0.0"} {
+		src := t.TempDir()
+		writeFile(t, filepath.Join(src, "SOUL.md"), "Soul "+v)
+		if _, err := client.PushPersonality(t.Context(), src, repository+":"+v, Personality{Name: "sre"}, WithChangelog("Changes in "+v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := client.ChangelogsSince(t.Context(), repository, "v1.0.0")
+	if err != nil {
+		t.Fatalf("ChangelogsSince() error = %v", err)
+	}
+	want := []VersionChangelog{
+		{Version: "v2.0.0", Changelog: "Changes in v2.0.0"},
+		{Version: "v1.2.0"},
+		{Version: "v1.1.0", Changelog: "Changes in v1.1.0"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ChangelogsSince() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ChangelogsSince()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if _, err := client.ChangelogsSince(t.Context(), repository, "latest"); err == nil {
+		t.Error("ChangelogsSince() with non-semver version succeeded")
+	}
+}
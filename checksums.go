@@ -0,0 +1,122 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	godigest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// MediaTypeChecksums is the media type of the checksum document, used both
+// as the artifactType of the checksum referrer and for its single layer.
+const MediaTypeChecksums = "application/vnd.giantswarm.klaus.checksums.v1+json"
+
+// Checksums lists the digests of a Klaus artifact and of every file in its
+// content layer, so that auditors can verify an artifact with standard
+// tools instead of the Go client.
+type Checksums struct {
+	// Manifest is the manifest digest.
+	Manifest string `json:"manifest"`
+	// Config is the config blob digest.
+	Config string `json:"config"`
+	// Layer is the content layer digest.
+	Layer string `json:"layer"`
+	// Files maps slash-separated paths in the content layer to their
+	// sha256 digests.
+	Files map[string]string `json:"files"`
+}
+
+// SHA256Sums renders the file checksums in the format of sha256sum, so an
+// extracted artifact can be checked with `sha256sum -c`.
+func (c *Checksums) SHA256Sums() []byte {
+	paths := make([]string, 0, len(c.Files))
+	for p := range c.Files {
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+
+	var buf bytes.Buffer
+	for _, p := range paths {
+		fmt.Fprintf(&buf, "%s  %s\n", strings.TrimPrefix(c.Files[p], "sha256:"), p)
+	}
+	return buf.Bytes()
+}
+
+// Checksums computes the checksum document of the artifact. File hashes
+// are read from the content layer, so they cover exactly what was packaged.
+// Encrypted layers are not opened, and their file hashes are omitted.
+func (a *BuiltArtifact) Checksums() (*Checksums, error) {
+	sums := &Checksums{
+		Manifest: a.Digest,
+		Config:   a.Manifest.Config.Digest.String(),
+		Layer:    a.Manifest.Layers[0].Digest.String(),
+	}
+	if isEncryptedMediaType(a.Manifest.Layers[0].MediaType) {
+		return sums, nil
+	}
+	layer, err := a.openLayer()
+	if err != nil {
+		return nil, fmt.Errorf("hashing content layer: %w", err)
+	}
+	defer layer.Close()
+	files, err := fileChecksums(layer)
+	if err != nil {
+		return nil, fmt.Errorf("hashing content layer: %w", err)
+	}
+	sums.Files = files
+	return sums, nil
+}
+
+// fileChecksums returns the sha256 digest of each regular file in a
+// gzip-compressed tar layer.
+func fileChecksums(layer io.Reader) (map[string]string, error) {
+	gzr, err := gzip.NewReader(layer)
+	if err != nil {
+		return nil, err
+	}
+	defer gzr.Close()
+
+	files := make(map[string]string)
+	tr := tar.NewReader(gzr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		d, err := godigest.SHA256.FromReader(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[h.Name] = d.String()
+	}
+}
+
+// pushChecksumReferrer pushes the checksum document as an OCI artifact
+// whose subject is the manifest described by subject, and returns the
+// referrer's manifest digest.
+func pushChecksumReferrer(ctx context.Context, repo *remote.Repository, subject ocispec.Descriptor, sums *Checksums) (string, error) {
+	doc, err := json.Marshal(sums)
+	if err != nil {
+		return "", fmt.Errorf("marshaling checksums: %w", err)
+	}
+	desc, err := pushReferrer(ctx, repo, subject, MediaTypeChecksums, doc, nil)
+	if err != nil {
+		return "", fmt.Errorf("pushing checksum referrer: %w", err)
+	}
+	return desc.Digest.String(), nil
+}
@@ -0,0 +1,158 @@
+package oci
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	godigest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestBuiltArtifact_Checksums(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "SOUL.md"), "Be calm.")
+	writeFile(t, filepath.Join(src, "skills", "k8s", "SKILL.md"), "# k8s")
+
+	a, err := BuildPersonality(src, Personality{Name: "sre"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sums, err := a.Checksums()
+	if err != nil {
+		t.Fatalf("Checksums() error = %v", err)
+	}
+
+	if sums.Manifest != a.Digest || sums.Config != a.Manifest.Config.Digest.String() || sums.Layer != a.Manifest.Layers[0].Digest.String() {
+		t.Errorf("artifact digests = %+v", sums)
+	}
+	want := map[string]string{
+		"SOUL.md":             godigest.FromString("Be calm.").String(),
+		"skills/k8s/SKILL.md": godigest.FromString("# k8s").String(),
+	}
+	if len(sums.Files) != len(want) {
+		t.Fatalf("Files = %v, want %v", sums.Files, want)
+	}
+	for name, d := range want {
+		if sums.Files[name] != d {
+			t.Errorf("Files[%q] = %q, want %q", name, sums.Files[name], d)
+		}
+	}
+
+	wantSums := godigest.FromString("Be calm.").Encoded() + "  SOUL.md\n" +
+		godigest.FromString("# k8s").Encoded() + "  skills/k8s/SKILL.md\n"
+	if got := string(sums.SHA256Sums()); got != wantSums {
+		t.Errorf("SHA256Sums() =\n%s\nwant\n%s", got, wantSums)
+	}
+}
+
+func TestPush_WithChecksumReferrer(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, ".claude-plugin", "plugin.json"), `{"name":"gs-base"}`)
+
+	result, err := client.PushPlugin(t.Context(), src, host+"/klaus-plugins/gs-base:v1.0.0", Plugin{Name: "gs-base"}, WithChecksumReferrer())
+	if err != nil {
+		t.Fatalf("PushPlugin() error = %v", err)
+	}
+	if result.Checksums == nil || result.Checksums.Manifest != result.Digest {
+		t.Fatalf("Checksums = %+v, want manifest %s", result.Checksums, result.Digest)
+	}
+	if result.ChecksumReferrer == "" {
+		t.Fatal("ChecksumReferrer not set")
+	}
+	// The test registry has no referrers API, so the referrer is indexed
+	// through the referrers tag schema.
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "referrers API") {
+		t.Errorf("Warnings = %q, want referrers tag schema warning", result.Warnings)
+	}
+
+	reg.mu.Lock()
+	body := reg.manifests[result.ChecksumReferrer]
+	reg.mu.Unlock()
+	var referrer ocispec.Manifest
+	if err := json.Unmarshal(body, &referrer); err != nil {
+		t.Fatalf("parsing referrer manifest: %v", err)
+	}
+	if referrer.ArtifactType != MediaTypeChecksums {
+		t.Errorf("ArtifactType = %q, want %q", referrer.ArtifactType, MediaTypeChecksums)
+	}
+	if referrer.Subject == nil || referrer.Subject.Digest.String() != result.Digest {
+		t.Errorf("Subject = %v, want %s", referrer.Subject, result.Digest)
+	}
+
+	reg.mu.Lock()
+	doc := reg.blobs[referrer.Layers[0].Digest.String()]
+	reg.mu.Unlock()
+	var sums Checksums
+	if err := json.Unmarshal(doc, &sums); err != nil {
+		t.Fatalf("parsing checksum document: %v", err)
+	}
+	if sums.Files[".claude-plugin/plugin.json"] != godigest.FromString(`{"name":"gs-base"}`).String() {
+		t.Errorf("Files = %v", sums.Files)
+	}
+}
+
+func TestPush_WithChecksumReferrer_Rejected(t *testing.T) {
+	reg := newCacheRegistry()
+	base := reg.handler()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && strings.Contains(r.URL.Path, "/manifests/") {
+			body, _ := io.ReadAll(r.Body)
+			if bytes.Contains(body, []byte(`"subject"`)) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				_, _ = w.Write([]byte(`{"errors":[{"code":"UNSUPPORTED","message":"manifest subject not supported"}]}`))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		base.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	client := NewClient(WithPlainHTTP(true))
+
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "SOUL.md"), "Be calm.")
+	ref := testRegistryHost(ts) + "/klaus-personalities/sre:v1.0.0"
+	result, err := client.PushPersonality(t.Context(), src, ref, Personality{Name: "sre"}, WithChecksumReferrer())
+	if err != nil {
+		t.Fatalf("PushPersonality() error = %v", err)
+	}
+	if result.ChecksumReferrer != "" {
+		t.Errorf("ChecksumReferrer = %q, want empty", result.ChecksumReferrer)
+	}
+	if len(result.Warnings) == 0 || !strings.Contains(result.Warnings[len(result.Warnings)-1], "rejected the checksum referrer") {
+		t.Errorf("Warnings = %q, want rejected referrer warning", result.Warnings)
+	}
+	if _, err := client.Resolve(t.Context(), ref); err != nil {
+		t.Errorf("Resolve() after degraded push error = %v", err)
+	}
+}
+
+func TestPush_WithoutChecksumReferrer(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "SOUL.md"), "Be calm.")
+	result, err := client.PushPersonality(t.Context(), src, host+"/klaus-personalities/sre:v1.0.0", Personality{Name: "sre"})
+	if err != nil {
+		t.Fatalf("PushPersonality() error = %v", err)
+	}
+	if result.ChecksumReferrer != "" || len(result.Warnings) != 0 {
+		t.Errorf("ChecksumReferrer = %q, Warnings = %q, want none", result.ChecksumReferrer, result.Warnings)
+	}
+	if result.Checksums == nil || len(result.Checksums.Files) != 1 {
+		t.Errorf("Checksums = %+v", result.Checksums)
+	}
+}
@@ -0,0 +1,179 @@
+package oci
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Files written by ClaudeConfig.WriteDir.
+const (
+	// ClaudeMCPConfigFile holds the merged MCP server configuration, for
+	// Claude Code's --mcp-config flag.
+	ClaudeMCPConfigFile = "mcp.json"
+	// ClaudeSystemPromptFile holds the personality's soul.
+	ClaudeSystemPromptFile = "system-prompt.md"
+)
+
+// ClaudeConfig is the Claude Code configuration of a pulled personality,
+// as produced by RenderClaudeConfig.
+type ClaudeConfig struct {
+	// PluginDirs lists the plugin directories to load, one --plugin-dir
+	// flag each: the personality's plugins in declaration order, followed
+	// by any further plugins passed to RenderClaudeConfig (e.g. resolved
+	// dependencies) sorted by repository.
+	PluginDirs []string
+	// MCPServers merges the MCP servers of all plugins' .mcp.json files,
+	// keyed by server name. ${CLAUDE_PLUGIN_ROOT} is expanded to the
+	// directory of the defining plugin, so the configuration is usable
+	// outside of the plugin.
+	MCPServers map[string]json.RawMessage
+	// SystemPrompt is the personality's soul; empty if it has none.
+	SystemPrompt string
+}
+
+// RenderClaudeConfig renders a pulled personality into the configuration
+// Claude Code runs it with. pluginDirs maps plugin repositories, as in
+// PluginReference.Repository, to the directories the plugins were pulled
+// to; it must contain every plugin of the personality. An MCP server name
+// defined by more than one plugin is an error, since Claude Code would
+// silently keep only one of them.
+func RenderClaudeConfig(pulled *PulledPersonality, pluginDirs map[string]string) (*ClaudeConfig, error) {
+	if pulled == nil {
+		return nil, errors.New("rendering Claude config: no personality")
+	}
+
+	var repos []string
+	for _, ref := range pulled.Plugins {
+		if _, ok := pluginDirs[ref.Repository]; !ok {
+			return nil, fmt.Errorf("rendering Claude config: no directory for plugin %s", ref.Repository)
+		}
+		if !slices.Contains(repos, ref.Repository) {
+			repos = append(repos, ref.Repository)
+		}
+	}
+	for _, repo := range slices.Sorted(maps.Keys(pluginDirs)) {
+		if !slices.Contains(repos, repo) {
+			repos = append(repos, repo)
+		}
+	}
+
+	cfg := &ClaudeConfig{
+		MCPServers:   map[string]json.RawMessage{},
+		SystemPrompt: pulled.Soul,
+	}
+	definedBy := map[string]string{}
+	for _, repo := range repos {
+		dir := pluginDirs[repo]
+		cfg.PluginDirs = append(cfg.PluginDirs, dir)
+
+		servers, err := readMCPServers(dir)
+		if err != nil {
+			return nil, fmt.Errorf("rendering Claude config: plugin %s: %w", repo, err)
+		}
+		for name, server := range servers {
+			if other, ok := definedBy[name]; ok {
+				return nil, fmt.Errorf("rendering Claude config: MCP server %q is defined by both %s and %s", name, other, repo)
+			}
+			definedBy[name] = repo
+			cfg.MCPServers[name] = server
+		}
+	}
+	return cfg, nil
+}
+
+// readMCPServers reads the MCP servers of the plugin in dir with
+// ${CLAUDE_PLUGIN_ROOT} expanded. A missing .mcp.json yields no servers.
+func readMCPServers(dir string) (map[string]json.RawMessage, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ".mcp.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var servers map[string]json.RawMessage
+	if err := json.Unmarshal(data, &servers); err != nil {
+		return nil, fmt.Errorf("parsing .mcp.json: %w", err)
+	}
+	// Accept the {"mcpServers": {...}} form of standalone MCP configs too.
+	if wrapped, ok := servers["mcpServers"]; ok && len(servers) == 1 {
+		servers = nil
+		if err := json.Unmarshal(wrapped, &servers); err != nil {
+			return nil, fmt.Errorf("parsing .mcp.json: %w", err)
+		}
+	}
+
+	root, err := json.Marshal(dir)
+	if err != nil {
+		return nil, err
+	}
+	root = root[1 : len(root)-1] // drop the quotes, keep the escaping
+	for name, server := range servers {
+		servers[name] = bytes.ReplaceAll(server, []byte("${CLAUDE_PLUGIN_ROOT}"), root)
+	}
+	return servers, nil
+}
+
+// MCPConfig returns the MCP configuration document of c, in the format of
+// Claude Code's --mcp-config flag.
+func (c *ClaudeConfig) MCPConfig() ([]byte, error) {
+	servers := c.MCPServers
+	if servers == nil {
+		servers = map[string]json.RawMessage{}
+	}
+	data, err := json.MarshalIndent(struct {
+		MCPServers map[string]json.RawMessage `json:"mcpServers"`
+	}{servers}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding MCP config: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// WriteDir writes ClaudeMCPConfigFile and, if the personality has a soul,
+// ClaudeSystemPromptFile to dir, creating dir if needed. Rendering into
+// the same dir again replaces the files of the previous render. Plugins are
+// loaded from PluginDirs in place and are not copied. The files are
+// readable by the owner only, since MCP server environments commonly carry
+// credentials.
+func (c *ClaudeConfig) WriteDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating %s: %w", dir, err)
+	}
+	mcp, err := c.MCPConfig()
+	if err != nil {
+		return err
+	}
+	if err := writePrivateFile(filepath.Join(dir, ClaudeMCPConfigFile), mcp); err != nil {
+		return fmt.Errorf("writing %s: %w", ClaudeMCPConfigFile, err)
+	}
+	if c.SystemPrompt == "" {
+		// Do not leave the soul of a previous render behind.
+		if err := os.Remove(filepath.Join(dir, ClaudeSystemPromptFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("removing %s: %w", ClaudeSystemPromptFile, err)
+		}
+		return nil
+	}
+	prompt := strings.TrimRight(c.SystemPrompt, "\n") + "\n"
+	if err := writePrivateFile(filepath.Join(dir, ClaudeSystemPromptFile), []byte(prompt)); err != nil {
+		return fmt.Errorf("writing %s: %w", ClaudeSystemPromptFile, err)
+	}
+	return nil
+}
+
+// writePrivateFile writes data to path with mode 0600, restricting the
+// mode of a file left by a previous render as well.
+func writePrivateFile(path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0o600)
+}
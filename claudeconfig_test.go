@@ -0,0 +1,126 @@
+package oci
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRenderClaudeConfig(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "gs-base")
+	k8s := filepath.Join(root, "gs-k8s")
+	dep := filepath.Join(root, "gs-dep")
+	writeFile(t, filepath.Join(base, ".mcp.json"), `{"github":{"command":"${CLAUDE_PLUGIN_ROOT}/bin/github-mcp"}}`)
+	writeFile(t, filepath.Join(k8s, ".mcp.json"), `{"mcpServers":{"kubernetes":{"command":"mcp-kubernetes"}}}`)
+
+	pulled := &PulledPersonality{
+		Personality: Personality{
+			Name: "sre",
+			Plugins: []PluginReference{
+				{Repository: "example.com/klaus-plugins/gs-k8s", Tag: "v1.0.0"},
+				{Repository: "example.com/klaus-plugins/gs-base", Tag: "v1.0.0"},
+			},
+		},
+		Soul: "Be calm.",
+	}
+	cfg, err := RenderClaudeConfig(pulled, map[string]string{
+		"example.com/klaus-plugins/gs-base": base,
+		"example.com/klaus-plugins/gs-k8s":  k8s,
+		"example.com/klaus-plugins/gs-dep":  dep,
+	})
+	if err != nil {
+		t.Fatalf("RenderClaudeConfig() error = %v", err)
+	}
+
+	if want := []string{k8s, base, dep}; !slices.Equal(cfg.PluginDirs, want) {
+		t.Errorf("PluginDirs = %v, want %v", cfg.PluginDirs, want)
+	}
+	if cfg.SystemPrompt != "Be calm." {
+		t.Errorf("SystemPrompt = %q", cfg.SystemPrompt)
+	}
+	var github struct{ Command string }
+	if err := json.Unmarshal(cfg.MCPServers["github"], &github); err != nil {
+		t.Fatal(err)
+	}
+	if want := base + "/bin/github-mcp"; github.Command != want {
+		t.Errorf("github command = %q, want %q", github.Command, want)
+	}
+	if _, ok := cfg.MCPServers["kubernetes"]; !ok || len(cfg.MCPServers) != 2 {
+		t.Errorf("MCPServers = %v, want github and kubernetes", cfg.MCPServers)
+	}
+
+	out := filepath.Join(root, "claude")
+	if err := cfg.WriteDir(out); err != nil {
+		t.Fatalf("WriteDir() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(out, ClaudeMCPConfigFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		MCPServers map[string]json.RawMessage `json:"mcpServers"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil || len(doc.MCPServers) != 2 {
+		t.Errorf("%s = %s, %v", ClaudeMCPConfigFile, data, err)
+	}
+	if prompt, _ := os.ReadFile(filepath.Join(out, ClaudeSystemPromptFile)); string(prompt) != "Be calm.\n" {
+		t.Errorf("%s = %q", ClaudeSystemPromptFile, prompt)
+	}
+	for _, name := range []string{ClaudeMCPConfigFile, ClaudeSystemPromptFile} {
+		info, err := os.Stat(filepath.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("%s mode = %v, want 0600", name, perm)
+		}
+	}
+
+	// Re-rendering without a soul removes the previous system prompt.
+	cfg.SystemPrompt = ""
+	if err := cfg.WriteDir(out); err != nil {
+		t.Fatalf("WriteDir() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, ClaudeSystemPromptFile)); !os.IsNotExist(err) {
+		t.Errorf("stale %s left behind: %v", ClaudeSystemPromptFile, err)
+	}
+}
+
+func TestRenderClaudeConfig_Errors(t *testing.T) {
+	root := t.TempDir()
+	a := filepath.Join(root, "a")
+	b := filepath.Join(root, "b")
+	writeFile(t, filepath.Join(a, ".mcp.json"), `{"github":{"command":"a"}}`)
+	writeFile(t, filepath.Join(b, ".mcp.json"), `{"github":{"command":"b"}}`)
+
+	pulled := &PulledPersonality{Personality: Personality{
+		Name:    "sre",
+		Plugins: []PluginReference{{Repository: "example.com/a"}, {Repository: "example.com/b"}},
+	}}
+
+	tests := map[string]struct {
+		dirs map[string]string
+		want string
+	}{
+		"missing plugin directory": {
+			dirs: map[string]string{"example.com/a": a},
+			want: "no directory for plugin example.com/b",
+		},
+		"MCP server collision": {
+			dirs: map[string]string{"example.com/a": a, "example.com/b": b},
+			want: `MCP server "github" is defined by both example.com/a and example.com/b`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := RenderClaudeConfig(pulled, tt.dirs)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("RenderClaudeConfig() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -5,11 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/semaphore"
 	"oras.land/oras-go/v2/registry/remote"
 	"oras.land/oras-go/v2/registry/remote/auth"
 )
@@ -21,6 +24,115 @@ type Client struct {
 	authClient  *auth.Client
 	concurrency int
 
+	// discovery maps registry hosts to providers that replace the OCI
+	// catalog API for repository enumeration.
+	discovery map[string]DiscoveryProvider
+
+	// harborAPI enables Harbor's REST API on hosts detected as Harbor.
+	// harborHosts caches detection results per host.
+	harborAPI   bool
+	harborHosts sync.Map
+
+	// capabilities caches detected registry features; see Supports.
+	capabilities sync.Map
+
+	// repositories and registries pool the remote.Repository and
+	// remote.Registry instances of the client by name and host.
+	repositories lruPool[*remote.Repository]
+	registries   lruPool[*remote.Registry]
+
+	// extraction limits what pulled content layers may write to disk.
+	extraction ExtractionPolicy
+
+	// keyWrappers wrap the keys of content layers encrypted on push and
+	// unwrap them on pull.
+	keyWrappers []KeyWrapper
+
+	// includePrivate disables redaction of private artifacts in describe
+	// results.
+	includePrivate bool
+
+	// labelFallback reads toolchain metadata from image config labels
+	// when the manifest has no Klaus annotations.
+	labelFallback bool
+
+	// evalResults adds attached evaluation results to personality
+	// describe results.
+	evalResults bool
+
+	// provenance verifies the provenance of described artifacts when set;
+	// see WithProvenanceVerification.
+	provenance *ProvenancePolicy
+
+	// trustPolicy configures verification per registry scope; see
+	// WithTrustPolicy.
+	trustPolicy *TrustPolicy
+
+	// deps caches dependency resolutions of fully pinned personalities.
+	deps depsCache
+
+	// pullCredentials and pushCredentials map registry hosts to the
+	// credentials replacing the configured credential sources for read
+	// and write requests; see WithPullCredentials.
+	pullCredentials map[string]auth.Credential
+	pushCredentials map[string]auth.Credential
+
+	// tokenCacheDir persists bearer tokens; see WithTokenCache.
+	tokenCacheDir string
+
+	// prewarmTokens fetches the tokens of batch listings up front; see
+	// WithTokenPrewarming.
+	prewarmTokens bool
+
+	// catalogIndexMaxAge is the age beyond which listings ignore catalog
+	// indexes; see WithCatalogIndexMaxAge.
+	catalogIndexMaxAge time.Duration
+
+	// mirrors maps registry bases to the mirrors that digest-pinned pulls
+	// fail over to; see WithMirrors.
+	mirrors map[string][]string
+
+	// auditSink receives records of pushes, pulls, and retags, attributed
+	// to auditActor.
+	auditSink  AuditSink
+	auditActor string
+
+	// userAgentConsumer identifies the consuming tool in the User-Agent
+	// header.
+	userAgentConsumer string
+
+	// debug receives request logs when set; see SetDebugOutput.
+	debug atomic.Pointer[debugOutput]
+
+	// warnings collects the Warning headers of registry responses; see
+	// WithRegistryWarningHandler.
+	warnings registryWarnings
+
+	// transport tunes the HTTP connection pool; blobSlots bounds
+	// concurrent blob downloads to blobConcurrency, and bandwidthLimit
+	// caps their combined rate in bytes per second.
+	transport       TransportOptions
+	blobConcurrency int
+	blobSlots       chan struct{}
+	bandwidthLimit  int64
+	// faults is injected into requests when set; see WithFaultInjection.
+	faults *FaultPolicy
+	// memory bounds the bytes held in blob buffers at once to
+	// memoryBudget when set; see WithMemoryBudget.
+	memory       *semaphore.Weighted
+	memoryBudget int64
+
+	// namespace replaces DefaultNamespace when set; see
+	// WithDefaultNamespace.
+	namespace string
+
+	// rewrites are applied to the references of personalities; see
+	// WithReferenceRewrites. NewClient compiles them into rewriter, or
+	// sets rewriteErr when they are invalid.
+	rewrites   []RewriteRule
+	rewriter   referenceRewriter
+	rewriteErr error
+
 	// cache configuration captured from WithCache*. The store itself is
 	// created lazily on first use so construction errors surface on the
 	// first cache-using call rather than forcing NewClient to change
@@ -101,13 +213,20 @@ func WithBackgroundRefresh(enabled bool) ClientOption {
 // NewClient creates a new OCI client for Klaus artifacts.
 func NewClient(opts ...ClientOption) *Client {
 	c := &Client{
-		authClient:  newAuthClient(""),
-		concurrency: defaultConcurrency,
-		cacheCfg:    defaultCacheConfig(),
+		authClient:         newAuthClient(""),
+		concurrency:        defaultConcurrency,
+		blobConcurrency:    defaultBlobConcurrency,
+		cacheCfg:           defaultCacheConfig(),
+		catalogIndexMaxAge: DefaultCatalogIndexMaxAge,
 	}
 	for _, o := range opts {
 		o(c)
 	}
+	c.configureAuth()
+	c.rewriter, c.rewriteErr = compileRewriteRules(c.rewrites)
+	c.blobSlots = make(chan struct{}, c.blobConcurrency)
+	c.authClient.SetUserAgent(c.userAgent())
+	c.configureTransport()
 	return c
 }
 
@@ -166,34 +285,61 @@ func (c *Client) Resolve(ctx context.Context, ref string) (string, error) {
 }
 
 // listRepositories queries the OCI registry catalog to find all repositories
-// under the given base path.
+// under the given base path. Hosts with a registered DiscoveryProvider are
+// enumerated through the provider instead, and Harbor registries through the
+// Harbor project API when WithHarborAPI is enabled.
 func (c *Client) listRepositories(ctx context.Context, registryBase string) ([]string, error) {
-	store, err := c.cacheStore()
+	var repos []string
+	err := c.walkRepositories(ctx, registryBase, "", func(repo string) error {
+		repos = append(repos, repo)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	if store != nil {
-		if repos, cerr := store.Repositories(ctx, registryBase); cerr == nil {
-			return repos, nil
-		}
-	}
+	return repos, nil
+}
 
+// walkRepositories calls fn for each repository under registryBase that
+// sorts after the repository after, in sorted order, like listRepositories.
+// The catalog API is read from after onwards, one page at a time, and
+// only as far as fn consumes it: fn returns errStopIteration to stop.
+func (c *Client) walkRepositories(ctx context.Context, registryBase, after string, fn func(repo string) error) error {
 	host, prefix := SplitRegistryBase(registryBase)
 
-	reg, err := remote.NewRegistry(host)
+	repos, listed, err := c.listRepositoriesAPI(ctx, registryBase)
 	if err != nil {
-		return nil, fmt.Errorf("creating registry client for %s: %w", host, err)
+		return err
+	}
+	if listed {
+		for _, repo := range slices.Sorted(slices.Values(repos)) {
+			if repo <= after {
+				continue
+			}
+			if err := fn(repo); err != nil {
+				if errors.Is(err, errStopIteration) {
+					return nil
+				}
+				return err
+			}
+		}
+		return nil
+	}
+
+	reg, err := c.registry(host)
+	if err != nil {
+		return err
 	}
-	reg.PlainHTTP = c.plainHTTP
-	reg.Client = c.authClient
 
 	// Seek past repositories that sort before our prefix by using the
 	// catalog's `last` parameter. We trim the trailing "/" from the prefix
 	// so the enumeration begins just before the first matching repo (the
 	// catalog returns entries strictly after the `last` value).
 	seekPos := strings.TrimSuffix(prefix, "/")
+	if name, ok := strings.CutPrefix(after, host+"/"); ok && name > seekPos {
+		seekPos = name
+	}
 
-	var repos []string
 	err = reg.Repositories(ctx, seekPos, func(batch []string) error {
 		for _, name := range batch {
 			if !strings.HasPrefix(name, prefix) {
@@ -202,15 +348,50 @@ func (c *Client) listRepositories(ctx context.Context, registryBase string) ([]s
 				}
 				continue
 			}
-			repos = append(repos, host+"/"+name)
+			if err := fn(host + "/" + name); err != nil {
+				return err
+			}
 		}
 		return nil
 	})
 	if err != nil && !errors.Is(err, errStopIteration) {
-		return nil, fmt.Errorf("listing repositories in %s: %w", registryBase, err)
+		return fmt.Errorf("listing repositories in %s: %w", registryBase, err)
+	}
+	return nil
+}
+
+// listRepositoriesAPI lists the repositories under registryBase in full
+// through a discovery provider, the Harbor API, or the response cache,
+// whichever applies. listed is false when the catalog API is to be used.
+func (c *Client) listRepositoriesAPI(ctx context.Context, registryBase string) (repos []string, listed bool, err error) {
+	host, _ := SplitRegistryBase(registryBase)
+
+	if p := c.discoveryProvider(host); p != nil {
+		repos, err := p.Repositories(ctx, registryBase)
+		if err != nil {
+			return nil, false, fmt.Errorf("listing repositories in %s: %w", registryBase, err)
+		}
+		return repos, true, nil
 	}
 
-	return repos, nil
+	if c.harborAPI && c.isHarbor(ctx, host) {
+		repos, err := c.newHarborClient(host).Repositories(ctx, registryBase)
+		if err != nil {
+			return nil, false, fmt.Errorf("listing repositories in %s: %w", registryBase, err)
+		}
+		return repos, true, nil
+	}
+
+	store, err := c.cacheStore()
+	if err != nil {
+		return nil, false, err
+	}
+	if store != nil {
+		if repos, cerr := store.Repositories(ctx, registryBase); cerr == nil {
+			return repos, true, nil
+		}
+	}
+	return nil, false, nil
 }
 
 // errStopIteration is a sentinel used to break out of paginated catalog
@@ -273,33 +454,3 @@ func (c *Client) resolveDescriptor(ctx context.Context, repo *remote.Repository,
 	}
 	return repo.Resolve(ctx, tag)
 }
-
-// newRepository creates a remote.Repository from a full OCI reference string
-// (e.g. "registry.example.com/repo:tag") and returns the repository client
-// and the tag/digest portion.
-func (c *Client) newRepository(ref string) (*remote.Repository, string, error) {
-	repo, err := remote.NewRepository(ref)
-	if err != nil {
-		return nil, "", fmt.Errorf("parsing reference %q: %w", ref, err)
-	}
-
-	tag := repo.Reference.Reference
-	repo.PlainHTTP = c.plainHTTP
-	repo.Client = c.authClient
-
-	return repo, tag, nil
-}
-
-// newRepositoryFromName creates a remote.Repository from a repository name
-// (without tag or digest), used for listing tags.
-func (c *Client) newRepositoryFromName(name string) (*remote.Repository, error) {
-	repo, err := remote.NewRepository(name)
-	if err != nil {
-		return nil, fmt.Errorf("creating repository for %q: %w", name, err)
-	}
-
-	repo.PlainHTTP = c.plainHTTP
-	repo.Client = c.authClient
-
-	return repo, nil
-}
@@ -0,0 +1,127 @@
+package oci
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ComponentType identifies a kind of component a plugin provides.
+type ComponentType string
+
+// Plugin component types.
+const (
+	ComponentSkill     ComponentType = "skill"
+	ComponentCommand   ComponentType = "command"
+	ComponentAgent     ComponentType = "agent"
+	ComponentMCPServer ComponentType = "mcpServer"
+	ComponentLSPServer ComponentType = "lspServer"
+)
+
+// componentTypes lists the component types in reporting order.
+var componentTypes = []ComponentType{
+	ComponentSkill,
+	ComponentCommand,
+	ComponentAgent,
+	ComponentMCPServer,
+	ComponentLSPServer,
+}
+
+// components returns the names of the components of type t that p provides.
+func (p Plugin) components(t ComponentType) []string {
+	switch t {
+	case ComponentSkill:
+		return p.Skills
+	case ComponentCommand:
+		return p.Commands
+	case ComponentAgent:
+		return p.Agents
+	case ComponentMCPServer:
+		return p.MCPServers
+	case ComponentLSPServer:
+		return p.LSPServers
+	}
+	return nil
+}
+
+// Collision is a component name defined by more than one plugin.
+type Collision struct {
+	Type ComponentType
+	Name string
+	// Plugins lists the names of the colliding plugins in resolution order.
+	Plugins []string
+}
+
+func (c Collision) String() string {
+	return fmt.Sprintf("%s %q is defined by %s", c.Type, c.Name, strings.Join(c.Plugins, ", "))
+}
+
+// ConflictReport lists component name collisions across a set of plugins.
+// Commands, agents, MCP servers, and LSP servers share a single namespace
+// inside an agent, so two plugins defining the same name break at runtime.
+// Skills are namespaced per plugin and are not reported.
+type ConflictReport struct {
+	Collisions []Collision
+}
+
+// HasConflicts reports whether any collision was found.
+func (r ConflictReport) HasConflicts() bool {
+	return len(r.Collisions) > 0
+}
+
+// String summarizes the collisions, one per line.
+func (r ConflictReport) String() string {
+	lines := make([]string, len(r.Collisions))
+	for i, c := range r.Collisions {
+		lines[i] = c.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DetectConflicts analyzes the resolved plugins for colliding command,
+// agent, MCP server, and LSP server names. Collisions are ordered by
+// component type, then by first occurrence.
+func (r *ResolvedDependencies) DetectConflicts() ConflictReport {
+	var report ConflictReport
+	for _, t := range componentTypes {
+		if t == ComponentSkill {
+			continue
+		}
+		for _, item := range indexComponents(r.Plugins, t) {
+			if len(item.plugins) > 1 {
+				report.Collisions = append(report.Collisions, Collision{
+					Type:    t,
+					Name:    item.name,
+					Plugins: item.plugins,
+				})
+			}
+		}
+	}
+	return report
+}
+
+// indexedComponent is a component name with the plugins providing it.
+type indexedComponent struct {
+	name    string
+	plugins []string
+}
+
+// indexComponents groups the components of type t across plugins by name,
+// in order of first occurrence. A plugin is listed at most once per name.
+func indexComponents(plugins []DescribedPlugin, t ComponentType) []indexedComponent {
+	var items []indexedComponent
+	pos := make(map[string]int)
+	for _, p := range plugins {
+		for _, name := range p.components(t) {
+			i, ok := pos[name]
+			if !ok {
+				pos[name] = len(items)
+				items = append(items, indexedComponent{name: name, plugins: []string{p.Name}})
+				continue
+			}
+			if last := items[i].plugins[len(items[i].plugins)-1]; last != p.Name {
+				items[i].plugins = append(items[i].plugins, p.Name)
+			}
+		}
+	}
+	return items
+}
@@ -0,0 +1,72 @@
+package oci
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDetectConflicts(t *testing.T) {
+	deps := &ResolvedDependencies{
+		Plugins: []DescribedPlugin{
+			{Plugin: Plugin{
+				Name:       "gs-base",
+				Skills:     []string{"kubernetes"},
+				Commands:   []string{"deploy", "hello"},
+				MCPServers: []string{"github"},
+			}},
+			{Plugin: Plugin{
+				Name:       "gs-platform",
+				Skills:     []string{"kubernetes"},
+				Commands:   []string{"deploy"},
+				Agents:     []string{"reviewer"},
+				LSPServers: []string{"gopls"},
+			}},
+			{Plugin: Plugin{
+				Name:       "gs-sre",
+				Commands:   []string{"deploy"},
+				Agents:     []string{"reviewer"},
+				MCPServers: []string{"github", "pagerduty"},
+				LSPServers: []string{"yamlls"},
+			}},
+		},
+	}
+
+	report := deps.DetectConflicts()
+	if !report.HasConflicts() {
+		t.Fatal("HasConflicts() = false, want true")
+	}
+
+	want := []Collision{
+		{Type: ComponentCommand, Name: "deploy", Plugins: []string{"gs-base", "gs-platform", "gs-sre"}},
+		{Type: ComponentAgent, Name: "reviewer", Plugins: []string{"gs-platform", "gs-sre"}},
+		{Type: ComponentMCPServer, Name: "github", Plugins: []string{"gs-base", "gs-sre"}},
+	}
+	if len(report.Collisions) != len(want) {
+		t.Fatalf("Collisions = %+v, want %+v", report.Collisions, want)
+	}
+	for i, c := range report.Collisions {
+		if c.Type != want[i].Type || c.Name != want[i].Name || !slices.Equal(c.Plugins, want[i].Plugins) {
+			t.Errorf("Collisions[%d] = %+v, want %+v", i, c, want[i])
+		}
+	}
+
+	if got := report.Collisions[0].String(); got != `command "deploy" is defined by gs-base, gs-platform, gs-sre` {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestDetectConflicts_None(t *testing.T) {
+	deps := &ResolvedDependencies{
+		Plugins: []DescribedPlugin{
+			{Plugin: Plugin{Name: "a", Commands: []string{"one"}}},
+			{Plugin: Plugin{Name: "b", Commands: []string{"two"}}},
+		},
+	}
+	report := deps.DetectConflicts()
+	if report.HasConflicts() {
+		t.Errorf("Collisions = %+v, want none", report.Collisions)
+	}
+	if report.String() != "" {
+		t.Errorf("String() = %q, want empty", report.String())
+	}
+}
@@ -0,0 +1,363 @@
+// Package conformance checks that a registry supports the operations of
+// klaus-oci, so it can be qualified before hosting Klaus artifacts.
+//
+// Run pushes a small test plugin below a base path of the target registry
+// and exercises pushing, resolving, describing, pulling, retagging,
+// listing, referrers, and deletion with it. Each operation is reported as
+// a separate check, so the report shows which features work:
+//
+//	client := oci.NewClient()
+//	report := conformance.Run(ctx, client, "registry.example.com/team/klaus-conformance")
+//	fmt.Print(report)
+//	if !report.Passed() {
+//		os.Exit(1)
+//	}
+//
+// The test plugin is pushed to <base>/plugin under a new version tag on
+// every run and left in place, since registries cannot delete
+// repositories through the OCI API. Use a base path set aside for the
+// checks.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	oci "github.com/giantswarm/klaus-oci"
+)
+
+// Check names one operation exercised by Run.
+type Check string
+
+// The checks of Run, in the order they run.
+const (
+	// CheckPing runs the authenticated /v2/ check.
+	CheckPing Check = "ping"
+	// CheckPush pushes the test plugin.
+	CheckPush Check = "push"
+	// CheckResolve lists the tags of the test plugin and resolves its
+	// latest version.
+	CheckResolve Check = "resolve"
+	// CheckDescribe fetches the manifest and config of the test plugin.
+	CheckDescribe Check = "describe"
+	// CheckPull pulls and extracts the test plugin.
+	CheckPull Check = "pull"
+	// CheckRetag adds a tag to the pushed manifest.
+	CheckRetag Check = "retag"
+	// CheckList finds the test plugin through the catalog API.
+	CheckList Check = "list"
+	// CheckReferrers attaches a scan summary as an OCI referrer and reads
+	// it back.
+	CheckReferrers Check = "referrers"
+	// CheckDelete quarantines the test plugin and releases it again,
+	// which deletes the quarantine marker manifest.
+	CheckDelete Check = "delete"
+)
+
+// optionalChecks are the checks of features klaus-oci can do without:
+// listing falls back to discovery providers, and referrers and deletion
+// only back optional operations.
+var optionalChecks = []Check{CheckList, CheckReferrers, CheckDelete}
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	// StatusPass means the operation works.
+	StatusPass Status = "pass"
+	// StatusFail means the operation failed; Result.Detail says why.
+	StatusFail Status = "fail"
+	// StatusSkip means the check did not run because a check it depends
+	// on failed.
+	StatusSkip Status = "skip"
+)
+
+// Result is the outcome of one check.
+type Result struct {
+	Check  Check
+	Status Status
+	// Optional is true for checks of features klaus-oci can do without;
+	// their failure does not fail the report.
+	Optional bool
+	// Detail explains failures and skips.
+	Detail string
+	// Duration is how long the check took.
+	Duration time.Duration
+}
+
+// Report is the result of Run.
+type Report struct {
+	// Target is the base path the checks ran against.
+	Target string
+	// Results lists the results in the order the checks ran.
+	Results []Result
+}
+
+// Passed reports whether every required check passed.
+func (r *Report) Passed() bool {
+	for _, res := range r.Results {
+		if !res.Optional && res.Status != StatusPass {
+			return false
+		}
+	}
+	return true
+}
+
+// Result returns the result of check.
+func (r *Report) Result(check Check) (Result, bool) {
+	i := slices.IndexFunc(r.Results, func(res Result) bool { return res.Check == check })
+	if i < 0 {
+		return Result{}, false
+	}
+	return r.Results[i], true
+}
+
+// String formats the report as one line per check.
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "conformance of %s\n", r.Target)
+	for _, res := range r.Results {
+		status := strings.ToUpper(string(res.Status))
+		if res.Optional {
+			status += "*"
+		}
+		fmt.Fprintf(&b, "  %-5s %-9s %8s", status, res.Check, res.Duration.Round(time.Millisecond))
+		if res.Detail != "" {
+			fmt.Fprintf(&b, "  %s", res.Detail)
+		}
+		b.WriteByte('\n')
+	}
+	if slices.ContainsFunc(r.Results, func(res Result) bool { return res.Optional }) {
+		b.WriteString("  (* optional)\n")
+	}
+	return b.String()
+}
+
+// Run runs all checks against the registry path base, e.g.
+// "registry.example.com/team/klaus-conformance", with client. Checks run
+// in order; those depending on a failed check are skipped. Run only
+// returns early when ctx is done, reporting the remaining checks as
+// skipped.
+func Run(ctx context.Context, client *oci.Client, base string) *Report {
+	base = strings.TrimSuffix(strings.TrimSpace(base), "/")
+	r := &runner{
+		client:     client,
+		base:       base,
+		repository: base + "/plugin",
+		version:    fmt.Sprintf("v0.1.%d", time.Now().Unix()),
+		report:     &Report{Target: base},
+	}
+	r.run(ctx)
+	return r.report
+}
+
+// runner holds the state shared between the checks of one run.
+type runner struct {
+	client     *oci.Client
+	base       string
+	repository string
+	version    string
+	report     *Report
+
+	// digest is the manifest digest of the pushed test plugin.
+	digest string
+}
+
+func (r *runner) ref() string { return r.repository + ":" + r.version }
+
+func (r *runner) run(ctx context.Context) {
+	steps := []struct {
+		check    Check
+		requires []Check
+		fn       func(context.Context) error
+	}{
+		{CheckPing, nil, r.ping},
+		{CheckPush, []Check{CheckPing}, r.push},
+		{CheckResolve, []Check{CheckPush}, r.resolve},
+		{CheckDescribe, []Check{CheckPush}, r.describe},
+		{CheckPull, []Check{CheckPush}, r.pull},
+		{CheckRetag, []Check{CheckPush}, r.retag},
+		{CheckList, []Check{CheckPush}, r.list},
+		{CheckReferrers, []Check{CheckPush}, r.referrers},
+		{CheckDelete, []Check{CheckReferrers}, r.delete},
+	}
+	for _, step := range steps {
+		res := Result{Check: step.check, Optional: slices.Contains(optionalChecks, step.check)}
+		if err := ctx.Err(); err != nil {
+			res.Status, res.Detail = StatusSkip, err.Error()
+			r.report.Results = append(r.report.Results, res)
+			continue
+		}
+		if failed := r.failed(step.requires); failed != "" {
+			res.Status, res.Detail = StatusSkip, fmt.Sprintf("requires %s", failed)
+			r.report.Results = append(r.report.Results, res)
+			continue
+		}
+		start := time.Now()
+		err := step.fn(ctx)
+		res.Duration = time.Since(start)
+		res.Status = StatusPass
+		if err != nil {
+			res.Status, res.Detail = StatusFail, err.Error()
+		}
+		r.report.Results = append(r.report.Results, res)
+	}
+}
+
+// failed returns the first of checks that did not pass, or "".
+func (r *runner) failed(checks []Check) Check {
+	for _, c := range checks {
+		if res, ok := r.report.Result(c); !ok || res.Status != StatusPass {
+			return c
+		}
+	}
+	return ""
+}
+
+func (r *runner) ping(ctx context.Context) error {
+	_, err := r.client.Ping(ctx, r.repository)
+	return err
+}
+
+func (r *runner) push(ctx context.Context) error {
+	dir, err := os.MkdirTemp("", "klaus-conformance-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	p, err := oci.ScaffoldPlugin(dir, oci.ScaffoldPluginOptions{
+		Name:        "klaus-conformance",
+		Description: "Registry conformance test plugin",
+		Skills:      []string{"check"},
+	})
+	if err != nil {
+		return err
+	}
+	result, err := r.client.PushPlugin(ctx, dir, r.ref(), *p)
+	if err != nil {
+		return err
+	}
+	r.digest = result.Digest
+	return nil
+}
+
+func (r *runner) resolve(ctx context.Context) error {
+	versions, err := r.client.ListPluginVersions(ctx, r.repository)
+	if err != nil {
+		return fmt.Errorf("listing tags: %w", err)
+	}
+	if !slices.Contains(versions, r.version) {
+		return fmt.Errorf("tag list %v misses %s", versions, r.version)
+	}
+	latest, err := r.client.ResolveLatestVersion(ctx, r.repository)
+	if err != nil {
+		return fmt.Errorf("resolving latest version: %w", err)
+	}
+	if latest != r.ref() {
+		return fmt.Errorf("latest version resolved to %s, want %s", latest, r.ref())
+	}
+	digest, err := r.client.Resolve(ctx, r.ref())
+	if err != nil {
+		return fmt.Errorf("resolving digest: %w", err)
+	}
+	if digest != r.digest {
+		return fmt.Errorf("%s resolved to %s, want %s", r.ref(), digest, r.digest)
+	}
+	return nil
+}
+
+func (r *runner) describe(ctx context.Context) error {
+	described, err := r.client.DescribePlugin(ctx, r.ref())
+	if err != nil {
+		return err
+	}
+	if described.Digest != r.digest || described.Name != "klaus-conformance" {
+		return fmt.Errorf("described %s (%s), want klaus-conformance (%s)", described.Name, described.Digest, r.digest)
+	}
+	return nil
+}
+
+func (r *runner) pull(ctx context.Context) error {
+	dir, err := os.MkdirTemp("", "klaus-conformance-pull-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	pulled, err := r.client.PullPlugin(ctx, r.ref(), filepath.Join(dir, "plugin"))
+	if err != nil {
+		return err
+	}
+	if pulled.Digest != r.digest {
+		return fmt.Errorf("pulled %s, want %s", pulled.Digest, r.digest)
+	}
+	if _, err := os.Stat(filepath.Join(pulled.Dir, "skills", "check", "SKILL.md")); err != nil {
+		return fmt.Errorf("pulled content is incomplete: %w", err)
+	}
+	return nil
+}
+
+func (r *runner) retag(ctx context.Context) error {
+	tag := r.version + "-retag"
+	if err := r.client.Retag(ctx, r.repository, r.digest, tag); err != nil {
+		return err
+	}
+	digest, err := r.client.Resolve(ctx, r.repository+":"+tag)
+	if err != nil {
+		return fmt.Errorf("resolving new tag: %w", err)
+	}
+	if digest != r.digest {
+		return fmt.Errorf("new tag points at %s, want %s", digest, r.digest)
+	}
+	return nil
+}
+
+func (r *runner) list(ctx context.Context) error {
+	entries, err := r.client.ListPlugins(ctx, oci.WithRegistry(r.base))
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(entries, func(e oci.ListEntry) bool { return e.Repository == r.repository }) {
+		return fmt.Errorf("catalog misses %s", r.repository)
+	}
+	return nil
+}
+
+func (r *runner) referrers(ctx context.Context) error {
+	digest, err := r.client.AttachScanSummary(ctx, r.ref(), oci.ScanSummary{Scanner: "klaus-oci conformance"})
+	if err != nil {
+		return fmt.Errorf("attaching: %w", err)
+	}
+	summaries, err := r.client.FetchScanSummaries(ctx, r.ref())
+	if err != nil {
+		return fmt.Errorf("listing: %w", err)
+	}
+	if !slices.ContainsFunc(summaries, func(s oci.ScanSummary) bool { return s.Digest == digest }) {
+		return fmt.Errorf("referrer %s is not listed", digest)
+	}
+	return nil
+}
+
+func (r *runner) delete(ctx context.Context) error {
+	if _, err := r.client.Quarantine(ctx, r.ref(), "conformance check"); err != nil {
+		return fmt.Errorf("pushing marker: %w", err)
+	}
+	if err := r.client.ReleaseQuarantine(ctx, r.ref()); err != nil {
+		return err
+	}
+	described, err := r.client.DescribePlugin(ctx, r.ref())
+	if err != nil {
+		return err
+	}
+	if described.Quarantine != nil {
+		return errors.New("deleted marker is still listed")
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package conformance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	oci "github.com/giantswarm/klaus-oci"
+)
+
+// memoryRegistry implements the subset of the distribution API Run uses.
+// Referrers are served through the tag schema fallback.
+type memoryRegistry struct {
+	noCatalog bool
+	noDelete  bool
+
+	mu        sync.Mutex
+	tags      map[string]map[string]string
+	manifests map[string][]byte
+	types     map[string]string
+	blobs     map[string][]byte
+}
+
+func newMemoryRegistry(t *testing.T, reg *memoryRegistry) string {
+	t.Helper()
+	reg.tags = map[string]map[string]string{}
+	reg.manifests = map[string][]byte{}
+	reg.types = map[string]string{}
+	reg.blobs = map[string][]byte{}
+	ts := httptest.NewServer(reg)
+	t.Cleanup(ts.Close)
+	return strings.TrimPrefix(ts.URL, "http://")
+}
+
+func digestOf(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (r *memoryRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	switch {
+	case req.URL.Path == "/v2/":
+		w.WriteHeader(http.StatusOK)
+	case path == "_catalog":
+		if r.noCatalog {
+			http.Error(w, "catalog disabled", http.StatusNotFound)
+			return
+		}
+		repos := []string{}
+		for repo := range r.tags {
+			repos = append(repos, repo)
+		}
+		slices.Sort(repos)
+		_ = json.NewEncoder(w).Encode(map[string][]string{"repositories": repos})
+	case strings.HasSuffix(path, "/tags/list"):
+		repo := strings.TrimSuffix(path, "/tags/list")
+		if r.tags[repo] == nil {
+			http.NotFound(w, req)
+			return
+		}
+		tags := []string{}
+		for tag := range r.tags[repo] {
+			tags = append(tags, tag)
+		}
+		slices.Sort(tags)
+		_ = json.NewEncoder(w).Encode(map[string]any{"name": repo, "tags": tags})
+	case strings.HasSuffix(path, "/blobs/uploads/") && req.Method == http.MethodPost:
+		w.Header().Set("Location", req.URL.Path+"upload")
+		w.WriteHeader(http.StatusAccepted)
+	case strings.HasSuffix(path, "/blobs/uploads/upload") && req.Method == http.MethodPut:
+		body, _ := io.ReadAll(req.Body)
+		digest := digestOf(body)
+		if digest != req.URL.Query().Get("digest") {
+			http.Error(w, "digest mismatch", http.StatusBadRequest)
+			return
+		}
+		r.blobs[digest] = body
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.WriteHeader(http.StatusCreated)
+	case strings.Contains(path, "/blobs/"):
+		digest := path[strings.LastIndex(path, "/")+1:]
+		r.serve(w, req, r.blobs[digest], "application/octet-stream", digest)
+	case strings.Contains(path, "/manifests/"):
+		idx := strings.Index(path, "/manifests/")
+		repo, ref := path[:idx], path[idx+len("/manifests/"):]
+		r.manifest(w, req, repo, ref)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (r *memoryRegistry) manifest(w http.ResponseWriter, req *http.Request, repo, ref string) {
+	switch req.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(req.Body)
+		digest := digestOf(body)
+		r.manifests[digest] = body
+		r.types[digest] = req.Header.Get("Content-Type")
+		if r.tags[repo] == nil {
+			r.tags[repo] = map[string]string{}
+		}
+		if !strings.HasPrefix(ref, "sha256:") {
+			r.tags[repo][ref] = digest
+		}
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodDelete:
+		if r.noDelete {
+			http.Error(w, "deletion disabled", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.manifests[ref] == nil {
+			http.NotFound(w, req)
+			return
+		}
+		delete(r.manifests, ref)
+		for tag, digest := range r.tags[repo] {
+			if digest == ref {
+				delete(r.tags[repo], tag)
+			}
+		}
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		digest := ref
+		if !strings.HasPrefix(ref, "sha256:") {
+			digest = r.tags[repo][ref]
+		}
+		r.serve(w, req, r.manifests[digest], r.types[digest], digest)
+	}
+}
+
+func (r *memoryRegistry) serve(w http.ResponseWriter, req *http.Request, body []byte, mediaType, digest string) {
+	if body == nil {
+		http.NotFound(w, req)
+		return
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Docker-Content-Digest", digest)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if req.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write(body)
+}
+
+func statuses(report *Report) map[Check]Status {
+	out := map[Check]Status{}
+	for _, res := range report.Results {
+		out[res.Check] = res.Status
+	}
+	return out
+}
+
+func TestRun(t *testing.T) {
+	host := newMemoryRegistry(t, &memoryRegistry{})
+	client := oci.NewClient(oci.WithPlainHTTP(true))
+
+	report := Run(t.Context(), client, host+"/klaus/conformance/")
+	if report.Target != host+"/klaus/conformance" {
+		t.Errorf("Target = %q", report.Target)
+	}
+	if !report.Passed() {
+		t.Fatalf("Passed() = false\n%s", report)
+	}
+	checks := []Check{CheckPing, CheckPush, CheckResolve, CheckDescribe, CheckPull, CheckRetag, CheckList, CheckReferrers, CheckDelete}
+	if len(report.Results) != len(checks) {
+		t.Fatalf("got %d results, want %d", len(report.Results), len(checks))
+	}
+	for i, res := range report.Results {
+		if res.Check != checks[i] || res.Status != StatusPass {
+			t.Errorf("result %d = %+v, want %s to pass", i, res, checks[i])
+		}
+	}
+}
+
+func TestRun_MissingOptionalFeatures(t *testing.T) {
+	host := newMemoryRegistry(t, &memoryRegistry{noCatalog: true, noDelete: true})
+	client := oci.NewClient(oci.WithPlainHTTP(true))
+
+	report := Run(t.Context(), client, host+"/klaus/conformance")
+	if !report.Passed() {
+		t.Errorf("Passed() = false, want missing optional features to pass\n%s", report)
+	}
+	got := statuses(report)
+	if got[CheckList] != StatusFail || got[CheckDelete] != StatusFail || got[CheckReferrers] != StatusPass {
+		t.Errorf("statuses = %v, want list and delete to fail", got)
+	}
+	res, ok := report.Result(CheckDelete)
+	if !ok || !res.Optional || res.Detail == "" {
+		t.Errorf("delete result = %+v, want an optional failure with detail", res)
+	}
+	if s := report.String(); !strings.Contains(s, "FAIL*") || !strings.Contains(s, "(* optional)") {
+		t.Errorf("String() = %q, want optional failures marked", s)
+	}
+}
+
+func TestRun_Unreachable(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	host := strings.TrimPrefix(ts.URL, "http://")
+	ts.Close()
+	client := oci.NewClient(oci.WithPlainHTTP(true))
+
+	report := Run(t.Context(), client, host+"/klaus/conformance")
+	if report.Passed() {
+		t.Fatal("Passed() = true for an unreachable registry")
+	}
+	for _, res := range report.Results[1:] {
+		if res.Status != StatusSkip || res.Detail == "" {
+			t.Errorf("result %+v, want skipped", res)
+		}
+	}
+	if res, _ := report.Result(CheckPush); res.Detail != "requires ping" {
+		t.Errorf("push Detail = %q, want requires ping", res.Detail)
+	}
+}
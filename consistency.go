@@ -0,0 +1,82 @@
+package oci
+
+import (
+	"fmt"
+	"os"
+)
+
+// ComponentDrift is a difference between the components a Plugin declares
+// and those discovered in its directory.
+type ComponentDrift struct {
+	// Kind is the component kind: "skill", "command", "agent", "hooks",
+	// "MCP server", or "LSP server".
+	Kind string
+	// Name is the component name; it is empty for hooks.
+	Name string
+	// Path is where discovery looks for the component, relative to the
+	// plugin directory, e.g. "skills/helm/SKILL.md".
+	Path string
+	// Missing is true for a declared component that was not found, and
+	// false for a found component that is not declared.
+	Missing bool
+}
+
+func (d ComponentDrift) String() string {
+	switch {
+	case d.Kind == "hooks" && d.Missing:
+		return "hooks are declared in the config but hooks/ is missing or empty"
+	case d.Kind == "hooks":
+		return "hooks/ is not declared in the config"
+	case d.Missing:
+		return fmt.Sprintf("%s %q is declared in the config but missing from the artifact (expected in %s)", d.Kind, d.Name, d.Path)
+	default:
+		return fmt.Sprintf("%s %q found in %s is not declared in the config", d.Kind, d.Name, d.Path)
+	}
+}
+
+// ConsistencyOption configures VerifyPluginConsistency.
+type ConsistencyOption func(*consistencyConfig)
+
+type consistencyConfig struct {
+	autoCorrect bool
+}
+
+// WithAutoCorrect makes VerifyPluginConsistency replace the plugin's
+// component fields with what discovery found, so the struct matches the
+// directory. The drift is still reported.
+func WithAutoCorrect() ConsistencyOption {
+	return func(cfg *consistencyConfig) { cfg.autoCorrect = true }
+}
+
+// VerifyPluginConsistency re-runs the component discovery of
+// ReadPluginFromDir on dir and compares the result with the skills,
+// commands, agents, hooks, and MCP and LSP servers plugin declares. It
+// returns one ComponentDrift per difference, or none when they agree. Use
+// it after a pull, or in CI to check that a plugin's metadata matches the
+// tree that is packaged.
+func VerifyPluginConsistency(dir string, plugin *Plugin, opts ...ConsistencyOption) ([]ComponentDrift, error) {
+	cfg := &consistencyConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("verifying plugin consistency: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("verifying plugin consistency: %s is not a directory", dir)
+	}
+
+	found := discoverComponents(dir)
+	drift := layoutDrift(plugin, found)
+
+	if cfg.autoCorrect {
+		plugin.Skills = found.Skills
+		plugin.Commands = found.Commands
+		plugin.Agents = found.Agents
+		plugin.HasHooks = found.HasHooks
+		plugin.MCPServers = found.MCPServers
+		plugin.LSPServers = found.LSPServers
+	}
+	return drift, nil
+}
@@ -0,0 +1,72 @@
+package oci
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestVerifyPluginConsistency(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "skills", "kubernetes", "SKILL.md"), "# k8s")
+	writeFile(t, filepath.Join(dir, "skills", "extra", "SKILL.md"), "# extra")
+	writeFile(t, filepath.Join(dir, "commands", "deploy.md"), "deploy")
+	writeFile(t, filepath.Join(dir, "agents", "reviewer.md"), "review")
+	writeFile(t, filepath.Join(dir, ".mcp.json"), `{"github":{}}`)
+
+	plugin := Plugin{
+		Name:       "drift",
+		Skills:     []string{"kubernetes", "helm"},
+		Commands:   []string{"deploy"},
+		Agents:     []string{"reviewer"},
+		HasHooks:   true,
+		MCPServers: []string{"github", "slack"},
+	}
+	drift, err := VerifyPluginConsistency(dir, &plugin)
+	if err != nil {
+		t.Fatalf("VerifyPluginConsistency() error = %v", err)
+	}
+	want := []ComponentDrift{
+		{Kind: "skill", Name: "helm", Path: "skills/helm/SKILL.md", Missing: true},
+		{Kind: "skill", Name: "extra", Path: "skills/extra/SKILL.md"},
+		{Kind: "MCP server", Name: "slack", Path: ".mcp.json", Missing: true},
+		{Kind: "hooks", Path: "hooks/", Missing: true},
+	}
+	if !slices.Equal(drift, want) {
+		t.Errorf("VerifyPluginConsistency() =\n%+v\nwant\n%+v", drift, want)
+	}
+	if !slices.Equal(plugin.Skills, []string{"kubernetes", "helm"}) {
+		t.Errorf("Skills = %v, changed without WithAutoCorrect", plugin.Skills)
+	}
+
+	if _, err := VerifyPluginConsistency(dir, &plugin, WithAutoCorrect()); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(plugin.Skills, []string{"extra", "kubernetes"}) || plugin.HasHooks || !slices.Equal(plugin.MCPServers, []string{"github"}) {
+		t.Errorf("corrected plugin = %+v", plugin)
+	}
+	if drift, err := VerifyPluginConsistency(dir, &plugin); err != nil || len(drift) != 0 {
+		t.Errorf("VerifyPluginConsistency() after correction = %+v, %v, want no drift", drift, err)
+	}
+
+	if _, err := VerifyPluginConsistency(filepath.Join(dir, "missing"), &plugin); err == nil {
+		t.Error("VerifyPluginConsistency() for a missing directory error = nil, want error")
+	}
+}
+
+func TestComponentDrift_String(t *testing.T) {
+	tests := []struct {
+		drift ComponentDrift
+		want  string
+	}{
+		{ComponentDrift{Kind: "skill", Name: "helm", Path: "skills/helm/SKILL.md", Missing: true}, `skill "helm" is declared in the config but missing from the artifact (expected in skills/helm/SKILL.md)`},
+		{ComponentDrift{Kind: "command", Name: "deploy", Path: "commands/deploy.md"}, `command "deploy" found in commands/deploy.md is not declared in the config`},
+		{ComponentDrift{Kind: "hooks", Path: "hooks/", Missing: true}, "hooks are declared in the config but hooks/ is missing or empty"},
+		{ComponentDrift{Kind: "hooks", Path: "hooks/"}, "hooks/ is not declared in the config"},
+	}
+	for _, tt := range tests {
+		if got := tt.drift.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
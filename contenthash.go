@@ -0,0 +1,109 @@
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ContentTreeHash returns the content-tree hash of dir: the root of a
+// Merkle tree over the sha256 digests of the files that packaging dir
+// would put into the content layer, e.g. "sha256:...". Each directory is
+// hashed over its sorted entries, each entry contributing its name, its
+// digest, and the file mode packaging normalizes it to. Like packaging,
+// the hash ignores timestamps, ownership, symlinks, and cache metadata,
+// so a directory and its pulled copy hash the same.
+//
+// PushPlugin and PushPersonality record the hash in the config blob (see
+// Plugin.ContentHash), so comparing versions with each other or with a
+// local directory needs no content layer download.
+func ContentTreeHash(dir string) (string, error) {
+	d, err := hashTree(dir)
+	if err != nil {
+		return "", fmt.Errorf("hashing %s: %w", dir, err)
+	}
+	return "sha256:" + hex.EncodeToString(d), nil
+}
+
+// hashTree returns the Merkle hash of the directory at path.
+func hashTree(path string) ([]byte, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	for _, e := range entries {
+		if e.Name() == cacheFileName {
+			continue
+		}
+		child := filepath.Join(path, e.Name())
+		var (
+			mode string
+			d    []byte
+		)
+		switch {
+		case e.IsDir():
+			mode = "40755"
+			d, err = hashTree(child)
+		case e.Type().IsRegular():
+			info, ierr := e.Info()
+			if ierr != nil {
+				return nil, ierr
+			}
+			mode = "100644"
+			if info.Mode()&0o111 != 0 {
+				mode = "100755"
+			}
+			d, err = hashFile(child)
+		default:
+			// Symlinks and other entries are not packaged.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(h, "%s %s sha256:%x\n", mode, e.Name(), d)
+	}
+	return h.Sum(nil), nil
+}
+
+// hashFile returns the sha256 digest of the file at path.
+func hashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// contentHashed is implemented by the artifact types whose config blob
+// records the content-tree hash.
+type contentHashed interface {
+	setContentHash(hash string)
+}
+
+func (p *Plugin) setContentHash(hash string)      { p.ContentHash = hash }
+func (p *Personality) setContentHash(hash string) { p.ContentHash = hash }
+
+// withContentHash sets the content-tree hash of sourceDir on artifact if
+// its type records one.
+func withContentHash[T any](sourceDir string, artifact *T) error {
+	a, ok := any(artifact).(contentHashed)
+	if !ok {
+		return nil
+	}
+	hash, err := ContentTreeHash(sourceDir)
+	if err != nil {
+		return err
+	}
+	a.setContentHash(hash)
+	return nil
+}
@@ -0,0 +1,84 @@
+package oci
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestContentTreeHash(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "SOUL.md"), "Be calm.")
+	writeFile(t, filepath.Join(src, "skills", "kubectl", "SKILL.md"), "# kubectl")
+	mkdirAll(t, filepath.Join(src, "empty"))
+
+	hash, err := ContentTreeHash(src)
+	if err != nil {
+		t.Fatalf("ContentTreeHash() error = %v", err)
+	}
+
+	// Permission bits other than the executable bit, cache metadata, and
+	// symlinks are not packaged and do not change the hash.
+	if err := os.Chmod(filepath.Join(src, "SOUL.md"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(src, cacheFileName), "{}")
+	if err := os.Symlink("SOUL.md", filepath.Join(src, "link.md")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ContentTreeHash(src); err != nil || got != hash {
+		t.Errorf("ContentTreeHash() = %s, %v, want unchanged %s", got, err, hash)
+	}
+
+	for name, change := range map[string]func(dir string){
+		"content":    func(dir string) { writeFile(t, filepath.Join(dir, "SOUL.md"), "Be bold.") },
+		"rename":     func(dir string) { os.Rename(filepath.Join(dir, "SOUL.md"), filepath.Join(dir, "SOUL.txt")) },
+		"executable": func(dir string) { os.Chmod(filepath.Join(dir, "SOUL.md"), 0o755) },
+		"empty dir":  func(dir string) { os.Remove(filepath.Join(dir, "empty")) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.CopyFS(dir, os.DirFS(src)); err != nil {
+				t.Fatal(err)
+			}
+			change(dir)
+			if got, err := ContentTreeHash(dir); err != nil || got == hash {
+				t.Errorf("ContentTreeHash() = %s, %v, want a different hash", got, err)
+			}
+		})
+	}
+}
+
+func TestPush_RecordsContentHash(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, ".claude-plugin", "plugin.json"), `{"name":"gs-base"}`)
+	writeFile(t, filepath.Join(src, "skills", "kubectl", "SKILL.md"), "# kubectl")
+	want, err := ContentTreeHash(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ref := host + "/klaus/gs-base:v1.0.0"
+	if _, err := client.PushPlugin(t.Context(), src, ref, Plugin{Name: "gs-base", ContentHash: "sha256:stale"}); err != nil {
+		t.Fatalf("PushPlugin() error = %v", err)
+	}
+	described, err := client.DescribePlugin(t.Context(), ref)
+	if err != nil {
+		t.Fatalf("DescribePlugin() error = %v", err)
+	}
+	if described.ContentHash != want {
+		t.Errorf("described ContentHash = %s, want %s", described.ContentHash, want)
+	}
+
+	pulled, err := client.PullPlugin(t.Context(), ref, filepath.Join(t.TempDir(), "gs-base"))
+	if err != nil {
+		t.Fatalf("PullPlugin() error = %v", err)
+	}
+	if got, err := ContentTreeHash(pulled.Dir); err != nil || got != want || pulled.ContentHash != want {
+		t.Errorf("pulled ContentTreeHash() = %s, %v, ContentHash = %s, want %s", got, err, pulled.ContentHash, want)
+	}
+}
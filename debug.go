@@ -0,0 +1,163 @@
+package oci
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithDebugTransport logs every registry HTTP request and response to w:
+// method, URL, headers, status, and time to response headers.
+// Authorization and cookie headers and credential-like query parameters,
+// such as the signatures of pre-signed blob URLs, are redacted; bodies are
+// never logged. Use Client.SetDebugOutput to turn logging on or off later.
+func WithDebugTransport(w io.Writer) ClientOption {
+	return func(c *Client) { c.SetDebugOutput(w) }
+}
+
+// SetDebugOutput turns request logging on by directing it to w, or off
+// when w is nil. It is safe to call while requests are in flight, e.g. from
+// a signal handler while troubleshooting a running process. Logging only
+// covers clients whose HTTP transport was configured by NewClient.
+func (c *Client) SetDebugOutput(w io.Writer) {
+	if w == nil {
+		c.debug.Store(nil)
+		return
+	}
+	c.debug.Store(&debugOutput{w: w})
+}
+
+// debugOutput serializes log entries of concurrent requests.
+type debugOutput struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (d *debugOutput) write(s string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, _ = io.WriteString(d.w, s)
+}
+
+// debugTransport logs requests and responses when its output is set.
+type debugTransport struct {
+	base   http.RoundTripper
+	output *atomic.Pointer[debugOutput]
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	out := t.output.Load()
+	if out == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	target := req.Method + " " + sanitizeURL(req.URL)
+	var b strings.Builder
+	fmt.Fprintf(&b, "--> %s\n", target)
+	writeDebugHeader(&b, req.Header)
+	out.write(b.String())
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Microsecond)
+
+	b.Reset()
+	if err != nil {
+		fmt.Fprintf(&b, "<-- error %s (%s): %v\n", target, elapsed, err)
+	} else {
+		fmt.Fprintf(&b, "<-- %s %s (%s)\n", resp.Status, target, elapsed)
+		writeDebugHeader(&b, resp.Header)
+	}
+	out.write(b.String())
+	return resp, err
+}
+
+// sensitiveHeaders are logged as "[redacted]".
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// writeDebugHeader writes h sorted by name, one value per line.
+func writeDebugHeader(b *strings.Builder, h http.Header) {
+	for _, name := range slices.Sorted(maps.Keys(h)) {
+		for _, v := range sanitizeHeader(name, h[name]) {
+			fmt.Fprintf(b, "    %s: %s\n", name, v)
+		}
+	}
+}
+
+// urlHeaders hold URLs, such as the pre-signed URLs of blob redirects,
+// and are logged through sanitizeURL.
+var urlHeaders = []string{
+	"Location",
+	"Content-Location",
+}
+
+// sanitizeHeader returns the values of header name with credentials
+// replaced.
+func sanitizeHeader(name string, values []string) []string {
+	name = http.CanonicalHeaderKey(name)
+	if slices.Contains(urlHeaders, name) {
+		clean := make([]string, len(values))
+		for i, v := range values {
+			if u, err := url.Parse(v); err == nil {
+				clean[i] = sanitizeURL(u)
+			} else {
+				clean[i] = "[redacted]"
+			}
+		}
+		return clean
+	}
+	if !slices.Contains(sensitiveHeaders, name) {
+		return values
+	}
+	redacted := make([]string, len(values))
+	for i, v := range values {
+		// Keep the scheme, which tells basic from bearer auth.
+		if scheme, _, ok := strings.Cut(v, " "); ok && strings.HasSuffix(name, "Authorization") {
+			redacted[i] = scheme + " [redacted]"
+		} else {
+			redacted[i] = "[redacted]"
+		}
+	}
+	return redacted
+}
+
+// sanitizeURL returns u without user info and with the values of query
+// parameters that look like credentials replaced, such as the signatures
+// of pre-signed object storage URLs that registries redirect blob
+// downloads to.
+func sanitizeURL(u *url.URL) string {
+	clean := *u
+	clean.User = nil
+	if clean.RawQuery != "" {
+		q := clean.Query()
+		for k := range q {
+			if isSensitiveParam(k) {
+				q[k] = []string{"redacted"}
+			}
+		}
+		clean.RawQuery = q.Encode()
+	}
+	return clean.String()
+}
+
+func isSensitiveParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range []string{"sig", "token", "credential", "key", "auth", "password", "secret"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
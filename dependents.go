@@ -0,0 +1,150 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+	"golang.org/x/sync/errgroup"
+)
+
+// ReverseDependency is a personality version that references a plugin.
+type ReverseDependency struct {
+	// Repository is the personality repository.
+	Repository string
+	// Tag is the personality tag.
+	Tag string
+	// Plugin is the plugin reference as written in the personality.
+	Plugin PluginReference
+}
+
+// ReverseDependencies reports which personalities under personalityBase
+// reference the plugin pluginRef, and at which plugin tags, to assess the
+// impact of a breaking plugin change. pluginRef is a short name (expanded
+// with DefaultPluginRegistry, or in the namespace set with
+// WithDefaultNamespace) or a repository; with a tag or digest, only
+// references pinning exactly that tag or digest are reported. An empty
+// personalityBase selects the personality registry of the namespace.
+//
+// Every tag of every personality repository is inspected, since older
+// versions may still be deployed. Results are sorted by repository, then
+// by version, newest first. Only direct references are reported; plugins
+// pulled in through plugin dependencies or Extends are not.
+func (c *Client) ReverseDependencies(ctx context.Context, pluginRef, personalityBase string) ([]ReverseDependency, error) {
+	pluginRef = strings.TrimSpace(pluginRef)
+	if pluginRef == "" {
+		return nil, fmt.Errorf("empty plugin reference")
+	}
+	pluginBase, pluginRef := c.expandTenant(ctx, KindPlugin, pluginRef)
+	if !strings.Contains(pluginRef, "/") {
+		pluginRef = pluginBase + "/" + pluginRef
+	}
+	want := PluginReference{Repository: RepositoryFromRef(pluginRef)}
+	if hasDigest(pluginRef) {
+		want.Digest = digestFromRef(pluginRef)
+	} else {
+		want.Tag = extractTag(pluginRef)
+	}
+	if personalityBase == "" {
+		personalityBase = c.registryBase(ctx, KindPersonality)
+	}
+
+	repos, err := c.listRepositories(ctx, personalityBase)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu      sync.Mutex
+		tagRefs []string
+	)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+	for _, repo := range repos {
+		g.Go(func() error {
+			tags, err := c.List(gctx, repo)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			for _, tag := range tags {
+				tagRefs = append(tagRefs, repo+":"+tag)
+			}
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var deps []ReverseDependency
+	g, gctx = errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+	for _, ref := range tagRefs {
+		g.Go(func() error {
+			described, err := c.DescribePersonality(gctx, ref)
+			var wrongType *ErrWrongArtifactType
+			if errors.As(err, &wrongType) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			for _, p := range described.Plugins {
+				if !referencesPlugin(p, want) {
+					continue
+				}
+				mu.Lock()
+				deps = append(deps, ReverseDependency{Repository: RepositoryFromRef(ref), Tag: described.Tag, Plugin: p})
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(deps, func(a, b ReverseDependency) int {
+		if c := strings.Compare(a.Repository, b.Repository); c != 0 {
+			return c
+		}
+		return compareTagsDescending(a.Tag, b.Tag)
+	})
+	return deps, nil
+}
+
+// referencesPlugin reports whether ref points at the plugin want. A want
+// without tag and digest matches any reference to its repository.
+func referencesPlugin(ref, want PluginReference) bool {
+	switch {
+	case ref.Repository != want.Repository:
+		return false
+	case want.Digest != "":
+		return ref.Digest == want.Digest
+	case want.Tag != "":
+		return ref.Tag == want.Tag
+	}
+	return true
+}
+
+// compareTagsDescending orders semver tags newest first, followed by other
+// tags in lexical order.
+func compareTagsDescending(a, b string) int {
+	va, errA := semver.NewVersion(a)
+	vb, errB := semver.NewVersion(b)
+	switch {
+	case errA == nil && errB == nil:
+		return vb.Compare(va)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,72 @@
+package oci
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReverseDependencies(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	base := host + "/klaus-personalities"
+	gsBase := host + "/klaus-plugins/gs-base"
+
+	push := func(name, version string, plugins ...PluginReference) {
+		t.Helper()
+		src := t.TempDir()
+		writeFile(t, filepath.Join(src, "SOUL.md"), "Be calm.")
+		if _, err := client.PushPersonality(t.Context(), src, base+"/"+name+":"+version, Personality{Name: name, Plugins: plugins}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	push("sre", "v1.0.0", PluginReference{Repository: gsBase, Tag: "v1.0.0"})
+	push("sre", "v1.1.0", PluginReference{Repository: gsBase, Tag: "v2.0.0"})
+	push("sre", "v1.10.0", PluginReference{Repository: gsBase, Tag: "v2.0.0"})
+	push("dev", "v0.1.0", PluginReference{Repository: host + "/klaus-plugins/gs-other", Tag: "v1.0.0"})
+	push("platform", "v3.0.0", PluginReference{Repository: host + "/klaus-plugins/gs-other"}, PluginReference{Repository: gsBase, Tag: "v2.0.0"})
+
+	// A plugin in the personality namespace is skipped.
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, ".claude-plugin", "plugin.json"), `{"name":"stray"}`)
+	if _, err := client.PushPlugin(t.Context(), src, base+"/stray:v1.0.0", Plugin{Name: "stray"}); err != nil {
+		t.Fatal(err)
+	}
+
+	deps, err := client.ReverseDependencies(t.Context(), gsBase, base)
+	if err != nil {
+		t.Fatalf("ReverseDependencies() error = %v", err)
+	}
+	want := []ReverseDependency{
+		{Repository: base + "/platform", Tag: "v3.0.0", Plugin: PluginReference{Repository: gsBase, Tag: "v2.0.0"}},
+		{Repository: base + "/sre", Tag: "v1.10.0", Plugin: PluginReference{Repository: gsBase, Tag: "v2.0.0"}},
+		{Repository: base + "/sre", Tag: "v1.1.0", Plugin: PluginReference{Repository: gsBase, Tag: "v2.0.0"}},
+		{Repository: base + "/sre", Tag: "v1.0.0", Plugin: PluginReference{Repository: gsBase, Tag: "v1.0.0"}},
+	}
+	if len(deps) != len(want) {
+		t.Fatalf("ReverseDependencies() = %+v, want %+v", deps, want)
+	}
+	for i := range want {
+		if deps[i] != want[i] {
+			t.Errorf("ReverseDependencies()[%d] = %+v, want %+v", i, deps[i], want[i])
+		}
+	}
+
+	deps, err = client.ReverseDependencies(t.Context(), gsBase+":v1.0.0", base)
+	if err != nil {
+		t.Fatalf("ReverseDependencies() error = %v", err)
+	}
+	if len(deps) != 1 || deps[0].Tag != "v1.0.0" {
+		t.Errorf("ReverseDependencies(v1.0.0) = %+v, want sre v1.0.0 only", deps)
+	}
+}
+
+func TestCompareTagsDescending(t *testing.T) {
+	tags := []string{"latest", "v1.2.0", "v1.10.0", "main", "v0.9.0"}
+	want := []string{"v1.10.0", "v1.2.0", "v0.9.0", "latest", "main"}
+	slices.SortFunc(tags, compareTagsDescending)
+	if !slices.Equal(tags, want) {
+		t.Errorf("sorted = %v, want %v", tags, want)
+	}
+}
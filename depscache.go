@@ -0,0 +1,234 @@
+package oci
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	godigest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxDepsCacheEntries bounds the in-memory dependency cache; it is
+// emptied when full.
+const maxDepsCacheEntries = 1024
+
+// depsCache holds the serialized results of ResolvePersonalityDeps for
+// personalities whose dependencies are all digest-pinned. Such results
+// are deterministic, since pinned manifests cannot change.
+type depsCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+
+	keyOnce sync.Once
+	key     []byte
+}
+
+// depsCacheKey returns the cache key of p's dependency resolution, or ""
+// when a dependency is not digest-pinned and the result may change. The
+// key covers the options that change describe results (private metadata,
+// label fallback, evaluation results, provenance and trust policies, and
+// reference rewrites) and a fingerprint of the credentials ctx uses for
+// the dependencies' registries, so results are never shared between
+// credentials.
+func (c *Client) depsCacheKey(ctx context.Context, p Personality) string {
+	if p.Toolchain.Repository != "" && p.Toolchain.Digest == "" {
+		return ""
+	}
+	refs := make([]string, 0, len(p.Plugins))
+	hosts := map[string]bool{}
+	if p.Toolchain.Repository != "" {
+		host, _ := SplitRegistryBase(p.Toolchain.Repository)
+		hosts[host] = true
+	}
+	for _, ref := range p.Plugins {
+		if ref.Digest == "" {
+			return ""
+		}
+		refs = append(refs, ref.Ref())
+		host, _ := SplitRegistryBase(ref.Repository)
+		hosts[host] = true
+	}
+	provenance, ok := provenanceFingerprint(c.provenance)
+	if !ok {
+		return ""
+	}
+	credentials := make([]string, 0, len(hosts))
+	for _, host := range slices.Sorted(maps.Keys(hosts)) {
+		cred, err := c.authClient.Credential(ctx, host)
+		if err != nil {
+			return ""
+		}
+		credentials = append(credentials, credentialFingerprint(c.depsFingerprintKey(), host, cred))
+	}
+	data, err := json.Marshal(struct {
+		Toolchain      string        `json:"toolchain"`
+		Plugins        []string      `json:"plugins"`
+		IncludePrivate bool          `json:"includePrivate"`
+		LabelFallback  bool          `json:"labelFallback"`
+		EvalResults    bool          `json:"evalResults"`
+		Provenance     string        `json:"provenance"`
+		TrustPolicy    *TrustPolicy  `json:"trustPolicy"`
+		Rewrites       []RewriteRule `json:"rewrites"`
+		Credentials    []string      `json:"credentials"`
+	}{p.Toolchain.Ref(), refs, c.includePrivate, c.labelFallback, c.evalResults, provenance, c.trustPolicy, c.rewrites, credentials})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// depsFingerprintKey returns the key of the credential fingerprints in
+// dependency cache keys: the cache directory's key with WithCache, as
+// keys name files there, and the process key otherwise.
+func (c *Client) depsFingerprintKey() []byte {
+	c.deps.keyOnce.Do(func() {
+		if c.cacheCfg.dir == "" {
+			c.deps.key = processFingerprintKey()
+		} else {
+			c.deps.key = fingerprintKey(c.cacheCfg.dir)
+		}
+	})
+	return c.deps.key
+}
+
+// provenanceFingerprint returns a digest of policy for cache keys, empty
+// for nil, and false if a key cannot be encoded.
+func provenanceFingerprint(policy *ProvenancePolicy) (string, bool) {
+	if policy == nil {
+		return "", true
+	}
+	h := sha256.New()
+	for _, builder := range policy.TrustedBuilders {
+		fmt.Fprintf(h, "builder %s\n", builder)
+	}
+	for _, key := range policy.PublicKeys {
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			return "", false
+		}
+		fmt.Fprintf(h, "key %x\n", der)
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// refreshQuarantine replaces the quarantine state of the dependencies in
+// a cached result with their current state, since artifacts can be
+// quarantined after the result was cached. Checking needs access to each
+// repository, so it also fails for credentials that cannot read them.
+func (c *Client) refreshQuarantine(ctx context.Context, result *ResolvedDependencies) error {
+	infos := make([]*ArtifactInfo, 0, len(result.Plugins)+1)
+	if result.Toolchain != nil {
+		infos = append(infos, &result.Toolchain.ArtifactInfo)
+	}
+	for i := range result.Plugins {
+		infos = append(infos, &result.Plugins[i].ArtifactInfo)
+	}
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+	for _, info := range infos {
+		g.Go(func() error {
+			repo, err := c.newRepositoryFromName(RepositoryFromRef(info.Ref))
+			if err != nil {
+				return err
+			}
+			info.Quarantine, err = quarantineOf(gctx, repo, ocispec.Descriptor{Digest: godigest.Digest(info.Digest)})
+			if err != nil {
+				return fmt.Errorf("checking quarantine of %s: %w", info.Ref, err)
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+// cacheableDeps reports whether result is complete and fully determined
+// by pinned references: nothing failed to resolve, and every transitive
+// plugin dependency is digest-pinned too.
+func cacheableDeps(result *ResolvedDependencies) bool {
+	if len(result.Warnings) > 0 {
+		return false
+	}
+	for _, dp := range result.Plugins {
+		for _, dep := range dp.Dependencies {
+			if dep.Digest == "" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// cachedDeps returns the cached result for key from memory or, with
+// WithCache, from disk.
+func (c *Client) cachedDeps(key string) (*ResolvedDependencies, bool) {
+	c.deps.mu.Lock()
+	data, ok := c.deps.entries[key]
+	c.deps.mu.Unlock()
+	if !ok {
+		path := c.depsCachePath(key)
+		if path == "" {
+			return nil, false
+		}
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, false
+		}
+		c.rememberDeps(key, data)
+	}
+
+	var result ResolvedDependencies
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, false
+	}
+	// Versions are not serialized; like describe, take them from the tag.
+	if result.Toolchain != nil {
+		result.Toolchain.Version = result.Toolchain.Tag
+	}
+	for i := range result.Plugins {
+		result.Plugins[i].Version = result.Plugins[i].Tag
+	}
+	result.Cached = true
+	return &result, true
+}
+
+// storeDeps caches result under key in memory and, with WithCache, on
+// disk. Disk write failures only cost a later cache miss.
+func (c *Client) storeDeps(key string, result *ResolvedDependencies) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	c.rememberDeps(key, data)
+	if path := c.depsCachePath(key); path != "" {
+		_ = writeIndexAtomic(path, json.RawMessage(data))
+	}
+}
+
+func (c *Client) rememberDeps(key string, data []byte) {
+	c.deps.mu.Lock()
+	defer c.deps.mu.Unlock()
+	if c.deps.entries == nil || len(c.deps.entries) >= maxDepsCacheEntries {
+		c.deps.entries = make(map[string][]byte)
+	}
+	c.deps.entries[key] = data
+}
+
+// depsCachePath returns the on-disk location of key, or "" without
+// WithCache.
+func (c *Client) depsCachePath(key string) string {
+	if c.cacheCfg.dir == "" {
+		return ""
+	}
+	return filepath.Join(c.cacheCfg.dir, "deps", key+".json")
+}
@@ -0,0 +1,223 @@
+package oci
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	godigest "github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// depsRegistry serves the dependencies of pinnedDepsPersonality, with
+// quarantine markers for the digests in quarantined.
+type depsRegistry struct {
+	*httptest.Server
+	mu          sync.Mutex
+	quarantined map[string]string
+	// requests counts requests other than referrer listings.
+	requests atomic.Int64
+}
+
+func (r *depsRegistry) quarantine(digest, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.quarantined[digest] = reason
+}
+
+// pinnedDepsPersonality serves a toolchain and two plugins and returns a
+// personality pinning them by digest.
+func pinnedDepsPersonality(t *testing.T) (Personality, *depsRegistry) {
+	t.Helper()
+	pluginJSON, _ := json.Marshal(pluginConfigBlob{Skills: []string{"kubernetes"}})
+	reg := &depsRegistry{quarantined: map[string]string{}}
+	handler := newArtifactHandler(map[string]testArtifactEntry{
+		"klaus-plugins/gs-base": {
+			configJSON:      pluginJSON,
+			configMediaType: MediaTypePluginConfig,
+			tags:            []string{"v1.0.0"},
+			annotations:     buildKlausAnnotations(commonMetadata{Name: "gs-base", Author: &Author{Name: "Giant Swarm"}}),
+		},
+		"klaus-plugins/gs-sre": {
+			configJSON:      pluginJSON,
+			configMediaType: MediaTypePluginConfig,
+			tags:            []string{"v0.5.0"},
+			annotations:     buildKlausAnnotations(commonMetadata{Name: "gs-sre"}),
+		},
+		"klaus-toolchains/go": {
+			configJSON:      []byte(`{"architecture":"amd64"}`),
+			configMediaType: ocispec.MediaTypeImageConfig,
+			tags:            []string{"v1.2.0"},
+			annotations:     map[string]string{AnnotationName: "go"},
+		},
+	})
+	reg.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, subject, ok := strings.Cut(r.URL.Path, "/referrers/")
+		if !ok {
+			reg.requests.Add(1)
+			handler.ServeHTTP(w, r)
+			return
+		}
+		index := ocispec.Index{Versioned: specs.Versioned{SchemaVersion: 2}, MediaType: ocispec.MediaTypeImageIndex, Manifests: []ocispec.Descriptor{}}
+		reg.mu.Lock()
+		if reason, ok := reg.quarantined[subject]; ok {
+			index.Manifests = append(index.Manifests, ocispec.Descriptor{
+				MediaType:    ocispec.MediaTypeImageManifest,
+				ArtifactType: MediaTypeQuarantine,
+				Digest:       godigest.FromString(reason),
+				Size:         2,
+				Annotations:  map[string]string{AnnotationQuarantineReason: reason},
+			})
+		}
+		reg.mu.Unlock()
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+		_ = json.NewEncoder(w).Encode(index)
+	}))
+	host := testRegistryHost(reg.Server)
+
+	client := NewClient(WithPlainHTTP(true))
+	p, err := client.PinPersonality(t.Context(), Personality{
+		Name:      "sre",
+		Toolchain: ToolchainReference{Repository: host + "/klaus-toolchains/go", Tag: "v1.2.0"},
+		Plugins: []PluginReference{
+			{Repository: host + "/klaus-plugins/gs-base", Tag: "v1.0.0"},
+			{Repository: host + "/klaus-plugins/gs-sre", Tag: "v0.5.0"},
+		},
+	})
+	if err != nil {
+		reg.Close()
+		t.Fatalf("PinPersonality() error = %v", err)
+	}
+	return p, reg
+}
+
+func TestResolvePersonalityDeps_Cache(t *testing.T) {
+	p, reg := pinnedDepsPersonality(t)
+	defer reg.Close()
+	dir := t.TempDir()
+	client := NewClient(WithPlainHTTP(true), WithCache(dir))
+
+	first, err := client.ResolvePersonalityDeps(t.Context(), p)
+	if err != nil {
+		t.Fatalf("ResolvePersonalityDeps() error = %v", err)
+	}
+	if first.Cached || len(first.Warnings) != 0 || len(first.Plugins) != 2 || first.Toolchain == nil {
+		t.Fatalf("first resolution = %+v", first)
+	}
+
+	// Cached results only need the quarantine checks.
+	before := reg.requests.Load()
+	second, err := client.ResolvePersonalityDeps(t.Context(), p)
+	if err != nil {
+		t.Fatalf("cached ResolvePersonalityDeps() error = %v", err)
+	}
+	if !second.Cached {
+		t.Error("second resolution not served from cache")
+	}
+	second.Cached = false
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("cached result = %+v, want %+v", second, first)
+	}
+	if n := reg.requests.Load() - before; n != 0 {
+		t.Errorf("cached resolution made %d requests besides quarantine checks, want 0", n)
+	}
+
+	// A new client finds the result on disk.
+	fresh := NewClient(WithPlainHTTP(true), WithCache(dir))
+	third, err := fresh.ResolvePersonalityDeps(t.Context(), p)
+	if err != nil {
+		t.Fatalf("disk-cached ResolvePersonalityDeps() error = %v", err)
+	}
+	third.Cached = false
+	if !reflect.DeepEqual(first, third) {
+		t.Errorf("disk-cached result = %+v, want %+v", third, first)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "deps")); len(entries) != 1 {
+		t.Errorf("deps cache holds %d entries, want 1", len(entries))
+	}
+
+	// Quarantines after caching are reported on cache hits.
+	reg.quarantine(p.Plugins[1].Digest, "CVE-2026-1234")
+	for _, c := range []*Client{client, NewClient(WithPlainHTTP(true), WithCache(dir))} {
+		got, err := c.ResolvePersonalityDeps(t.Context(), p)
+		if err != nil {
+			t.Fatalf("ResolvePersonalityDeps() error = %v", err)
+		}
+		if !got.Cached {
+			t.Error("resolution of quarantined dependency not served from cache")
+		}
+		q := got.Plugins[1].Quarantine
+		if q == nil || q.Reason != "CVE-2026-1234" || q.Digest == "" {
+			t.Errorf("Quarantine = %+v, want the marker", q)
+		}
+		if got.Plugins[0].Quarantine != nil {
+			t.Errorf("Quarantine of %s = %+v, want nil", got.Plugins[0].Ref, got.Plugins[0].Quarantine)
+		}
+	}
+}
+
+func TestResolvePersonalityDeps_CacheRequiresPins(t *testing.T) {
+	p, reg := pinnedDepsPersonality(t)
+	defer reg.Close()
+	client := NewClient(WithPlainHTTP(true))
+
+	unpinned := p
+	unpinned.Plugins = append([]PluginReference(nil), p.Plugins...)
+	unpinned.Plugins[1].Digest = ""
+	if key := client.depsCacheKey(t.Context(), unpinned); key != "" {
+		t.Errorf("depsCacheKey() of an unpinned personality = %q, want empty", key)
+	}
+	if _, err := client.ResolvePersonalityDeps(t.Context(), unpinned); err != nil {
+		t.Fatal(err)
+	}
+	again, err := client.ResolvePersonalityDeps(t.Context(), unpinned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Cached {
+		t.Error("unpinned resolution served from cache")
+	}
+
+	key := client.depsCacheKey(t.Context(), p)
+	host, _ := SplitRegistryBase(p.Plugins[0].Repository)
+	for name, other := range map[string]*Client{
+		"WithIncludePrivate":         NewClient(WithIncludePrivate()),
+		"WithEvalResults":            NewClient(WithEvalResults()),
+		"WithReferenceRewrites":      NewClient(WithReferenceRewrites(RewriteRule{Prefix: "a", Replacement: "b"})),
+		"WithPullCredentials":        NewClient(WithPullCredentials(host, auth.Credential{Username: "reader", Password: "secret"})),
+		"WithProvenanceVerification": NewClient(WithProvenanceVerification(ProvenancePolicy{TrustedBuilders: []string{"https://builder"}})),
+	} {
+		if other.depsCacheKey(t.Context(), p) == key {
+			t.Errorf("depsCacheKey() ignores %s", name)
+		}
+	}
+	tenantA := WithContextCredentials(t.Context(), auth.Credential{Username: "a", Password: "secret"})
+	tenantB := WithContextCredentials(t.Context(), auth.Credential{Username: "b", Password: "secret"})
+	if client.depsCacheKey(tenantA, p) == client.depsCacheKey(tenantB, p) {
+		t.Error("depsCacheKey() ignores context credentials")
+	}
+}
+
+func TestCacheableDeps(t *testing.T) {
+	pinned := DescribedPlugin{Plugin: Plugin{Dependencies: []PluginReference{{Repository: "r", Digest: "sha256:abc"}}}}
+	unpinned := DescribedPlugin{Plugin: Plugin{Dependencies: []PluginReference{{Repository: "r", Tag: "v1"}}}}
+
+	if !cacheableDeps(&ResolvedDependencies{Plugins: []DescribedPlugin{pinned}}) {
+		t.Error("pinned dependencies not cacheable")
+	}
+	if cacheableDeps(&ResolvedDependencies{Plugins: []DescribedPlugin{unpinned}}) {
+		t.Error("unpinned transitive dependency cacheable")
+	}
+	if cacheableDeps(&ResolvedDependencies{Warnings: []string{"plugin x: not found"}}) {
+		t.Error("result with warnings cacheable")
+	}
+}
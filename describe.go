@@ -5,104 +5,218 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2/registry/remote"
 )
 
+// WithIncludePrivate makes describe operations return the full metadata of
+// artifacts marked private (see AnnotationPrivate). By default, their
+// homepage, source repository, and author email and URL are omitted and
+// ArtifactInfo.Redacted is set, so catalogs serving mixed audiences do not
+// leak internal links. Pulls are never redacted.
+func WithIncludePrivate() ClientOption {
+	return func(c *Client) { c.includePrivate = true }
+}
+
 // DescribePlugin fetches the config blob for a plugin artifact and returns
 // metadata without downloading the content layer. The ref parameter supports
 // short names (e.g. "gs-base"), name:tag, or full OCI references.
 func (c *Client) DescribePlugin(ctx context.Context, ref string) (*DescribedPlugin, error) {
-	resolved, err := c.ResolvePluginRef(ctx, ref)
+	d, err := Describe(ctx, c, PluginKind, ref)
 	if err != nil {
-		return nil, fmt.Errorf("resolving plugin ref %q: %w", ref, err)
+		return nil, err
 	}
+	return &DescribedPlugin{ArtifactInfo: d.ArtifactInfo, Plugin: d.Artifact}, nil
+}
 
-	fm, err := c.fetchManifest(ctx, resolved)
+// describePluginManifest fetches the plugin config blob for an already
+// fetched manifest and assembles the DescribedPlugin.
+func describePluginManifest(ctx context.Context, fm *fetchedManifest, resolved string) (*DescribedPlugin, error) {
+	plugin, err := pluginFromManifest(ctx, fm, resolved)
 	if err != nil {
 		return nil, err
 	}
+	return &DescribedPlugin{ArtifactInfo: fm.info(resolved), Plugin: plugin}, nil
+}
 
+// pluginFromManifest fetches the plugin config blob for an already fetched
+// manifest and combines it with the manifest annotations.
+func pluginFromManifest(ctx context.Context, fm *fetchedManifest, resolved string) (Plugin, error) {
 	configJSON, err := fetchConfigBlob(ctx, fm.repo, resolved, fm.manifest.Config)
 	if err != nil {
-		return nil, err
+		return Plugin{}, err
 	}
 
 	var blob pluginConfigBlob
 	if err := json.Unmarshal(configJSON, &blob); err != nil {
-		return nil, fmt.Errorf("parsing plugin config for %s: %w", resolved, err)
+		return Plugin{}, fmt.Errorf("parsing plugin config for %s: %w", resolved, err)
 	}
-
-	plugin := pluginFromAnnotations(fm.manifest.Annotations, fm.tag, blob)
-
-	return &DescribedPlugin{
-		ArtifactInfo: ArtifactInfo{Ref: resolved, Tag: fm.tag, Digest: fm.digest},
-		Plugin:       plugin,
-	}, nil
+	return pluginFromAnnotations(fm.manifest.Annotations, fm.tag, blob), nil
 }
 
 // DescribePersonality fetches the config blob for a personality artifact
 // and returns metadata without downloading the content layer. The soul text
 // is NOT available via describe -- use PullPersonality to get it.
 func (c *Client) DescribePersonality(ctx context.Context, ref string) (*DescribedPersonality, error) {
-	resolved, err := c.ResolvePersonalityRef(ctx, ref)
+	d, fm, err := describeTyped(ctx, c, PersonalityKind, ref)
 	if err != nil {
-		return nil, fmt.Errorf("resolving personality ref %q: %w", ref, err)
+		return nil, err
 	}
+	return c.withEvalResults(ctx, fm, &DescribedPersonality{ArtifactInfo: d.ArtifactInfo, Personality: d.Artifact})
+}
 
-	fm, err := c.fetchManifest(ctx, resolved)
+// describePersonalityManifest fetches the personality config blob for an
+// already fetched manifest and assembles the DescribedPersonality, adding
+// evaluation results when enabled by WithEvalResults.
+func (c *Client) describePersonalityManifest(ctx context.Context, fm *fetchedManifest, resolved string) (*DescribedPersonality, error) {
+	personality, err := c.personalityFromManifest(ctx, fm, resolved)
 	if err != nil {
 		return nil, err
 	}
+	return c.withEvalResults(ctx, fm, &DescribedPersonality{ArtifactInfo: fm.info(resolved), Personality: personality})
+}
 
+// personalityFromManifest fetches the personality config blob for an
+// already fetched manifest and combines it with the manifest annotations,
+// applying the client's reference rewrites.
+func (c *Client) personalityFromManifest(ctx context.Context, fm *fetchedManifest, resolved string) (Personality, error) {
+	if c.rewriteErr != nil {
+		return Personality{}, c.rewriteErr
+	}
 	configJSON, err := fetchConfigBlob(ctx, fm.repo, resolved, fm.manifest.Config)
 	if err != nil {
-		return nil, err
+		return Personality{}, err
 	}
 
 	var blob personalityConfigBlob
 	if err := json.Unmarshal(configJSON, &blob); err != nil {
-		return nil, fmt.Errorf("parsing personality config for %s: %w", resolved, err)
+		return Personality{}, fmt.Errorf("parsing personality config for %s: %w", resolved, err)
 	}
+	p := personalityFromAnnotations(fm.manifest.Annotations, fm.tag, blob)
+	return p, c.rewritePersonality(&p)
+}
 
-	personality := personalityFromAnnotations(fm.manifest.Annotations, fm.tag, blob)
-
-	return &DescribedPersonality{
-		ArtifactInfo: ArtifactInfo{Ref: resolved, Tag: fm.tag, Digest: fm.digest},
-		Personality:  personality,
-	}, nil
+// withEvalResults adds the evaluation results of described when enabled
+// by WithEvalResults.
+func (c *Client) withEvalResults(ctx context.Context, fm *fetchedManifest, described *DescribedPersonality) (*DescribedPersonality, error) {
+	if c.evalResults {
+		var err error
+		if described.EvalResults, err = c.fetchEvalResults(ctx, fm, described.Ref); err != nil {
+			return nil, err
+		}
+	}
+	return described, nil
 }
 
 // DescribeToolchain fetches the manifest for a toolchain image and returns
 // metadata derived from OCI manifest annotations. No config blob or layers
 // are downloaded.
 func (c *Client) DescribeToolchain(ctx context.Context, ref string) (*DescribedToolchain, error) {
-	resolved, err := c.ResolveToolchainRef(ctx, ref)
+	d, err := Describe(ctx, c, ToolchainKind, ref)
 	if err != nil {
-		return nil, fmt.Errorf("resolving toolchain ref %q: %w", ref, err)
+		return nil, err
 	}
+	return &DescribedToolchain{ArtifactInfo: d.ArtifactInfo, Toolchain: d.Artifact}, nil
+}
 
-	fm, err := c.fetchManifest(ctx, resolved)
+// describeToolchainManifest assembles a DescribedToolchain from an already
+// fetched manifest.
+func (c *Client) describeToolchainManifest(ctx context.Context, fm *fetchedManifest, resolved string) (*DescribedToolchain, error) {
+	toolchain, err := c.toolchainFromManifest(ctx, fm, resolved)
 	if err != nil {
 		return nil, err
 	}
+	return &DescribedToolchain{ArtifactInfo: fm.info(resolved), Toolchain: toolchain}, nil
+}
 
+// toolchainFromManifest reads a Toolchain from the annotations of an
+// already fetched manifest, falling back to the image config labels when
+// enabled by WithLabelFallback.
+func (c *Client) toolchainFromManifest(ctx context.Context, fm *fetchedManifest, resolved string) (Toolchain, error) {
 	toolchain := toolchainFromAnnotations(fm.manifest.Annotations)
+	if c.labelFallback && !hasKlausAnnotations(fm.manifest.Annotations) {
+		labels, err := imageLabels(ctx, fm, resolved)
+		if err != nil {
+			return Toolchain{}, err
+		}
+		toolchain = toolchainFromLabels(labels)
+	}
 	toolchain.Version = fm.tag
+	return toolchain, nil
+}
 
-	return &DescribedToolchain{
-		ArtifactInfo: ArtifactInfo{Ref: resolved, Tag: fm.tag, Digest: fm.digest},
-		Toolchain:    toolchain,
-	}, nil
+// Describe fetches the manifest for a fully-qualified OCI reference,
+// detects the artifact kind from its config media type, and returns the
+// matching described artifact. References without a tag (or tagged
+// "latest") resolve to the highest semver tag. Short names are rejected
+// because they cannot be expanded without knowing the kind up front.
+func (c *Client) Describe(ctx context.Context, ref string) (*DescribedArtifact, error) {
+	ref = strings.TrimSpace(ref)
+	if !strings.Contains(ref, "/") {
+		return nil, fmt.Errorf("reference %q must be a fully-qualified OCI reference", ref)
+	}
+	resolved, err := resolveArtifactRef(ctx, c, ref, "")
+	if err != nil {
+		return nil, fmt.Errorf("resolving ref %q: %w", ref, err)
+	}
+
+	fm, err := c.fetchDescribeManifest(ctx, resolved)
+	if err != nil {
+		return nil, err
+	}
+	return c.describeManifest(ctx, fm, resolved)
+}
+
+// describeManifest detects the kind of an already fetched manifest and
+// assembles the matching described artifact.
+func (c *Client) describeManifest(ctx context.Context, fm *fetchedManifest, resolved string) (*DescribedArtifact, error) {
+	kind, ok := kindOfManifest(fm.mediaType, fm.manifest.ArtifactType, fm.manifest.Config.MediaType)
+	if !ok {
+		return nil, fmt.Errorf("unrecognized artifact type for %s (config media type %q)", resolved, fm.manifest.Config.MediaType)
+	}
+
+	var err error
+	result := &DescribedArtifact{Kind: kind}
+	switch kind {
+	case KindPlugin:
+		result.Plugin, err = describePluginManifest(ctx, fm, resolved)
+	case KindPersonality:
+		result.Personality, err = c.describePersonalityManifest(ctx, fm, resolved)
+	case KindToolchain:
+		result.Toolchain, err = c.describeToolchainManifest(ctx, fm, resolved)
+	default:
+		var k registeredKind
+		if k, err = lookupKind(kind); err == nil {
+			var custom CustomArtifact
+			if custom, err = customFromManifest(ctx, k, fm, resolved); err == nil {
+				result.Custom = &DescribedCustomArtifact{ArtifactInfo: fm.info(resolved), CustomArtifact: custom}
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 // fetchedManifest holds the intermediate result of fetching an OCI manifest.
 type fetchedManifest struct {
-	repo     *remote.Repository
-	manifest ocispec.Manifest
-	digest   string
-	tag      string
+	repo      *remote.Repository
+	manifest  ocispec.Manifest
+	mediaType string
+	digest    string
+	tag       string
+	// redacted is set when private annotations were removed from
+	// manifest.
+	redacted bool
+	// quarantined is set by fetchDescribeManifest for quarantined
+	// artifacts.
+	quarantined *Quarantine
+	// provenance is set by fetchDescribeManifest for clients verifying
+	// provenance.
+	provenance *ProvenanceStatus
 }
 
 // fetchManifest resolves a fully-qualified OCI reference, fetches its
@@ -129,18 +243,48 @@ func (c *Client) fetchManifest(ctx context.Context, ref string) (*fetchedManifes
 	defer manifestRC.Close()
 
 	var manifest ocispec.Manifest
-	if err := json.NewDecoder(manifestRC).Decode(&manifest); err != nil {
+	if err := json.NewDecoder(io.LimitReader(manifestRC, maxManifestBytes)).Decode(&manifest); err != nil {
 		return nil, fmt.Errorf("parsing manifest for %s: %w", ref, err)
 	}
 
+	mediaType := manifest.MediaType
+	if mediaType == "" {
+		mediaType = manifestDesc.MediaType
+	}
+
 	return &fetchedManifest{
-		repo:     repo,
-		manifest: manifest,
-		digest:   manifestDesc.Digest.String(),
-		tag:      tag,
+		repo:      repo,
+		manifest:  manifest,
+		mediaType: mediaType,
+		digest:    manifestDesc.Digest.String(),
+		tag:       tag,
 	}, nil
 }
 
+// fetchDescribeManifest fetches the manifest of ref like fetchManifest,
+// checks whether it is quarantined, verifies its provenance when enabled
+// by WithProvenanceVerification or WithTrustPolicy, and redacts the
+// annotations of private artifacts unless the client includes private
+// metadata.
+func (c *Client) fetchDescribeManifest(ctx context.Context, ref string) (*fetchedManifest, error) {
+	fm, err := c.fetchManifest(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if fm.quarantined, err = fm.quarantine(ctx); err != nil {
+		return nil, err
+	}
+	if policy := c.provenancePolicyFor(ref); policy != nil {
+		if fm.provenance, err = c.verifyProvenance(ctx, fm, ref, *policy); err != nil {
+			return nil, err
+		}
+	}
+	if !c.includePrivate {
+		fm.manifest.Annotations, fm.redacted = redactAnnotations(fm.manifest.Annotations)
+	}
+	return fm, nil
+}
+
 // fetchConfigBlob fetches a blob from the repository and returns its
 // raw bytes. Used to retrieve the config blob after fetching the manifest.
 func fetchConfigBlob(ctx context.Context, repo *remote.Repository, ref string, desc ocispec.Descriptor) ([]byte, error) {
@@ -150,7 +294,7 @@ func fetchConfigBlob(ctx context.Context, repo *remote.Repository, ref string, d
 	}
 	defer rc.Close()
 
-	data, err := io.ReadAll(rc)
+	data, err := readBlob(rc, desc, maxConfigBytes)
 	if err != nil {
 		return nil, fmt.Errorf("reading config for %s: %w", ref, err)
 	}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sort"
 	"strings"
 	"testing"
 
@@ -31,9 +32,15 @@ type builtArtifact struct {
 }
 
 // newArtifactRegistry creates a test OCI registry that serves manifests,
-// config blobs, and tag listings. The artifacts map is keyed by repository
+// config blobs, tag listings, and the repository catalog. The artifacts map is keyed by repository
 // name (e.g. "giantswarm/klaus-plugins/gs-base").
 func newArtifactRegistry(artifacts map[string]testArtifactEntry) *httptest.Server {
+	return httptest.NewServer(newArtifactHandler(artifacts))
+}
+
+// newArtifactHandler returns the handler behind newArtifactRegistry, for
+// tests that need to know the server address before building artifacts.
+func newArtifactHandler(artifacts map[string]testArtifactEntry) http.Handler {
 	built := make(map[string]*builtArtifact)
 	for name, entry := range artifacts {
 		configDigest := godigest.FromBytes(entry.configJSON)
@@ -63,7 +70,7 @@ func newArtifactRegistry(artifacts map[string]testArtifactEntry) *httptest.Serve
 		}
 	}
 
-	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
 
 		if path == "/v2/" || path == "/v2" {
@@ -78,6 +85,20 @@ func newArtifactRegistry(artifacts map[string]testArtifactEntry) *httptest.Serve
 
 		rest := strings.TrimPrefix(path, "/v2/")
 
+		if rest == "_catalog" {
+			last := r.URL.Query().Get("last")
+			repos := []string{}
+			for name := range built {
+				if name > last {
+					repos = append(repos, name)
+				}
+			}
+			sort.Strings(repos)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string][]string{"repositories": repos})
+			return
+		}
+
 		if strings.HasSuffix(rest, "/tags/list") {
 			repoName := strings.TrimSuffix(rest, "/tags/list")
 			art, ok := built[repoName]
@@ -145,7 +166,7 @@ func newArtifactRegistry(artifacts map[string]testArtifactEntry) *httptest.Serve
 		}
 
 		http.NotFound(w, r)
-	}))
+	})
 }
 
 func TestToolchainFromAnnotations(t *testing.T) {
@@ -681,6 +702,7 @@ func TestDescribePlugin_WithAllComponents(t *testing.T) {
 		HasHooks:   true,
 		MCPServers: []string{"server-x", "server-y"},
 		LSPServers: []string{"lsp-z"},
+		Secrets:    []SecretRequirement{{Name: "SERVER_X_TOKEN", MCPServer: "server-x"}},
 	}
 	configJSON, _ := json.Marshal(blob)
 	annotations := buildKlausAnnotations(commonMetadata{
@@ -737,6 +759,9 @@ func TestDescribePlugin_WithAllComponents(t *testing.T) {
 	if len(p.LSPServers) != 1 || p.LSPServers[0] != "lsp-z" {
 		t.Errorf("LSPServers = %v, want [lsp-z]", p.LSPServers)
 	}
+	if len(p.Secrets) != 1 || p.Secrets[0].Name != "SERVER_X_TOKEN" || p.Secrets[0].MCPServer != "server-x" {
+		t.Errorf("Secrets = %+v, want SERVER_X_TOKEN for server-x", p.Secrets)
+	}
 }
 
 func TestDescribePersonality_VersionFromTag(t *testing.T) {
@@ -953,3 +978,159 @@ func TestToolchainFromAnnotations_SingleKeyword(t *testing.T) {
 		t.Errorf("Keywords = %v, want [single]", tc.Keywords)
 	}
 }
+
+func TestDescribe_DetectsKind(t *testing.T) {
+	pluginJSON, _ := json.Marshal(pluginConfigBlob{Skills: []string{"kubernetes"}})
+	personalityJSON, _ := json.Marshal(personalityConfigBlob{})
+
+	ts := newArtifactRegistry(map[string]testArtifactEntry{
+		"giantswarm/klaus-plugins/gs-base": {
+			configJSON:      pluginJSON,
+			configMediaType: MediaTypePluginConfig,
+			tags:            []string{"v0.9.0", "v1.0.0"},
+			annotations:     map[string]string{AnnotationName: "gs-base"},
+		},
+		"giantswarm/klaus-personalities/sre": {
+			configJSON:      personalityJSON,
+			configMediaType: MediaTypePersonalityConfig,
+			tags:            []string{"v0.2.0"},
+			annotations:     map[string]string{AnnotationName: "sre"},
+		},
+		"giantswarm/klaus-toolchains/go": {
+			configJSON:      []byte(`{"architecture":"amd64"}`),
+			configMediaType: ocispec.MediaTypeImageConfig,
+			tags:            []string{"v1.2.0"},
+			annotations:     map[string]string{AnnotationName: "go"},
+		},
+		"giantswarm/other/thing": {
+			configJSON:      []byte(`{}`),
+			configMediaType: "application/vnd.example.config.v1+json",
+			tags:            []string{"v1.0.0"},
+		},
+	})
+	defer ts.Close()
+	host := testRegistryHost(ts)
+
+	client := NewClient(WithPlainHTTP(true))
+
+	tests := []struct {
+		ref      string
+		wantKind Kind
+		wantName string
+		wantTag  string
+	}{
+		{ref: host + "/giantswarm/klaus-plugins/gs-base", wantKind: KindPlugin, wantName: "gs-base", wantTag: "v1.0.0"},
+		{ref: host + "/giantswarm/klaus-personalities/sre:v0.2.0", wantKind: KindPersonality, wantName: "sre", wantTag: "v0.2.0"},
+		{ref: host + "/giantswarm/klaus-toolchains/go:v1.2.0", wantKind: KindToolchain, wantName: "go", wantTag: "v1.2.0"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.wantKind), func(t *testing.T) {
+			described, err := client.Describe(t.Context(), tt.ref)
+			if err != nil {
+				t.Fatalf("Describe() error = %v", err)
+			}
+			if described.Kind != tt.wantKind {
+				t.Fatalf("Kind = %q, want %q", described.Kind, tt.wantKind)
+			}
+
+			var name, tag string
+			set := 0
+			if described.Plugin != nil {
+				set++
+				name, tag = described.Plugin.Plugin.Name, described.Plugin.ArtifactInfo.Tag
+			}
+			if described.Personality != nil {
+				set++
+				name, tag = described.Personality.Personality.Name, described.Personality.ArtifactInfo.Tag
+			}
+			if described.Toolchain != nil {
+				set++
+				name, tag = described.Toolchain.Toolchain.Name, described.Toolchain.ArtifactInfo.Tag
+			}
+			if set != 1 {
+				t.Fatalf("%d variants set, want exactly 1", set)
+			}
+			if name != tt.wantName || tag != tt.wantTag {
+				t.Errorf("name@tag = %s@%s, want %s@%s", name, tag, tt.wantName, tt.wantTag)
+			}
+		})
+	}
+
+	t.Run("unknown config media type", func(t *testing.T) {
+		_, err := client.Describe(t.Context(), host+"/giantswarm/other/thing:v1.0.0")
+		if err == nil || !strings.Contains(err.Error(), "unrecognized artifact type") {
+			t.Fatalf("error = %v, want unrecognized artifact type", err)
+		}
+	})
+
+	t.Run("short name rejected", func(t *testing.T) {
+		if _, err := client.Describe(t.Context(), "gs-base"); err == nil {
+			t.Fatal("expected error for short name")
+		}
+	})
+}
+
+func TestDescribe_RedactsPrivate(t *testing.T) {
+	pluginJSON, _ := json.Marshal(pluginConfigBlob{})
+	annotations := func(private bool) map[string]string {
+		return Plugin{
+			Name:       "internal",
+			Homepage:   "https://wiki.internal.example.com/klaus",
+			SourceRepo: "https://git.internal.example.com/klaus/internal",
+			Author:     &Author{Name: "Platform", Email: "platform@example.com", URL: "https://internal.example.com"},
+			Private:    private,
+		}.Annotations()
+	}
+	ts := newArtifactRegistry(map[string]testArtifactEntry{
+		"giantswarm/klaus-plugins/internal": {
+			configJSON:      pluginJSON,
+			configMediaType: MediaTypePluginConfig,
+			tags:            []string{"v1.0.0"},
+			annotations:     annotations(true),
+		},
+		"giantswarm/klaus-plugins/public": {
+			configJSON:      pluginJSON,
+			configMediaType: MediaTypePluginConfig,
+			tags:            []string{"v1.0.0"},
+			annotations:     annotations(false),
+		},
+	})
+	defer ts.Close()
+	host := testRegistryHost(ts)
+
+	tests := []struct {
+		name         string
+		repo         string
+		opts         []ClientOption
+		wantRedacted bool
+	}{
+		{name: "private", repo: "internal", wantRedacted: true},
+		{name: "private included", repo: "internal", opts: []ClientOption{WithIncludePrivate()}},
+		{name: "public", repo: "public"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(append([]ClientOption{WithPlainHTTP(true)}, tt.opts...)...)
+			described, err := client.Describe(t.Context(), host+"/giantswarm/klaus-plugins/"+tt.repo+":v1.0.0")
+			if err != nil {
+				t.Fatalf("Describe() error = %v", err)
+			}
+			p := described.Plugin
+			if p.Redacted != tt.wantRedacted {
+				t.Errorf("Redacted = %v, want %v", p.Redacted, tt.wantRedacted)
+			}
+			if p.Name != "internal" || p.Author == nil || p.Author.Name != "Platform" {
+				t.Errorf("public metadata missing: %+v", p.Plugin)
+			}
+			if got := p.Homepage == ""; got != tt.wantRedacted {
+				t.Errorf("Homepage = %q, redacted = %v", p.Homepage, tt.wantRedacted)
+			}
+			if got := p.SourceRepo == "" && p.Author.Email == "" && p.Author.URL == ""; got != tt.wantRedacted {
+				t.Errorf("SourceRepo = %q, Author = %+v, redacted = %v", p.SourceRepo, p.Author, tt.wantRedacted)
+			}
+			if p.Private != (tt.repo == "internal") {
+				t.Errorf("Private = %v", p.Private)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package oci
+
+import (
+	"context"
+)
+
+// DiscoveryProvider enumerates the repositories under a registry base path.
+// Providers replace the OCI catalog API (/v2/_catalog) for registries that
+// do not expose it, such as ghcr.io. Listing operations consult the provider
+// registered for the base's host before falling back to the catalog.
+//
+// Returned repositories must be fully-qualified ("host/path/name") and lie
+// under the requested base.
+type DiscoveryProvider interface {
+	Repositories(ctx context.Context, registryBase string) ([]string, error)
+}
+
+// WithDiscoveryProvider registers p as the repository discovery mechanism
+// for host (e.g. "ghcr.io"). Listing operations against that host use the
+// provider instead of the OCI catalog API. Tag listing and resolution still
+// go through the registry's distribution API.
+func WithDiscoveryProvider(host string, p DiscoveryProvider) ClientOption {
+	return func(c *Client) {
+		if c.discovery == nil {
+			c.discovery = make(map[string]DiscoveryProvider)
+		}
+		c.discovery[host] = p
+	}
+}
+
+// discoveryProvider returns the provider registered for host, or nil when
+// listing should use the registry catalog.
+func (c *Client) discoveryProvider(host string) DiscoveryProvider {
+	return c.discovery[host]
+}
@@ -0,0 +1,144 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// GHCRHost is the registry host of the GitHub Container Registry.
+const GHCRHost = "ghcr.io"
+
+const defaultGitHubAPIURL = "https://api.github.com"
+
+// GHCRProvider discovers container packages on ghcr.io through the GitHub
+// packages REST API. ghcr.io does not implement the OCI catalog API, so
+// without a provider ListPlugins and friends cannot enumerate repositories
+// hosted there.
+//
+// The first path segment of a registry base is the GitHub owner (an
+// organization or a user). The remainder is matched against package names,
+// which for nested repositories contain slashes
+// (e.g. "ghcr.io/acme/klaus-plugins" lists the "klaus-plugins/*" packages
+// of the "acme" organization).
+type GHCRProvider struct {
+	token      string
+	apiURL     string
+	httpClient *http.Client
+}
+
+// NewGHCRProvider creates a GHCR discovery provider. The token is a GitHub
+// token with the read:packages scope; the packages API rejects anonymous
+// requests even for public packages.
+func NewGHCRProvider(token string) *GHCRProvider {
+	return &GHCRProvider{
+		token:      token,
+		apiURL:     defaultGitHubAPIURL,
+		httpClient: http.DefaultClient,
+	}
+}
+
+// WithGHCRToken registers a GHCRProvider for ghcr.io using the given GitHub
+// token. Registry credentials for pulling from ghcr.io are still resolved
+// from the Docker/Podman config as usual.
+func WithGHCRToken(token string) ClientOption {
+	return WithDiscoveryProvider(GHCRHost, NewGHCRProvider(token))
+}
+
+// ghcrPackage is the subset of the GitHub package object we need.
+type ghcrPackage struct {
+	Name string `json:"name"`
+}
+
+// Repositories lists the container packages of the base's owner whose names
+// start with the base's remaining path. Organization packages are tried
+// first; a 404 falls back to the user packages endpoint.
+func (p *GHCRProvider) Repositories(ctx context.Context, registryBase string) ([]string, error) {
+	host, prefix := SplitRegistryBase(registryBase)
+	owner, namePrefix, _ := strings.Cut(prefix, "/")
+	if owner == "" {
+		return nil, fmt.Errorf("registry base %q must include a GitHub owner", registryBase)
+	}
+
+	names, err := p.listPackages(ctx, "orgs", owner)
+	if err != nil && isHTTPNotFound(err) {
+		names, err = p.listPackages(ctx, "users", owner)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing GitHub packages for %s: %w", owner, err)
+	}
+
+	var repos []string
+	for _, name := range names {
+		if strings.HasPrefix(name, namePrefix) {
+			repos = append(repos, host+"/"+owner+"/"+name)
+		}
+	}
+	slices.Sort(repos)
+	return repos, nil
+}
+
+// listPackages paginates /{orgs|users}/{owner}/packages for container
+// packages and returns their names.
+func (p *GHCRProvider) listPackages(ctx context.Context, scope, owner string) ([]string, error) {
+	next := fmt.Sprintf("%s/%s/%s/packages?package_type=container&per_page=100",
+		strings.TrimSuffix(p.apiURL, "/"), scope, url.PathEscape(owner))
+
+	var names []string
+	for next != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, next, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		if p.token != "" {
+			req.Header.Set("Authorization", "Bearer "+p.token)
+		}
+
+		resp, err := p.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("GET %s: %w", next, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1024))
+			resp.Body.Close()
+			return nil, &httpStatusError{URL: next, StatusCode: resp.StatusCode, Status: resp.Status}
+		}
+
+		var page []ghcrPackage
+		derr := json.NewDecoder(io.LimitReader(resp.Body, 32*1024*1024)).Decode(&page)
+		resp.Body.Close()
+		if derr != nil {
+			return nil, fmt.Errorf("parsing packages from %s: %w", next, derr)
+		}
+		for _, pkg := range page {
+			names = append(names, pkg.Name)
+		}
+		next = parseNextLink(resp.Header.Get("Link"), next)
+	}
+	return names, nil
+}
+
+// httpStatusError reports an unexpected HTTP status from a non-registry
+// API (e.g. GitHub). The response body is intentionally not included.
+type httpStatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("GET %s: unexpected status %s", e.URL, e.Status)
+}
+
+func isHTTPNotFound(err error) bool {
+	var se *httpStatusError
+	return errors.As(err, &se) && se.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,189 @@
+package oci
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newGitHubPackagesAPI serves the GitHub packages list endpoints for a
+// single owner. When isOrg is false the org endpoint returns 404 so the
+// provider must fall back to the user endpoint. Packages are served two
+// per page to exercise Link-header pagination.
+func newGitHubPackagesAPI(t *testing.T, owner string, isOrg bool, packages []string, wantToken string) *httptest.Server {
+	t.Helper()
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer "+wantToken {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("package_type") != "container" {
+			http.Error(w, "bad package_type", http.StatusBadRequest)
+			return
+		}
+
+		scope := "users"
+		if isOrg {
+			scope = "orgs"
+		}
+		if r.URL.Path != fmt.Sprintf("/%s/%s/packages", scope, owner) {
+			http.NotFound(w, r)
+			return
+		}
+
+		page := 0
+		fmt.Sscanf(r.URL.Query().Get("page"), "%d", &page)
+		start := page * 2
+		end := min(start+2, len(packages))
+		var items []map[string]string
+		for _, name := range packages[start:end] {
+			items = append(items, map[string]string{"name": name})
+		}
+		if end < len(packages) {
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?package_type=container&per_page=100&page=%d>; rel="next"`, ts.URL, r.URL.Path, page+1))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(items)
+	}))
+	return ts
+}
+
+func TestGHCRProvider_Repositories(t *testing.T) {
+	packages := []string{
+		"klaus-plugins/gs-base",
+		"klaus-personalities/sre",
+		"klaus-plugins/gs-platform",
+		"unrelated",
+		"klaus-plugins-extra/foo",
+	}
+
+	tests := []struct {
+		name  string
+		isOrg bool
+		base  string
+		want  []string
+	}{
+		{
+			name:  "organization packages",
+			isOrg: true,
+			base:  "ghcr.io/acme/klaus-plugins",
+			want: []string{
+				"ghcr.io/acme/klaus-plugins/gs-base",
+				"ghcr.io/acme/klaus-plugins/gs-platform",
+			},
+		},
+		{
+			name:  "falls back to user packages",
+			isOrg: false,
+			base:  "ghcr.io/acme/klaus-personalities",
+			want:  []string{"ghcr.io/acme/klaus-personalities/sre"},
+		},
+		{
+			name:  "owner only lists everything",
+			isOrg: true,
+			base:  "ghcr.io/acme",
+			want: []string{
+				"ghcr.io/acme/klaus-personalities/sre",
+				"ghcr.io/acme/klaus-plugins-extra/foo",
+				"ghcr.io/acme/klaus-plugins/gs-base",
+				"ghcr.io/acme/klaus-plugins/gs-platform",
+				"ghcr.io/acme/unrelated",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newGitHubPackagesAPI(t, "acme", tt.isOrg, packages, "s3cret")
+			defer api.Close()
+
+			p := NewGHCRProvider("s3cret")
+			p.apiURL = api.URL
+
+			got, err := p.Repositories(t.Context(), tt.base)
+			if err != nil {
+				t.Fatalf("Repositories() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Repositories() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGHCRProvider_MissingOwner(t *testing.T) {
+	p := NewGHCRProvider("token")
+	if _, err := p.Repositories(t.Context(), "ghcr.io"); err == nil {
+		t.Fatal("expected error for base without owner")
+	}
+}
+
+func TestGHCRProvider_Unauthorized(t *testing.T) {
+	api := newGitHubPackagesAPI(t, "acme", true, []string{"klaus-plugins/gs-base"}, "s3cret")
+	defer api.Close()
+
+	p := NewGHCRProvider("wrong")
+	p.apiURL = api.URL
+
+	_, err := p.Repositories(t.Context(), "ghcr.io/acme/klaus-plugins")
+	if err == nil {
+		t.Fatal("expected error for rejected token")
+	}
+	if !strings.Contains(err.Error(), "401") {
+		t.Errorf("error = %v, want status 401", err)
+	}
+}
+
+func TestListPlugins_WithDiscoveryProvider(t *testing.T) {
+	ts := newTestRegistry(map[string][]string{
+		"acme/klaus-plugins/gs-base":     {"v0.1.0", "v0.2.0"},
+		"acme/klaus-plugins/gs-platform": {"v1.0.0"},
+	})
+	defer ts.Close()
+	host := testRegistryHost(ts)
+
+	api := newGitHubPackagesAPI(t, "acme", true, []string{
+		"klaus-plugins/gs-base",
+		"klaus-plugins/gs-platform",
+		"klaus-personalities/sre",
+	}, "s3cret")
+	defer api.Close()
+
+	p := NewGHCRProvider("s3cret")
+	p.apiURL = api.URL
+
+	client := NewClient(WithPlainHTTP(true), WithDiscoveryProvider(host, p))
+
+	entries, err := client.ListPlugins(t.Context(), WithRegistry(host+"/acme/klaus-plugins"))
+	if err != nil {
+		t.Fatalf("ListPlugins() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(entries), entries)
+	}
+	if entries[0].Name != "gs-base" || entries[0].Version != "v0.2.0" {
+		t.Errorf("entries[0] = %+v, want gs-base v0.2.0", entries[0])
+	}
+	if entries[1].Name != "gs-platform" || entries[1].Version != "v1.0.0" {
+		t.Errorf("entries[1] = %+v, want gs-platform v1.0.0", entries[1])
+	}
+}
+
+func TestWithGHCRToken(t *testing.T) {
+	client := NewClient(WithGHCRToken("token"))
+	p, ok := client.discoveryProvider(GHCRHost).(*GHCRProvider)
+	if !ok {
+		t.Fatalf("provider for %s = %T, want *GHCRProvider", GHCRHost, client.discoveryProvider(GHCRHost))
+	}
+	if p.token != "token" {
+		t.Errorf("token = %q, want %q", p.token, "token")
+	}
+	if client.discoveryProvider("gsoci.azurecr.io") != nil {
+		t.Error("unexpected provider for gsoci.azurecr.io")
+	}
+}
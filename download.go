@@ -0,0 +1,199 @@
+package oci
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// maxResumeAttempts is the number of times an interrupted blob download is
+// resumed within a single pull before the pull fails. The partial file is
+// kept, so a later pull resumes where this one stopped.
+const maxResumeAttempts = 3
+
+// partialPath returns the file that collects the download of desc for a
+// pull into destDir. It lives next to destDir, because destDir itself is
+// replaced on extraction, and is keyed by digest so a changed artifact
+// never resumes from stale bytes.
+func partialPath(destDir string, desc ocispec.Descriptor) (string, error) {
+	abs, err := filepath.Abs(destDir)
+	if err != nil {
+		return "", fmt.Errorf("resolving destination %s: %w", destDir, err)
+	}
+	name := fmt.Sprintf(".%s.%s-%s.partial", filepath.Base(abs), desc.Digest.Algorithm(), desc.Digest.Encoded())
+	return filepath.Join(filepath.Dir(abs), name), nil
+}
+
+// fetchContentLayer returns a reader for the content layer of a pull into
+// destDir. Without a cache store the layer is downloaded resumably into a
+// partial file next to destDir, which is removed when the reader is
+// closed. With a cache store configured the store serves and verifies the
+// layer instead.
+func (c *Client) fetchContentLayer(ctx context.Context, repo *remote.Repository, repoName string, desc ocispec.Descriptor, destDir string) (io.ReadCloser, error) {
+	store, err := c.cacheStore()
+	if desc.Size <= 0 || (err == nil && store != nil) {
+		return c.fetchBlob(ctx, repo, repoName, desc)
+	}
+	path, err := partialPath(destDir, desc)
+	if err != nil {
+		return nil, err
+	}
+	f, err := c.downloadBlob(ctx, repo, desc, path)
+	if err != nil {
+		return nil, err
+	}
+	return &partialFile{File: f}, nil
+}
+
+// partialFile removes a completed download when it is closed.
+type partialFile struct {
+	*os.File
+}
+
+func (f *partialFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+// downloadBlob downloads desc into the partial file at path and returns it
+// opened at offset zero once its digest has been verified. An existing
+// partial file is resumed with an HTTP Range request, and a download that
+// is interrupted mid-stream is resumed up to maxResumeAttempts times.
+// Registries that do not honor Range requests restart from zero. A digest
+// mismatch removes the partial file.
+func (c *Client) downloadBlob(ctx context.Context, repo *remote.Repository, desc ocispec.Descriptor, path string) (*os.File, error) {
+	if err := desc.Digest.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid digest for %s: %w", desc.MediaType, err)
+	}
+	release, err := c.acquireBlobSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating download directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening partial download: %w", err)
+	}
+
+	if err := c.resumeDownload(ctx, repo, desc, f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := verifyFile(f, desc); err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	return f, nil
+}
+
+// resumeDownload appends the missing bytes of desc to f.
+func (c *Client) resumeDownload(ctx context.Context, repo *remote.Repository, desc ocispec.Descriptor, f *os.File) error {
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if offset > desc.Size {
+		offset = 0
+	}
+
+	for attempt := 0; offset < desc.Size; attempt++ {
+		rc, start, err := fetchBlobRange(ctx, repo, desc, offset)
+		if err != nil {
+			return err
+		}
+		if start != offset {
+			offset = start
+		}
+		if err := f.Truncate(offset); err != nil {
+			rc.Close()
+			return err
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			rc.Close()
+			return err
+		}
+
+		n, err := io.Copy(f, io.LimitReader(rc, desc.Size-offset))
+		rc.Close()
+		offset += n
+		if err == nil && offset < desc.Size {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			if ctx.Err() != nil || attempt+1 >= maxResumeAttempts {
+				return fmt.Errorf("downloading %s (%d of %d bytes): %w", desc.Digest, offset, desc.Size, err)
+			}
+		}
+	}
+	return nil
+}
+
+// fetchBlobRange requests desc from offset onwards and returns the body
+// together with the offset it actually starts at, which is zero when the
+// registry ignored the Range header.
+func fetchBlobRange(ctx context.Context, repo *remote.Repository, desc ocispec.Descriptor, offset int64) (io.ReadCloser, int64, error) {
+	scheme := "https"
+	if repo.PlainHTTP {
+		scheme = "http"
+	}
+	url := fmt.Sprintf("%s://%s/v2/%s/blobs/%s", scheme, repo.Reference.Host(), repo.Reference.Repository, desc.Digest)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, desc.Size-1))
+	}
+
+	resp, err := repo.Client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("fetching %s: %w", desc.Digest, err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, 0, nil
+	case http.StatusPartialContent:
+		var start, end, size int64
+		if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &size); err != nil || start != offset {
+			resp.Body.Close()
+			return nil, 0, fmt.Errorf("fetching %s: invalid Content-Range %q for offset %d", desc.Digest, resp.Header.Get("Content-Range"), offset)
+		}
+		return resp.Body, offset, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		resp.Body.Close()
+		return fetchBlobRange(ctx, repo, desc, 0)
+	default:
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("fetching %s: unexpected status %s", desc.Digest, resp.Status)
+	}
+}
+
+// verifyFile checks that f holds exactly the content described by desc
+// and rewinds it.
+func verifyFile(f *os.File, desc ocispec.Descriptor) error {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	verifier := desc.Digest.Verifier()
+	n, err := io.Copy(verifier, f)
+	if err != nil {
+		return fmt.Errorf("verifying %s: %w", desc.Digest, err)
+	}
+	if n != desc.Size || !verifier.Verified() {
+		return fmt.Errorf("verifying %s: downloaded content does not match digest", desc.Digest)
+	}
+	_, err = f.Seek(0, io.SeekStart)
+	return err
+}
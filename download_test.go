@@ -0,0 +1,214 @@
+package oci
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"oras.land/oras-go/v2/content"
+)
+
+// blobServer serves a single blob for any blob GET. Range requests are
+// honored when ranges is set. It records the Range header of each request.
+type blobServer struct {
+	data   []byte
+	ranges bool
+
+	mu     sync.Mutex
+	ranged []string
+}
+
+func (s *blobServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.ranged = append(s.ranged, r.Header.Get("Range"))
+	s.mu.Unlock()
+	if !s.ranges {
+		r.Header.Del("Range")
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(s.data))
+}
+
+func (s *blobServer) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.ranged...)
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDownloadBlob_ResumesPartialFile(t *testing.T) {
+	data := randomBytes(t, 4096)
+	srv := &blobServer{data: data, ranges: true}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	client := NewClient(WithPlainHTTP(true))
+	repo, _, err := client.newRepository(testRegistryHost(ts) + "/r:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := content.NewDescriptorFromBytes("application/octet-stream", data)
+
+	path := filepath.Join(t.TempDir(), "blob.partial")
+	writeFile(t, path, string(data[:1000]))
+
+	f, err := client.downloadBlob(t.Context(), repo, desc, path)
+	if err != nil {
+		t.Fatalf("downloadBlob() error = %v", err)
+	}
+	defer f.Close()
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("downloaded content differs")
+	}
+	if reqs := srv.requests(); len(reqs) != 1 || reqs[0] != "bytes=1000-4095" {
+		t.Errorf("requests = %q, want one range request from offset 1000", reqs)
+	}
+}
+
+func TestDownloadBlob_RangeIgnored(t *testing.T) {
+	data := randomBytes(t, 4096)
+	srv := &blobServer{data: data}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	client := NewClient(WithPlainHTTP(true))
+	repo, _, _ := client.newRepository(testRegistryHost(ts) + "/r:v1")
+	desc := content.NewDescriptorFromBytes("application/octet-stream", data)
+
+	path := filepath.Join(t.TempDir(), "blob.partial")
+	writeFile(t, path, string(data[:1000]))
+
+	f, err := client.downloadBlob(t.Context(), repo, desc, path)
+	if err != nil {
+		t.Fatalf("downloadBlob() error = %v", err)
+	}
+	defer f.Close()
+
+	got, _ := os.ReadFile(path)
+	if !bytes.Equal(got, data) {
+		t.Error("downloaded content differs after restart from zero")
+	}
+}
+
+func TestDownloadBlob_DigestMismatch(t *testing.T) {
+	data := randomBytes(t, 4096)
+	srv := &blobServer{data: data, ranges: true}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	client := NewClient(WithPlainHTTP(true))
+	repo, _, _ := client.newRepository(testRegistryHost(ts) + "/r:v1")
+	desc := content.NewDescriptorFromBytes("application/octet-stream", data)
+
+	// A corrupted partial file must not produce a verified blob.
+	path := filepath.Join(t.TempDir(), "blob.partial")
+	writeFile(t, path, strings.Repeat("x", 1000))
+
+	if _, err := client.downloadBlob(t.Context(), repo, desc, path); err == nil || !strings.Contains(err.Error(), "does not match digest") {
+		t.Fatalf("downloadBlob() error = %v, want digest mismatch", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("partial file kept after digest mismatch")
+	}
+}
+
+func TestPull_ResumesInterruptedLayer(t *testing.T) {
+	reg := newCacheRegistry()
+	blob, _ := json.Marshal(pluginConfigBlob{})
+	addPullableArtifact(t, reg, "klaus-plugins/p", "v1.0.0", pluginArtifact, blob, map[string]string{
+		"data.bin": string(randomBytes(t, 64*1024)),
+	})
+
+	var (
+		mu          sync.Mutex
+		interrupted bool
+		ranges      []string
+	)
+	inner := reg.handler()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if r.Method != http.MethodGet || !strings.Contains(path, "/blobs/") {
+			inner.ServeHTTP(w, r)
+			return
+		}
+		reg.mu.Lock()
+		data := reg.blobs[path[strings.LastIndex(path, "/")+1:]]
+		reg.mu.Unlock()
+
+		mu.Lock()
+		first := !interrupted && len(data) > 1024
+		if first {
+			interrupted = true
+		}
+		ranges = append(ranges, r.Header.Get("Range"))
+		mu.Unlock()
+
+		if first {
+			// Announce the full length but stop halfway through.
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.Write(data[:len(data)/2])
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}))
+	defer ts.Close()
+
+	destDir := filepath.Join(t.TempDir(), "p")
+	client := NewClient(WithPlainHTTP(true))
+	pulled, err := client.PullPlugin(t.Context(), testRegistryHost(ts)+"/klaus-plugins/p:v1.0.0", destDir)
+	if err != nil {
+		t.Fatalf("PullPlugin() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(pulled.Dir, "data.bin")); err != nil {
+		t.Errorf("data.bin not extracted: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var resumed bool
+	for _, r := range ranges {
+		resumed = resumed || strings.HasPrefix(r, "bytes=")
+	}
+	if !interrupted || !resumed {
+		t.Errorf("interrupted = %v, range requests = %q; want a resumed download", interrupted, ranges)
+	}
+
+	partials, _ := filepath.Glob(filepath.Join(filepath.Dir(destDir), ".p.*.partial"))
+	if len(partials) != 0 {
+		t.Errorf("partial files left behind: %v", partials)
+	}
+}
+
+func TestPartialPath(t *testing.T) {
+	desc := content.NewDescriptorFromBytes("application/octet-stream", []byte("layer"))
+	parent := t.TempDir()
+	got, err := partialPath(filepath.Join(parent, "gs-base"), desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(parent, ".gs-base.sha256-"+desc.Digest.Encoded()+".partial")
+	if got != want {
+		t.Errorf("partialPath() = %q, want %q", got, want)
+	}
+}
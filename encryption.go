@@ -0,0 +1,434 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	godigest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Content layers are encrypted in the layer format of ocicrypt (the
+// library behind containerd's imgcrypt and skopeo's --encryption-key), so
+// encrypted Klaus artifacts can be inspected and decrypted with the same
+// key providers:
+//
+//   - the media type of the layer gets the "+encrypted" suffix;
+//   - the layer is encrypted with AES-256-CTR under a random key and
+//     authenticated with HMAC-SHA256 over the ciphertext;
+//   - the public cipher options (cipher and HMAC) are stored base64
+//     encoded in the AnnotationEncPubOpts layer annotation;
+//   - the private options (key, nonce, and plaintext digest) are wrapped
+//     by every configured KeyWrapper and stored under the wrapper's
+//     annotation, e.g. "org.opencontainers.image.enc.keys.provider.age".
+const (
+	// MediaTypeEncryptedSuffix is appended to the media type of encrypted
+	// content layers.
+	MediaTypeEncryptedSuffix = "+encrypted"
+
+	// AnnotationEncPubOpts holds the public cipher options of an
+	// encrypted layer.
+	AnnotationEncPubOpts = "org.opencontainers.image.enc.pubopts"
+
+	// AnnotationEncKeysPrefix prefixes the layer annotations that hold
+	// wrapped layer keys, one per key wrapping protocol.
+	AnnotationEncKeysPrefix = "org.opencontainers.image.enc.keys."
+
+	cipherAES256CTR = "AES_256_CTR_HMAC_SHA256"
+	aesKeySize      = 32
+)
+
+// KeyWrapper wraps and unwraps the private options of encrypted content
+// layers. Implementations delegate to a key management system, e.g. an
+// age identity or a cloud KMS key. KeyProviderCommand implements it for
+// external ocicrypt key provider binaries.
+type KeyWrapper interface {
+	// AnnotationID returns the layer annotation that stores keys wrapped
+	// by this wrapper. It must start with AnnotationEncKeysPrefix.
+	AnnotationID() string
+	// WrapKey encrypts the private layer options for the recipients of
+	// the wrapper.
+	WrapKey(ctx context.Context, optsData []byte) ([]byte, error)
+	// UnwrapKey decrypts wrapped private layer options. It returns an
+	// error when the wrapper holds no key able to decrypt them.
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// WithKeyWrappers configures the keys for content layer encryption. Pushes
+// with WithEncryption wrap the layer key with every wrapper, so any one of
+// them can decrypt the layer. Pulls of encrypted layers try the wrappers
+// whose annotation is present on the layer in order.
+func WithKeyWrappers(wrappers ...KeyWrapper) ClientOption {
+	return func(c *Client) { c.keyWrappers = append(c.keyWrappers, wrappers...) }
+}
+
+// WithEncryption encrypts the content layer for the key wrappers of the
+// client (see WithKeyWrappers). The config blob and manifest annotations
+// stay readable, so listing and describing encrypted artifacts works
+// without keys. Every encrypted push uses a fresh key and therefore
+// produces a new manifest digest, even for identical content.
+func WithEncryption() PushOption {
+	return func(cfg *pushConfig) { cfg.encrypt = true }
+}
+
+// publicLayerOptions are the ocicrypt cipher options stored in the clear.
+type publicLayerOptions struct {
+	CipherType    string            `json:"cipher"`
+	Hmac          []byte            `json:"hmac"`
+	CipherOptions map[string][]byte `json:"cipheroptions"`
+}
+
+// privateLayerOptions are the ocicrypt cipher options that are wrapped by
+// the key wrappers.
+type privateLayerOptions struct {
+	SymmetricKey  []byte            `json:"symkey"`
+	Digest        godigest.Digest   `json:"digest"`
+	CipherOptions map[string][]byte `json:"cipheroptions"`
+}
+
+// isEncryptedMediaType reports whether mediaType is an encrypted layer
+// media type.
+func isEncryptedMediaType(mediaType string) bool {
+	return strings.HasSuffix(mediaType, MediaTypeEncryptedSuffix)
+}
+
+// encryptArtifact returns a copy of built with its content layer encrypted
+// for wrappers.
+func encryptArtifact(ctx context.Context, built *BuiltArtifact, wrappers []KeyWrapper) (*BuiltArtifact, error) {
+	if len(wrappers) == 0 {
+		return nil, errors.New("encryption requested but no key wrappers configured (see WithKeyWrappers)")
+	}
+	plain := built.Layer
+	if built.layerPath != "" {
+		var err error
+		if plain, err = os.ReadFile(built.layerPath); err != nil {
+			return nil, fmt.Errorf("reading content layer: %w", err)
+		}
+	}
+	desc, layer, err := encryptLayer(ctx, built.Manifest.Layers[0], plain, wrappers)
+	if err != nil {
+		return nil, fmt.Errorf("encrypting content layer: %w", err)
+	}
+
+	manifest := built.Manifest
+	manifest.Layers = []ocispec.Descriptor{desc}
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling manifest: %w", err)
+	}
+	return &BuiltArtifact{
+		Manifest:     manifest,
+		ManifestJSON: manifestJSON,
+		Digest:       godigest.FromBytes(manifestJSON).String(),
+		Config:       built.Config,
+		Layer:        layer,
+		temps:        built.temps,
+	}, nil
+}
+
+// encryptLayer encrypts layer, described by desc, under a random key and
+// returns the descriptor and data of the encrypted layer.
+func encryptLayer(ctx context.Context, desc ocispec.Descriptor, layer []byte, wrappers []KeyWrapper) (ocispec.Descriptor, []byte, error) {
+	key := make([]byte, aesKeySize)
+	nonce := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(key); err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	ciphertext := make([]byte, len(layer))
+	cipher.NewCTR(block, nonce).XORKeyStream(ciphertext, layer)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(ciphertext)
+
+	optsData, err := json.Marshal(privateLayerOptions{
+		SymmetricKey:  key,
+		Digest:        desc.Digest,
+		CipherOptions: map[string][]byte{"nonce": nonce},
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	pubData, err := json.Marshal(publicLayerOptions{
+		CipherType:    cipherAES256CTR,
+		Hmac:          mac.Sum(nil),
+		CipherOptions: map[string][]byte{},
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+
+	annotations := map[string]string{
+		AnnotationEncPubOpts: base64.StdEncoding.EncodeToString(pubData),
+	}
+	for _, w := range wrappers {
+		id := w.AnnotationID()
+		if !strings.HasPrefix(id, AnnotationEncKeysPrefix) {
+			return ocispec.Descriptor{}, nil, fmt.Errorf("key wrapper annotation %q must start with %q", id, AnnotationEncKeysPrefix)
+		}
+		wrapped, err := w.WrapKey(ctx, optsData)
+		if err != nil {
+			return ocispec.Descriptor{}, nil, fmt.Errorf("wrapping key for %s: %w", id, err)
+		}
+		// Several wrappers of the same protocol share one annotation with
+		// comma-separated keys.
+		value := base64.StdEncoding.EncodeToString(wrapped)
+		if prev, ok := annotations[id]; ok {
+			value = prev + "," + value
+		}
+		annotations[id] = value
+	}
+
+	out := blobDescriptor(desc.MediaType+MediaTypeEncryptedSuffix, ciphertext)
+	out.Annotations = annotations
+	return out, ciphertext, nil
+}
+
+// decryptLayer returns a reader of the plaintext of the encrypted layer r,
+// described by desc. The HMAC and plaintext digest are verified when r is
+// read to the end; a mismatch fails the final read.
+func (c *Client) decryptLayer(ctx context.Context, ref string, r io.Reader, desc ocispec.Descriptor) (io.Reader, error) {
+	pubData, err := base64.StdEncoding.DecodeString(desc.Annotations[AnnotationEncPubOpts])
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", AnnotationEncPubOpts, err)
+	}
+	var pub publicLayerOptions
+	if err := json.Unmarshal(pubData, &pub); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", AnnotationEncPubOpts, err)
+	}
+	if pub.CipherType != cipherAES256CTR {
+		return nil, fmt.Errorf("unsupported layer cipher %q", pub.CipherType)
+	}
+
+	priv, err := c.unwrapLayerKey(ctx, ref, desc)
+	if err != nil {
+		return nil, err
+	}
+	nonce := priv.CipherOptions["nonce"]
+	if len(priv.SymmetricKey) != aesKeySize || len(nonce) != aes.BlockSize {
+		return nil, errors.New("invalid private layer options")
+	}
+	block, err := aes.NewCipher(priv.SymmetricKey)
+	if err != nil {
+		return nil, err
+	}
+
+	dr := &decryptReader{
+		r:       r,
+		stream:  cipher.NewCTR(block, nonce),
+		mac:     hmac.New(sha256.New, priv.SymmetricKey),
+		wantMAC: pub.Hmac,
+	}
+	if priv.Digest != "" {
+		if err := priv.Digest.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid plaintext digest: %w", err)
+		}
+		dr.digest = priv.Digest
+		dr.verifier = priv.Digest.Verifier()
+	}
+	return dr, nil
+}
+
+// unwrapLayerKey unwraps the private options of desc with the first
+// configured key wrapper that succeeds.
+func (c *Client) unwrapLayerKey(ctx context.Context, ref string, desc ocispec.Descriptor) (*privateLayerOptions, error) {
+	var errs []error
+	for _, w := range c.keyWrappers {
+		value, ok := desc.Annotations[w.AnnotationID()]
+		if !ok {
+			continue
+		}
+		for part := range strings.SplitSeq(value, ",") {
+			wrapped, err := base64.StdEncoding.DecodeString(part)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("decoding %s: %w", w.AnnotationID(), err))
+				continue
+			}
+			optsData, err := w.UnwrapKey(ctx, wrapped)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", w.AnnotationID(), err))
+				continue
+			}
+			var priv privateLayerOptions
+			if err := json.Unmarshal(optsData, &priv); err != nil {
+				return nil, fmt.Errorf("parsing private layer options: %w", err)
+			}
+			return &priv, nil
+		}
+	}
+
+	var protocols []string
+	for k := range desc.Annotations {
+		if id, ok := strings.CutPrefix(k, AnnotationEncKeysPrefix); ok {
+			protocols = append(protocols, id)
+		}
+	}
+	slices.Sort(protocols)
+	return nil, &ErrNoDecryptionKey{Ref: ref, Protocols: protocols, Err: errors.Join(errs...)}
+}
+
+// decryptReader decrypts an AES-CTR encrypted layer while computing its
+// HMAC and plaintext digest.
+type decryptReader struct {
+	r        io.Reader
+	stream   cipher.Stream
+	mac      hash.Hash
+	wantMAC  []byte
+	digest   godigest.Digest
+	verifier godigest.Verifier
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	if n > 0 {
+		d.mac.Write(p[:n])
+		d.stream.XORKeyStream(p[:n], p[:n])
+		if d.verifier != nil {
+			d.verifier.Write(p[:n])
+		}
+	}
+	if err == io.EOF {
+		if !hmac.Equal(d.mac.Sum(nil), d.wantMAC) {
+			return n, errors.New("encrypted layer HMAC mismatch")
+		}
+		if d.verifier != nil && !d.verifier.Verified() {
+			return n, fmt.Errorf("decrypted layer does not match digest %s", d.digest)
+		}
+	}
+	return n, err
+}
+
+// KeyProviderCommand wraps layer keys with an external ocicrypt key
+// provider binary, such as an age or cloud KMS provider. The binary is
+// run once per operation and speaks the ocicrypt keyprovider protocol:
+// a JSON request on stdin and a JSON response on stdout.
+type KeyProviderCommand struct {
+	// Name is the provider name. Wrapped keys are stored under the
+	// "org.opencontainers.image.enc.keys.provider.<Name>" annotation.
+	Name string
+	// Path is the provider binary.
+	Path string
+	// Args are passed to the binary.
+	Args []string
+	// Parameters are passed to the provider as encryption and decryption
+	// parameters, e.g. recipients or key identifiers.
+	Parameters map[string][][]byte
+}
+
+// keyProviderConfig mirrors ocicrypt's EncryptConfig and DecryptConfig.
+type keyProviderConfig struct {
+	Parameters    map[string][][]byte `json:"Parameters"`
+	DecryptConfig *keyProviderConfig  `json:"DecryptConfig,omitempty"`
+}
+
+type keyProviderInput struct {
+	Op              string                   `json:"op"`
+	KeyWrapParams   *keyProviderWrapParams   `json:"keywrapparams,omitempty"`
+	KeyUnwrapParams *keyProviderUnwrapParams `json:"keyunwrapparams,omitempty"`
+}
+
+type keyProviderWrapParams struct {
+	EC       keyProviderConfig `json:"ec"`
+	OptsData []byte            `json:"optsdata"`
+}
+
+type keyProviderUnwrapParams struct {
+	DC         keyProviderConfig `json:"dc"`
+	Annotation []byte            `json:"annotation"`
+}
+
+type keyProviderOutput struct {
+	KeyWrapResults struct {
+		Annotation []byte `json:"annotation"`
+	} `json:"keywrapresults"`
+	KeyUnwrapResults struct {
+		OptsData []byte `json:"optsdata"`
+	} `json:"keyunwrapresults"`
+}
+
+// AnnotationID implements KeyWrapper.
+func (k *KeyProviderCommand) AnnotationID() string {
+	return AnnotationEncKeysPrefix + "provider." + k.Name
+}
+
+// WrapKey implements KeyWrapper.
+func (k *KeyProviderCommand) WrapKey(ctx context.Context, optsData []byte) ([]byte, error) {
+	dc := keyProviderConfig{Parameters: k.Parameters}
+	out, err := k.run(ctx, keyProviderInput{
+		Op: "keywrap",
+		KeyWrapParams: &keyProviderWrapParams{
+			EC:       keyProviderConfig{Parameters: k.Parameters, DecryptConfig: &dc},
+			OptsData: optsData,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.KeyWrapResults.Annotation) == 0 {
+		return nil, fmt.Errorf("key provider %s returned no wrapped key", k.Name)
+	}
+	return out.KeyWrapResults.Annotation, nil
+}
+
+// UnwrapKey implements KeyWrapper.
+func (k *KeyProviderCommand) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	out, err := k.run(ctx, keyProviderInput{
+		Op: "keyunwrap",
+		KeyUnwrapParams: &keyProviderUnwrapParams{
+			DC:         keyProviderConfig{Parameters: k.Parameters},
+			Annotation: wrapped,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.KeyUnwrapResults.OptsData) == 0 {
+		return nil, fmt.Errorf("key provider %s returned no key", k.Name)
+	}
+	return out.KeyUnwrapResults.OptsData, nil
+}
+
+// run executes the provider binary with in as its request.
+func (k *KeyProviderCommand) run(ctx context.Context, in keyProviderInput) (*keyProviderOutput, error) {
+	req, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, k.Path, k.Args...)
+	cmd.Stdin = bytes.NewReader(req)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("key provider %s: %w: %s", k.Name, err, msg)
+		}
+		return nil, fmt.Errorf("key provider %s: %w", k.Name, err)
+	}
+	var out keyProviderOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("parsing key provider %s response: %w", k.Name, err)
+	}
+	return &out, nil
+}
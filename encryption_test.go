@@ -0,0 +1,260 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// gcmKeyWrapper wraps keys with AES-GCM under a fixed key encryption key,
+// standing in for a KMS.
+type gcmKeyWrapper struct {
+	name string
+	kek  []byte
+}
+
+func newGCMKeyWrapper(t *testing.T, name string) *gcmKeyWrapper {
+	t.Helper()
+	kek := make([]byte, 32)
+	if _, err := rand.Read(kek); err != nil {
+		t.Fatal(err)
+	}
+	return &gcmKeyWrapper{name: name, kek: kek}
+}
+
+func (w *gcmKeyWrapper) AnnotationID() string {
+	return AnnotationEncKeysPrefix + "provider." + w.name
+}
+
+func (w *gcmKeyWrapper) aead() cipher.AEAD {
+	block, _ := aes.NewCipher(w.kek)
+	aead, _ := cipher.NewGCM(block)
+	return aead
+}
+
+func (w *gcmKeyWrapper) WrapKey(_ context.Context, optsData []byte) ([]byte, error) {
+	aead := w.aead()
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, optsData, nil), nil
+}
+
+func (w *gcmKeyWrapper) UnwrapKey(_ context.Context, wrapped []byte) ([]byte, error) {
+	aead := w.aead()
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("short key")
+	}
+	return aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], nil)
+}
+
+func TestPushPull_Encrypted(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	wrapper := newGCMKeyWrapper(t, "kms")
+	client := NewClient(WithPlainHTTP(true), WithKeyWrappers(wrapper))
+
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, ".claude-plugin", "plugin.json"), `{"name":"gs-base"}`)
+	writeFile(t, filepath.Join(src, "skills", "secret", "SKILL.md"), "# proprietary")
+
+	ref := host + "/klaus-plugins/gs-base:v1.0.0"
+	result, err := client.PushPlugin(t.Context(), src, ref, Plugin{Name: "gs-base"}, WithEncryption())
+	if err != nil {
+		t.Fatalf("PushPlugin() error = %v", err)
+	}
+	if result.Checksums.Files != nil {
+		t.Errorf("Checksums.Files = %v, want none for an encrypted layer", result.Checksums.Files)
+	}
+
+	reg.mu.Lock()
+	body := reg.manifests[result.Digest]
+	reg.mu.Unlock()
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	layer := manifest.Layers[0]
+	if want := MediaTypePluginContent + MediaTypeEncryptedSuffix; layer.MediaType != want {
+		t.Errorf("layer media type = %q, want %q", layer.MediaType, want)
+	}
+	if layer.Annotations[AnnotationEncPubOpts] == "" || layer.Annotations[wrapper.AnnotationID()] == "" {
+		t.Errorf("layer annotations = %v, want pubopts and wrapped key", layer.Annotations)
+	}
+	if manifest.Annotations[AnnotationName] != "gs-base" {
+		t.Errorf("manifest annotations = %v, want them readable", manifest.Annotations)
+	}
+
+	dest := filepath.Join(t.TempDir(), "gs-base")
+	if _, err := client.PullPlugin(t.Context(), ref, dest); err != nil {
+		t.Fatalf("PullPlugin() error = %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dest, "skills", "secret", "SKILL.md"))
+	if err != nil || string(got) != "# proprietary" {
+		t.Errorf("SKILL.md = %q, %v", got, err)
+	}
+
+	t.Run("without key", func(t *testing.T) {
+		for name, c := range map[string]*Client{
+			"none":  NewClient(WithPlainHTTP(true)),
+			"other": NewClient(WithPlainHTTP(true), WithKeyWrappers(newGCMKeyWrapper(t, "kms"))),
+		} {
+			_, err := c.PullPlugin(t.Context(), ref, filepath.Join(t.TempDir(), "p"))
+			var noKey *ErrNoDecryptionKey
+			if !errors.As(err, &noKey) {
+				t.Fatalf("%s: PullPlugin() error = %v, want *ErrNoDecryptionKey", name, err)
+			}
+			if len(noKey.Protocols) != 1 || noKey.Protocols[0] != "provider.kms" {
+				t.Errorf("%s: Protocols = %v, want [provider.kms]", name, noKey.Protocols)
+			}
+		}
+	})
+}
+
+func TestPush_EncryptionWithoutKeys(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "SOUL.md"), "Be calm.")
+	_, err := client.PushPersonality(t.Context(), src, host+"/klaus/sre:v1.0.0", Personality{Name: "sre"}, WithEncryption())
+	if err == nil || !strings.Contains(err.Error(), "no key wrappers") {
+		t.Fatalf("PushPersonality() error = %v, want missing key wrappers", err)
+	}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if len(reg.blobs) != 0 {
+		t.Errorf("%d blobs uploaded, want none", len(reg.blobs))
+	}
+}
+
+func TestDecryptLayer(t *testing.T) {
+	w1, w2 := newGCMKeyWrapper(t, "kms"), newGCMKeyWrapper(t, "kms")
+	plain := []byte("layer content that is long enough to span a few cipher blocks")
+	desc, ciphertext, err := encryptLayer(t.Context(), blobDescriptor(MediaTypePluginContent, plain), plain, []KeyWrapper{w1, w2})
+	if err != nil {
+		t.Fatalf("encryptLayer() error = %v", err)
+	}
+	if bytes.Contains(ciphertext, plain[:16]) {
+		t.Fatal("ciphertext contains plaintext")
+	}
+	if n := strings.Count(desc.Annotations[w1.AnnotationID()], ","); n != 1 {
+		t.Errorf("wrapped keys = %d, want 2 in one annotation", n+1)
+	}
+
+	tests := []struct {
+		name    string
+		wrapper KeyWrapper
+		data    func() []byte
+		wantErr string
+	}{
+		{name: "first key", wrapper: w1, data: func() []byte { return ciphertext }},
+		{name: "second key", wrapper: w2, data: func() []byte { return ciphertext }},
+		{
+			name:    "tampered",
+			wrapper: w1,
+			data: func() []byte {
+				b := bytes.Clone(ciphertext)
+				b[3] ^= 0xff
+				return b
+			},
+			wantErr: "HMAC mismatch",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(WithKeyWrappers(tt.wrapper))
+			r, err := c.decryptLayer(t.Context(), "ref", bytes.NewReader(tt.data()), desc)
+			if err != nil {
+				t.Fatalf("decryptLayer() error = %v", err)
+			}
+			got, err := io.ReadAll(r)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ReadAll() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(got, plain) {
+				t.Errorf("ReadAll() = %q, %v, want plaintext", got, err)
+			}
+		})
+	}
+}
+
+// TestKeyProviderHelperProcess is not a real test. It is run as the key
+// provider binary by TestKeyProviderCommand and "wraps" keys by reversing
+// them.
+func TestKeyProviderHelperProcess(t *testing.T) {
+	if os.Getenv("KLAUS_OCI_KEYPROVIDER_HELPER") != "1" {
+		return
+	}
+	var in keyProviderInput
+	if err := json.NewDecoder(os.Stdin).Decode(&in); err != nil {
+		os.Exit(2)
+	}
+	var out keyProviderOutput
+	switch in.Op {
+	case "keywrap":
+		if string(in.KeyWrapParams.EC.Parameters["recipient"][0]) != "team-a" {
+			os.Stderr.WriteString("unknown recipient")
+			os.Exit(1)
+		}
+		out.KeyWrapResults.Annotation = reversed(in.KeyWrapParams.OptsData)
+	case "keyunwrap":
+		out.KeyUnwrapResults.OptsData = reversed(in.KeyUnwrapParams.Annotation)
+	}
+	json.NewEncoder(os.Stdout).Encode(out)
+	os.Exit(0)
+}
+
+func reversed(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i, c := range b {
+		r[len(b)-1-i] = c
+	}
+	return r
+}
+
+func TestKeyProviderCommand(t *testing.T) {
+	t.Setenv("KLAUS_OCI_KEYPROVIDER_HELPER", "1")
+	provider := &KeyProviderCommand{
+		Name:       "test",
+		Path:       os.Args[0],
+		Args:       []string{"-test.run=^TestKeyProviderHelperProcess$"},
+		Parameters: map[string][][]byte{"recipient": {[]byte("team-a")}},
+	}
+	if got, want := provider.AnnotationID(), "org.opencontainers.image.enc.keys.provider.test"; got != want {
+		t.Errorf("AnnotationID() = %q, want %q", got, want)
+	}
+
+	wrapped, err := provider.WrapKey(t.Context(), []byte("opts"))
+	if err != nil {
+		t.Fatalf("WrapKey() error = %v", err)
+	}
+	if string(wrapped) != "stpo" {
+		t.Errorf("WrapKey() = %q, want %q", wrapped, "stpo")
+	}
+	opts, err := provider.UnwrapKey(t.Context(), wrapped)
+	if err != nil || string(opts) != "opts" {
+		t.Errorf("UnwrapKey() = %q, %v, want %q", opts, err, "opts")
+	}
+
+	provider.Parameters = map[string][][]byte{"recipient": {[]byte("team-b")}}
+	if _, err := provider.WrapKey(t.Context(), []byte("opts")); err == nil || !strings.Contains(err.Error(), "unknown recipient") {
+		t.Errorf("WrapKey() error = %v, want provider stderr", err)
+	}
+}
@@ -0,0 +1,293 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"strings"
+
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+// ErrWrongArtifactType is returned by typed describe and pull operations
+// (DescribePlugin, PullPersonality, ...) when the manifest's config media
+// type does not belong to the requested artifact kind, for example when
+// DescribePlugin is called on a personality. Use errors.As to inspect it.
+type ErrWrongArtifactType struct {
+	// Ref is the reference that was inspected.
+	Ref string
+	// Expected is the kind the caller asked for.
+	Expected Kind
+	// Got is the detected kind, or empty when the config media type does
+	// not belong to any known kind.
+	Got Kind
+	// ConfigMediaType is the config media type found in the manifest.
+	ConfigMediaType string
+}
+
+func (e *ErrWrongArtifactType) Error() string {
+	if e.Got != "" {
+		return fmt.Sprintf("%s is a %s, not a %s", e.Ref, e.Got, e.Expected)
+	}
+	return fmt.Sprintf("%s is not a %s (config media type %q)", e.Ref, e.Expected, e.ConfigMediaType)
+}
+
+// ErrTagExists is returned when a tag may not be overwritten (see
+// WithNoClobber and WithNoOverwrite) but already points at a different
+// manifest. Use errors.As to inspect it.
+type ErrTagExists struct {
+	// Repository is the repository, e.g. "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base".
+	Repository string
+	// Tag is the tag that already exists.
+	Tag string
+	// Digest is the manifest digest the tag currently points at.
+	Digest string
+}
+
+func (e *ErrTagExists) Error() string {
+	return fmt.Sprintf("tag %s:%s already exists with digest %s", e.Repository, e.Tag, e.Digest)
+}
+
+// ErrTagConflict is returned by TagIfDigest when the tag no longer points
+// at the expected manifest. Use errors.As to inspect it.
+type ErrTagConflict struct {
+	// Repository is the repository, e.g. "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base".
+	Repository string
+	// Tag is the tag that was to be moved.
+	Tag string
+	// Expected is the digest the tag was expected to point at, or empty
+	// if it was expected not to exist.
+	Expected string
+	// Actual is the digest the tag points at, or empty if it does not
+	// exist.
+	Actual string
+}
+
+func (e *ErrTagConflict) Error() string {
+	describe := func(digest string) string {
+		if digest == "" {
+			return "no manifest"
+		}
+		return digest
+	}
+	return fmt.Sprintf("tag %s:%s points at %s, expected %s", e.Repository, e.Tag, describe(e.Actual), describe(e.Expected))
+}
+
+// ErrNoDecryptionKey is returned when pulling an artifact with an
+// encrypted content layer that none of the client's key wrappers (see
+// WithKeyWrappers) can decrypt. Use errors.As to inspect it.
+type ErrNoDecryptionKey struct {
+	// Ref is the reference that was pulled.
+	Ref string
+	// Protocols lists the key wrapping protocols the layer was encrypted
+	// for, e.g. "provider.age".
+	Protocols []string
+	// Err holds the unwrap errors of wrappers that were tried, if any.
+	Err error
+}
+
+func (e *ErrNoDecryptionKey) Error() string {
+	msg := "no key to decrypt content layer"
+	if len(e.Protocols) > 0 {
+		msg += " (encrypted for " + strings.Join(e.Protocols, ", ") + ")"
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ErrNoDecryptionKey) Unwrap() error { return e.Err }
+
+// ErrDuplicatePlugin is returned by Personality.AddPlugin when the
+// personality already references the plugin's repository. Use errors.As
+// to inspect it.
+type ErrDuplicatePlugin struct {
+	// Existing is the reference already in the personality.
+	Existing PluginReference
+}
+
+func (e *ErrDuplicatePlugin) Error() string {
+	return fmt.Sprintf("plugin %s is already referenced as %s", e.Existing.Repository, e.Existing.Ref())
+}
+
+// ErrUnknownArtifact is returned when a short name does not resolve to a
+// repository under the registry base. Suggestions lists the closest
+// existing names, best first, for "did you mean" hints. Use errors.As to
+// inspect it.
+type ErrUnknownArtifact struct {
+	// Name is the short name that was not found.
+	Name string
+	// Suggestions lists up to three similar names.
+	Suggestions []string
+	// Err is the resolution error.
+	Err error
+}
+
+func (e *ErrUnknownArtifact) Error() string {
+	if len(e.Suggestions) == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v (did you mean %s?)", e.Err, strings.Join(e.Suggestions, ", "))
+}
+
+func (e *ErrUnknownArtifact) Unwrap() error { return e.Err }
+
+// ErrTruncated is returned together with partial results when a listing
+// made with WithPartialResults reaches its deadline. The results cover
+// the repositories sorted before the first one that was not resolved in
+// time; pass Continuation to WithContinuation to list the rest. Use
+// errors.As to inspect it.
+type ErrTruncated struct {
+	// Remaining is the number of repositories not listed.
+	Remaining int
+	// Continuation resumes the listing with WithContinuation.
+	Continuation string
+}
+
+func (e *ErrTruncated) Error() string {
+	return fmt.Sprintf("listing truncated at deadline, %d repositories remaining", e.Remaining)
+}
+
+// ErrQuarantined is returned when pulling an artifact that was
+// quarantined (see Client.Quarantine) without WithAllowQuarantined. Use
+// errors.As to inspect it.
+type ErrQuarantined struct {
+	// Ref is the reference that was pulled.
+	Ref string
+	// Quarantine describes the quarantine.
+	Quarantine Quarantine
+}
+
+func (e *ErrQuarantined) Error() string {
+	if e.Quarantine.Reason == "" {
+		return fmt.Sprintf("%s is quarantined", e.Ref)
+	}
+	return fmt.Sprintf("%s is quarantined: %s", e.Ref, e.Quarantine.Reason)
+}
+
+// ErrMemoryBudget is returned when an operation needs a larger buffer
+// than the budget set with WithMemoryBudget allows. Use errors.As to
+// inspect it.
+type ErrMemoryBudget struct {
+	// What describes the buffer, e.g. "manifest of example.com/p:v1".
+	What string
+	// Size is the number of bytes the buffer needs.
+	Size int64
+	// Budget is the memory budget in bytes.
+	Budget int64
+}
+
+func (e *ErrMemoryBudget) Error() string {
+	return fmt.Sprintf("%s needs %d bytes, exceeding the memory budget of %d", e.What, e.Size, e.Budget)
+}
+
+// Category classifies an error by what it takes to resolve it, so CLIs
+// can choose exit codes and operators can choose between requeueing and
+// failing terminally. See ErrorCategory.
+type Category string
+
+// Error categories.
+const (
+	// CategoryNotFound: the artifact, tag, repository, or local file does
+	// not exist.
+	CategoryNotFound Category = "NotFound"
+	// CategoryAuth: credentials are missing or rejected, or no key can
+	// decrypt the content.
+	CategoryAuth Category = "Auth"
+	// CategoryNetwork: the registry could not be reached, timed out, or
+	// is throttling or failing. Retrying may succeed.
+	CategoryNetwork Category = "Network"
+	// CategoryValidation: an artifact or input is malformed or of the
+	// wrong kind.
+	CategoryValidation Category = "Validation"
+	// CategoryPolicy: the operation was refused by a policy, such as a
+	// quarantine, tag protection, or a memory budget.
+	CategoryPolicy Category = "Policy"
+	// CategoryInternal: any other error.
+	CategoryInternal Category = "Internal"
+)
+
+// Retryable reports whether errors of the category may succeed when
+// retried unchanged. Only network errors are.
+func (c Category) Retryable() bool {
+	return c == CategoryNetwork
+}
+
+// ErrorCategory classifies err, which may wrap the package's typed errors
+// and registry, network, and file system errors. It returns the empty
+// category for a nil error and CategoryInternal for unclassified errors.
+func ErrorCategory(err error) Category {
+	if err == nil {
+		return ""
+	}
+	var (
+		unknown     *ErrUnknownArtifact
+		noKey       *ErrNoDecryptionKey
+		wrongType   *ErrWrongArtifactType
+		duplicate   *ErrDuplicatePlugin
+		tagExists   *ErrTagExists
+		tagConflict *ErrTagConflict
+		quarantined *ErrQuarantined
+		budget      *ErrMemoryBudget
+		truncated   *ErrTruncated
+	)
+	switch {
+	case errors.As(err, &unknown):
+		return CategoryNotFound
+	case errors.As(err, &noKey):
+		return CategoryAuth
+	case errors.As(err, &wrongType), errors.As(err, &duplicate), errors.Is(err, ErrNoContentHash):
+		return CategoryValidation
+	case errors.As(err, &tagExists), errors.As(err, &tagConflict), errors.As(err, &quarantined), errors.As(err, &budget):
+		return CategoryPolicy
+	case errors.As(err, &truncated), errors.Is(err, context.DeadlineExceeded):
+		return CategoryNetwork
+	}
+
+	var resp *errcode.ErrorResponse
+	if errors.As(err, &resp) {
+		return statusCategory(resp.StatusCode)
+	}
+	var status *httpStatusError
+	if errors.As(err, &status) {
+		return statusCategory(status.StatusCode)
+	}
+
+	switch {
+	case errors.Is(err, errdef.ErrNotFound), errors.Is(err, fs.ErrNotExist):
+		return CategoryNotFound
+	case errors.Is(err, content.ErrMismatchedDigest), errors.Is(err, content.ErrInvalidDescriptorSize),
+		errors.Is(err, errdef.ErrInvalidDigest), errors.Is(err, errdef.ErrInvalidReference),
+		errors.Is(err, errdef.ErrInvalidMediaType), errors.Is(err, errdef.ErrMissingReference):
+		return CategoryValidation
+	case errors.Is(err, errdef.ErrSizeExceedsLimit):
+		return CategoryPolicy
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return CategoryNetwork
+	}
+	return CategoryInternal
+}
+
+// statusCategory classifies an HTTP error status.
+func statusCategory(code int) Category {
+	switch {
+	case code == http.StatusUnauthorized, code == http.StatusForbidden:
+		return CategoryAuth
+	case code == http.StatusNotFound:
+		return CategoryNotFound
+	case code == http.StatusTooManyRequests, code == http.StatusRequestTimeout, code >= 500:
+		return CategoryNetwork
+	case code >= 400:
+		return CategoryValidation
+	}
+	return CategoryInternal
+}
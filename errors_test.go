@@ -0,0 +1,278 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+func TestErrWrongArtifactType_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *ErrWrongArtifactType
+		want string
+	}{
+		{
+			name: "known kind",
+			err:  &ErrWrongArtifactType{Ref: "example.com/sre:v1", Expected: KindPlugin, Got: KindPersonality, ConfigMediaType: MediaTypePersonalityConfig},
+			want: "example.com/sre:v1 is a personality, not a plugin",
+		},
+		{
+			name: "unknown media type",
+			err:  &ErrWrongArtifactType{Ref: "example.com/x:v1", Expected: KindPlugin, ConfigMediaType: "application/vnd.example+json"},
+			want: `example.com/x:v1 is not a plugin (config media type "application/vnd.example+json")`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribe_WrongArtifactType(t *testing.T) {
+	personalityJSON, _ := json.Marshal(personalityConfigBlob{})
+	pluginJSON, _ := json.Marshal(pluginConfigBlob{})
+
+	ts := newArtifactRegistry(map[string]testArtifactEntry{
+		"giantswarm/klaus-personalities/sre": {
+			configJSON:      personalityJSON,
+			configMediaType: MediaTypePersonalityConfig,
+			tags:            []string{"v1.0.0"},
+		},
+		"giantswarm/klaus-plugins/gs-base": {
+			configJSON:      pluginJSON,
+			configMediaType: MediaTypePluginConfig,
+			tags:            []string{"v1.0.0"},
+		},
+	})
+	defer ts.Close()
+	host := testRegistryHost(ts)
+	client := NewClient(WithPlainHTTP(true))
+
+	personalityRef := host + "/giantswarm/klaus-personalities/sre:v1.0.0"
+	pluginRef := host + "/giantswarm/klaus-plugins/gs-base:v1.0.0"
+
+	tests := []struct {
+		name     string
+		describe func() error
+		expected Kind
+		got      Kind
+	}{
+		{
+			name:     "plugin on personality",
+			describe: func() error { _, err := client.DescribePlugin(t.Context(), personalityRef); return err },
+			expected: KindPlugin,
+			got:      KindPersonality,
+		},
+		{
+			name:     "personality on plugin",
+			describe: func() error { _, err := client.DescribePersonality(t.Context(), pluginRef); return err },
+			expected: KindPersonality,
+			got:      KindPlugin,
+		},
+		{
+			name:     "toolchain on plugin",
+			describe: func() error { _, err := client.DescribeToolchain(t.Context(), pluginRef); return err },
+			expected: KindToolchain,
+			got:      KindPlugin,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wrong *ErrWrongArtifactType
+			if err := tt.describe(); !errors.As(err, &wrong) {
+				t.Fatalf("error = %v, want *ErrWrongArtifactType", err)
+			}
+			if wrong.Expected != tt.expected || wrong.Got != tt.got {
+				t.Errorf("Expected/Got = %s/%s, want %s/%s", wrong.Expected, wrong.Got, tt.expected, tt.got)
+			}
+		})
+	}
+}
+
+func TestPull_WrongArtifactType(t *testing.T) {
+	reg := newCacheRegistry()
+	personalityJSON, _ := json.Marshal(personalityConfigBlob{})
+	addPullableArtifact(t, reg, "klaus-personalities/sre", "v1.0.0", personalityArtifact, personalityJSON,
+		map[string]string{"SOUL.md": "Be calm."})
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	ref := host + "/klaus-personalities/sre:v1.0.0"
+
+	var wrong *ErrWrongArtifactType
+	if _, err := client.PullPlugin(t.Context(), ref, t.TempDir()); !errors.As(err, &wrong) {
+		t.Fatalf("PullPlugin() error = %v, want *ErrWrongArtifactType", err)
+	}
+
+	t.Run("cache hit", func(t *testing.T) {
+		dir := t.TempDir()
+		if _, err := client.PullPersonality(t.Context(), ref, dir); err != nil {
+			t.Fatalf("PullPersonality() error = %v", err)
+		}
+		entry, err := ReadCacheEntry(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.ConfigMediaType != MediaTypePersonalityConfig {
+			t.Errorf("cached ConfigMediaType = %q, want %q", entry.ConfigMediaType, MediaTypePersonalityConfig)
+		}
+		if _, err := client.PullPlugin(t.Context(), ref, dir); !errors.As(err, &wrong) {
+			t.Fatalf("PullPlugin() on cached dir error = %v, want *ErrWrongArtifactType", err)
+		}
+	})
+}
+
+func TestCheckArtifactKind(t *testing.T) {
+	if err := checkArtifactKind("r", pluginArtifact, ocispec.MediaTypeImageManifest, "", MediaTypePluginConfig, nil); err != nil {
+		t.Errorf("checkArtifactKind() for matching kind = %v, want nil", err)
+	}
+	err := checkArtifactKind("r", toolchainArtifact, ocispec.MediaTypeImageManifest, "", MediaTypePersonalityConfig, nil)
+	var wrong *ErrWrongArtifactType
+	if !errors.As(err, &wrong) || wrong.Got != KindPersonality {
+		t.Errorf("checkArtifactKind() = %v, want personality mismatch", err)
+	}
+
+	// The type annotation must agree with the media types.
+	if err := checkArtifactKind("r", toolchainArtifact, ocispec.MediaTypeImageManifest, "", ocispec.MediaTypeImageConfig, Toolchain{}.Annotations()); err != nil {
+		t.Errorf("checkArtifactKind() for annotated toolchain = %v, want nil", err)
+	}
+	err = checkArtifactKind("r", toolchainArtifact, ocispec.MediaTypeImageManifest, "", ocispec.MediaTypeImageConfig, Personality{}.Annotations())
+	if !errors.As(err, &wrong) || wrong.Got != KindPersonality {
+		t.Errorf("checkArtifactKind() = %v, want personality mismatch from annotation", err)
+	}
+}
+
+func TestErrTagExists_Error(t *testing.T) {
+	err := &ErrTagExists{Repository: "example.com/klaus/sre", Tag: "v1.0.0", Digest: "sha256:abc"}
+	want := "tag example.com/klaus/sre:v1.0.0 already exists with digest sha256:abc"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestErrDuplicatePlugin_Error(t *testing.T) {
+	err := &ErrDuplicatePlugin{Existing: PluginReference{Repository: "example.com/plugins/gs-base", Tag: "v1.0.0"}}
+	want := "plugin example.com/plugins/gs-base is already referenced as example.com/plugins/gs-base:v1.0.0"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestErrUnknownArtifact_Error(t *testing.T) {
+	base := errors.New("no semver tags found for example.com/plugins/gs-bsae")
+	err := &ErrUnknownArtifact{Name: "gs-bsae", Err: base}
+	if err.Error() != base.Error() {
+		t.Errorf("Error() = %q, want %q", err.Error(), base.Error())
+	}
+	if !errors.Is(err, base) {
+		t.Error("errors.Is() = false, want true")
+	}
+
+	err.Suggestions = []string{"gs-base", "gs-ae"}
+	want := base.Error() + " (did you mean gs-base, gs-ae?)"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestErrTruncated_Error(t *testing.T) {
+	err := &ErrTruncated{Remaining: 3, Continuation: "abc"}
+	want := "listing truncated at deadline, 3 repositories remaining"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestErrQuarantined_Error(t *testing.T) {
+	err := &ErrQuarantined{Ref: "example.com/klaus/sre:v1.0.0", Quarantine: Quarantine{Reason: "compromised"}}
+	want := "example.com/klaus/sre:v1.0.0 is quarantined: compromised"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	err.Quarantine.Reason = ""
+	if got, want := err.Error(), "example.com/klaus/sre:v1.0.0 is quarantined"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestErrMemoryBudget_Error(t *testing.T) {
+	err := &ErrMemoryBudget{What: "config of example.com/klaus-plugins/p:v1.0.0", Size: 4096, Budget: 1024}
+	want := "config of example.com/klaus-plugins/p:v1.0.0 needs 4096 bytes, exceeding the memory budget of 1024"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestErrTagConflict_Error(t *testing.T) {
+	err := &ErrTagConflict{Repository: "example.com/klaus/sre", Tag: "stable", Expected: "sha256:aaa", Actual: "sha256:bbb"}
+	if got, want := err.Error(), "tag example.com/klaus/sre:stable points at sha256:bbb, expected sha256:aaa"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	err.Expected, err.Actual = "", "sha256:bbb"
+	if got, want := err.Error(), "tag example.com/klaus/sre:stable points at sha256:bbb, expected no manifest"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestErrorCategory(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Category
+	}{
+		{"nil", nil, ""},
+		{"unknown artifact", fmt.Errorf("resolving: %w", &ErrUnknownArtifact{Name: "sre", Err: errors.New("no repository")}), CategoryNotFound},
+		{"no decryption key", &ErrNoDecryptionKey{Ref: "example.com/p:v1"}, CategoryAuth},
+		{"wrong artifact type", &ErrWrongArtifactType{Ref: "example.com/p:v1"}, CategoryValidation},
+		{"quarantined", &ErrQuarantined{Ref: "example.com/p:v1"}, CategoryPolicy},
+		{"tag exists", &ErrTagExists{Repository: "example.com/p", Tag: "v1"}, CategoryPolicy},
+		{"deadline", fmt.Errorf("listing: %w", context.DeadlineExceeded), CategoryNetwork},
+		{"unauthorized", &errcode.ErrorResponse{StatusCode: http.StatusUnauthorized}, CategoryAuth},
+		{"manifest unknown", &errcode.ErrorResponse{StatusCode: http.StatusNotFound}, CategoryNotFound},
+		{"throttled", &errcode.ErrorResponse{StatusCode: http.StatusTooManyRequests}, CategoryNetwork},
+		{"manifest invalid", &errcode.ErrorResponse{StatusCode: http.StatusBadRequest}, CategoryValidation},
+		{"not found", fmt.Errorf("v1: %w", errdef.ErrNotFound), CategoryNotFound},
+		{"missing file", fmt.Errorf("reading: %w", fs.ErrNotExist), CategoryNotFound},
+		{"digest mismatch", content.ErrMismatchedDigest, CategoryValidation},
+		{"other", errors.New("boom"), CategoryInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorCategory(tt.err); got != tt.want {
+				t.Errorf("ErrorCategory() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorCategory_Client(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+
+	_, err := client.DescribePersonality(t.Context(), host+"/klaus/missing:v1.0.0")
+	if got := ErrorCategory(err); got != CategoryNotFound {
+		t.Errorf("ErrorCategory(%v) = %q, want NotFound", err, got)
+	}
+
+	ts := httptest.NewServer(http.NotFoundHandler())
+	unreachable := testRegistryHost(ts)
+	ts.Close()
+	_, err = client.DescribePersonality(t.Context(), unreachable+"/klaus/sre:v1.0.0")
+	if got := ErrorCategory(err); got != CategoryNetwork || !got.Retryable() {
+		t.Errorf("ErrorCategory(%v) = %q, want retryable Network", err, got)
+	}
+}
@@ -0,0 +1,209 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/errgroup"
+	"oras.land/oras-go/v2/content"
+)
+
+// MediaTypeEvalResults is the artifact type of evaluation results attached
+// to a personality with AttachEvalResults, and the media type of their
+// single layer.
+const MediaTypeEvalResults = "application/vnd.giantswarm.klaus.eval-results.v1+json"
+
+// maxEvalResultsSize bounds evaluation result documents, which hold scores
+// rather than full transcripts.
+const maxEvalResultsSize = 1 << 20
+
+// EvalResults records one evaluation run of a personality version, so
+// versions can be compared by measured quality.
+type EvalResults struct {
+	// Suites lists the scores per benchmark suite.
+	Suites []SuiteScores `json:"suites"`
+	// Model is the model the personality ran on, if relevant.
+	Model string `json:"model,omitempty"`
+	// Timestamp is when the evaluation ran. AttachEvalResults sets it to
+	// the current time when zero.
+	Timestamp time.Time `json:"timestamp"`
+	// ReportURL links to the full evaluation report.
+	ReportURL string `json:"reportURL,omitempty"`
+
+	// Digest is the manifest digest of the referrer holding the results,
+	// set when they are fetched.
+	Digest string `json:"-"`
+}
+
+// SuiteScores holds the scores of one benchmark suite.
+type SuiteScores struct {
+	// Suite names the benchmark suite, e.g. "incident-triage".
+	Suite string `json:"suite"`
+	// Version identifies the suite version; scores are only comparable
+	// within one version.
+	Version string `json:"version,omitempty"`
+	// Scores maps metric names to values, e.g. "pass_rate": 0.92.
+	Scores map[string]float64 `json:"scores"`
+}
+
+// WithEvalResults makes DescribePersonality and Describe list the
+// evaluation results attached to personalities in
+// DescribedPersonality.EvalResults. This costs one referrers request plus
+// a manifest and a blob GET per attached result.
+func WithEvalResults() ClientOption {
+	return func(c *Client) { c.evalResults = true }
+}
+
+// AttachEvalResults attaches results to the personality at ref as an OCI
+// referrer of artifact type MediaTypeEvalResults, and returns the
+// referrer's manifest digest. ref supports the same forms as
+// DescribePersonality; short names and "latest" are resolved first, so
+// the results are linked to the exact version. A personality can carry any
+// number of results, e.g. one per run.
+func (c *Client) AttachEvalResults(ctx context.Context, ref string, results EvalResults) (string, error) {
+	if len(results.Suites) == 0 {
+		return "", fmt.Errorf("evaluation results must include at least one suite")
+	}
+	for _, s := range results.Suites {
+		if s.Suite == "" {
+			return "", fmt.Errorf("evaluation results must name every suite")
+		}
+	}
+	if results.Timestamp.IsZero() {
+		results.Timestamp = time.Now().UTC()
+	}
+	doc, err := json.Marshal(results)
+	if err != nil {
+		return "", fmt.Errorf("marshaling evaluation results: %w", err)
+	}
+	if len(doc) > maxEvalResultsSize {
+		return "", fmt.Errorf("evaluation results are %d bytes, exceeding the limit of %d", len(doc), maxEvalResultsSize)
+	}
+
+	fm, resolved, err := c.fetchEvalSubject(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	subject, err := fm.repo.Resolve(ctx, fm.digest)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", resolved, err)
+	}
+	annotations := map[string]string{ocispec.AnnotationCreated: results.Timestamp.Format(time.RFC3339)}
+	desc, err := pushReferrer(ctx, fm.repo, subject, MediaTypeEvalResults, doc, annotations)
+	if err != nil {
+		return "", fmt.Errorf("attaching evaluation results to %s: %w", resolved, err)
+	}
+	return desc.Digest.String(), nil
+}
+
+// FetchEvalResults returns the evaluation results attached to the
+// personality at ref, newest first. ref supports the same forms as
+// DescribePersonality.
+func (c *Client) FetchEvalResults(ctx context.Context, ref string) ([]EvalResults, error) {
+	fm, resolved, err := c.fetchEvalSubject(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return c.fetchEvalResults(ctx, fm, resolved)
+}
+
+// fetchEvalSubject resolves ref to a personality and fetches its manifest.
+func (c *Client) fetchEvalSubject(ctx context.Context, ref string) (*fetchedManifest, string, error) {
+	resolved, err := c.ResolvePersonalityRef(ctx, ref)
+	if err != nil {
+		return nil, "", fmt.Errorf("resolving personality ref %q: %w", ref, err)
+	}
+	fm, err := c.fetchManifest(ctx, resolved)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := checkArtifactKind(resolved, personalityArtifact, fm.mediaType, fm.manifest.ArtifactType, fm.manifest.Config.MediaType, fm.manifest.Annotations); err != nil {
+		return nil, "", err
+	}
+	return fm, resolved, nil
+}
+
+// fetchEvalResults lists and fetches the evaluation results referring to
+// the manifest of fm, newest first.
+func (c *Client) fetchEvalResults(ctx context.Context, fm *fetchedManifest, ref string) ([]EvalResults, error) {
+	referrers, err := fm.referrers(ctx, MediaTypeEvalResults)
+	if err != nil {
+		return nil, fmt.Errorf("listing evaluation results of %s: %w", ref, err)
+	}
+
+	results := make([]EvalResults, len(referrers))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+	for i, desc := range referrers {
+		g.Go(func() error {
+			r, err := fetchEvalResultsDocument(gctx, fm, desc)
+			if err != nil {
+				return fmt.Errorf("fetching evaluation results %s of %s: %w", desc.Digest, ref, err)
+			}
+			results[i] = *r
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(results, func(a, b EvalResults) int {
+		return b.Timestamp.Compare(a.Timestamp)
+	})
+	return results, nil
+}
+
+// fetchEvalResultsDocument fetches the referrer manifest desc and decodes
+// its evaluation results layer.
+func fetchEvalResultsDocument(ctx context.Context, fm *fetchedManifest, desc ocispec.Descriptor) (*EvalResults, error) {
+	data, err := fm.fetchReferrerDocument(ctx, desc, MediaTypeEvalResults, maxEvalResultsSize)
+	if err != nil {
+		return nil, err
+	}
+	var results EvalResults
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("parsing evaluation results: %w", err)
+	}
+	results.Digest = desc.Digest.String()
+	return &results, nil
+}
+
+// referrers lists the referrers of artifactType to the fetched manifest.
+func (fm *fetchedManifest) referrers(ctx context.Context, artifactType string) ([]ocispec.Descriptor, error) {
+	subject := ocispec.Descriptor{MediaType: fm.mediaType, Digest: godigest.Digest(fm.digest)}
+	var referrers []ocispec.Descriptor
+	err := fm.repo.Referrers(ctx, subject, artifactType, func(page []ocispec.Descriptor) error {
+		referrers = append(referrers, page...)
+		return nil
+	})
+	return referrers, err
+}
+
+// fetchReferrerDocument fetches the referrer manifest desc and returns its
+// layer of mediaType, which must not exceed maxSize bytes.
+func (fm *fetchedManifest) fetchReferrerDocument(ctx context.Context, desc ocispec.Descriptor, mediaType string, maxSize int64) ([]byte, error) {
+	var manifest ocispec.Manifest
+	if err := fetchJSONManifest(ctx, fm.repo, desc.Digest.String(), &manifest); err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(manifest.Layers, func(l ocispec.Descriptor) bool { return l.MediaType == mediaType })
+	if i < 0 {
+		return nil, fmt.Errorf("no layer of media type %s", mediaType)
+	}
+	layer := manifest.Layers[i]
+	if layer.Size > maxSize {
+		return nil, fmt.Errorf("document is %d bytes, exceeding the limit of %d", layer.Size, maxSize)
+	}
+	rc, err := fm.repo.Fetch(ctx, layer)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return content.ReadAll(io.LimitReader(rc, maxSize), layer)
+}
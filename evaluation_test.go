@@ -0,0 +1,121 @@
+package oci
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAttachEvalResults(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	repository := host + "/klaus/sre"
+	pushVersions(t, client, repository, "v1.0.0", "v1.1.0")
+
+	older := EvalResults{
+		Suites:    []SuiteScores{{Suite: "incident-triage", Version: "1", Scores: map[string]float64{"pass_rate": 0.81}}},
+		Model:     "model-a",
+		Timestamp: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	newer := EvalResults{
+		Suites: []SuiteScores{
+			{Suite: "incident-triage", Version: "1", Scores: map[string]float64{"pass_rate": 0.86}},
+			{Suite: "postmortems", Scores: map[string]float64{"rubric": 4.2}},
+		},
+		Timestamp: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		ReportURL: "https://example.com/report",
+	}
+	for _, r := range []EvalResults{newer, older} {
+		if _, err := client.AttachEvalResults(t.Context(), repository+":v1.0.0", r); err != nil {
+			t.Fatalf("AttachEvalResults() error = %v", err)
+		}
+	}
+
+	results, err := client.FetchEvalResults(t.Context(), repository+":v1.0.0")
+	if err != nil {
+		t.Fatalf("FetchEvalResults() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("FetchEvalResults() = %d results, want 2", len(results))
+	}
+	if !results[0].Timestamp.Equal(newer.Timestamp) || len(results[0].Suites) != 2 || results[0].ReportURL != newer.ReportURL {
+		t.Errorf("results[0] = %+v, want the newer run", results[0])
+	}
+	if got := results[1].Suites[0].Scores["pass_rate"]; got != 0.81 || results[1].Model != "model-a" {
+		t.Errorf("results[1] = %+v, want the older run", results[1])
+	}
+	if results[0].Digest == "" || results[0].Digest == results[1].Digest {
+		t.Errorf("Digests = %q, %q, want distinct referrer digests", results[0].Digest, results[1].Digest)
+	}
+
+	// Results belong to one version.
+	latest, err := client.FetchEvalResults(t.Context(), repository)
+	if err != nil {
+		t.Fatalf("FetchEvalResults(latest) error = %v", err)
+	}
+	if len(latest) != 0 {
+		t.Errorf("FetchEvalResults(v1.1.0) = %+v, want none", latest)
+	}
+
+	described, err := client.DescribePersonality(t.Context(), repository+":v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if described.EvalResults != nil {
+		t.Errorf("EvalResults = %+v without WithEvalResults, want nil", described.EvalResults)
+	}
+
+	withEvals := NewClient(WithPlainHTTP(true), WithEvalResults())
+	described, err = withEvals.DescribePersonality(t.Context(), repository+":v1.0.0")
+	if err != nil {
+		t.Fatalf("DescribePersonality() error = %v", err)
+	}
+	if len(described.EvalResults) != 2 {
+		t.Errorf("EvalResults = %+v, want 2 results", described.EvalResults)
+	}
+	artifact, err := withEvals.Describe(t.Context(), repository+":v1.0.0")
+	if err != nil {
+		t.Fatalf("Describe() error = %v", err)
+	}
+	if len(artifact.Personality.EvalResults) != 2 {
+		t.Errorf("Describe() EvalResults = %+v, want 2 results", artifact.Personality.EvalResults)
+	}
+}
+
+func TestAttachEvalResults_Invalid(t *testing.T) {
+	client := NewClient(WithPlainHTTP(true))
+	tests := []struct {
+		name    string
+		results EvalResults
+	}{
+		{"no suites", EvalResults{}},
+		{"unnamed suite", EvalResults{Suites: []SuiteScores{{Scores: map[string]float64{"score": 1}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.AttachEvalResults(t.Context(), "example.invalid/klaus/sre:v1.0.0", tt.results); err == nil {
+				t.Error("AttachEvalResults() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestAttachEvalResults_DefaultsTimestamp(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	ref := host + "/klaus/sre:v1.0.0"
+	pushVersions(t, client, host+"/klaus/sre", "v1.0.0")
+
+	before := time.Now()
+	if _, err := client.AttachEvalResults(t.Context(), ref, EvalResults{Suites: []SuiteScores{{Suite: "smoke"}}}); err != nil {
+		t.Fatalf("AttachEvalResults() error = %v", err)
+	}
+	results, err := client.FetchEvalResults(t.Context(), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Timestamp.Before(before.Add(-time.Second)) {
+		t.Errorf("results = %+v, want one result timestamped now", results)
+	}
+}
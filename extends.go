@@ -0,0 +1,109 @@
+package oci
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxExtendsDepth bounds the parent chain so that a misconfigured registry
+// cannot make resolution run away even without a cycle.
+const maxExtendsDepth = 16
+
+// ResolvePersonalityInheritance follows p.Extends up the parent chain,
+// describing each parent from the registry, and returns p merged with its
+// ancestors. The result has Extends cleared. Parents are merged from the
+// root down, so a child always takes precedence over its parent:
+//
+//   - Metadata: Name and Version always come from p. Description, Author,
+//     Homepage, SourceRepo, License and Keywords are inherited when the
+//     child leaves them empty.
+//   - Toolchain: the child's toolchain replaces the parent's when its
+//     repository is set.
+//   - Requirements: the child's requirements replace the parent's when set.
+//   - Plugins: the parent's plugins come first, followed by the child's. A
+//     child reference to a repository the parent already lists replaces the
+//     parent's reference in place, so a child can pin another version.
+//
+// Souls are not inherited; each personality ships its own SOUL.md.
+//
+// A chain that references a personality repository twice is reported as a
+// cycle.
+func (c *Client) ResolvePersonalityInheritance(ctx context.Context, p Personality) (Personality, error) {
+	chain := []Personality{p}
+	seen := []string{}
+	next := p.Extends
+	for next != "" {
+		if len(seen) == maxExtendsDepth {
+			return Personality{}, fmt.Errorf("personality %s: extends chain exceeds %d levels", p.Name, maxExtendsDepth)
+		}
+		resolved, err := c.ResolvePersonalityRef(ctx, next)
+		if err != nil {
+			return Personality{}, fmt.Errorf("resolving parent personality %q: %w", next, err)
+		}
+		repo := RepositoryFromRef(resolved)
+		if slices.Contains(seen, repo) {
+			return Personality{}, fmt.Errorf("personality %s: extends cycle: %s -> %s", p.Name, strings.Join(seen, " -> "), repo)
+		}
+		seen = append(seen, repo)
+
+		parent, err := c.DescribePersonality(ctx, resolved)
+		if err != nil {
+			return Personality{}, fmt.Errorf("describing parent personality %s: %w", resolved, err)
+		}
+		chain = append(chain, parent.Personality)
+		next = parent.Personality.Extends
+	}
+
+	merged := chain[len(chain)-1]
+	for i := len(chain) - 2; i >= 0; i-- {
+		merged = mergePersonality(merged, chain[i])
+	}
+	merged.Extends = ""
+	return merged, nil
+}
+
+// mergePersonality returns child merged over parent using the precedence
+// documented on ResolvePersonalityInheritance.
+func mergePersonality(parent, child Personality) Personality {
+	merged := child
+	if merged.Description == "" {
+		merged.Description = parent.Description
+	}
+	if merged.Author == nil {
+		merged.Author = parent.Author
+	}
+	if merged.Homepage == "" {
+		merged.Homepage = parent.Homepage
+	}
+	if merged.SourceRepo == "" {
+		merged.SourceRepo = parent.SourceRepo
+	}
+	if merged.License == "" {
+		merged.License = parent.License
+	}
+	if len(merged.Keywords) == 0 {
+		merged.Keywords = parent.Keywords
+	}
+	if merged.Toolchain.Repository == "" {
+		merged.Toolchain = parent.Toolchain
+	}
+	if merged.Requirements == nil {
+		merged.Requirements = parent.Requirements
+	}
+
+	plugins := slices.Clone(parent.Plugins)
+	for _, ref := range child.Plugins {
+		i := slices.IndexFunc(plugins, func(p PluginReference) bool {
+			return p.Repository == ref.Repository
+		})
+		if i >= 0 {
+			plugins[i] = ref
+			continue
+		}
+		plugins = append(plugins, ref)
+	}
+	merged.Plugins = plugins
+	return merged
+}
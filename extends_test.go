@@ -0,0 +1,110 @@
+package oci
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func personalityEntry(t *testing.T, name, description string, blob personalityConfigBlob) testArtifactEntry {
+	t.Helper()
+	configJSON, err := json.Marshal(blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations := map[string]string{AnnotationName: name}
+	if description != "" {
+		annotations[AnnotationDescription] = description
+	}
+	return testArtifactEntry{
+		configJSON:      configJSON,
+		configMediaType: MediaTypePersonalityConfig,
+		tags:            []string{"v1.0.0"},
+		annotations:     annotations,
+	}
+}
+
+func TestResolvePersonalityInheritance(t *testing.T) {
+	ts := httptest.NewUnstartedServer(nil)
+	host := ts.Listener.Addr().String()
+	pluginRef := func(name, tag string) PluginReference {
+		return PluginReference{Repository: host + "/klaus-plugins/" + name, Tag: tag}
+	}
+
+	ts.Config.Handler = newArtifactHandler(map[string]testArtifactEntry{
+		"klaus-personalities/gs-base": personalityEntry(t, "gs-base", "Giant Swarm base", personalityConfigBlob{
+			Toolchain: ToolchainReference{Repository: host + "/klaus-toolchains/go", Tag: "v1.0.0"},
+			Plugins:   []PluginReference{pluginRef("gs-base", "v1.0.0"), pluginRef("gs-kubernetes", "v0.1.0")},
+		}),
+		"klaus-personalities/sre": personalityEntry(t, "sre", "", personalityConfigBlob{
+			Plugins: []PluginReference{pluginRef("gs-kubernetes", "v0.2.0"), pluginRef("gs-sre", "v0.5.0")},
+			Extends: host + "/klaus-personalities/gs-base:v1.0.0",
+		}),
+	})
+	ts.Start()
+	defer ts.Close()
+
+	client := NewClient(WithPlainHTTP(true))
+	got, err := client.ResolvePersonalityInheritance(t.Context(), Personality{
+		Name:    "phoenix-sre",
+		Extends: host + "/klaus-personalities/sre:v1.0.0",
+		Plugins: []PluginReference{pluginRef("phoenix-runbooks", "v0.1.0")},
+	})
+	if err != nil {
+		t.Fatalf("ResolvePersonalityInheritance() error = %v", err)
+	}
+
+	if got.Name != "phoenix-sre" || got.Extends != "" {
+		t.Errorf("Name = %q, Extends = %q", got.Name, got.Extends)
+	}
+	if got.Description != "Giant Swarm base" {
+		t.Errorf("Description = %q, want inherited from gs-base", got.Description)
+	}
+	if got.Toolchain.Repository != host+"/klaus-toolchains/go" {
+		t.Errorf("Toolchain = %+v, want inherited from gs-base", got.Toolchain)
+	}
+	wantPlugins := []PluginReference{
+		pluginRef("gs-base", "v1.0.0"),
+		pluginRef("gs-kubernetes", "v0.2.0"),
+		pluginRef("gs-sre", "v0.5.0"),
+		pluginRef("phoenix-runbooks", "v0.1.0"),
+	}
+	if !reflect.DeepEqual(got.Plugins, wantPlugins) {
+		t.Errorf("Plugins = %+v, want %+v", got.Plugins, wantPlugins)
+	}
+}
+
+func TestResolvePersonalityInheritance_Cycle(t *testing.T) {
+	ts := httptest.NewUnstartedServer(nil)
+	host := ts.Listener.Addr().String()
+
+	ts.Config.Handler = newArtifactHandler(map[string]testArtifactEntry{
+		"klaus-personalities/a": personalityEntry(t, "a", "", personalityConfigBlob{Extends: host + "/klaus-personalities/b:v1.0.0"}),
+		"klaus-personalities/b": personalityEntry(t, "b", "", personalityConfigBlob{Extends: host + "/klaus-personalities/a:v1.0.0"}),
+	})
+	ts.Start()
+	defer ts.Close()
+
+	client := NewClient(WithPlainHTTP(true))
+	_, err := client.ResolvePersonalityInheritance(t.Context(), Personality{
+		Name:    "child",
+		Extends: host + "/klaus-personalities/a:v1.0.0",
+	})
+	if err == nil || !strings.Contains(err.Error(), "extends cycle") {
+		t.Fatalf("error = %v, want extends cycle", err)
+	}
+}
+
+func TestResolvePersonalityInheritance_NoParent(t *testing.T) {
+	client := NewClient()
+	p := Personality{Name: "solo", Plugins: []PluginReference{{Repository: "example.com/p"}}}
+	got, err := client.ResolvePersonalityInheritance(t.Context(), p)
+	if err != nil {
+		t.Fatalf("ResolvePersonalityInheritance() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, p) {
+		t.Errorf("got %+v, want %+v", got, p)
+	}
+}
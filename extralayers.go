@@ -0,0 +1,285 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	godigest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// AnnotationLayerDirectory marks an extra layer packed from a directory
+// ("true"). Such layers are gzip-compressed tar archives and are extracted
+// by PullExtraLayers.
+const AnnotationLayerDirectory = "io.giantswarm.klaus.layer.directory"
+
+// extraLayerSource is a file or directory to attach with WithExtraLayer.
+type extraLayerSource struct {
+	path      string
+	mediaType string
+}
+
+// WithExtraLayer attaches the file or directory at path as an auxiliary
+// layer of the given media type, e.g. example configs or evaluation
+// datasets shipped with a personality. A directory is packed as a
+// gzip-compressed tar archive. The layer is named after the base name of
+// path, which must be unique among the extra layers, and is never
+// encrypted. Pulls ignore extra layers; download them with
+// PullExtraLayers.
+func WithExtraLayer(path, mediaType string) PushOption {
+	return func(cfg *pushConfig) {
+		cfg.extraLayers = append(cfg.extraLayers, extraLayerSource{path: path, mediaType: mediaType})
+	}
+}
+
+// ExtraLayer describes a layer attached with WithExtraLayer.
+type ExtraLayer struct {
+	// Name is the base name of the file or directory the layer was
+	// created from.
+	Name      string
+	MediaType string
+	Digest    string
+	Size      int64
+	// Directory is true for layers packed from a directory.
+	Directory bool
+}
+
+// withExtraLayers returns a copy of built with the extra layers appended
+// after the content layer. Layers larger than spillAt bytes are streamed
+// from disk when pushed: files from their source, directories from a
+// temporary archive. A negative spillAt keeps every layer in memory.
+func withExtraLayers(built *BuiltArtifact, sources []extraLayerSource, spillAt int64) (_ *BuiltArtifact, err error) {
+	manifest := built.Manifest
+	manifest.Layers = append([]ocispec.Descriptor(nil), manifest.Layers...)
+	extra := slices.Clone(built.ExtraLayers)
+	paths := slices.Clone(built.extraPaths)
+	paths = append(paths, make([]string, len(extra)-len(paths))...)
+	temps := slices.Clone(built.temps)
+	defer func() {
+		if err != nil {
+			for _, path := range temps[len(built.temps):] {
+				os.Remove(path)
+			}
+		}
+	}()
+	names := map[string]bool{}
+	for _, src := range sources {
+		if src.mediaType == "" {
+			return nil, fmt.Errorf("extra layer %s: media type is required", src.path)
+		}
+		if isReservedLayerMediaType(src.mediaType) {
+			return nil, fmt.Errorf("extra layer %s: media type %s is reserved", src.path, src.mediaType)
+		}
+		name := filepath.Base(filepath.Clean(src.path))
+		if names[name] {
+			return nil, fmt.Errorf("extra layer %s: duplicate layer name %q", src.path, name)
+		}
+		names[name] = true
+
+		info, err := os.Stat(src.path)
+		if err != nil {
+			return nil, fmt.Errorf("extra layer: %w", err)
+		}
+		desc, data, path, err := packExtraLayer(src, info, spillAt)
+		if err != nil {
+			return nil, fmt.Errorf("extra layer %s: %w", src.path, err)
+		}
+		if info.IsDir() && path != "" {
+			temps = append(temps, path)
+		}
+
+		desc.Annotations = map[string]string{ocispec.AnnotationTitle: name}
+		if info.IsDir() {
+			desc.Annotations[AnnotationLayerDirectory] = "true"
+		}
+		manifest.Layers = append(manifest.Layers, desc)
+		extra = append(extra, data)
+		paths = append(paths, path)
+	}
+
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling manifest: %w", err)
+	}
+	withExtra := *built
+	withExtra.Manifest = manifest
+	withExtra.ManifestJSON = manifestJSON
+	withExtra.Digest = godigest.FromBytes(manifestJSON).String()
+	withExtra.ExtraLayers = extra
+	withExtra.extraPaths = paths
+	withExtra.temps = temps
+	return &withExtra, nil
+}
+
+// packExtraLayer returns the descriptor of the extra layer src and either
+// its content or the path to stream it from. Files larger than spillAt
+// bytes are streamed from src.path; directories whose archive is larger
+// are spooled to a temporary file.
+func packExtraLayer(src extraLayerSource, info os.FileInfo, spillAt int64) (ocispec.Descriptor, []byte, string, error) {
+	if info.IsDir() {
+		spool := newBlobSpool(spillAt)
+		if err := writeTarGz(spool, src.path); err != nil {
+			spool.discard()
+			return ocispec.Descriptor{}, nil, "", err
+		}
+		return spool.finish(src.mediaType)
+	}
+	if spillAt < 0 || info.Size() <= spillAt {
+		data, err := os.ReadFile(src.path)
+		if err != nil {
+			return ocispec.Descriptor{}, nil, "", err
+		}
+		return blobDescriptor(src.mediaType, data), data, "", nil
+	}
+	f, err := os.Open(src.path)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, "", err
+	}
+	defer f.Close()
+	desc := ocispec.Descriptor{MediaType: src.mediaType}
+	if desc.Digest, err = godigest.SHA256.FromReader(f); err != nil {
+		return ocispec.Descriptor{}, nil, "", err
+	}
+	if desc.Size, err = f.Seek(0, io.SeekCurrent); err != nil {
+		return ocispec.Descriptor{}, nil, "", err
+	}
+	return desc, nil, src.path, nil
+}
+
+// isReservedLayerMediaType reports whether mediaType belongs to the layers
+// Klaus manages itself: content layers, encrypted or not, and changelogs.
+func isReservedLayerMediaType(mediaType string) bool {
+	if mediaType == MediaTypeChangelog {
+		return true
+	}
+	for _, k := range artifactKinds() {
+		if k.ContentMediaType != "" && (mediaType == k.ContentMediaType || mediaType == k.ContentMediaType+MediaTypeEncryptedSuffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// extraLayers returns the extra layers among layers.
+func extraLayers(layers []ocispec.Descriptor) []ExtraLayer {
+	var result []ExtraLayer
+	for _, l := range layers {
+		if !isReservedLayerMediaType(l.MediaType) {
+			result = append(result, extraLayer(l))
+		}
+	}
+	return result
+}
+
+// extraLayer describes the extra layer desc.
+func extraLayer(desc ocispec.Descriptor) ExtraLayer {
+	return ExtraLayer{
+		Name:      desc.Annotations[ocispec.AnnotationTitle],
+		MediaType: desc.MediaType,
+		Digest:    desc.Digest.String(),
+		Size:      desc.Size,
+		Directory: desc.Annotations[AnnotationLayerDirectory] == "true",
+	}
+}
+
+// ExtraLayers lists the layers attached to the artifact at ref with
+// WithExtraLayer. ref must include a tag or digest. Only the manifest is
+// downloaded.
+func (c *Client) ExtraLayers(ctx context.Context, ref string) ([]ExtraLayer, error) {
+	fm, err := c.fetchManifest(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return extraLayers(fm.manifest.Layers), nil
+}
+
+// PullExtraLayers downloads the layers attached to the artifact at ref
+// with WithExtraLayer into destDir and returns them. When mediaTypes are
+// given, only layers of those media types are downloaded. A layer is
+// written to destDir/<Name>; directory layers are extracted there,
+// replacing an existing directory, under the client's extraction policy.
+// ref must include a tag or digest. The content layer is not downloaded.
+func (c *Client) PullExtraLayers(ctx context.Context, ref, destDir string, mediaTypes ...string) ([]ExtraLayer, error) {
+	fm, err := c.fetchManifest(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var pulled []ExtraLayer
+	for _, l := range fm.manifest.Layers {
+		if isReservedLayerMediaType(l.MediaType) || (len(mediaTypes) > 0 && !slices.Contains(mediaTypes, l.MediaType)) {
+			continue
+		}
+		layer := extraLayer(l)
+		if err := checkComponentName(layer.Name); err != nil {
+			return nil, fmt.Errorf("extra layer %s of %s: %w", layer.Digest, ref, err)
+		}
+		if err := c.pullExtraLayer(ctx, fm, l, filepath.Join(destDir, layer.Name), layer.Directory); err != nil {
+			return nil, fmt.Errorf("pulling extra layer %s of %s: %w", layer.Name, ref, err)
+		}
+		pulled = append(pulled, layer)
+	}
+	return pulled, nil
+}
+
+// pullExtraLayer downloads desc to target, extracting it when it was
+// packed from a directory. The layer is staged next to target and moved
+// into place once verified, so a failed or cancelled download leaves an
+// existing target untouched.
+func (c *Client) pullExtraLayer(ctx context.Context, fm *fetchedManifest, desc ocispec.Descriptor, target string, dir bool) error {
+	rc, err := fm.repo.Fetch(ctx, desc)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	vr := content.NewVerifyReader(contextReader{ctx: ctx, r: rc}, desc)
+
+	if dir {
+		staged, err := stagingDir(target)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(staged)
+		if err := extractTarGz(vr, staged, c.extraction); err != nil {
+			return err
+		}
+		// The archive may end before the layer does; read the rest so the
+		// digest is verified.
+		if _, err := io.Copy(io.Discard, vr); err != nil {
+			return err
+		}
+		if err := vr.Verify(); err != nil {
+			return err
+		}
+		return replaceDir(staged, target)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".staging-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = io.Copy(f, vr)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = vr.Verify()
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0o644)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), target)
+}
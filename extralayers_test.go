@@ -0,0 +1,141 @@
+package oci
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testExamplesMediaType = "application/vnd.example.klaus.examples.v1.tar+gzip"
+	testDatasetMediaType  = "application/vnd.example.klaus.dataset.v1+jsonl"
+)
+
+func TestPushPersonality_ExtraLayers(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	ref := host + "/klaus/sre:v1.0.0"
+
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "SOUL.md"), "You are an SRE.")
+	aux := t.TempDir()
+	writeFile(t, filepath.Join(aux, "examples", "config.yaml"), "replicas: 3\n")
+	writeFile(t, filepath.Join(aux, "examples", "nested", "values.yaml"), "debug: true\n")
+	writeFile(t, filepath.Join(aux, "eval.jsonl"), `{"prompt":"why is the pod pending?"}`+"\n")
+
+	_, err := client.PushPersonality(t.Context(), src, ref, Personality{Name: "sre"},
+		WithExtraLayer(filepath.Join(aux, "examples"), testExamplesMediaType),
+		WithExtraLayer(filepath.Join(aux, "eval.jsonl"), testDatasetMediaType),
+		WithChangelog("## v1.0.0\n"))
+	if err != nil {
+		t.Fatalf("PushPersonality() error = %v", err)
+	}
+
+	layers, err := client.ExtraLayers(t.Context(), ref)
+	if err != nil {
+		t.Fatalf("ExtraLayers() error = %v", err)
+	}
+	if len(layers) != 2 {
+		t.Fatalf("ExtraLayers() = %+v, want 2 layers", layers)
+	}
+	if l := layers[0]; l.Name != "examples" || l.MediaType != testExamplesMediaType || !l.Directory {
+		t.Errorf("layers[0] = %+v, want examples directory", l)
+	}
+	if l := layers[1]; l.Name != "eval.jsonl" || l.MediaType != testDatasetMediaType || l.Directory {
+		t.Errorf("layers[1] = %+v, want eval.jsonl file", l)
+	}
+
+	dest := t.TempDir()
+	pulled, err := client.PullExtraLayers(t.Context(), ref, dest, testDatasetMediaType)
+	if err != nil {
+		t.Fatalf("PullExtraLayers() error = %v", err)
+	}
+	if len(pulled) != 1 || pulled[0].Name != "eval.jsonl" {
+		t.Errorf("PullExtraLayers() = %+v, want only eval.jsonl", pulled)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "examples")); !os.IsNotExist(err) {
+		t.Errorf("examples pulled despite media type filter, stat error = %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dest, "eval.jsonl")); !strings.Contains(string(got), "pending") {
+		t.Errorf("eval.jsonl = %q", got)
+	}
+
+	if _, err := client.PullExtraLayers(t.Context(), ref, dest); err != nil {
+		t.Fatalf("PullExtraLayers() error = %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dest, "examples", "nested", "values.yaml")); string(got) != "debug: true\n" {
+		t.Errorf("examples/nested/values.yaml = %q", got)
+	}
+
+	pulledPersonality, err := client.PullPersonality(t.Context(), ref, t.TempDir())
+	if err != nil {
+		t.Fatalf("PullPersonality() error = %v", err)
+	}
+	if pulledPersonality.Soul != "You are an SRE." {
+		t.Errorf("Soul = %q", pulledPersonality.Soul)
+	}
+	if log, err := client.FetchChangelog(t.Context(), ref); err != nil || log != "## v1.0.0\n" {
+		t.Errorf("FetchChangelog() = %q, %v", log, err)
+	}
+}
+
+func TestWithExtraLayers_Invalid(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "SOUL.md"), "soul")
+	built, err := BuildPersonality(src, Personality{Name: "sre"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	aux := t.TempDir()
+	writeFile(t, filepath.Join(aux, "a", "data.json"), "{}")
+	writeFile(t, filepath.Join(aux, "b", "data.json"), "{}")
+
+	tests := []struct {
+		name    string
+		sources []extraLayerSource
+	}{
+		{"no media type", []extraLayerSource{{path: filepath.Join(aux, "a", "data.json")}}},
+		{"content media type", []extraLayerSource{{path: filepath.Join(aux, "a", "data.json"), mediaType: MediaTypePersonalityContent}}},
+		{"changelog media type", []extraLayerSource{{path: filepath.Join(aux, "a", "data.json"), mediaType: MediaTypeChangelog}}},
+		{"missing path", []extraLayerSource{{path: filepath.Join(aux, "missing"), mediaType: testDatasetMediaType}}},
+		{"duplicate name", []extraLayerSource{
+			{path: filepath.Join(aux, "a", "data.json"), mediaType: testDatasetMediaType},
+			{path: filepath.Join(aux, "b", "data.json"), mediaType: testDatasetMediaType},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := withExtraLayers(built, tt.sources, -1); err == nil {
+				t.Error("withExtraLayers() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestWithExtraLayers_Blobs(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "SOUL.md"), "soul")
+	built, err := BuildPersonality(src, Personality{Name: "sre"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := filepath.Join(t.TempDir(), "data.jsonl")
+	writeFile(t, data, "{}\n")
+
+	extended, err := withExtraLayers(built, []extraLayerSource{{path: data, mediaType: testDatasetMediaType}}, -1)
+	if err != nil {
+		t.Fatalf("withExtraLayers() error = %v", err)
+	}
+	if extended.Digest == built.Digest {
+		t.Error("Digest unchanged by extra layer")
+	}
+	if len(built.Manifest.Layers) != 1 {
+		t.Errorf("original manifest modified: %d layers", len(built.Manifest.Layers))
+	}
+	blobs := extended.Blobs()
+	if len(blobs) != 3 || string(blobs[extended.Manifest.Layers[1].Digest.String()]) != "{}\n" {
+		t.Errorf("Blobs() = %d entries, want config, content, and extra layer", len(blobs))
+	}
+}
@@ -0,0 +1,186 @@
+package oci
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// defaultFaultStatus is the status code of injected server errors.
+const defaultFaultStatus = http.StatusServiceUnavailable
+
+// truncateUnknownLength is where bodies of unknown length are cut off.
+const truncateUnknownLength = 512
+
+// FaultPolicy configures the faults WithFaultInjection injects into
+// registry requests. Rates are probabilities between 0 and 1, drawn
+// independently per request.
+type FaultPolicy struct {
+	// Seed seeds the random source. Requests made one after another see
+	// the same faults on every run with the same seed.
+	Seed uint64
+	// ResetRate fails requests with a connection reset before they reach
+	// the registry.
+	ResetRate float64
+	// ErrorRate answers requests with ErrorStatus before they reach the
+	// registry.
+	ErrorRate float64
+	// ErrorStatus is the status code of injected errors. Defaults to 503.
+	ErrorStatus int
+	// LatencyRate delays requests by Latency before sending them.
+	LatencyRate float64
+	Latency     time.Duration
+	// TruncateRate cuts response bodies off halfway, as a dropped
+	// connection would, so reading them fails with io.ErrUnexpectedEOF.
+	TruncateRate float64
+	// Match limits faults to the requests it returns true for. Nil
+	// matches all requests.
+	Match func(*http.Request) bool
+	// MaxFaults stops injecting resets, errors, and truncations after
+	// that many, e.g. to assert recovery after a number of failures. Zero
+	// means no limit.
+	MaxFaults int
+}
+
+// WithFaultInjection wraps the client's HTTP transport to inject the
+// faults of policy: connection resets, server errors, latency, and
+// truncated bodies. It exercises retry, resume, and mirror failover in
+// tests and is not meant for production clients. Faults are injected
+// below request logging, so WithDebugTransport shows them. Clients whose
+// HTTP client was replaced are left unchanged.
+func WithFaultInjection(policy FaultPolicy) ClientOption {
+	return func(c *Client) { c.faults = &policy }
+}
+
+// faultTransport injects the faults of its policy into requests.
+type faultTransport struct {
+	base   http.RoundTripper
+	policy FaultPolicy
+
+	mu       sync.Mutex
+	rng      *rand.Rand
+	injected int
+}
+
+func newFaultTransport(base http.RoundTripper, policy FaultPolicy) *faultTransport {
+	if policy.ErrorStatus == 0 {
+		policy.ErrorStatus = defaultFaultStatus
+	}
+	return &faultTransport{
+		base:   base,
+		policy: policy,
+		rng:    rand.New(rand.NewPCG(policy.Seed, policy.Seed)),
+	}
+}
+
+// faultPlan is the set of faults drawn for one request.
+type faultPlan struct {
+	reset, fail, delay, truncate bool
+}
+
+// plan draws the faults of one request. Every matched request draws the
+// same number of values, so the sequence only depends on the seed and the
+// order of requests.
+func (t *faultTransport) plan(req *http.Request) faultPlan {
+	if t.policy.Match != nil && !t.policy.Match(req) {
+		return faultPlan{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p := faultPlan{
+		reset:    t.rng.Float64() < t.policy.ResetRate,
+		fail:     t.rng.Float64() < t.policy.ErrorRate,
+		delay:    t.rng.Float64() < t.policy.LatencyRate,
+		truncate: t.rng.Float64() < t.policy.TruncateRate,
+	}
+	// Only the first fault applies; resets preempt errors and errors
+	// preempt truncation.
+	p.fail = p.fail && !p.reset
+	p.truncate = p.truncate && !p.reset && !p.fail
+	if p.reset || p.fail || p.truncate {
+		if t.policy.MaxFaults > 0 && t.injected >= t.policy.MaxFaults {
+			p.reset, p.fail, p.truncate = false, false, false
+		} else {
+			t.injected++
+		}
+	}
+	return p
+}
+
+func (t *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := t.plan(req)
+	if p.delay && t.policy.Latency > 0 {
+		timer := time.NewTimer(t.policy.Latency)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	if p.reset {
+		return nil, &net.OpError{Op: "read", Net: "tcp", Addr: faultAddr(req.URL.Host), Err: syscall.ECONNRESET}
+	}
+	if p.fail {
+		return faultResponse(req, t.policy.ErrorStatus), nil
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !p.truncate || resp.ContentLength == 0 || req.Method == http.MethodHead {
+		return resp, err
+	}
+	limit := int64(truncateUnknownLength)
+	if resp.ContentLength > 0 {
+		limit = resp.ContentLength / 2
+	}
+	resp.Body = &truncatedBody{ReadCloser: resp.Body, remaining: limit}
+	return resp, nil
+}
+
+// faultResponse is an injected registry error response.
+func faultResponse(req *http.Request, status int) *http.Response {
+	body := fmt.Sprintf(`{"errors":[{"code":"UNAVAILABLE","message":"injected fault: %s"}]}`, http.StatusText(status))
+	return &http.Response{
+		Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader([]byte(body))),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// faultAddr is the remote address reported by injected connection resets.
+type faultAddr string
+
+func (a faultAddr) Network() string { return "tcp" }
+func (a faultAddr) String() string  { return string(a) }
+
+// truncatedBody ends a response body with io.ErrUnexpectedEOF after
+// remaining bytes.
+type truncatedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
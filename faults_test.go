@@ -0,0 +1,179 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// staticTransport answers every request with 200 and body.
+type staticTransport struct{ body []byte }
+
+func (s staticTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{},
+		Body:          io.NopCloser(bytes.NewReader(s.body)),
+		ContentLength: int64(len(s.body)),
+		Request:       req,
+	}, nil
+}
+
+// faultOutcome classifies the result of one request through t.
+func faultOutcome(t *testing.T, rt http.RoundTripper) string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "http://registry.example.com/v2/klaus/sre/blobs/sha256:abc", nil)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		return "reset"
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "error"
+	}
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		return "truncated"
+	}
+	return "ok"
+}
+
+func TestFaultTransport_Deterministic(t *testing.T) {
+	policy := FaultPolicy{Seed: 7, ResetRate: 0.2, ErrorRate: 0.2, TruncateRate: 0.3}
+	base := staticTransport{body: bytes.Repeat([]byte("x"), 100)}
+
+	run := func(p FaultPolicy) []string {
+		rt := newFaultTransport(base, p)
+		var outcomes []string
+		for range 50 {
+			outcomes = append(outcomes, faultOutcome(t, rt))
+		}
+		return outcomes
+	}
+	first, second := run(policy), run(policy)
+	if strings.Join(first, ",") != strings.Join(second, ",") {
+		t.Errorf("runs with the same seed differ:\n%v\n%v", first, second)
+	}
+	for _, want := range []string{"ok", "reset", "error", "truncated"} {
+		if !strings.Contains(strings.Join(first, ","), want) {
+			t.Errorf("outcomes %v never %s", first, want)
+		}
+	}
+	policy.Seed = 8
+	if strings.Join(run(policy), ",") == strings.Join(first, ",") {
+		t.Error("runs with different seeds are identical")
+	}
+}
+
+func TestFaultTransport_Faults(t *testing.T) {
+	base := staticTransport{body: bytes.Repeat([]byte("x"), 100)}
+	req := func() *http.Request {
+		return httptest.NewRequest(http.MethodGet, "http://registry.example.com/v2/", nil)
+	}
+
+	_, err := newFaultTransport(base, FaultPolicy{ResetRate: 1}).RoundTrip(req())
+	var netErr net.Error
+	if !errors.Is(err, syscall.ECONNRESET) || !errors.As(err, &netErr) {
+		t.Errorf("reset error = %v, want a network connection reset", err)
+	}
+
+	resp, err := newFaultTransport(base, FaultPolicy{ErrorRate: 1, ErrorStatus: http.StatusBadGateway}).RoundTrip(req())
+	if err != nil || resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("error response = %v, %v, want 502", resp, err)
+	}
+
+	resp, err = newFaultTransport(base, FaultPolicy{TruncateRate: 1}).RoundTrip(req())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if !errors.Is(err, io.ErrUnexpectedEOF) || len(data) != 50 {
+		t.Errorf("truncated body = %d bytes, %v, want 50 bytes and io.ErrUnexpectedEOF", len(data), err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err = newFaultTransport(base, FaultPolicy{LatencyRate: 1, Latency: time.Hour}).RoundTrip(req().WithContext(ctx))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("delayed request error = %v, want context.Canceled", err)
+	}
+}
+
+func TestFaultTransport_MatchAndMaxFaults(t *testing.T) {
+	rt := newFaultTransport(staticTransport{}, FaultPolicy{
+		ErrorRate: 1,
+		MaxFaults: 2,
+		Match:     func(r *http.Request) bool { return r.URL.Path != "/v2/" },
+	})
+
+	var got []int
+	for _, path := range []string{"/v2/", "/v2/a", "/v2/b", "/v2/c"} {
+		resp, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://registry.example.com"+path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		got = append(got, resp.StatusCode)
+	}
+	if !slices.Equal(got, []int{200, 503, 503, 200}) {
+		t.Errorf("statuses = %v, want the two matched requests to fail", got)
+	}
+}
+
+func TestWithFaultInjection_ResumesTruncatedLayer(t *testing.T) {
+	reg := newCacheRegistry()
+	blob, _ := json.Marshal(pluginConfigBlob{})
+	addPullableArtifact(t, reg, "klaus-plugins/p", "v1.0.0", pluginArtifact, blob, map[string]string{
+		"data.bin": string(randomBytes(t, 64*1024)),
+	})
+	var layer string
+	for digest, data := range reg.blobs {
+		if len(data) > 1024 {
+			layer = digest
+		}
+	}
+	host := newPullTestRegistry(t, reg)
+	ref := host + "/klaus-plugins/p:v1.0.0"
+	isLayer := func(r *http.Request) bool { return strings.HasSuffix(r.URL.Path, "/blobs/"+layer) }
+
+	client := NewClient(WithPlainHTTP(true), WithFaultInjection(FaultPolicy{TruncateRate: 1, MaxFaults: maxResumeAttempts - 1, Match: isLayer}))
+	if _, err := client.PullPlugin(t.Context(), ref, filepath.Join(t.TempDir(), "p")); err != nil {
+		t.Fatalf("PullPlugin() with recoverable truncations error = %v", err)
+	}
+
+	client = NewClient(WithPlainHTTP(true), WithFaultInjection(FaultPolicy{TruncateRate: 1, Match: isLayer}))
+	_, err := client.PullPlugin(t.Context(), ref, filepath.Join(t.TempDir(), "p"))
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("PullPlugin() with persistent truncation error = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestWithFaultInjection_MirrorFailover(t *testing.T) {
+	mirror := newCacheRegistry()
+	mirrorHost := newPullTestRegistry(t, mirror)
+	digest := pushVersions(t, NewClient(WithPlainHTTP(true)), mirrorHost+"/klaus/sre", "v1.0.0")["v1.0.0"]
+	primaryHost := newPullTestRegistry(t, newCacheRegistry())
+
+	client := NewClient(
+		WithPlainHTTP(true),
+		WithMirrors(primaryHost+"/klaus", mirrorHost+"/klaus"),
+		WithFaultInjection(FaultPolicy{ResetRate: 1, Match: func(r *http.Request) bool { return r.URL.Host == primaryHost }}),
+	)
+	p, err := client.PullPersonality(t.Context(), primaryHost+"/klaus/sre@"+digest, t.TempDir())
+	if err != nil {
+		t.Fatalf("PullPersonality() error = %v", err)
+	}
+	if p.Digest != digest {
+		t.Errorf("pulled %s, want %s", p.Digest, digest)
+	}
+}
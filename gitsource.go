@@ -0,0 +1,119 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// PushPluginFromGit packages the plugin at revision of the git repository
+// gitURL and pushes it to ref, so plugin repositories do not need their
+// own packaging pipeline. revision is a branch, tag, or commit; empty
+// selects the remote's default branch. The plugin is read with
+// ReadPluginFromDir from the repository root; its source repository
+// defaults to gitURL, without credentials.
+//
+// The repository is fetched with the git command, which must be on PATH,
+// and uses git's own credential configuration. Only the requested commit
+// is fetched when the remote allows it. The git metadata is not part of
+// the pushed artifact.
+func (c *Client) PushPluginFromGit(ctx context.Context, gitURL, revision, ref string, opts ...PushOption) (*PushResult, error) {
+	if gitURL == "" {
+		return nil, fmt.Errorf("git URL is required")
+	}
+	if strings.HasPrefix(gitURL, "-") || strings.HasPrefix(revision, "-") {
+		return nil, fmt.Errorf("invalid git source %q at %q", gitURL, revision)
+	}
+
+	dir, err := os.MkdirTemp("", "klaus-plugin-git-")
+	if err != nil {
+		return nil, fmt.Errorf("creating checkout directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := gitCheckout(ctx, gitURL, revision, dir); err != nil {
+		return nil, err
+	}
+	if err := os.RemoveAll(filepath.Join(dir, ".git")); err != nil {
+		return nil, fmt.Errorf("removing git metadata: %w", err)
+	}
+
+	plugin, err := ReadPluginFromDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading plugin from %s: %w", redactGitURL(gitURL), err)
+	}
+	if plugin.Name == "" {
+		return nil, fmt.Errorf("reading plugin from %s: plugin.json: name is required", redactGitURL(gitURL))
+	}
+	if plugin.SourceRepo == "" {
+		plugin.SourceRepo = redactGitURL(gitURL)
+	}
+	return c.PushPlugin(ctx, dir, ref, *plugin, opts...)
+}
+
+// gitCheckout checks out revision of the repository at gitURL into dir.
+// It fetches only the requested commit and falls back to fetching all
+// branches and tags for remotes that refuse shallow fetches of arbitrary
+// commits.
+func gitCheckout(ctx context.Context, gitURL, revision, dir string) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("fetching %s: git is not installed: %w", redactGitURL(gitURL), err)
+	}
+	if err := runGit(ctx, dir, "init", "--quiet"); err != nil {
+		return err
+	}
+
+	target := revision
+	if target == "" {
+		target = "HEAD"
+	}
+	if err := runGit(ctx, dir, "fetch", "--quiet", "--depth=1", "--", gitURL, target); err == nil {
+		return runGit(ctx, dir, "checkout", "--quiet", "--detach", "FETCH_HEAD")
+	}
+
+	if err := runGit(ctx, dir, "fetch", "--quiet", "--", gitURL,
+		"+refs/heads/*:refs/remotes/origin/*", "+refs/tags/*:refs/tags/*"); err != nil {
+		return fmt.Errorf("fetching %s: %w", redactGitURL(gitURL), err)
+	}
+	if revision == "" {
+		return fmt.Errorf("fetching %s: cannot determine the default branch", redactGitURL(gitURL))
+	}
+	for _, candidate := range []string{revision, "origin/" + revision} {
+		if err := runGit(ctx, dir, "checkout", "--quiet", "--detach", candidate); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("fetching %s: revision %q not found", redactGitURL(gitURL), revision)
+}
+
+// runGit runs git in dir, never prompting for credentials. Errors carry
+// git's diagnostic output.
+func runGit(ctx context.Context, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("git %s: %w: %s", args[0], err, msg)
+		}
+		return fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// redactGitURL removes credentials from gitURL for messages and metadata.
+func redactGitURL(gitURL string) string {
+	u, err := url.Parse(gitURL)
+	if err != nil || u.User == nil {
+		return gitURL
+	}
+	u.User = nil
+	return u.String()
+}
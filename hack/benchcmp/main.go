@@ -0,0 +1,140 @@
+// Command benchcmp compares two sets of Go benchmark results and fails
+// when a benchmark got slower than a threshold allows. It gates
+// performance regressions in CI:
+//
+//	git worktree add ../base main
+//	(cd ../base && go test -run '^$' -bench . -count 6 ./...) > old.txt
+//	go test -run '^$' -bench . -count 6 ./... > new.txt
+//	go run ./hack/benchcmp old.txt new.txt
+//
+// Each benchmark is compared by the median of its ns/op samples, which is
+// robust against single noisy runs. Benchmarks present in only one file
+// are listed but never fail the comparison.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// benchLine matches a benchmark result line and captures the name without
+// its GOMAXPROCS suffix and the ns/op value.
+var benchLine = regexp.MustCompile(`^(Benchmark\S+?)(?:-\d+)?\s+\d+\s+([\d.]+) ns/op`)
+
+func main() {
+	threshold := flag.Float64("threshold", 0.25, "maximum allowed slowdown as a fraction, e.g. 0.25 for 25%")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: benchcmp [-threshold f] old.txt new.txt\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	old, err := parseFile(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	cur, err := parseFile(flag.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if regressed := compare(os.Stdout, old, cur, *threshold); len(regressed) > 0 {
+		fmt.Fprintf(os.Stderr, "%d benchmark(s) regressed by more than %.0f%%: %s\n", len(regressed), *threshold*100, strings.Join(regressed, ", "))
+		os.Exit(1)
+	}
+}
+
+func parseFile(path string) (map[string][]float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	results, err := parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return results, nil
+}
+
+// parse collects the ns/op samples of each benchmark in r.
+func parse(r io.Reader) (map[string][]float64, error) {
+	results := map[string][]float64{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		m := benchLine.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		v, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %q: %w", scanner.Text(), err)
+		}
+		results[m[1]] = append(results[m[1]], v)
+	}
+	return results, scanner.Err()
+}
+
+// compare writes a table of the median ns/op of each benchmark in old and
+// cur to w, and returns the benchmarks that slowed down by more than
+// threshold.
+func compare(w io.Writer, old, cur map[string][]float64, threshold float64) []string {
+	names := make([]string, 0, len(old)+len(cur))
+	for name := range old {
+		names = append(names, name)
+	}
+	for name := range cur {
+		if _, ok := old[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var regressed []string
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "benchmark\told ns/op\tnew ns/op\tdelta\t")
+	for _, name := range names {
+		o, oldOK := old[name]
+		c, curOK := cur[name]
+		switch {
+		case !curOK:
+			fmt.Fprintf(tw, "%s\t%.0f\t-\tremoved\t\n", name, median(o))
+		case !oldOK:
+			fmt.Fprintf(tw, "%s\t-\t%.0f\tadded\t\n", name, median(c))
+		default:
+			om, cm := median(o), median(c)
+			delta := cm/om - 1
+			mark := ""
+			if delta > threshold {
+				mark = "REGRESSION"
+				regressed = append(regressed, name)
+			}
+			fmt.Fprintf(tw, "%s\t%.0f\t%.0f\t%+.1f%%\t%s\n", name, om, cm, delta*100, mark)
+		}
+	}
+	tw.Flush()
+	return regressed
+}
+
+// median returns the median of samples, which must not be empty.
+func median(samples []float64) float64 {
+	s := slices.Clone(samples)
+	slices.Sort(s)
+	if len(s)%2 == 1 {
+		return s[len(s)/2]
+	}
+	return (s[len(s)/2-1] + s[len(s)/2]) / 2
+}
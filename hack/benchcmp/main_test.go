@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const oldResults = `goos: linux
+goarch: amd64
+pkg: github.com/giantswarm/klaus-oci
+BenchmarkCreateTarGz/small-8     	      70	  16000000 ns/op	  24.21 MB/s	 8396992 B/op	    4110 allocs/op
+BenchmarkCreateTarGz/small-8     	      70	  17000000 ns/op	  24.21 MB/s	 8396992 B/op	    4110 allocs/op
+BenchmarkCreateTarGz/small-8     	      70	  90000000 ns/op	  24.21 MB/s	 8396992 B/op	    4110 allocs/op
+BenchmarkListPlugins/repos=100-8 	     120	   9500000 ns/op
+BenchmarkRemoved                 	    1000	      1000 ns/op
+PASS
+`
+
+const newResults = `BenchmarkCreateTarGz/small-8     	      70	  16500000 ns/op
+BenchmarkCreateTarGz/small-8     	      70	  16900000 ns/op
+BenchmarkListPlugins/repos=100-8 	      80	  14000000 ns/op
+BenchmarkAdded                   	    1000	      1000 ns/op
+`
+
+func TestParse(t *testing.T) {
+	got, err := parse(strings.NewReader(oldResults))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("parse() = %v, want 3 benchmarks", got)
+	}
+	if !slices.Equal(got["BenchmarkCreateTarGz/small"], []float64{16e6, 17e6, 90e6}) {
+		t.Errorf("samples = %v", got["BenchmarkCreateTarGz/small"])
+	}
+}
+
+func TestCompare(t *testing.T) {
+	old, _ := parse(strings.NewReader(oldResults))
+	cur, _ := parse(strings.NewReader(newResults))
+
+	var out bytes.Buffer
+	regressed := compare(&out, old, cur, 0.25)
+	if !slices.Equal(regressed, []string{"BenchmarkListPlugins/repos=100"}) {
+		t.Errorf("regressed = %v, want only the listing benchmark", regressed)
+	}
+	for _, want := range []string{"removed", "added", "+47.4%", "REGRESSION"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output misses %q:\n%s", want, out.String())
+		}
+	}
+	if regressed := compare(&out, old, cur, 0.5); len(regressed) != 0 {
+		t.Errorf("regressed = %v with a 50%% threshold, want none", regressed)
+	}
+}
+
+func TestMedian(t *testing.T) {
+	if got := median([]float64{3, 1, 2}); got != 2 {
+		t.Errorf("median() = %v, want 2", got)
+	}
+	if got := median([]float64{4, 1, 3, 2}); got != 2.5 {
+		t.Errorf("median() = %v, want 2.5", got)
+	}
+}
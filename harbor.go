@@ -0,0 +1,356 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// WithHarborAPI enables Harbor's native REST API (/api/v2.0) for registries
+// detected as Harbor. When enabled, repository listing is filtered
+// server-side by the Harbor project API instead of scanning the catalog,
+// WithLabel list filters are evaluated by Harbor, and HarborQuota becomes
+// available. Registries that are not Harbor keep using the OCI catalog.
+// Defaults to off.
+func WithHarborAPI(enabled bool) ClientOption {
+	return func(c *Client) { c.harborAPI = enabled }
+}
+
+// WithLabel restricts listing to repositories that contain at least one
+// artifact carrying the given Harbor label. Label filtering is evaluated by
+// Harbor and requires WithHarborAPI; listing fails against other registries.
+func WithLabel(label string) ListOption {
+	return func(cfg *listConfig) { cfg.label = label }
+}
+
+// RegistryQuota reports the storage quota of a Harbor project in bytes.
+// A negative Hard value means the project has no storage limit.
+type RegistryQuota struct {
+	Project string
+	Hard    int64
+	Used    int64
+}
+
+// HarborQuota returns the storage quota of the Harbor project that hosts
+// registryBase (e.g. "harbor.example.com/klaus/plugins" queries project
+// "klaus"). Requires WithHarborAPI and a Harbor registry.
+func (c *Client) HarborQuota(ctx context.Context, registryBase string) (*RegistryQuota, error) {
+	host, prefix := SplitRegistryBase(registryBase)
+	h, err := c.harborFor(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	project, _, _ := strings.Cut(prefix, "/")
+	if project == "" {
+		return nil, fmt.Errorf("registry base %q must include a Harbor project", registryBase)
+	}
+	return h.quota(ctx, project)
+}
+
+// harborFor returns a Harbor API client for host, or an error when the
+// Harbor API is disabled or host is not a Harbor registry.
+func (c *Client) harborFor(ctx context.Context, host string) (*harborClient, error) {
+	if !c.harborAPI {
+		return nil, fmt.Errorf("harbor API is disabled (see WithHarborAPI)")
+	}
+	if !c.isHarbor(ctx, host) {
+		return nil, fmt.Errorf("registry %s is not a Harbor registry", host)
+	}
+	return c.newHarborClient(host), nil
+}
+
+// isHarbor probes /api/version on host and caches the answer. Only
+// successful probes are cached so transient failures are retried.
+func (c *Client) isHarbor(ctx context.Context, host string) bool {
+	if v, ok := c.harborHosts.Load(host); ok {
+		return v.(bool)
+	}
+	ok, err := c.newHarborClient(host).detect(ctx)
+	if err != nil {
+		return false
+	}
+	c.harborHosts.Store(host, ok)
+	return ok
+}
+
+func (c *Client) newHarborClient(host string) *harborClient {
+	scheme := "https"
+	if c.plainHTTP {
+		scheme = "http"
+	}
+	httpClient := c.authClient.Client
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &harborClient{
+		host:       host,
+		baseURL:    scheme + "://" + host,
+		httpClient: httpClient,
+		credential: c.authClient.Credential,
+		userAgent:  c.userAgent(),
+	}
+}
+
+// harborClient is a minimal client for the Harbor v2.0 REST API. It reuses
+// the registry credentials: Harbor accepts the same basic auth for its API
+// as for the distribution endpoints.
+type harborClient struct {
+	host       string
+	baseURL    string
+	httpClient *http.Client
+	credential auth.CredentialFunc
+	userAgent  string
+}
+
+// detect reports whether the host serves Harbor's API version endpoint.
+func (h *harborClient) detect(ctx context.Context) (bool, error) {
+	var v struct {
+		Version string `json:"version"`
+	}
+	err := h.getJSON(ctx, "/api/version", &v)
+	if err != nil {
+		if isHTTPNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return strings.HasPrefix(v.Version, "v2"), nil
+}
+
+// Repositories implements DiscoveryProvider using the Harbor project
+// repositories API, filtered server-side by name.
+func (h *harborClient) Repositories(ctx context.Context, registryBase string) ([]string, error) {
+	_, prefix := SplitRegistryBase(registryBase)
+	project, rest, _ := strings.Cut(prefix, "/")
+	if project == "" {
+		return nil, fmt.Errorf("registry base %q must include a Harbor project", registryBase)
+	}
+
+	q := url.Values{}
+	q.Set("page_size", "100")
+	if rest != "" {
+		q.Set("q", "name=~"+project+"/"+rest)
+	}
+	path := "/api/v2.0/projects/" + url.PathEscape(project) + "/repositories?" + q.Encode()
+
+	var repos []string
+	err := h.paginate(ctx, path, func(dec *json.Decoder) error {
+		var page []struct {
+			Name string `json:"name"`
+		}
+		if err := dec.Decode(&page); err != nil {
+			return err
+		}
+		for _, r := range page {
+			if strings.HasPrefix(r.Name, prefix) {
+				repos = append(repos, h.host+"/"+r.Name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(repos)
+	return repos, nil
+}
+
+// hasLabeledArtifact reports whether any artifact in repository (a full
+// "host/project/path" name) carries the label with the given ID.
+func (h *harborClient) hasLabeledArtifact(ctx context.Context, repository string, labelID int64) (bool, error) {
+	_, name := splitHostPath(repository)
+	project, repoPath, _ := strings.Cut(name, "/")
+	q := url.Values{}
+	q.Set("q", fmt.Sprintf("labels=(%d)", labelID))
+	q.Set("page_size", "1")
+	path := "/api/v2.0/projects/" + url.PathEscape(project) +
+		"/repositories/" + url.PathEscape(url.PathEscape(repoPath)) + "/artifacts?" + q.Encode()
+
+	var artifacts []json.RawMessage
+	if err := h.getJSON(ctx, path, &artifacts); err != nil {
+		return false, err
+	}
+	return len(artifacts) > 0, nil
+}
+
+// labelIDs returns the IDs of global and project-scoped labels with the
+// given name. Harbor filters artifacts by label ID, not name.
+func (h *harborClient) labelIDs(ctx context.Context, project, label string) ([]int64, error) {
+	type harborLabel struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	var ids []int64
+	collect := func(labels []harborLabel) {
+		for _, l := range labels {
+			if l.Name == label {
+				ids = append(ids, l.ID)
+			}
+		}
+	}
+
+	var global []harborLabel
+	if err := h.getJSON(ctx, "/api/v2.0/labels?scope=g&name="+url.QueryEscape(label), &global); err != nil {
+		return nil, err
+	}
+	collect(global)
+
+	projectID, err := h.projectID(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	var scoped []harborLabel
+	path := fmt.Sprintf("/api/v2.0/labels?scope=p&project_id=%d&name=%s", projectID, url.QueryEscape(label))
+	if err := h.getJSON(ctx, path, &scoped); err != nil {
+		return nil, err
+	}
+	collect(scoped)
+
+	return ids, nil
+}
+
+func (h *harborClient) projectID(ctx context.Context, project string) (int64, error) {
+	var p struct {
+		ProjectID int64 `json:"project_id"`
+	}
+	if err := h.getJSON(ctx, "/api/v2.0/projects/"+url.PathEscape(project), &p); err != nil {
+		return 0, fmt.Errorf("looking up Harbor project %s: %w", project, err)
+	}
+	return p.ProjectID, nil
+}
+
+func (h *harborClient) quota(ctx context.Context, project string) (*RegistryQuota, error) {
+	id, err := h.projectID(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	var quotas []struct {
+		Hard map[string]int64 `json:"hard"`
+		Used map[string]int64 `json:"used"`
+	}
+	path := fmt.Sprintf("/api/v2.0/quotas?reference=project&reference_id=%d", id)
+	if err := h.getJSON(ctx, path, &quotas); err != nil {
+		return nil, fmt.Errorf("querying quota for Harbor project %s: %w", project, err)
+	}
+	if len(quotas) == 0 {
+		return nil, fmt.Errorf("no quota found for Harbor project %s", project)
+	}
+	return &RegistryQuota{
+		Project: project,
+		Hard:    quotas[0].Hard["storage"],
+		Used:    quotas[0].Used["storage"],
+	}, nil
+}
+
+// getJSON issues a GET against the Harbor API and decodes the response.
+func (h *harborClient) getJSON(ctx context.Context, path string, v any) error {
+	resp, err := h.get(ctx, h.baseURL+path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 32*1024*1024)).Decode(v); err != nil {
+		return fmt.Errorf("parsing response from %s: %w", path, err)
+	}
+	return nil
+}
+
+// paginate follows Harbor's Link headers, handing each page to fn.
+func (h *harborClient) paginate(ctx context.Context, path string, fn func(*json.Decoder) error) error {
+	next := h.baseURL + path
+	for next != "" {
+		resp, err := h.get(ctx, next)
+		if err != nil {
+			return err
+		}
+		derr := fn(json.NewDecoder(io.LimitReader(resp.Body, 32*1024*1024)))
+		resp.Body.Close()
+		if derr != nil {
+			return fmt.Errorf("parsing response from %s: %w", next, derr)
+		}
+		next = parseNextLink(resp.Header.Get("Link"), next)
+	}
+	return nil
+}
+
+func (h *harborClient) get(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", h.userAgent)
+	if h.credential != nil {
+		cred, err := h.credential(ctx, h.host)
+		if err == nil && cred.Username != "" {
+			req.SetBasicAuth(cred.Username, cred.Password)
+		}
+	}
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", u, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, &httpStatusError{URL: u, StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return resp, nil
+}
+
+// filterByLabel keeps only repositories that Harbor reports as containing
+// an artifact labeled with label. Repositories are checked concurrently,
+// bounded by the client's concurrency limit; order is preserved.
+func (c *Client) filterByLabel(ctx context.Context, registryBase string, repos []string, label string) ([]string, error) {
+	host, prefix := SplitRegistryBase(registryBase)
+	h, err := c.harborFor(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("filtering by label %q: %w", label, err)
+	}
+	project, _, _ := strings.Cut(prefix, "/")
+	ids, err := h.labelIDs(ctx, project, label)
+	if err != nil {
+		return nil, fmt.Errorf("looking up label %q: %w", label, err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	keep := make([]bool, len(repos))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+	for i, repo := range repos {
+		g.Go(func() error {
+			for _, id := range ids {
+				ok, err := h.hasLabeledArtifact(gctx, repo, id)
+				if err != nil {
+					return fmt.Errorf("searching artifacts in %s: %w", repo, err)
+				}
+				if ok {
+					keep[i] = true
+					return nil
+				}
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var filtered []string
+	for i, repo := range repos {
+		if keep[i] {
+			filtered = append(filtered, repo)
+		}
+	}
+	return filtered, nil
+}
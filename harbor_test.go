@@ -0,0 +1,245 @@
+package oci
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeHarbor serves the subset of the Harbor v2.0 API used by the client
+// plus the distribution tag listing endpoint. Catalog requests fail so tests
+// prove listing goes through the Harbor API.
+type fakeHarbor struct {
+	project   string
+	projectID int64
+	repos     map[string][]string // "project/path" -> tags
+	labeled   map[string]bool     // "project/path" -> has artifact with label 7
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func (f *fakeHarbor) requested(path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, p := range f.requests {
+		if p == path {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakeHarbor) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.requests = append(f.requests, r.URL.Path)
+		f.mu.Unlock()
+		writeJSON := func(v any) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(v)
+		}
+		switch {
+		case r.URL.Path == "/api/version":
+			writeJSON(map[string]string{"version": "v2.0"})
+
+		case r.URL.Path == "/v2/_catalog":
+			http.Error(w, "catalog disabled", http.StatusForbidden)
+
+		case r.URL.Path == "/api/v2.0/projects/"+f.project+"/repositories":
+			q := strings.TrimPrefix(r.URL.Query().Get("q"), "name=~")
+			var out []map[string]string
+			for name := range f.repos {
+				if strings.Contains(name, q) {
+					out = append(out, map[string]string{"name": name})
+				}
+			}
+			writeJSON(out)
+
+		case r.URL.Path == "/api/v2.0/projects/"+f.project:
+			writeJSON(map[string]int64{"project_id": f.projectID})
+
+		case r.URL.Path == "/api/v2.0/labels":
+			if r.URL.Query().Get("scope") == "p" && r.URL.Query().Get("name") == "stable" {
+				writeJSON([]map[string]any{{"id": 7, "name": "stable"}})
+				return
+			}
+			writeJSON([]map[string]any{})
+
+		case strings.HasPrefix(r.URL.Path, "/api/v2.0/projects/"+f.project+"/repositories/") && strings.HasSuffix(r.URL.Path, "/artifacts"):
+			repo := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v2.0/projects/"+f.project+"/repositories/"), "/artifacts")
+			repo = strings.ReplaceAll(repo, "%2F", "/")
+			if r.URL.Query().Get("q") == "labels=(7)" && f.labeled[f.project+"/"+repo] {
+				writeJSON([]map[string]string{{"digest": "sha256:abc"}})
+				return
+			}
+			writeJSON([]map[string]string{})
+
+		case r.URL.Path == "/api/v2.0/quotas":
+			if r.URL.Query().Get("reference_id") != "42" {
+				writeJSON([]any{})
+				return
+			}
+			writeJSON([]map[string]any{{
+				"hard": map[string]int64{"storage": 1000},
+				"used": map[string]int64{"storage": 250},
+			}})
+
+		case strings.HasSuffix(r.URL.Path, "/tags/list"):
+			name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v2/"), "/tags/list")
+			tags, ok := f.repos[name]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			writeJSON(map[string]any{"name": name, "tags": tags})
+
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func newFakeHarbor() *fakeHarbor {
+	return &fakeHarbor{
+		project:   "klaus",
+		projectID: 42,
+		repos: map[string][]string{
+			"klaus/plugins/gs-base":     {"v1.0.0"},
+			"klaus/plugins/gs-platform": {"v0.1.0", "v0.2.0"},
+			"klaus/personalities/sre":   {"v1.0.0"},
+		},
+		labeled: map[string]bool{
+			"klaus/plugins/gs-platform": true,
+		},
+	}
+}
+
+func TestListPlugins_HarborAPI(t *testing.T) {
+	f := newFakeHarbor()
+	ts := httptest.NewServer(f.handler())
+	defer ts.Close()
+	host := testRegistryHost(ts)
+
+	client := NewClient(WithPlainHTTP(true), WithHarborAPI(true))
+
+	entries, err := client.ListPlugins(t.Context(), WithRegistry(host+"/klaus/plugins"))
+	if err != nil {
+		t.Fatalf("ListPlugins() error = %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name+"@"+e.Version)
+	}
+	want := []string{"gs-base@v1.0.0", "gs-platform@v0.2.0"}
+	if !slices.Equal(names, want) {
+		t.Errorf("entries = %v, want %v", names, want)
+	}
+	if f.requested("/v2/_catalog") > 0 {
+		t.Error("catalog was queried despite Harbor API being enabled")
+	}
+}
+
+func TestListPlugins_HarborLabel(t *testing.T) {
+	f := newFakeHarbor()
+	ts := httptest.NewServer(f.handler())
+	defer ts.Close()
+	host := testRegistryHost(ts)
+
+	client := NewClient(WithPlainHTTP(true), WithHarborAPI(true))
+
+	entries, err := client.ListPlugins(t.Context(), WithRegistry(host+"/klaus/plugins"), WithLabel("stable"))
+	if err != nil {
+		t.Fatalf("ListPlugins() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name != "gs-platform" {
+		t.Errorf("entries = %+v, want only gs-platform", entries)
+	}
+
+	entries, err = client.ListPlugins(t.Context(), WithRegistry(host+"/klaus/plugins"), WithLabel("unknown"))
+	if err != nil {
+		t.Fatalf("ListPlugins() error = %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("entries = %+v, want none for unknown label", entries)
+	}
+}
+
+func TestWithLabel_RequiresHarbor(t *testing.T) {
+	ts := newTestRegistry(map[string][]string{
+		"klaus/plugins/gs-base": {"v1.0.0"},
+	})
+	defer ts.Close()
+	host := testRegistryHost(ts)
+
+	t.Run("api disabled", func(t *testing.T) {
+		client := NewClient(WithPlainHTTP(true))
+		_, err := client.ListPlugins(t.Context(), WithRegistry(host+"/klaus/plugins"), WithLabel("stable"))
+		if err == nil {
+			t.Fatal("expected error when Harbor API is disabled")
+		}
+	})
+
+	t.Run("not a harbor registry", func(t *testing.T) {
+		client := NewClient(WithPlainHTTP(true), WithHarborAPI(true))
+		_, err := client.ListPlugins(t.Context(), WithRegistry(host+"/klaus/plugins"), WithLabel("stable"))
+		if err == nil || !strings.Contains(err.Error(), "not a Harbor registry") {
+			t.Fatalf("error = %v, want not a Harbor registry", err)
+		}
+	})
+
+	t.Run("plain listing still uses catalog", func(t *testing.T) {
+		client := NewClient(WithPlainHTTP(true), WithHarborAPI(true))
+		entries, err := client.ListPlugins(t.Context(), WithRegistry(host+"/klaus/plugins"))
+		if err != nil {
+			t.Fatalf("ListPlugins() error = %v", err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("entries = %+v, want 1", entries)
+		}
+	})
+}
+
+func TestHarborQuota(t *testing.T) {
+	f := newFakeHarbor()
+	ts := httptest.NewServer(f.handler())
+	defer ts.Close()
+	host := testRegistryHost(ts)
+
+	client := NewClient(WithPlainHTTP(true), WithHarborAPI(true))
+
+	q, err := client.HarborQuota(t.Context(), host+"/klaus/plugins")
+	if err != nil {
+		t.Fatalf("HarborQuota() error = %v", err)
+	}
+	if q.Project != "klaus" || q.Hard != 1000 || q.Used != 250 {
+		t.Errorf("quota = %+v, want klaus 1000/250", q)
+	}
+
+	if _, err := client.HarborQuota(t.Context(), host); err == nil {
+		t.Error("expected error for base without project")
+	}
+}
+
+func TestIsHarbor_CachesDetection(t *testing.T) {
+	f := newFakeHarbor()
+	ts := httptest.NewServer(f.handler())
+	defer ts.Close()
+	host := testRegistryHost(ts)
+
+	client := NewClient(WithPlainHTTP(true), WithHarborAPI(true))
+	for range 3 {
+		if !client.isHarbor(t.Context(), host) {
+			t.Fatal("isHarbor() = false, want true")
+		}
+	}
+
+	if probes := f.requested("/api/version"); probes != 1 {
+		t.Errorf("version endpoint probed %d times, want 1", probes)
+	}
+}
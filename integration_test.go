@@ -0,0 +1,128 @@
+//go:build integration
+
+package oci_test
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"oras.land/oras-go/v2/registry/remote/errcode"
+
+	oci "github.com/giantswarm/klaus-oci"
+	"github.com/giantswarm/klaus-oci/conformance"
+	"github.com/giantswarm/klaus-oci/ocitest"
+)
+
+// The integration suite runs against distribution registry containers
+// started with docker. The registries start before HOME is replaced, so
+// docker keeps its configuration:
+//
+//	go test -tags integration -run Integration ./...
+//
+// Tests are skipped when docker is not available.
+
+var integrationVariants = []struct {
+	name string
+	opts []ocitest.RegistryOption
+}{
+	{name: "plain"},
+	{name: "auth", opts: []ocitest.RegistryOption{ocitest.WithRegistryAuth("klaus", "secret")}},
+	{name: "tls", opts: []ocitest.RegistryOption{ocitest.WithRegistryTLS()}},
+	{name: "auth and tls", opts: []ocitest.RegistryOption{ocitest.WithRegistryAuth("klaus", "secret"), ocitest.WithRegistryTLS()}},
+}
+
+func TestIntegration_PluginLifecycle(t *testing.T) {
+	for _, v := range integrationVariants {
+		t.Run(v.name, func(t *testing.T) {
+			reg := ocitest.StartRegistry(t, v.opts...)
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("XDG_RUNTIME_DIR", "")
+			cacheDir := t.TempDir()
+			client := oci.NewClient(append(reg.ClientOptions(), oci.WithCache(cacheDir))...)
+			repository := reg.Host + "/klaus/plugins/gs-base"
+			ref := repository + ":v1.0.0"
+
+			src := t.TempDir()
+			p, err := oci.ScaffoldPlugin(src, oci.ScaffoldPluginOptions{Name: "gs-base", Skills: []string{"kubectl"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			pushed, err := client.PushPlugin(t.Context(), src, ref, *p)
+			if err != nil {
+				t.Fatalf("PushPlugin() error = %v", err)
+			}
+
+			entries, err := client.ListPlugins(t.Context(), oci.WithRegistry(reg.Host+"/klaus/plugins"))
+			if err != nil {
+				t.Fatalf("ListPlugins() error = %v", err)
+			}
+			if !slices.ContainsFunc(entries, func(e oci.ListEntry) bool { return e.Repository == repository }) {
+				t.Errorf("ListPlugins() = %+v, want %s", entries, repository)
+			}
+
+			described, err := client.DescribePlugin(t.Context(), repository)
+			if err != nil {
+				t.Fatalf("DescribePlugin() error = %v", err)
+			}
+			if described.Digest != pushed.Digest || described.Name != "gs-base" {
+				t.Errorf("DescribePlugin() = %s (%s), want gs-base (%s)", described.Name, described.Digest, pushed.Digest)
+			}
+
+			dest := filepath.Join(t.TempDir(), "gs-base")
+			pulled, err := client.PullPlugin(t.Context(), ref, dest)
+			if err != nil {
+				t.Fatalf("PullPlugin() error = %v", err)
+			}
+			if pulled.Digest != pushed.Digest || pulled.Cached {
+				t.Errorf("PullPlugin() = %s (cached %v), want a fresh pull of %s", pulled.Digest, pulled.Cached, pushed.Digest)
+			}
+			again, err := client.PullPlugin(t.Context(), ref, dest)
+			if err != nil {
+				t.Fatalf("second PullPlugin() error = %v", err)
+			}
+			if !again.Cached {
+				t.Error("second PullPlugin() was not a cache hit")
+			}
+
+			// A second client reads through the shared response cache.
+			other := oci.NewClient(append(reg.ClientOptions(), oci.WithCache(cacheDir))...)
+			if _, err := other.DescribePlugin(t.Context(), ref); err != nil {
+				t.Errorf("DescribePlugin() through the shared cache error = %v", err)
+			}
+		})
+	}
+}
+
+func TestIntegration_Unauthorized(t *testing.T) {
+	reg := ocitest.StartRegistry(t, ocitest.WithRegistryAuth("klaus", "secret"))
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	anonymous := oci.NewClient(oci.WithPlainHTTP(true))
+
+	src := t.TempDir()
+	p, err := oci.ScaffoldPlugin(src, oci.ScaffoldPluginOptions{Name: "gs-base"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = anonymous.PushPlugin(t.Context(), src, reg.Host+"/klaus/plugins/gs-base:v1.0.0", *p)
+	var errResp *errcode.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.StatusCode != 401 {
+		t.Errorf("PushPlugin() without credentials error = %v, want 401", err)
+	}
+}
+
+func TestIntegration_Conformance(t *testing.T) {
+	reg := ocitest.StartRegistry(t)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	client := oci.NewClient(reg.ClientOptions()...)
+
+	report := conformance.Run(t.Context(), client, reg.Host+"/klaus/conformance")
+	for _, res := range report.Results {
+		if res.Status != conformance.StatusPass {
+			t.Errorf("%s\n%s", res.Check, report)
+		}
+	}
+}
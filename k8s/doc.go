@@ -0,0 +1,14 @@
+// Package k8s provides Klaus reference types for embedding in Kubernetes
+// custom resources. They mirror oci.PluginReference and
+// oci.ToolchainReference but carry kubebuilder validation markers and
+// DeepCopy methods, so an operator can use them directly in its CRD spec
+// and convert to the oci types when talking to a registry.
+//
+// The package deliberately does not import k8s.io/apimachinery: the types
+// are plain structs, which is all controller-gen needs to generate CRD
+// schemas for embedding types.
+//
+// +kubebuilder:object:generate=true
+package k8s
+
+//go:generate controller-gen object paths=.
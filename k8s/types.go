@@ -0,0 +1,75 @@
+package k8s
+
+import oci "github.com/giantswarm/klaus-oci"
+
+// PluginReference points to a plugin OCI artifact by repository and either
+// a tag or a digest. When both are set the digest wins.
+type PluginReference struct {
+	// Repository is the OCI repository of the plugin, e.g.
+	// "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base".
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*(:[0-9]+)?(/[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*)+$`
+	Repository string `json:"repository"`
+
+	// Tag is the OCI tag, usually a semver version such as "v1.2.0".
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`
+	Tag string `json:"tag,omitempty"`
+
+	// Digest pins the manifest digest, e.g. "sha256:abc...".
+	// +optional
+	// +kubebuilder:validation:Pattern=`^sha(256:[a-f0-9]{64}|512:[a-f0-9]{128})$`
+	Digest string `json:"digest,omitempty"`
+}
+
+// ToolchainReference points to a toolchain container image by repository
+// and either a tag or a digest. When both are set the digest wins.
+type ToolchainReference struct {
+	// Repository is the OCI repository of the toolchain image, e.g.
+	// "gsoci.azurecr.io/giantswarm/klaus-toolchains/go".
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*(:[0-9]+)?(/[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*)+$`
+	Repository string `json:"repository"`
+
+	// Tag is the OCI tag, usually a semver version such as "v1.2.0".
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`
+	Tag string `json:"tag,omitempty"`
+
+	// Digest pins the manifest digest, e.g. "sha256:abc...".
+	// +optional
+	// +kubebuilder:validation:Pattern=`^sha(256:[a-f0-9]{64}|512:[a-f0-9]{128})$`
+	Digest string `json:"digest,omitempty"`
+}
+
+// Ref returns the full OCI reference string for this plugin.
+func (p PluginReference) Ref() string {
+	return p.OCI().Ref()
+}
+
+// OCI converts p to the registry client type.
+func (p PluginReference) OCI() oci.PluginReference {
+	return oci.PluginReference{Repository: p.Repository, Tag: p.Tag, Digest: p.Digest}
+}
+
+// PluginReferenceFromOCI converts a registry client reference to the CRD
+// type.
+func PluginReferenceFromOCI(r oci.PluginReference) PluginReference {
+	return PluginReference{Repository: r.Repository, Tag: r.Tag, Digest: r.Digest}
+}
+
+// Ref returns the full OCI reference string for this toolchain.
+func (t ToolchainReference) Ref() string {
+	return t.OCI().Ref()
+}
+
+// OCI converts t to the registry client type.
+func (t ToolchainReference) OCI() oci.ToolchainReference {
+	return oci.ToolchainReference{Repository: t.Repository, Tag: t.Tag, Digest: t.Digest}
+}
+
+// ToolchainReferenceFromOCI converts a registry client reference to the CRD
+// type.
+func ToolchainReferenceFromOCI(r oci.ToolchainReference) ToolchainReference {
+	return ToolchainReference{Repository: r.Repository, Tag: r.Tag, Digest: r.Digest}
+}
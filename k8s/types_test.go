@@ -0,0 +1,95 @@
+package k8s
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	oci "github.com/giantswarm/klaus-oci"
+)
+
+func TestPluginReference_RoundTrip(t *testing.T) {
+	in := oci.PluginReference{
+		Repository: "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base",
+		Tag:        "v1.0.0",
+	}
+	ref := PluginReferenceFromOCI(in)
+	if got := ref.OCI(); got != in {
+		t.Errorf("OCI() = %+v, want %+v", got, in)
+	}
+	if got, want := ref.Ref(), in.Ref(); got != want {
+		t.Errorf("Ref() = %q, want %q", got, want)
+	}
+}
+
+func TestToolchainReference_RoundTrip(t *testing.T) {
+	in := oci.ToolchainReference{
+		Repository: "gsoci.azurecr.io/giantswarm/klaus-toolchains/go",
+		Tag:        "v1.2.0",
+		Digest:     "sha256:abc",
+	}
+	ref := ToolchainReferenceFromOCI(in)
+	if got := ref.OCI(); got != in {
+		t.Errorf("OCI() = %+v, want %+v", got, in)
+	}
+	if got := ref.Ref(); got != in.Repository+"@sha256:abc" {
+		t.Errorf("Ref() = %q, want digest reference", got)
+	}
+}
+
+func TestDeepCopy(t *testing.T) {
+	p := &PluginReference{Repository: "example.com/p", Tag: "v1"}
+	cp := p.DeepCopy()
+	if cp == p || *cp != *p {
+		t.Errorf("DeepCopy() = %p %+v, want distinct copy of %+v", cp, cp, p)
+	}
+	cp.Tag = "v2"
+	if p.Tag != "v1" {
+		t.Error("modifying the copy changed the original")
+	}
+
+	var nilRef *ToolchainReference
+	if nilRef.DeepCopy() != nil {
+		t.Error("DeepCopy() of nil should be nil")
+	}
+}
+
+func TestJSONMatchesOCITypes(t *testing.T) {
+	in := oci.PluginReference{Repository: "example.com/p", Digest: "sha256:abc"}
+	want, _ := json.Marshal(in)
+	got, _ := json.Marshal(PluginReferenceFromOCI(in))
+	if string(got) != string(want) {
+		t.Errorf("JSON = %s, want %s", got, want)
+	}
+}
+
+func TestRepositoryPattern(t *testing.T) {
+	src, err := os.ReadFile("types.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	markers := regexp.MustCompile("(?m)Pattern=`(.*)`\n\tRepository ").FindAllSubmatch(src, -1)
+	if len(markers) != 2 {
+		t.Fatalf("found %d repository patterns, want 2", len(markers))
+	}
+	for _, m := range markers {
+		pattern := regexp.MustCompile(string(m[1]))
+		for repo, want := range map[string]bool{
+			"gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base": true,
+			"localhost:5000/a__b":                               true,
+			"example.com/a--b":                                  true,
+			"example.com/a.b_c":                                 true,
+			"example.com/a___b":                                 false,
+			"example.com/a._b":                                  false,
+			"example.com/-a":                                    false,
+			"example.com/A":                                     false,
+			"example.com":                                       false,
+		} {
+			if got := pattern.MatchString(repo); got != want {
+				t.Errorf("pattern %s matches %q = %v, want %v", strings.TrimSpace(string(m[1])), repo, got, want)
+			}
+		}
+	}
+}
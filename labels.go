@@ -0,0 +1,106 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// WithLabelFallback makes toolchain describe operations read metadata from
+// the image config labels when the manifest carries no Klaus annotations.
+// Many toolchain images predate the annotation convention but set the
+// standard org.opencontainers.image.* labels, which are mapped as follows:
+// title to Name, description to Description, authors to Author.Name, url
+// to Homepage, source to SourceRepo, and licenses to License. For
+// multi-platform images, the labels of the first platform image are used.
+// The fallback costs one or two extra requests per toolchain.
+func WithLabelFallback() ClientOption {
+	return func(c *Client) { c.labelFallback = true }
+}
+
+// hasKlausAnnotations reports whether annotations carry any Klaus metadata.
+func hasKlausAnnotations(annotations map[string]string) bool {
+	for k := range annotations {
+		if strings.HasPrefix(k, "io.giantswarm.klaus.") {
+			return true
+		}
+	}
+	return false
+}
+
+// toolchainFromLabels maps the standard OCI image labels into a Toolchain.
+func toolchainFromLabels(labels map[string]string) Toolchain {
+	t := Toolchain{
+		Name:        labels[ocispec.AnnotationTitle],
+		Description: labels[ocispec.AnnotationDescription],
+		Homepage:    labels[ocispec.AnnotationURL],
+		SourceRepo:  labels[ocispec.AnnotationSource],
+		License:     labels[ocispec.AnnotationLicenses],
+	}
+	if authors := labels[ocispec.AnnotationAuthors]; authors != "" {
+		t.Author = &Author{Name: authors}
+	}
+	return t
+}
+
+// imageLabels returns the config labels of the image in fm. For an image
+// index, the first manifest with a real platform is used, skipping
+// attestation manifests.
+func imageLabels(ctx context.Context, fm *fetchedManifest, resolved string) (map[string]string, error) {
+	config := fm.manifest.Config
+	if isIndexMediaType(fm.mediaType) {
+		var err error
+		if config, err = indexImageConfig(ctx, fm, resolved); err != nil {
+			return nil, err
+		}
+	}
+	if config.Digest == "" {
+		return nil, nil
+	}
+
+	data, err := fetchConfigBlob(ctx, fm.repo, resolved, config)
+	if err != nil {
+		return nil, err
+	}
+	var image ocispec.Image
+	if err := json.Unmarshal(data, &image); err != nil {
+		return nil, fmt.Errorf("parsing image config for %s: %w", resolved, err)
+	}
+	return image.Config.Labels, nil
+}
+
+// indexImageConfig returns the config descriptor of the first platform
+// image listed in the index in fm, or a zero descriptor when there is none.
+func indexImageConfig(ctx context.Context, fm *fetchedManifest, resolved string) (ocispec.Descriptor, error) {
+	var index ocispec.Index
+	if err := fetchJSONManifest(ctx, fm.repo, fm.digest, &index); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("fetching index for %s: %w", resolved, err)
+	}
+	for _, desc := range index.Manifests {
+		if desc.Platform != nil && desc.Platform.OS == "unknown" {
+			continue
+		}
+		var manifest ocispec.Manifest
+		if err := fetchJSONManifest(ctx, fm.repo, desc.Digest.String(), &manifest); err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("fetching platform manifest for %s: %w", resolved, err)
+		}
+		return manifest.Config, nil
+	}
+	return ocispec.Descriptor{}, nil
+}
+
+// fetchJSONManifest fetches the manifest or index with the given digest
+// from repo and decodes it into v, reading at most maxManifestBytes.
+func fetchJSONManifest(ctx context.Context, repo *remote.Repository, digest string, v any) error {
+	_, rc, err := repo.FetchReference(ctx, digest)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return json.NewDecoder(io.LimitReader(rc, maxManifestBytes)).Decode(v)
+}
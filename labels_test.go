@@ -0,0 +1,166 @@
+package oci
+
+import (
+	"encoding/json"
+	"testing"
+
+	godigest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+var testImageLabels = map[string]string{
+	ocispec.AnnotationTitle:       "go",
+	ocispec.AnnotationDescription: "Go toolchain",
+	ocispec.AnnotationAuthors:     "Giant Swarm GmbH",
+	ocispec.AnnotationURL:         "https://docs.giantswarm.io/klaus/",
+	ocispec.AnnotationSource:      "https://github.com/giantswarm/klaus-images",
+	ocispec.AnnotationLicenses:    "Apache-2.0",
+}
+
+// imageManifest stores an image config with labels in reg and returns the
+// manifest referencing it.
+func imageManifest(t *testing.T, reg *cacheRegistry, labels, annotations map[string]string) []byte {
+	t.Helper()
+	config, err := json.Marshal(ocispec.Image{Config: ocispec.ImageConfig{Labels: labels}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config: ocispec.Descriptor{
+			MediaType: ocispec.MediaTypeImageConfig,
+			Digest:    godigest.Digest(reg.addBlob(config)),
+			Size:      int64(len(config)),
+		},
+		Layers:      []ocispec.Descriptor{},
+		Annotations: annotations,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return manifest
+}
+
+func TestDescribeToolchain_LabelFallback(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	repo := "giantswarm/klaus-toolchains/go"
+	reg.addManifest(repo, "v1.0.0", imageManifest(t, reg, testImageLabels, nil))
+	ref := host + "/" + repo + ":v1.0.0"
+
+	described, err := NewClient(WithPlainHTTP(true)).DescribeToolchain(t.Context(), ref)
+	if err != nil {
+		t.Fatalf("DescribeToolchain() error = %v", err)
+	}
+	if described.Toolchain.Name != "" {
+		t.Errorf("Name = %q without fallback, want empty", described.Toolchain.Name)
+	}
+
+	described, err = NewClient(WithPlainHTTP(true), WithLabelFallback()).DescribeToolchain(t.Context(), ref)
+	if err != nil {
+		t.Fatalf("DescribeToolchain() error = %v", err)
+	}
+	want := Toolchain{
+		Name:        "go",
+		Version:     "v1.0.0",
+		Description: "Go toolchain",
+		Author:      &Author{Name: "Giant Swarm GmbH"},
+		Homepage:    "https://docs.giantswarm.io/klaus/",
+		SourceRepo:  "https://github.com/giantswarm/klaus-images",
+		License:     "Apache-2.0",
+	}
+	got := described.Toolchain
+	if got.Author == nil || *got.Author != *want.Author {
+		t.Errorf("Author = %+v, want %+v", got.Author, want.Author)
+	}
+	got.Author, want.Author = nil, nil
+	if got.Name != want.Name || got.Version != want.Version || got.Description != want.Description ||
+		got.Homepage != want.Homepage || got.SourceRepo != want.SourceRepo || got.License != want.License {
+		t.Errorf("Toolchain = %+v, want %+v", got, want)
+	}
+}
+
+func TestDescribeToolchain_LabelFallbackPrefersAnnotations(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	repo := "giantswarm/klaus-toolchains/go"
+	annotations := Toolchain{Name: "golang"}.Annotations()
+	reg.addManifest(repo, "v1.0.0", imageManifest(t, reg, testImageLabels, annotations))
+	blobs := reg.blobCount.Load()
+
+	client := NewClient(WithPlainHTTP(true), WithLabelFallback())
+	described, err := client.DescribeToolchain(t.Context(), host+"/"+repo+":v1.0.0")
+	if err != nil {
+		t.Fatalf("DescribeToolchain() error = %v", err)
+	}
+	if described.Toolchain.Name != "golang" || described.Toolchain.Description != "" {
+		t.Errorf("Toolchain = %+v, want annotation metadata only", described.Toolchain)
+	}
+	if n := reg.blobCount.Load() - blobs; n != 0 {
+		t.Errorf("fetched %d blobs, want 0", n)
+	}
+}
+
+func TestDescribeToolchain_LabelFallbackIndex(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	repo := "giantswarm/klaus-toolchains/go"
+
+	attestation := imageManifest(t, reg, nil, nil)
+	image := imageManifest(t, reg, testImageLabels, nil)
+	attestationDigest := reg.addManifest(repo, "attestation", attestation)
+	imageDigest := reg.addManifest(repo, "amd64", image)
+	index, err := json.Marshal(ocispec.Index{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{
+			{
+				MediaType: ocispec.MediaTypeImageManifest,
+				Digest:    godigest.Digest(attestationDigest),
+				Size:      int64(len(attestation)),
+				Platform:  &ocispec.Platform{OS: "unknown", Architecture: "unknown"},
+			},
+			{
+				MediaType: ocispec.MediaTypeImageManifest,
+				Digest:    godigest.Digest(imageDigest),
+				Size:      int64(len(image)),
+				Platform:  &ocispec.Platform{OS: "linux", Architecture: "amd64"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg.addManifest(repo, "v1.0.0", index)
+
+	client := NewClient(WithPlainHTTP(true), WithLabelFallback())
+	described, err := client.Describe(t.Context(), host+"/"+repo+":v1.0.0")
+	if err != nil {
+		t.Fatalf("Describe() error = %v", err)
+	}
+	if described.Kind != KindToolchain {
+		t.Fatalf("Kind = %q, want %q", described.Kind, KindToolchain)
+	}
+	if described.Toolchain.Toolchain.Name != "go" || described.Toolchain.Toolchain.License != "Apache-2.0" {
+		t.Errorf("Toolchain = %+v, want label metadata", described.Toolchain.Toolchain)
+	}
+}
+
+func TestToolchainFromLabels(t *testing.T) {
+	tc := toolchainFromLabels(nil)
+	if tc.Name != "" || tc.Author != nil {
+		t.Errorf("toolchainFromLabels(nil) = %+v, want zero", tc)
+	}
+	tc = toolchainFromLabels(map[string]string{ocispec.AnnotationTitle: "python", ocispec.AnnotationVersion: "3.12"})
+	if tc.Name != "python" || tc.Version != "" {
+		t.Errorf("toolchainFromLabels() = %+v, want Name python and no Version", tc)
+	}
+}
+
+func TestHasKlausAnnotations(t *testing.T) {
+	if hasKlausAnnotations(map[string]string{ocispec.AnnotationTitle: "go"}) {
+		t.Error("hasKlausAnnotations() = true for OCI annotations only")
+	}
+	if !hasKlausAnnotations(map[string]string{AnnotationDescription: "Go"}) {
+		t.Error("hasKlausAnnotations() = false, want true")
+	}
+}
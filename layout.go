@@ -0,0 +1,58 @@
+package oci
+
+import (
+	"path/filepath"
+	"slices"
+)
+
+// layoutWarnings compares the components declared in the config blob of
+// pulled with its extracted tree, using the same discovery as
+// ReadPluginFromDir, and describes every component that is declared but
+// missing or present but undeclared.
+func layoutWarnings(pulled *PulledPlugin) []string {
+	var warnings []string
+	for _, d := range layoutDrift(&pulled.Plugin, discoverComponents(pulled.Dir)) {
+		warnings = append(warnings, d.String())
+	}
+	return warnings
+}
+
+// discoverComponents returns the components ReadPluginFromDir discovers
+// in dir.
+func discoverComponents(dir string) Plugin {
+	return Plugin{
+		Skills:     discoverSkills(dir),
+		Commands:   discoverMarkdownNames(filepath.Join(dir, "commands")),
+		Agents:     discoverMarkdownNames(filepath.Join(dir, "agents")),
+		HasHooks:   detectHooks(dir),
+		MCPServers: discoverJSONKeys(filepath.Join(dir, ".mcp.json")),
+		LSPServers: discoverJSONKeys(filepath.Join(dir, ".lsp.json")),
+	}
+}
+
+// layoutDrift returns the components of declared that are missing from
+// found, and those of found that declared does not list.
+func layoutDrift(declared *Plugin, found Plugin) []ComponentDrift {
+	var drift []ComponentDrift
+	compare := func(kind string, declared, found []string, path func(string) string) {
+		for _, name := range declared {
+			if !slices.Contains(found, name) {
+				drift = append(drift, ComponentDrift{Kind: kind, Name: name, Path: path(name), Missing: true})
+			}
+		}
+		for _, name := range found {
+			if !slices.Contains(declared, name) {
+				drift = append(drift, ComponentDrift{Kind: kind, Name: name, Path: path(name)})
+			}
+		}
+	}
+	compare("skill", declared.Skills, found.Skills, func(name string) string { return "skills/" + name + "/SKILL.md" })
+	compare("command", declared.Commands, found.Commands, func(name string) string { return "commands/" + name + ".md" })
+	compare("agent", declared.Agents, found.Agents, func(name string) string { return "agents/" + name + ".md" })
+	compare("MCP server", declared.MCPServers, found.MCPServers, func(string) string { return ".mcp.json" })
+	compare("LSP server", declared.LSPServers, found.LSPServers, func(string) string { return ".lsp.json" })
+	if declared.HasHooks != found.HasHooks {
+		drift = append(drift, ComponentDrift{Kind: "hooks", Path: "hooks/", Missing: declared.HasHooks})
+	}
+	return drift
+}
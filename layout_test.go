@@ -0,0 +1,68 @@
+package oci
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLayoutWarnings(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "skills", "kubernetes", "SKILL.md"), "# k8s")
+	writeFile(t, filepath.Join(dir, "skills", "extra", "SKILL.md"), "# extra")
+	writeFile(t, filepath.Join(dir, "commands", "deploy.md"), "deploy")
+	writeFile(t, filepath.Join(dir, "agents", "reviewer.md"), "review")
+	writeFile(t, filepath.Join(dir, ".mcp.json"), `{"github":{}}`)
+
+	got := layoutWarnings(&PulledPlugin{Dir: dir, Plugin: Plugin{
+		Skills:     []string{"kubernetes", "helm"},
+		Commands:   []string{"deploy"},
+		Agents:     []string{"reviewer"},
+		HasHooks:   true,
+		MCPServers: []string{"github", "slack"},
+	}})
+	want := []string{
+		`skill "helm" is declared in the config but missing from the artifact (expected in skills/helm/SKILL.md)`,
+		`skill "extra" found in skills/extra/SKILL.md is not declared in the config`,
+		`MCP server "slack" is declared in the config but missing from the artifact (expected in .mcp.json)`,
+		"hooks are declared in the config but hooks/ is missing or empty",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("layoutWarnings() =\n%q\nwant\n%q", got, want)
+	}
+
+	consistent := Plugin{Skills: []string{"extra", "kubernetes"}, Commands: []string{"deploy"}, Agents: []string{"reviewer"}, MCPServers: []string{"github"}}
+	if got := layoutWarnings(&PulledPlugin{Dir: dir, Plugin: consistent}); len(got) != 0 {
+		t.Errorf("layoutWarnings() for a consistent tree = %q, want none", got)
+	}
+}
+
+func TestPullPlugin_LayoutWarnings(t *testing.T) {
+	reg := newCacheRegistry()
+	blob, _ := json.Marshal(pluginConfigBlob{Skills: []string{"kubernetes", "helm"}})
+	addPullableArtifact(t, reg, "klaus-plugins/drift", "v1.0.0", pluginArtifact, blob, map[string]string{
+		"skills/kubernetes/SKILL.md": "# k8s",
+		"hooks/pre.sh":               "#!/bin/sh",
+	})
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	dest := filepath.Join(t.TempDir(), "drift")
+
+	for _, cached := range []bool{false, true} {
+		pulled, err := client.PullPlugin(t.Context(), host+"/klaus-plugins/drift:v1.0.0", dest)
+		if err != nil {
+			t.Fatalf("PullPlugin() error = %v", err)
+		}
+		if pulled.Cached != cached {
+			t.Fatalf("Cached = %v, want %v", pulled.Cached, cached)
+		}
+		want := []string{
+			`skill "helm" is declared in the config but missing from the artifact (expected in skills/helm/SKILL.md)`,
+			"hooks/ is not declared in the config",
+		}
+		if !slices.Equal(pulled.Warnings, want) {
+			t.Errorf("Warnings = %q, want %q", pulled.Warnings, want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ListSort is the order of listing results; see WithSort.
+type ListSort string
+
+const (
+	// SortByRepository sorts entries by repository, the default.
+	SortByRepository ListSort = ""
+	// SortByName sorts entries by name, then repository.
+	SortByName ListSort = "name"
+	// SortByVersion sorts entries by version, highest first. Entries
+	// whose version is not semver sort last.
+	SortByVersion ListSort = "version"
+	// SortByUpdated sorts entries by ListEntry.Updated, most recent
+	// first. Entries without a creation time sort last.
+	SortByUpdated ListSort = "updated"
+)
+
+// ListField is a ListEntry field that listing can resolve; see WithFields.
+type ListField string
+
+const (
+	// FieldName is the name and repository of each entry, which come from
+	// the repository catalog and cost no request per repository.
+	FieldName ListField = "name"
+	// FieldVersion is the latest version and reference of each entry. It
+	// costs one tag listing per repository.
+	FieldVersion ListField = "version"
+	// FieldUpdated is the creation time of the latest version, read from
+	// the standard org.opencontainers.image.created manifest annotation.
+	// It costs a tag listing and a manifest GET per repository.
+	FieldUpdated ListField = "updated"
+)
+
+// WithSort sets the order of listing results. Paged listings sort each
+// page; pages themselves follow repository order.
+func WithSort(order ListSort) ListOption {
+	return func(cfg *listConfig) { cfg.sort = order }
+}
+
+// WithFields limits what listing resolves per repository to the given
+// fields, avoiding network requests for fields that are not needed. By
+// default, name and version are resolved. Without FieldVersion, tags are
+// not listed: Version and Reference stay empty, and repositories without
+// semver tags are listed too. Sorting by version or update time,
+// WithVerifyArtifactType, and WithTypeAnnotation resolve versions
+// regardless.
+func WithFields(fields ...ListField) ListOption {
+	return func(cfg *listConfig) { cfg.fields = fields }
+}
+
+// requireField adds f to the fields of a listing limited by WithFields.
+func requireField(f ListField) ListOption {
+	return func(cfg *listConfig) {
+		if cfg.fields != nil && !slices.Contains(cfg.fields, f) {
+			cfg.fields = append(cfg.fields, f)
+		}
+	}
+}
+
+// resolvesVersion reports whether listing resolves each repository's
+// latest version.
+func (cfg *listConfig) resolvesVersion() bool {
+	return cfg.fields == nil || slices.Contains(cfg.fields, FieldVersion) ||
+		cfg.resolvesUpdated() || cfg.sort == SortByVersion || cfg.verifyType || cfg.typeAnnotation
+}
+
+// resolvesUpdated reports whether listing reads each latest version's
+// creation time.
+func (cfg *listConfig) resolvesUpdated() bool {
+	return slices.Contains(cfg.fields, FieldUpdated) || cfg.sort == SortByUpdated
+}
+
+// manifestCreated returns the org.opencontainers.image.created annotation
+// of the manifest of ref, or the zero time if it has none.
+func (c *Client) manifestCreated(ctx context.Context, ref string) (time.Time, error) {
+	repo, tag, err := c.newRepository(ref)
+	if err != nil {
+		return time.Time{}, err
+	}
+	_, rc, err := repo.FetchReference(ctx, tag)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("fetching manifest for %s: %w", ref, err)
+	}
+	defer rc.Close()
+
+	var m struct {
+		Annotations map[string]string `json:"annotations"`
+	}
+	if err := json.NewDecoder(io.LimitReader(rc, maxManifestBytes)).Decode(&m); err != nil {
+		return time.Time{}, fmt.Errorf("parsing manifest for %s: %w", ref, err)
+	}
+	created, ok := m.Annotations[ocispec.AnnotationCreated]
+	if !ok {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, created)
+	if err != nil {
+		return time.Time{}, nil
+	}
+	return t, nil
+}
+
+// sortListed sorts artifacts, which are in repository order, by order.
+func sortListed(artifacts []listedArtifact, order ListSort) {
+	switch order {
+	case SortByName:
+		slices.SortStableFunc(artifacts, func(a, b listedArtifact) int {
+			an, _ := extractNameVersion(a)
+			bn, _ := extractNameVersion(b)
+			return strings.Compare(an, bn)
+		})
+	case SortByVersion:
+		slices.SortStableFunc(artifacts, func(a, b listedArtifact) int {
+			_, av := extractNameVersion(a)
+			_, bv := extractNameVersion(b)
+			as, aerr := semver.NewVersion(av)
+			bs, berr := semver.NewVersion(bv)
+			switch {
+			case aerr != nil && berr != nil:
+				return 0
+			case aerr != nil:
+				return 1
+			case berr != nil:
+				return -1
+			}
+			return bs.Compare(as)
+		})
+	case SortByUpdated:
+		slices.SortStableFunc(artifacts, func(a, b listedArtifact) int {
+			switch {
+			case a.Updated.IsZero() && b.Updated.IsZero():
+				return 0
+			case a.Updated.IsZero():
+				return 1
+			case b.Updated.IsZero():
+				return -1
+			}
+			return b.Updated.Compare(a.Updated)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package oci
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// newSortRegistry serves plugins whose versions and creation times sort
+// differently from their names.
+func newSortRegistry(t *testing.T) (*cacheRegistry, string) {
+	t.Helper()
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	for _, p := range []struct{ repo, tag, created string }{
+		{"klaus-plugins/gs-a", "v1.0.0", "2025-03-01T00:00:00Z"},
+		{"klaus-plugins/gs-b", "v3.0.0", ""},
+		{"klaus-plugins/gs-c", "v2.0.0", "2025-06-01T00:00:00Z"},
+		{"klaus-plugins/gs-d", "main", "2025-01-01T00:00:00Z"},
+	} {
+		var annotations map[string]string
+		if p.created != "" {
+			annotations = map[string]string{ocispec.AnnotationCreated: p.created}
+		}
+		reg.addManifest(p.repo, p.tag, imageManifest(t, reg, nil, annotations))
+	}
+	return reg, host + "/klaus-plugins"
+}
+
+func TestListPlugins_Fields(t *testing.T) {
+	reg, base := newSortRegistry(t)
+	client := NewClient(WithPlainHTTP(true))
+
+	plugins, err := client.ListPlugins(t.Context(), WithRegistry(base), WithFields(FieldName))
+	if err != nil {
+		t.Fatalf("ListPlugins() error = %v", err)
+	}
+	if got := listEntryNames(plugins); !slices.Equal(got, []string{"gs-a", "gs-b", "gs-c", "gs-d"}) {
+		t.Errorf("names = %v, want all repositories", got)
+	}
+	for _, p := range plugins {
+		if p.Version != "" || p.Reference != "" || !p.Updated.IsZero() {
+			t.Errorf("%s resolved: %+v", p.Name, p)
+		}
+	}
+	if n := reg.tagsCount.Load() + reg.manifestCount.Load() + reg.headCount.Load(); n != 0 {
+		t.Errorf("name-only listing made %d per-repository requests, want 0", n)
+	}
+
+	plugins, err = client.ListPlugins(t.Context(), WithRegistry(base), WithFields(FieldName, FieldUpdated))
+	if err != nil {
+		t.Fatalf("ListPlugins() error = %v", err)
+	}
+	if len(plugins) != 3 || plugins[0].Version != "v1.0.0" {
+		t.Fatalf("plugins = %+v, want 3 resolved", plugins)
+	}
+	if want := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC); !plugins[0].Updated.Equal(want) {
+		t.Errorf("Updated = %v, want %v", plugins[0].Updated, want)
+	}
+	if !plugins[1].Updated.IsZero() {
+		t.Errorf("Updated without annotation = %v, want zero", plugins[1].Updated)
+	}
+}
+
+func TestListPlugins_Sort(t *testing.T) {
+	_, base := newSortRegistry(t)
+	client := NewClient(WithPlainHTTP(true))
+
+	tests := []struct {
+		order ListSort
+		want  []string
+	}{
+		{order: SortByRepository, want: []string{"gs-a", "gs-b", "gs-c"}},
+		{order: SortByName, want: []string{"gs-a", "gs-b", "gs-c"}},
+		{order: SortByVersion, want: []string{"gs-b", "gs-c", "gs-a"}},
+		{order: SortByUpdated, want: []string{"gs-c", "gs-a", "gs-b"}},
+	}
+	for _, tt := range tests {
+		plugins, err := client.ListPlugins(t.Context(), WithRegistry(base), WithSort(tt.order))
+		if err != nil {
+			t.Fatalf("ListPlugins(%q) error = %v", tt.order, err)
+		}
+		if got := listEntryNames(plugins); !slices.Equal(got, tt.want) {
+			t.Errorf("ListPlugins(%q) = %v, want %v", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestSortListed_NameAndVersion(t *testing.T) {
+	artifacts := []listedArtifact{
+		{Repository: "r/a", Name: "zeta", Reference: "r/a:main"},
+		{Repository: "r/b", Name: "alpha", Reference: "r/b:v1.0.0"},
+		{Repository: "r/c", Name: "mid", Reference: "r/c:v1.1.0-rc.1"},
+	}
+
+	sortListed(artifacts, SortByName)
+	if got := []string{artifacts[0].Name, artifacts[1].Name, artifacts[2].Name}; !slices.Equal(got, []string{"alpha", "mid", "zeta"}) {
+		t.Errorf("SortByName = %v", got)
+	}
+	sortListed(artifacts, SortByVersion)
+	if got := []string{artifacts[0].Name, artifacts[1].Name, artifacts[2].Name}; !slices.Equal(got, []string{"mid", "alpha", "zeta"}) {
+		t.Errorf("SortByVersion = %v", got)
+	}
+}
@@ -0,0 +1,114 @@
+package oci
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// WithNameGlob limits listing to artifacts whose name matches one of the
+// given path.Match patterns, e.g. "gs-*". The name is the repository path
+// below the registry base, or the toolchain name with WithToolchainLayout.
+// When all patterns start with the same literal text, the catalog scan
+// starts at the first repository with that prefix and stops after the
+// last one, instead of reading the whole namespace.
+func WithNameGlob(patterns ...string) ListOption {
+	return func(cfg *listConfig) { cfg.nameGlobs = append(cfg.nameGlobs, patterns...) }
+}
+
+// WithExcludeGlob drops artifacts whose name matches one of the given
+// path.Match patterns from listing, after WithNameGlob is applied.
+func WithExcludeGlob(patterns ...string) ListOption {
+	return func(cfg *listConfig) { cfg.excludeGlobs = append(cfg.excludeGlobs, patterns...) }
+}
+
+// checkGlobs reports malformed WithNameGlob and WithExcludeGlob patterns.
+func (cfg *listConfig) checkGlobs() error {
+	for _, p := range append(cfg.nameGlobs[:len(cfg.nameGlobs):len(cfg.nameGlobs)], cfg.excludeGlobs...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid glob %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// matchesGlobs reports whether the repository repo of base passes the
+// glob filters of cfg.
+func (cfg *listConfig) matchesGlobs(base, repo string) bool {
+	if len(cfg.nameGlobs) == 0 && len(cfg.excludeGlobs) == 0 {
+		return true
+	}
+	name := strings.TrimPrefix(repo, base+"/")
+	if cfg.layout != nil {
+		name = cfg.layout.Name(repo)
+	}
+	if len(cfg.nameGlobs) > 0 && !matchAny(cfg.nameGlobs, name) {
+		return false
+	}
+	return !matchAny(cfg.excludeGlobs, name)
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// namePrefix returns the literal text all WithNameGlob patterns start
+// with, which every listed repository path below the base starts with.
+// It is empty without name globs and with a toolchain layout, whose names
+// are not repository paths.
+func (cfg *listConfig) namePrefix() string {
+	if len(cfg.nameGlobs) == 0 || cfg.layout != nil {
+		return ""
+	}
+	prefix := literalPrefix(cfg.nameGlobs[0])
+	for _, p := range cfg.nameGlobs[1:] {
+		lit := literalPrefix(p)
+		i := 0
+		for i < len(prefix) && i < len(lit) && prefix[i] == lit[i] {
+			i++
+		}
+		prefix = prefix[:i]
+	}
+	return prefix
+}
+
+// literalPrefix returns the part of pattern before its first meta
+// character.
+func literalPrefix(pattern string) string {
+	if i := strings.IndexAny(pattern, `*?[\`); i >= 0 {
+		return pattern[:i]
+	}
+	return pattern
+}
+
+// scanNamespace calls fn for the repositories of base after the
+// repository after, in sorted order, like walkRepositories. With a
+// common WithNameGlob prefix, only the repositories starting with it are
+// scanned.
+func (c *Client) scanNamespace(ctx context.Context, base, after string, cfg *listConfig, fn func(repo string) error) error {
+	lit := cfg.namePrefix()
+	if lit == "" {
+		return c.walkRepositories(ctx, base, after, fn)
+	}
+	prefix := base + "/" + lit
+	// Start just before the prefix: the catalog lists repositories after
+	// the given one, and the prefix itself may be a repository.
+	if seek := prefix[:len(prefix)-1]; seek > after {
+		after = seek
+	}
+	return c.walkRepositories(ctx, base, after, func(repo string) error {
+		if !strings.HasPrefix(repo, prefix) {
+			if repo > prefix {
+				return errStopIteration
+			}
+			return nil
+		}
+		return fn(repo)
+	})
+}
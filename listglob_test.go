@@ -0,0 +1,102 @@
+package oci
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestListPlugins_Globs(t *testing.T) {
+	backend := newTestRegistry(map[string][]string{
+		"klaus-plugins/ae":          {"v1.0.0"},
+		"klaus-plugins/gs-base":     {"v1.0.0"},
+		"klaus-plugins/gs-platform": {"v1.0.0"},
+		"klaus-plugins/gs-test":     {"v1.0.0"},
+		"klaus-plugins/other":       {"v1.0.0"},
+	})
+	defer backend.Close()
+	var (
+		mu   sync.Mutex
+		seek []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/_catalog" {
+			mu.Lock()
+			seek = append(seek, r.URL.Query().Get("last"))
+			mu.Unlock()
+		}
+		backend.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	base := testRegistryHost(ts) + "/klaus-plugins"
+	client := NewClient(WithPlainHTTP(true))
+
+	plugins, err := client.ListPlugins(t.Context(), WithRegistry(base),
+		WithNameGlob("gs-*"), WithExcludeGlob("*-test"))
+	if err != nil {
+		t.Fatalf("ListPlugins() error = %v", err)
+	}
+	if got := listEntryNames(plugins); !slices.Equal(got, []string{"gs-base", "gs-platform"}) {
+		t.Errorf("ListPlugins() = %v, want [gs-base gs-platform]", got)
+	}
+	if !slices.Equal(seek, []string{"klaus-plugins/gs"}) {
+		t.Errorf("catalog seeks = %v, want [klaus-plugins/gs]", seek)
+	}
+
+	plugins, err = client.ListPlugins(t.Context(), WithRegistry(base), WithNameGlob("gs-b*", "o*"))
+	if err != nil {
+		t.Fatalf("ListPlugins() error = %v", err)
+	}
+	if got := listEntryNames(plugins); !slices.Equal(got, []string{"gs-base", "other"}) {
+		t.Errorf("ListPlugins() = %v, want [gs-base other]", got)
+	}
+
+	// A repository named exactly like the literal prefix is listed.
+	plugins, err = client.ListPlugins(t.Context(), WithRegistry(base), WithNameGlob("gs-base*"))
+	if err != nil {
+		t.Fatalf("ListPlugins() error = %v", err)
+	}
+	if got := listEntryNames(plugins); !slices.Equal(got, []string{"gs-base"}) {
+		t.Errorf("ListPlugins() = %v, want [gs-base]", got)
+	}
+
+	page, err := client.ListPluginsPage(t.Context(), PageRequest{Limit: 1}, WithRegistry(base), WithNameGlob("gs-p*"))
+	if err != nil {
+		t.Fatalf("ListPluginsPage() error = %v", err)
+	}
+	if got := listEntryNames(page.Entries); !slices.Equal(got, []string{"gs-platform"}) || page.NextToken != "" {
+		t.Errorf("ListPluginsPage() = %v, NextToken %q", got, page.NextToken)
+	}
+
+	if _, err := client.ListPlugins(t.Context(), WithRegistry(base), WithExcludeGlob("[")); err == nil {
+		t.Error("ListPlugins() with a malformed glob succeeded")
+	}
+}
+
+func TestListConfig_NamePrefix(t *testing.T) {
+	tests := []struct {
+		globs []string
+		want  string
+	}{
+		{globs: nil, want: ""},
+		{globs: []string{"gs-*"}, want: "gs-"},
+		{globs: []string{"gs-base"}, want: "gs-base"},
+		{globs: []string{"gs-b*", "gs-p?"}, want: "gs-"},
+		{globs: []string{"gs-*", "*-ae"}, want: ""},
+		{globs: []string{`gs\-*`}, want: "gs"},
+		{globs: []string{"team/[ab]*"}, want: "team/"},
+	}
+	for _, tt := range tests {
+		cfg := newListConfig([]ListOption{WithNameGlob(tt.globs...)})
+		if got := cfg.namePrefix(); got != tt.want {
+			t.Errorf("namePrefix(%q) = %q, want %q", tt.globs, got, tt.want)
+		}
+	}
+
+	cfg := newListConfig([]ListOption{WithNameGlob("gs-*"), WithToolchainLayout(DefaultToolchainLayout())})
+	if got := cfg.namePrefix(); got != "" {
+		t.Errorf("namePrefix() with layout = %q, want empty", got)
+	}
+}
@@ -2,10 +2,15 @@ package oci
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"golang.org/x/sync/errgroup"
@@ -17,14 +22,52 @@ type listedArtifact struct {
 	Repository string
 	// Reference is the resolved OCI reference including the latest semver tag.
 	Reference string
+	// Archived is true for artifacts found in the archive namespace.
+	Archived bool
+	// Name overrides the name derived from Repository when set.
+	Name string
+	// Updated is the creation time of the latest version; see
+	// FieldUpdated.
+	Updated time.Time
 }
 
 // ListOption configures the behaviour of listing methods.
 type ListOption func(*listConfig)
 
 type listConfig struct {
-	filter       func(repository string) bool
-	registryBase string
+	filter         func(repository string) bool
+	registryBase   string
+	label          string
+	verifyType     bool
+	typeAnnotation bool
+	archived       bool
+	layout         *ToolchainLayout
+	deadline       time.Time
+	continuation   string
+	sort           ListSort
+	fields         []ListField
+	nameGlobs      []string
+	excludeGlobs   []string
+}
+
+func newListConfig(opts []ListOption) *listConfig {
+	cfg := &listConfig{}
+	for _, o := range opts {
+		o(cfg)
+	}
+	return cfg
+}
+
+// base returns the registry base to list: that of the toolchain layout or
+// WithRegistry, or defaultBase.
+func (cfg *listConfig) base(defaultBase string) string {
+	switch {
+	case cfg.layout != nil:
+		return cfg.layout.Base
+	case cfg.registryBase != "":
+		return cfg.registryBase
+	}
+	return defaultBase
 }
 
 // WithFilter sets a predicate that is applied to each discovered repository
@@ -34,6 +77,60 @@ func WithFilter(fn func(repository string) bool) ListOption {
 	return func(cfg *listConfig) { cfg.filter = fn }
 }
 
+// WithVerifyArtifactType makes listing fetch the manifest of each resolved
+// artifact and drop those whose config media type does not match the kind
+// being listed (e.g. a personality pushed into the plugins namespace is
+// excluded from ListPlugins). This costs one manifest GET per repository.
+func WithVerifyArtifactType() ListOption {
+	return func(cfg *listConfig) { cfg.verifyType = true }
+}
+
+// WithTypeAnnotation makes listing classify each resolved artifact by its
+// io.giantswarm.klaus.type annotation (AnnotationType) and drop artifacts
+// annotated as another kind or not annotated at all. Unlike
+// WithVerifyArtifactType it also tells toolchains apart from unrelated
+// container images, so plugins, personalities, and toolchains can share one
+// registry namespace. Like WithVerifyArtifactType it costs one manifest GET
+// per repository, but never a config blob.
+func WithTypeAnnotation() ListOption {
+	return func(cfg *listConfig) { cfg.typeAnnotation = true }
+}
+
+// WithArchived makes listing also include the artifacts moved to the
+// archive namespace of the registry base by Client.Archive. Archived
+// entries have ListEntry.Archived set.
+func WithArchived() ListOption {
+	return func(cfg *listConfig) { cfg.archived = true }
+}
+
+// WithToolchainLayout makes listing discover toolchains by naming
+// convention: every repository of layout.Base whose first path element
+// starts with layout.Prefix and is not excluded is listed, named by its
+// last path element without the prefix. It replaces the registry base, so
+// WithRegistry has no effect. See DefaultToolchainLayout.
+func WithToolchainLayout(layout ToolchainLayout) ListOption {
+	return func(cfg *listConfig) { cfg.layout = &layout }
+}
+
+// WithPartialResults bounds the resolution of discovered repositories by
+// deadline. Repositories not resolved by then are not waited for: the
+// listing returns the entries resolved so far together with an
+// *ErrTruncated carrying a continuation token, instead of failing or
+// hanging on a huge catalog. Entries are returned for the repositories
+// sorted before the first unresolved one, so no entry is listed twice
+// when continuing. The repository catalog itself is always listed in
+// full. Paged listings (ListPluginsPage, ...) end the page early instead.
+func WithPartialResults(deadline time.Time) ListOption {
+	return func(cfg *listConfig) { cfg.deadline = deadline }
+}
+
+// WithContinuation resumes a listing truncated by WithPartialResults from
+// the token in ErrTruncated.Continuation. Tokens are interchangeable with
+// ListPage.NextToken.
+func WithContinuation(token string) ListOption {
+	return func(cfg *listConfig) { cfg.continuation = token }
+}
+
 // WithRegistry overrides the default registry base path for a listing
 // operation. This supports multi-source registry configurations where the
 // base path comes from user configuration rather than the default constants.
@@ -49,67 +146,222 @@ func WithRegistry(base string) ListOption {
 // limit (default 10, configurable via WithConcurrency). Results are sorted
 // alphabetically by repository name for deterministic output.
 //
-// Repositories that have no semver tags are silently skipped.
-func (c *Client) listArtifacts(ctx context.Context, defaultBase string, opts ...ListOption) ([]listedArtifact, error) {
-	cfg := &listConfig{}
-	for _, o := range opts {
-		o(cfg)
+// Repositories that have no semver tags are silently skipped, as are
+// artifacts of a different kind when WithVerifyArtifactType is set.
+//
+// With WithPartialResults, a listing that reaches its deadline returns
+// the artifacts resolved so far together with an *ErrTruncated.
+func (c *Client) listArtifacts(ctx context.Context, defaultBase string, kind artifactKind, opts ...ListOption) ([]listedArtifact, error) {
+	cfg := newListConfig(opts)
+	base := cfg.base(defaultBase)
+	if err := cfg.checkGlobs(); err != nil {
+		return nil, err
 	}
 
-	base := defaultBase
-	if cfg.registryBase != "" {
-		base = cfg.registryBase
+	tags := &discoveredTags{client: c, bases: []string{base}}
+	repos, err := c.listNamespace(ctx, base, cfg)
+	if err != nil {
+		return nil, err
+	}
+	archived := map[string]bool{}
+	if cfg.archived {
+		archivedRepos, err := c.listNamespace(ctx, ArchiveNamespace(base), cfg)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range archivedRepos {
+			archived[r] = true
+		}
+		repos = append(repos, archivedRepos...)
+		tags.bases = append(tags.bases, ArchiveNamespace(base))
 	}
 
-	repos, err := c.listRepositories(ctx, base)
+	slices.Sort(repos)
+	after, err := decodeContinuation(cfg.continuation)
 	if err != nil {
 		return nil, err
 	}
+	repos = slices.DeleteFunc(repos, func(r string) bool { return r <= after })
 
-	if cfg.filter != nil {
-		filtered := repos[:0]
-		for _, r := range repos {
-			if cfg.filter(r) {
-				filtered = append(filtered, r)
-			}
+	artifacts, n, err := c.resolveListed(ctx, repos, kind, cfg, archived, tags)
+	if err != nil {
+		return nil, err
+	}
+	sortListed(artifacts, cfg.sort)
+	if n < len(repos) {
+		if n > 0 {
+			after = repos[n-1]
 		}
-		repos = filtered
+		return artifacts, &ErrTruncated{Remaining: len(repos) - n, Continuation: encodeContinuation(after)}
 	}
+	return artifacts, nil
+}
 
-	var (
-		mu        sync.Mutex
-		artifacts []listedArtifact
-	)
-
-	g, ctx := errgroup.WithContext(ctx)
+// resolveListed resolves the latest version of each of the sorted repos
+// concurrently, bounded by the client's concurrency limit, and returns
+// the artifacts found, sorted by repository. n is the number of leading
+// repos that were resolved; it is less than len(repos) only when the
+// deadline of WithPartialResults passed first, in which case artifacts
+// holds only those of the first n repos. Tags are listed with tags, when
+// set, after discovering the catalog indexes of its bases, and with the
+// tags/list API otherwise.
+func (c *Client) resolveListed(ctx context.Context, repos []string, kind artifactKind, cfg *listConfig, archived map[string]bool, tags *discoveredTags) (artifacts []listedArtifact, n int, err error) {
+	var mu sync.Mutex
+
+	resolveCtx := ctx
+	if !cfg.deadline.IsZero() {
+		var cancel context.CancelFunc
+		resolveCtx, cancel = context.WithDeadline(ctx, cfg.deadline)
+		defer cancel()
+	}
+	// done marks the repositories whose resolution finished, whether or
+	// not they are listed; only the deadline leaves a repository undone.
+	done := make([]bool, len(repos))
+	timedOut := func() bool { return resolveCtx.Err() != nil && ctx.Err() == nil }
+
+	// The bases whose catalog indexes are looked up are pre-warmed along
+	// with the repositories.
+	var bases []string
+	if tags != nil && cfg.resolvesVersion() {
+		bases = tags.bases
+	}
+	scopes := c.prewarmScopes(resolveCtx, append(slices.Clone(repos), bases...))
+	if len(bases) > 0 {
+		tags.discover(resolveCtx, repos, scopes[len(repos):])
+	}
+	g, gctx := errgroup.WithContext(resolveCtx)
 	g.SetLimit(c.concurrency)
 
-	for _, repo := range repos {
+	for i, repo := range repos {
 		g.Go(func() error {
-			ref, err := c.ResolveLatestVersion(ctx, repo)
-			if err != nil {
-				return nil
+			gctx := withScopes(gctx, repo, scopes[i])
+			a := listedArtifact{
+				Repository: repo,
+				Archived:   archived[repo],
+			}
+			if cfg.resolvesVersion() {
+				var lister tagLister = c
+				var discovery string
+				if tags != nil {
+					lister, discovery = tags, tags.strategy(repo)
+				}
+				ref, err := c.resolveLatestVersion(gctx, lister, repo, discovery)
+				if err != nil {
+					done[i] = !timedOut()
+					return nil
+				}
+				if cfg.verifyType || cfg.typeAnnotation {
+					ok, err := c.isArtifactKind(gctx, ref, kind, cfg.typeAnnotation)
+					if err != nil || !ok {
+						done[i] = err == nil || !timedOut()
+						return nil
+					}
+				}
+				a.Reference = ref
 			}
+			if cfg.resolvesUpdated() {
+				updated, err := c.manifestCreated(gctx, a.Reference)
+				if err != nil && timedOut() {
+					return nil
+				}
+				a.Updated = updated
+			}
+			done[i] = true
 
+			if cfg.layout != nil {
+				a.Name = cfg.layout.Name(repo)
+			}
 			mu.Lock()
-			artifacts = append(artifacts, listedArtifact{
-				Repository: repo,
-				Reference:  ref,
-			})
+			artifacts = append(artifacts, a)
 			mu.Unlock()
 			return nil
 		})
 	}
 
 	if err := g.Wait(); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	slices.SortFunc(artifacts, func(a, b listedArtifact) int {
 		return strings.Compare(a.Repository, b.Repository)
 	})
 
-	return artifacts, nil
+	n = slices.Index(done, false)
+	if n < 0 || ctx.Err() != nil {
+		return artifacts, len(repos), nil
+	}
+	next := repos[n]
+	artifacts = slices.DeleteFunc(artifacts, func(a listedArtifact) bool { return a.Repository >= next })
+	return artifacts, n, nil
+}
+
+// continuationPrefix starts every decoded continuation token, so that
+// the token resuming from the beginning is not empty.
+const continuationPrefix = "after:"
+
+// encodeContinuation returns the continuation token that resumes a
+// listing after repository.
+func encodeContinuation(repository string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(continuationPrefix + repository))
+}
+
+// decodeContinuation returns the repository a continuation token resumes
+// after; the empty token starts from the beginning.
+func decodeContinuation(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", fmt.Errorf("invalid continuation token: %w", err)
+	}
+	after, ok := strings.CutPrefix(string(b), continuationPrefix)
+	if !ok {
+		return "", fmt.Errorf("invalid continuation token %q", token)
+	}
+	return after, nil
+}
+
+// listNamespace lists the repositories under base that pass the layout,
+// globs, label, and filter of cfg.
+func (c *Client) listNamespace(ctx context.Context, base string, cfg *listConfig) ([]string, error) {
+	var repos []string
+	err := c.scanNamespace(ctx, base, "", cfg, func(repo string) error {
+		repos = append(repos, repo)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.filterNamespace(ctx, base, repos, cfg)
+}
+
+// filterNamespace returns the repos of base that pass the layout, globs,
+// label, and filter of cfg.
+func (c *Client) filterNamespace(ctx context.Context, base string, repos []string, cfg *listConfig) ([]string, error) {
+	if cfg.layout != nil {
+		repos = slices.DeleteFunc(repos, func(r string) bool { return !cfg.layout.contains(base, r) })
+	}
+	repos = slices.DeleteFunc(repos, func(r string) bool { return !cfg.matchesGlobs(base, r) })
+
+	if cfg.label != "" {
+		var err error
+		repos, err = c.filterByLabel(ctx, base, repos, cfg.label)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.filter != nil {
+		filtered := repos[:0]
+		for _, r := range repos {
+			if cfg.filter(r) {
+				filtered = append(filtered, r)
+			}
+		}
+		repos = filtered
+	}
+	return repos, nil
 }
 
 // ListPersonalities discovers all personality artifacts under the default
@@ -117,27 +369,74 @@ func (c *Client) listArtifacts(ctx context.Context, defaultBase string, opts ...
 // ListEntry results with name and version extracted from the repository
 // path and tag.
 func (c *Client) ListPersonalities(ctx context.Context, opts ...ListOption) ([]ListEntry, error) {
-	return c.listEntries(ctx, DefaultPersonalityRegistry, opts...)
+	return c.listEntries(ctx, c.registryBase(ctx, KindPersonality), personalityArtifact, opts...)
 }
 
 // ListPlugins discovers all plugin artifacts under the default plugin
 // registry (or a custom one via WithRegistry) and returns ListEntry results.
 func (c *Client) ListPlugins(ctx context.Context, opts ...ListOption) ([]ListEntry, error) {
-	return c.listEntries(ctx, DefaultPluginRegistry, opts...)
+	return c.listEntries(ctx, c.registryBase(ctx, KindPlugin), pluginArtifact, opts...)
+}
+
+// ListPluginsDetailed lists plugins like ListPlugins and describes the
+// latest version of each, adding its metadata and manifest digest. This
+// costs one manifest and one config blob GET per plugin. Plugins that cannot
+// be described are skipped, matching how listing skips repositories without
+// semver tags.
+func (c *Client) ListPluginsDetailed(ctx context.Context, opts ...ListOption) ([]DetailedListEntry, error) {
+	opts = append(opts[:len(opts):len(opts)], requireField(FieldVersion))
+	entries, listErr := c.ListPlugins(ctx, opts...)
+	if listErr != nil && !isTruncated(listErr) {
+		return nil, listErr
+	}
+
+	detailed := make([]*DetailedListEntry, len(entries))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+	for i, e := range entries {
+		g.Go(func() error {
+			desc, err := c.DescribePlugin(gctx, e.Reference)
+			if err != nil {
+				return nil
+			}
+			detailed[i] = &DetailedListEntry{
+				ListEntry: e,
+				Digest:    desc.Digest,
+				Plugin:    desc.Plugin,
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	result := make([]DetailedListEntry, 0, len(detailed))
+	for _, d := range detailed {
+		if d != nil {
+			result = append(result, *d)
+		}
+	}
+	return result, listErr
 }
 
 // ListToolchains discovers all toolchain images under the default toolchain
-// registry (or a custom one via WithRegistry) and returns ListEntry results.
+// registry (or a custom one via WithRegistry, or by naming convention via
+// WithToolchainLayout) and returns ListEntry results.
 func (c *Client) ListToolchains(ctx context.Context, opts ...ListOption) ([]ListEntry, error) {
-	return c.listEntries(ctx, DefaultToolchainRegistry, opts...)
+	return c.listEntries(ctx, c.registryBase(ctx, KindToolchain), toolchainArtifact, opts...)
 }
 
-func (c *Client) listEntries(ctx context.Context, defaultBase string, opts ...ListOption) ([]ListEntry, error) {
-	artifacts, err := c.listArtifacts(ctx, defaultBase, opts...)
-	if err != nil {
+func (c *Client) listEntries(ctx context.Context, defaultBase string, kind artifactKind, opts ...ListOption) ([]ListEntry, error) {
+	artifacts, err := c.listArtifacts(ctx, defaultBase, kind, opts...)
+	if err != nil && !isTruncated(err) {
 		return nil, err
 	}
+	return toListEntries(artifacts), err
+}
 
+// toListEntries converts listed artifacts to list entries.
+func toListEntries(artifacts []listedArtifact) []ListEntry {
 	result := make([]ListEntry, len(artifacts))
 	for i, a := range artifacts {
 		name, version := extractNameVersion(a)
@@ -146,13 +445,61 @@ func (c *Client) listEntries(ctx context.Context, defaultBase string, opts ...Li
 			Version:    version,
 			Repository: a.Repository,
 			Reference:  a.Reference,
+			Archived:   a.Archived,
+			Updated:    a.Updated,
 		}
 	}
-	return result, nil
+	return result
+}
+
+// isTruncated reports whether err is an *ErrTruncated, which comes with
+// partial results.
+func isTruncated(err error) bool {
+	var truncated *ErrTruncated
+	return errors.As(err, &truncated)
 }
 
+// isArtifactKind fetches the manifest for ref and reports whether it is an
+// artifact of the given kind. With byAnnotation, only the AnnotationType
+// annotation decides; otherwise media types and the annotation are checked
+// as by checkArtifactKind.
+func (c *Client) isArtifactKind(ctx context.Context, ref string, kind artifactKind, byAnnotation bool) (bool, error) {
+	repo, tag, err := c.newRepository(ref)
+	if err != nil {
+		return false, err
+	}
+	_, rc, err := repo.FetchReference(ctx, tag)
+	if err != nil {
+		return false, fmt.Errorf("fetching manifest for %s: %w", ref, err)
+	}
+	defer rc.Close()
+
+	var m struct {
+		MediaType    string `json:"mediaType"`
+		ArtifactType string `json:"artifactType"`
+		Config       struct {
+			MediaType string `json:"mediaType"`
+		} `json:"config"`
+		Annotations map[string]string `json:"annotations"`
+	}
+	if err := json.NewDecoder(io.LimitReader(rc, maxManifestBytes)).Decode(&m); err != nil {
+		return false, fmt.Errorf("parsing manifest for %s: %w", ref, err)
+	}
+	if byAnnotation {
+		return KindFromAnnotations(m.Annotations) == kind.Kind, nil
+	}
+	return checkArtifactKind(ref, kind, m.MediaType, m.ArtifactType, m.Config.MediaType, m.Annotations) == nil, nil
+}
+
+// maxManifestBytes bounds manifest reads. The distribution spec recommends
+// registries accept manifests of at least 4 MiB.
+const maxManifestBytes = 4 * 1024 * 1024
+
 func extractNameVersion(a listedArtifact) (name, version string) {
-	name = ShortName(a.Repository)
+	name = a.Name
+	if name == "" {
+		name = ShortName(a.Repository)
+	}
 	_, version = SplitNameTag(a.Reference)
 	return name, version
 }
@@ -160,29 +507,30 @@ func extractNameVersion(a listedArtifact) (name, version string) {
 // ListPluginVersions returns all semver tags for a plugin, sorted descending.
 // nameOrRef can be a short name (e.g. "gs-base") or a full OCI repository path.
 func (c *Client) ListPluginVersions(ctx context.Context, nameOrRef string) ([]string, error) {
-	return c.listVersions(ctx, nameOrRef, DefaultPluginRegistry)
+	return c.listVersions(ctx, nameOrRef, KindPlugin)
 }
 
 // ListPersonalityVersions returns all semver tags for a personality, sorted descending.
 // nameOrRef can be a short name (e.g. "sre") or a full OCI repository path.
 func (c *Client) ListPersonalityVersions(ctx context.Context, nameOrRef string) ([]string, error) {
-	return c.listVersions(ctx, nameOrRef, DefaultPersonalityRegistry)
+	return c.listVersions(ctx, nameOrRef, KindPersonality)
 }
 
 // ListToolchainVersions returns all semver tags for a toolchain, sorted descending.
 // nameOrRef can be a short name (e.g. "go") or a full OCI repository path.
 func (c *Client) ListToolchainVersions(ctx context.Context, nameOrRef string) ([]string, error) {
-	return c.listVersions(ctx, nameOrRef, DefaultToolchainRegistry)
+	return c.listVersions(ctx, nameOrRef, KindToolchain)
 }
 
 // listVersions lists all semver tags for a single artifact, sorted descending.
-// Short names (no "/") are expanded using the given registry base.
-func (c *Client) listVersions(ctx context.Context, nameOrRef, registryBase string) ([]string, error) {
+// Short names (no "/") are expanded using the registry base of kind.
+func (c *Client) listVersions(ctx context.Context, nameOrRef string, kind Kind) ([]string, error) {
 	nameOrRef = strings.TrimSpace(nameOrRef)
 	if nameOrRef == "" {
 		return nil, fmt.Errorf("empty artifact reference")
 	}
 
+	registryBase, nameOrRef := c.expandTenant(ctx, kind, nameOrRef)
 	repo := nameOrRef
 	if !strings.Contains(nameOrRef, "/") {
 		repo = registryBase + "/" + nameOrRef
@@ -2,12 +2,17 @@ package oci
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"slices"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 // newTestRegistry creates a minimal OCI distribution API server backed by the
@@ -127,7 +132,7 @@ func TestListArtifacts(t *testing.T) {
 	client := NewClient(WithPlainHTTP(true))
 
 	t.Run("discovers artifacts with latest semver", func(t *testing.T) {
-		artifacts, err := client.listArtifacts(t.Context(), base)
+		artifacts, err := client.listArtifacts(t.Context(), base, pluginArtifact)
 		if err != nil {
 			t.Fatalf("listArtifacts() error = %v", err)
 		}
@@ -149,7 +154,7 @@ func TestListArtifacts(t *testing.T) {
 	})
 
 	t.Run("WithFilter keeps only matching repos", func(t *testing.T) {
-		artifacts, err := client.listArtifacts(t.Context(), base,
+		artifacts, err := client.listArtifacts(t.Context(), base, pluginArtifact,
 			WithFilter(func(repo string) bool {
 				return strings.HasSuffix(repo, "gs-base")
 			}),
@@ -166,7 +171,7 @@ func TestListArtifacts(t *testing.T) {
 	})
 
 	t.Run("WithFilter rejecting all returns empty", func(t *testing.T) {
-		artifacts, err := client.listArtifacts(t.Context(), base,
+		artifacts, err := client.listArtifacts(t.Context(), base, pluginArtifact,
 			WithFilter(func(string) bool { return false }),
 		)
 		if err != nil {
@@ -602,3 +607,213 @@ func TestListEntry_Fields(t *testing.T) {
 		t.Errorf("Reference = %q, want suffix :v1.0.0", entry.Reference)
 	}
 }
+
+func TestListPlugins_VerifyArtifactType(t *testing.T) {
+	pluginJSON, _ := json.Marshal(pluginConfigBlob{Skills: []string{"kubernetes"}})
+	personalityJSON, _ := json.Marshal(personalityConfigBlob{})
+
+	ts := newArtifactRegistry(map[string]testArtifactEntry{
+		"giantswarm/klaus-plugins/gs-base": {
+			configJSON:      pluginJSON,
+			configMediaType: MediaTypePluginConfig,
+			tags:            []string{"v1.0.0"},
+		},
+		"giantswarm/klaus-plugins/misplaced-sre": {
+			configJSON:      personalityJSON,
+			configMediaType: MediaTypePersonalityConfig,
+			tags:            []string{"v1.0.0"},
+		},
+		"giantswarm/klaus-plugins/go-image": {
+			configJSON:      []byte("{}"),
+			configMediaType: ocispec.MediaTypeImageConfig,
+			tags:            []string{"v1.0.0"},
+		},
+	})
+	defer ts.Close()
+	host := testRegistryHost(ts)
+
+	client := NewClient(WithPlainHTTP(true))
+	base := WithRegistry(host + "/giantswarm/klaus-plugins")
+
+	unverified, err := client.ListPlugins(t.Context(), base)
+	if err != nil {
+		t.Fatalf("ListPlugins() error = %v", err)
+	}
+	if len(unverified) != 3 {
+		t.Fatalf("without verification got %d entries, want 3", len(unverified))
+	}
+
+	verified, err := client.ListPlugins(t.Context(), base, WithVerifyArtifactType())
+	if err != nil {
+		t.Fatalf("ListPlugins(WithVerifyArtifactType) error = %v", err)
+	}
+	if len(verified) != 1 || verified[0].Name != "gs-base" {
+		t.Errorf("verified entries = %+v, want only gs-base", verified)
+	}
+
+	toolchains, err := client.ListToolchains(t.Context(), base, WithVerifyArtifactType())
+	if err != nil {
+		t.Fatalf("ListToolchains(WithVerifyArtifactType) error = %v", err)
+	}
+	if len(toolchains) != 1 || toolchains[0].Name != "go-image" {
+		t.Errorf("verified toolchains = %+v, want only go-image", toolchains)
+	}
+}
+
+func TestListToolchains_TypeAnnotation(t *testing.T) {
+	pluginJSON, _ := json.Marshal(pluginConfigBlob{})
+
+	// A mixed namespace: a plugin, an annotated toolchain image, and an
+	// unrelated container image that media types alone would accept as a
+	// toolchain.
+	ts := newArtifactRegistry(map[string]testArtifactEntry{
+		"giantswarm/klaus/gs-base": {
+			configJSON:      pluginJSON,
+			configMediaType: MediaTypePluginConfig,
+			tags:            []string{"v1.0.0"},
+			annotations:     Plugin{Name: "gs-base"}.Annotations(),
+		},
+		"giantswarm/klaus/go": {
+			configJSON:      []byte("{}"),
+			configMediaType: ocispec.MediaTypeImageConfig,
+			tags:            []string{"v1.0.0"},
+			annotations:     Toolchain{Name: "go"}.Annotations(),
+		},
+		"giantswarm/klaus/nginx": {
+			configJSON:      []byte("{}"),
+			configMediaType: ocispec.MediaTypeImageConfig,
+			tags:            []string{"v1.0.0"},
+		},
+	})
+	defer ts.Close()
+	host := testRegistryHost(ts)
+
+	client := NewClient(WithPlainHTTP(true))
+	base := WithRegistry(host + "/giantswarm/klaus")
+
+	byMediaType, err := client.ListToolchains(t.Context(), base, WithVerifyArtifactType())
+	if err != nil {
+		t.Fatalf("ListToolchains(WithVerifyArtifactType) error = %v", err)
+	}
+	if len(byMediaType) != 2 {
+		t.Errorf("media type verification got %d toolchains, want go and nginx", len(byMediaType))
+	}
+
+	toolchains, err := client.ListToolchains(t.Context(), base, WithTypeAnnotation())
+	if err != nil {
+		t.Fatalf("ListToolchains(WithTypeAnnotation) error = %v", err)
+	}
+	if len(toolchains) != 1 || toolchains[0].Name != "go" {
+		t.Errorf("toolchains = %+v, want only go", toolchains)
+	}
+
+	plugins, err := client.ListPlugins(t.Context(), base, WithTypeAnnotation())
+	if err != nil {
+		t.Fatalf("ListPlugins(WithTypeAnnotation) error = %v", err)
+	}
+	if len(plugins) != 1 || plugins[0].Name != "gs-base" {
+		t.Errorf("plugins = %+v, want only gs-base", plugins)
+	}
+}
+
+func TestListToolchains_Layout(t *testing.T) {
+	toolchain := testArtifactEntry{
+		configJSON:      []byte("{}"),
+		configMediaType: ocispec.MediaTypeImageConfig,
+		tags:            []string{"v1.0.0"},
+	}
+	ts := newArtifactRegistry(map[string]testArtifactEntry{
+		"giantswarm/klaus-go":                   toolchain,
+		"giantswarm/klaus-toolchains/python":    toolchain,
+		"giantswarm/klaus-plugins/gs-base":      toolchain,
+		"giantswarm/klaus-personalities/sre":    toolchain,
+		"giantswarm/app-operator":               toolchain,
+		"giantswarm/klaus-toolchains-archive/x": toolchain,
+	})
+	defer ts.Close()
+	host := testRegistryHost(ts)
+
+	layout := DefaultToolchainLayout()
+	layout.Base = host + "/giantswarm"
+
+	client := NewClient(WithPlainHTTP(true))
+	entries, err := client.ListToolchains(t.Context(), WithToolchainLayout(layout), WithRegistry(host+"/ignored"))
+	if err != nil {
+		t.Fatalf("ListToolchains() error = %v", err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name+"="+e.Repository)
+	}
+	want := []string{
+		"go=" + host + "/giantswarm/klaus-go",
+		"python=" + host + "/giantswarm/klaus-toolchains/python",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ListToolchains() = %v, want %v", got, want)
+	}
+}
+
+func TestListPlugins_PartialResults(t *testing.T) {
+	backend := newTestRegistry(map[string][]string{
+		"klaus-plugins/gs-a": {"v1.0.0"},
+		"klaus-plugins/gs-b": {"v1.0.0"},
+		"klaus-plugins/gs-c": {"v1.0.0"},
+		"klaus-plugins/gs-d": {"v1.0.0"},
+	})
+	defer backend.Close()
+	var slow atomic.Bool
+	slow.Store(true)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slow.Load() && r.URL.Path == "/v2/klaus-plugins/gs-c/tags/list" {
+			<-r.Context().Done()
+			return
+		}
+		backend.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	base := testRegistryHost(ts) + "/klaus-plugins"
+	client := NewClient(WithPlainHTTP(true))
+
+	plugins, err := client.ListPlugins(t.Context(), WithRegistry(base),
+		WithPartialResults(time.Now().Add(200*time.Millisecond)))
+	var truncated *ErrTruncated
+	if !errors.As(err, &truncated) {
+		t.Fatalf("ListPlugins() error = %v, want *ErrTruncated", err)
+	}
+	if truncated.Remaining != 2 {
+		t.Errorf("Remaining = %d, want 2", truncated.Remaining)
+	}
+	if got := listEntryNames(plugins); !slices.Equal(got, []string{"gs-a", "gs-b"}) {
+		t.Errorf("partial plugins = %v, want [gs-a gs-b]", got)
+	}
+
+	slow.Store(false)
+	plugins, err = client.ListPlugins(t.Context(), WithRegistry(base),
+		WithPartialResults(time.Now().Add(time.Minute)), WithContinuation(truncated.Continuation))
+	if err != nil {
+		t.Fatalf("continued ListPlugins() error = %v", err)
+	}
+	if got := listEntryNames(plugins); !slices.Equal(got, []string{"gs-c", "gs-d"}) {
+		t.Errorf("continued plugins = %v, want [gs-c gs-d]", got)
+	}
+}
+
+func TestListPlugins_InvalidContinuation(t *testing.T) {
+	ts := newTestRegistry(map[string][]string{"klaus-plugins/gs-a": {"v1.0.0"}})
+	defer ts.Close()
+	client := NewClient(WithPlainHTTP(true))
+
+	_, err := client.ListPlugins(t.Context(), WithRegistry(testRegistryHost(ts)+"/klaus-plugins"), WithContinuation("%%%"))
+	if err == nil || !strings.Contains(err.Error(), "continuation token") {
+		t.Errorf("ListPlugins() error = %v, want invalid continuation token", err)
+	}
+}
+
+func listEntryNames(entries []ListEntry) []string {
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name
+	}
+	return names
+}
@@ -0,0 +1,80 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	godigest "github.com/opencontainers/go-digest"
+)
+
+// ErrNoContentHash is returned by IsLocalDirCurrent for artifacts pushed
+// before config blobs recorded the content-tree hash.
+var ErrNoContentHash = errors.New("artifact records no content-tree hash")
+
+// IsLocalDirCurrent reports whether dir holds the content of an artifact
+// whose config blob records contentHash, such as the ContentHash of a
+// described plugin or personality. It reads dir but makes no registry
+// requests. It returns ErrNoContentHash when contentHash is empty;
+// Client.NeedsUpdate compares such artifacts by packaging dir instead.
+func IsLocalDirCurrent(dir, contentHash string) (bool, error) {
+	if contentHash == "" {
+		return false, ErrNoContentHash
+	}
+	local, err := ContentTreeHash(dir)
+	if err != nil {
+		return false, err
+	}
+	return local == contentHash, nil
+}
+
+// NeedsUpdate reports whether the content of the local directory dir
+// differs from the plugin or personality published at ref, e.g. a source
+// directory with unpublished changes or a pulled copy of another version.
+// ref must be a fully-qualified reference; references without a tag
+// resolve to the latest version first.
+//
+// The content-tree hash in the config blob is compared when recorded, so
+// only the manifest and config blob are fetched. Otherwise dir is packaged
+// and the digest of the result compared with the published content layer,
+// which matches for the same content since packaging is deterministic.
+// Encrypted artifacts without a content-tree hash cannot be compared.
+func (c *Client) NeedsUpdate(ctx context.Context, dir, ref string) (bool, error) {
+	fm, resolved, err := c.fetchReferrerSubject(ctx, ref)
+	if err != nil {
+		return false, err
+	}
+	kind, ok := kindOfManifest(fm.mediaType, fm.manifest.ArtifactType, fm.manifest.Config.MediaType)
+	if !ok || (kind != KindPlugin && kind != KindPersonality) {
+		return false, fmt.Errorf("%s is not a plugin or personality", resolved)
+	}
+
+	configJSON, err := fetchConfigBlob(ctx, fm.repo, resolved, fm.manifest.Config)
+	if err != nil {
+		return false, err
+	}
+	var blob struct {
+		ContentHash string `json:"contentHash"`
+	}
+	if err := json.Unmarshal(configJSON, &blob); err != nil {
+		return false, fmt.Errorf("parsing %s config for %s: %w", kind, resolved, err)
+	}
+	current, err := IsLocalDirCurrent(dir, blob.ContentHash)
+	if !errors.Is(err, ErrNoContentHash) {
+		return !current, err
+	}
+
+	if len(fm.manifest.Layers) == 0 {
+		return false, fmt.Errorf("%s has no content layer", resolved)
+	}
+	layer := fm.manifest.Layers[0]
+	if isEncryptedMediaType(layer.MediaType) {
+		return false, fmt.Errorf("cannot compare %s: its content layer is encrypted and it records no content-tree hash", resolved)
+	}
+	digester := godigest.SHA256.Digester()
+	if err := writeTarGz(digester.Hash(), dir); err != nil {
+		return false, fmt.Errorf("packaging %s: %w", dir, err)
+	}
+	return digester.Digest() != layer.Digest, nil
+}
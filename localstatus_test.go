@@ -0,0 +1,57 @@
+package oci
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestNeedsUpdate(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, ".claude-plugin", "plugin.json"), `{"name":"gs-base"}`)
+	writeFile(t, filepath.Join(src, "skills", "kubectl", "SKILL.md"), "# kubectl")
+	hashed := host + "/klaus/gs-base:v1.0.0"
+	if _, err := client.PushPlugin(t.Context(), src, hashed, Plugin{Name: "gs-base"}); err != nil {
+		t.Fatalf("PushPlugin() error = %v", err)
+	}
+
+	// An artifact pushed before config blobs recorded the hash.
+	configJSON, _ := json.Marshal(pluginConfigBlob{})
+	built, err := buildArtifact(src, configJSON, nil, pluginArtifact, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unhashed := host + "/klaus/gs-base:v0.9.0"
+	if _, err := client.push(t.Context(), unhashed, built, func() {}, nil); err != nil {
+		t.Fatalf("push() error = %v", err)
+	}
+
+	for _, ref := range []string{hashed, unhashed} {
+		if stale, err := client.NeedsUpdate(t.Context(), src, ref); err != nil || stale {
+			t.Errorf("NeedsUpdate(%s) = %v, %v, want false", ref, stale, err)
+		}
+	}
+
+	writeFile(t, filepath.Join(src, "skills", "kubectl", "SKILL.md"), "# kubectl, edited")
+	for _, ref := range []string{hashed, unhashed} {
+		if stale, err := client.NeedsUpdate(t.Context(), src, ref); err != nil || !stale {
+			t.Errorf("NeedsUpdate(%s) after an edit = %v, %v, want true", ref, stale, err)
+		}
+	}
+
+	described, err := client.DescribePlugin(t.Context(), hashed)
+	if err != nil {
+		t.Fatalf("DescribePlugin() error = %v", err)
+	}
+	if current, err := IsLocalDirCurrent(src, described.ContentHash); err != nil || current {
+		t.Errorf("IsLocalDirCurrent() = %v, %v, want false", current, err)
+	}
+	if _, err := IsLocalDirCurrent(src, ""); !errors.Is(err, ErrNoContentHash) {
+		t.Errorf("IsLocalDirCurrent() without a hash error = %v, want ErrNoContentHash", err)
+	}
+}
@@ -0,0 +1,282 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// MediaTypeMarketplace is the media type of a marketplace.json layer and
+// the artifactType of marketplace index artifacts.
+const MediaTypeMarketplace = "application/vnd.giantswarm.klaus-marketplace.v1+json"
+
+// DefaultMarketplaceName is the marketplace name used by ExportMarketplace
+// unless overridden with WithMarketplaceName.
+const DefaultMarketplaceName = "klaus"
+
+// MarketplaceOption configures ExportMarketplace.
+type MarketplaceOption func(*marketplace)
+
+// WithMarketplaceName sets the marketplace name users add with
+// "/plugin marketplace add". Defaults to DefaultMarketplaceName.
+func WithMarketplaceName(name string) MarketplaceOption {
+	return func(m *marketplace) { m.Name = name }
+}
+
+// WithMarketplaceOwner sets the marketplace maintainer.
+func WithMarketplaceOwner(owner Author) MarketplaceOption {
+	return func(m *marketplace) { m.Owner = owner }
+}
+
+// WithMarketplaceDescription sets the marketplace description.
+func WithMarketplaceDescription(description string) MarketplaceOption {
+	return func(m *marketplace) {
+		if m.Metadata == nil {
+			m.Metadata = &marketplaceMetadata{}
+		}
+		m.Metadata.Description = description
+	}
+}
+
+// marketplace is the Claude Code marketplace.json document:
+// https://code.claude.com/docs/en/plugin-marketplaces#marketplace-schema
+type marketplace struct {
+	Name     string               `json:"name"`
+	Owner    Author               `json:"owner"`
+	Metadata *marketplaceMetadata `json:"metadata,omitempty"`
+	Plugins  []marketplacePlugin  `json:"plugins"`
+}
+
+type marketplaceMetadata struct {
+	Description string `json:"description,omitempty"`
+}
+
+// marketplacePlugin is a marketplace plugin entry. OCI carries the Klaus
+// reference so that an imported marketplace can be pulled from the registry
+// again; Claude Code ignores the field.
+type marketplacePlugin struct {
+	Name        string            `json:"name"`
+	Source      marketplaceSource `json:"source"`
+	Description string            `json:"description,omitempty"`
+	Version     string            `json:"version,omitempty"`
+	Author      *Author           `json:"author,omitempty"`
+	Homepage    string            `json:"homepage,omitempty"`
+	Repository  string            `json:"repository,omitempty"`
+	License     string            `json:"license,omitempty"`
+	Keywords    []string          `json:"keywords,omitempty"`
+	OCI         *PluginReference  `json:"oci,omitempty"`
+}
+
+// marketplaceSource is the object form of a plugin source. Relative path
+// sources (plain strings) are accepted on import.
+type marketplaceSource struct {
+	Source string `json:"source"`
+	Repo   string `json:"repo,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Path   string `json:"-"`
+}
+
+func (s marketplaceSource) MarshalJSON() ([]byte, error) {
+	if s.Path != "" {
+		return json.Marshal(s.Path)
+	}
+	type plain marketplaceSource
+	return json.Marshal(plain(s))
+}
+
+func (s *marketplaceSource) UnmarshalJSON(data []byte) error {
+	var path string
+	if err := json.Unmarshal(data, &path); err == nil {
+		*s = marketplaceSource{Path: path}
+		return nil
+	}
+	type plain marketplaceSource
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// ExportMarketplace converts plugin list entries into a Claude Code
+// marketplace.json document. Each plugin's source points at its source
+// repository (GitHub repositories use the "github" source type, others the
+// "url" type), so every entry must have Plugin.SourceRepo set. The OCI
+// reference is kept in an additional "oci" field for ImportMarketplace.
+// Versions are exported without the leading "v" of the OCI tag.
+func ExportMarketplace(entries []DetailedListEntry, opts ...MarketplaceOption) ([]byte, error) {
+	m := marketplace{
+		Name:    DefaultMarketplaceName,
+		Plugins: make([]marketplacePlugin, 0, len(entries)),
+	}
+	for _, opt := range opts {
+		opt(&m)
+	}
+
+	for _, e := range entries {
+		name := e.Plugin.Name
+		if name == "" {
+			name = e.Name
+		}
+		if e.Plugin.SourceRepo == "" {
+			return nil, fmt.Errorf("plugin %s has no source repository", name)
+		}
+		m.Plugins = append(m.Plugins, marketplacePlugin{
+			Name:        name,
+			Source:      marketplaceSourceFor(e.Plugin.SourceRepo),
+			Description: e.Plugin.Description,
+			Version:     strings.TrimPrefix(e.Version, "v"),
+			Author:      e.Plugin.Author,
+			Homepage:    e.Plugin.Homepage,
+			Repository:  e.Plugin.SourceRepo,
+			License:     e.Plugin.License,
+			Keywords:    e.Plugin.Keywords,
+			OCI: &PluginReference{
+				Repository: e.Repository,
+				Tag:        e.Version,
+				Digest:     e.Digest,
+			},
+		})
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling marketplace: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// ImportMarketplace parses a Claude Code marketplace.json document into
+// plugin list entries. Entries exported by ExportMarketplace carry their OCI
+// reference and round-trip completely; entries from other marketplaces have
+// metadata only and an empty Repository and Reference.
+func ImportMarketplace(data []byte) ([]DetailedListEntry, error) {
+	var m marketplace
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing marketplace: %w", err)
+	}
+
+	entries := make([]DetailedListEntry, 0, len(m.Plugins))
+	for _, p := range m.Plugins {
+		if p.Name == "" {
+			return nil, fmt.Errorf("marketplace %s contains a plugin without a name", m.Name)
+		}
+		repo := p.Repository
+		if repo == "" {
+			repo = p.Source.repositoryURL()
+		}
+
+		e := DetailedListEntry{
+			ListEntry: ListEntry{Name: p.Name, Version: p.Version},
+			Plugin: Plugin{
+				Name:        p.Name,
+				Version:     p.Version,
+				Description: p.Description,
+				Author:      p.Author,
+				Homepage:    p.Homepage,
+				SourceRepo:  repo,
+				License:     p.License,
+				Keywords:    p.Keywords,
+			},
+		}
+		if p.OCI != nil && p.OCI.Repository != "" {
+			e.Repository = p.OCI.Repository
+			e.Digest = p.OCI.Digest
+			if p.OCI.Tag != "" {
+				e.Version = p.OCI.Tag
+				e.Plugin.Version = p.OCI.Tag
+			}
+			e.Reference = PluginReference{Repository: p.OCI.Repository, Tag: e.Version}.Ref()
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func marketplaceSourceFor(sourceRepo string) marketplaceSource {
+	if rest, ok := strings.CutPrefix(sourceRepo, "https://github.com/"); ok {
+		rest = strings.TrimSuffix(strings.TrimSuffix(rest, "/"), ".git")
+		if strings.Count(rest, "/") == 1 {
+			return marketplaceSource{Source: "github", Repo: rest}
+		}
+	}
+	return marketplaceSource{Source: "url", URL: sourceRepo}
+}
+
+// repositoryURL returns the repository URL a source points at, if any.
+func (s marketplaceSource) repositoryURL() string {
+	switch s.Source {
+	case "github":
+		return "https://github.com/" + s.Repo
+	case "url":
+		return s.URL
+	}
+	return ""
+}
+
+// GenerateMarketplaceIndex lists the plugins under each registry base,
+// enriches them with their metadata, exports them as a Claude Code
+// marketplace.json, and pushes the document to targetRef (which must include
+// a tag) as a single-layer OCI artifact with artifactType
+// MediaTypeMarketplace. Plugins without a source repository cannot be
+// expressed in a marketplace and are skipped. When the same plugin name
+// appears under several bases, the first base wins.
+//
+// The feed is not signed here; CI pipelines sign the returned digest with
+// their usual tooling (e.g. cosign) so that consumers can verify it.
+func (c *Client) GenerateMarketplaceIndex(ctx context.Context, bases []string, targetRef string, opts ...MarketplaceOption) (*PushResult, error) {
+	var entries []DetailedListEntry
+	seen := make(map[string]bool)
+	for _, base := range bases {
+		listed, err := c.ListPluginsDetailed(ctx, WithRegistry(base))
+		if err != nil {
+			return nil, fmt.Errorf("listing plugins under %s: %w", base, err)
+		}
+		for _, e := range listed {
+			if seen[e.Name] || e.Plugin.SourceRepo == "" {
+				continue
+			}
+			seen[e.Name] = true
+			entries = append(entries, e)
+		}
+	}
+	slices.SortFunc(entries, func(a, b DetailedListEntry) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	data, err := ExportMarketplace(entries, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, tag, err := c.newRepository(targetRef)
+	if err != nil {
+		return nil, err
+	}
+	if tag == "" {
+		return nil, fmt.Errorf("reference %q must include a tag", targetRef)
+	}
+
+	configDesc, err := pushBlob(ctx, repo, ocispec.MediaTypeEmptyJSON, ocispec.DescriptorEmptyJSON.Data)
+	if err != nil {
+		return nil, fmt.Errorf("pushing config blob: %w", err)
+	}
+	layerDesc, err := pushBlob(ctx, repo, MediaTypeMarketplace, data)
+	if err != nil {
+		return nil, fmt.Errorf("pushing marketplace layer: %w", err)
+	}
+	layerDesc.Annotations = map[string]string{ocispec.AnnotationTitle: "marketplace.json"}
+
+	manifestDesc, err := pushManifest(ctx, repo, ocispec.Manifest{
+		Versioned:    specs.Versioned{SchemaVersion: 2},
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: MediaTypeMarketplace,
+		Config:       configDesc,
+		Layers:       []ocispec.Descriptor{layerDesc},
+	}, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &PushResult{Digest: manifestDesc.Digest.String()}, nil
+}
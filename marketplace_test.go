@@ -0,0 +1,242 @@
+package oci
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func testMarketplaceEntries() []DetailedListEntry {
+	return []DetailedListEntry{
+		{
+			ListEntry: ListEntry{
+				Name:       "gs-base",
+				Version:    "v1.2.0",
+				Repository: "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base",
+				Reference:  "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base:v1.2.0",
+			},
+			Digest: "sha256:abc",
+			Plugin: Plugin{
+				Name:        "gs-base",
+				Version:     "v1.2.0",
+				Description: "A general purpose plugin",
+				Author:      &Author{Name: "Giant Swarm GmbH"},
+				SourceRepo:  "https://github.com/giantswarm/claude-code",
+				License:     "Apache-2.0",
+				Keywords:    []string{"giantswarm"},
+			},
+		},
+		{
+			ListEntry: ListEntry{
+				Name:       "internal",
+				Version:    "v0.1.0",
+				Repository: "registry.example.com/plugins/internal",
+				Reference:  "registry.example.com/plugins/internal:v0.1.0",
+			},
+			Plugin: Plugin{
+				Name:       "internal",
+				SourceRepo: "https://git.example.com/plugins/internal.git",
+			},
+		},
+	}
+}
+
+func TestExportMarketplace(t *testing.T) {
+	data, err := ExportMarketplace(testMarketplaceEntries(),
+		WithMarketplaceName("giantswarm"),
+		WithMarketplaceOwner(Author{Name: "Giant Swarm", Email: "dev@giantswarm.io"}),
+		WithMarketplaceDescription("Giant Swarm Klaus plugins"),
+	)
+	if err != nil {
+		t.Fatalf("ExportMarketplace() error = %v", err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if doc["name"] != "giantswarm" {
+		t.Errorf("name = %v, want giantswarm", doc["name"])
+	}
+	if owner := doc["owner"].(map[string]any); owner["email"] != "dev@giantswarm.io" {
+		t.Errorf("owner = %v", owner)
+	}
+
+	plugins := doc["plugins"].([]any)
+	if len(plugins) != 2 {
+		t.Fatalf("got %d plugins, want 2", len(plugins))
+	}
+	first := plugins[0].(map[string]any)
+	if first["version"] != "1.2.0" {
+		t.Errorf("version = %v, want 1.2.0", first["version"])
+	}
+	src := first["source"].(map[string]any)
+	if src["source"] != "github" || src["repo"] != "giantswarm/claude-code" {
+		t.Errorf("source = %v, want github giantswarm/claude-code", src)
+	}
+	second := plugins[1].(map[string]any)
+	src = second["source"].(map[string]any)
+	if src["source"] != "url" || src["url"] != "https://git.example.com/plugins/internal.git" {
+		t.Errorf("source = %v, want url source", src)
+	}
+}
+
+func TestExportMarketplace_Defaults(t *testing.T) {
+	data, err := ExportMarketplace(nil)
+	if err != nil {
+		t.Fatalf("ExportMarketplace() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"name": "klaus"`) || !strings.Contains(string(data), `"plugins": []`) {
+		t.Errorf("unexpected output: %s", data)
+	}
+}
+
+func TestExportMarketplace_MissingSource(t *testing.T) {
+	_, err := ExportMarketplace([]DetailedListEntry{{ListEntry: ListEntry{Name: "nosrc"}}})
+	if err == nil || !strings.Contains(err.Error(), "nosrc") {
+		t.Fatalf("error = %v, want error naming the plugin", err)
+	}
+}
+
+func TestImportMarketplace_RoundTrip(t *testing.T) {
+	entries := testMarketplaceEntries()
+	data, err := ExportMarketplace(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ImportMarketplace(data)
+	if err != nil {
+		t.Fatalf("ImportMarketplace() error = %v", err)
+	}
+	if len(got) != len(entries) {
+		t.Fatalf("got %d entries, want %d", len(got), len(entries))
+	}
+	for i := range entries {
+		if got[i].ListEntry != entries[i].ListEntry {
+			t.Errorf("entry %d ListEntry = %+v, want %+v", i, got[i].ListEntry, entries[i].ListEntry)
+		}
+		if got[i].Digest != entries[i].Digest {
+			t.Errorf("entry %d Digest = %q, want %q", i, got[i].Digest, entries[i].Digest)
+		}
+		if got[i].Plugin.SourceRepo != entries[i].Plugin.SourceRepo {
+			t.Errorf("entry %d SourceRepo = %q, want %q", i, got[i].Plugin.SourceRepo, entries[i].Plugin.SourceRepo)
+		}
+	}
+	if got[0].Plugin.Description != "A general purpose plugin" || got[0].Plugin.Author.Name != "Giant Swarm GmbH" {
+		t.Errorf("metadata not preserved: %+v", got[0].Plugin)
+	}
+}
+
+func TestImportMarketplace_ClaudeMarketplace(t *testing.T) {
+	data := []byte(`{
+  "name": "company-tools",
+  "owner": {"name": "DevTools Team"},
+  "plugins": [
+    {"name": "local-plugin", "source": "./plugins/local", "version": "2.0.0"},
+    {"name": "gh-plugin", "source": {"source": "github", "repo": "acme/gh-plugin"}, "description": "From GitHub"}
+  ]
+}`)
+	got, err := ImportMarketplace(data)
+	if err != nil {
+		t.Fatalf("ImportMarketplace() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	if got[0].Name != "local-plugin" || got[0].Version != "2.0.0" || got[0].Reference != "" {
+		t.Errorf("entry 0 = %+v", got[0])
+	}
+	if got[1].Plugin.SourceRepo != "https://github.com/acme/gh-plugin" {
+		t.Errorf("SourceRepo = %q, want GitHub URL", got[1].Plugin.SourceRepo)
+	}
+}
+
+func TestImportMarketplace_Invalid(t *testing.T) {
+	if _, err := ImportMarketplace([]byte("not json")); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+	if _, err := ImportMarketplace([]byte(`{"name":"x","plugins":[{"source":"./a"}]}`)); err == nil {
+		t.Error("expected error for plugin without name")
+	}
+}
+
+func TestGenerateMarketplaceIndex(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+
+	src := t.TempDir()
+	plugins := []struct {
+		ref    string
+		plugin Plugin
+	}{
+		{host + "/team-a/plugins/gs-base:v1.0.0", Plugin{Name: "gs-base", SourceRepo: "https://github.com/giantswarm/gs-base"}},
+		{host + "/team-a/plugins/no-source:v1.0.0", Plugin{Name: "no-source"}},
+		{host + "/team-b/plugins/gs-base:v2.0.0", Plugin{Name: "gs-base", SourceRepo: "https://github.com/other/gs-base"}},
+		{host + "/team-b/plugins/extra:v0.1.0", Plugin{Name: "extra", SourceRepo: "https://github.com/giantswarm/extra"}},
+	}
+	for _, p := range plugins {
+		if _, err := client.PushPlugin(t.Context(), src, p.ref, p.plugin); err != nil {
+			t.Fatalf("PushPlugin(%s) error = %v", p.ref, err)
+		}
+	}
+
+	result, err := client.GenerateMarketplaceIndex(t.Context(),
+		[]string{host + "/team-a/plugins", host + "/team-b/plugins"},
+		host+"/marketplace:latest",
+		WithMarketplaceName("giantswarm"),
+	)
+	if err != nil {
+		t.Fatalf("GenerateMarketplaceIndex() error = %v", err)
+	}
+
+	reg.mu.Lock()
+	manifestJSON := reg.manifests[result.Digest]
+	reg.mu.Unlock()
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.ArtifactType != MediaTypeMarketplace {
+		t.Errorf("artifactType = %q, want %q", manifest.ArtifactType, MediaTypeMarketplace)
+	}
+	if len(manifest.Layers) != 1 || manifest.Layers[0].MediaType != MediaTypeMarketplace {
+		t.Fatalf("layers = %+v, want one marketplace layer", manifest.Layers)
+	}
+
+	reg.mu.Lock()
+	data := reg.blobs[manifest.Layers[0].Digest.String()]
+	reg.mu.Unlock()
+	entries, err := ImportMarketplace(data)
+	if err != nil {
+		t.Fatalf("ImportMarketplace() error = %v", err)
+	}
+
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name+"@"+e.Version+"="+e.Plugin.SourceRepo)
+	}
+	want := []string{
+		"extra@v0.1.0=https://github.com/giantswarm/extra",
+		"gs-base@v1.0.0=https://github.com/giantswarm/gs-base",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("entries = %v, want %v", got, want)
+	}
+	if entries[1].Digest == "" || entries[1].Reference != host+"/team-a/plugins/gs-base:v1.0.0" {
+		t.Errorf("entry OCI reference not preserved: %+v", entries[1])
+	}
+}
+
+func TestGenerateMarketplaceIndex_RequiresTag(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+
+	if _, err := client.GenerateMarketplaceIndex(t.Context(), nil, host+"/marketplace"); err == nil {
+		t.Error("expected error for target without tag")
+	}
+}
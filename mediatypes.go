@@ -5,6 +5,8 @@
 // and a registry client that both klausctl and the klaus-operator can use.
 package oci
 
+import ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
 // Media types for Klaus plugin artifacts.
 const (
 	// MediaTypePluginConfig is the OCI media type for the plugin config blob.
@@ -12,6 +14,9 @@ const (
 
 	// MediaTypePluginContent is the OCI media type for the plugin content layer.
 	MediaTypePluginContent = "application/vnd.giantswarm.klaus-plugin.content.v1.tar+gzip"
+
+	// ArtifactTypePlugin is the OCI 1.1 manifest artifactType of plugins.
+	ArtifactTypePlugin = "application/vnd.giantswarm.klaus-plugin.v1"
 )
 
 // Media types for Klaus personality artifacts.
@@ -21,24 +26,120 @@ const (
 
 	// MediaTypePersonalityContent is the OCI media type for the personality content layer.
 	MediaTypePersonalityContent = "application/vnd.giantswarm.klaus-personality.content.v1.tar+gzip"
+
+	// ArtifactTypePersonality is the OCI 1.1 manifest artifactType of personalities.
+	ArtifactTypePersonality = "application/vnd.giantswarm.klaus-personality.v1"
 )
 
+// mediaTypeDockerImageConfig is the config media type of Docker schema 2
+// images. Toolchains built with docker buildx may carry either this or the
+// OCI image config media type.
+const mediaTypeDockerImageConfig = "application/vnd.docker.container.image.v1+json"
+
 // artifactKind bundles the media types for a specific Klaus artifact type.
 type artifactKind struct {
+	// Kind is the public identifier of this artifact type.
+	Kind Kind
 	// ConfigMediaType is the media type for the OCI config blob.
 	ConfigMediaType string
 	// ContentMediaType is the media type for the OCI content layer.
 	ContentMediaType string
+	// ArtifactType is the OCI 1.1 manifest artifactType set on push. It
+	// lets registries filter referrers by type and identifies artifacts
+	// whose config is the OCI empty descriptor.
+	ArtifactType string
+	// AltConfigMediaTypes lists additional config media types accepted as
+	// this kind (e.g. Docker image configs for toolchains).
+	AltConfigMediaTypes []string
+	// AcceptsIndex is true for kinds that may be published as an image
+	// index (multi-arch toolchain images), which have no config blob.
+	AcceptsIndex bool
+}
+
+// matchesManifest reports whether a manifest with the given media type,
+// artifactType, and config media type is an artifact of this kind. Either
+// the OCI 1.1 artifactType or the config media type identifies the kind, so
+// artifacts pushed before artifactType was set keep matching.
+func (k artifactKind) matchesManifest(manifestMediaType, artifactType, configMediaType string) bool {
+	if isIndexMediaType(manifestMediaType) {
+		return k.AcceptsIndex
+	}
+	if k.ArtifactType != "" && artifactType == k.ArtifactType {
+		return true
+	}
+	if configMediaType == k.ConfigMediaType {
+		return true
+	}
+	for _, mt := range k.AltConfigMediaTypes {
+		if configMediaType == mt {
+			return true
+		}
+	}
+	return false
+}
+
+func isIndexMediaType(mt string) bool {
+	return mt == ocispec.MediaTypeImageIndex || mt == "application/vnd.docker.distribution.manifest.list.v2+json"
 }
 
 var (
 	pluginArtifact = artifactKind{
+		Kind:             KindPlugin,
 		ConfigMediaType:  MediaTypePluginConfig,
 		ContentMediaType: MediaTypePluginContent,
+		ArtifactType:     ArtifactTypePlugin,
 	}
 
 	personalityArtifact = artifactKind{
+		Kind:             KindPersonality,
 		ConfigMediaType:  MediaTypePersonalityConfig,
 		ContentMediaType: MediaTypePersonalityContent,
+		ArtifactType:     ArtifactTypePersonality,
+	}
+
+	// toolchainArtifact describes toolchains, which are standard container
+	// images rather than custom Klaus artifacts. They have no Klaus content
+	// layer.
+	toolchainArtifact = artifactKind{
+		Kind:                KindToolchain,
+		ConfigMediaType:     ocispec.MediaTypeImageConfig,
+		AltConfigMediaTypes: []string{mediaTypeDockerImageConfig},
+		AcceptsIndex:        true,
 	}
 )
+
+// knownArtifactKinds lists the built-in kinds in detection order.
+var knownArtifactKinds = []artifactKind{pluginArtifact, personalityArtifact, toolchainArtifact}
+
+// kindOfManifest detects the artifact kind from a manifest's media type,
+// artifactType, and config media type, including kinds added with
+// RegisterArtifactKind. It returns false when no known kind matches.
+func kindOfManifest(manifestMediaType, artifactType, configMediaType string) (Kind, bool) {
+	for _, k := range artifactKinds() {
+		if k.matchesManifest(manifestMediaType, artifactType, configMediaType) {
+			return k.Kind, true
+		}
+	}
+	return "", false
+}
+
+// checkArtifactKind returns an *ErrWrongArtifactType when a manifest with
+// the given media types does not belong to kind, or when its AnnotationType
+// annotation names a different kind. Manifests without the annotation, such
+// as those pushed by older clients, are checked by media types alone.
+func checkArtifactKind(ref string, kind artifactKind, manifestMediaType, artifactType, configMediaType string, annotations map[string]string) error {
+	annotated := KindFromAnnotations(annotations)
+	if kind.matchesManifest(manifestMediaType, artifactType, configMediaType) && (annotated == "" || annotated == kind.Kind) {
+		return nil
+	}
+	got, _ := kindOfManifest(manifestMediaType, artifactType, configMediaType)
+	if annotated != "" {
+		got = annotated
+	}
+	return &ErrWrongArtifactType{
+		Ref:             ref,
+		Expected:        kind.Kind,
+		Got:             got,
+		ConfigMediaType: configMediaType,
+	}
+}
@@ -2,6 +2,8 @@ package oci
 
 import (
 	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 func TestArtifactKinds(t *testing.T) {
@@ -36,3 +38,62 @@ func TestArtifactKinds(t *testing.T) {
 		})
 	}
 }
+
+func TestArtifactKind_MatchesManifest(t *testing.T) {
+	tests := []struct {
+		name         string
+		kind         artifactKind
+		manifestType string
+		artifactType string
+		configType   string
+		wantMatches  bool
+	}{
+		{"plugin config", pluginArtifact, ocispec.MediaTypeImageManifest, "", MediaTypePluginConfig, true},
+		{"personality config as plugin", pluginArtifact, ocispec.MediaTypeImageManifest, "", MediaTypePersonalityConfig, false},
+		{"personality config", personalityArtifact, ocispec.MediaTypeImageManifest, "", MediaTypePersonalityConfig, true},
+		{"image config as plugin", pluginArtifact, ocispec.MediaTypeImageManifest, "", ocispec.MediaTypeImageConfig, false},
+		{"index as plugin", pluginArtifact, ocispec.MediaTypeImageIndex, "", "", false},
+		{"oci image config as toolchain", toolchainArtifact, ocispec.MediaTypeImageManifest, "", ocispec.MediaTypeImageConfig, true},
+		{"docker image config as toolchain", toolchainArtifact, "application/vnd.docker.distribution.manifest.v2+json", "", mediaTypeDockerImageConfig, true},
+		{"oci index as toolchain", toolchainArtifact, ocispec.MediaTypeImageIndex, "", "", true},
+		{"docker manifest list as toolchain", toolchainArtifact, "application/vnd.docker.distribution.manifest.list.v2+json", "", "", true},
+		{"plugin config as toolchain", toolchainArtifact, ocispec.MediaTypeImageManifest, "", MediaTypePluginConfig, false},
+		{"plugin artifactType with empty config", pluginArtifact, ocispec.MediaTypeImageManifest, ArtifactTypePlugin, ocispec.MediaTypeEmptyJSON, true},
+		{"plugin artifactType and config", pluginArtifact, ocispec.MediaTypeImageManifest, ArtifactTypePlugin, MediaTypePluginConfig, true},
+		{"plugin artifactType as personality", personalityArtifact, ocispec.MediaTypeImageManifest, ArtifactTypePlugin, ocispec.MediaTypeEmptyJSON, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.kind.matchesManifest(tt.manifestType, tt.artifactType, tt.configType); got != tt.wantMatches {
+				t.Errorf("matchesManifest(%q, %q, %q) = %v, want %v", tt.manifestType, tt.artifactType, tt.configType, got, tt.wantMatches)
+			}
+		})
+	}
+}
+
+func TestKindOfManifest(t *testing.T) {
+	tests := []struct {
+		name         string
+		manifestType string
+		artifactType string
+		configType   string
+		want         Kind
+		wantOK       bool
+	}{
+		{"plugin", ocispec.MediaTypeImageManifest, "", MediaTypePluginConfig, KindPlugin, true},
+		{"personality", ocispec.MediaTypeImageManifest, "", MediaTypePersonalityConfig, KindPersonality, true},
+		{"toolchain image", ocispec.MediaTypeImageManifest, "", ocispec.MediaTypeImageConfig, KindToolchain, true},
+		{"toolchain index", ocispec.MediaTypeImageIndex, "", "", KindToolchain, true},
+		{"unknown", ocispec.MediaTypeImageManifest, "", "application/vnd.example+json", "", false},
+		{"personality by artifactType", ocispec.MediaTypeImageManifest, ArtifactTypePersonality, ocispec.MediaTypeEmptyJSON, KindPersonality, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := kindOfManifest(tt.manifestType, tt.artifactType, tt.configType)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("kindOfManifest() = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
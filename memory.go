@@ -0,0 +1,151 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+
+	godigest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/semaphore"
+	"oras.land/oras-go/v2/content"
+)
+
+// layerSpoolThreshold is the size up to which layers packaged for a push
+// are held in memory. Larger layers spill to a temporary file and are
+// streamed from there.
+const layerSpoolThreshold = 8 << 20
+
+// maxConfigBytes bounds config blobs, which only hold metadata. Larger
+// configs are rejected before they are read.
+const maxConfigBytes = 4 * 1024 * 1024
+
+// WithMemoryBudget caps the memory the client holds in blob buffers at
+// once, so operator pods fail an operation instead of being OOM-killed.
+// Manifests and config blobs read by pulls and the in-memory part of
+// layers packaged for pushes count against the budget; content layers are
+// extracted while streaming and never do. An operation whose buffer alone
+// exceeds the budget fails immediately with *ErrMemoryBudget; others wait
+// while concurrent operations hold the budget. With a budget, each push
+// reserves up to 8 MiB for packaging, extra layers are streamed from
+// disk, and encrypting a layer reserves twice its size. Zero or a
+// negative value disables the cap (the default).
+func WithMemoryBudget(bytes int64) ClientOption {
+	return func(c *Client) {
+		c.memory, c.memoryBudget = nil, 0
+		if bytes > 0 {
+			c.memory, c.memoryBudget = semaphore.NewWeighted(bytes), bytes
+		}
+	}
+}
+
+// reserveMemory reserves n bytes of the memory budget for what, waiting
+// while concurrent operations hold it. The returned function releases the
+// reservation and is safe to call more than once.
+func (c *Client) reserveMemory(ctx context.Context, n int64, what string) (func(), error) {
+	if c.memory == nil || n <= 0 {
+		return func() {}, nil
+	}
+	if n > c.memoryBudget {
+		return nil, &ErrMemoryBudget{What: what, Size: n, Budget: c.memoryBudget}
+	}
+	if err := c.memory.Acquire(ctx, n); err != nil {
+		return nil, err
+	}
+	return sync.OnceFunc(func() { c.memory.Release(n) }), nil
+}
+
+// spoolThreshold returns the size up to which layers packaged for a push
+// stay in memory: layerSpoolThreshold, capped by the memory budget.
+func (c *Client) spoolThreshold() int64 {
+	if c.memory != nil {
+		return min(layerSpoolThreshold, c.memoryBudget)
+	}
+	return layerSpoolThreshold
+}
+
+// extraLayerSpoolThreshold returns the spool threshold of extra layers,
+// which are streamed from disk when a memory budget is set.
+func (c *Client) extraLayerSpoolThreshold() int64 {
+	if c.memory != nil {
+		return 0
+	}
+	return layerSpoolThreshold
+}
+
+// readBlob reads the blob described by desc from r and verifies it. Blobs
+// larger than limit are rejected before anything is allocated.
+func readBlob(r io.Reader, desc ocispec.Descriptor, limit int64) ([]byte, error) {
+	if desc.Size > limit {
+		return nil, fmt.Errorf("%s is %d bytes, exceeding the limit of %d", desc.Digest, desc.Size, limit)
+	}
+	return content.ReadAll(r, desc)
+}
+
+// blobSpool collects a blob and computes its digest. It holds up to
+// threshold bytes in memory and spills larger blobs to a temporary file.
+// A negative threshold never spills.
+type blobSpool struct {
+	threshold int64
+	digester  godigest.Digester
+	size      int64
+	buf       bytes.Buffer
+	file      *os.File
+}
+
+func newBlobSpool(threshold int64) *blobSpool {
+	if threshold < 0 {
+		threshold = math.MaxInt64
+	}
+	return &blobSpool{threshold: threshold, digester: godigest.SHA256.Digester()}
+}
+
+func (s *blobSpool) Write(p []byte) (int, error) {
+	if s.file == nil && int64(s.buf.Len())+int64(len(p)) > s.threshold {
+		f, err := os.CreateTemp("", "klaus-oci-blob-*")
+		if err != nil {
+			return 0, fmt.Errorf("spilling blob to disk: %w", err)
+		}
+		s.file = f
+		if _, err := f.Write(s.buf.Bytes()); err != nil {
+			return 0, err
+		}
+		s.buf = bytes.Buffer{}
+	}
+	var n int
+	var err error
+	if s.file != nil {
+		n, err = s.file.Write(p)
+	} else {
+		n, err = s.buf.Write(p)
+	}
+	s.digester.Hash().Write(p[:n])
+	s.size += int64(n)
+	return n, err
+}
+
+// finish returns the blob's descriptor and either its content or the path
+// of the file it spilled to, which the caller must remove.
+func (s *blobSpool) finish(mediaType string) (ocispec.Descriptor, []byte, string, error) {
+	desc := ocispec.Descriptor{MediaType: mediaType, Digest: s.digester.Digest(), Size: s.size}
+	if s.file == nil {
+		return desc, s.buf.Bytes(), "", nil
+	}
+	if err := s.file.Close(); err != nil {
+		os.Remove(s.file.Name())
+		return ocispec.Descriptor{}, nil, "", err
+	}
+	return desc, nil, s.file.Name(), nil
+}
+
+// discard removes the spilled file of an unfinished spool.
+func (s *blobSpool) discard() {
+	if s.file != nil {
+		s.file.Close()
+		os.Remove(s.file.Name())
+	}
+}
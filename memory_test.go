@@ -0,0 +1,245 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestReserveMemory(t *testing.T) {
+	client := NewClient(WithMemoryBudget(100))
+
+	release, err := client.reserveMemory(t.Context(), 80, "first")
+	if err != nil {
+		t.Fatalf("reserveMemory() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.reserveMemory(ctx, 30, "second"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("reserveMemory() beyond the remaining budget error = %v, want to wait until the deadline", err)
+	}
+	release()
+	release()
+	release, err = client.reserveMemory(t.Context(), 100, "second")
+	if err != nil {
+		t.Fatalf("reserveMemory() after release error = %v", err)
+	}
+	release()
+
+	_, err = client.reserveMemory(t.Context(), 101, "manifest of example.com/p:v1")
+	var budgetErr *ErrMemoryBudget
+	if !errors.As(err, &budgetErr) || budgetErr.Size != 101 || budgetErr.Budget != 100 {
+		t.Errorf("reserveMemory() above the budget error = %v, want *ErrMemoryBudget", err)
+	}
+
+	if _, err := NewClient().reserveMemory(t.Context(), 1<<40, "unbounded"); err != nil {
+		t.Errorf("reserveMemory() without a budget error = %v", err)
+	}
+}
+
+func TestBlobSpool(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	t.Setenv("TMP", os.Getenv("TMPDIR"))
+	data := randomBytes(t, 100)
+
+	small := newBlobSpool(100)
+	small.Write(data)
+	desc, got, path, err := small.finish("application/octet-stream")
+	if err != nil || path != "" || !bytes.Equal(got, data) {
+		t.Fatalf("finish() = %v, %q, %v, want the data in memory", got, path, err)
+	}
+	if desc.Digest != godigest.FromBytes(data) || desc.Size != 100 {
+		t.Errorf("descriptor = %+v", desc)
+	}
+
+	large := newBlobSpool(50)
+	large.Write(data[:40])
+	large.Write(data[40:])
+	desc, got, path, err = large.finish("application/octet-stream")
+	if err != nil || got != nil || path == "" {
+		t.Fatalf("finish() = %v, %q, %v, want a spilled file", got, path, err)
+	}
+	defer os.Remove(path)
+	spilled, err := os.ReadFile(path)
+	if err != nil || !bytes.Equal(spilled, data) {
+		t.Errorf("spilled file = %v, %v, want the data", spilled, err)
+	}
+	if desc.Digest != godigest.FromBytes(data) || desc.Size != 100 {
+		t.Errorf("descriptor = %+v", desc)
+	}
+
+	discarded := newBlobSpool(0)
+	discarded.Write(data)
+	discarded.discard()
+	if entries, _ := os.ReadDir(os.Getenv("TMPDIR")); len(entries) != 1 {
+		t.Errorf("temporary directory holds %d files, want only the finished spool", len(entries))
+	}
+}
+
+func TestReadBlob_Limit(t *testing.T) {
+	data := []byte(`{"name":"p"}`)
+	desc := blobDescriptor(ocispec.MediaTypeImageConfig, data)
+	if got, err := readBlob(bytes.NewReader(data), desc, 64); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("readBlob() = %q, %v", got, err)
+	}
+	if _, err := readBlob(bytes.NewReader(data), desc, 8); err == nil {
+		t.Error("readBlob() above the limit error = nil, want error")
+	}
+	desc.Digest = godigest.FromString("other")
+	if _, err := readBlob(bytes.NewReader(data), desc, 64); err == nil {
+		t.Error("readBlob() with a mismatched digest error = nil, want error")
+	}
+}
+
+func TestWithMemoryBudget_SpoolsPushedLayers(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	t.Setenv("TMP", tmp)
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true), WithMemoryBudget(16<<10))
+	ref := host + "/klaus-plugins/large:v1.0.0"
+
+	src := t.TempDir()
+	payload := randomBytes(t, 256<<10)
+	writeFile(t, filepath.Join(src, "SKILL.md"), "# large\n")
+	writeFile(t, filepath.Join(src, "data.bin"), string(payload))
+	aux := t.TempDir()
+	writeFile(t, filepath.Join(aux, "dataset", "eval.bin"), string(randomBytes(t, 64<<10)))
+	writeFile(t, filepath.Join(aux, "eval.jsonl"), string(randomBytes(t, 64<<10)))
+
+	result, err := client.PushPlugin(t.Context(), src, ref, Plugin{Name: "large"},
+		WithExtraLayer(filepath.Join(aux, "dataset"), testExamplesMediaType),
+		WithExtraLayer(filepath.Join(aux, "eval.jsonl"), testDatasetMediaType))
+	if err != nil {
+		t.Fatalf("PushPlugin() error = %v", err)
+	}
+	if result.Checksums.Files["data.bin"] != godigest.FromBytes(payload).String() {
+		t.Errorf("checksum of data.bin = %q", result.Checksums.Files["data.bin"])
+	}
+	if entries, _ := os.ReadDir(tmp); len(entries) != 0 {
+		t.Errorf("push left %d temporary files behind", len(entries))
+	}
+
+	built, err := BuildPlugin(src, Plugin{Name: "large"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reg.blobs[built.Manifest.Layers[0].Digest.String()]; !bytes.Equal(got, built.Layer) {
+		t.Error("spooled content layer differs from the in-memory build")
+	}
+
+	dest := t.TempDir()
+	if _, err := client.PullPlugin(t.Context(), ref, dest); err != nil {
+		t.Fatalf("PullPlugin() error = %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dest, "data.bin")); !bytes.Equal(got, payload) {
+		t.Error("pulled data.bin differs from the pushed file")
+	}
+	if _, err := client.PullExtraLayers(t.Context(), ref, t.TempDir()); err != nil {
+		t.Errorf("PullExtraLayers() error = %v", err)
+	}
+}
+
+func TestWithMemoryBudget_FailsFast(t *testing.T) {
+	reg := newCacheRegistry()
+	config, _ := json.Marshal(pluginConfigBlob{Skills: []string{string(bytes.Repeat([]byte("s"), 4096))}})
+	addPullableArtifact(t, reg, "klaus-plugins/p", "v1.0.0", pluginArtifact, config, map[string]string{"SKILL.md": "# p\n"})
+	host := newPullTestRegistry(t, reg)
+	ref := host + "/klaus-plugins/p:v1.0.0"
+
+	client := NewClient(WithPlainHTTP(true), WithMemoryBudget(2048))
+	_, err := client.PullPlugin(t.Context(), ref, t.TempDir())
+	var budgetErr *ErrMemoryBudget
+	if !errors.As(err, &budgetErr) || budgetErr.Budget != 2048 {
+		t.Fatalf("PullPlugin() error = %v, want *ErrMemoryBudget", err)
+	}
+
+	client = NewClient(WithPlainHTTP(true), WithMemoryBudget(1<<20))
+	if _, err := client.PullPlugin(t.Context(), ref, t.TempDir()); err != nil {
+		t.Errorf("PullPlugin() within the budget error = %v", err)
+	}
+}
+
+func TestWithMemoryBudget_EncryptionReservesLayer(t *testing.T) {
+	host := newPullTestRegistry(t, newCacheRegistry())
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "data.bin"), string(randomBytes(t, 64<<10)))
+
+	client := NewClient(WithPlainHTTP(true), WithKeyWrappers(newGCMKeyWrapper(t, "kms")), WithMemoryBudget(16<<10))
+	_, err := client.PushPlugin(t.Context(), src, host+"/klaus-plugins/secret:v1.0.0", Plugin{Name: "secret"}, WithEncryption())
+	var budgetErr *ErrMemoryBudget
+	if !errors.As(err, &budgetErr) {
+		t.Errorf("PushPlugin() with encryption error = %v, want *ErrMemoryBudget", err)
+	}
+}
+
+func TestWithMemoryBudget_ConcurrentOperations(t *testing.T) {
+	reg := newCacheRegistry()
+	config, _ := json.Marshal(pluginConfigBlob{Skills: []string{string(bytes.Repeat([]byte("s"), 4096))}})
+	digest := addPullableArtifact(t, reg, "klaus-plugins/p", "v1.0.0", pluginArtifact, config, map[string]string{"SKILL.md": "# p\n"})
+	manifestSize := int64(len(reg.manifests[digest]))
+	host := newPullTestRegistry(t, reg)
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+
+	// The budget fits a pull's manifest and config, but not the config
+	// while a second pull holds its manifest.
+	pulls := NewClient(WithPlainHTTP(true), WithMemoryBudget(int64(len(config))+2*manifestSize-1))
+	var g errgroup.Group
+	for range 8 {
+		g.Go(func() error {
+			_, err := pulls.PullPlugin(ctx, host+"/klaus-plugins/p:v1.0.0", t.TempDir())
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Errorf("concurrent PullPlugin() error = %v", err)
+	}
+
+	// Packaging reserves the whole budget, which encryption then needs.
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "data.bin"), string(randomBytes(t, 4<<10)))
+	pushes := NewClient(WithPlainHTTP(true), WithKeyWrappers(newGCMKeyWrapper(t, "kms")), WithMemoryBudget(64<<10))
+	for i := range 4 {
+		g.Go(func() error {
+			_, err := pushes.PushPlugin(ctx, src, fmt.Sprintf("%s/klaus-plugins/secret:v1.0.%d", host, i), Plugin{Name: "secret"}, WithEncryption())
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Errorf("concurrent encrypted PushPlugin() error = %v", err)
+	}
+}
+
+func TestDiskCache_StreamsBlobsLargerThanCache(t *testing.T) {
+	reg := newCacheRegistry()
+	data := randomBytes(t, 64<<10)
+	digest := reg.addBlob(data)
+
+	c, host, _ := newCacheTestClient(t, reg, WithCacheMaxSize(1024))
+	store, err := c.cacheStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := ocispec.Descriptor{Digest: godigest.Digest(digest), Size: int64(len(data)), MediaType: "application/octet-stream"}
+	rc, err := store.Fetch(t.Context(), host+"/team/large", desc)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	defer rc.Close()
+	var got bytes.Buffer
+	if _, err := got.ReadFrom(rc); err != nil || !bytes.Equal(got.Bytes(), data) {
+		t.Errorf("Fetch() content = %d bytes, %v, want the blob", got.Len(), err)
+	}
+}
@@ -0,0 +1,97 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+// WithMirrors configures fallback sources for digest-pinned pulls. When
+// pulling a reference under source (a registry host with an optional
+// repository prefix, e.g. "gsoci.azurecr.io/giantswarm", or a whole
+// repository, e.g. "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base")
+// fails because the registry is unavailable or no longer holds the
+// digest, the pull is retried against each mirror in order, with source
+// replaced by the mirror base. Because the reference pins a digest, the
+// manifest, config, and content layer fetched from a mirror are verified
+// against it. References by tag never fail over, since a mirror may hold
+// a different artifact under the same tag. The option may be given once
+// per source.
+func WithMirrors(source string, mirrors ...string) ClientOption {
+	return func(c *Client) {
+		if c.mirrors == nil {
+			c.mirrors = make(map[string][]string)
+		}
+		source = strings.TrimSuffix(source, "/")
+		for _, m := range mirrors {
+			c.mirrors[source] = append(c.mirrors[source], strings.TrimSuffix(m, "/"))
+		}
+	}
+}
+
+// mirrorRefs returns ref rewritten onto each mirror of the longest
+// configured source that contains it, in configuration order.
+func (c *Client) mirrorRefs(ref string) []string {
+	sources := make([]string, 0, len(c.mirrors))
+	for source := range c.mirrors {
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool { return len(sources[i]) > len(sources[j]) })
+	for _, source := range sources {
+		rest, ok := strings.CutPrefix(ref, source)
+		if !ok || !isSourceBoundary(source, rest) {
+			continue
+		}
+		refs := make([]string, 0, len(c.mirrors[source]))
+		for _, m := range c.mirrors[source] {
+			refs = append(refs, m+rest)
+		}
+		return refs
+	}
+	return nil
+}
+
+// isSourceBoundary reports whether a reference continuing a mirror source
+// with rest lies within the source: rest is empty or starts a path
+// element, or, when source names a repository, its digest or tag. A
+// source that is only a host never matches a port.
+func isSourceBoundary(source, rest string) bool {
+	if rest == "" || rest[0] == '/' {
+		return true
+	}
+	return strings.Contains(source, "/") && (rest[0] == '@' || rest[0] == ':')
+}
+
+// isMirrorFailover reports whether a failed pull should be retried against
+// a mirror: the registry does not have the content (404), is failing
+// (5xx), or cannot be reached. Cancellation never fails over.
+func isMirrorFailover(err error) bool {
+	if errors.Is(err, errdef.ErrNotFound) {
+		return true
+	}
+	var errResp *errcode.ErrorResponse
+	if errors.As(err, &errResp) && errResp.StatusCode == http.StatusNotFound {
+		return true
+	}
+	return isRegistryDown(err)
+}
+
+// isRegistryDown reports whether err shows the registry failing (5xx) or
+// unreachable. Cancellation does not count.
+func isRegistryDown(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var errResp *errcode.ErrorResponse
+	if errors.As(err, &errResp) {
+		return errResp.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
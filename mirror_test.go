@@ -0,0 +1,223 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+func TestWithMirrors_MirrorRefs(t *testing.T) {
+	client := NewClient(
+		WithMirrors("gsoci.azurecr.io", "mirror.example.com"),
+		WithMirrors("gsoci.azurecr.io/giantswarm/", "a.example.com/gs", "b.example.com"),
+		WithMirrors("gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base", "c.example.com/gs-base"),
+	)
+
+	tests := []struct {
+		ref  string
+		want []string
+	}{
+		{
+			ref:  "gsoci.azurecr.io/giantswarm/klaus-personalities/sre@sha256:abc",
+			want: []string{"a.example.com/gs/klaus-personalities/sre@sha256:abc", "b.example.com/klaus-personalities/sre@sha256:abc"},
+		},
+		{
+			ref:  "gsoci.azurecr.io/other/sre@sha256:abc",
+			want: []string{"mirror.example.com/other/sre@sha256:abc"},
+		},
+		{
+			ref:  "gsoci.azurecr.io/giantswarmers/sre@sha256:abc",
+			want: []string{"mirror.example.com/giantswarmers/sre@sha256:abc"},
+		},
+		{
+			ref:  "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base@sha256:abc",
+			want: []string{"c.example.com/gs-base@sha256:abc"},
+		},
+		{
+			ref:  "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base:v1.0.0",
+			want: []string{"c.example.com/gs-base:v1.0.0"},
+		},
+		{
+			ref:  "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base-extra@sha256:abc",
+			want: []string{"a.example.com/gs/klaus-plugins/gs-base-extra@sha256:abc", "b.example.com/klaus-plugins/gs-base-extra@sha256:abc"},
+		},
+		{ref: "gsoci.azurecr.io:443/giantswarm/sre@sha256:abc"},
+		{ref: "ghcr.io/giantswarm/sre@sha256:abc"},
+	}
+	for _, tt := range tests {
+		got := client.mirrorRefs(tt.ref)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("mirrorRefs(%q) = %v, want %v", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestPull_MirrorFailover(t *testing.T) {
+	mirror := newCacheRegistry()
+	mirrorHost := newPullTestRegistry(t, mirror)
+	digest := pushVersions(t, NewClient(WithPlainHTTP(true)), mirrorHost+"/klaus/sre", "v1.0.0")["v1.0.0"]
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+	}{
+		{name: "not found", handler: newCacheRegistry().handler()},
+		// The quarantine is checked in the mirror while the primary is
+		// down.
+		{name: "server error", handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(tt.handler)
+			t.Cleanup(ts.Close)
+			primaryHost := strings.TrimPrefix(ts.URL, "http://")
+			client := NewClient(WithPlainHTTP(true), WithMirrors(primaryHost+"/klaus", mirrorHost+"/klaus"))
+
+			dir := t.TempDir()
+			ref := primaryHost + "/klaus/sre@" + digest
+			p, err := client.PullPersonality(t.Context(), ref, dir)
+			if err != nil {
+				t.Fatalf("PullPersonality() error = %v", err)
+			}
+			if p.Digest != digest || p.Ref != ref {
+				t.Errorf("pulled %s as %s, want %s as %s", p.Digest, p.Ref, digest, ref)
+			}
+			if p.Soul != "Soul v1.0.0" {
+				t.Errorf("Soul = %q", p.Soul)
+			}
+			entry, err := ReadCacheEntry(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entry.Provenance == nil || entry.Provenance.Registry != mirrorHost {
+				t.Errorf("Provenance = %+v, want registry %s", entry.Provenance, mirrorHost)
+			}
+		})
+	}
+}
+
+func TestPull_MirrorQuarantineCheckedInPrimary(t *testing.T) {
+	mirror := newCacheRegistry()
+	mirrorHost := newPullTestRegistry(t, mirror)
+	digest := pushVersions(t, NewClient(WithPlainHTTP(true)), mirrorHost+"/klaus/sre", "v1.0.0")["v1.0.0"]
+
+	// The primary holds the quarantine marker but has lost the manifest.
+	primary := newCacheRegistry()
+	primaryHost := newReferrersTestRegistry(t, primary)
+	mirror.mu.Lock()
+	primary.mu.Lock()
+	maps.Copy(primary.manifests, mirror.manifests)
+	maps.Copy(primary.blobs, mirror.blobs)
+	primary.repos["klaus/sre"] = map[string]string{}
+	primary.mu.Unlock()
+	mirror.mu.Unlock()
+	client := NewClient(WithPlainHTTP(true), WithMirrors(primaryHost, mirrorHost))
+	ref := primaryHost + "/klaus/sre@" + digest
+	if _, err := client.Quarantine(t.Context(), ref, "CVE-2026-1234"); err != nil {
+		t.Fatalf("Quarantine() error = %v", err)
+	}
+	primary.mu.Lock()
+	delete(primary.manifests, digest)
+	primary.mu.Unlock()
+
+	_, err := client.PullPersonality(t.Context(), ref, t.TempDir())
+	var qerr *ErrQuarantined
+	if !errors.As(err, &qerr) || qerr.Quarantine.Reason != "CVE-2026-1234" {
+		t.Errorf("PullPersonality() from the mirror error = %v, want *ErrQuarantined", err)
+	}
+}
+
+func TestPull_MirrorQuarantineCheckedInMirrorWhilePrimaryDown(t *testing.T) {
+	mirror := newCacheRegistry()
+	mirrorHost := newReferrersTestRegistry(t, mirror)
+	digest := pushVersions(t, NewClient(WithPlainHTTP(true)), mirrorHost+"/klaus/sre", "v1.0.0")["v1.0.0"]
+	if _, err := NewClient(WithPlainHTTP(true)).Quarantine(t.Context(), mirrorHost+"/klaus/sre@"+digest, "CVE-2026-1234"); err != nil {
+		t.Fatalf("Quarantine() error = %v", err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(ts.Close)
+	primaryHost := strings.TrimPrefix(ts.URL, "http://")
+	client := NewClient(WithPlainHTTP(true), WithMirrors(primaryHost, mirrorHost))
+
+	_, err := client.PullPersonality(t.Context(), primaryHost+"/klaus/sre@"+digest, t.TempDir())
+	var qerr *ErrQuarantined
+	if !errors.As(err, &qerr) || qerr.Quarantine.Reason != "CVE-2026-1234" {
+		t.Errorf("PullPersonality() error = %v, want *ErrQuarantined from the mirror's marker", err)
+	}
+}
+
+func TestPull_NoMirrorFailoverForTags(t *testing.T) {
+	mirror := newCacheRegistry()
+	mirrorHost := newPullTestRegistry(t, mirror)
+	pushVersions(t, NewClient(WithPlainHTTP(true)), mirrorHost+"/klaus/sre", "v1.0.0")
+
+	primaryHost := newPullTestRegistry(t, newCacheRegistry())
+	client := NewClient(WithPlainHTTP(true), WithMirrors(primaryHost, mirrorHost))
+
+	if _, err := client.PullPersonality(t.Context(), primaryHost+"/klaus/sre:v1.0.0", t.TempDir()); err == nil {
+		t.Fatal("PullPersonality() by tag succeeded from a mirror")
+	}
+}
+
+func TestPull_MirrorContentMismatch(t *testing.T) {
+	mirror := newCacheRegistry()
+	mirrorHost := newPullTestRegistry(t, mirror)
+	digests := pushVersions(t, NewClient(WithPlainHTTP(true)), mirrorHost+"/klaus/sre", "v1.0.0", "v2.0.0")
+
+	// Serve the v2.0.0 manifest under the v1.0.0 digest.
+	mirror.mu.Lock()
+	mirror.manifests[digests["v1.0.0"]] = mirror.manifests[digests["v2.0.0"]]
+	mirror.mu.Unlock()
+
+	primaryHost := newPullTestRegistry(t, newCacheRegistry())
+	client := NewClient(WithPlainHTTP(true), WithMirrors(primaryHost, mirrorHost))
+
+	dir := t.TempDir()
+	_, err := client.PullPersonality(t.Context(), primaryHost+"/klaus/sre@"+digests["v1.0.0"], dir)
+	if err == nil {
+		t.Fatal("PullPersonality() accepted mismatched mirror content")
+	}
+	if !strings.Contains(err.Error(), "mirror "+mirrorHost) {
+		t.Errorf("error = %v, want mirror failure", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "SOUL.md")); !errors.Is(statErr, os.ErrNotExist) {
+		t.Errorf("SOUL.md written from mismatched content: %v", statErr)
+	}
+}
+
+func TestIsMirrorFailover(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "not found", err: fmt.Errorf("resolving: %w", errdef.ErrNotFound), want: true},
+		{name: "404", err: &errcode.ErrorResponse{StatusCode: http.StatusNotFound}, want: true},
+		{name: "502", err: &errcode.ErrorResponse{StatusCode: http.StatusBadGateway}, want: true},
+		{name: "401", err: &errcode.ErrorResponse{StatusCode: http.StatusUnauthorized}},
+		{name: "network", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: true},
+		{name: "canceled", err: fmt.Errorf("fetching: %w", context.Canceled)},
+		{name: "parse", err: errors.New("parsing manifest")},
+	}
+	for _, tt := range tests {
+		if got := isMirrorFailover(tt.err); got != tt.want {
+			t.Errorf("%s: isMirrorFailover() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
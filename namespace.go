@@ -0,0 +1,90 @@
+package oci
+
+import (
+	"context"
+	"strings"
+)
+
+// kindRepositories maps the built-in kinds to the repository holding them
+// within a namespace.
+var kindRepositories = map[Kind]string{
+	KindPlugin:      "klaus-plugins",
+	KindPersonality: "klaus-personalities",
+	KindToolchain:   "klaus-toolchains",
+}
+
+// WithDefaultNamespace sets the registry namespace that short names are
+// expanded in and that listings default to, e.g.
+// "registry.example.com/acme", in place of DefaultNamespace. Plugins,
+// personalities, and toolchains are expected in its klaus-plugins,
+// klaus-personalities, and klaus-toolchains repositories, so "sre"
+// resolves to "registry.example.com/acme/klaus-personalities/sre".
+//
+// With a namespace set, a short name may carry a tenant prefix: "team-x/sre"
+// resolves in the tenant's namespace, to
+// "registry.example.com/acme/team-x/klaus-personalities/sre". References
+// whose first element is a registry host (it contains "." or ":", or is
+// "localhost") are used as given. WithContextNamespace overrides the
+// namespace per request.
+func WithDefaultNamespace(namespace string) ClientOption {
+	return func(c *Client) { c.namespace = strings.Trim(strings.TrimSpace(namespace), "/") }
+}
+
+// contextNamespaceKey is the context key of WithContextNamespace.
+type contextNamespaceKey struct{}
+
+// WithContextNamespace returns a copy of ctx carrying namespace, which
+// clients use in place of the one set with WithDefaultNamespace for
+// requests made with the context. This allows one namespace per tenant on
+// a client shared between goroutines.
+func WithContextNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, contextNamespaceKey{}, strings.Trim(strings.TrimSpace(namespace), "/"))
+}
+
+// ContextNamespace returns the namespace attached to ctx with
+// WithContextNamespace.
+func ContextNamespace(ctx context.Context) (string, bool) {
+	namespace, ok := ctx.Value(contextNamespaceKey{}).(string)
+	return namespace, ok
+}
+
+// namespaceFor returns the namespace in effect for ctx, and whether one
+// was configured rather than defaulted.
+func (c *Client) namespaceFor(ctx context.Context) (string, bool) {
+	if namespace, ok := ContextNamespace(ctx); ok && namespace != "" {
+		return namespace, true
+	}
+	if c.namespace != "" {
+		return c.namespace, true
+	}
+	return DefaultNamespace, false
+}
+
+// registryBase returns the registry base of kind in the namespace in
+// effect for ctx, e.g. DefaultPluginRegistry without a namespace.
+func (c *Client) registryBase(ctx context.Context, kind Kind) string {
+	namespace, _ := c.namespaceFor(ctx)
+	return namespace + "/" + kindRepositories[kind]
+}
+
+// expandTenant splits a tenant-prefixed short name such as "team-x/sre"
+// into the registry base of kind in the tenant's namespace and the name
+// within it. Other references are returned with the registry base of kind
+// unchanged; tenant prefixes are only recognized with a configured
+// namespace.
+func (c *Client) expandTenant(ctx context.Context, kind Kind, ref string) (base, rest string) {
+	namespace, configured := c.namespaceFor(ctx)
+	base = namespace + "/" + kindRepositories[kind]
+	ref = strings.TrimSpace(ref)
+	tenant, name, ok := strings.Cut(ref, "/")
+	if !configured || !ok || isRegistryHost(tenant) || strings.Contains(name, "/") {
+		return base, ref
+	}
+	return namespace + "/" + tenant + "/" + kindRepositories[kind], name
+}
+
+// isRegistryHost reports whether the first element of a reference names a
+// registry host rather than a path, following the Docker convention.
+func isRegistryHost(element string) bool {
+	return strings.ContainsAny(element, ".:") || element == "localhost"
+}
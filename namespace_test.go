@@ -0,0 +1,89 @@
+package oci
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWithDefaultNamespace(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	pusher := NewClient(WithPlainHTTP(true))
+	pushVersions(t, pusher, host+"/acme/klaus-personalities/sre", "v1.0.0")
+	pushVersions(t, pusher, host+"/acme/team-x/klaus-personalities/sre", "v2.0.0")
+	pushVersions(t, pusher, host+"/other/klaus-personalities/sre", "v3.0.0")
+
+	client := NewClient(WithPlainHTTP(true), WithDefaultNamespace(host+"/acme/"))
+	tests := map[string]string{
+		"sre":                                   host + "/acme/klaus-personalities/sre:v1.0.0",
+		"team-x/sre":                            host + "/acme/team-x/klaus-personalities/sre:v2.0.0",
+		"team-x/sre:v2.0.0":                     host + "/acme/team-x/klaus-personalities/sre:v2.0.0",
+		host + "/other/klaus-personalities/sre": host + "/other/klaus-personalities/sre:v3.0.0",
+	}
+	for ref, want := range tests {
+		got, err := client.ResolvePersonalityRef(t.Context(), ref)
+		if err != nil || got != want {
+			t.Errorf("ResolvePersonalityRef(%q) = %q, %v, want %q", ref, got, err, want)
+		}
+	}
+
+	resolved, err := client.ResolveRefs(t.Context(), []string{"sre", "team-x/sre"}, KindPersonality)
+	if err != nil || resolved["team-x/sre"] != tests["team-x/sre"] || resolved["sre"] != tests["sre"] {
+		t.Errorf("ResolveRefs() = %v, %v", resolved, err)
+	}
+
+	versions, err := client.ListPersonalityVersions(t.Context(), "team-x/sre")
+	if err != nil || !slices.Equal(versions, []string{"v2.0.0"}) {
+		t.Errorf("ListPersonalityVersions(team-x/sre) = %v, %v", versions, err)
+	}
+
+	entries, err := client.ListPersonalities(t.Context())
+	if err != nil || len(entries) != 1 || entries[0].Reference != host+"/acme/klaus-personalities/sre:v1.0.0" {
+		t.Errorf("ListPersonalities() = %+v, %v, want only the acme personality", entries, err)
+	}
+}
+
+func TestWithContextNamespace(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true), WithDefaultNamespace(host+"/acme"))
+	pushVersions(t, client, host+"/acme/klaus-personalities/sre", "v1.0.0")
+	pushVersions(t, client, host+"/other/klaus-personalities/sre", "v3.0.0")
+
+	ctx := WithContextNamespace(t.Context(), host+"/other")
+	if ns, ok := ContextNamespace(ctx); !ok || ns != host+"/other" {
+		t.Errorf("ContextNamespace() = %q, %v", ns, ok)
+	}
+	got, err := client.ResolvePersonalityRef(ctx, "sre")
+	if err != nil || got != host+"/other/klaus-personalities/sre:v3.0.0" {
+		t.Errorf("ResolvePersonalityRef() with context namespace = %q, %v", got, err)
+	}
+	got, err = client.ResolvePersonalityRef(t.Context(), "sre")
+	if err != nil || got != host+"/acme/klaus-personalities/sre:v1.0.0" {
+		t.Errorf("ResolvePersonalityRef() with client namespace = %q, %v", got, err)
+	}
+}
+
+func TestExpandTenant(t *testing.T) {
+	plain := NewClient()
+	if base, rest := plain.expandTenant(t.Context(), KindPlugin, "team-x/gs-base"); base != DefaultPluginRegistry || rest != "team-x/gs-base" {
+		t.Errorf("expandTenant() without namespace = %q, %q, want the reference unchanged", base, rest)
+	}
+
+	client := NewClient(WithDefaultNamespace("registry.example.com/acme"))
+	tests := []struct {
+		ref, base, rest string
+	}{
+		{"gs-base", "registry.example.com/acme/klaus-plugins", "gs-base"},
+		{"team-x/gs-base:v1", "registry.example.com/acme/team-x/klaus-plugins", "gs-base:v1"},
+		{"localhost/klaus-plugins/gs-base", "registry.example.com/acme/klaus-plugins", "localhost/klaus-plugins/gs-base"},
+		{"localhost:5000/gs-base", "registry.example.com/acme/klaus-plugins", "localhost:5000/gs-base"},
+		{"ghcr.io/acme/gs-base", "registry.example.com/acme/klaus-plugins", "ghcr.io/acme/gs-base"},
+		{"a/b/gs-base", "registry.example.com/acme/klaus-plugins", "a/b/gs-base"},
+	}
+	for _, tt := range tests {
+		if base, rest := client.expandTenant(t.Context(), KindPlugin, tt.ref); base != tt.base || rest != tt.rest {
+			t.Errorf("expandTenant(%q) = %q, %q, want %q, %q", tt.ref, base, rest, tt.base, tt.rest)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Package ocitest builds Klaus OCI artifacts in memory for golden tests.
+//
+// The builders produce byte-identical manifests and blobs for identical
+// input, exactly as oci.Client.PushPlugin and PushPersonality would upload
+// them, so CI for plugin and personality repositories can assert the
+// manifest output without pushing to a registry:
+//
+//	func TestManifest(t *testing.T) {
+//		a, err := ocitest.BuildPluginArtifact("../plugins/gs-base")
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		ocitest.AssertGolden(t, "testdata/gs-base.manifest.json", a)
+//	}
+//
+// Run the tests with UPDATE_GOLDEN=1 to rewrite the golden files.
+//
+// StartRegistry runs a real distribution registry in a docker container,
+// optionally with authentication and TLS, for integration tests.
+package ocitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	oci "github.com/giantswarm/klaus-oci"
+)
+
+// UpdateGoldenEnv is the environment variable that makes AssertGolden
+// rewrite golden files instead of comparing against them.
+const UpdateGoldenEnv = "UPDATE_GOLDEN"
+
+// BuildPluginArtifact builds the plugin in dir, reading its metadata with
+// oci.ReadPluginFromDir.
+func BuildPluginArtifact(dir string) (*oci.BuiltArtifact, error) {
+	p, err := oci.ReadPluginFromDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	return oci.BuildPlugin(dir, *p)
+}
+
+// BuildPluginArtifactFromFiles builds a plugin from metadata and in-memory
+// files keyed by slash-separated relative path.
+func BuildPluginArtifactFromFiles(p oci.Plugin, files map[string]string) (*oci.BuiltArtifact, error) {
+	return withFiles(files, func(dir string) (*oci.BuiltArtifact, error) {
+		return oci.BuildPlugin(dir, p)
+	})
+}
+
+// BuildPersonalityArtifact builds the personality in dir, reading its
+// metadata with oci.ReadPersonalityFromDir.
+func BuildPersonalityArtifact(dir string) (*oci.BuiltArtifact, error) {
+	p, err := oci.ReadPersonalityFromDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	return oci.BuildPersonality(dir, *p)
+}
+
+// BuildPersonalityArtifactFromFiles builds a personality from metadata and
+// in-memory files keyed by slash-separated relative path.
+func BuildPersonalityArtifactFromFiles(p oci.Personality, files map[string]string) (*oci.BuiltArtifact, error) {
+	return withFiles(files, func(dir string) (*oci.BuiltArtifact, error) {
+		return oci.BuildPersonality(dir, p)
+	})
+}
+
+// withFiles writes files to a temporary directory, calls build on it, and
+// removes the directory again.
+func withFiles(files map[string]string, build func(dir string) (*oci.BuiltArtifact, error)) (*oci.BuiltArtifact, error) {
+	dir, err := os.MkdirTemp("", "ocitest-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return build(dir)
+}
+
+// AssertGolden compares the indented manifest of a with the golden file at
+// path and fails t when they differ. When UPDATE_GOLDEN is set, the golden
+// file is written instead.
+func AssertGolden(t testing.TB, path string, a *oci.BuiltArtifact) {
+	t.Helper()
+
+	got, err := indentManifest(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (set %s=1 to create it): %v", UpdateGoldenEnv, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("manifest does not match %s (set %s=1 to update)\ngot:\n%s\nwant:\n%s", path, UpdateGoldenEnv, got, want)
+	}
+}
+
+// indentManifest returns the manifest JSON in indented form with a
+// trailing newline, which keeps golden files reviewable.
+func indentManifest(a *oci.BuiltArtifact) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, a.ManifestJSON, "", "  "); err != nil {
+		return nil, fmt.Errorf("indenting manifest: %w", err)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,95 @@
+package ocitest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	oci "github.com/giantswarm/klaus-oci"
+)
+
+var pluginFiles = map[string]string{
+	".claude-plugin/plugin.json": `{"name":"gs-base","description":"Base plugin"}`,
+	"skills/kubectl/SKILL.md":    "# kubectl\n",
+	"commands/deploy.md":         "Deploy the app.\n",
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestBuildPluginArtifact_Golden(t *testing.T) {
+	a, err := BuildPluginArtifact(writeFiles(t, pluginFiles))
+	if err != nil {
+		t.Fatalf("BuildPluginArtifact() error = %v", err)
+	}
+	AssertGolden(t, filepath.Join("testdata", "gs-base.manifest.json"), a)
+}
+
+func TestBuildPluginArtifactFromFiles_MatchesDir(t *testing.T) {
+	fromDir, err := BuildPluginArtifact(writeFiles(t, pluginFiles))
+	if err != nil {
+		t.Fatalf("BuildPluginArtifact() error = %v", err)
+	}
+	fromFiles, err := BuildPluginArtifactFromFiles(oci.Plugin{
+		Name:        "gs-base",
+		Description: "Base plugin",
+		Skills:      []string{"kubectl"},
+		Commands:    []string{"deploy"},
+	}, pluginFiles)
+	if err != nil {
+		t.Fatalf("BuildPluginArtifactFromFiles() error = %v", err)
+	}
+	if fromDir.Digest != fromFiles.Digest {
+		t.Errorf("digest from dir = %s, from files = %s", fromDir.Digest, fromFiles.Digest)
+	}
+}
+
+func TestBuildPersonalityArtifact(t *testing.T) {
+	files := map[string]string{
+		"personality.yaml": "name: sre\nplugins:\n  - repository: example.com/klaus-plugins/gs-base\n    tag: v1.0.0\n",
+		"SOUL.md":          "Be calm.\n",
+	}
+	a, err := BuildPersonalityArtifact(writeFiles(t, files))
+	if err != nil {
+		t.Fatalf("BuildPersonalityArtifact() error = %v", err)
+	}
+	b, err := BuildPersonalityArtifactFromFiles(oci.Personality{
+		Name:    "sre",
+		Plugins: []oci.PluginReference{{Repository: "example.com/klaus-plugins/gs-base", Tag: "v1.0.0"}},
+	}, files)
+	if err != nil {
+		t.Fatalf("BuildPersonalityArtifactFromFiles() error = %v", err)
+	}
+	if a.Digest != b.Digest {
+		t.Errorf("digests differ: %s vs %s", a.Digest, b.Digest)
+	}
+	if a.Manifest.ArtifactType != oci.ArtifactTypePersonality {
+		t.Errorf("ArtifactType = %q", a.Manifest.ArtifactType)
+	}
+}
+
+func TestAssertGolden_Update(t *testing.T) {
+	a, err := BuildPluginArtifactFromFiles(oci.Plugin{Name: "p"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "golden", "p.json")
+
+	t.Setenv(UpdateGoldenEnv, "1")
+	AssertGolden(t, path, a)
+
+	t.Setenv(UpdateGoldenEnv, "")
+	AssertGolden(t, path, a)
+}
@@ -0,0 +1,272 @@
+package ocitest
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+
+	oci "github.com/giantswarm/klaus-oci"
+)
+
+// DefaultRegistryImage is the distribution registry image StartRegistry
+// runs unless WithRegistryImage selects another one.
+const DefaultRegistryImage = "registry:2"
+
+// htpasswdImage provides the htpasswd tool to create the bcrypt password
+// file of authenticated registries; registry images do not ship it.
+const htpasswdImage = "httpd:2"
+
+// registryStartTimeout bounds how long StartRegistry waits for the
+// registry to answer.
+const registryStartTimeout = 30 * time.Second
+
+// Registry is a distribution registry running in a container, started by
+// StartRegistry.
+type Registry struct {
+	// Host is the registry's "host:port" on the loopback interface.
+	Host string
+	// Username and Password are the credentials of an authenticated
+	// registry, empty otherwise.
+	Username string
+	Password string
+	// RootCAs trusts the registry's certificate when it serves TLS, nil
+	// otherwise.
+	RootCAs *x509.CertPool
+}
+
+// ClientOptions returns the client options to talk to the registry: plain
+// HTTP or TLS with the registry's CA, and its credentials.
+func (r *Registry) ClientOptions() []oci.ClientOption {
+	var opts []oci.ClientOption
+	if r.RootCAs == nil {
+		opts = append(opts, oci.WithPlainHTTP(true))
+	} else {
+		opts = append(opts, oci.WithTransportOptions(oci.TransportOptions{RootCAs: r.RootCAs}))
+	}
+	if r.Username != "" {
+		cred := auth.Credential{Username: r.Username, Password: r.Password}
+		opts = append(opts, oci.WithPullCredentials(r.Host, cred), oci.WithPushCredentials(r.Host, cred))
+	}
+	return opts
+}
+
+// RegistryOption configures StartRegistry.
+type RegistryOption func(*registryConfig)
+
+type registryConfig struct {
+	image    string
+	username string
+	password string
+	tls      bool
+}
+
+// WithRegistryImage runs image instead of DefaultRegistryImage. The image
+// must be configurable like the distribution registry, e.g. a pinned
+// "registry:2.8.3".
+func WithRegistryImage(image string) RegistryOption {
+	return func(cfg *registryConfig) { cfg.image = image }
+}
+
+// WithRegistryAuth requires HTTP basic authentication with username and
+// password.
+func WithRegistryAuth(username, password string) RegistryOption {
+	return func(cfg *registryConfig) { cfg.username, cfg.password = username, password }
+}
+
+// WithRegistryTLS serves the registry over TLS with a certificate from a
+// throwaway CA, trusted through Registry.RootCAs.
+func WithRegistryTLS() RegistryOption {
+	return func(cfg *registryConfig) { cfg.tls = true }
+}
+
+// StartRegistry runs a distribution registry container with docker for
+// the duration of t, with manifest deletion enabled. The test is skipped
+// when docker is not available, so integration suites can run wherever
+// the tests run:
+//
+//	reg := ocitest.StartRegistry(t, ocitest.WithRegistryAuth("klaus", "secret"), ocitest.WithRegistryTLS())
+//	client := oci.NewClient(reg.ClientOptions()...)
+func StartRegistry(t testing.TB, opts ...RegistryOption) *Registry {
+	t.Helper()
+	cfg := registryConfig{image: DefaultRegistryImage}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	if _, err := docker(t.Context(), "version", "--format", "{{.Server.Version}}"); err != nil {
+		t.Skipf("docker is not available: %v", err)
+	}
+
+	dir := t.TempDir()
+	reg := &Registry{Username: cfg.username, Password: cfg.password}
+	args := []string{
+		"run", "--detach",
+		"--publish", "127.0.0.1::5000",
+		"--volume", dir + ":/ocitest:ro",
+		"--env", "REGISTRY_STORAGE_DELETE_ENABLED=true",
+	}
+	if cfg.tls {
+		pool, err := writeCertificate(dir)
+		if err != nil {
+			t.Fatalf("creating registry certificate: %v", err)
+		}
+		reg.RootCAs = pool
+		args = append(args,
+			"--env", "REGISTRY_HTTP_TLS_CERTIFICATE=/ocitest/cert.pem",
+			"--env", "REGISTRY_HTTP_TLS_KEY=/ocitest/key.pem",
+		)
+	}
+	if cfg.username != "" {
+		entry, err := docker(t.Context(), "run", "--rm", "--entrypoint", "htpasswd", htpasswdImage, "-Bbn", cfg.username, cfg.password)
+		if err != nil {
+			t.Fatalf("creating htpasswd file: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "htpasswd"), []byte(entry), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		args = append(args,
+			"--env", "REGISTRY_AUTH=htpasswd",
+			"--env", "REGISTRY_AUTH_HTPASSWD_REALM=ocitest",
+			"--env", "REGISTRY_AUTH_HTPASSWD_PATH=/ocitest/htpasswd",
+		)
+	}
+
+	id, err := docker(t.Context(), append(args, cfg.image)...)
+	if err != nil {
+		t.Fatalf("starting registry: %v", err)
+	}
+	id = strings.TrimSpace(id)
+	t.Cleanup(func() {
+		_, _ = docker(context.Background(), "rm", "--force", "--volumes", id)
+	})
+
+	port, err := docker(t.Context(), "port", id, "5000/tcp")
+	if err != nil {
+		t.Fatalf("looking up registry port: %v", err)
+	}
+	reg.Host = strings.TrimSpace(strings.SplitN(port, "\n", 2)[0])
+	if err := reg.wait(t.Context()); err != nil {
+		logs, _ := docker(context.Background(), "logs", id)
+		t.Fatalf("registry did not start: %v\n%s", err, logs)
+	}
+	return reg
+}
+
+// wait polls the registry's API root until it answers.
+func (r *Registry) wait(ctx context.Context) error {
+	scheme := "http"
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if r.RootCAs != nil {
+		scheme = "https"
+		transport.TLSClientConfig = &tls.Config{RootCAs: r.RootCAs}
+	}
+	client := &http.Client{Transport: transport, Timeout: time.Second}
+	defer client.CloseIdleConnections()
+
+	ctx, cancel := context.WithTimeout(ctx, registryStartTimeout)
+	defer cancel()
+	var lastErr error
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+r.Host+"/v2/", nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusUnauthorized {
+				return nil
+			}
+			err = fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		lastErr = err
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// docker runs the docker CLI and returns its standard output.
+func docker(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("docker %s: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("docker %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// writeCertificate writes a self-signed certificate for the loopback
+// addresses to cert.pem and key.pem in dir, and returns a pool trusting
+// it.
+func writeCertificate(dir string) (*x509.CertPool, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "ocitest registry"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(filepath.Join(dir, "cert.pem"), certPEM, 0o644); err != nil {
+		return nil, err
+	}
+	// The registry process in the container may run as another user.
+	if err := os.WriteFile(filepath.Join(dir, "key.pem"), keyPEM, 0o644); err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return pool, nil
+}
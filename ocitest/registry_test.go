@@ -0,0 +1,63 @@
+package ocitest
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	oci "github.com/giantswarm/klaus-oci"
+)
+
+func TestRegistryClientOptions_TLS(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	dir := t.TempDir()
+	pool, err := writeCertificate(dir)
+	if err != nil {
+		t.Fatalf("writeCertificate() error = %v", err)
+	}
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+	if err != nil {
+		t.Fatalf("loading written key pair: %v", err)
+	}
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	ts.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	ts.StartTLS()
+	defer ts.Close()
+
+	reg := &Registry{Host: strings.TrimPrefix(ts.URL, "https://"), RootCAs: pool}
+	client := oci.NewClient(reg.ClientOptions()...)
+	if _, err := client.Ping(t.Context(), reg.Host+"/klaus/sre"); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+}
+
+func TestRegistryClientOptions_Auth(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "klaus" || pass != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="ocitest"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+	host := strings.TrimPrefix(ts.URL, "http://")
+
+	anonymous := &Registry{Host: host}
+	if _, err := oci.NewClient(anonymous.ClientOptions()...).Ping(t.Context(), host+"/klaus/sre"); err == nil {
+		t.Error("Ping() without credentials succeeded")
+	}
+	reg := &Registry{Host: host, Username: "klaus", Password: "secret"}
+	if _, err := oci.NewClient(reg.ClientOptions()...).Ping(t.Context(), host+"/klaus/sre"); err != nil {
+		t.Errorf("Ping() with credentials error = %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Overlay customizes a base personality without forking it. Overlays are
+// small YAML documents, typically kept next to the instance configuration:
+//
+//	addPlugins:
+//	  - repository: gsoci.azurecr.io/giantswarm/klaus-plugins/team-runbooks
+//	    tag: v0.3.0
+//	removePlugins:
+//	  - gs-platform
+//	toolchainTag: v1.4.0
+//	appendSoul: |
+//	  Always page the phoenix on-call before touching production.
+type Overlay struct {
+	// AddPlugins are added to the base plugins. A plugin whose repository
+	// is already in the base replaces the base reference, which lets an
+	// overlay pin a different version.
+	AddPlugins []PluginReference `yaml:"addPlugins,omitempty" json:"addPlugins,omitempty"`
+	// RemovePlugins names base plugins to drop, by full repository or by
+	// short name (e.g. "gs-platform").
+	RemovePlugins []string `yaml:"removePlugins,omitempty" json:"removePlugins,omitempty"`
+	// ToolchainTag overrides the tag of the base toolchain. Any digest
+	// pinned by the base is dropped.
+	ToolchainTag string `yaml:"toolchainTag,omitempty" json:"toolchainTag,omitempty"`
+	// AppendSoul is appended to the base soul, separated by a blank line.
+	AppendSoul string `yaml:"appendSoul,omitempty" json:"appendSoul,omitempty"`
+}
+
+// ReadOverlayFile reads an overlay from a local YAML file.
+func ReadOverlayFile(path string) (*Overlay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading overlay: %w", err)
+	}
+	return ParseOverlay(data)
+}
+
+// ParseOverlay parses an overlay YAML document. Unknown fields are rejected
+// so that typos do not silently leave the base personality unchanged.
+func ParseOverlay(data []byte) (*Overlay, error) {
+	var o Overlay
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&o); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing overlay: %w", err)
+	}
+	for i, p := range o.AddPlugins {
+		if p.Repository == "" {
+			return nil, fmt.Errorf("parsing overlay: addPlugins[%d]: repository is required", i)
+		}
+	}
+	return &o, nil
+}
+
+// ApplyOverlay returns base with overlay applied. Removals are applied
+// before additions. It fails when a removed plugin is not part of the base
+// personality or when the overlay overrides the tag of a personality
+// without a toolchain, since both usually mean the base has changed under
+// the overlay. The soul is not part of Personality; use Overlay.ApplySoul.
+func ApplyOverlay(base Personality, overlay Overlay) (Personality, error) {
+	result := base
+	plugins := slices.Clone(base.Plugins)
+
+	for _, name := range overlay.RemovePlugins {
+		i := slices.IndexFunc(plugins, func(p PluginReference) bool {
+			return p.Repository == name || ShortName(p.Repository) == name
+		})
+		if i < 0 {
+			return Personality{}, fmt.Errorf("overlay removes plugin %s, which is not part of personality %s", name, base.Name)
+		}
+		plugins = slices.Delete(plugins, i, i+1)
+	}
+
+	for _, add := range overlay.AddPlugins {
+		i := slices.IndexFunc(plugins, func(p PluginReference) bool {
+			return p.Repository == add.Repository
+		})
+		if i >= 0 {
+			plugins[i] = add
+			continue
+		}
+		plugins = append(plugins, add)
+	}
+	result.Plugins = plugins
+
+	if overlay.ToolchainTag != "" {
+		if base.Toolchain.Repository == "" {
+			return Personality{}, fmt.Errorf("overlay overrides the toolchain tag, but personality %s has no toolchain", base.Name)
+		}
+		result.Toolchain = ToolchainReference{
+			Repository: base.Toolchain.Repository,
+			Tag:        overlay.ToolchainTag,
+		}
+	}
+
+	return result, nil
+}
+
+// ApplySoul returns soul with the overlay's AppendSoul text appended.
+func (o Overlay) ApplySoul(soul string) string {
+	if o.AppendSoul == "" {
+		return soul
+	}
+	if soul == "" {
+		return o.AppendSoul
+	}
+	return strings.TrimRight(soul, "\n") + "\n\n" + o.AppendSoul
+}
+
+// ValidateOverlay applies overlay to base and checks that every reference
+// the overlay introduces -- added plugins and the overridden toolchain --
+// exists in its registry. All missing references are reported together.
+// References inherited from the base are not checked.
+func (c *Client) ValidateOverlay(ctx context.Context, base Personality, overlay Overlay) (Personality, error) {
+	result, err := ApplyOverlay(base, overlay)
+	if err != nil {
+		return Personality{}, err
+	}
+
+	var refs []string
+	for _, p := range overlay.AddPlugins {
+		refs = append(refs, p.Ref())
+	}
+	if overlay.ToolchainTag != "" {
+		refs = append(refs, result.Toolchain.Ref())
+	}
+
+	var errs []error
+	for _, ref := range refs {
+		if _, err := c.Resolve(ctx, ref); err != nil {
+			errs = append(errs, fmt.Errorf("overlay reference %s: %w", ref, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return Personality{}, err
+	}
+	return result, nil
+}
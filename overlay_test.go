@@ -0,0 +1,172 @@
+package oci
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func overlayBase() Personality {
+	return Personality{
+		Name: "sre",
+		Toolchain: ToolchainReference{
+			Repository: "gsoci.azurecr.io/giantswarm/klaus-toolchains/go",
+			Digest:     "sha256:abc",
+		},
+		Plugins: []PluginReference{
+			{Repository: "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base", Tag: "v1.0.0"},
+			{Repository: "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-platform", Tag: "v0.2.0"},
+		},
+	}
+}
+
+func TestApplyOverlay(t *testing.T) {
+	got, err := ApplyOverlay(overlayBase(), Overlay{
+		AddPlugins: []PluginReference{
+			{Repository: "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base", Tag: "v1.1.0"},
+			{Repository: "example.com/team/runbooks", Tag: "v0.3.0"},
+		},
+		RemovePlugins: []string{"gs-platform"},
+		ToolchainTag:  "v1.4.0",
+	})
+	if err != nil {
+		t.Fatalf("ApplyOverlay() error = %v", err)
+	}
+
+	wantPlugins := []PluginReference{
+		{Repository: "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base", Tag: "v1.1.0"},
+		{Repository: "example.com/team/runbooks", Tag: "v0.3.0"},
+	}
+	if !reflect.DeepEqual(got.Plugins, wantPlugins) {
+		t.Errorf("Plugins = %+v, want %+v", got.Plugins, wantPlugins)
+	}
+	if want := "gsoci.azurecr.io/giantswarm/klaus-toolchains/go:v1.4.0"; got.Toolchain.Ref() != want {
+		t.Errorf("Toolchain = %s, want %s", got.Toolchain.Ref(), want)
+	}
+	if base := overlayBase(); len(base.Plugins) != 2 {
+		t.Error("ApplyOverlay modified the base plugins")
+	}
+}
+
+func TestApplyOverlay_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    Personality
+		overlay Overlay
+		wantErr string
+	}{
+		{
+			name:    "unknown removed plugin",
+			base:    overlayBase(),
+			overlay: Overlay{RemovePlugins: []string{"gs-sre"}},
+			wantErr: "not part of personality sre",
+		},
+		{
+			name:    "toolchain tag without toolchain",
+			base:    Personality{Name: "bare"},
+			overlay: Overlay{ToolchainTag: "v1.0.0"},
+			wantErr: "has no toolchain",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ApplyOverlay(tt.base, tt.overlay)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOverlay_ApplySoul(t *testing.T) {
+	o := Overlay{AppendSoul: "Page phoenix first."}
+	if got, want := o.ApplySoul("You are an SRE.\n"), "You are an SRE.\n\nPage phoenix first."; got != want {
+		t.Errorf("ApplySoul() = %q, want %q", got, want)
+	}
+	if got := (Overlay{}).ApplySoul("unchanged"); got != "unchanged" {
+		t.Errorf("ApplySoul() = %q, want unchanged", got)
+	}
+}
+
+func TestReadOverlayFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overlay.yaml")
+	data := `addPlugins:
+  - repository: example.com/team/runbooks
+    tag: v0.3.0
+removePlugins: [gs-platform]
+toolchainTag: v1.4.0
+appendSoul: Page phoenix first.
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	o, err := ReadOverlayFile(path)
+	if err != nil {
+		t.Fatalf("ReadOverlayFile() error = %v", err)
+	}
+	if len(o.AddPlugins) != 1 || o.AddPlugins[0].Ref() != "example.com/team/runbooks:v0.3.0" {
+		t.Errorf("AddPlugins = %+v", o.AddPlugins)
+	}
+	if o.ToolchainTag != "v1.4.0" || o.AppendSoul != "Page phoenix first." || o.RemovePlugins[0] != "gs-platform" {
+		t.Errorf("overlay = %+v", o)
+	}
+
+	if _, err := ParseOverlay([]byte("addPlugin: []\n")); err == nil {
+		t.Error("expected error for unknown field")
+	}
+	if _, err := ParseOverlay([]byte("addPlugins:\n  - tag: v1\n")); err == nil {
+		t.Error("expected error for plugin without repository")
+	}
+	if _, err := ParseOverlay(nil); err != nil {
+		t.Errorf("ParseOverlay(empty) error = %v", err)
+	}
+}
+
+func TestValidateOverlay(t *testing.T) {
+	ts := newArtifactRegistry(map[string]testArtifactEntry{
+		"giantswarm/klaus-plugins/gs-base": {
+			configJSON:      []byte(`{}`),
+			configMediaType: MediaTypePluginConfig,
+			tags:            []string{"v1.0.0"},
+		},
+		"giantswarm/klaus-toolchains/go": {
+			configJSON:      []byte(`{}`),
+			configMediaType: MediaTypePluginConfig,
+			tags:            []string{"v1.0.0"},
+		},
+	})
+	defer ts.Close()
+	host := testRegistryHost(ts)
+	client := NewClient(WithPlainHTTP(true))
+
+	base := Personality{
+		Name:      "sre",
+		Toolchain: ToolchainReference{Repository: host + "/giantswarm/klaus-toolchains/go", Tag: "v0.9.0"},
+	}
+
+	got, err := client.ValidateOverlay(t.Context(), base, Overlay{
+		AddPlugins:   []PluginReference{{Repository: host + "/giantswarm/klaus-plugins/gs-base", Tag: "v1.0.0"}},
+		ToolchainTag: "v1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("ValidateOverlay() error = %v", err)
+	}
+	if len(got.Plugins) != 1 || got.Toolchain.Tag != "v1.0.0" {
+		t.Errorf("result = %+v", got)
+	}
+
+	_, err = client.ValidateOverlay(t.Context(), base, Overlay{
+		AddPlugins:   []PluginReference{{Repository: host + "/giantswarm/klaus-plugins/missing", Tag: "v1.0.0"}},
+		ToolchainTag: "v9.9.9",
+	})
+	if err == nil {
+		t.Fatal("expected error for missing references")
+	}
+	for _, want := range []string{"klaus-plugins/missing:v1.0.0", "klaus-toolchains/go:v9.9.9"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, want it to mention %s", err, want)
+		}
+	}
+}
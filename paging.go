@@ -0,0 +1,141 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// DefaultPageLimit is the page size of paged listings whose PageRequest
+// sets no Limit.
+const DefaultPageLimit = 100
+
+// PageRequest selects a page of a paged listing.
+type PageRequest struct {
+	// Limit is the maximum number of repositories listed in the page;
+	// zero or negative means DefaultPageLimit.
+	Limit int
+	// Token is the NextToken of the previous page; empty for the first
+	// page.
+	Token string
+}
+
+// ListPage is one page of a paged listing.
+type ListPage struct {
+	// Entries are the artifacts of the page, sorted by repository.
+	// Repositories without semver tags are skipped as in ListPlugins, so
+	// a page may hold fewer than Limit entries even when more follow.
+	Entries []ListEntry
+	// NextToken requests the next page; empty on the last page.
+	NextToken string
+}
+
+// ListPluginsPage lists one page of the plugins ListPlugins would list.
+// Only as much of the registry catalog is read as the page needs, and only
+// the page's repositories are resolved, so web catalogs can paginate a
+// large namespace without listing all of it per request. All ListOptions
+// apply; WithPartialResults ends a page early at its deadline, with
+// NextToken continuing from there.
+func (c *Client) ListPluginsPage(ctx context.Context, req PageRequest, opts ...ListOption) (*ListPage, error) {
+	return c.listPage(ctx, c.registryBase(ctx, KindPlugin), pluginArtifact, req, opts...)
+}
+
+// ListPersonalitiesPage lists one page of the personalities
+// ListPersonalities would list, like ListPluginsPage.
+func (c *Client) ListPersonalitiesPage(ctx context.Context, req PageRequest, opts ...ListOption) (*ListPage, error) {
+	return c.listPage(ctx, c.registryBase(ctx, KindPersonality), personalityArtifact, req, opts...)
+}
+
+// ListToolchainsPage lists one page of the toolchains ListToolchains would
+// list, like ListPluginsPage.
+func (c *Client) ListToolchainsPage(ctx context.Context, req PageRequest, opts ...ListOption) (*ListPage, error) {
+	return c.listPage(ctx, c.registryBase(ctx, KindToolchain), toolchainArtifact, req, opts...)
+}
+
+func (c *Client) listPage(ctx context.Context, defaultBase string, kind artifactKind, req PageRequest, opts ...ListOption) (*ListPage, error) {
+	cfg := newListConfig(opts)
+	base := cfg.base(defaultBase)
+	if err := cfg.checkGlobs(); err != nil {
+		return nil, err
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	after, err := decodeContinuation(req.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	// The archive namespace sorts before base, so walking the namespaces
+	// in order keeps repositories sorted across both.
+	bases := []string{base}
+	if cfg.archived {
+		bases = append(bases, ArchiveNamespace(base))
+		slices.SortFunc(bases, func(a, b string) int { return strings.Compare(a+"/", b+"/") })
+	}
+
+	// Collect one repository more than the page holds to learn whether
+	// another page follows.
+	var repos []string
+	for _, b := range bases {
+		var pending []string
+		flush := func() error {
+			kept, err := c.filterNamespace(ctx, b, pending, cfg)
+			if err != nil {
+				return err
+			}
+			repos = append(repos, kept...)
+			pending = nil
+			if len(repos) > limit {
+				return errStopIteration
+			}
+			return nil
+		}
+		err := c.scanNamespace(ctx, b, after, cfg, func(repo string) error {
+			pending = append(pending, repo)
+			if len(pending) < limit+1-len(repos) {
+				return nil
+			}
+			return flush()
+		})
+		if err == nil && len(pending) > 0 {
+			err = flush()
+		}
+		if err != nil && !errors.Is(err, errStopIteration) {
+			return nil, err
+		}
+		if len(repos) > limit {
+			break
+		}
+	}
+
+	more := len(repos) > limit
+	if more {
+		repos = repos[:limit]
+	}
+	archived := map[string]bool{}
+	for _, r := range repos {
+		if cfg.archived && strings.HasPrefix(r, ArchiveNamespace(base)+"/") {
+			archived[r] = true
+		}
+	}
+
+	artifacts, n, err := c.resolveListed(ctx, repos, kind, cfg, archived, nil)
+	if err != nil {
+		return nil, err
+	}
+	sortListed(artifacts, cfg.sort)
+	page := &ListPage{Entries: toListEntries(artifacts)}
+	switch {
+	case n < len(repos):
+		if n > 0 {
+			after = repos[n-1]
+		}
+		page.NextToken = encodeContinuation(after)
+	case more:
+		page.NextToken = encodeContinuation(repos[n-1])
+	}
+	return page, nil
+}
@@ -0,0 +1,127 @@
+package oci
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestListPluginsPage(t *testing.T) {
+	backend := newTestRegistry(map[string][]string{
+		"klaus-plugins/gs-a": {"v1.0.0"},
+		"klaus-plugins/gs-b": {"v1.1.0"},
+		"klaus-plugins/gs-c": {"main"},
+		"klaus-plugins/gs-d": {"v1.0.0"},
+		"klaus-plugins/gs-e": {"v2.0.0"},
+		"other/gs-f":         {"v1.0.0"},
+	})
+	defer backend.Close()
+	var (
+		mu   sync.Mutex
+		seek []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/_catalog" {
+			mu.Lock()
+			seek = append(seek, r.URL.Query().Get("last"))
+			mu.Unlock()
+		}
+		backend.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	host := testRegistryHost(ts)
+	client := NewClient(WithPlainHTTP(true))
+
+	var pages [][]string
+	req := PageRequest{Limit: 2}
+	for {
+		page, err := client.ListPluginsPage(t.Context(), req, WithRegistry(host+"/klaus-plugins"))
+		if err != nil {
+			t.Fatalf("ListPluginsPage() error = %v", err)
+		}
+		pages = append(pages, listEntryNames(page.Entries))
+		if page.NextToken == "" {
+			break
+		}
+		req.Token = page.NextToken
+	}
+
+	// gs-c has no semver tags, so its page holds one entry.
+	want := [][]string{{"gs-a", "gs-b"}, {"gs-d"}, {"gs-e"}}
+	if !slices.EqualFunc(pages, want, slices.Equal) {
+		t.Errorf("pages = %v, want %v", pages, want)
+	}
+	wantSeek := []string{"klaus-plugins", "klaus-plugins/gs-b", "klaus-plugins/gs-d"}
+	if !slices.Equal(seek, wantSeek) {
+		t.Errorf("catalog seeks = %v, want %v", seek, wantSeek)
+	}
+}
+
+func TestListPluginsPage_FilterAndArchived(t *testing.T) {
+	ts := newTestRegistry(map[string][]string{
+		"klaus-plugins/gs-a":         {"v1.0.0"},
+		"klaus-plugins/gs-b":         {"v1.0.0"},
+		"klaus-plugins/skip":         {"v1.0.0"},
+		"klaus-plugins-archive/gs-z": {"v1.0.0"},
+	})
+	defer ts.Close()
+	host := testRegistryHost(ts)
+	client := NewClient(WithPlainHTTP(true))
+	opts := []ListOption{
+		WithRegistry(host + "/klaus-plugins"),
+		WithArchived(),
+		WithFilter(func(repo string) bool { return !strings.HasSuffix(repo, "/skip") }),
+	}
+
+	first, err := client.ListPluginsPage(t.Context(), PageRequest{Limit: 2}, opts...)
+	if err != nil {
+		t.Fatalf("ListPluginsPage() error = %v", err)
+	}
+	if got := listEntryNames(first.Entries); !slices.Equal(got, []string{"gs-z", "gs-a"}) {
+		t.Errorf("first page = %v, want [gs-z gs-a]", got)
+	}
+	if !first.Entries[0].Archived || first.Entries[1].Archived {
+		t.Errorf("Archived = %v, %v, want true, false", first.Entries[0].Archived, first.Entries[1].Archived)
+	}
+
+	second, err := client.ListPluginsPage(t.Context(), PageRequest{Limit: 2, Token: first.NextToken}, opts...)
+	if err != nil {
+		t.Fatalf("ListPluginsPage() error = %v", err)
+	}
+	if got := listEntryNames(second.Entries); !slices.Equal(got, []string{"gs-b"}) || second.NextToken != "" {
+		t.Errorf("second page = %v, NextToken %q, want [gs-b] and no token", got, second.NextToken)
+	}
+}
+
+func TestListPluginsPage_DefaultLimit(t *testing.T) {
+	ts := newTestRegistry(map[string][]string{"klaus-plugins/gs-a": {"v1.0.0"}})
+	defer ts.Close()
+	client := NewClient(WithPlainHTTP(true))
+
+	page, err := client.ListPluginsPage(t.Context(), PageRequest{}, WithRegistry(testRegistryHost(ts)+"/klaus-plugins"))
+	if err != nil {
+		t.Fatalf("ListPluginsPage() error = %v", err)
+	}
+	if len(page.Entries) != 1 || page.NextToken != "" {
+		t.Errorf("page = %+v, want one entry and no token", page)
+	}
+}
+
+func TestContinuationToken(t *testing.T) {
+	for _, repo := range []string{"", "example.com/klaus-plugins/gs-a"} {
+		token := encodeContinuation(repo)
+		if token == "" {
+			t.Errorf("encodeContinuation(%q) is empty", repo)
+		}
+		got, err := decodeContinuation(token)
+		if err != nil || got != repo {
+			t.Errorf("decodeContinuation(%q) = %q, %v, want %q", token, got, err, repo)
+		}
+	}
+	if _, err := decodeContinuation("Z2FyYmFnZQ"); err == nil {
+		t.Error("decodeContinuation() of a token without prefix succeeded")
+	}
+}
@@ -0,0 +1,135 @@
+package oci
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// KubeAccess is the level of Kubernetes API access a plugin requests.
+type KubeAccess string
+
+// Kubernetes API access levels, in increasing order of privilege.
+const (
+	KubeAccessNone  KubeAccess = ""
+	KubeAccessRead  KubeAccess = "read"
+	KubeAccessWrite KubeAccess = "write"
+)
+
+// rank orders access levels; unknown levels rank above write so that they
+// are never admitted by accident.
+func (a KubeAccess) rank() int {
+	switch a {
+	case KubeAccessNone:
+		return 0
+	case KubeAccessRead:
+		return 1
+	case KubeAccessWrite:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Permissions declares the capabilities a plugin needs at runtime. They are
+// read from the "permissions" section of .claude-plugin/klaus.json:
+//
+//	{
+//	  "permissions": {
+//	    "filesystemWrite": ["/workspace"],
+//	    "networkHosts": ["api.github.com", "*.githubusercontent.com"],
+//	    "shellExec": true,
+//	    "kubeAPI": "read"
+//	  }
+//	}
+type Permissions struct {
+	// FilesystemWrite lists the directories the plugin writes to.
+	FilesystemWrite []string `json:"filesystemWrite,omitempty"`
+	// NetworkHosts lists the hosts the plugin connects to. A leading "*."
+	// matches any subdomain.
+	NetworkHosts []string `json:"networkHosts,omitempty"`
+	// ShellExec is set when the plugin runs shell commands.
+	ShellExec bool `json:"shellExec,omitempty"`
+	// KubeAPI is the Kubernetes API access the plugin needs.
+	KubeAPI KubeAccess `json:"kubeAPI,omitempty"`
+}
+
+// PermissionPolicy is the set of capabilities a cluster admin allows.
+type PermissionPolicy struct {
+	// AllowedWritePaths lists directories plugins may write to, including
+	// their subdirectories.
+	AllowedWritePaths []string
+	// AllowedHosts lists hosts plugins may connect to. "*.example.com"
+	// allows every subdomain of example.com and "*" allows any host.
+	AllowedHosts []string
+	// AllowShellExec permits plugins that run shell commands.
+	AllowShellExec bool
+	// MaxKubeAPI is the highest Kubernetes API access allowed.
+	MaxKubeAPI KubeAccess
+	// RequireDeclaration rejects plugins that do not declare permissions
+	// at all, instead of treating them as requesting nothing.
+	RequireDeclaration bool
+}
+
+// PermissionViolation describes a requested capability the policy does not
+// allow.
+type PermissionViolation struct {
+	// Permission is the name of the permission field, e.g. "networkHosts".
+	Permission string
+	// Value is the requested value that is not allowed.
+	Value string
+}
+
+func (v PermissionViolation) String() string {
+	return fmt.Sprintf("%s %s is not allowed", v.Permission, v.Value)
+}
+
+// EvaluatePermissions checks the permissions declared by plugin against
+// policy and returns every violation, or nil when the plugin is allowed.
+func EvaluatePermissions(plugin Plugin, policy PermissionPolicy) []PermissionViolation {
+	perms := plugin.Permissions
+	if perms == nil {
+		if policy.RequireDeclaration {
+			return []PermissionViolation{{Permission: "permissions", Value: "(undeclared)"}}
+		}
+		return nil
+	}
+
+	var violations []PermissionViolation
+	for _, p := range perms.FilesystemWrite {
+		if !slices.ContainsFunc(policy.AllowedWritePaths, func(allowed string) bool { return pathWithin(p, allowed) }) {
+			violations = append(violations, PermissionViolation{Permission: "filesystemWrite", Value: p})
+		}
+	}
+	for _, h := range perms.NetworkHosts {
+		if !slices.ContainsFunc(policy.AllowedHosts, func(allowed string) bool { return hostAllowed(h, allowed) }) {
+			violations = append(violations, PermissionViolation{Permission: "networkHosts", Value: h})
+		}
+	}
+	if perms.ShellExec && !policy.AllowShellExec {
+		violations = append(violations, PermissionViolation{Permission: "shellExec", Value: "true"})
+	}
+	if perms.KubeAPI.rank() > policy.MaxKubeAPI.rank() {
+		violations = append(violations, PermissionViolation{Permission: "kubeAPI", Value: string(perms.KubeAPI)})
+	}
+	return violations
+}
+
+// pathWithin reports whether p is dir or lies below it.
+func pathWithin(p, dir string) bool {
+	p, dir = path.Clean(p), path.Clean(dir)
+	return p == dir || dir == "/" || strings.HasPrefix(p, dir+"/")
+}
+
+// hostAllowed reports whether the requested host (which may itself be a
+// "*." wildcard) is covered by the allowed pattern.
+func hostAllowed(host, allowed string) bool {
+	if allowed == "*" || host == allowed {
+		return true
+	}
+	if suffix, ok := strings.CutPrefix(allowed, "*"); ok && strings.HasPrefix(suffix, ".") {
+		return strings.HasSuffix(host, suffix)
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package oci
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEvaluatePermissions(t *testing.T) {
+	policy := PermissionPolicy{
+		AllowedWritePaths: []string{"/workspace"},
+		AllowedHosts:      []string{"api.github.com", "*.giantswarm.io"},
+		MaxKubeAPI:        KubeAccessRead,
+	}
+
+	tests := []struct {
+		name   string
+		perms  *Permissions
+		policy PermissionPolicy
+		want   []PermissionViolation
+	}{
+		{name: "undeclared", policy: policy},
+		{
+			name:   "undeclared but required",
+			policy: PermissionPolicy{RequireDeclaration: true},
+			want:   []PermissionViolation{{Permission: "permissions", Value: "(undeclared)"}},
+		},
+		{
+			name: "within policy",
+			perms: &Permissions{
+				FilesystemWrite: []string{"/workspace/repo", "/workspace"},
+				NetworkHosts:    []string{"api.github.com", "docs.giantswarm.io", "*.api.giantswarm.io"},
+				KubeAPI:         KubeAccessRead,
+			},
+			policy: policy,
+		},
+		{
+			name: "beyond policy",
+			perms: &Permissions{
+				FilesystemWrite: []string{"/workspace-other", "/etc"},
+				NetworkHosts:    []string{"giantswarm.io", "evil.example.com"},
+				ShellExec:       true,
+				KubeAPI:         KubeAccessWrite,
+			},
+			policy: policy,
+			want: []PermissionViolation{
+				{Permission: "filesystemWrite", Value: "/workspace-other"},
+				{Permission: "filesystemWrite", Value: "/etc"},
+				{Permission: "networkHosts", Value: "giantswarm.io"},
+				{Permission: "networkHosts", Value: "evil.example.com"},
+				{Permission: "shellExec", Value: "true"},
+				{Permission: "kubeAPI", Value: "write"},
+			},
+		},
+		{
+			name:   "permissive policy",
+			perms:  &Permissions{FilesystemWrite: []string{"/etc"}, NetworkHosts: []string{"example.com"}, ShellExec: true, KubeAPI: KubeAccessWrite},
+			policy: PermissionPolicy{AllowedWritePaths: []string{"/"}, AllowedHosts: []string{"*"}, AllowShellExec: true, MaxKubeAPI: KubeAccessWrite},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EvaluatePermissions(Plugin{Name: "p", Permissions: tt.perms}, tt.policy)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EvaluatePermissions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermissionViolation_String(t *testing.T) {
+	v := PermissionViolation{Permission: "networkHosts", Value: "evil.example.com"}
+	if got, want := v.String(), "networkHosts evil.example.com is not allowed"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestReadPluginFromDir_KlausPermissions(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".claude-plugin", "plugin.json"), `{"name":"gs-kubernetes"}`)
+	writeFile(t, filepath.Join(dir, ".claude-plugin", "klaus.json"),
+		`{"permissions":{"networkHosts":["api.github.com"],"shellExec":true,"kubeAPI":"read"}}`)
+
+	plugin, err := ReadPluginFromDir(dir)
+	if err != nil {
+		t.Fatalf("ReadPluginFromDir() error = %v", err)
+	}
+	want := &Permissions{NetworkHosts: []string{"api.github.com"}, ShellExec: true, KubeAPI: KubeAccessRead}
+	if !reflect.DeepEqual(plugin.Permissions, want) {
+		t.Errorf("Permissions = %+v, want %+v", plugin.Permissions, want)
+	}
+
+	writeFile(t, filepath.Join(dir, ".claude-plugin", "klaus.json"), `{"permissions":{"kubeAPI":"admin"}}`)
+	if _, err := ReadPluginFromDir(dir); err == nil {
+		t.Error("expected error for unknown kubeAPI access")
+	}
+}
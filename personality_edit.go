@@ -0,0 +1,140 @@
+package oci
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"oras.land/oras-go/v2/registry"
+)
+
+// ReferenceResolver resolves a short name or reference to a
+// fully-qualified reference, e.g. Client.ResolvePluginRef or
+// Client.ResolveToolchainRef.
+type ReferenceResolver func(ctx context.Context, ref string) (string, error)
+
+// ParsePluginReference parses a plugin reference such as "gs-base",
+// "gs-base:v1.0.0", or "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base@sha256:...".
+// Short names are expanded with DefaultPluginRegistry.
+func ParsePluginReference(ref string) (PluginReference, error) {
+	repo, tag, digest, err := parseArtifactReference(ref, DefaultPluginRegistry)
+	if err != nil {
+		return PluginReference{}, err
+	}
+	return PluginReference{Repository: repo, Tag: tag, Digest: digest}, nil
+}
+
+// ParseToolchainReference parses a toolchain reference like
+// ParsePluginReference, expanding short names with
+// DefaultToolchainRegistry.
+func ParseToolchainReference(ref string) (ToolchainReference, error) {
+	repo, tag, digest, err := parseArtifactReference(ref, DefaultToolchainRegistry)
+	if err != nil {
+		return ToolchainReference{}, err
+	}
+	return ToolchainReference{Repository: repo, Tag: tag, Digest: digest}, nil
+}
+
+// parseArtifactReference validates ref and splits it into repository, tag,
+// and digest, expanding short names with registryBase.
+func parseArtifactReference(ref, registryBase string) (repo, tag, digest string, err error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", "", "", fmt.Errorf("empty artifact reference")
+	}
+	full := ref
+	if !strings.Contains(ref, "/") {
+		full = registryBase + "/" + ref
+	}
+	parsed, err := registry.ParseReference(full)
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+	repo = parsed.Registry + "/" + parsed.Repository
+	if hasDigest(full) {
+		return repo, "", parsed.Reference, nil
+	}
+	return repo, parsed.Reference, "", nil
+}
+
+// AddPlugin adds the plugin ref to the personality. ref is parsed with
+// ParsePluginReference after resolve, if not nil, has resolved it, so
+// that passing Client.ResolvePluginRef pins short names and untagged
+// references to their latest version. Adding a plugin whose repository
+// is already referenced returns *ErrDuplicatePlugin.
+func (p *Personality) AddPlugin(ctx context.Context, ref string, resolve ReferenceResolver) error {
+	if resolve != nil {
+		resolved, err := resolve(ctx, ref)
+		if err != nil {
+			return err
+		}
+		ref = resolved
+	}
+	plugin, err := ParsePluginReference(ref)
+	if err != nil {
+		return err
+	}
+	for _, existing := range p.Plugins {
+		if existing.Repository == plugin.Repository {
+			return &ErrDuplicatePlugin{Existing: existing}
+		}
+	}
+	p.Plugins = append(p.Plugins, plugin)
+	return nil
+}
+
+// RemovePlugin removes the plugin with the given repository, or short
+// name if it is unambiguous, from the personality. Any tag or digest on
+// nameOrRef is ignored.
+func (p *Personality) RemovePlugin(nameOrRef string) error {
+	want := strings.TrimSpace(nameOrRef)
+	byName := !strings.Contains(want, "/")
+	if byName {
+		want, _ = SplitNameTag(want)
+	} else {
+		want = RepositoryFromRef(want)
+	}
+	if want == "" {
+		return fmt.Errorf("empty plugin reference")
+	}
+
+	match := -1
+	for i, plugin := range p.Plugins {
+		if plugin.Repository != want && (!byName || ShortName(plugin.Repository) != want) {
+			continue
+		}
+		if match >= 0 {
+			return fmt.Errorf("plugin name %q is ambiguous: %s and %s", want, p.Plugins[match].Repository, plugin.Repository)
+		}
+		match = i
+	}
+	if match < 0 {
+		return fmt.Errorf("personality %s does not reference plugin %s", p.Name, nameOrRef)
+	}
+	p.Plugins = slices.Delete(p.Plugins, match, match+1)
+	return nil
+}
+
+// SetToolchain replaces the personality's toolchain with ref, resolved and
+// parsed like AddPlugin does for plugins, e.g. with
+// Client.ResolveToolchainRef. An empty ref removes the toolchain.
+func (p *Personality) SetToolchain(ctx context.Context, ref string, resolve ReferenceResolver) error {
+	if strings.TrimSpace(ref) == "" {
+		p.Toolchain = ToolchainReference{}
+		return nil
+	}
+	if resolve != nil {
+		resolved, err := resolve(ctx, ref)
+		if err != nil {
+			return err
+		}
+		ref = resolved
+	}
+	toolchain, err := ParseToolchainReference(ref)
+	if err != nil {
+		return err
+	}
+	p.Toolchain = toolchain
+	return nil
+}
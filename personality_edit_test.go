@@ -0,0 +1,103 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParsePluginReference(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    PluginReference
+		wantErr bool
+	}{
+		{ref: "gs-base", want: PluginReference{Repository: DefaultPluginRegistry + "/gs-base"}},
+		{ref: "gs-base:v1.0.0", want: PluginReference{Repository: DefaultPluginRegistry + "/gs-base", Tag: "v1.0.0"}},
+		{ref: "localhost:5000/plugins/gs-base", want: PluginReference{Repository: "localhost:5000/plugins/gs-base"}},
+		{
+			ref:  "example.com/plugins/gs-base@sha256:" + sum256Hex(nil),
+			want: PluginReference{Repository: "example.com/plugins/gs-base", Digest: "sha256:" + sum256Hex(nil)},
+		},
+		{ref: "", wantErr: true},
+		{ref: "GS-Base", wantErr: true},
+		{ref: "example.com/plugins/gs-base@sha256:short", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePluginReference(tt.ref)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePluginReference(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePluginReference(%q) = %+v, want %+v", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestPersonality_AddPlugin(t *testing.T) {
+	p := Personality{Name: "sre"}
+	if err := p.AddPlugin(t.Context(), "gs-base:v1.0.0", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var dup *ErrDuplicatePlugin
+	if err := p.AddPlugin(t.Context(), DefaultPluginRegistry+"/gs-base:v2.0.0", nil); !errors.As(err, &dup) || dup.Existing.Tag != "v1.0.0" {
+		t.Errorf("AddPlugin() duplicate error = %v, want *ErrDuplicatePlugin", err)
+	}
+
+	resolve := func(_ context.Context, ref string) (string, error) {
+		return DefaultPluginRegistry + "/" + ref + ":v0.3.0", nil
+	}
+	if err := p.AddPlugin(t.Context(), "gs-flux", resolve); err != nil {
+		t.Fatal(err)
+	}
+	want := PluginReference{Repository: DefaultPluginRegistry + "/gs-flux", Tag: "v0.3.0"}
+	if len(p.Plugins) != 2 || p.Plugins[1] != want {
+		t.Errorf("Plugins = %+v, want gs-flux pinned to v0.3.0", p.Plugins)
+	}
+
+	failing := func(context.Context, string) (string, error) { return "", errors.New("not found") }
+	if err := p.AddPlugin(t.Context(), "gs-missing", failing); err == nil || len(p.Plugins) != 2 {
+		t.Errorf("AddPlugin() with failing resolver = %v, plugins %+v", err, p.Plugins)
+	}
+}
+
+func TestPersonality_RemovePlugin(t *testing.T) {
+	p := Personality{Name: "sre", Plugins: []PluginReference{
+		{Repository: "a.example.com/plugins/gs-base", Tag: "v1.0.0"},
+		{Repository: "b.example.com/plugins/gs-base", Tag: "v1.0.0"},
+		{Repository: "a.example.com/plugins/gs-flux", Tag: "v1.0.0"},
+	}}
+	if err := p.RemovePlugin("gs-base"); err == nil {
+		t.Error("RemovePlugin() with an ambiguous name succeeded")
+	}
+	if err := p.RemovePlugin("gs-flux:v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.RemovePlugin("b.example.com/plugins/gs-base:v9.9.9"); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Plugins) != 1 || p.Plugins[0].Repository != "a.example.com/plugins/gs-base" {
+		t.Errorf("Plugins = %+v", p.Plugins)
+	}
+	if err := p.RemovePlugin("gs-flux"); err == nil {
+		t.Error("RemovePlugin() of a missing plugin succeeded")
+	}
+}
+
+func TestPersonality_SetToolchain(t *testing.T) {
+	p := Personality{Name: "sre"}
+	if err := p.SetToolchain(t.Context(), "go:v1.2.0", nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := (ToolchainReference{Repository: DefaultToolchainRegistry + "/go", Tag: "v1.2.0"}); p.Toolchain != want {
+		t.Errorf("Toolchain = %+v, want %+v", p.Toolchain, want)
+	}
+	if err := p.SetToolchain(t.Context(), "not a reference", nil); err == nil {
+		t.Error("SetToolchain() with an invalid reference succeeded")
+	}
+	if err := p.SetToolchain(t.Context(), "", nil); err != nil || p.Toolchain != (ToolchainReference{}) {
+		t.Errorf("SetToolchain(\"\") = %v, toolchain %+v", err, p.Toolchain)
+	}
+}
@@ -0,0 +1,195 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"golang.org/x/sync/errgroup"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// PinPersonality returns a copy of p in which the toolchain and every
+// plugin reference carry both a tag and the manifest digest it currently
+// points at. References without a tag, or tagged "latest", are resolved to
+// the highest semver tag first. References that already have a digest are
+// kept as they are. Extends is not changed.
+//
+// Pinned compositions pull exactly the artifacts they were pinned to, while
+// the tag keeps them readable; see UnpinnedReferences to audit a
+// composition. Any reference that cannot be resolved fails the pin.
+func (c *Client) PinPersonality(ctx context.Context, p Personality) (Personality, error) {
+	pinned := p
+	pinned.Plugins = slices.Clone(p.Plugins)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+	if p.Toolchain.Repository != "" && p.Toolchain.Digest == "" {
+		g.Go(func() error {
+			tag, digest, err := c.pinReference(gctx, p.Toolchain.Ref(), c.ResolveToolchainRef)
+			if err != nil {
+				return fmt.Errorf("pinning toolchain %s: %w", p.Toolchain.Ref(), err)
+			}
+			pinned.Toolchain.Tag, pinned.Toolchain.Digest = tag, digest
+			return nil
+		})
+	}
+	for i, ref := range p.Plugins {
+		if ref.Digest != "" {
+			continue
+		}
+		g.Go(func() error {
+			tag, digest, err := c.pinReference(gctx, ref.Ref(), c.ResolvePluginRef)
+			if err != nil {
+				return fmt.Errorf("pinning plugin %s: %w", ref.Ref(), err)
+			}
+			pinned.Plugins[i].Tag, pinned.Plugins[i].Digest = tag, digest
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return Personality{}, err
+	}
+	return pinned, nil
+}
+
+// pinReference resolves ref with resolve and returns its tag and the
+// digest the tag points at.
+func (c *Client) pinReference(ctx context.Context, ref string, resolve ReferenceResolver) (tag, digest string, err error) {
+	resolved, err := resolve(ctx, ref)
+	if err != nil {
+		return "", "", err
+	}
+	digest, err = c.Resolve(ctx, resolved)
+	if err != nil {
+		return "", "", err
+	}
+	return extractTag(resolved), digest, nil
+}
+
+// UnpinnedReferences returns the toolchain and plugin references of p that
+// have no digest, in the form of their Ref. Their content can change
+// without the composition changing; PinPersonality pins them.
+func UnpinnedReferences(p Personality) []string {
+	var refs []string
+	if p.Toolchain.Repository != "" && p.Toolchain.Digest == "" {
+		refs = append(refs, p.Toolchain.Ref())
+	}
+	for _, ref := range p.Plugins {
+		if ref.Digest == "" {
+			refs = append(refs, ref.Ref())
+		}
+	}
+	return refs
+}
+
+// PinDrift names a way a pinned reference drifted from the registry.
+type PinDrift string
+
+const (
+	// DriftMissing reports a pinned digest that no longer exists in the
+	// registry. Pulling the composition fails.
+	DriftMissing PinDrift = "missing"
+	// DriftTagMoved reports a tag that points at another manifest than
+	// the digest pinned next to it. The pin still pulls the old content.
+	DriftTagMoved PinDrift = "tagMoved"
+	// DriftTagMissing reports a tag pinned next to a digest that no
+	// longer exists.
+	DriftTagMissing PinDrift = "tagMissing"
+)
+
+// PinFinding is a drift found by VerifyPins.
+type PinFinding struct {
+	// Ref is the pinned reference (repository@digest).
+	Ref string `json:"ref"`
+	// Tag is the tag pinned next to the digest, if any.
+	Tag   string   `json:"tag,omitempty"`
+	Drift PinDrift `json:"drift"`
+	// Severity is SeverityError for DriftMissing and SeverityWarning
+	// otherwise.
+	Severity Severity `json:"severity"`
+	// CurrentDigest is the digest Tag points at now, set for
+	// DriftTagMoved.
+	CurrentDigest string `json:"currentDigest,omitempty"`
+	Message       string `json:"message"`
+}
+
+// VerifyPins checks the digest-pinned toolchain and plugin references of
+// p against the registry, e.g. in a periodic job alerting when pinned
+// content disappears. It reports pinned digests that no longer exist and,
+// for references that also carry a tag, tags that were moved to another
+// manifest or deleted. References without a digest are not checked; see
+// UnpinnedReferences. Findings are ordered like the references in p, the
+// toolchain first. Tag resolution bypasses the response cache. An error
+// is returned only when the registry cannot be queried.
+func (c *Client) VerifyPins(ctx context.Context, p Personality) ([]PinFinding, error) {
+	type pin struct{ repository, tag, digest string }
+	var pins []pin
+	if p.Toolchain.Repository != "" && p.Toolchain.Digest != "" {
+		pins = append(pins, pin{p.Toolchain.Repository, p.Toolchain.Tag, p.Toolchain.Digest})
+	}
+	for _, ref := range p.Plugins {
+		if ref.Digest != "" {
+			pins = append(pins, pin{ref.Repository, ref.Tag, ref.Digest})
+		}
+	}
+
+	findings := make([]*PinFinding, len(pins))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+	for i, pin := range pins {
+		g.Go(func() error {
+			f, err := c.verifyPin(gctx, pin.repository, pin.tag, pin.digest)
+			findings[i] = f
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var result []PinFinding
+	for _, f := range findings {
+		if f != nil {
+			result = append(result, *f)
+		}
+	}
+	return result, nil
+}
+
+// verifyPin checks one pinned reference and returns its drift, or nil if
+// it has none.
+func (c *Client) verifyPin(ctx context.Context, repository, tag, digest string) (*PinFinding, error) {
+	ref := repository + "@" + digest
+	repo, err := c.newRepositoryFromName(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = repo.Resolve(ctx, digest)
+	if errors.Is(err, errdef.ErrNotFound) {
+		return &PinFinding{Ref: ref, Tag: tag, Drift: DriftMissing, Severity: SeverityError,
+			Message: fmt.Sprintf("pinned manifest %s no longer exists in %s", digest, repository)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	if tag == "" {
+		return nil, nil
+	}
+
+	current, err := repo.Resolve(ctx, tag)
+	if errors.Is(err, errdef.ErrNotFound) {
+		return &PinFinding{Ref: ref, Tag: tag, Drift: DriftTagMissing, Severity: SeverityWarning,
+			Message: fmt.Sprintf("tag %s no longer exists in %s", tag, repository)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s:%s: %w", repository, tag, err)
+	}
+	if d := current.Digest.String(); d != digest {
+		return &PinFinding{Ref: ref, Tag: tag, Drift: DriftTagMoved, Severity: SeverityWarning, CurrentDigest: d,
+			Message: fmt.Sprintf("tag %s moved from %s to %s", tag, TruncateDigest(digest), TruncateDigest(d))}, nil
+	}
+	return nil, nil
+}
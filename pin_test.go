@@ -0,0 +1,150 @@
+package oci
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPinPersonality(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	toolchain := host + "/klaus-toolchains/go"
+	base := host + "/klaus-plugins/gs-base"
+	flux := host + "/klaus-plugins/gs-flux"
+	toolchainDigests := pushVersions(t, client, toolchain, "v1.0.0", "v1.2.0")
+	baseDigests := pushVersions(t, client, base, "v1.0.0", "v1.1.0")
+	fluxDigests := pushVersions(t, client, flux, "v0.3.0")
+
+	p := Personality{
+		Name:      "sre",
+		Toolchain: ToolchainReference{Repository: toolchain},
+		Plugins: []PluginReference{
+			{Repository: base, Tag: "v1.0.0"},
+			{Repository: flux, Tag: "latest"},
+			{Repository: host + "/klaus-plugins/gs-pinned", Digest: "sha256:" + sum256Hex([]byte("pinned"))},
+		},
+	}
+	if got := UnpinnedReferences(p); len(got) != 3 {
+		t.Errorf("UnpinnedReferences() = %v, want toolchain, gs-base, and gs-flux", got)
+	}
+
+	pinned, err := client.PinPersonality(t.Context(), p)
+	if err != nil {
+		t.Fatalf("PinPersonality() error = %v", err)
+	}
+	if want := (ToolchainReference{Repository: toolchain, Tag: "v1.2.0", Digest: toolchainDigests["v1.2.0"]}); pinned.Toolchain != want {
+		t.Errorf("Toolchain = %+v, want %+v", pinned.Toolchain, want)
+	}
+	want := []PluginReference{
+		{Repository: base, Tag: "v1.0.0", Digest: baseDigests["v1.0.0"]},
+		{Repository: flux, Tag: "v0.3.0", Digest: fluxDigests["v0.3.0"]},
+		p.Plugins[2],
+	}
+	if !slices.Equal(pinned.Plugins, want) {
+		t.Errorf("Plugins = %+v, want %+v", pinned.Plugins, want)
+	}
+	if got := UnpinnedReferences(pinned); len(got) != 0 {
+		t.Errorf("UnpinnedReferences(pinned) = %v, want none", got)
+	}
+	if p.Plugins[0].Digest != "" || p.Toolchain.Digest != "" {
+		t.Error("PinPersonality modified its argument")
+	}
+}
+
+func TestPinPersonality_Unresolvable(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+
+	p := Personality{Plugins: []PluginReference{{Repository: host + "/klaus-plugins/missing", Tag: "v1.0.0"}}}
+	if _, err := client.PinPersonality(t.Context(), p); err == nil {
+		t.Fatal("PinPersonality() error = nil, want error")
+	}
+}
+
+func TestUnpinnedReferences(t *testing.T) {
+	p := Personality{
+		Toolchain: ToolchainReference{Repository: "example.com/toolchains/go", Tag: "v1.0.0", Digest: "sha256:abc"},
+		Plugins: []PluginReference{
+			{Repository: "example.com/plugins/gs-base", Tag: "v1.0.0"},
+			{Repository: "example.com/plugins/gs-flux"},
+			{Repository: "example.com/plugins/gs-ae", Digest: "sha256:def"},
+		},
+	}
+	want := []string{"example.com/plugins/gs-base:v1.0.0", "example.com/plugins/gs-flux"}
+	if got := UnpinnedReferences(p); !slices.Equal(got, want) {
+		t.Errorf("UnpinnedReferences() = %v, want %v", got, want)
+	}
+	if got := UnpinnedReferences(Personality{}); got != nil {
+		t.Errorf("UnpinnedReferences(empty) = %v, want nil", got)
+	}
+}
+
+func TestVerifyPins(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	toolchain := host + "/klaus-toolchains/go"
+	base := host + "/klaus-plugins/gs-base"
+	flux := host + "/klaus-plugins/gs-flux"
+	toolchainDigests := pushVersions(t, client, toolchain, "v1.0.0")
+	baseDigests := pushVersions(t, client, base, "v1.0.0", "v1.1.0")
+	fluxDigests := pushVersions(t, client, flux, "v0.3.0")
+	gone := "sha256:" + sum256Hex([]byte("gone"))
+
+	p := Personality{
+		Toolchain: ToolchainReference{Repository: toolchain, Tag: "v1.0.0", Digest: toolchainDigests["v1.0.0"]},
+		Plugins: []PluginReference{
+			{Repository: base, Tag: "v1.0.0", Digest: baseDigests["v1.0.0"]},
+			{Repository: flux, Tag: "v0.3.0", Digest: fluxDigests["v0.3.0"]},
+			{Repository: host + "/klaus-plugins/gs-ae", Digest: gone},
+			{Repository: host + "/klaus-plugins/gs-unpinned", Tag: "v1.0.0"},
+		},
+	}
+
+	findings, err := client.VerifyPins(t.Context(), p)
+	if err != nil {
+		t.Fatalf("VerifyPins() error = %v", err)
+	}
+	if len(findings) != 1 || findings[0].Drift != DriftMissing || findings[0].Severity != SeverityError {
+		t.Fatalf("VerifyPins() = %+v, want only the missing gs-ae digest", findings)
+	}
+
+	if err := client.Retag(t.Context(), base, baseDigests["v1.1.0"], "v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := client.newRepositoryFromName(flux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err := repo.Resolve(t.Context(), "v0.3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Delete(t.Context(), desc); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err = client.VerifyPins(t.Context(), p)
+	if err != nil {
+		t.Fatalf("VerifyPins() error = %v", err)
+	}
+	want := []PinFinding{
+		{Ref: base + "@" + baseDigests["v1.0.0"], Tag: "v1.0.0", Drift: DriftTagMoved, Severity: SeverityWarning, CurrentDigest: baseDigests["v1.1.0"]},
+		{Ref: flux + "@" + fluxDigests["v0.3.0"], Tag: "v0.3.0", Drift: DriftTagMissing, Severity: SeverityWarning},
+		{Ref: host + "/klaus-plugins/gs-ae@" + gone, Drift: DriftMissing, Severity: SeverityError},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("VerifyPins() = %+v, want %d findings", findings, len(want))
+	}
+	for i, f := range findings {
+		if f.Message == "" {
+			t.Errorf("findings[%d].Message is empty", i)
+		}
+		f.Message = ""
+		if f != want[i] {
+			t.Errorf("findings[%d] = %+v, want %+v", i, f, want[i])
+		}
+	}
+}
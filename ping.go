@@ -0,0 +1,252 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+// Support is a tri-state answer to whether a registry supports a feature.
+type Support string
+
+const (
+	// SupportUnknown means support could not be determined.
+	SupportUnknown Support = "unknown"
+	// Supported means the registry supports the feature.
+	Supported Support = "supported"
+	// Unsupported means the registry does not support the feature, or does
+	// not allow it for the client's credentials.
+	Unsupported Support = "unsupported"
+)
+
+// Feature is an optional registry feature detected by Client.Supports.
+type Feature string
+
+const (
+	// FeatureReferrers is the OCI referrers API. It is detected per
+	// repository.
+	FeatureReferrers Feature = "referrers"
+	// FeatureDelete is manifest deletion through the registry API.
+	FeatureDelete Feature = "delete"
+	// FeatureCatalog is the catalog API, as available to the client's
+	// credentials.
+	FeatureCatalog Feature = "catalog"
+)
+
+// RegistryCapabilities are hints about the optional features of a
+// registry. They are detected without modifying the registry, so some
+// may be SupportUnknown.
+type RegistryCapabilities struct {
+	// Referrers reports support for the OCI referrers API. It is probed
+	// only when Ping is given a repository.
+	Referrers Support
+	// Delete reports whether manifests can be deleted through the
+	// registry API. There is no side-effect-free probe for deletion, so
+	// it is derived from the registry product (Harbor) or well-known
+	// hosts.
+	Delete Support
+	// Catalog reports whether the registry serves the catalog API to the
+	// client.
+	Catalog Support
+}
+
+// PingResult is the result of Client.Ping.
+type PingResult struct {
+	// Host is the registry host that was checked.
+	Host string
+	// Latency is the duration of the /v2/ check, including
+	// authentication.
+	Latency time.Duration
+	// Capabilities are the capability hints of the registry.
+	Capabilities RegistryCapabilities
+}
+
+// Ping checks that the registry serving target answers the /v2/ API
+// check with the client's credentials, for use as a readiness gate.
+// target is a registry host, optionally followed by a repository (e.g.
+// "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base"), which enables the
+// referrers probe. The /v2/ check runs on every call; capability hints are
+// detected on the first successful ping of a target and cached by the
+// client; see Supports.
+func (c *Client) Ping(ctx context.Context, target string) (*PingResult, error) {
+	host, repository, _ := strings.Cut(strings.Trim(strings.TrimSpace(target), "/"), "/")
+	if host == "" {
+		return nil, fmt.Errorf("invalid registry %q", target)
+	}
+	reg, err := c.registry(host)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	if err := reg.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("pinging %s: %w", host, err)
+	}
+	result := &PingResult{Host: host, Latency: time.Since(start)}
+
+	result.Capabilities = RegistryCapabilities{
+		Referrers: c.supports(ctx, host, repository, FeatureReferrers),
+		Delete:    c.supports(ctx, host, repository, FeatureDelete),
+		Catalog:   c.supports(ctx, host, repository, FeatureCatalog),
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Supports reports whether the registry serving target supports feature.
+// target is a registry host, optionally followed by a repository, which
+// FeatureReferrers requires. Features are detected with read-only
+// requests on first use and cached by the client, so higher-level
+// operations can consult them cheaply. Detection failures yield
+// SupportUnknown; only a canceled ctx returns an error.
+func (c *Client) Supports(ctx context.Context, target string, feature Feature) (Support, error) {
+	host, repository, _ := strings.Cut(strings.Trim(strings.TrimSpace(target), "/"), "/")
+	if host == "" {
+		return SupportUnknown, fmt.Errorf("invalid registry %q", target)
+	}
+	s := c.supports(ctx, host, repository, feature)
+	if err := ctx.Err(); err != nil {
+		return SupportUnknown, err
+	}
+	return s, nil
+}
+
+// supports detects feature for host and, for repository-scoped features,
+// repository, and caches the answer. Answers obtained while ctx was
+// canceled are not cached.
+func (c *Client) supports(ctx context.Context, host, repository string, feature Feature) Support {
+	key := string(feature) + " " + host
+	if feature == FeatureReferrers {
+		if repository == "" {
+			return SupportUnknown
+		}
+		key += "/" + repository
+	}
+	if cached, ok := c.capabilities.Load(key); ok {
+		return cached.(Support)
+	}
+
+	s := SupportUnknown
+	switch feature {
+	case FeatureReferrers:
+		s = c.probeReferrers(ctx, host, repository)
+	case FeatureDelete:
+		s = c.deleteSupport(ctx, host)
+	case FeatureCatalog:
+		s = c.probeCatalog(ctx, host)
+	}
+	if ctx.Err() == nil {
+		c.capabilities.Store(key, s)
+	}
+	return s
+}
+
+// isUnsupportedResponse reports whether err is a registry response that
+// rejects a request type outright (404, 405, 415, or 501), as registries
+// do for APIs or manifest fields they do not implement.
+func isUnsupportedResponse(err error) bool {
+	var errResp *errcode.ErrorResponse
+	if !errors.As(err, &errResp) {
+		return false
+	}
+	switch errResp.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusUnsupportedMediaType, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// errStopListing ends a catalog listing after the first page.
+var errStopListing = errors.New("stop listing")
+
+// probeCatalog requests a single catalog entry.
+func (c *Client) probeCatalog(ctx context.Context, host string) Support {
+	reg, err := remote.NewRegistry(host)
+	if err != nil {
+		return SupportUnknown
+	}
+	reg.PlainHTTP = c.plainHTTP
+	reg.Client = c.authClient
+	reg.RepositoryListPageSize = 1
+	err = reg.Repositories(ctx, "", func([]string) error { return errStopListing })
+	var errResp *errcode.ErrorResponse
+	switch {
+	case err == nil, errors.Is(err, errStopListing):
+		return Supported
+	case errors.As(err, &errResp):
+		if errResp.StatusCode >= 400 && errResp.StatusCode < 500 || errResp.StatusCode == http.StatusNotImplemented {
+			return Unsupported
+		}
+	}
+	return SupportUnknown
+}
+
+// probeReferrers queries the referrers of the empty JSON descriptor in
+// repository. Registries implementing the referrers API answer with an
+// index even for unknown subjects; others answer 404.
+func (c *Client) probeReferrers(ctx context.Context, host, repository string) Support {
+	scheme := "https"
+	if c.plainHTTP {
+		scheme = "http"
+	}
+	u := fmt.Sprintf("%s://%s/v2/%s/referrers/%s", scheme, host, repository, ocispec.DescriptorEmptyJSON.Digest)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return SupportUnknown
+	}
+	resp, err := c.authClient.Do(req)
+	if err != nil {
+		return SupportUnknown
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return Supported
+	case http.StatusNotFound:
+		// A missing repository says nothing about the API.
+		var body struct {
+			Errors []struct {
+				Code string `json:"code"`
+			} `json:"errors"`
+		}
+		if json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&body) == nil &&
+			len(body.Errors) > 0 && body.Errors[0].Code == errcode.ErrorCodeNameUnknown {
+			return SupportUnknown
+		}
+		return Unsupported
+	case http.StatusBadRequest, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return Unsupported
+	}
+	return SupportUnknown
+}
+
+// deleteSupport derives manifest deletion support from the registry
+// product and well-known hosts.
+func (c *Client) deleteSupport(ctx context.Context, host string) Support {
+	switch {
+	case host == "ghcr.io", host == "docker.io", host == "registry-1.docker.io":
+		// Deletion requires the GitHub or Docker Hub web APIs.
+		return Unsupported
+	case strings.HasSuffix(host, ".azurecr.io"),
+		strings.HasSuffix(host, ".amazonaws.com"),
+		strings.HasSuffix(host, ".pkg.dev"),
+		host == "gcr.io", strings.HasSuffix(host, ".gcr.io"):
+		return Supported
+	}
+	if c.isHarbor(ctx, host) {
+		return Supported
+	}
+	return SupportUnknown
+}
@@ -0,0 +1,181 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+func TestPing(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+
+	result, err := client.Ping(t.Context(), host)
+	if err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if result.Host != host || result.Latency <= 0 {
+		t.Errorf("Ping() = %+v", result)
+	}
+	want := RegistryCapabilities{Referrers: SupportUnknown, Delete: SupportUnknown, Catalog: Supported}
+	if result.Capabilities != want {
+		t.Errorf("Capabilities = %+v, want %+v", result.Capabilities, want)
+	}
+}
+
+func TestPing_Capabilities(t *testing.T) {
+	var pings, probes atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/":
+			pings.Add(1)
+		case r.URL.Path == "/v2/_catalog":
+			probes.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"errors":[{"code":"UNAUTHORIZED"}]}`))
+		case strings.HasPrefix(r.URL.Path, "/v2/klaus/plugins/referrers/"):
+			probes.Add(1)
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+			_ = json.NewEncoder(w).Encode(ocispec.Index{MediaType: ocispec.MediaTypeImageIndex, Manifests: []ocispec.Descriptor{}})
+		case strings.HasPrefix(r.URL.Path, "/v2/missing/referrers/"):
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[{"code":"NAME_UNKNOWN"}]}`))
+		case r.URL.Path == "/api/version":
+			probes.Add(1)
+			_, _ = w.Write([]byte(`{"version":"v2.10.0"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	host := testRegistryHost(ts)
+	client := NewClient(WithPlainHTTP(true))
+
+	for range 2 {
+		result, err := client.Ping(t.Context(), host+"/klaus/plugins")
+		if err != nil {
+			t.Fatalf("Ping() error = %v", err)
+		}
+		want := RegistryCapabilities{Referrers: Supported, Delete: Supported, Catalog: Unsupported}
+		if result.Capabilities != want {
+			t.Errorf("Capabilities = %+v, want %+v", result.Capabilities, want)
+		}
+	}
+	if got := pings.Load(); got != 2 {
+		t.Errorf("/v2/ checked %d times, want every ping", got)
+	}
+	if got := probes.Load(); got != 3 {
+		t.Errorf("%d capability probes, want 3 (cached after the first ping)", got)
+	}
+
+	result, err := client.Ping(t.Context(), host+"/missing")
+	if err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if result.Capabilities.Referrers != SupportUnknown {
+		t.Errorf("Referrers for missing repository = %q, want unknown", result.Capabilities.Referrers)
+	}
+}
+
+func TestPing_Unavailable(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	client := NewClient(WithPlainHTTP(true))
+	if _, err := client.Ping(t.Context(), testRegistryHost(ts)); err == nil {
+		t.Fatal("Ping() succeeded against an unavailable registry")
+	}
+	if _, err := client.Ping(t.Context(), ""); err == nil {
+		t.Fatal("Ping() succeeded without a host")
+	}
+}
+
+func TestDeleteSupport_WellKnownHosts(t *testing.T) {
+	client := NewClient()
+	tests := map[string]Support{
+		"ghcr.io":                              Unsupported,
+		"docker.io":                            Unsupported,
+		"gsoci.azurecr.io":                     Supported,
+		"europe-docker.pkg.dev":                Supported,
+		"1234.dkr.ecr.eu-west-1.amazonaws.com": Supported,
+	}
+	for host, want := range tests {
+		if got := client.deleteSupport(t.Context(), host); got != want {
+			t.Errorf("deleteSupport(%s) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestSupports(t *testing.T) {
+	var probes atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/referrers/") {
+			probes.Add(1)
+		}
+		http.NotFound(w, r)
+	}))
+	defer ts.Close()
+	host := testRegistryHost(ts)
+	client := NewClient(WithPlainHTTP(true))
+
+	for range 2 {
+		got, err := client.Supports(t.Context(), host+"/klaus/plugins", FeatureReferrers)
+		if err != nil {
+			t.Fatalf("Supports() error = %v", err)
+		}
+		if got != Unsupported {
+			t.Errorf("Supports(referrers) = %q, want unsupported", got)
+		}
+	}
+	if got := probes.Load(); got != 1 {
+		t.Errorf("referrers probed %d times, want 1", got)
+	}
+
+	if got, err := client.Supports(t.Context(), host, FeatureReferrers); err != nil || got != SupportUnknown {
+		t.Errorf("Supports(referrers) without repository = %q, %v, want unknown", got, err)
+	}
+	if got, err := client.Supports(t.Context(), host, Feature("zstd")); err != nil || got != SupportUnknown {
+		t.Errorf("Supports(zstd) = %q, %v, want unknown", got, err)
+	}
+	if _, err := client.Supports(t.Context(), " / ", FeatureCatalog); err == nil {
+		t.Error("Supports() succeeded without a host")
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, err := client.Supports(ctx, host+"/klaus/other", FeatureReferrers); !errors.Is(err, context.Canceled) {
+		t.Errorf("Supports() with canceled context error = %v, want context.Canceled", err)
+	}
+}
+
+func TestIsUnsupportedResponse(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&errcode.ErrorResponse{StatusCode: http.StatusMethodNotAllowed}, true},
+		{fmt.Errorf("pushing: %w", &errcode.ErrorResponse{StatusCode: http.StatusNotImplemented}), true},
+		{&errcode.ErrorResponse{StatusCode: http.StatusUnauthorized}, false},
+		{&errcode.ErrorResponse{StatusCode: http.StatusInternalServerError}, false},
+		{errors.New("connection refused"), false},
+	}
+	for _, tt := range tests {
+		if got := isUnsupportedResponse(tt.err); got != tt.want {
+			t.Errorf("isUnsupportedResponse(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package oci
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// Pool capacities. Long-running clients that touch many repositories,
+// e.g. catalog walks or tenant namespaces, evict the least recently used
+// instances beyond them; an evicted repository only loses its detected
+// referrers API state.
+const (
+	maxPooledRepositories = 1024
+	maxPooledRegistries   = 128
+)
+
+// lruPool holds up to capacity values by key, evicting the least
+// recently used. The zero value is an empty pool.
+type lruPool[V any] struct {
+	mu    sync.Mutex
+	order list.List // of *lruEntry[V], most recently used first
+	items map[string]*list.Element
+}
+
+type lruEntry[V any] struct {
+	key   string
+	value V
+}
+
+// getOrCreate returns the pooled value of key, creating it with create on
+// first use. create runs without the pool locked; of concurrent creations
+// the first stored wins.
+func (p *lruPool[V]) getOrCreate(key string, capacity int, create func() (V, error)) (V, error) {
+	if v, ok := p.get(key); ok {
+		return v, nil
+	}
+	v, err := create()
+	if err != nil {
+		return v, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e, ok := p.items[key]; ok {
+		p.order.MoveToFront(e)
+		return e.Value.(*lruEntry[V]).value, nil
+	}
+	if p.items == nil {
+		p.items = make(map[string]*list.Element)
+	}
+	p.items[key] = p.order.PushFront(&lruEntry[V]{key: key, value: v})
+	for p.order.Len() > capacity {
+		oldest := p.order.Back()
+		p.order.Remove(oldest)
+		delete(p.items, oldest.Value.(*lruEntry[V]).key)
+	}
+	return v, nil
+}
+
+func (p *lruPool[V]) get(key string) (V, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	p.order.MoveToFront(e)
+	return e.Value.(*lruEntry[V]).value, true
+}
+
+func (p *lruPool[V]) len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.order.Len()
+}
+
+// repository returns the client's shared remote.Repository for name (e.g.
+// "registry.example.com/giantswarm/klaus-plugins/gs-base"), creating it on
+// first use. Sharing one instance per repository keeps state such as the
+// detected referrers API support across operations; connections and auth
+// tokens are shared through the client's auth client. The pool is bounded
+// by maxPooledRepositories. Pooled repositories must not be modified by
+// callers.
+func (c *Client) repository(name string) (*remote.Repository, error) {
+	return c.repositories.getOrCreate(name, maxPooledRepositories, func() (*remote.Repository, error) {
+		repo, err := remote.NewRepository(name)
+		if err != nil {
+			return nil, err
+		}
+		repo.PlainHTTP = c.plainHTTP
+		repo.Client = c.authClient
+		return repo, nil
+	})
+}
+
+// registry returns the client's shared remote.Registry for host, creating
+// it on first use. Pooled registries must not be modified by callers.
+func (c *Client) registry(host string) (*remote.Registry, error) {
+	return c.registries.getOrCreate(host, maxPooledRegistries, func() (*remote.Registry, error) {
+		reg, err := remote.NewRegistry(host)
+		if err != nil {
+			return nil, fmt.Errorf("creating registry client for %s: %w", host, err)
+		}
+		reg.PlainHTTP = c.plainHTTP
+		reg.Client = c.authClient
+		return reg, nil
+	})
+}
+
+// newRepository returns the pooled remote.Repository for a full OCI
+// reference string (e.g. "registry.example.com/repo:tag") and the
+// tag/digest portion.
+func (c *Client) newRepository(ref string) (*remote.Repository, string, error) {
+	parsed, err := registry.ParseReference(ref)
+	if err != nil {
+		return nil, "", fmt.Errorf("parsing reference %q: %w", ref, err)
+	}
+	repo, err := c.repository(parsed.Registry + "/" + parsed.Repository)
+	if err != nil {
+		return nil, "", fmt.Errorf("parsing reference %q: %w", ref, err)
+	}
+	return repo, parsed.Reference, nil
+}
+
+// newRepositoryFromName returns the pooled remote.Repository for a
+// repository name (without tag or digest), used for listing tags.
+func (c *Client) newRepositoryFromName(name string) (*remote.Repository, error) {
+	repo, err := c.repository(name)
+	if err != nil {
+		return nil, fmt.Errorf("creating repository for %q: %w", name, err)
+	}
+	return repo, nil
+}
@@ -0,0 +1,101 @@
+package oci
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestNewRepository_Pooled(t *testing.T) {
+	client := NewClient(WithPlainHTTP(true))
+
+	a, tag, err := client.newRepository("registry.example.com/klaus-plugins/gs-base:v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag != "v1.0.0" {
+		t.Errorf("tag = %q, want v1.0.0", tag)
+	}
+	b, digest, err := client.newRepository("registry.example.com/klaus-plugins/gs-base@sha256:" + sum256Hex(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != "sha256:"+sum256Hex(nil) {
+		t.Errorf("digest = %q", digest)
+	}
+	c, err := client.newRepositoryFromName("registry.example.com/klaus-plugins/gs-base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b || a != c {
+		t.Error("references to one repository returned different repository clients")
+	}
+	if !a.PlainHTTP || a.Client != client.authClient {
+		t.Error("pooled repository does not use the client's settings")
+	}
+
+	other, _, err := client.newRepository("registry.example.com/klaus-plugins/gs-other:v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == a {
+		t.Error("different repositories share a repository client")
+	}
+	if other2, _, _ := NewClient().newRepository("registry.example.com/klaus-plugins/gs-other:v1.0.0"); other2 == other {
+		t.Error("clients share a repository pool")
+	}
+
+	if _, _, err := client.newRepository("not a reference"); err == nil {
+		t.Error("newRepository() with an invalid reference succeeded")
+	}
+}
+
+func TestRegistry_PooledConcurrently(t *testing.T) {
+	client := NewClient()
+	regs := make([]any, 8)
+	var wg sync.WaitGroup
+	for i := range regs {
+		wg.Go(func() {
+			reg, err := client.registry("registry.example.com")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			regs[i] = reg
+		})
+	}
+	wg.Wait()
+	for _, reg := range regs[1:] {
+		if reg != regs[0] {
+			t.Fatal("concurrent callers got different registry clients")
+		}
+	}
+}
+
+func TestNewRepository_PoolBounded(t *testing.T) {
+	client := NewClient()
+	first, err := client.repository("registry.example.com/klaus-plugins/repo-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= maxPooledRepositories; i++ {
+		if _, err := client.repository(fmt.Sprintf("registry.example.com/klaus-plugins/repo-%d", i)); err != nil {
+			t.Fatal(err)
+		}
+		if i == maxPooledRepositories/2 {
+			// Using repo-0 again keeps it from being the oldest entry.
+			if again, _ := client.repository("registry.example.com/klaus-plugins/repo-0"); again != first {
+				t.Fatal("repository evicted before the pool was full")
+			}
+		}
+	}
+	if n := client.repositories.len(); n != maxPooledRepositories {
+		t.Errorf("pooled repositories = %d, want %d", n, maxPooledRepositories)
+	}
+	if again, _ := client.repository("registry.example.com/klaus-plugins/repo-0"); again != first {
+		t.Error("recently used repository was evicted")
+	}
+	if _, ok := client.repositories.get("registry.example.com/klaus-plugins/repo-1"); ok {
+		t.Error("least recently used repository was not evicted")
+	}
+}
@@ -0,0 +1,375 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Media types of provenance attestations. Attestations are attached as OCI
+// referrers whose artifact type, and the media type of their single layer,
+// is either a bare in-toto statement or a DSSE envelope wrapping one.
+const (
+	MediaTypeInTotoStatement = "application/vnd.in-toto+json"
+	MediaTypeDSSEEnvelope    = "application/vnd.dsse.envelope.v1+json"
+)
+
+// SLSA provenance predicate types recognized by provenance verification.
+const (
+	PredicateSLSAProvenanceV02 = "https://slsa.dev/provenance/v0.2"
+	PredicateSLSAProvenanceV1  = "https://slsa.dev/provenance/v1"
+)
+
+// maxProvenanceSize bounds provenance documents, which describe one build
+// rather than carrying build logs.
+const maxProvenanceSize = 4 << 20
+
+// ProvenancePolicy decides which SLSA provenance attestations verify an
+// artifact.
+type ProvenancePolicy struct {
+	// TrustedBuilders lists the builder IDs trusted to build artifacts.
+	// An entry ending in "*" matches builder IDs by prefix, e.g.
+	// "https://github.com/slsa-framework/slsa-github-generator/*".
+	TrustedBuilders []string
+	// PublicKeys, when set, requires attestations to be DSSE envelopes
+	// signed by one of the keys. *ecdsa.PublicKey and *rsa.PublicKey
+	// signatures are verified over the SHA-256 digest, ed25519.PublicKey
+	// signatures over the message. Without keys, unsigned statements are
+	// accepted, which only proves what the pusher of the attestation
+	// claims.
+	PublicKeys []crypto.PublicKey
+}
+
+// ProvenanceStatus is the outcome of verifying the provenance of an
+// artifact version.
+type ProvenanceStatus struct {
+	// Verified is true if an attestation satisfied the policy.
+	Verified bool
+	// BuilderID, BuildType, and PredicateType are read from the
+	// verifying attestation, or from the first one if none verified.
+	BuilderID     string
+	BuildType     string
+	PredicateType string
+	// Signed is true if the attestation's DSSE signature was verified
+	// against one of the policy's public keys.
+	Signed bool
+	// Digest is the manifest digest of the referrer holding the
+	// attestation.
+	Digest string
+	// Reasons lists why attestations failed to verify, prefixed with
+	// their referrer digest. It is empty when Verified is true.
+	Reasons []string
+}
+
+// WithProvenanceVerification makes describe operations verify the SLSA
+// provenance attached to artifacts against policy and report the outcome
+// in ArtifactInfo.Provenance. This costs one referrers request plus a
+// manifest and a blob GET per attestation.
+func WithProvenanceVerification(policy ProvenancePolicy) ClientOption {
+	return func(c *Client) { c.provenance = &policy }
+}
+
+// AttachProvenance attaches a provenance attestation to the artifact at
+// ref as an OCI referrer, and returns the referrer's manifest digest. doc
+// is an in-toto statement or a DSSE envelope wrapping one, as produced by
+// SLSA builders. ref must be a fully-qualified reference; references
+// without a tag resolve to the latest version first.
+func (c *Client) AttachProvenance(ctx context.Context, ref string, doc []byte) (string, error) {
+	if len(doc) > maxProvenanceSize {
+		return "", fmt.Errorf("provenance is %d bytes, exceeding the limit of %d", len(doc), maxProvenanceSize)
+	}
+	mediaType := MediaTypeInTotoStatement
+	if env, err := parseDSSEEnvelope(doc); err == nil {
+		mediaType = MediaTypeDSSEEnvelope
+		if _, err := env.statement(); err != nil {
+			return "", err
+		}
+	} else if _, err := parseInTotoStatement(doc); err != nil {
+		return "", err
+	}
+
+	fm, resolved, err := c.fetchReferrerSubject(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	subject, err := fm.repo.Resolve(ctx, fm.digest)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", resolved, err)
+	}
+	desc, err := pushReferrer(ctx, fm.repo, subject, mediaType, doc, nil)
+	if err != nil {
+		return "", fmt.Errorf("attaching provenance to %s: %w", resolved, err)
+	}
+	return desc.Digest.String(), nil
+}
+
+// VerifyProvenance verifies the provenance attestations attached to the
+// artifact at ref against policy. ref supports the same forms as
+// AttachProvenance. An artifact that fails verification is reported in
+// the status, not as an error.
+func (c *Client) VerifyProvenance(ctx context.Context, ref string, policy ProvenancePolicy) (*ProvenanceStatus, error) {
+	fm, resolved, err := c.fetchReferrerSubject(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return c.verifyProvenance(ctx, fm, resolved, policy)
+}
+
+// verifyProvenance fetches the attestations referring to the manifest of
+// fm and verifies them against policy.
+func (c *Client) verifyProvenance(ctx context.Context, fm *fetchedManifest, ref string, policy ProvenancePolicy) (*ProvenanceStatus, error) {
+	referrers, err := fm.referrers(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("listing provenance of %s: %w", ref, err)
+	}
+	return c.verifyProvenanceReferrers(ctx, fm, ref, referrers, policy)
+}
+
+// verifyProvenanceReferrers verifies the attestations among the referrers
+// of fm against policy. The first verifying attestation determines the
+// status.
+func (c *Client) verifyProvenanceReferrers(ctx context.Context, fm *fetchedManifest, ref string, referrers []ocispec.Descriptor, policy ProvenancePolicy) (*ProvenanceStatus, error) {
+	var status *ProvenanceStatus
+	var reasons []string
+	for _, desc := range referrers {
+		if desc.ArtifactType != MediaTypeInTotoStatement && desc.ArtifactType != MediaTypeDSSEEnvelope {
+			continue
+		}
+		data, err := fm.fetchReferrerDocument(ctx, desc, desc.ArtifactType, maxProvenanceSize)
+		if err != nil {
+			return nil, fmt.Errorf("fetching provenance %s of %s: %w", desc.Digest, ref, err)
+		}
+		candidate := policy.verify(desc, data, fm.digest)
+		if candidate.Verified {
+			return candidate, nil
+		}
+		if status == nil {
+			status = candidate
+		}
+		for _, reason := range candidate.Reasons {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", desc.Digest, reason))
+		}
+	}
+	if status == nil {
+		return &ProvenanceStatus{Reasons: []string{"no provenance attestation attached"}}, nil
+	}
+	status.Reasons = reasons
+	return status, nil
+}
+
+// verify verifies one attestation held in the referrer desc against the
+// policy for the subject manifest digest.
+func (p ProvenancePolicy) verify(desc ocispec.Descriptor, data []byte, subjectDigest string) *ProvenanceStatus {
+	status := &ProvenanceStatus{Digest: desc.Digest.String()}
+	fail := func(format string, args ...any) *ProvenanceStatus {
+		status.Reasons = append(status.Reasons, fmt.Sprintf(format, args...))
+		return status
+	}
+
+	var statement *inTotoStatement
+	var env *dsseEnvelope
+	var err error
+	if desc.ArtifactType == MediaTypeDSSEEnvelope {
+		if env, err = parseDSSEEnvelope(data); err == nil {
+			statement, err = env.statement()
+		}
+	} else {
+		statement, err = parseInTotoStatement(data)
+	}
+	if err != nil {
+		return fail("%v", err)
+	}
+	status.PredicateType = statement.PredicateType
+	status.BuilderID, status.BuildType = statement.builder()
+
+	if len(p.PublicKeys) > 0 {
+		if env == nil {
+			return fail("attestation is not signed")
+		}
+		if !env.verify(p.PublicKeys) {
+			return fail("no valid signature by a trusted key")
+		}
+		status.Signed = true
+	}
+	if status.PredicateType != PredicateSLSAProvenanceV02 && status.PredicateType != PredicateSLSAProvenanceV1 {
+		return fail("predicate type %q is not SLSA provenance", status.PredicateType)
+	}
+	if !statement.covers(subjectDigest) {
+		return fail("attestation subject does not include %s", subjectDigest)
+	}
+	if !p.trustsBuilder(status.BuilderID) {
+		if status.BuilderID == "" {
+			return fail("attestation names no builder")
+		}
+		return fail("builder %q is not trusted", status.BuilderID)
+	}
+	status.Verified = true
+	return status
+}
+
+// trustsBuilder reports whether id matches one of the trusted builders.
+func (p ProvenancePolicy) trustsBuilder(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, trusted := range p.TrustedBuilders {
+		if prefix, ok := strings.CutSuffix(trusted, "*"); ok {
+			if strings.HasPrefix(id, prefix) {
+				return true
+			}
+		} else if id == trusted {
+			return true
+		}
+	}
+	return false
+}
+
+// inTotoStatement is the part of an in-toto statement read by provenance
+// verification, covering SLSA provenance v0.2 and v1 predicates.
+type inTotoStatement struct {
+	Type          string `json:"_type"`
+	PredicateType string `json:"predicateType"`
+	Subject       []struct {
+		Name   string            `json:"name"`
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+	Predicate struct {
+		// SLSA v0.2.
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+		BuildType string `json:"buildType"`
+		// SLSA v1.
+		BuildDefinition struct {
+			BuildType string `json:"buildType"`
+		} `json:"buildDefinition"`
+		RunDetails struct {
+			Builder struct {
+				ID string `json:"id"`
+			} `json:"builder"`
+		} `json:"runDetails"`
+	} `json:"predicate"`
+}
+
+// parseInTotoStatement decodes an in-toto statement.
+func parseInTotoStatement(data []byte) (*inTotoStatement, error) {
+	var s inTotoStatement
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parsing in-toto statement: %w", err)
+	}
+	if !strings.HasPrefix(s.Type, "https://in-toto.io/Statement/") {
+		return nil, fmt.Errorf("unsupported in-toto statement type %q", s.Type)
+	}
+	return &s, nil
+}
+
+// builder returns the builder ID and build type of the statement's
+// predicate, whichever SLSA version it uses.
+func (s *inTotoStatement) builder() (id, buildType string) {
+	if s.PredicateType == PredicateSLSAProvenanceV1 {
+		return s.Predicate.RunDetails.Builder.ID, s.Predicate.BuildDefinition.BuildType
+	}
+	return s.Predicate.Builder.ID, s.Predicate.BuildType
+}
+
+// covers reports whether the statement's subjects include digest, e.g.
+// "sha256:abc...".
+func (s *inTotoStatement) covers(digest string) bool {
+	algorithm, hex, ok := strings.Cut(digest, ":")
+	if !ok {
+		return false
+	}
+	for _, subject := range s.Subject {
+		if subject.Digest[algorithm] == hex {
+			return true
+		}
+	}
+	return false
+}
+
+// dsseEnvelope is a DSSE envelope carrying a signed in-toto statement.
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+	Signatures  []struct {
+		KeyID string `json:"keyid"`
+		Sig   string `json:"sig"`
+	} `json:"signatures"`
+}
+
+// parseDSSEEnvelope decodes a DSSE envelope.
+func parseDSSEEnvelope(data []byte) (*dsseEnvelope, error) {
+	var env dsseEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("parsing DSSE envelope: %w", err)
+	}
+	if env.PayloadType == "" {
+		return nil, fmt.Errorf("DSSE envelope has no payload type")
+	}
+	return &env, nil
+}
+
+// statement decodes the in-toto statement carried by the envelope.
+func (e *dsseEnvelope) statement() (*inTotoStatement, error) {
+	if e.PayloadType != MediaTypeInTotoStatement {
+		return nil, fmt.Errorf("DSSE payload type %q is not an in-toto statement", e.PayloadType)
+	}
+	payload, err := base64.StdEncoding.DecodeString(e.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("decoding DSSE payload: %w", err)
+	}
+	return parseInTotoStatement(payload)
+}
+
+// verify reports whether one of the envelope's signatures is valid for one
+// of keys.
+func (e *dsseEnvelope) verify(keys []crypto.PublicKey) bool {
+	payload, err := base64.StdEncoding.DecodeString(e.Payload)
+	if err != nil {
+		return false
+	}
+	message := dssePAE(e.PayloadType, payload)
+	hash := sha256.Sum256(message)
+	for _, s := range e.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err != nil {
+			continue
+		}
+		for _, key := range keys {
+			switch key := key.(type) {
+			case *ecdsa.PublicKey:
+				if ecdsa.VerifyASN1(key, hash[:], sig) {
+					return true
+				}
+			case *rsa.PublicKey:
+				if rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig) == nil {
+					return true
+				}
+			case ed25519.PublicKey:
+				if ed25519.Verify(key, message, sig) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// dssePAE returns the DSSE pre-authentication encoding of a payload, the
+// message that envelope signatures sign.
+func dssePAE(payloadType string, payload []byte) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "DSSEv1 %d %s %d ", len(payloadType), payloadType, len(payload))
+	b.Write(payload)
+	return b.Bytes()
+}
@@ -0,0 +1,196 @@
+package oci
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	godigest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const testBuilder = "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_generic_slsa3.yml@refs/tags/v2.0.0"
+
+// slsaStatement returns an SLSA v1 provenance statement for digest built
+// by builder.
+func slsaStatement(t *testing.T, digest, builder string) []byte {
+	t.Helper()
+	algorithm, hex, _ := strings.Cut(digest, ":")
+	statement := map[string]any{
+		"_type":         "https://in-toto.io/Statement/v1",
+		"subject":       []any{map[string]any{"name": "sre", "digest": map[string]string{algorithm: hex}}},
+		"predicateType": PredicateSLSAProvenanceV1,
+		"predicate": map[string]any{
+			"buildDefinition": map[string]any{"buildType": "https://slsa-framework.github.io/github-actions-buildtypes/workflow/v1"},
+			"runDetails":      map[string]any{"builder": map[string]any{"id": builder}},
+		},
+	}
+	data, err := json.Marshal(statement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// signEnvelope wraps statement in a DSSE envelope signed with key.
+func signEnvelope(t *testing.T, statement []byte, key ed25519.PrivateKey) []byte {
+	t.Helper()
+	sig := ed25519.Sign(key, dssePAE(MediaTypeInTotoStatement, statement))
+	data, err := json.Marshal(map[string]any{
+		"payloadType": MediaTypeInTotoStatement,
+		"payload":     base64.StdEncoding.EncodeToString(statement),
+		"signatures":  []any{map[string]string{"sig": base64.StdEncoding.EncodeToString(sig)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestVerifyProvenance(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	repository := host + "/klaus/sre"
+	digests := pushVersions(t, client, repository, "v1.0.0", "v1.1.0")
+	ref := repository + ":v1.0.0"
+	policy := ProvenancePolicy{TrustedBuilders: []string{"https://github.com/slsa-framework/slsa-github-generator/*"}}
+
+	status, err := client.VerifyProvenance(t.Context(), ref, policy)
+	if err != nil {
+		t.Fatalf("VerifyProvenance() error = %v", err)
+	}
+	if status.Verified || len(status.Reasons) != 1 {
+		t.Errorf("status = %+v, want unverified without attestations", status)
+	}
+
+	if _, err := client.AttachProvenance(t.Context(), ref, slsaStatement(t, digests["v1.0.0"], "https://ci.example.com/builder")); err != nil {
+		t.Fatalf("AttachProvenance() error = %v", err)
+	}
+	status, err = client.VerifyProvenance(t.Context(), ref, policy)
+	if err != nil {
+		t.Fatalf("VerifyProvenance() error = %v", err)
+	}
+	if status.Verified || status.BuilderID != "https://ci.example.com/builder" || len(status.Reasons) != 1 || !strings.Contains(status.Reasons[0], "not trusted") {
+		t.Errorf("status = %+v, want an untrusted builder", status)
+	}
+
+	if _, err := client.AttachProvenance(t.Context(), ref, slsaStatement(t, digests["v1.0.0"], testBuilder)); err != nil {
+		t.Fatalf("AttachProvenance() error = %v", err)
+	}
+	status, err = client.VerifyProvenance(t.Context(), ref, policy)
+	if err != nil {
+		t.Fatalf("VerifyProvenance() error = %v", err)
+	}
+	if !status.Verified || status.BuilderID != testBuilder || status.PredicateType != PredicateSLSAProvenanceV1 || status.Signed || status.Digest == "" || len(status.Reasons) != 0 {
+		t.Errorf("status = %+v, want verified by the trusted builder", status)
+	}
+
+	// Describe reports the status for clients verifying provenance.
+	verifying := NewClient(WithPlainHTTP(true), WithProvenanceVerification(policy))
+	described, err := verifying.DescribePersonality(t.Context(), ref)
+	if err != nil {
+		t.Fatalf("DescribePersonality() error = %v", err)
+	}
+	if described.Provenance == nil || !described.Provenance.Verified {
+		t.Errorf("Provenance = %+v, want verified", described.Provenance)
+	}
+	other, err := verifying.Describe(t.Context(), repository+":v1.1.0")
+	if err != nil {
+		t.Fatalf("Describe() error = %v", err)
+	}
+	if other.Personality.Provenance == nil || other.Personality.Provenance.Verified {
+		t.Errorf("v1.1.0 Provenance = %+v, want unverified", other.Personality.Provenance)
+	}
+	plain, err := client.DescribePersonality(t.Context(), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.Provenance != nil {
+		t.Errorf("Provenance = %+v without WithProvenanceVerification, want nil", plain.Provenance)
+	}
+}
+
+func TestVerifyProvenance_Signed(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	repository := host + "/klaus/sre"
+	digests := pushVersions(t, client, repository, "v1.0.0")
+	ref := repository + ":v1.0.0"
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := ProvenancePolicy{TrustedBuilders: []string{testBuilder}, PublicKeys: []crypto.PublicKey{pub}}
+	statement := slsaStatement(t, digests["v1.0.0"], testBuilder)
+
+	// Unsigned statements and foreign signatures are rejected.
+	for _, doc := range [][]byte{statement, signEnvelope(t, statement, otherKey)} {
+		if _, err := client.AttachProvenance(t.Context(), ref, doc); err != nil {
+			t.Fatalf("AttachProvenance() error = %v", err)
+		}
+	}
+	status, err := client.VerifyProvenance(t.Context(), ref, policy)
+	if err != nil {
+		t.Fatalf("VerifyProvenance() error = %v", err)
+	}
+	if status.Verified || len(status.Reasons) != 2 {
+		t.Errorf("status = %+v, want two rejected attestations", status)
+	}
+
+	if _, err := client.AttachProvenance(t.Context(), ref, signEnvelope(t, statement, priv)); err != nil {
+		t.Fatalf("AttachProvenance() error = %v", err)
+	}
+	status, err = client.VerifyProvenance(t.Context(), ref, policy)
+	if err != nil {
+		t.Fatalf("VerifyProvenance() error = %v", err)
+	}
+	if !status.Verified || !status.Signed {
+		t.Errorf("status = %+v, want a verified signature", status)
+	}
+}
+
+func TestProvenancePolicy_Verify(t *testing.T) {
+	digest := godigest.FromString("manifest").String()
+	policy := ProvenancePolicy{TrustedBuilders: []string{testBuilder}}
+	desc := ocispec.Descriptor{ArtifactType: MediaTypeInTotoStatement, Digest: godigest.FromString("referrer")}
+
+	v02 := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"https://slsa.dev/provenance/v0.2",` +
+		`"subject":[{"name":"sre","digest":{"sha256":"` + strings.TrimPrefix(digest, "sha256:") + `"}}],` +
+		`"predicate":{"builder":{"id":"` + testBuilder + `"},"buildType":"https://example.com/build"}}`)
+
+	tests := []struct {
+		name   string
+		doc    []byte
+		want   bool
+		reason string
+	}{
+		{name: "SLSA v0.2", doc: v02, want: true},
+		{name: "SLSA v1", doc: slsaStatement(t, digest, testBuilder), want: true},
+		{name: "other subject", doc: slsaStatement(t, godigest.FromString("other").String(), testBuilder), reason: "subject does not include"},
+		{name: "no builder", doc: slsaStatement(t, digest, ""), reason: "names no builder"},
+		{name: "not SLSA", doc: []byte(`{"_type":"https://in-toto.io/Statement/v1","predicateType":"https://spdx.dev/Document"}`), reason: "is not SLSA provenance"},
+		{name: "not in-toto", doc: []byte(`{"_type":"other"}`), reason: "unsupported in-toto statement type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := policy.verify(desc, tt.doc, digest)
+			if status.Verified != tt.want {
+				t.Fatalf("Verified = %v, want %v (reasons %v)", status.Verified, tt.want, status.Reasons)
+			}
+			if tt.reason != "" && (len(status.Reasons) != 1 || !strings.Contains(status.Reasons[0], tt.reason)) {
+				t.Errorf("Reasons = %v, want %q", status.Reasons, tt.reason)
+			}
+		})
+	}
+}
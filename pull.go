@@ -9,16 +9,56 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry/remote"
 )
 
 // pull downloads a Klaus artifact from an OCI registry and extracts it to destDir.
 // The kind parameter determines which content media type to look for in the manifest.
 // If the artifact is already cached with a matching digest, the pull is skipped
-// and pullResult.Cached is set to true.
-func (c *Client) pull(ctx context.Context, ref string, destDir string, kind artifactKind) (*pullResult, error) {
-	repo, tag, err := c.newRepository(ref)
+// and pullResult.Cached is set to true. Digest-pinned references fail over
+// to the mirrors configured with WithMirrors. Quarantined artifacts are
+// refused unless cfg allows them.
+func (c *Client) pull(ctx context.Context, ref string, destDir string, kind artifactKind, cfg *pullConfig) (result *pullResult, err error) {
+	defer func() {
+		rec := AuditRecord{Operation: AuditPull, Ref: ref}
+		if result != nil {
+			rec.Digest, rec.Cached = result.Digest, result.Cached
+		}
+		c.recordAudit(ctx, rec, err)
+	}()
+
+	result, err = c.pullFrom(ctx, ref, ref, destDir, kind, cfg)
+	if err == nil || !hasDigest(ref) || !isMirrorFailover(err) {
+		return result, err
+	}
+	errs := []error{err}
+	for _, source := range c.mirrorRefs(ref) {
+		result, merr := c.pullFrom(ctx, ref, source, destDir, kind, cfg)
+		if merr == nil {
+			return result, nil
+		}
+		errs = append(errs, fmt.Errorf("mirror %s: %w", source, merr))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// pullFrom pulls ref from source, which is ref itself or the same digest
+// in a mirror. The manifest, config, and content layer are verified
+// against their digests, so a mirror cannot substitute other content.
+// The quarantine is checked in the repository of ref, since mirrors may
+// lack the quarantine markers. When that registry is down, which is when
+// pulls fail over, it is checked in the mirror instead; a pull from a
+// mirror fails when it cannot be checked there either, unless cfg allows
+// quarantined artifacts.
+func (c *Client) pullFrom(ctx context.Context, ref, source string, destDir string, kind artifactKind, cfg *pullConfig) (*pullResult, error) {
+	repo, tag, err := c.newRepository(source)
 	if err != nil {
 		return nil, err
 	}
@@ -27,51 +67,86 @@ func (c *Client) pull(ctx context.Context, ref string, destDir string, kind arti
 		return nil, fmt.Errorf("reference %q must include a tag or digest", ref)
 	}
 
-	manifestDesc, err := c.resolveDescriptor(ctx, repo, ref, tag)
-	if err != nil {
-		return nil, fmt.Errorf("resolving %s: %w", ref, err)
+	var manifestDesc ocispec.Descriptor
+	if m := cfg.manifest; m != nil && m.ref == source {
+		manifestDesc = m.desc
+	} else if manifestDesc, err = c.resolveDescriptor(ctx, repo, source, tag); err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", source, err)
+	}
+	if hasDigest(ref) && manifestDesc.Digest.String() != digestFromRef(ref) {
+		return nil, fmt.Errorf("%s resolved to %s, want %s", source, manifestDesc.Digest, digestFromRef(ref))
 	}
 
 	digest := manifestDesc.Digest.String()
 
+	quarantineRepo := repo
+	if source != ref {
+		if quarantineRepo, _, err = c.newRepository(ref); err != nil {
+			return nil, err
+		}
+	}
+	quarantine, err := quarantineOf(ctx, quarantineRepo, manifestDesc)
+	if err != nil && source != ref && isRegistryDown(err) {
+		quarantine, err = quarantineOf(ctx, repo, manifestDesc)
+	}
+	if err != nil && (source == ref || !cfg.allowQuarantined) {
+		return nil, fmt.Errorf("checking quarantine of %s: %w", ref, err)
+	}
+	if quarantine != nil && !cfg.allowQuarantined {
+		return nil, &ErrQuarantined{Ref: ref, Quarantine: *quarantine}
+	}
+
 	if IsCached(destDir, digest) {
 		entry, _ := ReadCacheEntry(destDir)
 		var configJSON []byte
 		var annotations map[string]string
 		if entry != nil {
+			if entry.ConfigMediaType != "" || entry.ArtifactType != "" {
+				if err := checkArtifactKind(ref, kind, ocispec.MediaTypeImageManifest, entry.ArtifactType, entry.ConfigMediaType, entry.Annotations); err != nil {
+					return nil, err
+				}
+			}
 			configJSON = entry.ConfigJSON
 			annotations = entry.Annotations
 		}
 
-		return &pullResult{Digest: digest, Ref: ref, Cached: true, ConfigJSON: configJSON, Annotations: annotations}, nil
+		return &pullResult{Digest: digest, Ref: ref, Cached: true, ConfigJSON: configJSON, Annotations: annotations, Quarantine: quarantine}, nil
 	}
 
-	repoName := RepositoryFromRef(ref)
-
-	manifestRC, err := c.fetchWithStore(ctx, repo, repoName, manifestDesc)
-	if err != nil {
-		return nil, fmt.Errorf("fetching manifest for %s: %w", ref, err)
-	}
-	defer manifestRC.Close()
+	repoName := RepositoryFromRef(source)
 
 	var manifest ocispec.Manifest
-	if err := json.NewDecoder(manifestRC).Decode(&manifest); err != nil {
-		return nil, fmt.Errorf("parsing manifest for %s: %w", ref, err)
+	if m := cfg.manifest; m != nil && m.desc.Digest == manifestDesc.Digest {
+		manifest = m.manifest
+	} else if manifest, err = c.fetchPullManifest(ctx, repo, ref, source, manifestDesc); err != nil {
+		return nil, err
+	}
+	manifestMediaType := manifest.MediaType
+	if manifestMediaType == "" {
+		manifestMediaType = manifestDesc.MediaType
+	}
+	if err := checkArtifactKind(ref, kind, manifestMediaType, manifest.ArtifactType, manifest.Config.MediaType, manifest.Annotations); err != nil {
+		return nil, err
 	}
 
-	configRC, err := c.fetchWithStore(ctx, repo, repoName, manifest.Config)
+	releaseConfig, err := c.reserveMemory(ctx, manifest.Config.Size, "config of "+ref)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseConfig()
+	configRC, err := c.fetchBlob(ctx, repo, repoName, manifest.Config)
 	if err != nil {
 		return nil, fmt.Errorf("fetching config for %s: %w", ref, err)
 	}
 	defer configRC.Close()
-	configJSON, err := io.ReadAll(configRC)
+	configJSON, err := readBlob(configRC, manifest.Config, maxConfigBytes)
 	if err != nil {
 		return nil, fmt.Errorf("reading config for %s: %w", ref, err)
 	}
 
 	var contentLayer *ocispec.Descriptor
 	for i := range manifest.Layers {
-		if manifest.Layers[i].MediaType == kind.ContentMediaType {
+		if mt := manifest.Layers[i].MediaType; mt == kind.ContentMediaType || mt == kind.ContentMediaType+MediaTypeEncryptedSuffix {
 			contentLayer = &manifest.Layers[i]
 			break
 		}
@@ -80,95 +155,259 @@ func (c *Client) pull(ctx context.Context, ref string, destDir string, kind arti
 		return nil, fmt.Errorf("no content layer found in %s (expected media type %s)", ref, kind.ContentMediaType)
 	}
 
-	layerRC, err := c.fetchWithStore(ctx, repo, repoName, *contentLayer)
+	layerRC, err := c.fetchContentLayer(ctx, repo, repoName, *contentLayer, destDir)
 	if err != nil {
 		return nil, fmt.Errorf("fetching content layer for %s: %w", ref, err)
 	}
 	defer layerRC.Close()
 
-	if err := cleanAndCreate(destDir); err != nil {
+	var layer io.Reader = layerRC
+	var verifier *content.VerifyReader
+	encrypted := isEncryptedMediaType(contentLayer.MediaType)
+	if encrypted {
+		if layer, err = c.decryptLayer(ctx, ref, layerRC, *contentLayer); err != nil {
+			return nil, fmt.Errorf("decrypting content layer for %s: %w", ref, err)
+		}
+	} else if contentLayer.Size > 0 {
+		verifier = content.NewVerifyReader(layerRC, *contentLayer)
+		layer = verifier
+	}
+
+	// Extract into a staging directory that replaces destDir only once the
+	// content is verified, so a failed or cancelled pull leaves the
+	// previous version in place.
+	staged, err := stagingDir(destDir)
+	if err != nil {
 		return nil, err
 	}
+	defer os.RemoveAll(staged)
 
-	if err := extractTarGz(layerRC, destDir); err != nil {
+	layer = contextReader{ctx: ctx, r: layer}
+	if err := extractTarGz(layer, staged, c.extraction); err != nil {
 		return nil, fmt.Errorf("extracting content for %s: %w", ref, err)
 	}
+	if encrypted {
+		// The archive may end before the layer does; read the rest so the
+		// HMAC and digest of a decrypted layer are verified.
+		if _, err := io.Copy(io.Discard, layer); err != nil {
+			return nil, fmt.Errorf("verifying content for %s: %w", ref, err)
+		}
+	}
+	if verifier != nil {
+		if _, err := io.Copy(io.Discard, layer); err != nil {
+			return nil, fmt.Errorf("verifying content for %s: %w", ref, err)
+		}
+		if err := verifier.Verify(); err != nil {
+			return nil, fmt.Errorf("verifying content for %s: %w", ref, err)
+		}
+	}
 
 	cacheEntry := CacheEntry{
-		Digest:      digest,
-		Ref:         ref,
-		ConfigJSON:  configJSON,
-		Annotations: manifest.Annotations,
+		Digest:          digest,
+		Ref:             ref,
+		ConfigJSON:      configJSON,
+		ConfigMediaType: manifest.Config.MediaType,
+		ArtifactType:    manifest.ArtifactType,
+		Annotations:     manifest.Annotations,
+		Provenance: &Provenance{
+			Registry:      repo.Reference.Registry,
+			Repository:    repo.Reference.Repository,
+			Identity:      c.credentialIdentity(ctx, repo.Reference.Registry),
+			ClientVersion: Version(),
+			Signature:     SignatureNotVerified,
+		},
 	}
-	if err := WriteCacheEntry(destDir, cacheEntry); err != nil {
+	if err := WriteCacheEntry(staged, cacheEntry); err != nil {
 		return nil, fmt.Errorf("writing cache entry: %w", err)
 	}
+	if err := replaceDir(staged, destDir); err != nil {
+		return nil, err
+	}
 
-	return &pullResult{Digest: digest, Ref: ref, ConfigJSON: configJSON, Annotations: manifest.Annotations}, nil
+	return &pullResult{Digest: digest, Ref: ref, ConfigJSON: configJSON, Annotations: manifest.Annotations, Quarantine: quarantine}, nil
+}
+
+// prefetchedManifest is the manifest desc of ref, fetched ahead of a pull.
+type prefetchedManifest struct {
+	ref      string
+	desc     ocispec.Descriptor
+	manifest ocispec.Manifest
+}
+
+// fetchPullManifest fetches and parses the manifest desc of ref from
+// source. The memory it reserves is released before returning, so pulls
+// never wait for the budget of the config while holding part of it.
+func (c *Client) fetchPullManifest(ctx context.Context, repo *remote.Repository, ref, source string, desc ocispec.Descriptor) (ocispec.Manifest, error) {
+	release, err := c.reserveMemory(ctx, desc.Size, "manifest of "+ref)
+	if err != nil {
+		return ocispec.Manifest{}, err
+	}
+	defer release()
+	rc, err := c.fetchWithStore(ctx, repo, RepositoryFromRef(source), desc)
+	if err != nil {
+		return ocispec.Manifest{}, fmt.Errorf("fetching manifest for %s: %w", ref, err)
+	}
+	defer rc.Close()
+
+	manifestJSON, err := readBlob(rc, desc, maxManifestBytes)
+	if err != nil {
+		return ocispec.Manifest{}, fmt.Errorf("reading manifest for %s: %w", ref, err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return ocispec.Manifest{}, fmt.Errorf("parsing manifest for %s: %w", ref, err)
+	}
+	return manifest, nil
 }
 
 // PullPersonality downloads a personality artifact from an OCI registry and
 // returns a PulledPersonality with metadata, composition, and soul content.
 // Both annotations (common metadata) and the config blob (composition data)
 // are persisted in the cache entry so that metadata is always populated,
-// even on cache hits.
-func (c *Client) PullPersonality(ctx context.Context, ref string, cacheDir string) (*PulledPersonality, error) {
-	result, err := c.pull(ctx, ref, cacheDir, personalityArtifact)
+// even on cache hits. Use WithSoulVariant to pick a soul variant and
+// WithSoulValues to render a templated soul.
+func (c *Client) PullPersonality(ctx context.Context, ref string, cacheDir string, opts ...PullOption) (*PulledPersonality, error) {
+	cfg := newPullConfig(opts)
+	pulled, err := pullTyped(ctx, c, PersonalityKind, ref, cacheDir, cfg)
+	if err != nil {
+		return nil, err
+	}
+	p, err := readPulledSoul(pulled)
 	if err != nil {
 		return nil, err
 	}
-	return parsePersonalityFromDir(cacheDir, ref, result)
+	if err := selectSoulVariant(p, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", ref, err)
+	}
+	if err := renderPulledSoul(p, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", ref, err)
+	}
+	return p, nil
 }
 
 // PullPlugin downloads a plugin artifact from an OCI registry and returns
 // a PulledPlugin with metadata and the extraction directory. Common metadata
 // is populated from manifest annotations; type-specific fields come from the
 // config blob.
-func (c *Client) PullPlugin(ctx context.Context, ref string, destDir string) (*PulledPlugin, error) {
-	result, err := c.pull(ctx, ref, destDir, pluginArtifact)
+func (c *Client) PullPlugin(ctx context.Context, ref string, destDir string, opts ...PullOption) (*PulledPlugin, error) {
+	p, err := Pull(ctx, c, PluginKind, ref, destDir, opts...)
 	if err != nil {
 		return nil, err
 	}
-	_, tag := SplitNameTag(ref)
+	pulled := &PulledPlugin{ArtifactInfo: p.ArtifactInfo, Plugin: p.Artifact, Dir: p.Dir, Cached: p.Cached}
+	pulled.Warnings = layoutWarnings(pulled)
+	return pulled, nil
+}
 
+// decodePlugin reads a Plugin from the manifest annotations and config
+// blob of a pulled plugin.
+func decodePlugin(annotations map[string]string, tag string, configJSON []byte) (Plugin, error) {
 	var blob pluginConfigBlob
-	if result.ConfigJSON != nil {
-		if err := json.Unmarshal(result.ConfigJSON, &blob); err != nil {
-			return nil, fmt.Errorf("parsing plugin config: %w", err)
+	if configJSON != nil {
+		if err := json.Unmarshal(configJSON, &blob); err != nil {
+			return Plugin{}, fmt.Errorf("parsing plugin config: %w", err)
 		}
 	}
-
-	return &PulledPlugin{
-		ArtifactInfo: ArtifactInfo{Ref: ref, Tag: tag, Digest: result.Digest},
-		Plugin:       pluginFromAnnotations(result.Annotations, tag, blob),
-		Dir:          destDir,
-		Cached:       result.Cached,
-	}, nil
+	return pluginFromAnnotations(annotations, tag, blob), nil
 }
 
-func parsePersonalityFromDir(dir, ref string, result *pullResult) (*PulledPersonality, error) {
-	_, tag := SplitNameTag(ref)
+// PullAny fetches the manifest for ref, detects the artifact kind from its
+// config media type, and pulls it into destDir as that kind, reusing the
+// fetched manifest so that a tag moved in between is not pulled as the
+// wrong kind. The result has Kind set and exactly one of Plugin,
+// Personality, or Custom populated, the latter for kinds added with
+// RegisterArtifactKind. Toolchains are container images consumed by the
+// container runtime and cannot be extracted, so PullAny returns an error
+// for them.
+func (c *Client) PullAny(ctx context.Context, ref string, destDir string, opts ...PullOption) (*PulledArtifact, error) {
+	repo, tag, err := c.newRepository(ref)
+	if err != nil {
+		return nil, err
+	}
+	if tag == "" {
+		return nil, fmt.Errorf("reference %q must include a tag or digest", ref)
+	}
+	desc, err := c.resolveDescriptor(ctx, repo, ref, tag)
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	manifest, err := c.fetchPullManifest(ctx, repo, ref, ref, desc)
+	if err != nil {
+		return nil, err
+	}
+	mediaType := manifest.MediaType
+	if mediaType == "" {
+		mediaType = desc.MediaType
+	}
+
+	kind, ok := kindOfManifest(mediaType, manifest.ArtifactType, manifest.Config.MediaType)
+	if !ok {
+		return nil, fmt.Errorf("unrecognized artifact type for %s (config media type %q)", ref, manifest.Config.MediaType)
+	}
+	opts = append(slices.Clip(opts), func(cfg *pullConfig) {
+		cfg.manifest = &prefetchedManifest{ref: ref, desc: desc, manifest: manifest}
+	})
+
+	result := &PulledArtifact{Kind: kind}
+	switch kind {
+	case KindPlugin:
+		result.Plugin, err = c.PullPlugin(ctx, ref, destDir, opts...)
+	case KindPersonality:
+		result.Personality, err = c.PullPersonality(ctx, ref, destDir, opts...)
+	case KindToolchain:
+		return nil, fmt.Errorf("%s is a %s; toolchain images cannot be pulled as Klaus artifacts", ref, kind)
+	default:
+		result.Custom, err = c.PullArtifact(ctx, kind, ref, destDir, opts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
 
+// decodePersonality reads a Personality from the manifest annotations and
+// config blob of a pulled personality.
+func decodePersonality(annotations map[string]string, tag string, configJSON []byte) (Personality, error) {
 	var blob personalityConfigBlob
-	if result.ConfigJSON != nil {
-		if err := json.Unmarshal(result.ConfigJSON, &blob); err != nil {
-			return nil, fmt.Errorf("parsing personality config: %w", err)
+	if configJSON != nil {
+		if err := json.Unmarshal(configJSON, &blob); err != nil {
+			return Personality{}, fmt.Errorf("parsing personality config: %w", err)
 		}
 	}
+	return personalityFromAnnotations(annotations, tag, blob), nil
+}
 
+// readPulledSoul reads the soul and soul variants of a pulled
+// personality.
+func readPulledSoul(pulled *Pulled[Personality]) (*PulledPersonality, error) {
 	p := &PulledPersonality{
-		ArtifactInfo: ArtifactInfo{Ref: ref, Tag: tag, Digest: result.Digest},
-		Personality:  personalityFromAnnotations(result.Annotations, tag, blob),
-		Dir:          dir,
-		Cached:       result.Cached,
+		ArtifactInfo: pulled.ArtifactInfo,
+		Personality:  pulled.Artifact,
+		Dir:          pulled.Dir,
+		Cached:       pulled.Cached,
 	}
 
-	soulData, err := os.ReadFile(filepath.Join(dir, "SOUL.md"))
+	soulData, err := os.ReadFile(filepath.Join(pulled.Dir, "SOUL.md"))
 	if err == nil {
 		p.Soul = string(soulData)
 	} else if !errors.Is(err, fs.ErrNotExist) {
 		return nil, fmt.Errorf("reading SOUL.md: %w", err)
 	}
+	p.SoulVariants = discoverSoulVariants(pulled.Dir)
 
 	return p, nil
 }
+
+// contextReader reads from r until ctx is done, so long extractions stop
+// promptly when an operation is cancelled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -2,11 +2,86 @@ package oci
 
 import (
 	"encoding/json"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	godigest "github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote/auth"
 )
 
+// addPullableArtifact packages files into a content layer of the given kind
+// and registers the manifest, config, and layer with reg under repo:tag.
+// It returns the manifest digest.
+func addPullableArtifact(t testing.TB, reg *cacheRegistry, repo, tag string, kind artifactKind, configJSON []byte, files map[string]string) string {
+	t.Helper()
+	src := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	layer, err := createTarGz(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configDigest := reg.addBlob(configJSON)
+	layerDigest := reg.addBlob(layer)
+	manifest := ocispec.Manifest{
+		Versioned:    specs.Versioned{SchemaVersion: 2},
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: kind.ArtifactType,
+		Config: ocispec.Descriptor{
+			MediaType: kind.ConfigMediaType,
+			Digest:    godigest.Digest(configDigest),
+			Size:      int64(len(configJSON)),
+		},
+		Layers: []ocispec.Descriptor{{
+			MediaType: kind.ContentMediaType,
+			Digest:    godigest.Digest(layerDigest),
+			Size:      int64(len(layer)),
+		}},
+	}
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reg.addManifest(repo, tag, body)
+}
+
+// newPullTestRegistry starts reg and returns its host.
+func newPullTestRegistry(t testing.TB, reg *cacheRegistry) string {
+	t.Helper()
+	ts := httptest.NewServer(reg.handler())
+	t.Cleanup(ts.Close)
+	return strings.TrimPrefix(ts.URL, "http://")
+}
+
+// parsePersonalityFromDir assembles a PulledPersonality from a pull result
+// and the extracted dir, as PullPersonality does.
+func parsePersonalityFromDir(dir, ref string, result *pullResult) (*PulledPersonality, error) {
+	_, tag := SplitNameTag(ref)
+	personality, err := decodePersonality(result.Annotations, tag, result.ConfigJSON)
+	if err != nil {
+		return nil, err
+	}
+	return readPulledSoul(&Pulled[Personality]{
+		ArtifactInfo: ArtifactInfo{Ref: ref, Tag: tag, Digest: result.Digest, Quarantine: result.Quarantine},
+		Artifact:     personality,
+		Dir:          dir,
+		Cached:       result.Cached,
+	})
+}
+
 func TestParsePersonalityFromDir(t *testing.T) {
 	dir := t.TempDir()
 
@@ -300,3 +375,126 @@ func TestParsePersonalityFromDir_WithFullMetadata(t *testing.T) {
 		t.Errorf("Dir = %q, want %q", p.Dir, dir)
 	}
 }
+
+func TestPullAny(t *testing.T) {
+	reg := newCacheRegistry()
+	pluginJSON, _ := json.Marshal(pluginConfigBlob{Skills: []string{"kubernetes"}})
+	personalityJSON, _ := json.Marshal(personalityConfigBlob{})
+	addPullableArtifact(t, reg, "klaus-plugins/gs-base", "v1.0.0", pluginArtifact, pluginJSON,
+		map[string]string{"skills/kubernetes/SKILL.md": "# k8s"})
+	addPullableArtifact(t, reg, "klaus-personalities/sre", "v1.0.0", personalityArtifact, personalityJSON,
+		map[string]string{"SOUL.md": "Be calm."})
+	host := newPullTestRegistry(t, reg)
+
+	client := NewClient(WithPlainHTTP(true))
+
+	t.Run("plugin", func(t *testing.T) {
+		dir := t.TempDir()
+		heads, gets := reg.headCount.Load(), reg.manifestCount.Load()
+		pulled, err := client.PullAny(t.Context(), host+"/klaus-plugins/gs-base:v1.0.0", dir)
+		if err != nil {
+			t.Fatalf("PullAny() error = %v", err)
+		}
+		// The manifest fetched to detect the kind is pulled.
+		if n := reg.headCount.Load() - heads; n != 1 {
+			t.Errorf("manifest HEAD requests = %d, want 1", n)
+		}
+		if n := reg.manifestCount.Load() - gets; n != 1 {
+			t.Errorf("manifest GET requests = %d, want 1", n)
+		}
+		if pulled.Kind != KindPlugin || pulled.Plugin == nil || pulled.Personality != nil {
+			t.Fatalf("PullAny() = %+v, want plugin only", pulled)
+		}
+		if len(pulled.Plugin.Skills) != 1 || pulled.Plugin.Skills[0] != "kubernetes" {
+			t.Errorf("Skills = %v, want [kubernetes]", pulled.Plugin.Skills)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "skills", "kubernetes", "SKILL.md")); err != nil {
+			t.Errorf("skill not extracted: %v", err)
+		}
+	})
+
+	t.Run("personality", func(t *testing.T) {
+		pulled, err := client.PullAny(t.Context(), host+"/klaus-personalities/sre:v1.0.0", t.TempDir())
+		if err != nil {
+			t.Fatalf("PullAny() error = %v", err)
+		}
+		if pulled.Kind != KindPersonality || pulled.Personality == nil || pulled.Plugin != nil {
+			t.Fatalf("PullAny() = %+v, want personality only", pulled)
+		}
+		if pulled.Personality.Soul != "Be calm." {
+			t.Errorf("Soul = %q, want %q", pulled.Personality.Soul, "Be calm.")
+		}
+	})
+
+	t.Run("unknown kind", func(t *testing.T) {
+		addPullableArtifact(t, reg, "other/thing", "v1.0.0",
+			artifactKind{ConfigMediaType: "application/vnd.example+json", ContentMediaType: "application/vnd.example.tar+gzip"},
+			[]byte(`{}`), nil)
+		_, err := client.PullAny(t.Context(), host+"/other/thing:v1.0.0", t.TempDir())
+		if err == nil || !strings.Contains(err.Error(), "unrecognized artifact type") {
+			t.Fatalf("error = %v, want unrecognized artifact type", err)
+		}
+	})
+}
+
+func TestPullPlugin_ArtifactTypeWithEmptyConfig(t *testing.T) {
+	reg := newCacheRegistry()
+	// An OCI 1.1 artifact identified only by artifactType, with the empty
+	// config descriptor.
+	kind := artifactKind{
+		ConfigMediaType:  ocispec.MediaTypeEmptyJSON,
+		ContentMediaType: MediaTypePluginContent,
+		ArtifactType:     ArtifactTypePlugin,
+	}
+	addPullableArtifact(t, reg, "klaus-plugins/gs-base", "v1.0.0", kind, []byte("{}"),
+		map[string]string{"README.md": "hi"})
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+
+	pulled, err := client.PullPlugin(t.Context(), host+"/klaus-plugins/gs-base:v1.0.0", t.TempDir())
+	if err != nil {
+		t.Fatalf("PullPlugin() error = %v", err)
+	}
+	if pulled.Version != "v1.0.0" {
+		t.Errorf("Version = %q, want v1.0.0", pulled.Version)
+	}
+
+	if _, err := client.PullPersonality(t.Context(), host+"/klaus-plugins/gs-base:v1.0.0", t.TempDir()); err == nil {
+		t.Error("PullPersonality() succeeded on a plugin artifactType, want error")
+	}
+}
+
+func TestPull_RecordsProvenance(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	repository := host + "/klaus-personalities/sre"
+	pushVersions(t, client, repository, "v1.0.0")
+
+	dir := filepath.Join(t.TempDir(), "sre")
+	ctx := WithContextCredentials(t.Context(), auth.Credential{Username: "alice", Password: "secret"})
+	if _, err := client.PullPersonality(ctx, repository+":v1.0.0", dir); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := ReadCacheEntry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Provenance{
+		Registry:      host,
+		Repository:    "klaus-personalities/sre",
+		Identity:      "alice",
+		ClientVersion: Version(),
+		Signature:     SignatureNotVerified,
+	}
+	if entry.Provenance == nil || *entry.Provenance != want {
+		t.Errorf("Provenance = %+v, want %+v", entry.Provenance, want)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, cacheFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Error("cache entry contains the password")
+	}
+}
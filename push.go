@@ -5,17 +5,75 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"time"
 
-	godigest "github.com/opencontainers/go-digest"
 	specs "github.com/opencontainers/image-spec/specs-go"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote"
 )
 
-// push packages a directory and pushes it to an OCI registry as a Klaus artifact.
-// The configJSON is the marshaled type-specific config blob (pluginConfigBlob or
-// personalityConfigBlob). The annotations map carries common metadata and is set
-// directly on the manifest.
-func (c *Client) push(ctx context.Context, sourceDir string, ref string, configJSON []byte, annotations map[string]string, kind artifactKind) (*PushResult, error) {
+// PushOption configures push operations.
+type PushOption func(*pushConfig)
+
+type pushConfig struct {
+	checksumReferrer bool
+	additionalTags   []string
+	noClobber        bool
+	encrypt          bool
+	changelog        []byte
+	extraLayers      []extraLayerSource
+}
+
+func newPushConfig(opts []PushOption) *pushConfig {
+	cfg := &pushConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithChecksumReferrer attaches the artifact's checksum document as an OCI
+// referrer (artifactType MediaTypeChecksums), so it can be discovered
+// through the referrers API, e.g. with `oras discover`.
+func WithChecksumReferrer() PushOption {
+	return func(cfg *pushConfig) { cfg.checksumReferrer = true }
+}
+
+// WithAdditionalTags tags the pushed manifest with aliases besides the tag
+// of the reference, e.g. floating "latest", "v1", and "v1.2" tags. All
+// tags are validated before anything is uploaded, and every alias points
+// at the same manifest digest as the primary tag.
+func WithAdditionalTags(tags ...string) PushOption {
+	return func(cfg *pushConfig) { cfg.additionalTags = append(cfg.additionalTags, tags...) }
+}
+
+// WithNoClobber refuses to move the tag of the pushed reference: when it
+// already points at a different manifest, the push fails with
+// *ErrTagExists before anything is uploaded. Republishing identical
+// content succeeds. Tags from WithAdditionalTags are floating aliases and
+// are still moved.
+func WithNoClobber() PushOption {
+	return func(cfg *pushConfig) { cfg.noClobber = true }
+}
+
+// push uploads a built Klaus artifact to an OCI registry and tags it, and
+// releases the temporary files of built and the copies derived from it.
+// releaseBuild releases the memory reserved for packaging built; push
+// calls it before reserving more, so it never waits for the budget while
+// holding part of it.
+func (c *Client) push(ctx context.Context, ref string, built *BuiltArtifact, releaseBuild func(), opts []PushOption) (result *PushResult, err error) {
+	defer func() { built.release() }()
+	defer func() {
+		rec := AuditRecord{Operation: AuditPush, Ref: ref}
+		if result != nil {
+			rec.Digest, rec.Tags = result.Digest, result.Tags
+		}
+		c.recordAudit(ctx, rec, err)
+	}()
+
+	cfg := newPushConfig(opts)
+
 	repo, tag, err := c.newRepository(ref)
 	if err != nil {
 		return nil, err
@@ -24,92 +82,253 @@ func (c *Client) push(ctx context.Context, sourceDir string, ref string, configJ
 	if tag == "" {
 		return nil, fmt.Errorf("reference %q must include a tag", ref)
 	}
-
-	configDesc := ocispec.Descriptor{
-		MediaType: kind.ConfigMediaType,
-		Digest:    godigest.FromBytes(configJSON),
-		Size:      int64(len(configJSON)),
+	aliases, err := additionalTags(repo, tag, cfg.additionalTags)
+	if err != nil {
+		return nil, err
+	}
+	// Each step derives a copy of built, which keeps its temporary files.
+	var next *BuiltArtifact
+	if cfg.encrypt {
+		// Encryption holds the plain and the encrypted layer in memory,
+		// which this reservation covers in place of the packaging one.
+		releaseBuild()
+		release, err := c.reserveMemory(ctx, 2*built.Manifest.Layers[0].Size, "encrypting the content layer of "+ref)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		if next, err = encryptArtifact(ctx, built, c.keyWrappers); err != nil {
+			return nil, err
+		}
+		built = next
+	}
+	if len(cfg.extraLayers) > 0 {
+		if next, err = withExtraLayers(built, cfg.extraLayers, c.extraLayerSpoolThreshold()); err != nil {
+			return nil, err
+		}
+		built = next
+	}
+	if len(cfg.changelog) > 0 {
+		if next, err = withChangelog(built, cfg.changelog); err != nil {
+			return nil, err
+		}
+		built = next
+	}
+	if cfg.noClobber {
+		if err := checkTagUnchanged(ctx, repo, tag, built.Digest); err != nil {
+			return nil, err
+		}
 	}
 
-	if err := repo.Push(ctx, configDesc, bytes.NewReader(configJSON)); err != nil {
+	if _, err := pushBlob(ctx, repo, built.Manifest.Config.MediaType, built.Config); err != nil {
 		return nil, fmt.Errorf("pushing config blob: %w", err)
 	}
+	if err := pushLayer(ctx, repo, built.Manifest.Layers[0], built.openLayer); err != nil {
+		return nil, fmt.Errorf("pushing content layer: %w", err)
+	}
+	for i := range built.ExtraLayers {
+		open := func() (io.ReadCloser, error) { return built.openExtraLayer(i) }
+		if err := pushLayer(ctx, repo, built.Manifest.Layers[1+i], open); err != nil {
+			return nil, fmt.Errorf("pushing extra layer: %w", err)
+		}
+	}
+	if built.Changelog != nil {
+		if _, err := pushBlob(ctx, repo, MediaTypeChangelog, built.Changelog); err != nil {
+			return nil, fmt.Errorf("pushing changelog layer: %w", err)
+		}
+	}
 
-	layerData, err := createTarGz(sourceDir)
+	sums, err := built.Checksums()
 	if err != nil {
-		return nil, fmt.Errorf("creating archive: %w", err)
+		return nil, err
 	}
-	layerDesc := ocispec.Descriptor{
-		MediaType: kind.ContentMediaType,
-		Digest:    godigest.FromBytes(layerData),
-		Size:      int64(len(layerData)),
+
+	// The manifest is pushed untagged and tagged last, so a push that fails
+	// or is cancelled before then leaves no tag behind.
+	manifestDesc, err := pushManifest(ctx, repo, built.Manifest, "")
+	if err != nil {
+		return nil, err
 	}
 
-	if err := repo.Push(ctx, layerDesc, bytes.NewReader(layerData)); err != nil {
-		return nil, fmt.Errorf("pushing content layer: %w", err)
+	result = &PushResult{
+		Digest:    manifestDesc.Digest.String(),
+		Tags:      append([]string{tag}, aliases...),
+		Checksums: sums,
+	}
+	if cfg.checksumReferrer {
+		if err := c.attachChecksums(ctx, repo, manifestDesc, result); err != nil {
+			return nil, err
+		}
 	}
+	if err := applyTags(ctx, repo, manifestDesc, result.Tags); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
 
-	manifest := ocispec.Manifest{
-		Versioned:   specs.Versioned{SchemaVersion: 2},
-		MediaType:   ocispec.MediaTypeImageManifest,
-		Config:      configDesc,
-		Layers:      []ocispec.Descriptor{layerDesc},
-		Annotations: annotations,
+// tagTimeout bounds the tagging step of a push, which runs to completion
+// even when the push is cancelled.
+const tagTimeout = 30 * time.Second
+
+// applyTags points tags at desc. It fails without tagging if ctx is
+// already done; once started, it ignores cancellation of ctx, so the tags
+// of a push are applied together rather than leaving some of them at the
+// previous version.
+func applyTags(ctx context.Context, repo *remote.Repository, desc ocispec.Descriptor, tags []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tagTimeout)
+	defer cancel()
+	for _, tag := range tags {
+		if err := repo.Tag(ctx, desc, tag); err != nil {
+			return fmt.Errorf("tagging manifest as %s: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+// attachChecksums pushes the checksum referrer of a push. Registries
+// without the referrers API are served through the referrers tag schema;
+// registries that reject referrer manifests outright leave the push
+// without a referrer and with a warning instead of failing it.
+func (c *Client) attachChecksums(ctx context.Context, repo *remote.Repository, subject ocispec.Descriptor, result *PushResult) error {
+	host, repository := repo.Reference.Registry, repo.Reference.Repository
+	if c.supports(ctx, host, repository, FeatureReferrers) == Unsupported {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"%s does not support the referrers API; the checksum referrer is indexed under the tag %s-%s instead",
+			host, subject.Digest.Algorithm(), subject.Digest.Encoded()))
+	}
+
+	digest, err := pushChecksumReferrer(ctx, repo, subject, result.Checksums)
+	if err != nil {
+		if !isUnsupportedResponse(err) {
+			return err
+		}
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s rejected the checksum referrer, which was not attached: %v", host, err))
+		return nil
+	}
+	result.ChecksumReferrer = digest
+	return nil
+}
+
+// additionalTags validates the alias tags for a push to tag and returns
+// them without duplicates or the primary tag itself.
+func additionalTags(repo *remote.Repository, tag string, tags []string) ([]string, error) {
+	seen := map[string]bool{tag: true}
+	var aliases []string
+	for _, t := range tags {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		ref := repo.Reference
+		ref.Reference = t
+		if err := ref.ValidateReferenceAsTag(); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, t)
+	}
+	return aliases, nil
+}
+
+// pushLayer streams the layer described by desc from open to repo.
+func pushLayer(ctx context.Context, repo *remote.Repository, desc ocispec.Descriptor, open func() (io.ReadCloser, error)) error {
+	rc, err := open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return repo.Push(ctx, desc, rc)
+}
+
+// pushBlob pushes data as a blob with the given media type and returns its
+// descriptor.
+func pushBlob(ctx context.Context, repo *remote.Repository, mediaType string, data []byte) (ocispec.Descriptor, error) {
+	desc := blobDescriptor(mediaType, data)
+	if err := repo.Push(ctx, desc, bytes.NewReader(data)); err != nil {
+		return ocispec.Descriptor{}, err
 	}
+	return desc, nil
+}
 
+// pushManifest marshals and pushes an image manifest and, when tag is not
+// empty, tags it.
+func pushManifest(ctx context.Context, repo *remote.Repository, manifest ocispec.Manifest, tag string) (ocispec.Descriptor, error) {
 	manifestJSON, err := json.Marshal(manifest)
 	if err != nil {
-		return nil, fmt.Errorf("marshaling manifest: %w", err)
+		return ocispec.Descriptor{}, fmt.Errorf("marshaling manifest: %w", err)
 	}
-	manifestDesc := ocispec.Descriptor{
-		MediaType: ocispec.MediaTypeImageManifest,
-		Digest:    godigest.FromBytes(manifestJSON),
-		Size:      int64(len(manifestJSON)),
+	manifestDesc, err := pushBlob(ctx, repo, ocispec.MediaTypeImageManifest, manifestJSON)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("pushing manifest: %w", err)
 	}
 
-	if err := repo.Push(ctx, manifestDesc, bytes.NewReader(manifestJSON)); err != nil {
-		return nil, fmt.Errorf("pushing manifest: %w", err)
+	if tag != "" {
+		if err := repo.Tag(ctx, manifestDesc, tag); err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("tagging manifest as %s: %w", tag, err)
+		}
 	}
+	return manifestDesc, nil
+}
 
-	if err := repo.Tag(ctx, manifestDesc, tag); err != nil {
-		return nil, fmt.Errorf("tagging manifest as %s: %w", tag, err)
+// pushReferrer pushes doc as the single layer of an OCI artifact of the
+// given type whose subject is the manifest described by subject.
+func pushReferrer(ctx context.Context, repo *remote.Repository, subject ocispec.Descriptor, artifactType string, doc []byte, annotations map[string]string) (ocispec.Descriptor, error) {
+	if _, err := pushBlob(ctx, repo, ocispec.MediaTypeEmptyJSON, ocispec.DescriptorEmptyJSON.Data); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("pushing empty config: %w", err)
+	}
+	layer, err := pushBlob(ctx, repo, artifactType, doc)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("pushing layer: %w", err)
 	}
 
-	return &PushResult{Digest: manifestDesc.Digest.String()}, nil
+	manifest := ocispec.Manifest{
+		Versioned:    specs.Versioned{SchemaVersion: 2},
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: artifactType,
+		Config:       ocispec.DescriptorEmptyJSON,
+		Layers:       []ocispec.Descriptor{layer},
+		Subject:      &subject,
+		Annotations:  annotations,
+	}
+	return pushManifest(ctx, repo, manifest, "")
 }
 
 // PushPersonality pushes a personality artifact to an OCI registry.
 // Common metadata (name, description, author, etc.) is stored as Klaus
 // annotations on the manifest. The config blob contains only composition
-// data (toolchain + plugins). Version is conveyed through the OCI tag.
-func (c *Client) PushPersonality(ctx context.Context, sourceDir, ref string, p Personality) (*PushResult, error) {
-	blob := personalityConfigBlob{
-		Toolchain: p.Toolchain,
-		Plugins:   p.Plugins,
-	}
-	configJSON, err := json.Marshal(blob)
-	if err != nil {
-		return nil, fmt.Errorf("marshaling personality config: %w", err)
-	}
-	return c.push(ctx, sourceDir, ref, configJSON, buildKlausAnnotations(p.klausMetadata()), personalityArtifact)
+// data (toolchain + plugins) and the content-tree hash of sourceDir.
+// Version is conveyed through the OCI tag. Annotations in the
+// AnnotationsFileName of sourceDir are added to the manifest.
+func (c *Client) PushPersonality(ctx context.Context, sourceDir, ref string, p Personality, opts ...PushOption) (*PushResult, error) {
+	return Push(ctx, c, PersonalityKind, sourceDir, ref, p, opts...)
 }
 
 // PushPlugin pushes a plugin artifact to an OCI registry.
 // Common metadata (name, description, author, etc.) is stored as Klaus
 // annotations on the manifest. The config blob contains only discovered
-// components (skills, commands, etc.). Version is conveyed through the OCI tag.
-func (c *Client) PushPlugin(ctx context.Context, sourceDir, ref string, p Plugin) (*PushResult, error) {
-	blob := pluginConfigBlob{
-		Skills:     p.Skills,
-		Commands:   p.Commands,
-		Agents:     p.Agents,
-		HasHooks:   p.HasHooks,
-		MCPServers: p.MCPServers,
-		LSPServers: p.LSPServers,
-	}
-	configJSON, err := json.Marshal(blob)
+// components (skills, commands, etc.) and the content-tree hash of
+// sourceDir. Version is conveyed through the OCI tag. Annotations in the
+// AnnotationsFileName of sourceDir are added to the manifest.
+func (c *Client) PushPlugin(ctx context.Context, sourceDir, ref string, p Plugin, opts ...PushOption) (*PushResult, error) {
+	return Push(ctx, c, PluginKind, sourceDir, ref, p, opts...)
+}
+
+// buildAndPush packages an artifact with build and pushes it. The content
+// layer is held in memory up to the client's spool threshold, which is
+// reserved from the memory budget for the push, and streamed from a
+// temporary file beyond it.
+func (c *Client) buildAndPush(ctx context.Context, ref string, opts []PushOption, build func(spillAt int64) (*BuiltArtifact, error)) (*PushResult, error) {
+	spillAt := c.spoolThreshold()
+	release, err := c.reserveMemory(ctx, spillAt, "packaging "+ref)
 	if err != nil {
-		return nil, fmt.Errorf("marshaling plugin config: %w", err)
+		return nil, err
+	}
+	defer release()
+	built, err := build(spillAt)
+	if err != nil {
+		return nil, err
 	}
-	return c.push(ctx, sourceDir, ref, configJSON, buildKlausAnnotations(p.klausMetadata()), pluginArtifact)
+	return c.push(ctx, ref, built, release, opts)
 }
@@ -2,7 +2,12 @@ package oci
 
 import (
 	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 func TestPluginConfigBlob_ExcludesCommonMetadata(t *testing.T) {
@@ -151,6 +156,7 @@ func TestPushPlugin_AnnotationsFromMetadata(t *testing.T) {
 	annotations := buildKlausAnnotations(p.klausMetadata())
 
 	expected := map[string]string{
+		AnnotationType:        "plugin",
 		AnnotationName:        "gs-base",
 		AnnotationDescription: "Giant Swarm base plugin",
 		AnnotationAuthorName:  "Giant Swarm",
@@ -211,8 +217,11 @@ func TestPushPlugin_MinimalMetadata(t *testing.T) {
 	if annotations[AnnotationName] != "minimal" {
 		t.Errorf("name = %q, want %q", annotations[AnnotationName], "minimal")
 	}
-	if len(annotations) != 1 {
-		t.Errorf("got %d annotations, want 1 (name only)", len(annotations))
+	if annotations[AnnotationType] != string(KindPlugin) {
+		t.Errorf("type = %q, want %q", annotations[AnnotationType], KindPlugin)
+	}
+	if len(annotations) != 2 {
+		t.Errorf("got %d annotations, want 2 (type and name only)", len(annotations))
 	}
 }
 
@@ -222,3 +231,175 @@ func TestPushPlugin_NoMetadata(t *testing.T) {
 		t.Errorf("expected nil annotations for empty metadata, got %v", annotations)
 	}
 }
+
+func TestPush_SetsArtifactType(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "SOUL.md"), []byte("Be calm."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		push func(ref string) (*PushResult, error)
+		want string
+	}{
+		{
+			name: "plugin",
+			push: func(ref string) (*PushResult, error) {
+				return client.PushPlugin(t.Context(), src, ref, Plugin{Name: "gs-base"})
+			},
+			want: ArtifactTypePlugin,
+		},
+		{
+			name: "personality",
+			push: func(ref string) (*PushResult, error) {
+				return client.PushPersonality(t.Context(), src, ref, Personality{Name: "sre"})
+			},
+			want: ArtifactTypePersonality,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.push(host + "/klaus/" + tt.name + ":v1.0.0")
+			if err != nil {
+				t.Fatalf("push error = %v", err)
+			}
+			reg.mu.Lock()
+			body := reg.manifests[result.Digest]
+			reg.mu.Unlock()
+
+			var manifest ocispec.Manifest
+			if err := json.Unmarshal(body, &manifest); err != nil {
+				t.Fatal(err)
+			}
+			if manifest.ArtifactType != tt.want {
+				t.Errorf("artifactType = %q, want %q", manifest.ArtifactType, tt.want)
+			}
+		})
+	}
+}
+
+func TestPush_WithAdditionalTags(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "SOUL.md"), "Be calm.")
+
+	result, err := client.PushPersonality(t.Context(), src, host+"/klaus/sre:v1.2.3", Personality{Name: "sre"},
+		WithAdditionalTags("v1", "v1.2", "latest", "v1"))
+	if err != nil {
+		t.Fatalf("PushPersonality() error = %v", err)
+	}
+
+	wantTags := []string{"v1.2.3", "v1", "v1.2", "latest"}
+	if len(result.Tags) != len(wantTags) {
+		t.Fatalf("Tags = %v, want %v", result.Tags, wantTags)
+	}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	for i, tag := range wantTags {
+		if result.Tags[i] != tag {
+			t.Errorf("Tags[%d] = %q, want %q", i, result.Tags[i], tag)
+		}
+		if got := reg.repos["klaus/sre"][tag]; got != result.Digest {
+			t.Errorf("tag %s -> %q, want %s", tag, got, result.Digest)
+		}
+	}
+}
+
+func TestPush_WithAdditionalTags_Invalid(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "SOUL.md"), "Be calm.")
+
+	_, err := client.PushPersonality(t.Context(), src, host+"/klaus/sre:v1.2.3", Personality{Name: "sre"},
+		WithAdditionalTags("v1", "not a tag"))
+	if err == nil {
+		t.Fatal("expected error for invalid tag")
+	}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if len(reg.blobs) != 0 || len(reg.manifests) != 0 {
+		t.Errorf("uploaded %d blobs and %d manifests before rejecting the tag", len(reg.blobs), len(reg.manifests))
+	}
+}
+
+func TestPush_WithNoClobber(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	ref := host + "/klaus/sre:v1.2.0"
+
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "SOUL.md"), "Be calm.")
+	first, err := client.PushPersonality(t.Context(), src, ref, Personality{Name: "sre"}, WithNoClobber())
+	if err != nil {
+		t.Fatalf("first push error = %v", err)
+	}
+
+	// Republishing identical content is allowed.
+	if _, err := client.PushPersonality(t.Context(), src, ref, Personality{Name: "sre"}, WithNoClobber()); err != nil {
+		t.Errorf("identical push error = %v", err)
+	}
+
+	writeFile(t, filepath.Join(src, "SOUL.md"), "Be bold.")
+	_, err = client.PushPersonality(t.Context(), src, ref, Personality{Name: "sre"}, WithNoClobber())
+	var exists *ErrTagExists
+	if !errors.As(err, &exists) {
+		t.Fatalf("changed push error = %v, want *ErrTagExists", err)
+	}
+	if exists.Tag != "v1.2.0" || exists.Digest != first.Digest {
+		t.Errorf("ErrTagExists = %+v", exists)
+	}
+
+	got, err := client.Resolve(t.Context(), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != first.Digest {
+		t.Errorf("tag moved to %s, want %s", got, first.Digest)
+	}
+
+	// Without the option the tag is overwritten as before.
+	if _, err := client.PushPersonality(t.Context(), src, ref, Personality{Name: "sre"}); err != nil {
+		t.Errorf("push without WithNoClobber error = %v", err)
+	}
+}
+
+func TestAnnotations_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        Kind
+	}{
+		{"plugin", Plugin{Name: "gs-base"}.Annotations(), KindPlugin},
+		{"personality", Personality{Name: "sre"}.Annotations(), KindPersonality},
+		{"toolchain", Toolchain{Name: "go", License: "MIT"}.Annotations(), KindToolchain},
+		{"missing", map[string]string{AnnotationName: "x"}, ""},
+		{"unknown", map[string]string{AnnotationType: "widget"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KindFromAnnotations(tt.annotations); got != tt.want {
+				t.Errorf("KindFromAnnotations() = %q, want %q", got, tt.want)
+			}
+			if got := metadataFromAnnotations(tt.annotations).Kind; got != tt.want {
+				t.Errorf("metadataFromAnnotations().Kind = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	tc := toolchainFromAnnotations(Toolchain{Name: "go", License: "MIT"}.Annotations())
+	if tc.Name != "go" || tc.License != "MIT" {
+		t.Errorf("toolchainFromAnnotations() = %+v", tc)
+	}
+}
@@ -0,0 +1,125 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DiscoveredPlugin is a plugin source directory found by DiscoverPlugins.
+type DiscoveredPlugin struct {
+	// Dir is the plugin directory, the parent of .claude-plugin.
+	Dir string
+	// Plugin is the metadata read with ReadPluginFromDir.
+	Plugin Plugin
+}
+
+// DiscoverPlugins finds all plugins under rootDir, i.e. every directory
+// containing .claude-plugin/plugin.json, and reads them with
+// ReadPluginFromDir. Hidden directories such as .git are not searched,
+// nor are the directories of plugins already found. Plugins are returned
+// sorted by directory. Plugin names must be unique, since they become
+// repository names.
+func DiscoverPlugins(rootDir string) ([]DiscoveredPlugin, error) {
+	var plugins []DiscoveredPlugin
+	seen := map[string]string{}
+	err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != rootDir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(path, ".claude-plugin", "plugin.json")); err != nil {
+			return nil
+		}
+
+		p, err := ReadPluginFromDir(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if p.Name == "" {
+			return fmt.Errorf("%s: plugin.json: name is required", path)
+		}
+		if other, ok := seen[p.Name]; ok {
+			return fmt.Errorf("plugin %q is defined in both %s and %s", p.Name, other, path)
+		}
+		seen[p.Name] = path
+		plugins = append(plugins, DiscoveredPlugin{Dir: path, Plugin: *p})
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, fmt.Errorf("discovering plugins in %s: %w", rootDir, err)
+	}
+	return plugins, nil
+}
+
+// VersionFunc returns the tag to push a discovered plugin as, e.g. from a
+// VERSION file in its directory or the CI build number. An empty tag
+// skips the plugin.
+type VersionFunc func(p DiscoveredPlugin) (string, error)
+
+// BatchPushResult is the outcome of pushing one plugin with PushAll.
+type BatchPushResult struct {
+	DiscoveredPlugin
+	// Ref is the reference pushed to; empty if the plugin was skipped or
+	// its version could not be determined.
+	Ref string
+	// Result is set when the push succeeded.
+	Result *PushResult
+	// Err is the error of a failed push.
+	Err error
+}
+
+// PushAll discovers the plugins under rootDir with DiscoverPlugins and
+// pushes each to registryBase/<name>:<version>, using versionFn for the
+// version. Pushes run concurrently (see WithConcurrency) and a failed
+// push does not stop the others. PushAll returns one result per plugin in
+// discovery order, together with the failures joined into one error.
+// Nothing is pushed when discovery fails.
+func (c *Client) PushAll(ctx context.Context, rootDir, registryBase string, versionFn VersionFunc, opts ...PushOption) ([]BatchPushResult, error) {
+	plugins, err := DiscoverPlugins(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	registryBase = strings.TrimSuffix(registryBase, "/")
+
+	results := make([]BatchPushResult, len(plugins))
+	var g errgroup.Group
+	g.SetLimit(c.concurrency)
+	for i, p := range plugins {
+		results[i].DiscoveredPlugin = p
+		g.Go(func() error {
+			r := &results[i]
+			tag, err := versionFn(p)
+			if err != nil {
+				r.Err = fmt.Errorf("determining version: %w", err)
+				return nil
+			}
+			if tag == "" {
+				return nil
+			}
+			r.Ref = registryBase + "/" + p.Plugin.Name + ":" + tag
+			r.Result, r.Err = c.PushPlugin(ctx, p.Dir, r.Ref, p.Plugin, opts...)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("plugin %s: %w", r.Plugin.Name, r.Err))
+		}
+	}
+	return results, errors.Join(errs...)
+}
@@ -0,0 +1,96 @@
+package oci
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newPluginMonorepo lays out gs-base and gs-k8s (nested under a group
+// directory) below a temporary root, plus a plugin inside .git that
+// discovery must ignore.
+func newPluginMonorepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "README.md"), "# plugins")
+	writeFile(t, filepath.Join(root, "gs-base", ".claude-plugin", "plugin.json"), `{"name":"gs-base"}`)
+	writeFile(t, filepath.Join(root, "gs-base", "VERSION"), "v1.0.0")
+	writeFile(t, filepath.Join(root, "platform", "gs-k8s", ".claude-plugin", "plugin.json"), `{"name":"gs-k8s"}`)
+	writeFile(t, filepath.Join(root, "platform", "gs-k8s", "VERSION"), "v2.1.0")
+	writeFile(t, filepath.Join(root, "platform", "gs-k8s", "skills", "k8s", "SKILL.md"), "# k8s")
+	writeFile(t, filepath.Join(root, ".git", "plugin", ".claude-plugin", "plugin.json"), `{"name":"hidden"}`)
+	return root
+}
+
+func TestDiscoverPlugins(t *testing.T) {
+	root := newPluginMonorepo(t)
+
+	plugins, err := DiscoverPlugins(root)
+	if err != nil {
+		t.Fatalf("DiscoverPlugins() error = %v", err)
+	}
+	if len(plugins) != 2 {
+		t.Fatalf("DiscoverPlugins() found %d plugins, want 2: %+v", len(plugins), plugins)
+	}
+	if plugins[0].Plugin.Name != "gs-base" || plugins[0].Dir != filepath.Join(root, "gs-base") {
+		t.Errorf("plugins[0] = %+v", plugins[0])
+	}
+	if plugins[1].Plugin.Name != "gs-k8s" || len(plugins[1].Plugin.Skills) != 1 {
+		t.Errorf("plugins[1] = %+v", plugins[1])
+	}
+
+	writeFile(t, filepath.Join(root, "copy", ".claude-plugin", "plugin.json"), `{"name":"gs-base"}`)
+	if _, err := DiscoverPlugins(root); err == nil || !strings.Contains(err.Error(), `plugin "gs-base" is defined in both`) {
+		t.Errorf("DiscoverPlugins() with duplicate name error = %v", err)
+	}
+}
+
+func TestPushAll(t *testing.T) {
+	root := newPluginMonorepo(t)
+	writeFile(t, filepath.Join(root, "gs-wip", ".claude-plugin", "plugin.json"), `{"name":"gs-wip"}`)
+	writeFile(t, filepath.Join(root, "gs-broken", ".claude-plugin", "plugin.json"), `{"name":"gs-broken"}`)
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true), WithConcurrency(2))
+
+	versionFn := func(p DiscoveredPlugin) (string, error) {
+		switch p.Plugin.Name {
+		case "gs-wip":
+			return "", nil
+		case "gs-broken":
+			return "", errors.New("no VERSION file")
+		}
+		data, err := os.ReadFile(filepath.Join(p.Dir, "VERSION"))
+		return strings.TrimSpace(string(data)), err
+	}
+	results, err := client.PushAll(t.Context(), root, host+"/klaus-plugins/", versionFn, WithAdditionalTags("latest"))
+	if err == nil || !strings.Contains(err.Error(), "plugin gs-broken: determining version: no VERSION file") {
+		t.Errorf("PushAll() error = %v, want gs-broken failure", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("PushAll() returned %d results, want 4", len(results))
+	}
+
+	byName := map[string]BatchPushResult{}
+	for _, r := range results {
+		byName[r.Plugin.Name] = r
+	}
+	for name, tag := range map[string]string{"gs-base": "v1.0.0", "gs-k8s": "v2.1.0"} {
+		r := byName[name]
+		if r.Err != nil || r.Result == nil {
+			t.Errorf("%s: Result = %+v, Err = %v", name, r.Result, r.Err)
+			continue
+		}
+		if want := host + "/klaus-plugins/" + name + ":" + tag; r.Ref != want {
+			t.Errorf("%s: Ref = %q, want %q", name, r.Ref, want)
+		}
+		if _, err := client.Resolve(t.Context(), host+"/klaus-plugins/"+name+":latest"); err != nil {
+			t.Errorf("%s: push options not applied: %v", name, err)
+		}
+	}
+	if r := byName["gs-wip"]; r.Ref != "" || r.Result != nil || r.Err != nil {
+		t.Errorf("gs-wip was not skipped: %+v", r)
+	}
+}
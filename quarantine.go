@@ -0,0 +1,154 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// MediaTypeQuarantine is the artifact type of the quarantine markers
+// attached to artifacts with Quarantine, and the media type of their
+// single layer.
+const MediaTypeQuarantine = "application/vnd.giantswarm.klaus.quarantine.v1+json"
+
+// Quarantine describes why an artifact version was quarantined.
+type Quarantine struct {
+	// Reason explains the quarantine, e.g. a security advisory.
+	Reason string `json:"reason"`
+	// Timestamp is when the artifact was quarantined.
+	Timestamp time.Time `json:"timestamp"`
+
+	// Digest is the manifest digest of the referrer marking the
+	// quarantine, set when it is fetched.
+	Digest string `json:"-"`
+}
+
+// WithAllowQuarantined lets pulls of quarantined artifacts succeed, e.g.
+// for forensic analysis. The pulled artifact's ArtifactInfo.Quarantine
+// still reports the quarantine.
+func WithAllowQuarantined() PullOption {
+	return func(cfg *pullConfig) { cfg.allowQuarantined = true }
+}
+
+// Quarantine marks the artifact at ref as quarantined by attaching an OCI
+// referrer of artifact type MediaTypeQuarantine, and returns the
+// referrer's manifest digest. It is a kill switch for compromised
+// versions that keeps them in the registry for forensics: describe
+// results report the quarantine in ArtifactInfo.Quarantine, and pulls
+// fail with ErrQuarantined unless made WithAllowQuarantined. ref must be
+// a fully-qualified reference; references without a tag resolve to the
+// latest version first, so the marker applies to one exact manifest.
+func (c *Client) Quarantine(ctx context.Context, ref, reason string) (digest string, err error) {
+	if strings.TrimSpace(reason) == "" {
+		return "", fmt.Errorf("quarantine reason must not be empty")
+	}
+	fm, resolved, err := c.fetchReferrerSubject(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		c.recordAudit(ctx, AuditRecord{Operation: AuditQuarantine, Ref: resolved, Digest: fm.digest}, err)
+	}()
+
+	q := Quarantine{Reason: reason, Timestamp: time.Now().UTC()}
+	doc, err := json.Marshal(q)
+	if err != nil {
+		return "", fmt.Errorf("marshaling quarantine: %w", err)
+	}
+	subject, err := fm.repo.Resolve(ctx, fm.digest)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", resolved, err)
+	}
+	annotations := map[string]string{
+		AnnotationQuarantineReason: reason,
+		ocispec.AnnotationCreated:  q.Timestamp.Format(time.RFC3339),
+	}
+	desc, err := pushReferrer(ctx, fm.repo, subject, MediaTypeQuarantine, doc, annotations)
+	if err != nil {
+		return "", fmt.Errorf("quarantining %s: %w", resolved, err)
+	}
+	return desc.Digest.String(), nil
+}
+
+// ReleaseQuarantine lifts the quarantine of the artifact at ref by
+// deleting all its quarantine markers. ref supports the same forms as
+// Quarantine. Releasing an artifact that is not quarantined is a no-op.
+func (c *Client) ReleaseQuarantine(ctx context.Context, ref string) (err error) {
+	fm, resolved, err := c.fetchReferrerSubject(ctx, ref)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		c.recordAudit(ctx, AuditRecord{Operation: AuditReleaseQuarantine, Ref: resolved, Digest: fm.digest}, err)
+	}()
+
+	markers, err := fm.referrers(ctx, MediaTypeQuarantine)
+	if err != nil {
+		return fmt.Errorf("listing quarantine markers of %s: %w", resolved, err)
+	}
+	for _, desc := range markers {
+		if err := fm.repo.Delete(ctx, desc); err != nil {
+			return fmt.Errorf("deleting quarantine marker %s of %s: %w", desc.Digest, resolved, err)
+		}
+	}
+	return nil
+}
+
+// fetchReferrerSubject resolves the fully-qualified ref of an artifact of
+// any kind and fetches its manifest, to attach or read referrers.
+func (c *Client) fetchReferrerSubject(ctx context.Context, ref string) (*fetchedManifest, string, error) {
+	ref = strings.TrimSpace(ref)
+	if !strings.Contains(ref, "/") {
+		return nil, "", fmt.Errorf("reference %q must be a fully-qualified OCI reference", ref)
+	}
+	resolved, err := resolveArtifactRef(ctx, c, ref, "")
+	if err != nil {
+		return nil, "", fmt.Errorf("resolving ref %q: %w", ref, err)
+	}
+	fm, err := c.fetchManifest(ctx, resolved)
+	if err != nil {
+		return nil, "", err
+	}
+	return fm, resolved, nil
+}
+
+// quarantineOf returns the newest quarantine marker of subject in repo,
+// or nil if it is not quarantined. Markers are read from the referrer
+// annotations, so no manifests are fetched.
+func quarantineOf(ctx context.Context, repo *remote.Repository, subject ocispec.Descriptor) (*Quarantine, error) {
+	var q *Quarantine
+	err := repo.Referrers(ctx, subject, MediaTypeQuarantine, func(page []ocispec.Descriptor) error {
+		for _, desc := range page {
+			marker := &Quarantine{
+				Reason: desc.Annotations[AnnotationQuarantineReason],
+				Digest: desc.Digest.String(),
+			}
+			marker.Timestamp, _ = time.Parse(time.RFC3339, desc.Annotations[ocispec.AnnotationCreated])
+			if q == nil || marker.Timestamp.After(q.Timestamp) {
+				q = marker
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// quarantine returns the quarantine of the fetched manifest, or nil if
+// it is not quarantined.
+func (fm *fetchedManifest) quarantine(ctx context.Context) (*Quarantine, error) {
+	subject := ocispec.Descriptor{MediaType: fm.mediaType, Digest: godigest.Digest(fm.digest)}
+	q, err := quarantineOf(ctx, fm.repo, subject)
+	if err != nil {
+		return nil, fmt.Errorf("checking quarantine of %s: %w", fm.repository(), err)
+	}
+	return q, nil
+}
@@ -0,0 +1,125 @@
+package oci
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestQuarantine(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	repository := host + "/klaus/sre"
+	digests := pushVersions(t, client, repository, "v1.0.0", "v1.1.0")
+	ref := repository + ":v1.0.0"
+
+	// Pull once before the quarantine to populate the local cache.
+	destDir := filepath.Join(t.TempDir(), "sre")
+	if _, err := client.PullPersonality(t.Context(), ref, destDir); err != nil {
+		t.Fatalf("PullPersonality() error = %v", err)
+	}
+
+	if _, err := client.Quarantine(t.Context(), ref, "CVE-2026-0001"); err != nil {
+		t.Fatalf("Quarantine() error = %v", err)
+	}
+
+	described, err := client.DescribePersonality(t.Context(), ref)
+	if err != nil {
+		t.Fatalf("DescribePersonality() error = %v", err)
+	}
+	if described.Quarantine == nil || described.Quarantine.Reason != "CVE-2026-0001" || described.Quarantine.Timestamp.IsZero() || described.Quarantine.Digest == "" {
+		t.Errorf("Quarantine = %+v, want the quarantine marker", described.Quarantine)
+	}
+	artifact, err := client.Describe(t.Context(), ref)
+	if err != nil {
+		t.Fatalf("Describe() error = %v", err)
+	}
+	if artifact.Personality.Quarantine == nil {
+		t.Error("Describe() did not report the quarantine")
+	}
+
+	// The quarantine applies to one version only.
+	other, err := client.DescribePersonality(t.Context(), repository+":v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Quarantine != nil {
+		t.Errorf("v1.1.0 Quarantine = %+v, want nil", other.Quarantine)
+	}
+
+	// Pulls are refused, also when the artifact is cached locally.
+	for _, pullRef := range []string{ref, repository + "@" + digests["v1.0.0"]} {
+		_, err = client.PullPersonality(t.Context(), pullRef, destDir)
+		var qerr *ErrQuarantined
+		if !errors.As(err, &qerr) {
+			t.Fatalf("PullPersonality(%s) error = %v, want ErrQuarantined", pullRef, err)
+		}
+		if qerr.Quarantine.Reason != "CVE-2026-0001" || qerr.Ref != pullRef {
+			t.Errorf("ErrQuarantined = %+v", qerr)
+		}
+	}
+	if _, err := client.PullAny(t.Context(), ref, t.TempDir()); err == nil {
+		t.Error("PullAny() of a quarantined artifact succeeded")
+	}
+
+	pulled, err := client.PullPersonality(t.Context(), ref, filepath.Join(t.TempDir(), "sre"), WithAllowQuarantined())
+	if err != nil {
+		t.Fatalf("PullPersonality(WithAllowQuarantined) error = %v", err)
+	}
+	if pulled.Quarantine == nil || pulled.Soul == "" {
+		t.Errorf("pulled = %+v, want content and quarantine", pulled)
+	}
+
+	if err := client.ReleaseQuarantine(t.Context(), ref); err != nil {
+		t.Fatalf("ReleaseQuarantine() error = %v", err)
+	}
+	if _, err := client.PullPersonality(t.Context(), ref, destDir); err != nil {
+		t.Fatalf("PullPersonality() after release error = %v", err)
+	}
+	described, err = client.DescribePersonality(t.Context(), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if described.Quarantine != nil {
+		t.Errorf("Quarantine after release = %+v, want nil", described.Quarantine)
+	}
+}
+
+func TestQuarantine_Audit(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	sink := &memoryAuditSink{}
+	client := NewClient(WithPlainHTTP(true), WithAuditSink(sink))
+	repository := host + "/klaus/sre"
+	pushVersions(t, client, repository, "v1.0.0")
+
+	if _, err := client.Quarantine(t.Context(), repository, "compromised"); err != nil {
+		t.Fatalf("Quarantine() error = %v", err)
+	}
+	if err := client.ReleaseQuarantine(t.Context(), repository); err != nil {
+		t.Fatalf("ReleaseQuarantine() error = %v", err)
+	}
+
+	var ops []AuditOperation
+	for _, r := range sink.all() {
+		ops = append(ops, r.Operation)
+		if r.Operation == AuditQuarantine && r.Ref != repository+":v1.0.0" {
+			t.Errorf("quarantine record Ref = %q, want the resolved version", r.Ref)
+		}
+	}
+	if !slices.Contains(ops, AuditQuarantine) || !slices.Contains(ops, AuditReleaseQuarantine) {
+		t.Errorf("audit operations = %v, want quarantine and release", ops)
+	}
+}
+
+func TestQuarantine_Invalid(t *testing.T) {
+	client := NewClient()
+	if _, err := client.Quarantine(t.Context(), "example.com/klaus/sre:v1.0.0", " "); err == nil {
+		t.Error("Quarantine() without reason succeeded")
+	}
+	if _, err := client.Quarantine(t.Context(), "sre", "reason"); err == nil {
+		t.Error("Quarantine() of a short name succeeded")
+	}
+}
@@ -2,7 +2,9 @@ package oci
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"slices"
@@ -23,6 +25,14 @@ import (
 //   - .mcp.json top-level keys -> MCPServers
 //   - .lsp.json top-level keys -> LSPServers
 //
+// Klaus extensions are read from the optional .claude-plugin/klaus.json,
+// which keeps fields Claude Code does not know out of plugin.json:
+//
+//   - "dependencies" -> Dependencies (replaces any list in plugin.json)
+//   - "secrets" -> Secrets (replaces any list in plugin.json; each
+//     mcpServer must be defined in .mcp.json)
+//   - "permissions" -> Permissions (replaces any section in plugin.json)
+//
 // Version is NOT set -- it is conveyed via the OCI tag at push time.
 func ReadPluginFromDir(dir string) (*Plugin, error) {
 	manifestPath := filepath.Join(dir, ".claude-plugin", "plugin.json")
@@ -43,9 +53,73 @@ func ReadPluginFromDir(dir string) (*Plugin, error) {
 	plugin.MCPServers = discoverJSONKeys(filepath.Join(dir, ".mcp.json"))
 	plugin.LSPServers = discoverJSONKeys(filepath.Join(dir, ".lsp.json"))
 
+	ext, err := readKlausExtension(dir)
+	if err != nil {
+		return nil, err
+	}
+	if ext.Dependencies != nil {
+		plugin.Dependencies = ext.Dependencies
+	}
+	if ext.Secrets != nil {
+		plugin.Secrets = ext.Secrets
+	}
+	if ext.Permissions != nil {
+		plugin.Permissions = ext.Permissions
+	}
+	if ext.Private {
+		plugin.Private = true
+	}
+	for i, s := range plugin.Secrets {
+		if s.MCPServer != "" && !slices.Contains(plugin.MCPServers, s.MCPServer) {
+			return nil, fmt.Errorf("secrets[%d]: MCP server %q is not defined in .mcp.json", i, s.MCPServer)
+		}
+	}
+
 	return &plugin, nil
 }
 
+// klausExtensionFile is the path of the Klaus plugin extension file,
+// relative to the plugin directory.
+var klausExtensionFile = filepath.Join(".claude-plugin", "klaus.json")
+
+// klausExtension is the schema of .claude-plugin/klaus.json.
+type klausExtension struct {
+	Dependencies []PluginReference   `json:"dependencies,omitempty"`
+	Secrets      []SecretRequirement `json:"secrets,omitempty"`
+	Permissions  *Permissions        `json:"permissions,omitempty"`
+	Private      bool                `json:"private,omitempty"`
+}
+
+// readKlausExtension reads .claude-plugin/klaus.json. A missing file yields
+// an empty extension.
+func readKlausExtension(dir string) (klausExtension, error) {
+	var ext klausExtension
+	data, err := os.ReadFile(filepath.Join(dir, klausExtensionFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ext, nil
+	}
+	if err != nil {
+		return ext, fmt.Errorf("reading %s: %w", klausExtensionFile, err)
+	}
+	if err := json.Unmarshal(data, &ext); err != nil {
+		return ext, fmt.Errorf("parsing %s: %w", klausExtensionFile, err)
+	}
+	for i, dep := range ext.Dependencies {
+		if dep.Repository == "" {
+			return ext, fmt.Errorf("%s: dependencies[%d]: repository is required", klausExtensionFile, i)
+		}
+	}
+	for i, s := range ext.Secrets {
+		if !envVarPattern.MatchString(s.Name) {
+			return ext, fmt.Errorf("%s: secrets[%d]: %q is not a valid environment variable name", klausExtensionFile, i, s.Name)
+		}
+	}
+	if ext.Permissions != nil && ext.Permissions.KubeAPI.rank() > KubeAccessWrite.rank() {
+		return ext, fmt.Errorf("%s: permissions: unknown kubeAPI access %q", klausExtensionFile, ext.Permissions.KubeAPI)
+	}
+	return ext, nil
+}
+
 // ReadPersonalityFromDir reads a personality's metadata from its source
 // directory by parsing personality.yaml.
 //
@@ -67,6 +141,9 @@ func ReadPersonalityFromDir(dir string) (*Personality, error) {
 	if p.Name == "" {
 		return nil, fmt.Errorf("personality.yaml: name is required")
 	}
+	if err := p.Requirements.validate(); err != nil {
+		return nil, fmt.Errorf("personality.yaml: requirements: %w", err)
+	}
 
 	return &p, nil
 }
@@ -3,6 +3,7 @@ package oci
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -227,6 +228,7 @@ plugins:
     tag: latest
   - repository: gsoci.azurecr.io/giantswarm/klaus-plugins/gs-sre
     tag: v1.2.0
+extends: gs-base:v1.0.0
 `)
 
 	p, err := ReadPersonalityFromDir(dir)
@@ -258,6 +260,9 @@ plugins:
 	if p.Toolchain.Tag != "latest" {
 		t.Errorf("Toolchain.Tag = %q", p.Toolchain.Tag)
 	}
+	if p.Extends != "gs-base:v1.0.0" {
+		t.Errorf("Extends = %q", p.Extends)
+	}
 	if len(p.Plugins) != 2 {
 		t.Fatalf("Plugins length = %d, want 2", len(p.Plugins))
 	}
@@ -691,3 +696,108 @@ func mkdirAll(t *testing.T, path string) {
 		t.Fatalf("creating directory %s: %v", path, err)
 	}
 }
+
+func TestReadPluginFromDir_KlausDependencies(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".claude-plugin", "plugin.json"), `{"name":"gs-platform"}`)
+	writeFile(t, filepath.Join(dir, ".claude-plugin", "klaus.json"), `{
+  "dependencies": [
+    {"repository": "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base", "tag": "v1.0.0"},
+    {"repository": "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-kubernetes"}
+  ]
+}`)
+
+	plugin, err := ReadPluginFromDir(dir)
+	if err != nil {
+		t.Fatalf("ReadPluginFromDir() error = %v", err)
+	}
+	if len(plugin.Dependencies) != 2 {
+		t.Fatalf("Dependencies = %+v, want 2 entries", plugin.Dependencies)
+	}
+	if got := plugin.Dependencies[0].Ref(); got != "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base:v1.0.0" {
+		t.Errorf("Dependencies[0] = %q", got)
+	}
+}
+
+func TestReadPluginFromDir_KlausDependenciesOverridePluginJSON(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".claude-plugin", "plugin.json"),
+		`{"name":"gs-platform","dependencies":[{"repository":"example.com/a"}]}`)
+
+	plugin, err := ReadPluginFromDir(dir)
+	if err != nil {
+		t.Fatalf("ReadPluginFromDir() error = %v", err)
+	}
+	if len(plugin.Dependencies) != 1 || plugin.Dependencies[0].Repository != "example.com/a" {
+		t.Errorf("Dependencies = %+v, want plugin.json list", plugin.Dependencies)
+	}
+
+	writeFile(t, filepath.Join(dir, ".claude-plugin", "klaus.json"), `{"dependencies":[{"repository":"example.com/b"}]}`)
+	plugin, err = ReadPluginFromDir(dir)
+	if err != nil {
+		t.Fatalf("ReadPluginFromDir() error = %v", err)
+	}
+	if len(plugin.Dependencies) != 1 || plugin.Dependencies[0].Repository != "example.com/b" {
+		t.Errorf("Dependencies = %+v, want klaus.json list", plugin.Dependencies)
+	}
+}
+
+func TestReadPluginFromDir_KlausPrivate(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".claude-plugin", "plugin.json"), `{"name":"internal"}`)
+	writeFile(t, filepath.Join(dir, ".claude-plugin", "klaus.json"), `{"private":true}`)
+
+	plugin, err := ReadPluginFromDir(dir)
+	if err != nil {
+		t.Fatalf("ReadPluginFromDir() error = %v", err)
+	}
+	if !plugin.Private {
+		t.Error("Private = false, want true from klaus.json")
+	}
+	if got := plugin.Annotations()[AnnotationPrivate]; got != "true" {
+		t.Errorf("annotation %s = %q, want \"true\"", AnnotationPrivate, got)
+	}
+}
+
+func TestReadPluginFromDir_InvalidKlausJSON(t *testing.T) {
+	tests := map[string]string{
+		"invalid json":       `{`,
+		"missing repository": `{"dependencies":[{"tag":"v1.0.0"}]}`,
+		"invalid secret":     `{"secrets":[{"name":"GITHUB-TOKEN"}]}`,
+		"unknown mcp server": `{"secrets":[{"name":"TOKEN","mcpServer":"jira"}]}`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, ".claude-plugin", "plugin.json"), `{"name":"p"}`)
+			writeFile(t, filepath.Join(dir, ".claude-plugin", "klaus.json"), content)
+			if _, err := ReadPluginFromDir(dir); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestReadPluginFromDir_KlausSecrets(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".claude-plugin", "plugin.json"), `{"name":"gs-github"}`)
+	writeFile(t, filepath.Join(dir, ".mcp.json"), `{"github":{"command":"github-mcp","env":{"GITHUB_TOKEN":"${GITHUB_TOKEN}"}}}`)
+	writeFile(t, filepath.Join(dir, ".claude-plugin", "klaus.json"), `{
+  "secrets": [
+    {"name": "GITHUB_TOKEN", "description": "Token with repo scope", "mcpServer": "github"},
+    {"name": "GITHUB_ENTERPRISE_URL", "optional": true}
+  ]
+}`)
+
+	plugin, err := ReadPluginFromDir(dir)
+	if err != nil {
+		t.Fatalf("ReadPluginFromDir() error = %v", err)
+	}
+	want := []SecretRequirement{
+		{Name: "GITHUB_TOKEN", Description: "Token with repo scope", MCPServer: "github"},
+		{Name: "GITHUB_ENTERPRISE_URL", Optional: true},
+	}
+	if !reflect.DeepEqual(plugin.Secrets, want) {
+		t.Errorf("Secrets = %+v, want %+v", plugin.Secrets, want)
+	}
+}
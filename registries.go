@@ -2,11 +2,15 @@ package oci
 
 import "strings"
 
+// DefaultNamespace is the registry namespace of the Giant Swarm artifacts,
+// used unless WithDefaultNamespace sets another.
+const DefaultNamespace = "gsoci.azurecr.io/giantswarm"
+
 // Default OCI registry base paths for each Klaus artifact type.
 const (
-	DefaultPluginRegistry      = "gsoci.azurecr.io/giantswarm/klaus-plugins"
-	DefaultPersonalityRegistry = "gsoci.azurecr.io/giantswarm/klaus-personalities"
-	DefaultToolchainRegistry   = "gsoci.azurecr.io/giantswarm/klaus-toolchains"
+	DefaultPluginRegistry      = DefaultNamespace + "/klaus-plugins"
+	DefaultPersonalityRegistry = DefaultNamespace + "/klaus-personalities"
+	DefaultToolchainRegistry   = DefaultNamespace + "/klaus-toolchains"
 )
 
 // ToolchainRegistryRef returns the full registry reference for a toolchain
@@ -20,3 +24,70 @@ func ToolchainRegistryRef(name string) string {
 	}
 	return DefaultToolchainRegistry + "/" + name
 }
+
+// ToolchainLayout describes a registry that names toolchain images by
+// convention instead of keeping them under a dedicated path, such as
+// gsoci.azurecr.io/giantswarm/klaus-go next to the klaus-plugins and
+// klaus-personalities namespaces. Use it with WithToolchainLayout.
+type ToolchainLayout struct {
+	// Base is the namespace holding the images, e.g.
+	// "gsoci.azurecr.io/giantswarm".
+	Base string
+	// Prefix marks toolchain repositories below Base (e.g. "klaus-") and
+	// is stripped from their names.
+	Prefix string
+	// Exclude lists the paths below Base that hold other artifact kinds,
+	// e.g. "klaus-plugins". Their archive namespaces are excluded too.
+	Exclude []string
+}
+
+// DefaultToolchainLayout returns the layout of the Giant Swarm registry:
+// every giantswarm/klaus-* repository outside the plugin and personality
+// namespaces is a toolchain. Images in the dedicated klaus-toolchains
+// namespace are included, so both naming schemes list the same way.
+func DefaultToolchainLayout() ToolchainLayout {
+	return ToolchainLayout{
+		Base:    "gsoci.azurecr.io/giantswarm",
+		Prefix:  "klaus-",
+		Exclude: []string{"klaus-plugins", "klaus-personalities"},
+	}
+}
+
+// Contains reports whether repository (e.g.
+// "gsoci.azurecr.io/giantswarm/klaus-go") is a toolchain image in the
+// layout.
+func (l ToolchainLayout) Contains(repository string) bool {
+	return l.contains(l.Base, repository)
+}
+
+// contains is Contains for the layout moved to namespace base, which
+// differs from l.Base when listing the archive namespace.
+func (l ToolchainLayout) contains(base, repository string) bool {
+	rest, ok := strings.CutPrefix(repository, base+"/")
+	if !ok {
+		return false
+	}
+	first, _, _ := strings.Cut(rest, "/")
+	if !strings.HasPrefix(first, l.Prefix) || strings.HasSuffix(first, ArchiveSuffix) {
+		return false
+	}
+	for _, e := range l.Exclude {
+		if first == e {
+			return false
+		}
+	}
+	return true
+}
+
+// Name returns the toolchain name of repository: its last path element
+// without Prefix, so that both giantswarm/klaus-go and
+// giantswarm/klaus-toolchains/go are named "go".
+func (l ToolchainLayout) Name(repository string) string {
+	return strings.TrimPrefix(ShortName(repository), l.Prefix)
+}
+
+// Repository returns the repository of the toolchain named name, e.g.
+// "gsoci.azurecr.io/giantswarm/klaus-go" for "go".
+func (l ToolchainLayout) Repository(name string) string {
+	return l.Base + "/" + l.Prefix + name
+}
@@ -21,3 +21,32 @@ func TestToolchainRegistryRef(t *testing.T) {
 		})
 	}
 }
+
+func TestToolchainLayout(t *testing.T) {
+	l := DefaultToolchainLayout()
+	tests := []struct {
+		repository string
+		contains   bool
+		name       string
+	}{
+		{"gsoci.azurecr.io/giantswarm/klaus-go", true, "go"},
+		{"gsoci.azurecr.io/giantswarm/klaus-toolchains/go", true, "go"},
+		{"gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base", false, "gs-base"},
+		{"gsoci.azurecr.io/giantswarm/klaus-personalities/sre", false, "sre"},
+		{"gsoci.azurecr.io/giantswarm/klaus-toolchains-archive/go", false, "go"},
+		{"gsoci.azurecr.io/giantswarm/app-operator", false, "app-operator"},
+		{"gsoci.azurecr.io/other/klaus-go", false, "go"},
+		{"gsoci.azurecr.io/giantswarm-go", false, "giantswarm-go"},
+	}
+	for _, tt := range tests {
+		if got := l.Contains(tt.repository); got != tt.contains {
+			t.Errorf("Contains(%q) = %v, want %v", tt.repository, got, tt.contains)
+		}
+		if got := l.Name(tt.repository); got != tt.name {
+			t.Errorf("Name(%q) = %q, want %q", tt.repository, got, tt.name)
+		}
+	}
+	if got, want := l.Repository("go"), "gsoci.azurecr.io/giantswarm/klaus-go"; got != want {
+		t.Errorf("Repository() = %q, want %q", got, want)
+	}
+}
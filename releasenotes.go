@@ -0,0 +1,326 @@
+package oci
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ReleaseNotes describes what changed between two versions of an
+// artifact, as generated by GenerateReleaseNotes.
+type ReleaseNotes struct {
+	Kind Kind
+	// Name is the artifact name of the new version.
+	Name string
+	// Repository is the repository both versions belong to.
+	Repository string
+
+	OldVersion, NewVersion string
+	OldDigest, NewDigest   string
+	// Change classifies the version change: "major", "minor", "patch",
+	// "prerelease", or "downgrade". Empty when either version is not a
+	// semver tag or both are equal.
+	Change string
+
+	// OldRevision and NewRevision are the source revisions recorded in
+	// the org.opencontainers.image.revision annotation, if any, and
+	// Source the org.opencontainers.image.source of the new version.
+	OldRevision, NewRevision string
+	Source                   string
+
+	// Components lists the plugin components added and removed, by type.
+	Components []ComponentChange
+	// Plugins lists the plugin references a personality added, removed,
+	// or moved to another tag or digest.
+	Plugins []ReferenceChange
+	// Toolchain is set when a personality's toolchain reference changed.
+	Toolchain *ReferenceChange
+
+	// Changelogs holds the changelog layers of the versions after
+	// OldVersion up to NewVersion, newest first. For non-semver versions
+	// it holds the changelog of the new version only.
+	Changelogs []VersionChangelog
+}
+
+// ComponentChange lists the components of one type that a plugin version
+// added and removed.
+type ComponentChange struct {
+	Type    ComponentType
+	Added   []string
+	Removed []string
+}
+
+// ReferenceChange is a changed artifact reference. Old is empty for added
+// references and New for removed ones.
+type ReferenceChange struct {
+	Repository string
+	Old, New   string
+}
+
+// GenerateReleaseNotes compares two versions of the same artifact and
+// combines the version change, the component and composition changes, the
+// recorded source revisions, and the changelog layers (see WithChangelog)
+// in between. References without a tag resolve to the highest semver tag,
+// like Describe. Only manifests, config blobs, and changelog layers are
+// downloaded.
+func (c *Client) GenerateReleaseNotes(ctx context.Context, oldRef, newRef string) (*ReleaseNotes, error) {
+	oldFM, oldArtifact, err := c.describeForNotes(ctx, oldRef)
+	if err != nil {
+		return nil, err
+	}
+	newFM, newArtifact, err := c.describeForNotes(ctx, newRef)
+	if err != nil {
+		return nil, err
+	}
+
+	repository := newFM.repository()
+	if old := oldFM.repository(); old != repository {
+		return nil, fmt.Errorf("cannot compare artifacts of different repositories %s and %s", old, repository)
+	}
+	if oldArtifact.Kind != newArtifact.Kind {
+		return nil, fmt.Errorf("cannot compare a %s with a %s", oldArtifact.Kind, newArtifact.Kind)
+	}
+
+	notes := &ReleaseNotes{
+		Kind:        newArtifact.Kind,
+		Repository:  repository,
+		OldVersion:  oldFM.tag,
+		NewVersion:  newFM.tag,
+		OldDigest:   oldFM.digest,
+		NewDigest:   newFM.digest,
+		Change:      versionChange(oldFM.tag, newFM.tag),
+		OldRevision: oldFM.manifest.Annotations[ocispec.AnnotationRevision],
+		NewRevision: newFM.manifest.Annotations[ocispec.AnnotationRevision],
+		Source:      newFM.manifest.Annotations[ocispec.AnnotationSource],
+	}
+
+	switch notes.Kind {
+	case KindPlugin:
+		notes.Name = newArtifact.Plugin.Name
+		notes.Components = diffComponents(oldArtifact.Plugin.Plugin, newArtifact.Plugin.Plugin)
+	case KindPersonality:
+		oldP, newP := oldArtifact.Personality.Personality, newArtifact.Personality.Personality
+		notes.Name = newP.Name
+		notes.Plugins = diffPluginReferences(oldP.Plugins, newP.Plugins)
+		if oldTC, newTC := oldP.Toolchain, newP.Toolchain; oldTC != newTC {
+			notes.Toolchain = &ReferenceChange{Repository: newTC.Repository, Old: oldTC.Ref(), New: newTC.Ref()}
+			if newTC.Repository == "" {
+				notes.Toolchain.Repository = oldTC.Repository
+			}
+		}
+	case KindToolchain:
+		notes.Name = newArtifact.Toolchain.Name
+	}
+	if notes.Name == "" {
+		notes.Name = ShortName(repository)
+	}
+
+	if notes.Changelogs, err = c.changelogsBetween(ctx, repository, notes.OldVersion, notes.NewVersion); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// describeForNotes resolves and describes ref, keeping the fetched
+// manifest for its annotations.
+func (c *Client) describeForNotes(ctx context.Context, ref string) (*fetchedManifest, *DescribedArtifact, error) {
+	ref = strings.TrimSpace(ref)
+	if !strings.Contains(ref, "/") {
+		return nil, nil, fmt.Errorf("reference %q must be a fully-qualified OCI reference", ref)
+	}
+	resolved, err := resolveArtifactRef(ctx, c, ref, "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolving ref %q: %w", ref, err)
+	}
+	fm, err := c.fetchDescribeManifest(ctx, resolved)
+	if err != nil {
+		return nil, nil, err
+	}
+	described, err := c.describeManifest(ctx, fm, resolved)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fm, described, nil
+}
+
+// repository returns the "host/repository" of the fetched manifest.
+func (fm *fetchedManifest) repository() string {
+	return fm.repo.Reference.Registry + "/" + fm.repo.Reference.Repository
+}
+
+// changelogsBetween returns the changelogs of the versions after oldVersion
+// up to newVersion, or only that of newVersion when the versions are not
+// both semver.
+func (c *Client) changelogsBetween(ctx context.Context, repository, oldVersion, newVersion string) ([]VersionChangelog, error) {
+	newVer, err := semver.NewVersion(newVersion)
+	if _, oerr := semver.NewVersion(oldVersion); err != nil || oerr != nil {
+		log, err := c.FetchChangelog(ctx, repository+":"+newVersion)
+		if err != nil || log == "" {
+			return nil, err
+		}
+		return []VersionChangelog{{Version: newVersion, Changelog: log}}, nil
+	}
+
+	all, err := c.ChangelogsSince(ctx, repository, oldVersion)
+	if err != nil {
+		return nil, err
+	}
+	var logs []VersionChangelog
+	for _, l := range all {
+		if v, _ := semver.NewVersion(l.Version); l.Changelog != "" && !v.GreaterThan(newVer) {
+			logs = append(logs, l)
+		}
+	}
+	return logs, nil
+}
+
+// versionChange classifies the change from oldTag to newTag.
+func versionChange(oldTag, newTag string) string {
+	oldVer, err := semver.NewVersion(oldTag)
+	if err != nil {
+		return ""
+	}
+	newVer, err := semver.NewVersion(newTag)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case newVer.Equal(oldVer):
+		return ""
+	case newVer.LessThan(oldVer):
+		return "downgrade"
+	case newVer.Major() != oldVer.Major():
+		return "major"
+	case newVer.Minor() != oldVer.Minor():
+		return "minor"
+	case newVer.Patch() != oldVer.Patch():
+		return "patch"
+	}
+	return "prerelease"
+}
+
+// diffComponents lists the components added and removed from oldPlugin to
+// newPlugin, in component type order.
+func diffComponents(oldPlugin, newPlugin Plugin) []ComponentChange {
+	var changes []ComponentChange
+	for _, t := range componentTypes {
+		oldNames, newNames := oldPlugin.components(t), newPlugin.components(t)
+		change := ComponentChange{Type: t}
+		for _, name := range newNames {
+			if !slices.Contains(oldNames, name) {
+				change.Added = append(change.Added, name)
+			}
+		}
+		for _, name := range oldNames {
+			if !slices.Contains(newNames, name) {
+				change.Removed = append(change.Removed, name)
+			}
+		}
+		if len(change.Added) > 0 || len(change.Removed) > 0 {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// diffPluginReferences lists the plugin references that were added,
+// removed, or changed, in order of newRefs followed by removals.
+func diffPluginReferences(oldRefs, newRefs []PluginReference) []ReferenceChange {
+	oldByRepo := make(map[string]PluginReference, len(oldRefs))
+	for _, r := range oldRefs {
+		oldByRepo[r.Repository] = r
+	}
+	var changes []ReferenceChange
+	seen := make(map[string]bool, len(newRefs))
+	for _, r := range newRefs {
+		seen[r.Repository] = true
+		old, ok := oldByRepo[r.Repository]
+		switch {
+		case !ok:
+			changes = append(changes, ReferenceChange{Repository: r.Repository, New: r.Ref()})
+		case old != r:
+			changes = append(changes, ReferenceChange{Repository: r.Repository, Old: old.Ref(), New: r.Ref()})
+		}
+	}
+	for _, r := range oldRefs {
+		if !seen[r.Repository] {
+			changes = append(changes, ReferenceChange{Repository: r.Repository, Old: r.Ref()})
+		}
+	}
+	return changes
+}
+
+// Markdown renders the release notes as a markdown document.
+func (n *ReleaseNotes) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s %s → %s", n.Name, n.OldVersion, n.NewVersion)
+	if n.Change != "" {
+		fmt.Fprintf(&b, " (%s)", n.Change)
+	}
+	b.WriteString("\n")
+
+	var changes []string
+	for _, c := range n.Components {
+		if len(c.Added) > 0 {
+			changes = append(changes, fmt.Sprintf("Added %s: %s", c.Type, strings.Join(c.Added, ", ")))
+		}
+		if len(c.Removed) > 0 {
+			changes = append(changes, fmt.Sprintf("Removed %s: %s", c.Type, strings.Join(c.Removed, ", ")))
+		}
+	}
+	for _, p := range n.Plugins {
+		changes = append(changes, "Plugin "+p.describe())
+	}
+	if n.Toolchain != nil {
+		changes = append(changes, "Toolchain "+n.Toolchain.describe())
+	}
+	if n.OldRevision != "" || n.NewRevision != "" {
+		rev := fmt.Sprintf("Revision %s → %s", orNone(n.OldRevision), orNone(n.NewRevision))
+		if n.Source != "" {
+			rev += " (" + n.Source + ")"
+		}
+		changes = append(changes, rev)
+	}
+	if len(changes) > 0 {
+		b.WriteString("\n## Changes\n\n")
+		for _, line := range changes {
+			b.WriteString("- " + line + "\n")
+		}
+	}
+
+	for _, l := range n.Changelogs {
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n", l.Version, strings.TrimSpace(l.Changelog))
+	}
+	return b.String()
+}
+
+func (r ReferenceChange) describe() string {
+	name := ShortName(r.Repository)
+	switch {
+	case r.Old == "":
+		return fmt.Sprintf("%s added (%s)", name, r.New)
+	case r.New == "":
+		return fmt.Sprintf("%s removed", name)
+	}
+	return fmt.Sprintf("%s %s → %s", name, refVersion(r.Old), refVersion(r.New))
+}
+
+// refVersion returns the tag or shortened digest of ref for display.
+func refVersion(ref string) string {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		return TruncateDigest(ref[i+1:])
+	}
+	_, tag := SplitNameTag(ref)
+	return orNone(tag)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
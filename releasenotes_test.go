@@ -0,0 +1,136 @@
+package oci
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGenerateReleaseNotes_Plugin(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	repository := host + "/klaus-plugins/gs-base"
+
+	push := func(version string, skills []string, changelog string) {
+		t.Helper()
+		src := t.TempDir()
+		writeFile(t, filepath.Join(src, ".claude-plugin", "plugin.json"), `{"name":"gs-base"}`)
+		for _, s := range skills {
+			writeFile(t, filepath.Join(src, "skills", s, "SKILL.md"), "# "+s)
+		}
+		p, err := ReadPluginFromDir(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.PushPlugin(t.Context(), src, repository+":"+version, *p, WithChangelog(changelog)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	push("v1.0.0", []string{"k8s", "flux"}, "Initial release")
+	push("v1.1.0", []string{"k8s", "helm"}, "Add helm")
+	push("v1.2.0", []string{"k8s", "helm"}, "")
+	push("v1.3.0", []string{"k8s"}, "Not yet released")
+
+	notes, err := client.GenerateReleaseNotes(t.Context(), repository+":v1.0.0", repository+":v1.2.0")
+	if err != nil {
+		t.Fatalf("GenerateReleaseNotes() error = %v", err)
+	}
+	if notes.Kind != KindPlugin || notes.Name != "gs-base" || notes.Change != "minor" {
+		t.Errorf("notes = %+v", notes)
+	}
+	want := []ComponentChange{{Type: ComponentSkill, Added: []string{"helm"}, Removed: []string{"flux"}}}
+	if len(notes.Components) != 1 || notes.Components[0].Type != want[0].Type ||
+		!slices.Equal(notes.Components[0].Added, want[0].Added) || !slices.Equal(notes.Components[0].Removed, want[0].Removed) {
+		t.Errorf("Components = %+v, want %+v", notes.Components, want)
+	}
+	if len(notes.Changelogs) != 1 || notes.Changelogs[0] != (VersionChangelog{Version: "v1.1.0", Changelog: "Add helm"}) {
+		t.Errorf("Changelogs = %+v, want only v1.1.0", notes.Changelogs)
+	}
+
+	md := notes.Markdown()
+	for _, s := range []string{"# gs-base v1.0.0 → v1.2.0 (minor)", "- Added skill: helm", "- Removed skill: flux", "## v1.1.0\n\nAdd helm"} {
+		if !strings.Contains(md, s) {
+			t.Errorf("Markdown() missing %q:\n%s", s, md)
+		}
+	}
+
+	if _, err := client.GenerateReleaseNotes(t.Context(), repository+":v1.0.0", host+"/klaus-plugins/other:v1.0.0"); err == nil {
+		t.Error("GenerateReleaseNotes() across repositories succeeded")
+	}
+}
+
+func TestGenerateReleaseNotes_Personality(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	repository := host + "/klaus-personalities/sre"
+
+	push := func(version string, p Personality) {
+		t.Helper()
+		src := t.TempDir()
+		writeFile(t, filepath.Join(src, "SOUL.md"), "Be calm.")
+		if _, err := client.PushPersonality(t.Context(), src, repository+":"+version, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	push("v1.0.0", Personality{
+		Name:      "sre",
+		Toolchain: ToolchainReference{Repository: "example.com/klaus-toolchains/go", Tag: "v1.0.0"},
+		Plugins: []PluginReference{
+			{Repository: "example.com/klaus-plugins/gs-base", Tag: "v1.0.0"},
+			{Repository: "example.com/klaus-plugins/gs-flux", Tag: "v1.0.0"},
+		},
+	})
+	push("v2.0.0", Personality{
+		Name:      "sre",
+		Toolchain: ToolchainReference{Repository: "example.com/klaus-toolchains/go", Tag: "v2.0.0"},
+		Plugins: []PluginReference{
+			{Repository: "example.com/klaus-plugins/gs-base", Digest: "sha256:0123456789abcdef0123"},
+			{Repository: "example.com/klaus-plugins/gs-helm", Tag: "v1.0.0"},
+		},
+	})
+
+	notes, err := client.GenerateReleaseNotes(t.Context(), repository+":v1.0.0", repository+":v2.0.0")
+	if err != nil {
+		t.Fatalf("GenerateReleaseNotes() error = %v", err)
+	}
+	if notes.Change != "major" || notes.Toolchain == nil || notes.Toolchain.New != "example.com/klaus-toolchains/go:v2.0.0" {
+		t.Errorf("notes = %+v, toolchain = %+v", notes, notes.Toolchain)
+	}
+	if len(notes.Plugins) != 3 {
+		t.Fatalf("Plugins = %+v, want 3 changes", notes.Plugins)
+	}
+
+	md := notes.Markdown()
+	for _, s := range []string{
+		"- Plugin gs-base v1.0.0 → sha256:0123456789ab",
+		"- Plugin gs-helm added (example.com/klaus-plugins/gs-helm:v1.0.0)",
+		"- Plugin gs-flux removed",
+		"- Toolchain go v1.0.0 → v2.0.0",
+	} {
+		if !strings.Contains(md, s) {
+			t.Errorf("Markdown() missing %q:\n%s", s, md)
+		}
+	}
+}
+
+func TestVersionChange(t *testing.T) {
+	tests := []struct {
+		old, new, want string
+	}{
+		{"v1.0.0", "v2.0.0", "major"},
+		{"v1.0.0", "v1.1.0", "minor"},
+		{"v1.0.0", "v1.0.1", "patch"},
+		{"v1.0.0-rc.1", "v1.0.0-rc.2", "prerelease"},
+		{"v1.1.0", "v1.0.0", "downgrade"},
+		{"v1.0.0", "v1.0.0", ""},
+		{"latest", "v1.0.0", ""},
+	}
+	for _, tt := range tests {
+		if got := versionChange(tt.old, tt.new); got != tt.want {
+			t.Errorf("versionChange(%s, %s) = %q, want %q", tt.old, tt.new, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package oci
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Requirements describes what a personality needs from its runtime
+// environment. The operator translates them into pod resources and
+// preflight checks; this package only carries and validates them.
+//
+//	requirements:
+//	  cpu: 500m
+//	  memory: 1Gi
+//	  gpu: 1
+//	  egress:
+//	    - api.github.com
+//	  secrets:
+//	    - name: GITHUB_TOKEN
+//	      mcpServer: github
+//	      description: Token with repo scope
+type Requirements struct {
+	// CPU is a Kubernetes quantity hint for the CPU request, e.g. "500m".
+	CPU string `yaml:"cpu,omitempty" json:"cpu,omitempty"`
+	// Memory is a Kubernetes quantity hint for the memory request, e.g. "1Gi".
+	Memory string `yaml:"memory,omitempty" json:"memory,omitempty"`
+	// GPU is the number of GPUs the personality needs.
+	GPU int `yaml:"gpu,omitempty" json:"gpu,omitempty"`
+	// Egress lists the hosts the personality must reach over the network.
+	Egress []string `yaml:"egress,omitempty" json:"egress,omitempty"`
+	// Secrets lists the secrets that must be provided at runtime.
+	Secrets []SecretRequirement `yaml:"secrets,omitempty" json:"secrets,omitempty"`
+}
+
+// SecretRequirement describes a secret a personality or plugin expects,
+// typically an environment variable consumed by an MCP server.
+type SecretRequirement struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	// MCPServer names the MCP server that consumes the secret, if any.
+	MCPServer string `yaml:"mcpServer,omitempty" json:"mcpServer,omitempty"`
+	// Optional secrets may be omitted; the consuming feature is disabled.
+	Optional bool `yaml:"optional,omitempty" json:"optional,omitempty"`
+}
+
+// quantityPattern matches the Kubernetes resource quantity syntax closely
+// enough to catch typos such as "1GB" without depending on apimachinery.
+var quantityPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?(m|k|M|G|T|P|E|Ki|Mi|Gi|Ti|Pi|Ei)?$`)
+
+// envVarPattern matches valid environment variable names.
+var envVarPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validate checks r for obviously invalid values. A nil r is valid.
+func (r *Requirements) validate() error {
+	if r == nil {
+		return nil
+	}
+	var errs []error
+	if r.CPU != "" && !quantityPattern.MatchString(r.CPU) {
+		errs = append(errs, fmt.Errorf("cpu %q is not a valid quantity", r.CPU))
+	}
+	if r.Memory != "" && !quantityPattern.MatchString(r.Memory) {
+		errs = append(errs, fmt.Errorf("memory %q is not a valid quantity", r.Memory))
+	}
+	if r.GPU < 0 {
+		errs = append(errs, fmt.Errorf("gpu must not be negative, got %d", r.GPU))
+	}
+	seen := make(map[string]bool)
+	for i, s := range r.Secrets {
+		switch {
+		case s.Name == "":
+			errs = append(errs, fmt.Errorf("secrets[%d]: name is required", i))
+		case seen[s.Name]:
+			errs = append(errs, fmt.Errorf("secrets[%d]: duplicate secret %s", i, s.Name))
+		}
+		seen[s.Name] = true
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,88 @@
+package oci
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRequirements_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       *Requirements
+		wantErr string
+	}{
+		{name: "nil"},
+		{name: "valid", r: &Requirements{CPU: "500m", Memory: "1.5Gi", GPU: 1, Secrets: []SecretRequirement{{Name: "TOKEN"}}}},
+		{name: "bad cpu", r: &Requirements{CPU: "half"}, wantErr: "cpu"},
+		{name: "bad memory", r: &Requirements{Memory: "1GB"}, wantErr: "memory"},
+		{name: "negative gpu", r: &Requirements{GPU: -1}, wantErr: "gpu"},
+		{name: "unnamed secret", r: &Requirements{Secrets: []SecretRequirement{{MCPServer: "github"}}}, wantErr: "name is required"},
+		{name: "duplicate secret", r: &Requirements{Secrets: []SecretRequirement{{Name: "A"}, {Name: "A"}}}, wantErr: "duplicate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.r.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadPersonalityFromDir_Requirements(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "personality.yaml"), `
+name: sre
+requirements:
+  cpu: 500m
+  memory: 1Gi
+  egress: [api.github.com]
+  secrets:
+    - name: GITHUB_TOKEN
+      mcpServer: github
+`)
+	p, err := ReadPersonalityFromDir(dir)
+	if err != nil {
+		t.Fatalf("ReadPersonalityFromDir() error = %v", err)
+	}
+	want := &Requirements{
+		CPU:     "500m",
+		Memory:  "1Gi",
+		Egress:  []string{"api.github.com"},
+		Secrets: []SecretRequirement{{Name: "GITHUB_TOKEN", MCPServer: "github"}},
+	}
+	if !reflect.DeepEqual(p.Requirements, want) {
+		t.Errorf("Requirements = %+v, want %+v", p.Requirements, want)
+	}
+
+	writeFile(t, filepath.Join(dir, "personality.yaml"), "name: sre\nrequirements:\n  memory: 1GB\n")
+	if _, err := ReadPersonalityFromDir(dir); err == nil {
+		t.Error("expected error for invalid memory quantity")
+	}
+}
+
+func TestDescribePersonality_Requirements(t *testing.T) {
+	want := &Requirements{GPU: 1, Secrets: []SecretRequirement{{Name: "OPENAI_API_KEY", Optional: true}}}
+	ts := newArtifactRegistry(map[string]testArtifactEntry{
+		"klaus-personalities/ml": personalityEntry(t, "ml", "", personalityConfigBlob{Requirements: want}),
+	})
+	defer ts.Close()
+	host := testRegistryHost(ts)
+
+	client := NewClient(WithPlainHTTP(true))
+	desc, err := client.DescribePersonality(t.Context(), host+"/klaus-personalities/ml:v1.0.0")
+	if err != nil {
+		t.Fatalf("DescribePersonality() error = %v", err)
+	}
+	if !reflect.DeepEqual(desc.Requirements, want) {
+		t.Errorf("Requirements = %+v, want %+v", desc.Requirements, want)
+	}
+}
@@ -2,8 +2,12 @@ package oci
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
 )
 
 // tagLister can list tags for an OCI repository. Declared as an interface to
@@ -15,23 +19,41 @@ type tagLister interface {
 // ResolveLatestVersion lists tags for a repository and returns the full
 // reference with the highest semver tag (e.g. "repo:v1.2.3").
 func (c *Client) ResolveLatestVersion(ctx context.Context, repository string) (string, error) {
-	return resolveLatestSemver(ctx, c, repository)
+	return c.resolveLatestVersion(ctx, c, repository, "")
+}
+
+// resolveLatestVersion is ResolveLatestVersion listing tags with lister.
+// discovery, if set, describes in the resolution trace how lister
+// discovers the tags.
+func (c *Client) resolveLatestVersion(ctx context.Context, lister tagLister, repository, discovery string) (resolved string, err error) {
+	res := startResolution(ctx, repository)
+	defer func() { res.finish(ctx, resolved, err) }()
+	if discovery != "" {
+		res.decide("%s", discovery)
+	}
+	return resolveLatestSemver(ctx, lister, repository, res)
 }
 
 // ResolveToolchainRef resolves a toolchain short name or OCI reference to a
 // fully-qualified reference with its latest semver tag.
 // Short names (e.g. "go") are expanded using the default toolchain registry
-// (e.g. "gsoci.azurecr.io/giantswarm/klaus-toolchains/go:v1.0.0").
+// (e.g. "gsoci.azurecr.io/giantswarm/klaus-toolchains/go:v1.0.0"), or that
+// of the namespace set with WithDefaultNamespace.
 func (c *Client) ResolveToolchainRef(ctx context.Context, ref string) (string, error) {
-	return resolveArtifactRef(ctx, c, ref, DefaultToolchainRegistry)
+	base, ref := c.expandTenant(ctx, KindToolchain, ref)
+	return c.resolveWithSuggestions(ctx, c, ref, base)
 }
 
 // ResolvePluginRef resolves a plugin short name or OCI reference to a
 // fully-qualified reference with its latest semver tag.
 // Short names (e.g. "gs-ae") are expanded using the default plugin registry
 // (e.g. "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-ae:v0.0.3").
+// When a short name matches no repository, the error is an
+// *ErrUnknownArtifact suggesting similar names. The same holds for
+// ResolveToolchainRef and ResolvePersonalityRef.
 func (c *Client) ResolvePluginRef(ctx context.Context, ref string) (string, error) {
-	return resolveArtifactRef(ctx, c, ref, DefaultPluginRegistry)
+	base, ref := c.expandTenant(ctx, KindPlugin, ref)
+	return c.resolveWithSuggestions(ctx, c, ref, base)
 }
 
 // ResolvePersonalityRef resolves a personality short name or OCI reference to a
@@ -39,10 +61,87 @@ func (c *Client) ResolvePluginRef(ctx context.Context, ref string) (string, erro
 // Short names (e.g. "sre") are expanded using the default personality registry
 // (e.g. "gsoci.azurecr.io/giantswarm/klaus-personalities/sre:v0.2.0").
 func (c *Client) ResolvePersonalityRef(ctx context.Context, ref string) (string, error) {
-	return resolveArtifactRef(ctx, c, ref, DefaultPersonalityRegistry)
+	base, ref := c.expandTenant(ctx, KindPersonality, ref)
+	return c.resolveWithSuggestions(ctx, c, ref, base)
+}
+
+// ResolveRefs resolves many short names or OCI references of one kind at
+// once, like ResolvePluginRef and its siblings, and returns the resolved
+// reference of each input. Inputs are deduplicated, tags are listed once
+// per repository however many inputs name it, and inputs are resolved
+// concurrently, bounded by the client's concurrency limit. Inputs that
+// fail to resolve are missing from the map; their errors are joined in
+// the returned error.
+func (c *Client) ResolveRefs(ctx context.Context, refs []string, kind Kind) (map[string]string, error) {
+	if _, ok := kindRepositories[kind]; !ok {
+		return nil, fmt.Errorf("unknown artifact kind %q", kind)
+	}
+
+	lister := &onceTagLister{lister: c}
+	var (
+		mu       sync.Mutex
+		resolved = make(map[string]string, len(refs))
+		errs     []error
+	)
+	var g errgroup.Group
+	g.SetLimit(c.concurrency)
+	seen := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		g.Go(func() error {
+			base, short := c.expandTenant(ctx, kind, ref)
+			r, err := c.resolveWithSuggestions(ctx, lister, short, base)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("resolving %q: %w", ref, err))
+				return nil
+			}
+			resolved[ref] = r
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return resolved, errors.Join(errs...)
+}
+
+// onceTagLister lists the tags of each repository at most once, sharing
+// the result between concurrent callers.
+type onceTagLister struct {
+	lister tagLister
+	mu     sync.Mutex
+	lists  map[string]*tagList
+}
+
+type tagList struct {
+	once sync.Once
+	tags []string
+	err  error
 }
 
-func resolveArtifactRef(ctx context.Context, lister tagLister, ref, registryBase string) (string, error) {
+func (l *onceTagLister) List(ctx context.Context, repository string) ([]string, error) {
+	l.mu.Lock()
+	if l.lists == nil {
+		l.lists = make(map[string]*tagList)
+	}
+	list, ok := l.lists[repository]
+	if !ok {
+		list = &tagList{}
+		l.lists[repository] = list
+	}
+	l.mu.Unlock()
+
+	list.once.Do(func() { list.tags, list.err = l.lister.List(ctx, repository) })
+	return list.tags, list.err
+}
+
+func resolveArtifactRef(ctx context.Context, lister tagLister, ref, registryBase string) (resolved string, err error) {
+	res := startResolution(ctx, ref)
+	defer func() { res.finish(ctx, resolved, err) }()
+
 	ref = strings.TrimSpace(ref)
 	if ref == "" {
 		return "", fmt.Errorf("empty artifact reference")
@@ -50,44 +149,53 @@ func resolveArtifactRef(ctx context.Context, lister tagLister, ref, registryBase
 
 	if strings.Contains(ref, "/") {
 		if !hasTagOrDigest(ref) {
-			return resolveLatestSemver(ctx, lister, ref)
+			res.decide("no tag given; resolving the highest semver tag")
+			return resolveLatestSemver(ctx, lister, ref, res)
 		}
+		repo := RepositoryFromRef(ref)
 		if hasDigest(ref) {
+			res.given(repo, digestFromRef(ref), "digest")
 			return ref, nil
 		}
 		tag := extractTag(ref)
 		if tag != "latest" {
+			res.given(repo, tag, "tag")
 			return ref, nil
 		}
-		repo := RepositoryFromRef(ref)
-		return resolveLatestSemver(ctx, lister, repo)
+		res.decide("tag latest is resolved to the highest semver tag")
+		return resolveLatestSemver(ctx, lister, repo, res)
 	}
 
 	name, tag := SplitNameTag(ref)
 	fullRepo := registryBase + "/" + name
+	res.expand(name, registryBase)
 
 	if tag != "" && tag != "latest" {
+		res.given(fullRepo, tag, "tag")
 		return fullRepo + ":" + tag, nil
 	}
 
-	return resolveLatestSemver(ctx, lister, fullRepo)
+	return resolveLatestSemver(ctx, lister, fullRepo, res)
 }
 
-func resolveLatestSemver(ctx context.Context, lister tagLister, repo string) (string, error) {
-	tag, err := resolveLatestTagForRepo(ctx, lister, repo)
+// resolveLatestSemver returns repo tagged with its highest semver tag,
+// recording the choice in res.
+func resolveLatestSemver(ctx context.Context, lister tagLister, repo string, res *Resolution) (string, error) {
+	tag, err := resolveLatestTagForRepo(ctx, lister, repo, res)
 	if err != nil {
 		return "", err
 	}
 	return repo + ":" + tag, nil
 }
 
-func resolveLatestTagForRepo(ctx context.Context, lister tagLister, repo string) (string, error) {
+func resolveLatestTagForRepo(ctx context.Context, lister tagLister, repo string, res *Resolution) (string, error) {
 	tags, err := lister.List(ctx, repo)
 	if err != nil {
 		return "", fmt.Errorf("listing tags for %s: %w", repo, err)
 	}
 
 	latest := LatestSemverTag(tags)
+	res.chooseTag(repo, tags, latest)
 	if latest == "" {
 		return "", fmt.Errorf("no semver tags found for %s", repo)
 	}
@@ -9,24 +9,43 @@ import (
 )
 
 // ResolvePersonalityDeps resolves a personality's toolchain and plugin
-// references by describing each dependency from the registry. The toolchain
-// and all plugins are resolved concurrently, bounded by the client's
-// concurrency limit.
+// references by describing each dependency from the registry. Plugin
+// dependencies declared by the plugins themselves are followed
+// transitively, level by level, and each plugin repository is included at
+// most once. The toolchain and the plugins of each level are resolved
+// concurrently, bounded by the client's concurrency limit.
+//
+// When two references name the same repository with different tags or
+// digests, the one closer to the personality wins and the other is
+// reported in Conflicts (and as a warning). A reference without a tag or
+// digest is compatible with any version.
 //
 // Missing or unreachable artifacts produce warnings rather than hard failures,
 // allowing callers to present partial results (e.g. "plugin gs-sre: not found
 // in registry").
+//
+// When the toolchain and every plugin, including transitive dependencies,
+// are pinned by digest, the result cannot change and is cached: in memory,
+// and with WithCache also on disk, keyed by the client options and the
+// credentials in use. Repeated resolutions of the same pinned personality
+// return the cached result, with Cached set, after only checking each
+// dependency's quarantine state. Results with warnings are never cached.
 func (c *Client) ResolvePersonalityDeps(ctx context.Context, p Personality) (*ResolvedDependencies, error) {
-	result := &ResolvedDependencies{}
+	key := c.depsCacheKey(ctx, p)
+	if key != "" {
+		if result, ok := c.cachedDeps(key); ok && c.refreshQuarantine(ctx, result) == nil {
+			return result, nil
+		}
+	}
 
-	g, ctx := errgroup.WithContext(ctx)
-	g.SetLimit(c.concurrency)
+	result := &ResolvedDependencies{}
 
+	g, gctx := errgroup.WithContext(ctx)
 	var mu sync.Mutex
 
 	if p.Toolchain.Repository != "" {
 		g.Go(func() error {
-			tc, err := c.DescribeToolchain(ctx, p.Toolchain.Ref())
+			tc, err := c.DescribeToolchain(gctx, p.Toolchain.Ref())
 			mu.Lock()
 			defer mu.Unlock()
 			if err != nil {
@@ -39,32 +58,118 @@ func (c *Client) ResolvePersonalityDeps(ctx context.Context, p Personality) (*Re
 		})
 	}
 
-	plugins := make([]DescribedPlugin, len(p.Plugins))
-	for i, pRef := range p.Plugins {
-		g.Go(func() error {
-			dp, err := c.DescribePlugin(ctx, pRef.Ref())
-			mu.Lock()
-			defer mu.Unlock()
-			if err != nil {
-				result.Warnings = append(result.Warnings,
-					fmt.Sprintf("plugin %s: %v", pRef.Ref(), err))
-				return nil
-			}
-			plugins[i] = *dp
-			return nil
-		})
-	}
+	g.Go(func() error {
+		return c.resolvePluginDeps(gctx, p.Plugins, result, &mu)
+	})
 
 	if err := g.Wait(); err != nil {
 		return nil, err
 	}
+	if key != "" && cacheableDeps(result) {
+		c.storeDeps(key, result)
+	}
+	return result, nil
+}
+
+// pluginRequest is a plugin reference waiting to be described, together
+// with the plugin that declared it (empty for the personality itself).
+type pluginRequest struct {
+	ref        PluginReference
+	requiredBy string
+}
+
+// resolvePluginDeps describes plugins breadth-first, appending them and any
+// conflicts or warnings to result under mu.
+func (c *Client) resolvePluginDeps(ctx context.Context, direct []PluginReference, result *ResolvedDependencies, mu *sync.Mutex) error {
+	selected := make(map[string]PluginReference)
+
+	level := make([]pluginRequest, len(direct))
+	for i, ref := range direct {
+		level[i] = pluginRequest{ref: ref}
+	}
 
-	// Collect only the successfully resolved plugins (non-zero entries).
-	for _, dp := range plugins {
-		if dp.Plugin.Name != "" || dp.ArtifactInfo.Ref != "" {
-			result.Plugins = append(result.Plugins, dp)
+	for len(level) > 0 {
+		var batch []pluginRequest
+		for _, req := range level {
+			prev, ok := selected[req.ref.Repository]
+			if !ok {
+				selected[req.ref.Repository] = req.ref
+				batch = append(batch, req)
+				continue
+			}
+			if pluginRefsConflict(prev, req.ref) {
+				conflict := DependencyConflict{
+					Repository: req.ref.Repository,
+					Selected:   prev.Ref(),
+					Requested:  req.ref.Ref(),
+					RequiredBy: req.requiredBy,
+				}
+				mu.Lock()
+				result.Conflicts = append(result.Conflicts, conflict)
+				result.Warnings = append(result.Warnings, fmt.Sprintf(
+					"plugin %s: version conflict, %s requires %s", conflict.Selected, conflictSource(req.requiredBy), conflict.Requested))
+				mu.Unlock()
+			}
+		}
+
+		described := make([]*DescribedPlugin, len(batch))
+		g, gctx := errgroup.WithContext(ctx)
+		g.SetLimit(c.concurrency)
+		for i, req := range batch {
+			g.Go(func() error {
+				dp, err := c.DescribePlugin(gctx, req.ref.Ref())
+				if err != nil {
+					mu.Lock()
+					defer mu.Unlock()
+					if req.requiredBy != "" {
+						result.Warnings = append(result.Warnings,
+							fmt.Sprintf("plugin %s (required by %s): %v", req.ref.Ref(), req.requiredBy, err))
+					} else {
+						result.Warnings = append(result.Warnings,
+							fmt.Sprintf("plugin %s: %v", req.ref.Ref(), err))
+					}
+					return nil
+				}
+				described[i] = dp
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return err
+		}
+
+		level = nil
+		for _, dp := range described {
+			if dp == nil {
+				continue
+			}
+			mu.Lock()
+			result.Plugins = append(result.Plugins, *dp)
+			mu.Unlock()
+			for _, dep := range dp.Dependencies {
+				level = append(level, pluginRequest{ref: dep, requiredBy: dp.Name})
+			}
 		}
 	}
+	return nil
+}
 
-	return result, nil
+// pluginRefsConflict reports whether two references to the same repository
+// pin different versions. Unpinned references are compatible with any
+// version.
+func pluginRefsConflict(a, b PluginReference) bool {
+	if a.Digest != "" && b.Digest != "" {
+		return a.Digest != b.Digest
+	}
+	if a.Digest != "" || b.Digest != "" {
+		return false
+	}
+	return a.Tag != "" && b.Tag != "" && a.Tag != b.Tag
+}
+
+func conflictSource(requiredBy string) string {
+	if requiredBy == "" {
+		return "the personality"
+	}
+	return requiredBy
 }
@@ -2,6 +2,7 @@ package oci
 
 import (
 	"encoding/json"
+	"net/http/httptest"
 	"slices"
 	"testing"
 
@@ -466,3 +467,89 @@ func TestResolvePersonalityDeps_ToolchainVersionFromTag(t *testing.T) {
 		t.Errorf("Toolchain.Name = %q, want %q", deps.Toolchain.Toolchain.Name, "go")
 	}
 }
+
+func TestResolvePersonalityDeps_TransitivePluginDeps(t *testing.T) {
+	ts := httptest.NewUnstartedServer(nil)
+	host := ts.Listener.Addr().String()
+
+	repo := func(name string) string { return host + "/giantswarm/klaus-plugins/" + name }
+	pluginEntry := func(name, tag string, deps ...PluginReference) testArtifactEntry {
+		blob, _ := json.Marshal(pluginConfigBlob{Dependencies: deps})
+		return testArtifactEntry{
+			configJSON:      blob,
+			configMediaType: MediaTypePluginConfig,
+			tags:            []string{tag},
+			annotations:     buildKlausAnnotations(commonMetadata{Name: name}),
+		}
+	}
+
+	artifacts := map[string]testArtifactEntry{
+		// gs-platform -> gs-kubernetes -> gs-base (cycle back to gs-platform)
+		"giantswarm/klaus-plugins/gs-platform": pluginEntry("gs-platform", "v1.0.0",
+			PluginReference{Repository: repo("gs-kubernetes"), Tag: "v0.3.0"},
+			PluginReference{Repository: repo("gs-base"), Tag: "v1.0.0"},
+		),
+		"giantswarm/klaus-plugins/gs-kubernetes": pluginEntry("gs-kubernetes", "v0.3.0",
+			PluginReference{Repository: repo("gs-base"), Tag: "v2.0.0"},
+			PluginReference{Repository: repo("gs-platform")},
+			PluginReference{Repository: repo("gs-missing"), Tag: "v1.0.0"},
+		),
+		"giantswarm/klaus-plugins/gs-base": pluginEntry("gs-base", "v1.0.0"),
+	}
+
+	ts.Config.Handler = newArtifactHandler(artifacts)
+	ts.Start()
+	defer ts.Close()
+
+	client := NewClient(WithPlainHTTP(true))
+	deps, err := client.ResolvePersonalityDeps(t.Context(), Personality{
+		Name:    "platform",
+		Plugins: []PluginReference{{Repository: repo("gs-platform"), Tag: "v1.0.0"}},
+	})
+	if err != nil {
+		t.Fatalf("ResolvePersonalityDeps() error = %v", err)
+	}
+
+	var names []string
+	for _, p := range deps.Plugins {
+		names = append(names, p.Name+"@"+p.Version)
+	}
+	want := []string{"gs-platform@v1.0.0", "gs-kubernetes@v0.3.0", "gs-base@v1.0.0"}
+	if !slices.Equal(names, want) {
+		t.Errorf("Plugins = %v, want %v", names, want)
+	}
+
+	if len(deps.Conflicts) != 1 {
+		t.Fatalf("Conflicts = %+v, want 1", deps.Conflicts)
+	}
+	c := deps.Conflicts[0]
+	if c.Repository != repo("gs-base") || c.Selected != repo("gs-base")+":v1.0.0" ||
+		c.Requested != repo("gs-base")+":v2.0.0" || c.RequiredBy != "gs-kubernetes" {
+		t.Errorf("Conflict = %+v", c)
+	}
+
+	if len(deps.Warnings) != 2 {
+		t.Errorf("Warnings = %v, want conflict and missing plugin", deps.Warnings)
+	}
+}
+
+func TestPluginRefsConflict(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b PluginReference
+		want bool
+	}{
+		{"same tag", PluginReference{Tag: "v1"}, PluginReference{Tag: "v1"}, false},
+		{"different tags", PluginReference{Tag: "v1"}, PluginReference{Tag: "v2"}, true},
+		{"unpinned", PluginReference{}, PluginReference{Tag: "v2"}, false},
+		{"different digests", PluginReference{Digest: "sha256:a"}, PluginReference{Digest: "sha256:b"}, true},
+		{"digest and tag", PluginReference{Digest: "sha256:a"}, PluginReference{Tag: "v1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pluginRefsConflict(tt.a, tt.b); got != tt.want {
+				t.Errorf("pluginRefsConflict() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
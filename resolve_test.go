@@ -3,6 +3,8 @@ package oci
 import (
 	"context"
 	"fmt"
+	"maps"
+	"strings"
 	"testing"
 )
 
@@ -281,3 +283,38 @@ func TestResolvePersonalityRef(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveRefs(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	repository := host + "/klaus-personalities/sre"
+	pushVersions(t, client, repository, "v1.0.0", "v1.1.0")
+
+	before := reg.tagsCount.Load()
+	got, err := client.ResolveRefs(t.Context(), []string{
+		repository,
+		repository + ":latest",
+		repository,
+		repository + ":v1.0.0",
+		host + "/klaus-personalities/missing",
+	}, KindPersonality)
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("ResolveRefs() error = %v, want error for missing", err)
+	}
+	want := map[string]string{
+		repository:             repository + ":v1.1.0",
+		repository + ":latest": repository + ":v1.1.0",
+		repository + ":v1.0.0": repository + ":v1.0.0",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("ResolveRefs() = %v, want %v", got, want)
+	}
+	if n := reg.tagsCount.Load() - before; n != 2 {
+		t.Errorf("ResolveRefs() listed tags %d times, want once per repository (2)", n)
+	}
+
+	if _, err := client.ResolveRefs(t.Context(), []string{"sre"}, Kind("widget")); err == nil {
+		t.Error("ResolveRefs() with an unknown kind succeeded")
+	}
+}
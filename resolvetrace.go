@@ -0,0 +1,176 @@
+package oci
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// ResolutionTrace records how references were resolved to versions, to
+// explain why a resolution picked the version it did. Attach a trace to a
+// context with WithResolutionTrace; every resolution made with that
+// context, including those inside Describe*, Pull*, and dependency
+// resolution, appends a Resolution. A trace is safe for concurrent use.
+type ResolutionTrace struct {
+	mu          sync.Mutex
+	resolutions []Resolution
+}
+
+// Resolution is the record of one reference resolution.
+type Resolution struct {
+	// Input is the reference as given.
+	Input string
+	// RegistryBase is the registry base a short name was expanded with;
+	// empty for fully-qualified references.
+	RegistryBase string
+	// Repository is the repository the reference resolved in.
+	Repository string
+	// Candidates are the tags listed for Repository, in registry order.
+	// Empty when the reference named its tag or digest.
+	Candidates []string
+	// Skipped are the candidate tags that were not chosen, with the reason.
+	Skipped []SkippedTag
+	// Chosen is the selected tag, or the digest of a digest reference.
+	Chosen string
+	// Ref is the resolved reference; empty when resolution failed.
+	Ref string
+	// Decisions describes each step of the resolution, in order.
+	Decisions []string
+	// Err is the resolution error, if any.
+	Err error
+}
+
+// SkippedTag is a candidate tag that resolution did not choose.
+type SkippedTag struct {
+	Tag    string
+	Reason string
+}
+
+// resolutionTraceKey is the context key of WithResolutionTrace.
+type resolutionTraceKey struct{}
+
+// WithResolutionTrace returns a copy of ctx that records resolutions into
+// trace.
+func WithResolutionTrace(ctx context.Context, trace *ResolutionTrace) context.Context {
+	return context.WithValue(ctx, resolutionTraceKey{}, trace)
+}
+
+// Resolutions returns the recorded resolutions in completion order.
+func (t *ResolutionTrace) Resolutions() []Resolution {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Resolution(nil), t.resolutions...)
+}
+
+// String formats the trace for display, one resolution per paragraph.
+func (t *ResolutionTrace) String() string {
+	var b strings.Builder
+	for i, r := range t.Resolutions() {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if r.Err != nil {
+			fmt.Fprintf(&b, "%s: %v\n", r.Input, r.Err)
+		} else {
+			fmt.Fprintf(&b, "%s -> %s\n", r.Input, r.Ref)
+		}
+		for _, d := range r.Decisions {
+			fmt.Fprintf(&b, "  %s\n", d)
+		}
+	}
+	return b.String()
+}
+
+// startResolution begins recording the resolution of input if ctx carries
+// a trace. It returns nil otherwise; all methods of a nil *Resolution
+// are no-ops.
+func startResolution(ctx context.Context, input string) *Resolution {
+	if _, ok := ctx.Value(resolutionTraceKey{}).(*ResolutionTrace); !ok {
+		return nil
+	}
+	return &Resolution{Input: input}
+}
+
+// finish records r with its outcome into the trace of ctx.
+func (r *Resolution) finish(ctx context.Context, ref string, err error) {
+	if r == nil {
+		return
+	}
+	r.Ref, r.Err = ref, err
+	if err != nil {
+		r.Ref = ""
+	}
+	trace := ctx.Value(resolutionTraceKey{}).(*ResolutionTrace)
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	trace.resolutions = append(trace.resolutions, *r)
+}
+
+func (r *Resolution) decide(format string, args ...any) {
+	if r == nil {
+		return
+	}
+	r.Decisions = append(r.Decisions, fmt.Sprintf(format, args...))
+}
+
+// expand records the expansion of a short name with registryBase.
+func (r *Resolution) expand(name, registryBase string) {
+	if r == nil {
+		return
+	}
+	r.RegistryBase = registryBase
+	r.decide("short name %s expanded with registry base %s", name, registryBase)
+}
+
+// given records a tag or digest named by the reference itself.
+func (r *Resolution) given(repo, chosen, kind string) {
+	if r == nil {
+		return
+	}
+	r.Repository, r.Chosen = repo, chosen
+	r.decide("%s %s given; used as-is", kind, chosen)
+}
+
+// chooseTag records the selection of latest among the candidate tags.
+func (r *Resolution) chooseTag(repo string, tags []string, latest string) {
+	if r == nil {
+		return
+	}
+	r.Repository = repo
+	r.Candidates = tags
+	r.Chosen = latest
+	r.decide("listed %d tags in %s", len(tags), repo)
+
+	var chosen *semver.Version
+	if latest != "" {
+		chosen = semver.MustParse(latest)
+	}
+	for _, tag := range tags {
+		if tag == latest {
+			continue
+		}
+		v, err := semver.NewVersion(tag)
+		switch {
+		case err != nil:
+			r.Skipped = append(r.Skipped, SkippedTag{Tag: tag, Reason: "not a semver version"})
+		case chosen != nil && v.Equal(chosen):
+			r.Skipped = append(r.Skipped, SkippedTag{Tag: tag, Reason: "same version as " + latest})
+		default:
+			r.Skipped = append(r.Skipped, SkippedTag{Tag: tag, Reason: "lower than " + latest})
+		}
+	}
+	for _, s := range r.Skipped {
+		r.decide("skipped %s: %s", s.Tag, s.Reason)
+	}
+	switch {
+	case chosen == nil:
+		r.decide("no semver tags to choose from")
+	case chosen.Prerelease() != "":
+		r.decide("chose %s, the highest semver tag; it is a prerelease, and prereleases are not excluded", latest)
+	default:
+		r.decide("chose %s, the highest semver tag", latest)
+	}
+}
@@ -0,0 +1,99 @@
+package oci
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestResolutionTrace(t *testing.T) {
+	lister := &mockTagLister{
+		tags: map[string][]string{
+			"gsoci.azurecr.io/giantswarm/klaus-personalities/sre": {"v1.0.0", "latest", "v1.1.0-rc.1", "v0.9.0"},
+		},
+	}
+	trace := &ResolutionTrace{}
+	ctx := WithResolutionTrace(t.Context(), trace)
+
+	got, err := resolveArtifactRef(ctx, lister, "sre", DefaultPersonalityRegistry)
+	if err != nil {
+		t.Fatalf("resolveArtifactRef() error = %v", err)
+	}
+	if got != DefaultPersonalityRegistry+"/sre:v1.1.0-rc.1" {
+		t.Fatalf("resolveArtifactRef() = %q", got)
+	}
+
+	resolutions := trace.Resolutions()
+	if len(resolutions) != 1 {
+		t.Fatalf("got %d resolutions, want 1", len(resolutions))
+	}
+	r := resolutions[0]
+	if r.Input != "sre" || r.RegistryBase != DefaultPersonalityRegistry || r.Ref != got || r.Chosen != "v1.1.0-rc.1" {
+		t.Errorf("Resolution = %+v", r)
+	}
+	if r.Repository != DefaultPersonalityRegistry+"/sre" || len(r.Candidates) != 4 {
+		t.Errorf("Repository = %q, Candidates = %v", r.Repository, r.Candidates)
+	}
+	wantSkipped := []SkippedTag{
+		{Tag: "v1.0.0", Reason: "lower than v1.1.0-rc.1"},
+		{Tag: "latest", Reason: "not a semver version"},
+		{Tag: "v0.9.0", Reason: "lower than v1.1.0-rc.1"},
+	}
+	if !reflect.DeepEqual(r.Skipped, wantSkipped) {
+		t.Errorf("Skipped = %+v, want %+v", r.Skipped, wantSkipped)
+	}
+	if out := trace.String(); !strings.Contains(out, "sre -> "+got) || !strings.Contains(out, "prerelease") {
+		t.Errorf("String() = %q", out)
+	}
+}
+
+func TestResolutionTrace_GivenAndFailed(t *testing.T) {
+	lister := &mockTagLister{}
+	trace := &ResolutionTrace{}
+	ctx := WithResolutionTrace(t.Context(), trace)
+
+	if _, err := resolveArtifactRef(ctx, lister, "example.com/org/sre:v1.0.0", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resolveArtifactRef(ctx, lister, "example.com/org/missing", ""); err == nil {
+		t.Fatal("resolveArtifactRef() error = nil, want error")
+	}
+
+	resolutions := trace.Resolutions()
+	if len(resolutions) != 2 {
+		t.Fatalf("got %d resolutions, want 2", len(resolutions))
+	}
+	if r := resolutions[0]; r.Chosen != "v1.0.0" || r.Candidates != nil || r.Ref != "example.com/org/sre:v1.0.0" {
+		t.Errorf("explicit tag Resolution = %+v", r)
+	}
+	if r := resolutions[1]; r.Err == nil || r.Ref != "" {
+		t.Errorf("failed Resolution = %+v", r)
+	}
+}
+
+func TestResolutionTrace_Concurrent(t *testing.T) {
+	lister := &mockTagLister{tags: map[string][]string{"example.com/org/sre": {"v1.0.0"}}}
+	trace := &ResolutionTrace{}
+	ctx := WithResolutionTrace(t.Context(), trace)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			if _, err := resolveArtifactRef(ctx, lister, "example.com/org/sre", ""); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+	if n := len(trace.Resolutions()); n != 8 {
+		t.Errorf("got %d resolutions, want 8", n)
+	}
+}
+
+func TestResolutionTrace_Untraced(t *testing.T) {
+	if res := startResolution(context.Background(), "sre"); res != nil {
+		t.Errorf("startResolution() without trace = %+v, want nil", res)
+	}
+}
@@ -0,0 +1,91 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	godigest "github.com/opencontainers/go-digest"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// RetagOption configures Retag.
+type RetagOption func(*retagConfig)
+
+type retagConfig struct {
+	noOverwrite bool
+}
+
+// WithNoOverwrite makes Retag fail with *ErrTagExists when the tag already
+// points at a different manifest. Retagging to the digest the tag already
+// points at still succeeds.
+func WithNoOverwrite() RetagOption {
+	return func(cfg *retagConfig) { cfg.noOverwrite = true }
+}
+
+// Retag points newTag in repository (e.g.
+// "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base") at the manifest
+// with the given digest. No blobs are uploaded, which makes it suitable for
+// promotion flows and floating major-version tags. The overwrite check of
+// WithNoOverwrite and the tag update are separate registry requests, so a
+// concurrent writer can still win between them.
+func (c *Client) Retag(ctx context.Context, repository, digest, newTag string, opts ...RetagOption) (err error) {
+	defer func() {
+		c.recordAudit(ctx, AuditRecord{Operation: AuditRetag, Ref: repository + ":" + newTag, Digest: digest}, err)
+	}()
+
+	cfg := &retagConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	d, err := godigest.Parse(digest)
+	if err != nil {
+		return fmt.Errorf("invalid digest %q: %w", digest, err)
+	}
+	repo, tag, err := c.newRepository(repository + ":" + newTag)
+	if err != nil {
+		return err
+	}
+	if err := (registry.Reference{Reference: tag}).ValidateReferenceAsTag(); err != nil {
+		return err
+	}
+
+	desc, err := repo.Resolve(ctx, d.String())
+	if err != nil {
+		return fmt.Errorf("resolving %s@%s: %w", repository, d, err)
+	}
+
+	if cfg.noOverwrite {
+		if err := checkTagUnchanged(ctx, repo, newTag, desc.Digest.String()); err != nil {
+			return err
+		}
+	}
+
+	if err := repo.Tag(ctx, desc, newTag); err != nil {
+		return fmt.Errorf("tagging %s@%s as %s: %w", repository, d, newTag, err)
+	}
+	return nil
+}
+
+// checkTagUnchanged returns *ErrTagExists when tag exists in repo and
+// points at a manifest other than digest.
+func checkTagUnchanged(ctx context.Context, repo *remote.Repository, tag, digest string) error {
+	existing, err := repo.Resolve(ctx, tag)
+	if errors.Is(err, errdef.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("checking tag %s: %w", tag, err)
+	}
+	if existing.Digest.String() != digest {
+		return &ErrTagExists{
+			Repository: repo.Reference.Registry + "/" + repo.Reference.Repository,
+			Tag:        tag,
+			Digest:     existing.Digest.String(),
+		}
+	}
+	return nil
+}
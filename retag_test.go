@@ -0,0 +1,98 @@
+package oci
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// pushVersions pushes one personality per version, each with distinct
+// content, and returns the manifest digests keyed by version.
+func pushVersions(t *testing.T, client *Client, repository string, versions ...string) map[string]string {
+	t.Helper()
+	digests := make(map[string]string)
+	for _, v := range versions {
+		src := t.TempDir()
+		writeFile(t, filepath.Join(src, "SOUL.md"), "Soul "+v)
+		result, err := client.PushPersonality(t.Context(), src, repository+":"+v, Personality{Name: "sre"})
+		if err != nil {
+			t.Fatalf("PushPersonality(%s) error = %v", v, err)
+		}
+		digests[v] = result.Digest
+	}
+	return digests
+}
+
+func TestRetag(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	repository := host + "/klaus/sre"
+	digests := pushVersions(t, client, repository, "v1.0.0", "v1.1.0")
+
+	if err := client.Retag(t.Context(), repository, digests["v1.0.0"], "v1"); err != nil {
+		t.Fatalf("Retag() error = %v", err)
+	}
+	if err := client.Retag(t.Context(), repository, digests["v1.1.0"], "v1"); err != nil {
+		t.Fatalf("Retag() overwrite error = %v", err)
+	}
+
+	got, err := client.Resolve(t.Context(), repository+":v1")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if got != digests["v1.1.0"] {
+		t.Errorf("v1 -> %s, want %s", got, digests["v1.1.0"])
+	}
+}
+
+func TestRetag_WithNoOverwrite(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	repository := host + "/klaus/sre"
+	digests := pushVersions(t, client, repository, "v1.0.0", "v1.1.0")
+
+	// Retagging to the digest the tag already has is a no-op.
+	if err := client.Retag(t.Context(), repository, digests["v1.0.0"], "v1.0.0", WithNoOverwrite()); err != nil {
+		t.Errorf("Retag() same digest error = %v", err)
+	}
+	// A new tag is created.
+	if err := client.Retag(t.Context(), repository, digests["v1.0.0"], "stable", WithNoOverwrite()); err != nil {
+		t.Errorf("Retag() new tag error = %v", err)
+	}
+
+	err := client.Retag(t.Context(), repository, digests["v1.1.0"], "v1.0.0", WithNoOverwrite())
+	var exists *ErrTagExists
+	if !errors.As(err, &exists) {
+		t.Fatalf("Retag() error = %v, want *ErrTagExists", err)
+	}
+	if exists.Tag != "v1.0.0" || exists.Digest != digests["v1.0.0"] {
+		t.Errorf("ErrTagExists = %+v", exists)
+	}
+}
+
+func TestRetag_Errors(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	repository := host + "/klaus/sre"
+	digests := pushVersions(t, client, repository, "v1.0.0")
+
+	tests := []struct {
+		name   string
+		digest string
+		tag    string
+	}{
+		{name: "invalid digest", digest: "sha256:nope", tag: "v1"},
+		{name: "unknown manifest", digest: "sha256:" + sum256Hex([]byte("missing")), tag: "v1"},
+		{name: "invalid tag", digest: digests["v1.0.0"], tag: "not a tag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := client.Retag(t.Context(), repository, tt.digest, tt.tag); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
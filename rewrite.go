@@ -0,0 +1,131 @@
+package oci
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RewriteRule rewrites the references inside personalities, e.g. to run
+// a personality published with gsoci.azurecr.io references against a
+// mirrored registry. Exactly one of Prefix or Regexp is set.
+type RewriteRule struct {
+	// Prefix matches references starting with it at a path boundary, e.g.
+	// "gsoci.azurecr.io/giantswarm" matches
+	// "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base" but not
+	// "gsoci.azurecr.io/giantswarm-dev/x". The prefix is replaced by
+	// Replacement.
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	// Regexp matches references against a regular expression. The matched
+	// text is replaced by Replacement, in which $1 or ${name} expand to
+	// submatches.
+	Regexp string `yaml:"regexp,omitempty" json:"regexp,omitempty"`
+	// Replacement is the text matched references are rewritten with.
+	Replacement string `yaml:"replacement" json:"replacement"`
+}
+
+// WithReferenceRewrites rewrites the toolchain, plugin, and parent
+// references of every personality read from a config blob, by pulls and
+// describes alike, with rules as RewriteReferences does. The rules are
+// compiled once by NewClient; invalid rules fail those operations before
+// the config blob is fetched.
+func WithReferenceRewrites(rules ...RewriteRule) ClientOption {
+	return func(c *Client) { c.rewrites = append(c.rewrites, rules...) }
+}
+
+// RewriteReferences applies rules to the toolchain and plugin
+// repositories and the Extends reference of p. For each reference, the
+// first matching rule applies; tags and digests are kept. It fails without
+// changing p when a rule is invalid.
+func RewriteReferences(p *Personality, rules []RewriteRule) error {
+	rewrite, err := compileRewriteRules(rules)
+	if err != nil {
+		return err
+	}
+	rewrite.apply(p)
+	return nil
+}
+
+// referenceRewriter is a compiled list of rewrite rules.
+type referenceRewriter []compiledRewriteRule
+
+type compiledRewriteRule struct {
+	RewriteRule
+	re *regexp.Regexp
+}
+
+func compileRewriteRules(rules []RewriteRule) (referenceRewriter, error) {
+	compiled := make(referenceRewriter, 0, len(rules))
+	for i, r := range rules {
+		if (r.Prefix == "") == (r.Regexp == "") {
+			return nil, fmt.Errorf("rewrite rule %d: exactly one of prefix and regexp must be set", i+1)
+		}
+		c := compiledRewriteRule{RewriteRule: r}
+		if r.Regexp != "" {
+			re, err := regexp.Compile(r.Regexp)
+			if err != nil {
+				return nil, fmt.Errorf("rewrite rule %d: %w", i+1, err)
+			}
+			c.re = re
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// rewrite returns the repository repo rewritten by the first matching
+// rule.
+func (rw referenceRewriter) rewrite(repo string) string {
+	for _, r := range rw {
+		if r.re != nil {
+			if r.re.MatchString(repo) {
+				return r.re.ReplaceAllString(repo, r.Replacement)
+			}
+			continue
+		}
+		prefix := strings.TrimSuffix(r.Prefix, "/")
+		if rest, ok := strings.CutPrefix(repo, prefix); ok && (rest == "" || rest[0] == '/') {
+			return strings.TrimSuffix(r.Replacement, "/") + rest
+		}
+	}
+	return repo
+}
+
+// rewriteRef returns ref rewritten like its repository, keeping its tag
+// or digest, so that rules never see or change them.
+func (rw referenceRewriter) rewriteRef(ref string) string {
+	repo := RepositoryFromRef(ref)
+	return rw.rewrite(repo) + ref[len(repo):]
+}
+
+// apply rewrites the references of p. Plugins is copied, so slices shared
+// with other values are left alone.
+func (rw referenceRewriter) apply(p *Personality) {
+	if len(rw) == 0 {
+		return
+	}
+	if p.Toolchain.Repository != "" {
+		p.Toolchain.Repository = rw.rewrite(p.Toolchain.Repository)
+	}
+	if p.Plugins != nil {
+		plugins := make([]PluginReference, len(p.Plugins))
+		for i, ref := range p.Plugins {
+			ref.Repository = rw.rewrite(ref.Repository)
+			plugins[i] = ref
+		}
+		p.Plugins = plugins
+	}
+	if p.Extends != "" {
+		p.Extends = rw.rewriteRef(p.Extends)
+	}
+}
+
+// rewritePersonality applies the client's rewrite rules to p, failing
+// when they are invalid.
+func (c *Client) rewritePersonality(p *Personality) error {
+	if c.rewriteErr != nil {
+		return c.rewriteErr
+	}
+	c.rewriter.apply(p)
+	return nil
+}
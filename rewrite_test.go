@@ -0,0 +1,133 @@
+package oci
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRewriteReferences(t *testing.T) {
+	plugins := []PluginReference{
+		{Repository: "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base", Tag: "v1.0.0"},
+		{Repository: "gsoci.azurecr.io/giantswarm-dev/klaus-plugins/gs-dev", Digest: "sha256:abc"},
+		{Repository: "ghcr.io/acme/klaus-plugins/acme", Tag: "v2.0.0"},
+	}
+	p := Personality{
+		Toolchain: ToolchainReference{Repository: "gsoci.azurecr.io/giantswarm/klaus-toolchains/go", Tag: "v1.1.0"},
+		Plugins:   plugins,
+		Extends:   "gsoci.azurecr.io/giantswarm/klaus-personalities/base:v1.0.0",
+	}
+	rules := []RewriteRule{
+		{Prefix: "gsoci.azurecr.io/giantswarm/", Replacement: "mirror.example.com/gs"},
+		{Regexp: `^ghcr\.io/([^/]+)/`, Replacement: "mirror.example.com/ghcr/$1/"},
+		{Prefix: "ghcr.io", Replacement: "unused.example.com"},
+	}
+	if err := RewriteReferences(&p, rules); err != nil {
+		t.Fatalf("RewriteReferences() error = %v", err)
+	}
+
+	if want := (ToolchainReference{Repository: "mirror.example.com/gs/klaus-toolchains/go", Tag: "v1.1.0"}); p.Toolchain != want {
+		t.Errorf("Toolchain = %+v, want %+v", p.Toolchain, want)
+	}
+	want := []PluginReference{
+		{Repository: "mirror.example.com/gs/klaus-plugins/gs-base", Tag: "v1.0.0"},
+		{Repository: "gsoci.azurecr.io/giantswarm-dev/klaus-plugins/gs-dev", Digest: "sha256:abc"},
+		{Repository: "mirror.example.com/ghcr/acme/klaus-plugins/acme", Tag: "v2.0.0"},
+	}
+	if !slices.Equal(p.Plugins, want) {
+		t.Errorf("Plugins = %+v, want %+v", p.Plugins, want)
+	}
+	if p.Extends != "mirror.example.com/gs/klaus-personalities/base:v1.0.0" {
+		t.Errorf("Extends = %q", p.Extends)
+	}
+	if plugins[0].Repository != "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base" {
+		t.Error("RewriteReferences() modified the caller's plugin slice")
+	}
+}
+
+func TestRewriteReferences_ExtendsTagAndDigest(t *testing.T) {
+	rules := []RewriteRule{
+		{Regexp: `/base$`, Replacement: "/core"},
+		{Regexp: `sha256`, Replacement: "sha512"},
+		{Prefix: "example.com/personalities/sre:v1", Replacement: "example.com/x"},
+	}
+	for extends, want := range map[string]string{
+		"example.com/personalities/base:v1.0.0":         "example.com/personalities/core:v1.0.0",
+		"example.com/personalities/base@sha256:abc":     "example.com/personalities/core@sha256:abc",
+		"example.com/personalities/sre:v1.0.0":          "example.com/personalities/sre:v1.0.0",
+		"localhost:5000/personalities/base:v2.0.0":      "localhost:5000/personalities/core:v2.0.0",
+		"localhost:5000/personalities/base@sha256:abc":  "localhost:5000/personalities/core@sha256:abc",
+		"localhost:5000/personalities/other@sha256:abc": "localhost:5000/personalities/other@sha256:abc",
+	} {
+		p := Personality{Extends: extends}
+		if err := RewriteReferences(&p, rules); err != nil {
+			t.Fatalf("RewriteReferences() error = %v", err)
+		}
+		if p.Extends != want {
+			t.Errorf("Extends %q rewritten to %q, want %q", extends, p.Extends, want)
+		}
+	}
+}
+
+func TestRewriteReferences_InvalidRules(t *testing.T) {
+	for name, rule := range map[string]RewriteRule{
+		"neither":        {Replacement: "x"},
+		"both":           {Prefix: "a", Regexp: "b", Replacement: "x"},
+		"invalid regexp": {Regexp: "(", Replacement: "x"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := Personality{Toolchain: ToolchainReference{Repository: "a/b"}}
+			if err := RewriteReferences(&p, []RewriteRule{rule}); err == nil || !strings.Contains(err.Error(), "rewrite rule 1") {
+				t.Errorf("RewriteReferences() error = %v, want an invalid rule error", err)
+			}
+			if p.Toolchain.Repository != "a/b" {
+				t.Errorf("Toolchain = %q, changed by an invalid rule", p.Toolchain.Repository)
+			}
+		})
+	}
+}
+
+func TestWithReferenceRewrites(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "SOUL.md"), "You are an SRE.")
+	ref := host + "/klaus-personalities/sre:v1.0.0"
+	_, err := NewClient(WithPlainHTTP(true)).PushPersonality(t.Context(), src, ref, Personality{
+		Name:      "sre",
+		Toolchain: ToolchainReference{Repository: DefaultToolchainRegistry + "/go", Tag: "v1.0.0"},
+		Plugins:   []PluginReference{{Repository: DefaultPluginRegistry + "/gs-base", Tag: "v1.0.0"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient(WithPlainHTTP(true), WithReferenceRewrites(RewriteRule{Prefix: DefaultNamespace, Replacement: "mirror.example.com/gs"}))
+	check := func(op string, p Personality) {
+		t.Helper()
+		if p.Toolchain.Repository != "mirror.example.com/gs/klaus-toolchains/go" || p.Plugins[0].Repository != "mirror.example.com/gs/klaus-plugins/gs-base" {
+			t.Errorf("%s references = %+v, %+v, want them rewritten", op, p.Toolchain, p.Plugins)
+		}
+	}
+	pulled, err := client.PullPersonality(t.Context(), ref, t.TempDir())
+	if err != nil {
+		t.Fatalf("PullPersonality() error = %v", err)
+	}
+	check("PullPersonality()", pulled.Personality)
+	described, err := client.DescribePersonality(t.Context(), ref)
+	if err != nil {
+		t.Fatalf("DescribePersonality() error = %v", err)
+	}
+	check("DescribePersonality()", described.Personality)
+	detected, err := client.Describe(t.Context(), ref)
+	if err != nil {
+		t.Fatalf("Describe() error = %v", err)
+	}
+	check("Describe()", detected.Personality.Personality)
+
+	invalid := NewClient(WithPlainHTTP(true), WithReferenceRewrites(RewriteRule{Regexp: "(", Replacement: "x"}))
+	if _, err := invalid.DescribePersonality(t.Context(), ref); err == nil || !strings.Contains(err.Error(), "rewrite rule 1") {
+		t.Errorf("DescribePersonality() with an invalid rule error = %v, want an invalid rule error", err)
+	}
+}
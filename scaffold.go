@@ -0,0 +1,173 @@
+package oci
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ScaffoldPluginOptions configures ScaffoldPlugin.
+type ScaffoldPluginOptions struct {
+	// Name is the plugin name. Defaults to the base name of the directory.
+	Name        string
+	Description string
+	Author      *Author
+	// Skills, Commands, and Agents name the components to create stubs
+	// for, as skills/<name>/SKILL.md, commands/<name>.md, and
+	// agents/<name>.md.
+	Skills   []string
+	Commands []string
+	Agents   []string
+}
+
+// ScaffoldPersonalityOptions configures ScaffoldPersonality.
+type ScaffoldPersonalityOptions struct {
+	// Name is the personality name. Defaults to the base name of the
+	// directory.
+	Name        string
+	Description string
+	Author      *Author
+	Toolchain   ToolchainReference
+	Plugins     []PluginReference
+}
+
+// ScaffoldPlugin creates the canonical plugin directory structure in dir:
+// .claude-plugin/plugin.json, the skills/ and commands/ directories, and a
+// stub for every component named in opts. The directory is created if
+// needed; existing files are never overwritten, and an existing
+// plugin.json is an error. It returns the plugin as ReadPluginFromDir
+// reads it back.
+func ScaffoldPlugin(dir string, opts ScaffoldPluginOptions) (*Plugin, error) {
+	name, err := scaffoldName(dir, opts.Name)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := json.MarshalIndent(Plugin{Name: name, Description: opts.Description, Author: opts.Author}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding plugin.json: %w", err)
+	}
+
+	for _, names := range [][]string{opts.Skills, opts.Commands, opts.Agents} {
+		for _, n := range names {
+			if err := checkComponentName(n); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	manifestPath := filepath.Join(".claude-plugin", "plugin.json")
+	files := map[string]string{manifestPath: string(manifest) + "\n"}
+	for _, s := range opts.Skills {
+		files[filepath.Join("skills", s, "SKILL.md")] = fmt.Sprintf("---\nname: %s\ndescription: TODO: describe when to use this skill.\n---\n\n# %s\n", s, s)
+	}
+	for _, c := range opts.Commands {
+		files[filepath.Join("commands", c+".md")] = fmt.Sprintf("---\ndescription: TODO: describe what /%s does.\n---\n\n", c)
+	}
+	for _, a := range opts.Agents {
+		files[filepath.Join("agents", a+".md")] = fmt.Sprintf("---\nname: %s\ndescription: TODO: describe when to use this agent.\n---\n\n", a)
+	}
+
+	if err := writeScaffold(dir, manifestPath, files, "skills", "commands"); err != nil {
+		return nil, err
+	}
+	return ReadPluginFromDir(dir)
+}
+
+// ScaffoldPersonality creates the canonical personality directory
+// structure in dir: personality.yaml and a SOUL.md stub. The directory is
+// created if needed; existing files are never overwritten, and an
+// existing personality.yaml is an error. It returns the personality as
+// ReadPersonalityFromDir reads it back.
+func ScaffoldPersonality(dir string, opts ScaffoldPersonalityOptions) (*Personality, error) {
+	name, err := scaffoldName(dir, opts.Name)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	p := Personality{
+		Name:        name,
+		Description: opts.Description,
+		Author:      opts.Author,
+		Toolchain:   opts.Toolchain,
+		Plugins:     opts.Plugins,
+	}
+	if err := enc.Encode(p); err != nil {
+		return nil, fmt.Errorf("encoding personality.yaml: %w", err)
+	}
+
+	files := map[string]string{
+		"personality.yaml": buf.String(),
+		"SOUL.md":          fmt.Sprintf("# %s\n\nTODO: describe who %s is and how it works.\n", name, name),
+	}
+	if err := writeScaffold(dir, "personality.yaml", files); err != nil {
+		return nil, err
+	}
+	return ReadPersonalityFromDir(dir)
+}
+
+// scaffoldName returns name, or the base name of dir when name is empty.
+func scaffoldName(dir, name string) (string, error) {
+	if name != "" {
+		return name, nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	name = filepath.Base(abs)
+	if name == string(filepath.Separator) || name == "." {
+		return "", fmt.Errorf("cannot derive a name from %s", dir)
+	}
+	return name, nil
+}
+
+// checkComponentName rejects component names that are not a single path
+// element.
+func checkComponentName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid component name %q", name)
+	}
+	return nil
+}
+
+// writeScaffold creates dir with the given subdirectories and files, keyed
+// by path relative to dir. Existing files are kept, but the manifest file
+// must not exist yet.
+func writeScaffold(dir, manifest string, files map[string]string, dirs ...string) error {
+	if _, err := os.Stat(filepath.Join(dir, manifest)); err == nil {
+		return fmt.Errorf("%s already exists in %s", filepath.ToSlash(manifest), dir)
+	}
+	for _, d := range dirs {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0o755); err != nil {
+			return err
+		}
+	}
+	for rel, content := range files {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, err = f.WriteString(content)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("writing %s: %w", rel, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package oci
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestScaffoldPlugin(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "gs-base")
+	p, err := ScaffoldPlugin(dir, ScaffoldPluginOptions{
+		Description: "Base tools",
+		Author:      &Author{Name: "Giant Swarm"},
+		Skills:      []string{"k8s"},
+		Commands:    []string{"init"},
+		Agents:      []string{"reviewer"},
+	})
+	if err != nil {
+		t.Fatalf("ScaffoldPlugin() error = %v", err)
+	}
+	if p.Name != "gs-base" || p.Description != "Base tools" || p.Author == nil || p.Author.Name != "Giant Swarm" {
+		t.Errorf("plugin = %+v", p)
+	}
+	if !slices.Equal(p.Skills, []string{"k8s"}) || !slices.Equal(p.Commands, []string{"init"}) || !slices.Equal(p.Agents, []string{"reviewer"}) {
+		t.Errorf("components = %v %v %v", p.Skills, p.Commands, p.Agents)
+	}
+	if fi, err := os.Stat(filepath.Join(dir, "commands")); err != nil || !fi.IsDir() {
+		t.Errorf("commands/ not created: %v", err)
+	}
+
+	if _, err := ScaffoldPlugin(dir, ScaffoldPluginOptions{}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("ScaffoldPlugin() over an existing plugin error = %v", err)
+	}
+	if _, err := ScaffoldPlugin(t.TempDir(), ScaffoldPluginOptions{Skills: []string{"../escape"}}); err == nil {
+		t.Error("ScaffoldPlugin() with a path as skill name succeeded")
+	}
+}
+
+func TestScaffoldPlugin_KeepsExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "skills", "k8s", "SKILL.md"), "mine")
+	if _, err := ScaffoldPlugin(dir, ScaffoldPluginOptions{Name: "gs-base", Skills: []string{"k8s"}}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "skills", "k8s", "SKILL.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "mine" {
+		t.Errorf("SKILL.md = %q, want it kept", data)
+	}
+}
+
+func TestScaffoldPersonality(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sre")
+	p, err := ScaffoldPersonality(dir, ScaffoldPersonalityOptions{
+		Toolchain: ToolchainReference{Repository: DefaultToolchainRegistry + "/go", Tag: "v1.0.0"},
+		Plugins:   []PluginReference{{Repository: DefaultPluginRegistry + "/gs-base", Tag: "v1.0.0"}},
+	})
+	if err != nil {
+		t.Fatalf("ScaffoldPersonality() error = %v", err)
+	}
+	if p.Name != "sre" || p.Toolchain.Tag != "v1.0.0" || len(p.Plugins) != 1 {
+		t.Errorf("personality = %+v", p)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "SOUL.md")); err != nil {
+		t.Errorf("SOUL.md not created: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "personality.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "name: sre\n") {
+		t.Errorf("personality.yaml =\n%s", data)
+	}
+	if _, err := ScaffoldPersonality(dir, ScaffoldPersonalityOptions{}); err == nil {
+		t.Error("ScaffoldPersonality() over an existing personality succeeded")
+	}
+
+	bare := t.TempDir()
+	if _, err := ScaffoldPersonality(bare, ScaffoldPersonalityOptions{Name: "bare"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(filepath.Join(bare, "personality.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "name: bare\n" {
+		t.Errorf("bare personality.yaml = %q, want the name only", data)
+	}
+}
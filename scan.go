@@ -0,0 +1,284 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/errgroup"
+)
+
+// MediaTypeScanSummary is the artifact type of vulnerability scan
+// summaries attached to artifacts with AttachScanSummary, and the media
+// type of their single layer.
+const MediaTypeScanSummary = "application/vnd.giantswarm.klaus.scan-summary.v1+json"
+
+// maxScanSummarySize bounds scan summary documents, which list findings
+// rather than full scanner reports.
+const maxScanSummarySize = 4 << 20
+
+// VulnerabilitySeverity grades a vulnerability finding.
+type VulnerabilitySeverity string
+
+// Vulnerability severities, from least to most severe. Severities are
+// compared case-insensitively; unrecognized and missing severities rank
+// as critical.
+const (
+	VulnerabilityLow      VulnerabilitySeverity = "LOW"
+	VulnerabilityMedium   VulnerabilitySeverity = "MEDIUM"
+	VulnerabilityHigh     VulnerabilitySeverity = "HIGH"
+	VulnerabilityCritical VulnerabilitySeverity = "CRITICAL"
+)
+
+// rank orders severities. Unrecognized and empty severities rank as
+// critical.
+func (s VulnerabilitySeverity) rank() int {
+	switch VulnerabilitySeverity(strings.ToUpper(string(s))) {
+	case VulnerabilityLow:
+		return 1
+	case VulnerabilityMedium:
+		return 2
+	case VulnerabilityHigh:
+		return 3
+	}
+	return 4
+}
+
+// ScanSummary records one vulnerability scan of an artifact version.
+type ScanSummary struct {
+	// Scanner identifies the scanner and its version, e.g. "trivy 0.58.1".
+	Scanner string `json:"scanner,omitempty"`
+	// Timestamp is when the scan ran. AttachScanSummary sets it to the
+	// current time when zero.
+	Timestamp time.Time `json:"timestamp"`
+	// Vulnerabilities lists the findings.
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+
+	// Digest is the manifest digest of the referrer holding the summary,
+	// set when it is fetched.
+	Digest string `json:"-"`
+}
+
+// Vulnerability is a single scan finding.
+type Vulnerability struct {
+	// ID identifies the vulnerability, e.g. "CVE-2026-1234".
+	ID string `json:"id"`
+	// Severity grades the vulnerability.
+	Severity VulnerabilitySeverity `json:"severity"`
+	// Package names the affected package, if known.
+	Package string `json:"package,omitempty"`
+}
+
+// AttachScanSummary attaches summary to the artifact at ref as an OCI
+// referrer of artifact type MediaTypeScanSummary, and returns the
+// referrer's manifest digest. ref must be a fully-qualified reference;
+// references without a tag resolve to the latest version first. Rescans
+// attach further summaries; EvaluateSecurityPolicy uses the newest.
+func (c *Client) AttachScanSummary(ctx context.Context, ref string, summary ScanSummary) (string, error) {
+	for _, v := range summary.Vulnerabilities {
+		if v.ID == "" {
+			return "", fmt.Errorf("scan summary must identify every vulnerability")
+		}
+	}
+	if summary.Vulnerabilities == nil {
+		summary.Vulnerabilities = []Vulnerability{}
+	}
+	if summary.Timestamp.IsZero() {
+		summary.Timestamp = time.Now().UTC()
+	}
+	doc, err := json.Marshal(summary)
+	if err != nil {
+		return "", fmt.Errorf("marshaling scan summary: %w", err)
+	}
+	if len(doc) > maxScanSummarySize {
+		return "", fmt.Errorf("scan summary is %d bytes, exceeding the limit of %d", len(doc), maxScanSummarySize)
+	}
+
+	fm, resolved, err := c.fetchReferrerSubject(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	subject, err := fm.repo.Resolve(ctx, fm.digest)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", resolved, err)
+	}
+	annotations := map[string]string{ocispec.AnnotationCreated: summary.Timestamp.Format(time.RFC3339)}
+	desc, err := pushReferrer(ctx, fm.repo, subject, MediaTypeScanSummary, doc, annotations)
+	if err != nil {
+		return "", fmt.Errorf("attaching scan summary to %s: %w", resolved, err)
+	}
+	return desc.Digest.String(), nil
+}
+
+// FetchScanSummaries returns the scan summaries attached to the artifact
+// at ref, newest first. ref supports the same forms as AttachScanSummary.
+func (c *Client) FetchScanSummaries(ctx context.Context, ref string) ([]ScanSummary, error) {
+	fm, resolved, err := c.fetchReferrerSubject(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return c.fetchScanSummaries(ctx, fm, resolved)
+}
+
+// fetchScanSummaries lists and fetches the scan summaries referring to the
+// manifest of fm, newest first.
+func (c *Client) fetchScanSummaries(ctx context.Context, fm *fetchedManifest, ref string) ([]ScanSummary, error) {
+	referrers, err := fm.referrers(ctx, MediaTypeScanSummary)
+	if err != nil {
+		return nil, fmt.Errorf("listing scan summaries of %s: %w", ref, err)
+	}
+
+	summaries := make([]ScanSummary, len(referrers))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+	for i, desc := range referrers {
+		g.Go(func() error {
+			data, err := fm.fetchReferrerDocument(gctx, desc, MediaTypeScanSummary, maxScanSummarySize)
+			if err != nil {
+				return fmt.Errorf("fetching scan summary %s of %s: %w", desc.Digest, ref, err)
+			}
+			if err := json.Unmarshal(data, &summaries[i]); err != nil {
+				return fmt.Errorf("parsing scan summary %s of %s: %w", desc.Digest, ref, err)
+			}
+			summaries[i].Digest = desc.Digest.String()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(summaries, func(a, b ScanSummary) int {
+		return b.Timestamp.Compare(a.Timestamp)
+	})
+	return summaries, nil
+}
+
+// SecurityPolicy decides whether an artifact is admitted based on its
+// newest scan summary.
+type SecurityPolicy struct {
+	// MaxSeverity is the most severe vulnerability admitted. The zero
+	// value admits no vulnerabilities.
+	MaxSeverity VulnerabilitySeverity
+	// Exceptions admit listed vulnerabilities regardless of severity
+	// until they expire.
+	Exceptions []CVEException
+	// MaxScanAge rejects artifacts whose newest scan is older. Zero
+	// disables the check.
+	MaxScanAge time.Duration
+	// AllowUnscanned admits artifacts without a scan summary.
+	AllowUnscanned bool
+}
+
+// CVEException admits one vulnerability, e.g. a false positive or one
+// with a mitigation in place.
+type CVEException struct {
+	// ID is the vulnerability ID, matched case-insensitively.
+	ID string
+	// Expires is when the exception stops applying. The zero value
+	// never expires.
+	Expires time.Time
+	// Reason documents why the exception was granted.
+	Reason string
+}
+
+// PolicyResult is the outcome of evaluating a SecurityPolicy.
+type PolicyResult struct {
+	// Ref is the evaluated reference and Digest its manifest digest; set
+	// by EvaluateSecurityPolicy.
+	Ref    string
+	Digest string
+	// Passed is true if the artifact is admitted.
+	Passed bool
+	// Reasons lists why the artifact failed, one per violation.
+	Reasons []string
+	// Exempted lists the IDs of vulnerabilities admitted by exceptions.
+	Exempted []string
+	// Warnings lists exceptions that have expired, so they can be
+	// renewed or removed.
+	Warnings []string
+	// Scan is the evaluated scan summary, nil if there was none.
+	Scan *ScanSummary
+}
+
+// EvaluateSecurityPolicy evaluates policy against the newest scan summary
+// attached to the artifact at ref (see AttachScanSummary), for admission
+// control and CI gates. ref supports the same forms as AttachScanSummary.
+// A failing policy is reported in the result, not as an error.
+func (c *Client) EvaluateSecurityPolicy(ctx context.Context, ref string, policy SecurityPolicy) (*PolicyResult, error) {
+	fm, resolved, err := c.fetchReferrerSubject(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	summaries, err := c.fetchScanSummaries(ctx, fm, resolved)
+	if err != nil {
+		return nil, err
+	}
+	var newest *ScanSummary
+	if len(summaries) > 0 {
+		newest = &summaries[0]
+	}
+	result := policy.Evaluate(newest, time.Now())
+	result.Ref, result.Digest = resolved, fm.digest
+	return result, nil
+}
+
+// Evaluate evaluates the policy against summary at time now. A nil
+// summary means the artifact was not scanned.
+func (p SecurityPolicy) Evaluate(summary *ScanSummary, now time.Time) *PolicyResult {
+	result := &PolicyResult{Scan: summary}
+	if summary == nil {
+		if !p.AllowUnscanned {
+			result.Reasons = append(result.Reasons, "no scan summary attached")
+		}
+		result.Passed = len(result.Reasons) == 0
+		return result
+	}
+	if p.MaxScanAge > 0 && now.Sub(summary.Timestamp) > p.MaxScanAge {
+		result.Reasons = append(result.Reasons, fmt.Sprintf("newest scan from %s is older than %s", summary.Timestamp.Format(time.RFC3339), p.MaxScanAge))
+	}
+
+	exceptions := make(map[string]CVEException, len(p.Exceptions))
+	for _, e := range p.Exceptions {
+		id := strings.ToUpper(e.ID)
+		if !e.Expires.IsZero() && !now.Before(e.Expires) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("exception for %s expired at %s", e.ID, e.Expires.Format(time.RFC3339)))
+			continue
+		}
+		exceptions[id] = e
+	}
+	for _, v := range summary.Vulnerabilities {
+		if p.MaxSeverity != "" && v.Severity.rank() <= p.MaxSeverity.rank() {
+			continue
+		}
+		if _, ok := exceptions[strings.ToUpper(v.ID)]; ok {
+			if !slices.Contains(result.Exempted, v.ID) {
+				result.Exempted = append(result.Exempted, v.ID)
+			}
+			continue
+		}
+		reason := fmt.Sprintf("%s (%s)", v.ID, severityName(v.Severity))
+		if v.Package != "" {
+			reason = fmt.Sprintf("%s (%s in %s)", v.ID, severityName(v.Severity), v.Package)
+		}
+		if p.MaxSeverity == "" {
+			reason += " is not allowed"
+		} else {
+			reason += fmt.Sprintf(" exceeds the maximum severity %s", severityName(p.MaxSeverity))
+		}
+		result.Reasons = append(result.Reasons, reason)
+	}
+	result.Passed = len(result.Reasons) == 0
+	return result
+}
+
+// severityName returns s for messages, "UNKNOWN" when empty.
+func severityName(s VulnerabilitySeverity) string {
+	if s == "" {
+		return "UNKNOWN"
+	}
+	return strings.ToUpper(string(s))
+}
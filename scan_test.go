@@ -0,0 +1,177 @@
+package oci
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAttachScanSummary(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	repository := host + "/klaus/sre"
+	pushVersions(t, client, repository, "v1.0.0")
+	ref := repository + ":v1.0.0"
+
+	older := ScanSummary{
+		Scanner:         "trivy 0.58.0",
+		Timestamp:       time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Vulnerabilities: []Vulnerability{{ID: "CVE-2026-0001", Severity: VulnerabilityCritical, Package: "openssl"}},
+	}
+	newer := ScanSummary{
+		Scanner:         "trivy 0.58.1",
+		Timestamp:       time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		Vulnerabilities: []Vulnerability{{ID: "CVE-2026-0002", Severity: VulnerabilityMedium}},
+	}
+	for _, s := range []ScanSummary{newer, older} {
+		if _, err := client.AttachScanSummary(t.Context(), ref, s); err != nil {
+			t.Fatalf("AttachScanSummary() error = %v", err)
+		}
+	}
+
+	summaries, err := client.FetchScanSummaries(t.Context(), ref)
+	if err != nil {
+		t.Fatalf("FetchScanSummaries() error = %v", err)
+	}
+	if len(summaries) != 2 {
+		t.Fatalf("FetchScanSummaries() = %d summaries, want 2", len(summaries))
+	}
+	if summaries[0].Scanner != newer.Scanner || summaries[1].Vulnerabilities[0].Package != "openssl" {
+		t.Errorf("summaries = %+v, want newest first", summaries)
+	}
+	if summaries[0].Digest == "" || summaries[0].Digest == summaries[1].Digest {
+		t.Errorf("Digests = %q, %q, want distinct referrer digests", summaries[0].Digest, summaries[1].Digest)
+	}
+
+	// Only the newest scan counts: the critical finding was fixed.
+	result, err := client.EvaluateSecurityPolicy(t.Context(), repository, SecurityPolicy{MaxSeverity: VulnerabilityHigh})
+	if err != nil {
+		t.Fatalf("EvaluateSecurityPolicy() error = %v", err)
+	}
+	if !result.Passed || result.Ref != ref || result.Digest == "" || result.Scan == nil || result.Scan.Scanner != newer.Scanner {
+		t.Errorf("result = %+v, want a pass on the newest scan of %s", result, ref)
+	}
+
+	result, err = client.EvaluateSecurityPolicy(t.Context(), ref, SecurityPolicy{MaxSeverity: VulnerabilityLow})
+	if err != nil {
+		t.Fatalf("EvaluateSecurityPolicy() error = %v", err)
+	}
+	if result.Passed || len(result.Reasons) != 1 || !strings.Contains(result.Reasons[0], "CVE-2026-0002") {
+		t.Errorf("result = %+v, want a failure for CVE-2026-0002", result)
+	}
+}
+
+func TestEvaluateSecurityPolicy_Unscanned(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	repository := host + "/klaus/sre"
+	pushVersions(t, client, repository, "v1.0.0")
+
+	result, err := client.EvaluateSecurityPolicy(t.Context(), repository+":v1.0.0", SecurityPolicy{MaxSeverity: VulnerabilityCritical})
+	if err != nil {
+		t.Fatalf("EvaluateSecurityPolicy() error = %v", err)
+	}
+	if result.Passed || result.Scan != nil {
+		t.Errorf("result = %+v, want failure without a scan", result)
+	}
+}
+
+func TestAttachScanSummary_Invalid(t *testing.T) {
+	client := NewClient()
+	summary := ScanSummary{Vulnerabilities: []Vulnerability{{Severity: VulnerabilityLow}}}
+	if _, err := client.AttachScanSummary(t.Context(), "example.com/klaus/sre:v1.0.0", summary); err == nil {
+		t.Error("AttachScanSummary() with an unidentified vulnerability succeeded")
+	}
+}
+
+func TestSecurityPolicy_Evaluate(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	scan := &ScanSummary{
+		Timestamp: now.Add(-48 * time.Hour),
+		Vulnerabilities: []Vulnerability{
+			{ID: "CVE-1", Severity: VulnerabilityLow},
+			{ID: "CVE-2", Severity: "high"},
+			{ID: "CVE-3", Severity: VulnerabilityCritical, Package: "libx"},
+			{ID: "CVE-4"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		policy   SecurityPolicy
+		scan     *ScanSummary
+		passed   bool
+		reasons  []string
+		exempted []string
+		warnings int
+	}{
+		{
+			name:    "zero policy admits nothing",
+			scan:    scan,
+			reasons: []string{"CVE-1 (LOW) is not allowed", "CVE-2 (HIGH) is not allowed", "CVE-3 (CRITICAL in libx) is not allowed", "CVE-4 (UNKNOWN) is not allowed"},
+		},
+		{
+			name:    "max severity",
+			policy:  SecurityPolicy{MaxSeverity: VulnerabilityHigh},
+			scan:    scan,
+			reasons: []string{"CVE-3 (CRITICAL in libx) exceeds the maximum severity HIGH", "CVE-4 (UNKNOWN) exceeds the maximum severity HIGH"},
+		},
+		{
+			name: "exceptions",
+			policy: SecurityPolicy{MaxSeverity: VulnerabilityHigh, Exceptions: []CVEException{
+				{ID: "cve-3", Expires: now.Add(time.Hour)},
+				{ID: "CVE-4"},
+			}},
+			scan:     scan,
+			passed:   true,
+			exempted: []string{"CVE-3", "CVE-4"},
+		},
+		{
+			name: "expired exception",
+			policy: SecurityPolicy{MaxSeverity: VulnerabilityHigh, Exceptions: []CVEException{
+				{ID: "CVE-3", Expires: now},
+				{ID: "CVE-4"},
+			}},
+			scan:     scan,
+			reasons:  []string{"CVE-3 (CRITICAL in libx) exceeds the maximum severity HIGH"},
+			exempted: []string{"CVE-4"},
+			warnings: 1,
+		},
+		{
+			name:    "stale scan",
+			policy:  SecurityPolicy{MaxSeverity: VulnerabilityCritical, MaxScanAge: 24 * time.Hour},
+			scan:    scan,
+			reasons: []string{"newest scan from 2026-05-30T00:00:00Z is older than 24h0m0s"},
+		},
+		{
+			name:    "unscanned",
+			policy:  SecurityPolicy{MaxSeverity: VulnerabilityCritical},
+			reasons: []string{"no scan summary attached"},
+		},
+		{
+			name:   "unscanned allowed",
+			policy: SecurityPolicy{AllowUnscanned: true},
+			passed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.policy.Evaluate(tt.scan, now)
+			if result.Passed != tt.passed {
+				t.Errorf("Passed = %v, want %v", result.Passed, tt.passed)
+			}
+			if !slices.Equal(result.Reasons, tt.reasons) {
+				t.Errorf("Reasons = %q, want %q", result.Reasons, tt.reasons)
+			}
+			if !slices.Equal(result.Exempted, tt.exempted) {
+				t.Errorf("Exempted = %v, want %v", result.Exempted, tt.exempted)
+			}
+			if len(result.Warnings) != tt.warnings {
+				t.Errorf("Warnings = %v, want %d", result.Warnings, tt.warnings)
+			}
+		})
+	}
+}
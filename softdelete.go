@@ -0,0 +1,165 @@
+package oci
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+)
+
+// ArchiveSuffix is appended to a registry namespace to form its archive
+// namespace, e.g. "giantswarm/klaus-plugins-archive" for
+// "giantswarm/klaus-plugins".
+const ArchiveSuffix = "-archive"
+
+// ArchiveResult describes the artifacts moved by Client.Archive.
+type ArchiveResult struct {
+	// Repository is the archive repository the artifacts were copied to.
+	Repository string
+	// Tags lists the archived tags, sorted.
+	Tags []string
+	// Digests lists the manifest digests deleted from the source
+	// repository, sorted.
+	Digests []string
+}
+
+// ArchiveNamespace returns the archive namespace of a registry base, e.g.
+// "gsoci.azurecr.io/giantswarm/klaus-plugins-archive" for
+// DefaultPluginRegistry.
+func ArchiveNamespace(base string) string {
+	return strings.TrimSuffix(base, "/") + ArchiveSuffix
+}
+
+// ArchiveRepository returns the repository an artifact repository is
+// archived to: the repository of the same name in the archive namespace of
+// its parent. For example,
+// "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base" is archived to
+// "gsoci.azurecr.io/giantswarm/klaus-plugins-archive/gs-base". It returns
+// "" for repositories directly under the registry host and for
+// repositories that are already archived.
+func ArchiveRepository(repository string) string {
+	parent, name, ok := cutLast(repository, "/")
+	if !ok || !strings.Contains(parent, "/") || strings.HasSuffix(parent, ArchiveSuffix) {
+		return ""
+	}
+	return ArchiveNamespace(parent) + "/" + name
+}
+
+// cutLast slices s around the last instance of sep.
+func cutLast(s, sep string) (before, after string, found bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:i], s[i+len(sep):], true
+}
+
+// Archive soft-deletes obsolete artifacts by moving them to the archive
+// repository (see ArchiveRepository), from where they can still be pulled
+// or copied back. With a tag or digest, ref selects one manifest, which is
+// archived with every tag pointing at it; a bare repository archives all
+// its tags. Archived manifests are copied with their blobs, then deleted
+// from the source repository by digest, which removes their tags there.
+// Referrers such as checksums are not copied.
+//
+// Registries known not to support deletion (see Client.Supports) are
+// rejected before anything is copied. If a deletion fails after the copy,
+// the error says so; archiving again is safe, since copies are
+// idempotent.
+func (c *Client) Archive(ctx context.Context, ref string) (*ArchiveResult, error) {
+	ref = strings.TrimSpace(ref)
+	repository := RepositoryFromRef(ref)
+	archive := ArchiveRepository(repository)
+	if archive == "" {
+		return nil, fmt.Errorf("cannot archive %s: repository has no archivable namespace", repository)
+	}
+	host, path, _ := strings.Cut(repository, "/")
+	if c.supports(ctx, host, path, FeatureDelete) == Unsupported {
+		return nil, fmt.Errorf("cannot archive %s: %s does not support deleting manifests", ref, host)
+	}
+
+	src, err := c.newRepositoryFromName(repository)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := c.newRepositoryFromName(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := c.List(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	descs := map[string]ocispec.Descriptor{}
+	tagsOf := map[string][]string{}
+	for _, tag := range tags {
+		desc, err := src.Resolve(ctx, tag)
+		if err != nil {
+			return nil, fmt.Errorf("resolving %s:%s: %w", repository, tag, err)
+		}
+		descs[desc.Digest.String()] = desc
+		tagsOf[desc.Digest.String()] = append(tagsOf[desc.Digest.String()], tag)
+	}
+
+	var digests []string
+	if hasTagOrDigest(ref) {
+		reference := extractTag(ref)
+		if hasDigest(ref) {
+			reference = digestFromRef(ref)
+		}
+		desc, err := src.Resolve(ctx, reference)
+		if err != nil {
+			return nil, fmt.Errorf("resolving %s: %w", ref, err)
+		}
+		descs[desc.Digest.String()] = desc
+		digests = []string{desc.Digest.String()}
+	} else {
+		for d := range tagsOf {
+			digests = append(digests, d)
+		}
+		if len(digests) == 0 {
+			return nil, fmt.Errorf("cannot archive %s: repository has no tags", repository)
+		}
+	}
+	slices.Sort(digests)
+
+	result := &ArchiveResult{Repository: archive}
+	for _, d := range digests {
+		if err := c.archiveManifest(ctx, repository, src, dst, descs[d], tagsOf[d]); err != nil {
+			return nil, err
+		}
+		result.Tags = append(result.Tags, tagsOf[d]...)
+	}
+	slices.Sort(result.Tags)
+
+	for _, d := range digests {
+		if err := src.Delete(ctx, descs[d]); err != nil {
+			return nil, fmt.Errorf("archived %s to %s, but deleting %s from the source failed: %w", ref, archive, d, err)
+		}
+		result.Digests = append(result.Digests, d)
+	}
+	return result, nil
+}
+
+// archiveManifest copies the manifest desc with its blobs from src, the
+// repository named repository, to dst and tags it there with tags.
+func (c *Client) archiveManifest(ctx context.Context, repository string, src, dst oras.Target, desc ocispec.Descriptor, tags []string) (err error) {
+	ref := repository + "@" + desc.Digest.String()
+	defer func() {
+		c.recordAudit(ctx, AuditRecord{Operation: AuditArchive, Ref: ref, Digest: desc.Digest.String(), Tags: tags}, err)
+	}()
+
+	if _, err = oras.Copy(ctx, src, desc.Digest.String(), dst, desc.Digest.String(), oras.DefaultCopyOptions); err != nil {
+		return fmt.Errorf("copying %s to the archive: %w", ref, err)
+	}
+	for _, tag := range tags {
+		if err = dst.Tag(ctx, desc, tag); err != nil {
+			return fmt.Errorf("tagging archived %s as %s: %w", ref, tag, err)
+		}
+	}
+	return nil
+}
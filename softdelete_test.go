@@ -0,0 +1,91 @@
+package oci
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestArchive(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	sink := &memoryAuditSink{}
+	client := NewClient(WithPlainHTTP(true), WithAuditSink(sink))
+	base := host + "/klaus-personalities"
+	repository := base + "/sre"
+	digests := pushVersions(t, client, repository, "v1.0.0", "v1.1.0")
+	if err := client.Retag(t.Context(), repository, digests["v1.0.0"], "legacy"); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := client.Archive(t.Context(), repository+":v1.0.0")
+	if err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+	archive := host + "/klaus-personalities-archive/sre"
+	if result.Repository != archive || !slices.Equal(result.Tags, []string{"legacy", "v1.0.0"}) ||
+		!slices.Equal(result.Digests, []string{digests["v1.0.0"]}) {
+		t.Errorf("Archive() = %+v", result)
+	}
+
+	tags, err := client.List(t.Context(), repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(tags, []string{"v1.1.0"}) {
+		t.Errorf("source tags = %v, want [v1.1.0]", tags)
+	}
+	if records := sink.all(); records[len(records)-1].Operation != AuditArchive {
+		t.Errorf("last audit record = %+v, want an archive", records[len(records)-1])
+	}
+	if _, err := client.PullPersonality(t.Context(), archive+":v1.0.0", t.TempDir()); err != nil {
+		t.Errorf("pulling archived personality: %v", err)
+	}
+
+	entries, err := client.ListPersonalities(t.Context(), WithRegistry(base))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Archived {
+		t.Errorf("ListPersonalities() = %+v, want the active sre only", entries)
+	}
+	entries, err = client.ListPersonalities(t.Context(), WithRegistry(base), WithArchived())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || !entries[0].Archived || entries[0].Version != "v1.0.0" || entries[1].Archived {
+		t.Errorf("ListPersonalities(WithArchived) = %+v, want active and archived sre", entries)
+	}
+
+	// Archiving the bare repository moves the remaining tags.
+	result, err = client.Archive(t.Context(), repository)
+	if err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+	if !slices.Equal(result.Tags, []string{"v1.1.0"}) {
+		t.Errorf("Archive() tags = %v, want [v1.1.0]", result.Tags)
+	}
+	if _, err := client.Archive(t.Context(), archive); err == nil {
+		t.Error("Archive() of an archived repository succeeded")
+	}
+}
+
+func TestArchive_DeleteUnsupported(t *testing.T) {
+	client := NewClient()
+	if _, err := client.Archive(t.Context(), "ghcr.io/acme/klaus-plugins/gs-base:v1.0.0"); err == nil {
+		t.Fatal("Archive() on a registry without deletion succeeded")
+	}
+}
+
+func TestArchiveRepository(t *testing.T) {
+	tests := map[string]string{
+		DefaultPluginRegistry + "/gs-base":         DefaultPluginRegistry + "-archive/gs-base",
+		"example.com/team/klaus-personalities/sre": "example.com/team/klaus-personalities-archive/sre",
+		"example.com/sre":                          "",
+		"example.com/klaus-plugins-archive/gs-old": "",
+	}
+	for repository, want := range tests {
+		if got := ArchiveRepository(repository); got != want {
+			t.Errorf("ArchiveRepository(%s) = %q, want %q", repository, got, want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package oci
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// soulValuesFile holds default values for the SOUL.md template, in the
+// personality content layer.
+const soulValuesFile = "soul.values.yaml"
+
+// PullOption configures pull operations.
+type PullOption func(*pullConfig)
+
+type pullConfig struct {
+	soulValues  map[string]any
+	soulVariant string
+
+	// allowQuarantined permits pulling quarantined artifacts; see
+	// WithAllowQuarantined.
+	allowQuarantined bool
+
+	// manifest is the manifest PullAny fetched to detect the kind, which
+	// the pull reuses instead of fetching it again.
+	manifest *prefetchedManifest
+}
+
+func newPullConfig(opts []PullOption) *pullConfig {
+	cfg := &pullConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithSoulValues renders SOUL.md as a Go text/template with the given
+// values, for instance-specific details such as the cluster name or team.
+// The values are merged over the defaults in the personality's
+// soul.values.yaml, nested maps key by key. Templates referencing a value
+// that is defined in neither place fail to render. Only
+// PulledPersonality.Soul is rendered; the extracted files are left as
+// published.
+func WithSoulValues(values map[string]any) PullOption {
+	return func(cfg *pullConfig) { cfg.soulValues = values }
+}
+
+// WithSoulVariant selects the soul variant SOUL.<variant>.md (e.g.
+// "concise" for SOUL.concise.md), letting one personality carry souls tuned
+// for different locales or model budgets. When the personality has no such
+// variant, PullPersonality falls back to SOUL.md and leaves
+// PulledPersonality.SoulVariant empty.
+func WithSoulVariant(variant string) PullOption {
+	return func(cfg *pullConfig) { cfg.soulVariant = variant }
+}
+
+// RenderSoul renders a SOUL.md template with values. It is the rendering
+// used by WithSoulValues, for callers that obtain the soul text elsewhere.
+func RenderSoul(soul string, values map[string]any) (string, error) {
+	tmpl, err := template.New("SOUL.md").Option("missingkey=error").Parse(soul)
+	if err != nil {
+		return "", fmt.Errorf("parsing SOUL.md template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, values); err != nil {
+		return "", fmt.Errorf("rendering SOUL.md: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// readSoulValues reads soul.values.yaml from dir. A missing file yields no
+// defaults.
+func readSoulValues(dir string) (map[string]any, error) {
+	data, err := os.ReadFile(filepath.Join(dir, soulValuesFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", soulValuesFile, err)
+	}
+	var values map[string]any
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", soulValuesFile, err)
+	}
+	return values, nil
+}
+
+// mergeValues returns defaults overlaid with overrides. Nested maps are
+// merged recursively; any other override value replaces the default.
+// Neither input is modified.
+func mergeValues(defaults, overrides map[string]any) map[string]any {
+	out := make(map[string]any, len(defaults)+len(overrides))
+	for k, v := range defaults {
+		out[k] = v
+	}
+	for k, v := range overrides {
+		dm, dok := out[k].(map[string]any)
+		om, ook := v.(map[string]any)
+		if dok && ook {
+			out[k] = mergeValues(dm, om)
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// soulVariantFile returns the file name of a soul variant.
+func soulVariantFile(variant string) string {
+	return "SOUL." + variant + ".md"
+}
+
+// discoverSoulVariants returns the sorted variant names of the
+// SOUL.<variant>.md files in dir.
+func discoverSoulVariants(dir string) []string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "SOUL.*.md"))
+	var variants []string
+	for _, m := range matches {
+		v := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(m), "SOUL."), ".md")
+		if v != "" {
+			variants = append(variants, v)
+		}
+	}
+	slices.Sort(variants)
+	return variants
+}
+
+// selectSoulVariant replaces p.Soul with the requested variant when the
+// personality provides it.
+func selectSoulVariant(p *PulledPersonality, cfg *pullConfig) error {
+	if cfg.soulVariant == "" {
+		return nil
+	}
+	if strings.ContainsAny(cfg.soulVariant, `/\`) || strings.Contains(cfg.soulVariant, "..") {
+		return fmt.Errorf("invalid soul variant %q", cfg.soulVariant)
+	}
+	data, err := os.ReadFile(filepath.Join(p.Dir, soulVariantFile(cfg.soulVariant)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", soulVariantFile(cfg.soulVariant), err)
+	}
+	p.Soul = string(data)
+	p.SoulVariant = cfg.soulVariant
+	return nil
+}
+
+// renderPulledSoul renders p.Soul when soul values were requested.
+func renderPulledSoul(p *PulledPersonality, cfg *pullConfig) error {
+	if cfg.soulValues == nil {
+		return nil
+	}
+	defaults, err := readSoulValues(p.Dir)
+	if err != nil {
+		return err
+	}
+	rendered, err := RenderSoul(p.Soul, mergeValues(defaults, cfg.soulValues))
+	if err != nil {
+		return err
+	}
+	p.Soul = rendered
+	return nil
+}
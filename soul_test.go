@@ -0,0 +1,135 @@
+package oci
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRenderSoul(t *testing.T) {
+	got, err := RenderSoul("You operate {{ .cluster.name }} for team {{ .team }}.", map[string]any{
+		"cluster": map[string]any{"name": "golem"},
+		"team":    "phoenix",
+	})
+	if err != nil {
+		t.Fatalf("RenderSoul() error = %v", err)
+	}
+	if want := "You operate golem for team phoenix."; got != want {
+		t.Errorf("RenderSoul() = %q, want %q", got, want)
+	}
+
+	if _, err := RenderSoul("{{ .missing }}", map[string]any{}); err == nil {
+		t.Error("expected error for missing value")
+	}
+	if _, err := RenderSoul("{{ .broken", nil); err == nil {
+		t.Error("expected error for invalid template")
+	}
+}
+
+func TestMergeValues(t *testing.T) {
+	defaults := map[string]any{
+		"team":    "platform",
+		"cluster": map[string]any{"name": "default", "provider": "capa"},
+	}
+	overrides := map[string]any{
+		"cluster": map[string]any{"name": "golem"},
+		"extra":   true,
+	}
+	got := mergeValues(defaults, overrides)
+
+	cluster := got["cluster"].(map[string]any)
+	if cluster["name"] != "golem" || cluster["provider"] != "capa" {
+		t.Errorf("cluster = %v, want name overridden and provider kept", cluster)
+	}
+	if got["team"] != "platform" || got["extra"] != true {
+		t.Errorf("merged = %v", got)
+	}
+	if defaults["cluster"].(map[string]any)["name"] != "default" {
+		t.Error("mergeValues modified defaults")
+	}
+}
+
+func TestPullPersonality_WithSoulValues(t *testing.T) {
+	reg := newCacheRegistry()
+	blob, _ := json.Marshal(personalityConfigBlob{})
+	addPullableArtifact(t, reg, "klaus-personalities/sre", "v1.0.0", personalityArtifact, blob, map[string]string{
+		"SOUL.md":          "You operate {{ .cluster.name }} ({{ .cluster.provider }}) for team {{ .team }}.",
+		"soul.values.yaml": "team: platform\ncluster:\n  name: default\n  provider: capa\n",
+	})
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	ref := host + "/klaus-personalities/sre:v1.0.0"
+
+	t.Run("rendered", func(t *testing.T) {
+		pulled, err := client.PullPersonality(t.Context(), ref, t.TempDir(),
+			WithSoulValues(map[string]any{"cluster": map[string]any{"name": "golem"}}))
+		if err != nil {
+			t.Fatalf("PullPersonality() error = %v", err)
+		}
+		if want := "You operate golem (capa) for team platform."; pulled.Soul != want {
+			t.Errorf("Soul = %q, want %q", pulled.Soul, want)
+		}
+	})
+
+	t.Run("raw without values", func(t *testing.T) {
+		pulled, err := client.PullPersonality(t.Context(), ref, t.TempDir())
+		if err != nil {
+			t.Fatalf("PullPersonality() error = %v", err)
+		}
+		if !strings.Contains(pulled.Soul, "{{ .cluster.name }}") {
+			t.Errorf("Soul = %q, want unrendered template", pulled.Soul)
+		}
+	})
+}
+
+func TestPullPersonality_WithSoulVariant(t *testing.T) {
+	reg := newCacheRegistry()
+	blob, _ := json.Marshal(personalityConfigBlob{})
+	addPullableArtifact(t, reg, "klaus-personalities/sre", "v1.0.0", personalityArtifact, blob, map[string]string{
+		"SOUL.md":         "You are a thorough SRE for {{ .team }}.",
+		"SOUL.concise.md": "Be brief, {{ .team }}.",
+		"SOUL.de.md":      "Du bist SRE.",
+	})
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	ref := host + "/klaus-personalities/sre:v1.0.0"
+
+	tests := []struct {
+		name        string
+		opts        []PullOption
+		wantSoul    string
+		wantVariant string
+	}{
+		{name: "default", wantSoul: "You are a thorough SRE for {{ .team }}."},
+		{name: "variant", opts: []PullOption{WithSoulVariant("de")}, wantSoul: "Du bist SRE.", wantVariant: "de"},
+		{name: "missing variant falls back", opts: []PullOption{WithSoulVariant("fr")}, wantSoul: "You are a thorough SRE for {{ .team }}."},
+		{
+			name:        "variant rendered",
+			opts:        []PullOption{WithSoulVariant("concise"), WithSoulValues(map[string]any{"team": "phoenix"})},
+			wantSoul:    "Be brief, phoenix.",
+			wantVariant: "concise",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pulled, err := client.PullPersonality(t.Context(), ref, t.TempDir(), tt.opts...)
+			if err != nil {
+				t.Fatalf("PullPersonality() error = %v", err)
+			}
+			if pulled.Soul != tt.wantSoul {
+				t.Errorf("Soul = %q, want %q", pulled.Soul, tt.wantSoul)
+			}
+			if pulled.SoulVariant != tt.wantVariant {
+				t.Errorf("SoulVariant = %q, want %q", pulled.SoulVariant, tt.wantVariant)
+			}
+			if want := []string{"concise", "de"}; !slices.Equal(pulled.SoulVariants, want) {
+				t.Errorf("SoulVariants = %v, want %v", pulled.SoulVariants, want)
+			}
+		})
+	}
+
+	if _, err := client.PullPersonality(t.Context(), ref, t.TempDir(), WithSoulVariant("../x")); err == nil {
+		t.Error("expected error for variant with path separator")
+	}
+}
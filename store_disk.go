@@ -21,6 +21,7 @@ import (
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"golang.org/x/sync/singleflight"
+	"oras.land/oras-go/v2/content"
 	orasoci "oras.land/oras-go/v2/content/oci"
 	"oras.land/oras-go/v2/registry/remote/auth"
 )
@@ -279,23 +280,59 @@ func (d *diskCache) Repositories(ctx context.Context, base string) ([]string, er
 const maxUnknownSizeBytes int64 = 64 * 1024 * 1024
 
 // Fetch serves a manifest or blob from the content store. Misses are
-// satisfied by fetching from the registry, verifying the digest, and
-// inserting into the store. Descriptors with an unknown size bypass the
-// content store (oras-go's Push requires exact size for verification)
-// but the response is still digest-verified in process before being
-// returned to the caller.
+// satisfied by streaming the content from the registry into the store,
+// which verifies its size and digest, so layers are never held in memory.
+// Blobs too large for the store's size limit are evicted right away and
+// streamed from the registry instead, verified as they are read.
+// Descriptors with an unknown size bypass the content store (oras-go's
+// Push requires exact size for verification); their content is read into
+// memory, bounded by maxUnknownSizeBytes, and digest-verified in process
+// before being returned to the caller.
 func (d *diskCache) Fetch(ctx context.Context, repo string, desc ocispec.Descriptor) (io.ReadCloser, error) {
 	if err := desc.Digest.Validate(); err != nil {
 		return nil, fmt.Errorf("cache: invalid descriptor digest: %w", err)
 	}
+	host, name := splitHostPath(repo)
 	if desc.Size > 0 {
 		if exists, err := d.storage.Exists(ctx, desc); err == nil && exists {
 			return d.storage.Fetch(ctx, desc)
 		}
+		_, err, _ := d.sf.Do("blob:"+desc.Digest.String(), func() (any, error) {
+			if host == "" || name == "" {
+				return nil, fmt.Errorf("cache: invalid repository %q", repo)
+			}
+			rc, err := d.fetchContent(ctx, host, name, desc)
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			err = d.storage.Push(ctx, desc, rc)
+			switch {
+			case err == nil || isAlreadyExists(err):
+			case errors.Is(err, content.ErrMismatchedDigest):
+				return nil, fmt.Errorf("cache: digest mismatch for %s: %w", desc.Digest, err)
+			case errors.Is(err, io.ErrUnexpectedEOF):
+				return nil, fmt.Errorf("cache: short read for %s: %w", desc.Digest, err)
+			default:
+				return nil, fmt.Errorf("storing %s: %w", desc.Digest, err)
+			}
+			d.evictIfNeeded()
+			return nil, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if rc, err := d.storage.Fetch(ctx, desc); err == nil {
+			return rc, nil
+		}
+		rc, err := d.fetchContent(ctx, host, name, desc)
+		if err != nil {
+			return nil, err
+		}
+		return verifiedReadCloser{vr: content.NewVerifyReader(rc, desc), Closer: rc}, nil
 	}
 
 	v, err, _ := d.sf.Do("blob:"+desc.Digest.String(), func() (any, error) {
-		host, name := splitHostPath(repo)
 		if host == "" || name == "" {
 			return nil, fmt.Errorf("cache: invalid repository %q", repo)
 		}
@@ -305,37 +342,19 @@ func (d *diskCache) Fetch(ctx context.Context, repo string, desc ocispec.Descrip
 		}
 		defer rc.Close()
 
-		var limit int64
-		if desc.Size > 0 {
-			limit = desc.Size
-		} else {
-			limit = maxUnknownSizeBytes
-		}
 		// Read one byte past the limit so an oversize body fails fast
 		// rather than silently truncating.
-		data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+		data, err := io.ReadAll(io.LimitReader(rc, maxUnknownSizeBytes+1))
 		if err != nil {
 			return nil, fmt.Errorf("reading %s: %w", desc.Digest, err)
 		}
-		if int64(len(data)) > limit {
-			return nil, fmt.Errorf("cache: content for %s exceeds size limit %d", desc.Digest, limit)
-		}
-		if desc.Size > 0 && int64(len(data)) != desc.Size {
-			return nil, fmt.Errorf("cache: short read for %s: got %d want %d", desc.Digest, len(data), desc.Size)
+		if int64(len(data)) > maxUnknownSizeBytes {
+			return nil, fmt.Errorf("cache: content for %s exceeds size limit %d", desc.Digest, maxUnknownSizeBytes)
 		}
 		// Always verify the digest before the bytes leave this function.
-		// The oras-go content store would do this on Push, but we cannot
-		// rely on Push for the unknown-size path.
 		if err := verifyDigest(desc.Digest, data); err != nil {
 			return nil, err
 		}
-		if desc.Size > 0 {
-			pushErr := d.storage.Push(ctx, desc, bytes.NewReader(data))
-			if pushErr != nil && !isAlreadyExists(pushErr) {
-				return nil, fmt.Errorf("storing %s: %w", desc.Digest, pushErr)
-			}
-			d.evictIfNeeded()
-		}
 		return data, nil
 	})
 	if err != nil {
@@ -345,6 +364,23 @@ func (d *diskCache) Fetch(ctx context.Context, repo string, desc ocispec.Descrip
 	return io.NopCloser(bytes.NewReader(data)), nil
 }
 
+// verifiedReadCloser verifies the content read through vr against its
+// descriptor once reading reaches the end.
+type verifiedReadCloser struct {
+	vr *content.VerifyReader
+	io.Closer
+}
+
+func (v verifiedReadCloser) Read(p []byte) (int, error) {
+	n, err := v.vr.Read(p)
+	if err == io.EOF {
+		if verr := v.vr.Verify(); verr != nil {
+			return n, verr
+		}
+	}
+	return n, err
+}
+
 // verifyDigest returns an error if the sha256 of data does not match d.
 // Only sha256 digests are supported; anything else is rejected.
 func verifyDigest(d digest.Digest, data []byte) error {
@@ -486,18 +522,24 @@ func readCatalogIndex(path string) (catalogIndexEntry, bool) {
 // on the same path each produce a valid final state -- the later renamer
 // wins.
 func writeIndexAtomic(path string, v any) error {
+	return writeJSONAtomic(path, v, 0o755, 0o644)
+}
+
+// writeJSONAtomic is writeIndexAtomic with the given permissions for
+// created directories and the file.
+func writeJSONAtomic(path string, v any, dirPerm, perm os.FileMode) error {
 	data, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
 		return err
 	}
 	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
 		return err
 	}
 	var suffix [8]byte
 	_, _ = rand.Read(suffix[:])
 	tmp := filepath.Join(dir, filepath.Base(path)+".tmp."+hex.EncodeToString(suffix[:]))
-	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
 	if err != nil {
 		return err
 	}
@@ -22,8 +22,9 @@ import (
 )
 
 // cacheRegistry is a minimal OCI distribution API server with optional
-// ETag support for tag listing, HEAD support for manifests, and request
-// counters for testing caching behaviour.
+// ETag support for tag listing, HEAD support for manifests, monolithic
+// blob and manifest uploads, manifest deletion, and request counters for
+// testing caching behaviour.
 type cacheRegistry struct {
 	mu sync.Mutex
 
@@ -131,6 +132,62 @@ func (r *cacheRegistry) handler() http.Handler {
 			w.Header().Set("ETag", etag)
 			_ = json.NewEncoder(w).Encode(map[string]any{"name": repo, "tags": tags})
 			return
+		case req.Method == http.MethodPost && strings.HasSuffix(path, "/blobs/uploads/"):
+			w.Header().Set("Location", path+"upload")
+			w.WriteHeader(http.StatusAccepted)
+			return
+		case req.Method == http.MethodPut && strings.HasSuffix(path, "/blobs/uploads/upload"):
+			body, _ := io.ReadAll(req.Body)
+			digest := r.addBlob(body)
+			if want := req.URL.Query().Get("digest"); want != digest {
+				http.Error(w, "digest mismatch", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", digest)
+			w.WriteHeader(http.StatusCreated)
+			return
+		case req.Method == http.MethodPut && strings.Contains(path, "/manifests/"):
+			idx := strings.Index(path, "/manifests/")
+			repo := strings.TrimPrefix(path[:idx], "/v2/")
+			ref := path[idx+len("/manifests/"):]
+			body, _ := io.ReadAll(req.Body)
+			var digest string
+			if strings.HasPrefix(ref, "sha256:") {
+				digest = "sha256:" + sum256Hex(body)
+				r.mu.Lock()
+				r.manifests[digest] = body
+				if r.repos[repo] == nil {
+					r.repos[repo] = map[string]string{}
+				}
+				if !contains(r.catalogRepos, repo) {
+					r.catalogRepos = append(r.catalogRepos, repo)
+					sort.Strings(r.catalogRepos)
+				}
+				r.mu.Unlock()
+			} else {
+				digest = r.addManifest(repo, ref, body)
+			}
+			w.Header().Set("Docker-Content-Digest", digest)
+			w.WriteHeader(http.StatusCreated)
+			return
+		case req.Method == http.MethodDelete && strings.Contains(path, "/manifests/sha256:"):
+			idx := strings.Index(path, "/manifests/")
+			repo := strings.TrimPrefix(path[:idx], "/v2/")
+			digest := path[idx+len("/manifests/"):]
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if r.manifests[digest] == nil {
+				http.NotFound(w, req)
+				return
+			}
+			for tag, d := range r.repos[repo] {
+				if d == digest {
+					delete(r.repos[repo], tag)
+				}
+			}
+			r.tagListETag[repo] = fmt.Sprintf("%q", sum256Hex([]byte(fmt.Sprintf("%v", r.repos[repo])))[:12])
+			w.WriteHeader(http.StatusAccepted)
+			return
 		case strings.Contains(path, "/manifests/"):
 			idx := strings.Index(path, "/manifests/")
 			repo := strings.TrimPrefix(path[:idx], "/v2/")
@@ -147,8 +204,15 @@ func (r *cacheRegistry) handler() http.Handler {
 				http.NotFound(w, req)
 				return
 			}
+			mediaType := ocispec.MediaTypeImageManifest
+			var probe struct {
+				MediaType string `json:"mediaType"`
+			}
+			if json.Unmarshal(body, &probe) == nil && probe.MediaType != "" {
+				mediaType = probe.MediaType
+			}
 			w.Header().Set("Docker-Content-Digest", digest)
-			w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+			w.Header().Set("Content-Type", mediaType)
 			w.Header().Set("Content-Length", fmt.Sprintf("%d", len(body)))
 			if req.Method == http.MethodHead {
 				r.headCount.Add(1)
@@ -0,0 +1,87 @@
+package oci
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// maxSuggestions is the number of names suggested by ErrUnknownArtifact.
+const maxSuggestions = 3
+
+// resolveWithSuggestions resolves ref like resolveArtifactRef, listing
+// tags with lister. When a short name fails to resolve because no such
+// repository exists under registryBase, the error is wrapped in
+// *ErrUnknownArtifact with the closest existing names.
+func (c *Client) resolveWithSuggestions(ctx context.Context, lister tagLister, ref, registryBase string) (string, error) {
+	resolved, err := resolveArtifactRef(ctx, lister, ref, registryBase)
+	ref = strings.TrimSpace(ref)
+	if err == nil || ref == "" || strings.Contains(ref, "/") || ctx.Err() != nil {
+		return resolved, err
+	}
+
+	name, _ := SplitNameTag(ref)
+	repos, lerr := c.listRepositories(ctx, registryBase)
+	if lerr != nil {
+		return "", err
+	}
+	names := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		if repo == registryBase+"/"+name {
+			// The repository exists; the error is about its tags.
+			return "", err
+		}
+		names = append(names, strings.TrimPrefix(repo, registryBase+"/"))
+	}
+	return "", &ErrUnknownArtifact{Name: name, Suggestions: closestNames(name, names, maxSuggestions), Err: err}
+}
+
+// closestNames returns up to n of candidates closest to name by edit
+// distance, best first. Candidates further away than half of name's
+// length are not considered similar.
+func closestNames(name string, candidates []string, n int) []string {
+	type scored struct {
+		name     string
+		distance int
+	}
+	limit := max(len(name)/2, 1)
+	var matches []scored
+	for _, c := range candidates {
+		if d := levenshtein(name, c); d <= limit {
+			matches = append(matches, scored{c, d})
+		}
+	}
+	slices.SortFunc(matches, func(a, b scored) int {
+		if a.distance != b.distance {
+			return a.distance - b.distance
+		}
+		return strings.Compare(a.name, b.name)
+	})
+	var names []string
+	for _, m := range matches[:min(n, len(matches))] {
+		names = append(names, m.name)
+	}
+	return names
+}
+
+// levenshtein returns the edit distance between a and b in runes.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,101 @@
+package oci
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestResolveWithSuggestions(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	base := host + "/klaus-plugins"
+	pushVersions(t, client, base+"/gs-base", "v1.0.0")
+	pushVersions(t, client, base+"/gs-flux", "v1.0.0")
+	pushVersions(t, client, base+"/kubernetes", "v1.0.0")
+
+	got, err := client.resolveWithSuggestions(t.Context(), client, "gs-base", base)
+	if err != nil {
+		t.Fatalf("resolveWithSuggestions() error = %v", err)
+	}
+	if want := base + "/gs-base:v1.0.0"; got != want {
+		t.Errorf("resolveWithSuggestions() = %q, want %q", got, want)
+	}
+
+	_, err = client.resolveWithSuggestions(t.Context(), client, "gs-bsae", base)
+	var unknown *ErrUnknownArtifact
+	if !errors.As(err, &unknown) {
+		t.Fatalf("resolveWithSuggestions() error = %v, want *ErrUnknownArtifact", err)
+	}
+	if unknown.Name != "gs-bsae" {
+		t.Errorf("Name = %q, want %q", unknown.Name, "gs-bsae")
+	}
+	if want := []string{"gs-base"}; !slices.Equal(unknown.Suggestions, want) {
+		t.Errorf("Suggestions = %v, want %v", unknown.Suggestions, want)
+	}
+
+	_, err = client.resolveWithSuggestions(t.Context(), client, "zzzzzzzzzz", base)
+	if !errors.As(err, &unknown) {
+		t.Fatalf("resolveWithSuggestions() error = %v, want *ErrUnknownArtifact", err)
+	}
+	if len(unknown.Suggestions) != 0 {
+		t.Errorf("Suggestions = %v, want none", unknown.Suggestions)
+	}
+}
+
+func TestResolveWithSuggestions_FullReference(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	base := host + "/klaus-plugins"
+	pushVersions(t, client, base+"/gs-base", "v1.0.0")
+
+	_, err := client.resolveWithSuggestions(t.Context(), client, base+"/gs-bsae", base)
+	if err == nil {
+		t.Fatal("resolveWithSuggestions() error = nil, want error")
+	}
+	var unknown *ErrUnknownArtifact
+	if errors.As(err, &unknown) {
+		t.Errorf("full references should not get suggestions, got %v", err)
+	}
+}
+
+func TestClosestNames(t *testing.T) {
+	candidates := []string{"gs-base", "gs-flux", "gs-ae", "kubernetes"}
+	tests := []struct {
+		name string
+		n    int
+		want []string
+	}{
+		{name: "gs-bsae", n: 3, want: []string{"gs-ae", "gs-base"}},
+		{name: "gs-bsae", n: 1, want: []string{"gs-ae"}},
+		{name: "kubernetis", n: 3, want: []string{"kubernetes"}},
+		{name: "terraform", n: 3, want: nil},
+	}
+	for _, tt := range tests {
+		if got := closestNames(tt.name, candidates, tt.n); !slices.Equal(got, tt.want) {
+			t.Errorf("closestNames(%q, %d) = %v, want %v", tt.name, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"gs-base", "gs-base", 0},
+		{"gs-base", "gs-bsae", 2},
+		{"kitten", "sitting", 3},
+		{"ünï", "uni", 2},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
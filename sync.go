@@ -0,0 +1,114 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// syncInterval is how often SyncLocalPlugin checks the source directory
+// for changes. It is a variable so tests can shorten it.
+var syncInterval = 500 * time.Millisecond
+
+// SyncEvent reports a synchronization of SyncLocalPlugin.
+type SyncEvent struct {
+	// ContentHash is the content-tree hash of the synchronized content
+	// (see ContentTreeHash), empty if the source could not be hashed.
+	ContentHash string
+	// Time is when the synchronization finished.
+	Time time.Time
+	// Err is set if the synchronization failed. The target directory
+	// then keeps its previous content, and the next change is retried.
+	Err error
+}
+
+// SyncLocalPlugin keeps targetDir in sync with the plugin source directory
+// dir for hot-reload development loops against a running agent. It
+// synchronizes once, then watches dir and synchronizes again whenever its
+// content-tree hash changes, until ctx is done. Each synchronization
+// packages dir and extracts the result into targetDir with the client's
+// extraction policy, so targetDir holds exactly what pushing dir and
+// pulling it would produce. targetDir is replaced at once, never leaving
+// a mix of old and new files, and holds no pull cache metadata, so a
+// later pull into it downloads again.
+//
+// Each synchronization is reported on events unless events is nil; sends
+// block until received or ctx is done. SyncLocalPlugin returns an error
+// if the first synchronization fails, and nil once ctx is done.
+func (c *Client) SyncLocalPlugin(ctx context.Context, dir, targetDir string, events chan<- SyncEvent) error {
+	hash, err := c.syncLocalDir(dir, targetDir)
+	if err != nil {
+		return err
+	}
+	if !sendSyncEvent(ctx, events, SyncEvent{ContentHash: hash, Time: time.Now()}) {
+		return nil
+	}
+
+	// reported is the hash of the last reported content, empty if it
+	// could not be hashed, so failures are reported once per change.
+	synced, reported := hash, hash
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		current, err := ContentTreeHash(dir)
+		if current == reported {
+			continue
+		}
+		reported = current
+		if err == nil && current != synced {
+			if _, err = c.syncLocalDir(dir, targetDir); err == nil {
+				synced = current
+			}
+		}
+		if !sendSyncEvent(ctx, events, SyncEvent{ContentHash: current, Time: time.Now(), Err: err}) {
+			return nil
+		}
+	}
+}
+
+// syncLocalDir packages dir and extracts it into targetDir, and returns
+// the content-tree hash of dir as of before packaging.
+func (c *Client) syncLocalDir(dir, targetDir string) (string, error) {
+	hash, err := ContentTreeHash(dir)
+	if err != nil {
+		return "", err
+	}
+	layer, err := createTarGz(dir)
+	if err != nil {
+		return "", fmt.Errorf("packaging %s: %w", dir, err)
+	}
+	staged, err := stagingDir(targetDir)
+	if err != nil {
+		return "", err
+	}
+	if err := extractTarGz(bytes.NewReader(layer), staged, c.extraction); err != nil {
+		os.RemoveAll(staged)
+		return "", fmt.Errorf("extracting %s: %w", dir, err)
+	}
+	if err := replaceDir(staged, targetDir); err != nil {
+		os.RemoveAll(staged)
+		return "", err
+	}
+	return hash, nil
+}
+
+// sendSyncEvent sends e on events unless events is nil, and reports
+// false if ctx is done first.
+func sendSyncEvent(ctx context.Context, events chan<- SyncEvent, e SyncEvent) bool {
+	if events == nil {
+		return ctx.Err() == nil
+	}
+	select {
+	case events <- e:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,85 @@
+package oci
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSyncLocalPlugin(t *testing.T) {
+	interval := syncInterval
+	syncInterval = 10 * time.Millisecond
+	t.Cleanup(func() { syncInterval = interval })
+
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, ".claude-plugin", "plugin.json"), `{"name":"gs-base"}`)
+	writeFile(t, filepath.Join(src, "skills", "kubectl", "SKILL.md"), "# kubectl")
+	target := filepath.Join(t.TempDir(), "gs-base")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	events := make(chan SyncEvent)
+	done := make(chan error, 1)
+	go func() { done <- NewClient().SyncLocalPlugin(ctx, src, target, events) }()
+
+	next := func() SyncEvent {
+		t.Helper()
+		select {
+		case e := <-events:
+			if e.Err != nil {
+				t.Fatalf("sync error = %v", e.Err)
+			}
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatal("no sync event")
+			return SyncEvent{}
+		}
+	}
+	readTarget := func(name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(target, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	first := next()
+	if want, _ := ContentTreeHash(src); first.ContentHash != want {
+		t.Errorf("ContentHash = %s, want %s", first.ContentHash, want)
+	}
+	if got := readTarget("skills/kubectl/SKILL.md"); got != "# kubectl" {
+		t.Errorf("synced SKILL.md = %q", got)
+	}
+
+	writeFile(t, filepath.Join(src, "skills", "kubectl", "SKILL.md"), "# kubectl, edited")
+	writeFile(t, filepath.Join(src, "commands", "hello.md"), "Hello")
+	// The edits may be synchronized one at a time.
+	want, _ := ContentTreeHash(src)
+	second := next()
+	for second.ContentHash != want {
+		second = next()
+	}
+	if got := readTarget("skills/kubectl/SKILL.md"); got != "# kubectl, edited" {
+		t.Errorf("synced SKILL.md = %q after an edit", got)
+	}
+	if got := readTarget("commands/hello.md"); got != "Hello" {
+		t.Errorf("synced hello.md = %q", got)
+	}
+	if hash, err := ContentTreeHash(target); err != nil || hash != second.ContentHash {
+		t.Errorf("target ContentTreeHash() = %s, %v, want %s", hash, err, second.ContentHash)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("SyncLocalPlugin() = %v, want nil after cancellation", err)
+	}
+}
+
+func TestSyncLocalPlugin_MissingSource(t *testing.T) {
+	err := NewClient().SyncLocalPlugin(t.Context(), filepath.Join(t.TempDir(), "missing"), t.TempDir(), nil)
+	if err == nil {
+		t.Error("SyncLocalPlugin() succeeded for a missing source, want error")
+	}
+}
@@ -0,0 +1,139 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	godigest "github.com/opencontainers/go-digest"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// TagIfDigest points tag in repository (e.g.
+// "gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base") at the manifest
+// newDigest, but only while the tag still points at expectedOld. An empty
+// expectedOld requires the tag not to exist yet. When the tag has moved
+// elsewhere, TagIfDigest fails with *ErrTagConflict instead of
+// overwriting it, so two CI runs racing to move a channel tag such as
+// "stable" cannot silently overwrite each other: the loser re-reads the
+// tag and decides again.
+//
+// The operation is idempotent: when the tag already points at newDigest,
+// it succeeds without writing. The tag update is a conditional request
+// (If-Match on the expected digest, or If-None-Match: * for a new tag),
+// which registries that support conditional requests reject with 412
+// Precondition Failed when another writer won the race. Registries that
+// ignore the headers only get the preceding check, leaving a short window
+// between the check and the update.
+func (c *Client) TagIfDigest(ctx context.Context, repository, tag, expectedOld, newDigest string) (err error) {
+	defer func() {
+		c.recordAudit(ctx, AuditRecord{Operation: AuditRetag, Ref: repository + ":" + tag, Digest: newDigest}, err)
+	}()
+
+	d, err := godigest.Parse(newDigest)
+	if err != nil {
+		return fmt.Errorf("invalid digest %q: %w", newDigest, err)
+	}
+	if expectedOld != "" {
+		if _, err := godigest.Parse(expectedOld); err != nil {
+			return fmt.Errorf("invalid expected digest %q: %w", expectedOld, err)
+		}
+	}
+	repo, tag, err := c.newRepository(repository + ":" + tag)
+	if err != nil {
+		return err
+	}
+	if err := (registry.Reference{Reference: tag}).ValidateReferenceAsTag(); err != nil {
+		return err
+	}
+
+	desc, rc, err := repo.FetchReference(ctx, d.String())
+	if err != nil {
+		return fmt.Errorf("fetching %s@%s: %w", repository, d, err)
+	}
+	manifestJSON, err := readBlob(rc, desc, maxManifestBytes)
+	rc.Close()
+	if err != nil {
+		return fmt.Errorf("reading %s@%s: %w", repository, d, err)
+	}
+
+	current, err := tagDigest(ctx, repo, tag)
+	if err != nil {
+		return err
+	}
+	if current == d.String() {
+		return nil
+	}
+	if current != expectedOld {
+		return &ErrTagConflict{Repository: repository, Tag: tag, Expected: expectedOld, Actual: current}
+	}
+
+	status, err := putTagIf(ctx, repo, tag, desc.MediaType, manifestJSON, expectedOld)
+	if err != nil {
+		return fmt.Errorf("tagging %s@%s as %s: %w", repository, d, tag, err)
+	}
+	if status == http.StatusPreconditionFailed {
+		actual, err := tagDigest(ctx, repo, tag)
+		if err != nil {
+			return err
+		}
+		if actual == d.String() {
+			return nil
+		}
+		return &ErrTagConflict{Repository: repository, Tag: tag, Expected: expectedOld, Actual: actual}
+	}
+	return nil
+}
+
+// tagDigest returns the manifest digest tag points at, or "" if the tag
+// does not exist.
+func tagDigest(ctx context.Context, repo *remote.Repository, tag string) (string, error) {
+	desc, err := repo.Resolve(ctx, tag)
+	if errors.Is(err, errdef.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("resolving tag %s: %w", tag, err)
+	}
+	return desc.Digest.String(), nil
+}
+
+// putTagIf uploads manifestJSON under tag on the condition that the tag
+// currently points at expected, or does not exist if expected is empty.
+// It returns http.StatusPreconditionFailed when the registry rejected the
+// condition. The request carries the push scope, so it is authorized like
+// oras' own manifest uploads, with the push credentials where separate.
+func putTagIf(ctx context.Context, repo *remote.Repository, tag, mediaType string, manifestJSON []byte, expected string) (int, error) {
+	ctx = auth.AppendRepositoryScope(ctx, repo.Reference, auth.ActionPull, auth.ActionPush)
+	scheme := "https"
+	if repo.PlainHTTP {
+		scheme = "http"
+	}
+	url := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, repo.Reference.Host(), repo.Reference.Repository, tag)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(manifestJSON))
+	if err != nil {
+		return 0, err
+	}
+	req.ContentLength = int64(len(manifestJSON))
+	req.Header.Set("Content-Type", mediaType)
+	if expected == "" {
+		req.Header.Set("If-None-Match", "*")
+	} else {
+		req.Header.Set("If-Match", `"`+expected+`"`)
+	}
+
+	resp, err := repo.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPreconditionFailed && resp.StatusCode/100 != 2 {
+		return 0, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,163 @@
+package oci
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// newConditionalRegistry serves reg and evaluates If-Match and
+// If-None-Match on manifest uploads by tag against the tag's current
+// digest, like registries that support conditional requests. beforePut,
+// if set, runs before the condition is evaluated.
+func newConditionalRegistry(t *testing.T, reg *cacheRegistry, beforePut func()) (string, *atomic.Int32) {
+	t.Helper()
+	handler := reg.handler()
+	var puts atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		repo, tag, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v2/"), "/manifests/")
+		if r.Method == http.MethodPut && ok && !strings.HasPrefix(tag, "sha256:") {
+			puts.Add(1)
+			if beforePut != nil {
+				beforePut()
+			}
+			reg.mu.Lock()
+			current := reg.repos[repo][tag]
+			reg.mu.Unlock()
+			ifMatch, ifNoneMatch := r.Header.Get("If-Match"), r.Header.Get("If-None-Match")
+			if (ifMatch != "" && ifMatch != `"`+current+`"`) || (ifNoneMatch == "*" && current != "") {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return strings.TrimPrefix(ts.URL, "http://"), &puts
+}
+
+func TestTagIfDigest(t *testing.T) {
+	reg := newCacheRegistry()
+	host, puts := newConditionalRegistry(t, reg, nil)
+	client := NewClient(WithPlainHTTP(true))
+	repository := host + "/klaus/sre"
+	digests := pushVersions(t, client, repository, "v1.0.0", "v1.1.0", "v1.2.0")
+
+	if err := client.TagIfDigest(t.Context(), repository, "stable", "", digests["v1.0.0"]); err != nil {
+		t.Fatalf("TagIfDigest() creating the tag error = %v", err)
+	}
+	if err := client.TagIfDigest(t.Context(), repository, "stable", digests["v1.0.0"], digests["v1.1.0"]); err != nil {
+		t.Fatalf("TagIfDigest() moving the tag error = %v", err)
+	}
+	if got, _ := client.Resolve(t.Context(), repository+":stable"); got != digests["v1.1.0"] {
+		t.Errorf("stable = %s, want v1.1.0 %s", got, digests["v1.1.0"])
+	}
+
+	before := puts.Load()
+	if err := client.TagIfDigest(t.Context(), repository, "stable", digests["v1.0.0"], digests["v1.1.0"]); err != nil {
+		t.Errorf("TagIfDigest() repeating the move error = %v, want success", err)
+	}
+	if puts.Load() != before {
+		t.Error("TagIfDigest() rewrote a tag that already points at the new digest")
+	}
+
+	err := client.TagIfDigest(t.Context(), repository, "stable", digests["v1.0.0"], digests["v1.2.0"])
+	var conflict *ErrTagConflict
+	if !errors.As(err, &conflict) || conflict.Actual != digests["v1.1.0"] || conflict.Expected != digests["v1.0.0"] {
+		t.Fatalf("TagIfDigest() with a stale expected digest error = %v, want *ErrTagConflict", err)
+	}
+	if err := client.TagIfDigest(t.Context(), repository, "stable", "", digests["v1.2.0"]); !errors.As(err, &conflict) {
+		t.Errorf("TagIfDigest() creating an existing tag error = %v, want *ErrTagConflict", err)
+	}
+	if got, _ := client.Resolve(t.Context(), repository+":stable"); got != digests["v1.1.0"] {
+		t.Errorf("stable = %s after conflicts, want v1.1.0 %s", got, digests["v1.1.0"])
+	}
+}
+
+func TestTagIfDigest_LosesRace(t *testing.T) {
+	reg := newCacheRegistry()
+	var race func()
+	host, _ := newConditionalRegistry(t, reg, func() {
+		if race != nil {
+			race()
+		}
+	})
+	client := NewClient(WithPlainHTTP(true))
+	repository := host + "/klaus/sre"
+	digests := pushVersions(t, client, repository, "v1.0.0", "v1.1.0", "v1.2.0")
+	if err := client.TagIfDigest(t.Context(), repository, "stable", "", digests["v1.0.0"]); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another CI run moves stable between the check and the update.
+	race = func() {
+		race = nil
+		reg.mu.Lock()
+		reg.repos["klaus/sre"]["stable"] = digests["v1.2.0"]
+		reg.mu.Unlock()
+	}
+	err := client.TagIfDigest(t.Context(), repository, "stable", digests["v1.0.0"], digests["v1.1.0"])
+	var conflict *ErrTagConflict
+	if !errors.As(err, &conflict) || conflict.Actual != digests["v1.2.0"] {
+		t.Fatalf("TagIfDigest() losing the race error = %v, want *ErrTagConflict at v1.2.0", err)
+	}
+	if got, _ := client.Resolve(t.Context(), repository+":stable"); got != digests["v1.2.0"] {
+		t.Errorf("stable = %s, want the winner's v1.2.0 %s", got, digests["v1.2.0"])
+	}
+}
+
+func TestTagIfDigest_InvalidArguments(t *testing.T) {
+	client := NewClient()
+	digest := "sha256:" + strings.Repeat("a", 64)
+	for name, args := range map[string][3]string{
+		"new digest":      {"stable", "", "latest"},
+		"expected digest": {"stable", "v1", digest},
+		"tag":             {"-stable", "", digest},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := client.TagIfDigest(t.Context(), "example.com/klaus/sre", args[0], args[1], args[2]); err == nil {
+				t.Error("TagIfDigest() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestTagIfDigest_SplitCredentials(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_RUNTIME_DIR", "")
+
+	reg := newCacheRegistry()
+	credentials := map[string]string{"reader": "r", "writer": "w"}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		write := r.Method != http.MethodGet && r.Method != http.MethodHead
+		if !ok || credentials[user] != pass || write && user != "writer" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		reg.handler().ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	repository := testRegistryHost(ts) + "/klaus/sre"
+
+	client := NewClient(WithPlainHTTP(true),
+		WithPullCredentials(testRegistryHost(ts), auth.Credential{Username: "reader", Password: "r"}),
+		WithPushCredentials(testRegistryHost(ts), auth.Credential{Username: "writer", Password: "w"}))
+	digests := pushVersions(t, client, repository, "v1.0.0", "v1.1.0")
+
+	if err := client.TagIfDigest(t.Context(), repository, "stable", "", digests["v1.0.0"]); err != nil {
+		t.Fatalf("TagIfDigest() creating the tag error = %v", err)
+	}
+	if err := client.TagIfDigest(t.Context(), repository, "stable", digests["v1.0.0"], digests["v1.1.0"]); err != nil {
+		t.Fatalf("TagIfDigest() moving the tag error = %v", err)
+	}
+	if got, _ := client.Resolve(t.Context(), repository+":stable"); got != digests["v1.1.0"] {
+		t.Errorf("stable = %s, want v1.1.0 %s", got, digests["v1.1.0"])
+	}
+}
@@ -0,0 +1,198 @@
+package oci
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+const (
+	// defaultTokenTTL is the lifetime assumed for tokens that do not
+	// state their expiry, the token spec's default for expires_in.
+	defaultTokenTTL = 60 * time.Second
+
+	// tokenExpiryMargin is how long before their expiry cached tokens
+	// stop being used, so they don't expire in flight.
+	tokenExpiryMargin = 10 * time.Second
+)
+
+// WithTokenCache persists registry bearer tokens in dir, so that
+// short-lived processes, e.g. CLI invocations, reuse the tokens of
+// previous runs instead of authenticating on every run. Tokens are kept
+// until they expire, which is read from the token when it is a JWT (as
+// issued by ACR and most registries) and assumed to be 60 seconds
+// otherwise. Tokens are only reused with the credentials they were
+// obtained with, identified by an HMAC keyed with a random key stored in
+// dir. Files are created readable only by the owner on Unix-like
+// systems; dir should not be shared between users. Basic auth
+// credentials and tokens obtained with context credentials (see
+// WithContextCredentials) are never written to disk. An empty dir leaves
+// the cache disabled.
+func WithTokenCache(dir string) ClientOption {
+	return func(c *Client) { c.tokenCacheDir = dir }
+}
+
+// tokenFileCache is an auth.Cache that keeps bearer tokens in one file
+// per registry, written with writeJSONAtomic. Concurrent fetches are
+// combined by the in-memory cache it wraps, which also handles all other
+// schemes.
+type tokenFileCache struct {
+	dir        string
+	credential auth.CredentialFunc
+	inner      auth.Cache
+	// key returns the fingerprint key of dir, read on first use.
+	key func() []byte
+
+	mu    sync.Mutex
+	files map[string]*tokenFile
+}
+
+// tokenFile is the on-disk token cache of one registry.
+type tokenFile struct {
+	// Credential identifies the credential the tokens were obtained
+	// with; see credentialFingerprint.
+	Credential string                 `json:"credential"`
+	Tokens     map[string]cachedToken `json:"tokens"`
+}
+
+// cachedToken is a bearer token cached for a scope key.
+type cachedToken struct {
+	Token   string    `json:"token"`
+	Expires time.Time `json:"expires"`
+}
+
+func newTokenFileCache(dir string, credential auth.CredentialFunc) *tokenFileCache {
+	return &tokenFileCache{
+		dir:        dir,
+		credential: credential,
+		inner:      auth.NewCache(),
+		key:        sync.OnceValue(func() []byte { return fingerprintKey(dir) }),
+		files:      make(map[string]*tokenFile),
+	}
+}
+
+// GetScheme implements auth.Cache. Registries with valid cached tokens
+// use the bearer scheme.
+func (c *tokenFileCache) GetScheme(ctx context.Context, registry string) (auth.Scheme, error) {
+	if scheme, err := c.inner.GetScheme(ctx, registry); err == nil {
+		return scheme, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, t := range c.load(ctx, registry).Tokens {
+		if t.valid() {
+			return auth.SchemeBearer, nil
+		}
+	}
+	return auth.SchemeUnknown, errdef.ErrNotFound
+}
+
+// GetToken implements auth.Cache. Bearer tokens close to their expiry
+// are not returned.
+func (c *tokenFileCache) GetToken(ctx context.Context, registry string, scheme auth.Scheme, key string) (string, error) {
+	if scheme != auth.SchemeBearer {
+		return c.inner.GetToken(ctx, registry, scheme, key)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t, ok := c.load(ctx, registry).Tokens[key]; ok && t.valid() {
+		return t.Token, nil
+	}
+	return "", errdef.ErrNotFound
+}
+
+// Set implements auth.Cache and persists fetched bearer tokens.
+func (c *tokenFileCache) Set(ctx context.Context, registry string, scheme auth.Scheme, key string, fetch func(context.Context) (string, error)) (string, error) {
+	if scheme != auth.SchemeBearer {
+		return c.inner.Set(ctx, registry, scheme, key, fetch)
+	}
+	return c.inner.Set(ctx, registry, scheme, key, func(ctx context.Context) (string, error) {
+		token, err := fetch(ctx)
+		if err == nil {
+			c.store(ctx, registry, key, token)
+		}
+		return token, err
+	})
+}
+
+// load returns the tokens of registry, reading them from disk on first
+// use. Tokens obtained with other credentials are discarded. c.mu must
+// be held.
+func (c *tokenFileCache) load(ctx context.Context, registry string) *tokenFile {
+	if f, ok := c.files[registry]; ok {
+		return f
+	}
+	fingerprint := c.fingerprint(ctx, registry)
+	f := &tokenFile{}
+	if data, err := os.ReadFile(c.path(registry)); err == nil {
+		_ = json.Unmarshal(data, f)
+	}
+	if f.Credential != fingerprint || f.Tokens == nil {
+		f = &tokenFile{Credential: fingerprint, Tokens: make(map[string]cachedToken)}
+	}
+	c.files[registry] = f
+	return f
+}
+
+// store caches token for key and rewrites the file of registry without
+// expired tokens. Write failures only cost the reuse of the token.
+func (c *tokenFileCache) store(ctx context.Context, registry, key, token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f := c.load(ctx, registry)
+	f.Tokens[key] = cachedToken{Token: token, Expires: tokenExpiry(token, time.Now())}
+	for k, t := range f.Tokens {
+		if !t.valid() {
+			delete(f.Tokens, k)
+		}
+	}
+	_ = writeJSONAtomic(c.path(registry), f, 0o700, 0o600)
+}
+
+// path returns the token file of registry. Registry names are hashed as
+// they may contain characters that are invalid in file names.
+func (c *tokenFileCache) path(registry string) string {
+	sum := sha256.Sum256([]byte(registry))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// fingerprint identifies the credential used for registry without
+// revealing it; see credentialFingerprint.
+func (c *tokenFileCache) fingerprint(ctx context.Context, registry string) string {
+	cred, err := c.credential(ctx, registry)
+	if err != nil {
+		return ""
+	}
+	return credentialFingerprint(c.key(), registry, cred)
+}
+
+func (t cachedToken) valid() bool {
+	return time.Now().Add(tokenExpiryMargin).Before(t.Expires)
+}
+
+// tokenExpiry returns the expiry of token: the exp claim of JWTs,
+// otherwise defaultTokenTTL after now.
+func tokenExpiry(token string, now time.Time) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) == 3 {
+		if payload, err := base64.RawURLEncoding.DecodeString(parts[1]); err == nil {
+			var claims struct {
+				Exp int64 `json:"exp"`
+			}
+			if json.Unmarshal(payload, &claims) == nil && claims.Exp > 0 {
+				return time.Unix(claims.Exp, 0)
+			}
+		}
+	}
+	return now.Add(defaultTokenTTL)
+}
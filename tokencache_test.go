@@ -0,0 +1,203 @@
+package oci
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+func TestWithTokenCache_ReusedAcrossClients(t *testing.T) {
+	tr, host := newTokenRegistry(t, tokenTestRepos(2))
+	dir := t.TempDir()
+
+	list := func() {
+		t.Helper()
+		client := NewClient(WithPlainHTTP(true), WithTokenCache(dir))
+		plugins, err := client.ListPlugins(t.Context(), WithRegistry(host+"/giantswarm/klaus-plugins"))
+		if err != nil {
+			t.Fatalf("ListPlugins() error = %v", err)
+		}
+		if len(plugins) != 2 {
+			t.Fatalf("got %d plugins, want 2", len(plugins))
+		}
+	}
+
+	list()
+	tokenRequests, unauthorized := tr.counts()
+	if tokenRequests == 0 {
+		t.Fatal("first run requested no tokens")
+	}
+
+	list()
+	if got, _ := tr.counts(); got != tokenRequests {
+		t.Errorf("second run requested %d tokens, want cached tokens", got-tokenRequests)
+	}
+	if _, got := tr.counts(); got != unauthorized {
+		t.Errorf("second run was challenged %d times, want 0", got-unauthorized)
+	}
+}
+
+func TestTokenFileCache_CredentialChange(t *testing.T) {
+	dir := t.TempDir()
+	ctx := t.Context()
+	credential := func(user string) auth.CredentialFunc {
+		return func(context.Context, string) (auth.Credential, error) {
+			return auth.Credential{Username: user, Password: "secret"}, nil
+		}
+	}
+
+	alice := newTokenFileCache(dir, credential("alice"))
+	if _, err := alice.Set(ctx, "registry.example.com", auth.SchemeBearer, "repository:a:pull", func(context.Context) (string, error) {
+		return "alice-token", nil
+	}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	reloaded := newTokenFileCache(dir, credential("alice"))
+	if token, err := reloaded.GetToken(ctx, "registry.example.com", auth.SchemeBearer, "repository:a:pull"); err != nil || token != "alice-token" {
+		t.Errorf("GetToken with the same credential = %q, %v; want alice-token", token, err)
+	}
+	if scheme, err := reloaded.GetScheme(ctx, "registry.example.com"); err != nil || scheme != auth.SchemeBearer {
+		t.Errorf("GetScheme = %v, %v; want bearer", scheme, err)
+	}
+
+	bob := newTokenFileCache(dir, credential("bob"))
+	if token, err := bob.GetToken(ctx, "registry.example.com", auth.SchemeBearer, "repository:a:pull"); err == nil {
+		t.Errorf("GetToken with another credential = %q, want miss", token)
+	}
+	if _, err := bob.GetScheme(ctx, "registry.example.com"); err == nil {
+		t.Error("GetScheme with another credential succeeded, want miss")
+	}
+}
+
+func TestTokenFileCache_Expiry(t *testing.T) {
+	dir := t.TempDir()
+	ctx := t.Context()
+	anonymous := func(context.Context, string) (auth.Credential, error) { return auth.EmptyCredential, nil }
+
+	cache := newTokenFileCache(dir, anonymous)
+	expired := testJWT(time.Now().Add(-time.Minute))
+	if _, err := cache.Set(ctx, "registry.example.com", auth.SchemeBearer, "k", func(context.Context) (string, error) {
+		return expired, nil
+	}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if token, err := cache.GetToken(ctx, "registry.example.com", auth.SchemeBearer, "k"); err == nil {
+		t.Errorf("GetToken returned expired token %q", token)
+	}
+
+	fetches := 0
+	fresh := testJWT(time.Now().Add(time.Hour))
+	for range 2 {
+		if _, err := cache.GetToken(ctx, "registry.example.com", auth.SchemeBearer, "k"); err == nil {
+			continue
+		}
+		if _, err := cache.Set(ctx, "registry.example.com", auth.SchemeBearer, "k", func(context.Context) (string, error) {
+			fetches++
+			return fresh, nil
+		}); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("fetched %d times, want 1", fetches)
+	}
+}
+
+func TestTokenFileCache_BasicNotPersisted(t *testing.T) {
+	dir := t.TempDir()
+	ctx := t.Context()
+	anonymous := func(context.Context, string) (auth.Credential, error) { return auth.EmptyCredential, nil }
+
+	cache := newTokenFileCache(dir, anonymous)
+	if _, err := cache.Set(ctx, "registry.example.com", auth.SchemeBasic, "", func(context.Context) (string, error) {
+		return "dXNlcjpwYXNz", nil
+	}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if token, err := cache.GetToken(ctx, "registry.example.com", auth.SchemeBasic, ""); err != nil || token != "dXNlcjpwYXNz" {
+		t.Errorf("GetToken = %q, %v", token, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("basic credentials written to disk: %v", entries)
+	}
+}
+
+func TestTokenFileCache_Permissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not enforced on windows")
+	}
+	dir := filepath.Join(t.TempDir(), "tokens")
+	ctx := t.Context()
+	anonymous := func(context.Context, string) (auth.Credential, error) { return auth.EmptyCredential, nil }
+
+	cache := newTokenFileCache(dir, anonymous)
+	if _, err := cache.Set(ctx, "registry.example.com", auth.SchemeBearer, "k", func(context.Context) (string, error) {
+		return "token", nil
+	}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if fi, err := os.Stat(dir); err != nil || fi.Mode().Perm() != 0o700 {
+		t.Errorf("dir mode = %v, %v; want 0700", fi.Mode().Perm(), err)
+	}
+	fi, err := os.Stat(cache.path("registry.example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("file mode = %v, want 0600", fi.Mode().Perm())
+	}
+	if fi, err := os.Stat(filepath.Join(dir, fingerprintKeyFile)); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("key file mode = %v, %v; want 0600", fi.Mode().Perm(), err)
+	}
+}
+
+func TestTokenFileCache_KeyedFingerprint(t *testing.T) {
+	ctx := t.Context()
+	cred := auth.Credential{Username: "alice", Password: "secret"}
+	credential := func(context.Context, string) (auth.Credential, error) { return cred, nil }
+	dir := t.TempDir()
+
+	fingerprint := newTokenFileCache(dir, credential).fingerprint(ctx, "registry.example.com")
+	if again := newTokenFileCache(dir, credential).fingerprint(ctx, "registry.example.com"); again != fingerprint {
+		t.Errorf("fingerprint changed between caches of one directory: %s, %s", fingerprint, again)
+	}
+	if other := newTokenFileCache(t.TempDir(), credential).fingerprint(ctx, "registry.example.com"); other == fingerprint {
+		t.Error("caches of different directories share fingerprints")
+	}
+	if unkeyed := sum256Hex([]byte("registry.example.com\x00alice\x00secret\x00\x00")); fingerprint == unkeyed {
+		t.Error("fingerprint is an unkeyed hash of the credential")
+	}
+}
+
+func TestTokenExpiry(t *testing.T) {
+	now := time.Now()
+	exp := now.Add(time.Hour).Truncate(time.Second)
+	if got := tokenExpiry(testJWT(exp), now); !got.Equal(exp) {
+		t.Errorf("JWT expiry = %v, want %v", got, exp)
+	}
+	if got := tokenExpiry("opaque-token", now); !got.Equal(now.Add(defaultTokenTTL)) {
+		t.Errorf("opaque token expiry = %v, want %v", got, now.Add(defaultTokenTTL))
+	}
+	if got := tokenExpiry("a.!!!.c", now); !got.Equal(now.Add(defaultTokenTTL)) {
+		t.Errorf("malformed JWT expiry = %v, want %v", got, now.Add(defaultTokenTTL))
+	}
+}
+
+// testJWT returns an unsigned JWT expiring at exp.
+func testJWT(exp time.Time) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+		enc.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp.Unix()))) + "."
+}
@@ -0,0 +1,94 @@
+package oci
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// tokenScopeBatch is the number of repositories whose pull scopes share
+// one pre-warmed token.
+const tokenScopeBatch = 50
+
+// WithTokenPrewarming makes batch listings request registry tokens up
+// front. Repositories are grouped per host into batches whose pull
+// scopes are requested together, so one token, fetched once before the
+// repositories are resolved and shared by all concurrent requests,
+// covers the whole batch. Without it every repository costs its own
+// challenge round trip and token request, which on public registries
+// issuing anonymous bearer tokens dominates the listing time. Registries
+// that refuse multi-scope token requests fall back to per-repository
+// tokens.
+func WithTokenPrewarming() ClientOption {
+	return func(c *Client) {
+		c.prewarmTokens = true
+	}
+}
+
+// prewarmScopes groups repos (full "host/path" names) into token batches
+// and fetches the token of each batch. It returns, per repository, the
+// scopes its requests should carry (see withScopes). They are nil when
+// pre-warming is disabled or the batch token could not be fetched, e.g.
+// from token servers refusing multi-scope requests, so those requests
+// use per-repository tokens.
+func (c *Client) prewarmScopes(ctx context.Context, repos []string) [][]string {
+	scopes := make([][]string, len(repos))
+	if !c.prewarmTokens {
+		return scopes
+	}
+	byHost := make(map[string][]int)
+	var hosts []string
+	for i, repo := range repos {
+		host, _ := splitHostPath(repo)
+		if _, ok := byHost[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		byHost[host] = append(byHost[host], i)
+	}
+
+	var g errgroup.Group
+	g.SetLimit(c.concurrency)
+	for _, host := range hosts {
+		indices := byHost[host]
+		for start := 0; start < len(indices); start += tokenScopeBatch {
+			batch := indices[start:min(start+tokenScopeBatch, len(indices))]
+			batchScopes := make([]string, len(batch))
+			for j, i := range batch {
+				_, path := splitHostPath(repos[i])
+				batchScopes[j] = auth.ScopeRepository(path, auth.ActionPull)
+			}
+			g.Go(func() error {
+				if c.prewarmToken(auth.AppendScopesForHost(ctx, host, batchScopes...), host) {
+					for _, i := range batch {
+						scopes[i] = batchScopes
+					}
+				}
+				return nil
+			})
+		}
+	}
+	_ = g.Wait()
+	return scopes
+}
+
+// prewarmToken pings host with the scopes of ctx, which makes the auth
+// client fetch and cache the token for them, and reports whether the
+// ping succeeded.
+func (c *Client) prewarmToken(ctx context.Context, host string) bool {
+	reg, err := c.registry(host)
+	if err != nil {
+		return false
+	}
+	return reg.Ping(ctx) == nil
+}
+
+// withScopes returns ctx carrying the pre-warmed scopes of repo, so that
+// its requests use the batch token.
+func withScopes(ctx context.Context, repo string, scopes []string) context.Context {
+	if len(scopes) == 0 {
+		return ctx
+	}
+	host, _ := splitHostPath(repo)
+	return auth.AppendScopesForHost(ctx, host, scopes...)
+}
@@ -0,0 +1,202 @@
+package oci
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// tokenRegistry wraps a test registry with anonymous bearer token auth.
+// Tokens are issued for any requested scopes and grant pull access to
+// the repositories they name.
+type tokenRegistry struct {
+	mu            sync.Mutex
+	tokens        map[string][]string
+	tokenRequests int
+	unauthorized  int
+	// maxScopes, when set, makes the token server refuse requests for
+	// more scopes.
+	maxScopes int
+}
+
+func newTokenRegistry(t *testing.T, repos map[string][]string) (*tokenRegistry, string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_RUNTIME_DIR", "")
+
+	tr := &tokenRegistry{tokens: make(map[string][]string)}
+	backend := newTestRegistry(repos)
+	t.Cleanup(backend.Close)
+
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			tr.mu.Lock()
+			tr.tokenRequests++
+			if tr.maxScopes > 0 && len(r.URL.Query()["scope"]) > tr.maxScopes {
+				tr.mu.Unlock()
+				http.Error(w, "too many scopes", http.StatusBadRequest)
+				return
+			}
+			token := fmt.Sprintf("token-%d", tr.tokenRequests)
+			tr.tokens[token] = r.URL.Query()["scope"]
+			tr.mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]string{"token": token})
+			return
+		}
+
+		scope := ""
+		if repo, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/v2/"), "/tags/list"); ok {
+			scope = "repository:" + repo + ":pull"
+		}
+		if r.URL.Path != "/v2/_catalog" && !tr.authorized(r, scope) {
+			challenge := fmt.Sprintf(`Bearer realm="%s/token",service="test"`, ts.URL)
+			if scope != "" {
+				challenge += fmt.Sprintf(`,scope="%s"`, scope)
+			}
+			tr.mu.Lock()
+			tr.unauthorized++
+			tr.mu.Unlock()
+			w.Header().Set("WWW-Authenticate", challenge)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		backend.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return tr, testRegistryHost(ts)
+}
+
+// authorized reports whether r carries a token granting scope, or any
+// issued token when scope is empty.
+func (tr *tokenRegistry) authorized(r *http.Request, scope string) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	granted, ok := tr.tokens[token]
+	if !ok {
+		return false
+	}
+	if scope == "" {
+		return true
+	}
+	for _, s := range granted {
+		if slices.Contains(strings.Fields(s), scope) {
+			return true
+		}
+	}
+	return false
+}
+
+func (tr *tokenRegistry) counts() (tokenRequests, unauthorized int) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return tr.tokenRequests, tr.unauthorized
+}
+
+func tokenTestRepos(n int) map[string][]string {
+	repos := make(map[string][]string, n)
+	for i := range n {
+		repos[fmt.Sprintf("giantswarm/klaus-plugins/p%03d", i)] = []string{"v1.0.0"}
+	}
+	return repos
+}
+
+func TestWithTokenPrewarming(t *testing.T) {
+	const n = 2*tokenScopeBatch + 10
+	tr, host := newTokenRegistry(t, tokenTestRepos(n))
+
+	client := NewClient(WithPlainHTTP(true), WithTokenPrewarming())
+	plugins, err := client.ListPlugins(t.Context(), WithRegistry(host+"/giantswarm/klaus-plugins"))
+	if err != nil {
+		t.Fatalf("ListPlugins() error = %v", err)
+	}
+	if len(plugins) != n {
+		t.Fatalf("got %d plugins, want %d", len(plugins), n)
+	}
+	for _, p := range plugins {
+		if p.Version != "v1.0.0" {
+			t.Fatalf("plugin %s version = %q, want v1.0.0", p.Name, p.Version)
+		}
+	}
+
+	tokenRequests, unauthorized := tr.counts()
+	if want := 3; tokenRequests != want {
+		t.Errorf("token requests = %d, want one per batch (%d)", tokenRequests, want)
+	}
+	// Only the pre-warming pings are challenged.
+	if unauthorized != tokenRequests {
+		t.Errorf("unauthorized responses = %d, want %d", unauthorized, tokenRequests)
+	}
+}
+
+func TestWithTokenPrewarming_Disabled(t *testing.T) {
+	const n = 20
+	tr, host := newTokenRegistry(t, tokenTestRepos(n))
+
+	client := NewClient(WithPlainHTTP(true))
+	plugins, err := client.ListPlugins(t.Context(), WithRegistry(host+"/giantswarm/klaus-plugins"))
+	if err != nil {
+		t.Fatalf("ListPlugins() error = %v", err)
+	}
+	if len(plugins) != n {
+		t.Fatalf("got %d plugins, want %d", len(plugins), n)
+	}
+	if tokenRequests, _ := tr.counts(); tokenRequests < n {
+		t.Errorf("token requests = %d, want at least one per repository (%d)", tokenRequests, n)
+	}
+}
+
+func TestWithTokenPrewarming_MultiScopeRefused(t *testing.T) {
+	const n = 5
+	tr, host := newTokenRegistry(t, tokenTestRepos(n))
+	tr.maxScopes = 1
+
+	client := NewClient(WithPlainHTTP(true), WithTokenPrewarming())
+	plugins, err := client.ListPlugins(t.Context(), WithRegistry(host+"/giantswarm/klaus-plugins"))
+	if err != nil {
+		t.Fatalf("ListPlugins() error = %v", err)
+	}
+	if len(plugins) != n {
+		t.Errorf("got %d plugins, want %d with per-repository tokens", len(plugins), n)
+	}
+}
+
+func TestPrewarmScopes_GroupsByHost(t *testing.T) {
+	client := NewClient(WithPlainHTTP(true), WithTokenPrewarming())
+	a, b := newTestRegistry(nil), newTestRegistry(nil)
+	t.Cleanup(a.Close)
+	t.Cleanup(b.Close)
+	hostA, hostB := testRegistryHost(a), testRegistryHost(b)
+	// Failed pre-warming leaves the scopes of unreachable hosts unset.
+	scopes := client.prewarmScopes(t.Context(), []string{
+		hostA + "/a",
+		hostB + "/b",
+		hostA + "/c",
+		"127.0.0.1:1/d",
+	})
+	want := [][]string{
+		{"repository:a:pull", "repository:c:pull"},
+		{"repository:b:pull"},
+		{"repository:a:pull", "repository:c:pull"},
+		nil,
+	}
+	for i := range want {
+		if !slices.Equal(scopes[i], want[i]) {
+			t.Errorf("scopes[%d] = %v, want %v", i, scopes[i], want[i])
+		}
+	}
+
+	if scopes := NewClient().prewarmScopes(t.Context(), []string{"example.com/a"}); scopes[0] != nil {
+		t.Errorf("disabled pre-warming returned scopes %v", scopes[0])
+	}
+}
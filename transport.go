@@ -0,0 +1,279 @@
+package oci
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// defaultBlobConcurrency is the default number of concurrent blob
+// downloads per client.
+const defaultBlobConcurrency = 10
+
+// TransportOptions tunes the HTTP connection pool used for registry
+// traffic. Zero fields keep their defaults.
+type TransportOptions struct {
+	// MaxIdleConnsPerHost is the number of idle connections kept per
+	// registry host. Defaults to the larger of the listing and blob
+	// concurrency, so bulk operations reuse connections instead of
+	// opening new ones (net/http keeps only 2 by default).
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost caps the connections to a registry host, including
+	// active ones. Zero means no limit.
+	MaxConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept. Defaults to
+	// the net/http default of 90 seconds.
+	IdleConnTimeout time.Duration
+	// DisableHTTP2 forces HTTP/1.1. By default HTTP/2 is negotiated when
+	// the registry supports it, multiplexing requests over few
+	// connections.
+	DisableHTTP2 bool
+	// RootCAs are the certificate authorities trusted for registry TLS
+	// connections, e.g. for registries with certificates from a private
+	// CA. Nil uses the system pool.
+	RootCAs *x509.CertPool
+}
+
+// WithTransportOptions tunes the HTTP transport used for registry
+// requests.
+func WithTransportOptions(o TransportOptions) ClientOption {
+	return func(c *Client) { c.transport = o }
+}
+
+// WithBlobConcurrency limits the number of blob downloads (config blobs
+// and content layers) the client runs at the same time, across all
+// concurrent pulls. Defaults to 10.
+func WithBlobConcurrency(n int) ClientOption {
+	return func(c *Client) {
+		if n > 0 {
+			c.blobConcurrency = n
+		}
+	}
+}
+
+// WithBandwidthLimit caps the combined download rate of blobs (config
+// blobs and content layers) at bytesPerSec, shared by all concurrent pulls
+// of the client. Manifests, tag lists and other API requests are not
+// throttled. Zero or a negative value disables the limit (the default).
+func WithBandwidthLimit(bytesPerSec int64) ClientOption {
+	return func(c *Client) { c.bandwidthLimit = bytesPerSec }
+}
+
+// configureTransport installs a tuned transport on the auth client. It is
+// called once by NewClient after all options are applied. Clients whose
+// HTTP client was replaced are left unchanged.
+func (c *Client) configureTransport() {
+	if c.authClient.Client != nil && c.authClient.Client != http.DefaultClient {
+		return
+	}
+
+	rt := http.DefaultTransport
+	if base, ok := rt.(*http.Transport); ok {
+		rt = c.tuneTransport(base.Clone())
+	}
+	if c.faults != nil {
+		rt = newFaultTransport(rt, *c.faults)
+	}
+	if c.bandwidthLimit > 0 {
+		rt = &throttledTransport{base: rt, limiter: newBandwidthLimiter(c.bandwidthLimit)}
+	}
+	rt = &warningTransport{base: rt, warnings: &c.warnings}
+	rt = &debugTransport{base: rt, output: &c.debug}
+
+	c.authClient.Client = &http.Client{Transport: rt}
+}
+
+// tuneTransport applies the client's TransportOptions to t.
+func (c *Client) tuneTransport(t *http.Transport) *http.Transport {
+	t.MaxIdleConnsPerHost = c.transport.MaxIdleConnsPerHost
+	if t.MaxIdleConnsPerHost == 0 {
+		t.MaxIdleConnsPerHost = max(c.concurrency, c.blobConcurrency)
+	}
+	if t.MaxIdleConns < t.MaxIdleConnsPerHost {
+		t.MaxIdleConns = t.MaxIdleConnsPerHost
+	}
+	t.MaxConnsPerHost = c.transport.MaxConnsPerHost
+	if c.transport.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = c.transport.IdleConnTimeout
+	}
+	if c.transport.RootCAs != nil {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		}
+		t.TLSClientConfig.RootCAs = c.transport.RootCAs
+	}
+	if c.transport.DisableHTTP2 {
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	} else {
+		t.ForceAttemptHTTP2 = true
+	}
+	return t
+}
+
+// fetchBlob fetches a blob through fetchWithStore while holding one of the
+// client's blob download slots. The slot is released when the blob has
+// been read to the end or the reader is closed, whichever comes first.
+func (c *Client) fetchBlob(ctx context.Context, repo *remote.Repository, repoName string, desc ocispec.Descriptor) (io.ReadCloser, error) {
+	release, err := c.acquireBlobSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := c.fetchWithStore(ctx, repo, repoName, desc)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &slotReader{ReadCloser: rc, release: release}, nil
+}
+
+// acquireBlobSlot blocks until a blob download slot is free or ctx is
+// done. The returned function releases the slot and is safe to call more
+// than once.
+func (c *Client) acquireBlobSlot(ctx context.Context) (func(), error) {
+	select {
+	case c.blobSlots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return sync.OnceFunc(func() { <-c.blobSlots }), nil
+}
+
+// slotReader releases a blob download slot once its reader is exhausted
+// or closed.
+type slotReader struct {
+	io.ReadCloser
+	release func()
+}
+
+func (r *slotReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if err != nil {
+		r.release()
+	}
+	return n, err
+}
+
+func (r *slotReader) Close() error {
+	r.release()
+	return r.ReadCloser.Close()
+}
+
+// throttledTransport limits the rate at which blob response bodies are
+// read. Registries commonly redirect blob downloads to object storage, so
+// responses to redirects of a blob request are throttled as well.
+type throttledTransport struct {
+	base    http.RoundTripper
+	limiter *bandwidthLimiter
+}
+
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !isBlobDownload(req) {
+		return resp, err
+	}
+	resp.Body = &throttledReader{ReadCloser: resp.Body, ctx: req.Context(), limiter: t.limiter}
+	return resp, nil
+}
+
+// isBlobDownload reports whether req fetches a blob, either directly or by
+// following redirects from a blob request.
+func isBlobDownload(req *http.Request) bool {
+	for req != nil {
+		if req.Method == http.MethodGet && strings.Contains(req.URL.Path, "/blobs/") &&
+			!strings.Contains(req.URL.Path, "/blobs/uploads/") {
+			return true
+		}
+		if req.Response == nil {
+			return false
+		}
+		req = req.Response.Request
+	}
+	return false
+}
+
+// throttleChunk bounds a single throttled read so the limiter can smooth
+// the rate instead of admitting large bursts.
+const throttleChunk = 32 * 1024
+
+// bandwidthLimiter is a token bucket shared by all throttled readers of a
+// client. Readers reserve tokens for the bytes they read and sleep until
+// the bucket has refilled, so concurrent downloads share the rate.
+type bandwidthLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // bytes per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBandwidthLimiter(bytesPerSec int64) *bandwidthLimiter {
+	burst := float64(min(bytesPerSec, throttleChunk))
+	return &bandwidthLimiter{
+		rate:   float64(bytesPerSec),
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// chunk returns the maximum number of bytes to read at once.
+func (l *bandwidthLimiter) chunk() int {
+	return max(int(l.burst), 1)
+}
+
+// wait takes n tokens from the bucket and blocks until they are covered
+// by the refill rate or ctx is done.
+func (l *bandwidthLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens -= float64(n)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// throttledReader reads through a bandwidthLimiter.
+type throttledReader struct {
+	io.ReadCloser
+	ctx     context.Context
+	limiter *bandwidthLimiter
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > r.limiter.chunk() {
+		p = p[:r.limiter.chunk()]
+	}
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		if werr := r.limiter.wait(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
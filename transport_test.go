@@ -0,0 +1,235 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithBlobConcurrency(t *testing.T) {
+	reg := newCacheRegistry()
+	blob, _ := json.Marshal(pluginConfigBlob{})
+	for i := range 6 {
+		addPullableArtifact(t, reg, fmt.Sprintf("klaus-plugins/p%d", i), "v1.0.0", pluginArtifact, blob, map[string]string{
+			"README.md": fmt.Sprintf("plugin %d", i),
+		})
+	}
+
+	var inFlight, peak atomic.Int32
+	inner := reg.handler()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/blobs/") {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		inner.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	host := testRegistryHost(ts)
+
+	client := NewClient(WithPlainHTTP(true), WithBlobConcurrency(2))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 6)
+	for i := range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.PullPlugin(t.Context(), fmt.Sprintf("%s/klaus-plugins/p%d:v1.0.0", host, i), t.TempDir())
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("PullPlugin() error = %v", err)
+		}
+	}
+
+	if got := peak.Load(); got > 2 {
+		t.Errorf("peak concurrent blob fetches = %d, want at most 2", got)
+	}
+}
+
+func TestFetchBlob_ReleasesSlotOnEOF(t *testing.T) {
+	reg := newCacheRegistry()
+	blob, _ := json.Marshal(pluginConfigBlob{})
+	addPullableArtifact(t, reg, "klaus-plugins/p", "v1.0.0", pluginArtifact, blob, map[string]string{"a": "b"})
+	host := newPullTestRegistry(t, reg)
+
+	// A single slot must be enough for one pull, which fetches the config
+	// blob before the content layer while keeping both readers open.
+	client := NewClient(WithPlainHTTP(true), WithBlobConcurrency(1))
+	if _, err := client.PullPlugin(t.Context(), host+"/klaus-plugins/p:v1.0.0", t.TempDir()); err != nil {
+		t.Fatalf("PullPlugin() error = %v", err)
+	}
+	if n := len(client.blobSlots); n != 0 {
+		t.Errorf("%d blob slots still held after pull", n)
+	}
+}
+
+func TestConfigureTransport(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []ClientOption
+		wantIdle     int
+		wantMaxConns int
+		wantHTTP2    bool
+	}{
+		{
+			name:      "defaults",
+			wantIdle:  defaultBlobConcurrency,
+			wantHTTP2: true,
+		},
+		{
+			name:      "follows concurrency",
+			opts:      []ClientOption{WithConcurrency(25), WithBlobConcurrency(4)},
+			wantIdle:  25,
+			wantHTTP2: true,
+		},
+		{
+			name: "explicit",
+			opts: []ClientOption{WithTransportOptions(TransportOptions{
+				MaxIdleConnsPerHost: 50,
+				MaxConnsPerHost:     8,
+				DisableHTTP2:        true,
+			})},
+			wantIdle:     50,
+			wantMaxConns: 8,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(tt.opts...)
+			rt := c.authClient.Client.Transport
+			if d, ok := rt.(*debugTransport); ok {
+				rt = d.base
+			}
+			if w, ok := rt.(*warningTransport); ok {
+				rt = w.base
+			}
+			tr, ok := rt.(*http.Transport)
+			if !ok {
+				t.Fatalf("transport = %T, want *http.Transport", rt)
+			}
+			if tr.MaxIdleConnsPerHost != tt.wantIdle {
+				t.Errorf("MaxIdleConnsPerHost = %d, want %d", tr.MaxIdleConnsPerHost, tt.wantIdle)
+			}
+			if tr.MaxConnsPerHost != tt.wantMaxConns {
+				t.Errorf("MaxConnsPerHost = %d, want %d", tr.MaxConnsPerHost, tt.wantMaxConns)
+			}
+			if tr.ForceAttemptHTTP2 != tt.wantHTTP2 {
+				t.Errorf("ForceAttemptHTTP2 = %v, want %v", tr.ForceAttemptHTTP2, tt.wantHTTP2)
+			}
+			if tr == http.DefaultTransport {
+				t.Error("client modified http.DefaultTransport")
+			}
+		})
+	}
+}
+
+func TestWithTransportOptions_RootCAs(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+	target := strings.TrimPrefix(ts.URL, "https://") + "/klaus/sre"
+
+	if _, err := NewClient().Ping(t.Context(), target); err == nil {
+		t.Fatal("Ping() of a registry with an untrusted certificate succeeded")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.Certificate())
+	client := NewClient(WithTransportOptions(TransportOptions{RootCAs: pool}))
+	if _, err := client.Ping(t.Context(), target); err != nil {
+		t.Fatalf("Ping() with RootCAs error = %v", err)
+	}
+}
+
+func TestWithBandwidthLimit(t *testing.T) {
+	reg := newCacheRegistry()
+	blob, _ := json.Marshal(pluginConfigBlob{})
+	// Random content does not compress, so the layer stays ~120 KB.
+	content := make([]byte, 120_000)
+	if _, err := rand.Read(content); err != nil {
+		t.Fatal(err)
+	}
+	addPullableArtifact(t, reg, "klaus-plugins/p", "v1.0.0", pluginArtifact, blob, map[string]string{
+		"data.bin": string(content),
+	})
+	host := newPullTestRegistry(t, reg)
+
+	client := NewClient(WithPlainHTTP(true), WithBandwidthLimit(200_000))
+	start := time.Now()
+	if _, err := client.PullPlugin(t.Context(), host+"/klaus-plugins/p:v1.0.0", t.TempDir()); err != nil {
+		t.Fatalf("PullPlugin() error = %v", err)
+	}
+	// 120 KB at 200 KB/s with a 32 KiB burst takes at least ~0.44s.
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("pull took %v, want throttled to at least 300ms", elapsed)
+	}
+}
+
+func TestThrottledReader_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	r := &throttledReader{
+		ReadCloser: io.NopCloser(bytes.NewReader(make([]byte, 4096))),
+		ctx:        ctx,
+		limiter:    newBandwidthLimiter(1024),
+	}
+	if _, err := io.ReadAll(r); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadAll() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestIsBlobDownload(t *testing.T) {
+	get := func(rawURL string) *http.Request {
+		u, _ := url.Parse(rawURL)
+		return &http.Request{Method: http.MethodGet, URL: u}
+	}
+	redirected := get("https://storage.example.com/sha256/abc?sig=x")
+	redirected.Response = &http.Response{Request: get("https://r.example.com/v2/p/blobs/sha256:abc")}
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want bool
+	}{
+		{"blob", get("https://r.example.com/v2/p/blobs/sha256:abc"), true},
+		{"manifest", get("https://r.example.com/v2/p/manifests/v1"), false},
+		{"upload", get("https://r.example.com/v2/p/blobs/uploads/123"), false},
+		{"head", &http.Request{Method: http.MethodHead, URL: get("https://r.example.com/v2/p/blobs/sha256:abc").URL}, false},
+		{"redirect", redirected, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBlobDownload(tt.req); got != tt.want {
+				t.Errorf("isBlobDownload() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
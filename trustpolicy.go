@@ -0,0 +1,272 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// TrustPolicyConfigMapKey is the ConfigMap data key ParseTrustPolicy reads
+// the policy document from when the ConfigMap holds more than one key.
+const TrustPolicyConfigMapKey = "trust-policy.yaml"
+
+// AttestationType names a kind of referrer a trust policy can require.
+type AttestationType string
+
+// Attestation types, named after the operations that attach them.
+const (
+	// AttestationProvenance requires SLSA provenance that verifies
+	// against the scope's builders and keys (see VerifyProvenance).
+	AttestationProvenance AttestationType = "provenance"
+	// AttestationScanSummary requires a scan summary (see
+	// AttachScanSummary).
+	AttestationScanSummary AttestationType = "scan-summary"
+	// AttestationChecksums requires a checksum referrer (see
+	// WithChecksumReferrer).
+	AttestationChecksums AttestationType = "checksums"
+	// AttestationEvalResults requires evaluation results (see
+	// AttachEvalResults).
+	AttestationEvalResults AttestationType = "eval-results"
+)
+
+// attestationArtifactTypes maps the attestation types to the artifact
+// types of their referrers.
+var attestationArtifactTypes = map[AttestationType][]string{
+	AttestationProvenance:  {MediaTypeInTotoStatement, MediaTypeDSSEEnvelope},
+	AttestationScanSummary: {MediaTypeScanSummary},
+	AttestationChecksums:   {MediaTypeChecksums},
+	AttestationEvalResults: {MediaTypeEvalResults},
+}
+
+// TrustPolicy declares, per registry or namespace, which builders and
+// keys are trusted and which attestations artifacts must carry, so that
+// policy lives in configuration rather than code:
+//
+//	scopes:
+//	  - scope: gsoci.azurecr.io/giantswarm
+//	    trustedBuilders:
+//	      - https://github.com/slsa-framework/slsa-github-generator/*
+//	    keys:
+//	      - |
+//	        -----BEGIN PUBLIC KEY-----
+//	        ...
+//	        -----END PUBLIC KEY-----
+//	    requiredAttestations: [provenance, scan-summary]
+//	  - scope: "*"
+//	    requiredAttestations: [checksums]
+type TrustPolicy struct {
+	Scopes []TrustScope `yaml:"scopes" json:"scopes"`
+}
+
+// TrustScope is the trust configuration of the repositories under Scope.
+type TrustScope struct {
+	// Scope is a registry base such as "gsoci.azurecr.io/giantswarm",
+	// matched at a path boundary, or "*" for every repository. The most
+	// specific matching scope applies.
+	Scope string `yaml:"scope" json:"scope"`
+	// TrustedBuilders lists the trusted SLSA builder IDs, with the
+	// syntax of ProvenancePolicy.TrustedBuilders.
+	TrustedBuilders []string `yaml:"trustedBuilders,omitempty" json:"trustedBuilders,omitempty"`
+	// Keys lists PEM-encoded public keys trusted to sign attestations.
+	Keys []string `yaml:"keys,omitempty" json:"keys,omitempty"`
+	// RequiredAttestations lists the attestations artifacts in scope
+	// must carry.
+	RequiredAttestations []AttestationType `yaml:"requiredAttestations,omitempty" json:"requiredAttestations,omitempty"`
+
+	// publicKeys holds the parsed Keys.
+	publicKeys []crypto.PublicKey
+}
+
+// ReadTrustPolicyFile reads a trust policy from a local YAML file, either
+// the policy document itself or a ConfigMap holding it.
+func ReadTrustPolicyFile(path string) (*TrustPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading trust policy: %w", err)
+	}
+	return ParseTrustPolicy(data)
+}
+
+// ParseTrustPolicy parses a trust policy document. data may also be a
+// Kubernetes ConfigMap holding the document, under TrustPolicyConfigMapKey
+// or as its only data key, as mounted or fetched by operators. Unknown
+// fields, unparsable keys, and unknown attestation types are rejected, so
+// that typos do not silently weaken the policy.
+func ParseTrustPolicy(data []byte) (*TrustPolicy, error) {
+	var configMap struct {
+		Kind string            `yaml:"kind"`
+		Data map[string]string `yaml:"data"`
+	}
+	if err := yaml.Unmarshal(data, &configMap); err == nil && configMap.Kind == "ConfigMap" {
+		doc, ok := configMap.Data[TrustPolicyConfigMapKey]
+		if !ok {
+			if len(configMap.Data) != 1 {
+				return nil, fmt.Errorf("parsing trust policy: ConfigMap has no %s key", TrustPolicyConfigMapKey)
+			}
+			for _, v := range configMap.Data {
+				doc = v
+			}
+		}
+		data = []byte(doc)
+	}
+
+	var p TrustPolicy
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing trust policy: %w", err)
+	}
+	for i := range p.Scopes {
+		s := &p.Scopes[i]
+		if s.Scope == "" {
+			return nil, fmt.Errorf("parsing trust policy: scopes[%d]: scope is required", i)
+		}
+		s.Scope = strings.TrimSuffix(s.Scope, "/")
+		for j, key := range s.Keys {
+			pub, err := parsePublicKey(key)
+			if err != nil {
+				return nil, fmt.Errorf("parsing trust policy: scopes[%d].keys[%d]: %w", i, j, err)
+			}
+			s.publicKeys = append(s.publicKeys, pub)
+		}
+		for _, a := range s.RequiredAttestations {
+			if _, ok := attestationArtifactTypes[a]; !ok {
+				return nil, fmt.Errorf("parsing trust policy: scopes[%d]: unknown attestation type %q", i, a)
+			}
+		}
+	}
+	return &p, nil
+}
+
+// parsePublicKey parses a PEM-encoded PKIX public key.
+func parsePublicKey(data string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// ScopeFor returns the most specific scope that applies to ref, or nil
+// if none does.
+func (p *TrustPolicy) ScopeFor(ref string) *TrustScope {
+	repo := RepositoryFromRef(ref)
+	var best *TrustScope
+	for i := range p.Scopes {
+		s := &p.Scopes[i]
+		if s.Scope != "*" && repo != s.Scope && !strings.HasPrefix(repo, s.Scope+"/") {
+			continue
+		}
+		if best == nil || best.Scope == "*" || (s.Scope != "*" && len(s.Scope) > len(best.Scope)) {
+			best = s
+		}
+	}
+	return best
+}
+
+// ProvenancePolicy returns the provenance policy of the scope.
+func (s *TrustScope) ProvenancePolicy() ProvenancePolicy {
+	return ProvenancePolicy{TrustedBuilders: s.TrustedBuilders, PublicKeys: s.publicKeys}
+}
+
+// WithTrustPolicy applies policy to the client's verification features:
+// describe operations verify the provenance of artifacts whose scope
+// trusts builders or requires provenance, reporting the outcome in
+// ArtifactInfo.Provenance, and VerifyTrust checks the required
+// attestations. WithProvenanceVerification takes precedence for
+// describe operations.
+func WithTrustPolicy(policy *TrustPolicy) ClientOption {
+	return func(c *Client) { c.trustPolicy = policy }
+}
+
+// provenancePolicyFor returns the provenance policy describe operations
+// apply to ref, or nil when provenance is not verified.
+func (c *Client) provenancePolicyFor(ref string) *ProvenancePolicy {
+	if c.provenance != nil {
+		return c.provenance
+	}
+	if c.trustPolicy == nil {
+		return nil
+	}
+	s := c.trustPolicy.ScopeFor(ref)
+	if s == nil || (len(s.TrustedBuilders) == 0 && !slices.Contains(s.RequiredAttestations, AttestationProvenance)) {
+		return nil
+	}
+	policy := s.ProvenancePolicy()
+	return &policy
+}
+
+// TrustResult is the outcome of checking an artifact against a trust
+// policy.
+type TrustResult struct {
+	// Ref is the checked reference and Digest its manifest digest.
+	Ref    string
+	Digest string
+	// Scope is the applied scope, empty if no scope applies.
+	Scope string
+	// Passed is true if the artifact carries every required attestation.
+	Passed bool
+	// Reasons lists why the artifact failed, one per violation.
+	Reasons []string
+	// Provenance is the provenance status when the scope requires
+	// provenance.
+	Provenance *ProvenanceStatus
+}
+
+// VerifyTrust checks the artifact at ref against the client's trust
+// policy (see WithTrustPolicy): it must carry every attestation its scope
+// requires, and its provenance must verify against the scope's builders
+// and keys. ref must be a fully-qualified reference; references without a
+// tag resolve to the latest version first. Artifacts outside every scope
+// pass. A failing artifact is reported in the result, not as an error.
+func (c *Client) VerifyTrust(ctx context.Context, ref string) (*TrustResult, error) {
+	if c.trustPolicy == nil {
+		return nil, fmt.Errorf("client has no trust policy")
+	}
+	fm, resolved, err := c.fetchReferrerSubject(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	result := &TrustResult{Ref: resolved, Digest: fm.digest}
+	scope := c.trustPolicy.ScopeFor(resolved)
+	if scope == nil {
+		result.Passed = true
+		return result, nil
+	}
+	result.Scope = scope.Scope
+
+	referrers, err := fm.referrers(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("listing attestations of %s: %w", resolved, err)
+	}
+	for _, a := range scope.RequiredAttestations {
+		if a == AttestationProvenance {
+			if result.Provenance, err = c.verifyProvenanceReferrers(ctx, fm, resolved, referrers, scope.ProvenancePolicy()); err != nil {
+				return nil, err
+			}
+			if !result.Provenance.Verified {
+				result.Reasons = append(result.Reasons, "provenance not verified: "+strings.Join(result.Provenance.Reasons, "; "))
+			}
+			continue
+		}
+		found := slices.ContainsFunc(referrers, func(r ocispec.Descriptor) bool {
+			return slices.Contains(attestationArtifactTypes[a], r.ArtifactType)
+		})
+		if !found {
+			result.Reasons = append(result.Reasons, fmt.Sprintf("no %s attestation attached", a))
+		}
+	}
+	result.Passed = len(result.Reasons) == 0
+	return result, nil
+}
@@ -0,0 +1,139 @@
+package oci
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+// publicKeyPEM returns pub PEM-encoded, indented for a YAML block scalar.
+func publicKeyPEM(t *testing.T, pub ed25519.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	return strings.ReplaceAll(strings.TrimSpace(string(data)), "\n", "\n        ")
+}
+
+func TestParseTrustPolicy(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := `scopes:
+  - scope: gsoci.azurecr.io/giantswarm/
+    trustedBuilders: [https://ci.example.com/*]
+    keys:
+      - |
+        ` + publicKeyPEM(t, pub) + `
+    requiredAttestations: [provenance, scan-summary]
+  - scope: gsoci.azurecr.io/giantswarm/klaus-plugins
+    requiredAttestations: [checksums]
+  - scope: "*"
+`
+	configMap := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: klaus-trust\ndata:\n  trust-policy.yaml: |\n" +
+		"    " + strings.ReplaceAll(strings.TrimSuffix(doc, "\n"), "\n", "\n    ") + "\n"
+
+	for name, data := range map[string]string{"document": doc, "ConfigMap": configMap} {
+		t.Run(name, func(t *testing.T) {
+			p, err := ParseTrustPolicy([]byte(data))
+			if err != nil {
+				t.Fatalf("ParseTrustPolicy() error = %v", err)
+			}
+			if len(p.Scopes) != 3 || p.Scopes[0].Scope != "gsoci.azurecr.io/giantswarm" {
+				t.Fatalf("Scopes = %+v", p.Scopes)
+			}
+			policy := p.Scopes[0].ProvenancePolicy()
+			if len(policy.PublicKeys) != 1 || !pub.Equal(policy.PublicKeys[0]) {
+				t.Errorf("PublicKeys = %v, want the configured key", policy.PublicKeys)
+			}
+
+			for ref, want := range map[string]string{
+				"gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base:v1.0.0": "gsoci.azurecr.io/giantswarm/klaus-plugins",
+				"gsoci.azurecr.io/giantswarm/klaus-personalities/sre":      "gsoci.azurecr.io/giantswarm",
+				"gsoci.azurecr.io/giantswarmish/sre:v1.0.0":                "*",
+			} {
+				if got := p.ScopeFor(ref); got == nil || got.Scope != want {
+					t.Errorf("ScopeFor(%q) = %+v, want %s", ref, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseTrustPolicy_Invalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown field":       "scopes:\n  - scope: example.com\n    trustedBuilder: [x]\n",
+		"missing scope":       "scopes:\n  - requiredAttestations: [provenance]\n",
+		"unknown attestation": "scopes:\n  - scope: example.com\n    requiredAttestations: [signature]\n",
+		"invalid key":         "scopes:\n  - scope: example.com\n    keys: [not-a-key]\n",
+		"ambiguous ConfigMap": "kind: ConfigMap\ndata:\n  a: \"\"\n  b: \"\"\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseTrustPolicy([]byte(data)); err == nil {
+				t.Error("ParseTrustPolicy() succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestVerifyTrust(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	repository := host + "/klaus/sre"
+	policy, err := ParseTrustPolicy([]byte("scopes:\n  - scope: " + host + "/klaus\n    trustedBuilders: [\"" + testBuilder + "\"]\n    requiredAttestations: [provenance, scan-summary]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(WithPlainHTTP(true), WithTrustPolicy(policy))
+	digests := pushVersions(t, client, repository, "v1.0.0")
+	ref := repository + ":v1.0.0"
+
+	result, err := client.VerifyTrust(t.Context(), ref)
+	if err != nil {
+		t.Fatalf("VerifyTrust() error = %v", err)
+	}
+	if result.Passed || result.Scope != host+"/klaus" || len(result.Reasons) != 2 || result.Provenance == nil {
+		t.Errorf("result = %+v, want missing provenance and scan summary", result)
+	}
+
+	if _, err := client.AttachProvenance(t.Context(), ref, slsaStatement(t, digests["v1.0.0"], testBuilder)); err != nil {
+		t.Fatal(err)
+	}
+	result, err = client.VerifyTrust(t.Context(), ref)
+	if err != nil {
+		t.Fatalf("VerifyTrust() error = %v", err)
+	}
+	if result.Passed || len(result.Reasons) != 1 || !strings.Contains(result.Reasons[0], "scan-summary") || !result.Provenance.Verified {
+		t.Errorf("result = %+v, want only a missing scan summary", result)
+	}
+
+	if _, err := client.AttachScanSummary(t.Context(), ref, ScanSummary{}); err != nil {
+		t.Fatal(err)
+	}
+	result, err = client.VerifyTrust(t.Context(), ref)
+	if err != nil {
+		t.Fatalf("VerifyTrust() error = %v", err)
+	}
+	if !result.Passed || result.Digest != digests["v1.0.0"] {
+		t.Errorf("result = %+v, want a pass", result)
+	}
+
+	// Describe verifies provenance with the scope's builders.
+	described, err := client.DescribePersonality(t.Context(), ref)
+	if err != nil {
+		t.Fatalf("DescribePersonality() error = %v", err)
+	}
+	if described.Provenance == nil || !described.Provenance.Verified {
+		t.Errorf("Provenance = %+v, want verified", described.Provenance)
+	}
+
+	if _, err := NewClient(WithPlainHTTP(true)).VerifyTrust(t.Context(), ref); err == nil {
+		t.Error("VerifyTrust() without a trust policy succeeded, want error")
+	}
+}
@@ -0,0 +1,222 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// TypedKind binds a Klaus artifact kind to the Go type T that holds its
+// metadata. It parameterizes the generic Describe, Pull, and Push, so a
+// kind only supplies how T is read from and written to a manifest. Use
+// PluginKind, PersonalityKind, ToolchainKind, or CustomKind.
+type TypedKind[T any] struct {
+	// spec returns the media types of the kind.
+	spec func() (artifactKind, error)
+	// resolve expands ref to a fully-qualified reference.
+	resolve func(c *Client, ctx context.Context, ref string) (string, error)
+	// describe reads T from a fetched manifest of the kind.
+	describe func(c *Client, ctx context.Context, fm *fetchedManifest, resolved string) (T, error)
+	// decode reads T from the annotations and config blob of a pulled
+	// artifact; nil for kinds that cannot be pulled.
+	decode func(c *Client, annotations map[string]string, tag string, configJSON []byte) (T, error)
+	// encode returns the config blob and annotations to push for T; nil
+	// for kinds that cannot be pushed.
+	encode func(T) ([]byte, map[string]string, error)
+}
+
+// Described is an artifact's metadata with its OCI metadata, as returned
+// by Describe.
+type Described[T any] struct {
+	ArtifactInfo
+	Artifact T
+}
+
+// Pulled is an artifact's metadata with its OCI metadata and local file
+// state, as returned by Pull.
+type Pulled[T any] struct {
+	ArtifactInfo
+	Artifact T
+	Dir      string // Local directory where files were extracted
+	Cached   bool   // True if pull was skipped (cache hit)
+}
+
+func builtinSpec(k artifactKind) func() (artifactKind, error) {
+	return func() (artifactKind, error) { return k, nil }
+}
+
+var (
+	// PluginKind describes plugins for Describe, Pull, and Push.
+	PluginKind = TypedKind[Plugin]{
+		spec:    builtinSpec(pluginArtifact),
+		resolve: (*Client).ResolvePluginRef,
+		describe: func(_ *Client, ctx context.Context, fm *fetchedManifest, resolved string) (Plugin, error) {
+			return pluginFromManifest(ctx, fm, resolved)
+		},
+		decode: func(_ *Client, annotations map[string]string, tag string, configJSON []byte) (Plugin, error) {
+			return decodePlugin(annotations, tag, configJSON)
+		},
+		encode: Plugin.encode,
+	}
+
+	// PersonalityKind describes personalities for Describe, Pull, and
+	// Push. Pull returns the metadata only; PullPersonality also reads
+	// and renders the soul.
+	PersonalityKind = TypedKind[Personality]{
+		spec:     builtinSpec(personalityArtifact),
+		resolve:  (*Client).ResolvePersonalityRef,
+		describe: (*Client).personalityFromManifest,
+		decode: func(c *Client, annotations map[string]string, tag string, configJSON []byte) (Personality, error) {
+			p, err := decodePersonality(annotations, tag, configJSON)
+			if err != nil {
+				return Personality{}, err
+			}
+			return p, c.rewritePersonality(&p)
+		},
+		encode: Personality.encode,
+	}
+
+	// ToolchainKind describes toolchains for Describe. Toolchains are
+	// container images built and run by container tooling, so Pull and
+	// Push reject them.
+	ToolchainKind = TypedKind[Toolchain]{
+		spec:     builtinSpec(toolchainArtifact),
+		resolve:  (*Client).ResolveToolchainRef,
+		describe: (*Client).toolchainFromManifest,
+	}
+)
+
+// CustomKind describes the kind added under name with
+// RegisterArtifactKind. The kind is looked up when used, so CustomKind
+// may be called before the kind is registered.
+func CustomKind(name Kind) TypedKind[CustomArtifact] {
+	return TypedKind[CustomArtifact]{
+		spec: func() (artifactKind, error) {
+			k, err := lookupKind(name)
+			return k.artifactKind, err
+		},
+		resolve: func(c *Client, ctx context.Context, ref string) (string, error) {
+			k, err := lookupKind(name)
+			if err != nil {
+				return "", err
+			}
+			return k.resolveRef(ctx, c, ref)
+		},
+		describe: func(_ *Client, ctx context.Context, fm *fetchedManifest, resolved string) (CustomArtifact, error) {
+			k, err := lookupKind(name)
+			if err != nil {
+				return CustomArtifact{}, err
+			}
+			return customFromManifest(ctx, k, fm, resolved)
+		},
+		decode: func(_ *Client, annotations map[string]string, tag string, configJSON []byte) (CustomArtifact, error) {
+			k, err := lookupKind(name)
+			if err != nil {
+				return CustomArtifact{}, err
+			}
+			return k.customArtifact(annotations, tag, configJSON)
+		},
+		encode: func(a CustomArtifact) ([]byte, map[string]string, error) {
+			a.Kind = name
+			configJSON, err := json.Marshal(a.Config)
+			if err != nil {
+				return nil, nil, fmt.Errorf("marshaling %s config: %w", name, err)
+			}
+			return configJSON, a.annotations(), nil
+		},
+	}
+}
+
+// Describe fetches the manifest and config blob of ref and returns the
+// artifact's metadata as T without downloading the content layer. Short
+// names are expanded against the kind's default registry. It fails with
+// *ErrWrongArtifactType when ref is an artifact of another kind.
+func Describe[T any](ctx context.Context, c *Client, kind TypedKind[T], ref string) (*Described[T], error) {
+	described, _, err := describeTyped(ctx, c, kind, ref)
+	return described, err
+}
+
+// describeTyped implements Describe and also returns the fetched manifest
+// for callers that read more from it.
+func describeTyped[T any](ctx context.Context, c *Client, kind TypedKind[T], ref string) (*Described[T], *fetchedManifest, error) {
+	spec, err := kind.spec()
+	if err != nil {
+		return nil, nil, err
+	}
+	resolved, err := kind.resolve(c, ctx, ref)
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolving %s ref %q: %w", spec.Kind, ref, err)
+	}
+
+	fm, err := c.fetchDescribeManifest(ctx, resolved)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkArtifactKind(resolved, spec, fm.mediaType, fm.manifest.ArtifactType, fm.manifest.Config.MediaType, fm.manifest.Annotations); err != nil {
+		return nil, nil, err
+	}
+	artifact, err := kind.describe(c, ctx, fm, resolved)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &Described[T]{ArtifactInfo: fm.info(resolved), Artifact: artifact}, fm, nil
+}
+
+// Pull downloads the artifact ref into destDir and returns its metadata
+// as T. Artifacts already extracted at the same digest are not
+// downloaded again.
+func Pull[T any](ctx context.Context, c *Client, kind TypedKind[T], ref, destDir string, opts ...PullOption) (*Pulled[T], error) {
+	return pullTyped(ctx, c, kind, ref, destDir, newPullConfig(opts))
+}
+
+func pullTyped[T any](ctx context.Context, c *Client, kind TypedKind[T], ref, destDir string, cfg *pullConfig) (*Pulled[T], error) {
+	spec, err := kind.spec()
+	if err != nil {
+		return nil, err
+	}
+	if kind.decode == nil {
+		return nil, fmt.Errorf("%s artifacts cannot be pulled as Klaus artifacts", spec.Kind)
+	}
+	result, err := c.pull(ctx, ref, destDir, spec, cfg)
+	if err != nil {
+		return nil, err
+	}
+	_, tag := SplitNameTag(ref)
+	artifact, err := kind.decode(c, result.Annotations, tag, result.ConfigJSON)
+	if err != nil {
+		return nil, err
+	}
+	return &Pulled[T]{
+		ArtifactInfo: ArtifactInfo{Ref: ref, Tag: tag, Digest: result.Digest, Quarantine: result.Quarantine},
+		Artifact:     artifact,
+		Dir:          destDir,
+		Cached:       result.Cached,
+	}, nil
+}
+
+// Push packages sourceDir with the config blob and annotations of
+// artifact and pushes it to ref.
+func Push[T any](ctx context.Context, c *Client, kind TypedKind[T], sourceDir, ref string, artifact T, opts ...PushOption) (*PushResult, error) {
+	spec, err := kind.spec()
+	if err != nil {
+		return nil, err
+	}
+	if kind.encode == nil {
+		return nil, fmt.Errorf("%s artifacts cannot be pushed as Klaus artifacts", spec.Kind)
+	}
+	if err := withContentHash(sourceDir, &artifact); err != nil {
+		return nil, err
+	}
+	configJSON, annotations, err := kind.encode(artifact)
+	if err != nil {
+		return nil, err
+	}
+	return c.buildAndPush(ctx, ref, opts, func(spillAt int64) (*BuiltArtifact, error) {
+		return buildArtifact(sourceDir, configJSON, annotations, spec, spillAt)
+	})
+}
+
+// info returns the ArtifactInfo of a manifest fetched for describe.
+func (fm *fetchedManifest) info(resolved string) ArtifactInfo {
+	return ArtifactInfo{Ref: resolved, Tag: fm.tag, Digest: fm.digest, Redacted: fm.redacted, Quarantine: fm.quarantined, Provenance: fm.provenance}
+}
@@ -0,0 +1,81 @@
+package oci
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTypedKind_Plugin(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "skills", "helm", "SKILL.md"), "# helm")
+	ref := host + "/klaus-plugins/gs-base:v1.0.0"
+
+	pushed, err := Push(t.Context(), client, PluginKind, src, ref, Plugin{Name: "gs-base", Skills: []string{"helm"}})
+	if err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	described, err := Describe(t.Context(), client, PluginKind, ref)
+	if err != nil {
+		t.Fatalf("Describe() error = %v", err)
+	}
+	if described.Digest != pushed.Digest || described.Artifact.Name != "gs-base" || described.Artifact.Version != "v1.0.0" || !slices.Equal(described.Artifact.Skills, []string{"helm"}) {
+		t.Errorf("Describe() = %+v", described)
+	}
+
+	dest := t.TempDir()
+	pulled, err := Pull(t.Context(), client, PluginKind, ref, dest)
+	if err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if pulled.Digest != pushed.Digest || pulled.Dir != dest || pulled.Artifact.Name != "gs-base" {
+		t.Errorf("Pull() = %+v", pulled)
+	}
+	assertFileContent(t, filepath.Join(dest, "skills", "helm", "SKILL.md"), "# helm")
+
+	var wrong *ErrWrongArtifactType
+	if _, err := Describe(t.Context(), client, PersonalityKind, ref); !errors.As(err, &wrong) || wrong.Expected != KindPersonality {
+		t.Errorf("Describe(PersonalityKind) of a plugin error = %v, want *ErrWrongArtifactType", err)
+	}
+}
+
+func TestTypedKind_ToolchainNotPulledOrPushed(t *testing.T) {
+	client := NewClient()
+	if _, err := Pull(t.Context(), client, ToolchainKind, "example.com/klaus-toolchains/go:v1", t.TempDir()); err == nil || !strings.Contains(err.Error(), "cannot be pulled") {
+		t.Errorf("Pull(ToolchainKind) error = %v", err)
+	}
+	if _, err := Push(t.Context(), client, ToolchainKind, t.TempDir(), "example.com/klaus-toolchains/go:v1", Toolchain{}); err == nil || !strings.Contains(err.Error(), "cannot be pushed") {
+		t.Errorf("Push(ToolchainKind) error = %v", err)
+	}
+}
+
+func TestCustomKind_LookedUpWhenUsed(t *testing.T) {
+	kind := CustomKind(testKindDataset)
+	client := NewClient()
+	if _, err := Describe(t.Context(), client, kind, "example.com/klaus-datasets/evals:v1"); err == nil || !strings.Contains(err.Error(), "unknown artifact kind") {
+		t.Fatalf("Describe() before registration error = %v", err)
+	}
+
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	registerTestKind(t, host+"/klaus-datasets")
+	client = NewClient(WithPlainHTTP(true))
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "rows.jsonl"), "{}\n")
+	if _, err := Push(t.Context(), client, kind, src, host+"/klaus-datasets/evals:v1.0.0", CustomArtifact{Name: "evals", Config: testDatasetConfig{Rows: 1}}); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	described, err := Describe(t.Context(), client, kind, "evals")
+	if err != nil {
+		t.Fatalf("Describe() error = %v", err)
+	}
+	if described.Artifact.Kind != testKindDataset || described.Artifact.Name != "evals" {
+		t.Errorf("Describe() = %+v", described)
+	}
+}
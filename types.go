@@ -1,5 +1,7 @@
 package oci
 
+import "time"
+
 // Author represents the author of an artifact.
 type Author struct {
 	Name  string `json:"name,omitempty" yaml:"name,omitempty"`
@@ -25,6 +27,10 @@ type Author struct {
 // Claude Code where to find components. Here we store the *discovered*
 // component names so that Describe can report what the plugin provides
 // without downloading the content layer.
+//
+// The third group holds Klaus extensions that Claude Code does not define.
+// They are read from .claude-plugin/klaus.json and stored in the config
+// blob.
 type Plugin struct {
 	// --- Manifest metadata (from plugin.json) ---
 
@@ -54,10 +60,29 @@ type Plugin struct {
 	MCPServers []string `json:"mcpServers,omitempty"`
 	// LSPServers lists LSP server names (keys from .lsp.json).
 	LSPServers []string `json:"lspServers,omitempty"`
+	// ContentHash is the content-tree hash of the plugin directory (see
+	// ContentTreeHash). It is empty for artifacts pushed before the hash
+	// was recorded.
+	ContentHash string `json:"contentHash,omitempty"`
+
+	// --- Klaus extensions (from .claude-plugin/klaus.json) ---
+
+	// Dependencies lists other plugins this plugin requires. Personality
+	// dependency resolution pulls them in transitively.
+	Dependencies []PluginReference `json:"dependencies,omitempty"`
+	// Secrets lists the environment variables the plugin's MCP servers
+	// need. Only names and descriptions are declared, never values.
+	Secrets []SecretRequirement `json:"secrets,omitempty"`
+	// Permissions declares the runtime capabilities the plugin needs. Nil
+	// means the plugin did not declare any; see EvaluatePermissions.
+	Permissions *Permissions `json:"permissions,omitempty"`
+	// Private marks the plugin as private; see AnnotationPrivate.
+	Private bool `json:"private,omitempty"`
 }
 
 func (p Plugin) klausMetadata() commonMetadata {
 	return commonMetadata{
+		Kind:        KindPlugin,
 		Name:        p.Name,
 		Description: p.Description,
 		Author:      p.Author,
@@ -65,6 +90,25 @@ func (p Plugin) klausMetadata() commonMetadata {
 		SourceRepo:  p.SourceRepo,
 		License:     p.License,
 		Keywords:    p.Keywords,
+		Private:     p.Private,
+	}
+}
+
+// configBlob returns the OCI config blob stored for p.
+func (p Plugin) configBlob() pluginConfigBlob {
+	return pluginConfigBlob{
+		Skills:     p.Skills,
+		Commands:   p.Commands,
+		Agents:     p.Agents,
+		HasHooks:   p.HasHooks,
+		MCPServers: p.MCPServers,
+		LSPServers: p.LSPServers,
+
+		ContentHash: p.ContentHash,
+
+		Dependencies: p.Dependencies,
+		Secrets:      p.Secrets,
+		Permissions:  p.Permissions,
 	}
 }
 
@@ -86,6 +130,7 @@ func (p Plugin) klausMetadata() commonMetadata {
 // Fields are grouped by origin:
 //   - Metadata: from personality.yaml (stored as OCI manifest annotations)
 //   - Composition: from personality.yaml (stored in OCI config blob)
+//   - Runtime: from personality.yaml (stored in OCI config blob)
 //   - Version: from OCI tags (not in personality.yaml, not in config blob)
 type Personality struct {
 	// --- Metadata (from personality.yaml) ---
@@ -97,6 +142,8 @@ type Personality struct {
 	SourceRepo  string   `yaml:"repository,omitempty" json:"repository,omitempty"`
 	License     string   `yaml:"license,omitempty" json:"license,omitempty"`
 	Keywords    []string `yaml:"keywords,omitempty" json:"keywords,omitempty"`
+	// Private marks the personality as private; see AnnotationPrivate.
+	Private bool `yaml:"private,omitempty" json:"private,omitempty"`
 
 	// --- Composition (from personality.yaml) ---
 
@@ -104,6 +151,16 @@ type Personality struct {
 	Toolchain ToolchainReference `yaml:"toolchain,omitempty" json:"toolchain,omitempty"`
 	// Plugins lists the plugin artifacts that compose this personality's capabilities.
 	Plugins []PluginReference `yaml:"plugins,omitempty" json:"plugins,omitempty"`
+	// Extends references a parent personality (e.g. "gs-base:v1.0.0")
+	// whose composition and metadata this personality inherits. See
+	// Client.ResolvePersonalityInheritance for the merge rules.
+	Extends string `yaml:"extends,omitempty" json:"extends,omitempty"`
+
+	// --- Runtime (from personality.yaml, stored in OCI config blob) ---
+
+	// Requirements describes what the personality needs from the
+	// environment it runs in.
+	Requirements *Requirements `yaml:"requirements,omitempty" json:"requirements,omitempty"`
 
 	// --- External fields (not in personality.yaml, not in config blob) ---
 
@@ -111,10 +168,16 @@ type Personality struct {
 	// conveyed via the OCI tag when pushing, and populated from the resolved
 	// OCI tag when fetching (describe/pull).
 	Version string `yaml:"-" json:"-"`
+	// ContentHash is the content-tree hash of the personality directory
+	// (see ContentTreeHash), computed at push time and stored in the
+	// config blob. It is empty for artifacts pushed before the hash was
+	// recorded.
+	ContentHash string `yaml:"-" json:"contentHash,omitempty"`
 }
 
 func (p Personality) klausMetadata() commonMetadata {
 	return commonMetadata{
+		Kind:        KindPersonality,
 		Name:        p.Name,
 		Description: p.Description,
 		Author:      p.Author,
@@ -122,6 +185,19 @@ func (p Personality) klausMetadata() commonMetadata {
 		SourceRepo:  p.SourceRepo,
 		License:     p.License,
 		Keywords:    p.Keywords,
+		Private:     p.Private,
+	}
+}
+
+// configBlob returns the OCI config blob stored for p.
+func (p Personality) configBlob() personalityConfigBlob {
+	return personalityConfigBlob{
+		Toolchain: p.Toolchain,
+		Plugins:   p.Plugins,
+		Extends:   p.Extends,
+
+		Requirements: p.Requirements,
+		ContentHash:  p.ContentHash,
 	}
 }
 
@@ -147,6 +223,22 @@ type Toolchain struct {
 	SourceRepo  string   `json:"repository,omitempty"`
 	License     string   `json:"license,omitempty"`
 	Keywords    []string `json:"keywords,omitempty"`
+	// Private marks the toolchain as private; see AnnotationPrivate.
+	Private bool `json:"private,omitempty"`
+}
+
+func (t Toolchain) klausMetadata() commonMetadata {
+	return commonMetadata{
+		Kind:        KindToolchain,
+		Name:        t.Name,
+		Description: t.Description,
+		Author:      t.Author,
+		Homepage:    t.Homepage,
+		SourceRepo:  t.SourceRepo,
+		License:     t.License,
+		Keywords:    t.Keywords,
+		Private:     t.Private,
+	}
 }
 
 // PluginReference points to a plugin OCI artifact.
@@ -190,21 +282,52 @@ func (t ToolchainReference) Ref() string {
 	return t.Repository
 }
 
+// Kind identifies the type of a Klaus artifact.
+type Kind string
+
+// Klaus artifact kinds.
+const (
+	KindPlugin      Kind = "plugin"
+	KindPersonality Kind = "personality"
+	KindToolchain   Kind = "toolchain"
+)
+
 // ArtifactInfo holds OCI-level metadata returned by all operations
 // that contact the registry (describe, pull).
 type ArtifactInfo struct {
 	Ref    string // Fully-qualified OCI reference (includes tag)
 	Tag    string // Resolved OCI tag (e.g. "v1.0.0") -- source of truth for Version
 	Digest string // Manifest digest
+	// Redacted is true if private metadata was omitted from a describe
+	// result (see WithIncludePrivate).
+	Redacted bool
+	// Quarantine is set if the artifact was quarantined (see
+	// Client.Quarantine).
+	Quarantine *Quarantine
+	// Provenance is the outcome of verifying the artifact's SLSA
+	// provenance, set by describe operations of clients created
+	// WithProvenanceVerification or WithTrustPolicy.
+	Provenance *ProvenanceStatus
 }
 
 // ListEntry holds metadata for an artifact discovered by list operations.
 // Populated from the registry catalog + tag resolution (no config fetch).
 type ListEntry struct {
-	Name       string // Short name (e.g. "sre", "gs-base")
-	Version    string // Latest semver tag (e.g. "v1.0.0")
-	Repository string // Full OCI repository path
-	Reference  string // Full OCI reference with tag
+	Name       string    // Short name (e.g. "sre", "gs-base")
+	Version    string    // Latest semver tag (e.g. "v1.0.0")
+	Repository string    // Full OCI repository path
+	Reference  string    // Full OCI reference with tag
+	Archived   bool      // In the archive namespace; see WithArchived
+	Updated    time.Time // Creation time of the latest version; see FieldUpdated
+}
+
+// DetailedListEntry is a plugin ListEntry enriched with the plugin's
+// metadata and manifest digest, as needed for catalogs such as a Claude
+// Code marketplace feed.
+type DetailedListEntry struct {
+	ListEntry
+	Digest string
+	Plugin Plugin
 }
 
 // DescribedPlugin is a Plugin with its OCI metadata.
@@ -218,6 +341,10 @@ type DescribedPlugin struct {
 type DescribedPersonality struct {
 	ArtifactInfo
 	Personality
+	// EvalResults lists the evaluation results attached to the
+	// personality, newest first. Only set by clients created
+	// WithEvalResults.
+	EvalResults []EvalResults
 }
 
 // DescribedToolchain is a Toolchain with its OCI metadata.
@@ -226,12 +353,29 @@ type DescribedToolchain struct {
 	Toolchain
 }
 
+// DescribedArtifact is the result of Describe, which detects the artifact
+// kind from the manifest. Exactly one of Plugin, Personality, Toolchain,
+// or Custom is set, according to Kind; Custom holds kinds added with
+// RegisterArtifactKind.
+type DescribedArtifact struct {
+	Kind        Kind
+	Plugin      *DescribedPlugin
+	Personality *DescribedPersonality
+	Toolchain   *DescribedToolchain
+	Custom      *DescribedCustomArtifact
+}
+
 // PulledPlugin is a Plugin with OCI metadata and local file state.
 type PulledPlugin struct {
 	ArtifactInfo
 	Plugin
 	Dir    string // Local directory where files were extracted
 	Cached bool   // True if pull was skipped (cache hit)
+	// Warnings lists discrepancies between the components declared in the
+	// config blob and the extracted tree, e.g. a declared skill without
+	// skills/<name>/SKILL.md. They point at packaging bugs; the pull
+	// itself succeeded.
+	Warnings []string
 }
 
 // PulledPersonality is a Personality with OCI metadata, local file state,
@@ -239,22 +383,67 @@ type PulledPlugin struct {
 type PulledPersonality struct {
 	ArtifactInfo
 	Personality
-	Soul   string // Behavioral identity text from SOUL.md (content layer only)
-	Dir    string
-	Cached bool
+	Soul         string   // Behavioral identity text from SOUL.md (content layer only)
+	SoulVariant  string   // Variant Soul was read from (see WithSoulVariant); empty for SOUL.md
+	SoulVariants []string // Variants available as SOUL.<variant>.md
+	Dir          string
+	Cached       bool
+}
+
+// PulledArtifact is the result of PullAny, which detects the artifact kind
+// from the manifest. Exactly one of Plugin, Personality, or Custom is set,
+// according to Kind; Custom holds kinds added with RegisterArtifactKind.
+type PulledArtifact struct {
+	Kind        Kind
+	Plugin      *PulledPlugin
+	Personality *PulledPersonality
+	Custom      *PulledCustomArtifact
 }
 
 // ResolvedDependencies holds the result of resolving a personality's
 // toolchain and plugin references.
 type ResolvedDependencies struct {
 	Toolchain *DescribedToolchain
+	// Plugins holds the personality's plugins followed by their transitive
+	// dependencies, each plugin repository at most once.
 	Plugins   []DescribedPlugin
+	Conflicts []DependencyConflict
 	Warnings  []string // e.g. "plugin gs-sre: not found in registry"
+	// Cached is true when the result was served from the dependency
+	// cache; see Client.ResolvePersonalityDeps.
+	Cached bool `json:"-"`
+}
+
+// DependencyConflict records a plugin dependency that was dropped because
+// another version of the same plugin repository had already been selected.
+// The personality's own plugin references win over transitive
+// dependencies; among dependencies, the one closest to the personality
+// wins.
+type DependencyConflict struct {
+	Repository string
+	// Selected is the reference that was kept.
+	Selected string
+	// Requested is the conflicting reference that was dropped.
+	Requested string
+	// RequiredBy is the name of the plugin that declared Requested.
+	RequiredBy string
 }
 
 // PushResult holds the outcome of a push operation.
 type PushResult struct {
 	Digest string
+	// Tags lists the tags pointing at the manifest: the tag of the pushed
+	// reference followed by any WithAdditionalTags aliases.
+	Tags []string
+	// Checksums lists the digests of the pushed manifest, blobs, and files.
+	Checksums *Checksums
+	// ChecksumReferrer is the manifest digest of the checksum referrer, set
+	// when pushed with WithChecksumReferrer.
+	ChecksumReferrer string
+	// Warnings describes optional steps of the push that the registry's
+	// capabilities forced to degrade or skip, e.g. a checksum referrer
+	// the registry rejected. The artifact itself was pushed.
+	Warnings []string
 }
 
 // pluginConfigBlob is the OCI config blob schema for plugins.
@@ -266,6 +455,12 @@ type pluginConfigBlob struct {
 	HasHooks   bool     `json:"hasHooks,omitempty"`
 	MCPServers []string `json:"mcpServers,omitempty"`
 	LSPServers []string `json:"lspServers,omitempty"`
+
+	ContentHash string `json:"contentHash,omitempty"`
+
+	Dependencies []PluginReference   `json:"dependencies,omitempty"`
+	Secrets      []SecretRequirement `json:"secrets,omitempty"`
+	Permissions  *Permissions        `json:"permissions,omitempty"`
 }
 
 // personalityConfigBlob is the OCI config blob schema for personalities.
@@ -273,6 +468,10 @@ type pluginConfigBlob struct {
 type personalityConfigBlob struct {
 	Toolchain ToolchainReference `json:"toolchain,omitempty"`
 	Plugins   []PluginReference  `json:"plugins,omitempty"`
+	Extends   string             `json:"extends,omitempty"`
+
+	Requirements *Requirements `json:"requirements,omitempty"`
+	ContentHash  string        `json:"contentHash,omitempty"`
 }
 
 // pullResult holds the result of a successful internal pull operation.
@@ -282,4 +481,5 @@ type pullResult struct {
 	Cached      bool
 	ConfigJSON  []byte            // Raw OCI config blob (read from cache entry on cache hit).
 	Annotations map[string]string // OCI manifest annotations (persisted in cache).
+	Quarantine  *Quarantine       // Set for quarantined artifacts pulled WithAllowQuarantined.
 }
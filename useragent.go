@@ -0,0 +1,57 @@
+package oci
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// modulePath is the import path of this module, used to look up its
+// version in the build information of the running binary.
+const modulePath = "github.com/giantswarm/klaus-oci"
+
+// Version returns the version of the klaus-oci module linked into the
+// running binary, e.g. "v0.12.0". It returns "(devel)" when the version is
+// unknown, e.g. in tests of this module or builds without module support.
+func Version() string {
+	return moduleVersion()
+}
+
+var moduleVersion = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+	if info.Main.Path == modulePath && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != modulePath {
+			continue
+		}
+		if dep.Replace != nil && dep.Replace.Version != "" {
+			return dep.Replace.Version
+		}
+		if dep.Version != "" {
+			return dep.Version
+		}
+	}
+	return "(devel)"
+})
+
+// WithUserAgent identifies the consumer of the library, e.g.
+// "klausctl/1.4.0", in the User-Agent header of registry requests:
+// "klaus-oci/<version> (<consumer>)". Without it, the header is
+// "klaus-oci/<version>", so registry operators can tell Klaus traffic
+// apart and, with a consumer set, tell its tools apart.
+func WithUserAgent(consumer string) ClientOption {
+	return func(c *Client) { c.userAgentConsumer = consumer }
+}
+
+// userAgent returns the User-Agent header sent with registry requests.
+func (c *Client) userAgent() string {
+	ua := "klaus-oci/" + Version()
+	if c.userAgentConsumer != "" {
+		ua += " (" + c.userAgentConsumer + ")"
+	}
+	return ua
+}
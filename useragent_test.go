@@ -0,0 +1,58 @@
+package oci
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	// Tests run as the main module, whose version is not stamped.
+	if got := Version(); got != "(devel)" && !strings.HasPrefix(got, "v") {
+		t.Errorf("Version() = %q, want a module version", got)
+	}
+}
+
+func TestWithUserAgent(t *testing.T) {
+	reg := newCacheRegistry()
+	inner := reg.handler()
+	var (
+		mu     sync.Mutex
+		agents = map[string]string{}
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		agents[r.URL.Path] = r.UserAgent()
+		mu.Unlock()
+		inner.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	host := testRegistryHost(ts)
+
+	tests := []struct {
+		name string
+		opts []ClientOption
+		want string
+	}{
+		{name: "default", want: "klaus-oci/" + Version()},
+		{name: "consumer", opts: []ClientOption{WithUserAgent("klausctl/1.4.0")}, want: "klaus-oci/" + Version() + " (klausctl/1.4.0)"},
+		{name: "after auth env", opts: []ClientOption{WithUserAgent("klaus-operator"), WithRegistryAuthEnv("KLAUS_TEST_AUTH")}, want: "klaus-oci/" + Version() + " (klaus-operator)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(append([]ClientOption{WithPlainHTTP(true)}, tt.opts...)...)
+			_, _ = client.Resolve(t.Context(), host+"/klaus/sre:v1.0.0")
+			_, _ = client.newHarborClient(host).detect(t.Context())
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, path := range []string{"/v2/klaus/sre/manifests/v1.0.0", "/api/version"} {
+				if got := agents[path]; got != tt.want {
+					t.Errorf("User-Agent for %s = %q, want %q", path, got, tt.want)
+				}
+			}
+		})
+	}
+}
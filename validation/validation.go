@@ -0,0 +1,437 @@
+// Package validation checks the Klaus artifact references of a KlausInstance
+// so that an operator can reject invalid instances from a
+// ValidatingAdmissionWebhook.
+//
+// A Validator resolves each reference (short names, missing tags, "latest"),
+// confirms the artifact exists in its registry, applies admission policies,
+// and optionally verifies signatures. All problems are collected into a
+// single Response instead of failing on the first one, so users see every
+// broken reference at once. The policy and signature checks of successful
+// validations are cached for a short time, per resolved digest and
+// credential, because the API server calls webhooks on every create and
+// update.
+//
+// The package does not depend on Kubernetes API types. The operator maps a
+// Response onto an admission/v1 AdmissionResponse:
+//
+//	resp := v.Validate(ctx, validation.Request{
+//		Personality: instance.Spec.Personality,
+//		Toolchain:   instance.Spec.Toolchain,
+//		Plugins:     pluginRefs(instance.Spec.Plugins),
+//	})
+//	return admissionv1.AdmissionResponse{
+//		Allowed:  resp.Allowed,
+//		Warnings: resp.Warnings,
+//		Result:   &metav1.Status{Message: resp.Message()},
+//	}
+package validation
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	oci "github.com/giantswarm/klaus-oci"
+)
+
+// DefaultCacheTTL is how long successful validations are reused.
+const DefaultCacheTTL = time.Minute
+
+// maxCacheEntries bounds the validations a Validator caches.
+const maxCacheEntries = 4096
+
+// Resolver resolves and checks artifact references. *oci.Client satisfies
+// this interface.
+type Resolver interface {
+	ResolvePersonalityRef(ctx context.Context, ref string) (string, error)
+	ResolveToolchainRef(ctx context.Context, ref string) (string, error)
+	ResolvePluginRef(ctx context.Context, ref string) (string, error)
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// Policy decides whether a resolved reference may be admitted. Check
+// returns a non-nil error describing the violation when it may not.
+type Policy interface {
+	Check(ctx context.Context, kind oci.Kind, ref, digest string) error
+}
+
+// PolicyFunc adapts a function to the Policy interface.
+type PolicyFunc func(ctx context.Context, kind oci.Kind, ref, digest string) error
+
+// Check calls f.
+func (f PolicyFunc) Check(ctx context.Context, kind oci.Kind, ref, digest string) error {
+	return f(ctx, kind, ref, digest)
+}
+
+// AllowedRegistries returns a Policy that admits only references under one
+// of the given registry base paths (e.g. "gsoci.azurecr.io/giantswarm").
+func AllowedRegistries(bases ...string) Policy {
+	return PolicyFunc(func(_ context.Context, _ oci.Kind, ref, _ string) error {
+		repo := oci.RepositoryFromRef(ref)
+		for _, base := range bases {
+			base = strings.TrimSuffix(base, "/")
+			if repo == base || strings.HasPrefix(repo, base+"/") {
+				return nil
+			}
+		}
+		return fmt.Errorf("registry of %s is not allowed (allowed: %s)", repo, strings.Join(bases, ", "))
+	})
+}
+
+// SecurityEvaluator evaluates security policies against the scan
+// summaries attached to artifacts. *oci.Client satisfies this interface.
+type SecurityEvaluator interface {
+	EvaluateSecurityPolicy(ctx context.Context, ref string, policy oci.SecurityPolicy) (*oci.PolicyResult, error)
+}
+
+// ScanPolicy returns a Policy that admits only artifacts whose newest scan
+// summary passes policy (see oci.Client.EvaluateSecurityPolicy). The
+// reference is evaluated pinned to the resolved digest, so the scan is
+// that of the admitted manifest.
+func ScanPolicy(e SecurityEvaluator, policy oci.SecurityPolicy) Policy {
+	return PolicyFunc(func(ctx context.Context, _ oci.Kind, ref, digest string) error {
+		if digest != "" {
+			ref = oci.RepositoryFromRef(ref) + "@" + digest
+		}
+		result, err := e.EvaluateSecurityPolicy(ctx, ref, policy)
+		if err != nil {
+			return fmt.Errorf("evaluating security policy: %w", err)
+		}
+		if !result.Passed {
+			return fmt.Errorf("security policy violated: %s", strings.Join(result.Reasons, "; "))
+		}
+		return nil
+	})
+}
+
+// TrustVerifier checks artifacts against a trust policy. *oci.Client
+// created with oci.WithTrustPolicy satisfies this interface.
+type TrustVerifier interface {
+	VerifyTrust(ctx context.Context, ref string) (*oci.TrustResult, error)
+}
+
+// TrustPolicy returns a Policy that admits only artifacts carrying the
+// attestations their trust policy scope requires (see
+// oci.Client.VerifyTrust). Like ScanPolicy, the reference is checked
+// pinned to the resolved digest.
+func TrustPolicy(v TrustVerifier) Policy {
+	return PolicyFunc(func(ctx context.Context, _ oci.Kind, ref, digest string) error {
+		if digest != "" {
+			ref = oci.RepositoryFromRef(ref) + "@" + digest
+		}
+		result, err := v.VerifyTrust(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("verifying trust policy: %w", err)
+		}
+		if !result.Passed {
+			return fmt.Errorf("trust policy violated: %s", strings.Join(result.Reasons, "; "))
+		}
+		return nil
+	})
+}
+
+// SignatureVerifier verifies that the artifact with the given digest is
+// signed by a trusted identity.
+type SignatureVerifier interface {
+	Verify(ctx context.Context, ref, digest string) error
+}
+
+// Option configures a Validator.
+type Option func(*Validator)
+
+// WithPolicy adds an admission policy. Policies are evaluated in the order
+// they were added and all violations are reported.
+func WithPolicy(p Policy) Option {
+	return func(v *Validator) { v.policies = append(v.policies, p) }
+}
+
+// WithSignatureVerifier enables signature verification of every resolved
+// reference.
+func WithSignatureVerifier(sv SignatureVerifier) Option {
+	return func(v *Validator) { v.verifier = sv }
+}
+
+// WithCacheTTL sets how long successful validations are reused. A
+// non-positive ttl disables caching. References are resolved on every
+// validation; only the policy and signature checks of a resolved digest
+// are skipped, and only for requests made with the same context
+// credentials (see oci.WithContextCredentials).
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(v *Validator) { v.cacheTTL = ttl }
+}
+
+// Validator validates Klaus references for admission. It is safe for
+// concurrent use.
+type Validator struct {
+	resolver Resolver
+	policies []Policy
+	verifier SignatureVerifier
+	cacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[cacheKey]time.Time
+	// nextSweep is when expired cache entries are dropped next.
+	nextSweep time.Time
+	now       func() time.Time
+	// credentialKey keys the credential fingerprints of cache keys, so
+	// they cannot be checked against guessed passwords.
+	credentialKey []byte
+}
+
+// cacheKey identifies a successful validation: the resolved reference and
+// digest, and the context credentials they were checked with.
+type cacheKey struct {
+	kind       oci.Kind
+	resolved   string
+	digest     string
+	credential string
+}
+
+// New creates a Validator that resolves references through r.
+func New(r Resolver, opts ...Option) *Validator {
+	v := &Validator{
+		resolver: r,
+		cacheTTL: DefaultCacheTTL,
+		cache:    make(map[cacheKey]time.Time),
+		now:      time.Now,
+	}
+	v.credentialKey = make([]byte, 32)
+	_, _ = rand.Read(v.credentialKey)
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Request holds the references of a KlausInstance. Empty fields are
+// skipped.
+type Request struct {
+	Personality string
+	Toolchain   string
+	Plugins     []string
+}
+
+// Violation describes why a single reference was rejected.
+type Violation struct {
+	// Field is the path of the offending field, e.g. "spec.plugins[1]".
+	Field string
+	// Ref is the reference as given in the request.
+	Ref string
+	// Message explains the problem.
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s: %s", v.Field, v.Ref, v.Message)
+}
+
+// Resolved is a reference that passed validation.
+type Resolved struct {
+	Field  string
+	Kind   oci.Kind
+	Ref    string
+	Digest string
+}
+
+// Response is the aggregated result of validating a Request.
+type Response struct {
+	// Allowed is true when no reference was rejected.
+	Allowed bool
+	// Violations lists every rejected reference.
+	Violations []Violation
+	// Warnings are non-fatal findings, such as references that float on a
+	// tag instead of pinning a version.
+	Warnings []string
+	// Resolved lists the fully-qualified reference and digest of every
+	// reference that passed validation.
+	Resolved []Resolved
+}
+
+// Message summarizes the violations in one line, suitable for an
+// admission response status message. It is empty when the request is
+// allowed.
+func (r *Response) Message() string {
+	if r.Allowed {
+		return ""
+	}
+	parts := make([]string, len(r.Violations))
+	for i, v := range r.Violations {
+		parts[i] = v.String()
+	}
+	return strings.Join(parts, "; ")
+}
+
+type check struct {
+	field string
+	kind  oci.Kind
+	ref   string
+}
+
+// Validate checks every reference in req concurrently and returns the
+// aggregated response. It never returns an error: registry failures are
+// reported as violations so that the webhook fails closed.
+func (v *Validator) Validate(ctx context.Context, req Request) *Response {
+	var checks []check
+	if req.Personality != "" {
+		checks = append(checks, check{field: "spec.personality", kind: oci.KindPersonality, ref: req.Personality})
+	}
+	if req.Toolchain != "" {
+		checks = append(checks, check{field: "spec.toolchain", kind: oci.KindToolchain, ref: req.Toolchain})
+	}
+	for i, p := range req.Plugins {
+		checks = append(checks, check{field: fmt.Sprintf("spec.plugins[%d]", i), kind: oci.KindPlugin, ref: p})
+	}
+
+	type outcome struct {
+		resolved Resolved
+		warning  string
+		errs     []string
+	}
+	outcomes := make([]outcome, len(checks))
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resolved, digest, errs := v.check(ctx, c)
+			outcomes[i] = outcome{
+				resolved: Resolved{Field: c.field, Kind: c.kind, Ref: resolved, Digest: digest},
+				errs:     errs,
+			}
+			if len(errs) == 0 && isFloating(c.ref) {
+				outcomes[i].warning = fmt.Sprintf("%s: %s resolved to %s; pin the version to make the instance reproducible", c.field, c.ref, resolved)
+			}
+		}()
+	}
+	wg.Wait()
+
+	resp := &Response{}
+	for i, o := range outcomes {
+		for _, msg := range o.errs {
+			resp.Violations = append(resp.Violations, Violation{Field: checks[i].field, Ref: checks[i].ref, Message: msg})
+		}
+		if len(o.errs) == 0 {
+			resp.Resolved = append(resp.Resolved, o.resolved)
+		}
+		if o.warning != "" {
+			resp.Warnings = append(resp.Warnings, o.warning)
+		}
+	}
+	resp.Allowed = len(resp.Violations) == 0
+	return resp
+}
+
+// check resolves, verifies, and applies policies to a single reference.
+// Resolution and existence failures stop further checks; policy and
+// signature failures are all collected.
+func (v *Validator) check(ctx context.Context, c check) (resolved, digest string, errs []string) {
+	resolved, err := v.resolve(ctx, c.kind, c.ref)
+	if err != nil {
+		return "", "", []string{fmt.Sprintf("resolving reference: %v", err)}
+	}
+	digest, err = v.resolver.Resolve(ctx, resolved)
+	if err != nil {
+		return resolved, "", []string{fmt.Sprintf("artifact not found: %v", err)}
+	}
+	key := cacheKey{kind: c.kind, resolved: resolved, digest: digest, credential: v.credential(ctx)}
+	if v.cached(key) {
+		return resolved, digest, nil
+	}
+
+	for _, p := range v.policies {
+		if err := p.Check(ctx, c.kind, resolved, digest); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if v.verifier != nil {
+		if err := v.verifier.Verify(ctx, resolved, digest); err != nil {
+			errs = append(errs, fmt.Sprintf("signature verification failed: %v", err))
+		}
+	}
+
+	if len(errs) == 0 {
+		v.store(key)
+	}
+	return resolved, digest, errs
+}
+
+// isFloating reports whether ref lacks a version: no tag, "latest", and
+// no digest.
+func isFloating(ref string) bool {
+	if strings.Contains(ref, "@") {
+		return false
+	}
+	_, tag := oci.SplitNameTag(ref)
+	return tag == "" || tag == "latest"
+}
+
+func (v *Validator) resolve(ctx context.Context, kind oci.Kind, ref string) (string, error) {
+	switch kind {
+	case oci.KindPersonality:
+		return v.resolver.ResolvePersonalityRef(ctx, ref)
+	case oci.KindToolchain:
+		return v.resolver.ResolveToolchainRef(ctx, ref)
+	default:
+		return v.resolver.ResolvePluginRef(ctx, ref)
+	}
+}
+
+// credential returns a keyed fingerprint of the context credentials of
+// ctx, empty without them.
+func (v *Validator) credential(ctx context.Context) string {
+	cred, ok := oci.ContextCredentials(ctx)
+	if !ok {
+		return ""
+	}
+	mac := hmac.New(sha256.New, v.credentialKey)
+	mac.Write([]byte(strings.Join([]string{cred.Username, cred.Password, cred.RefreshToken, cred.AccessToken}, "\x00")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (v *Validator) cached(key cacheKey) bool {
+	if v.cacheTTL <= 0 {
+		return false
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	expires, ok := v.cache[key]
+	if !ok || v.now().After(expires) {
+		delete(v.cache, key)
+		return false
+	}
+	return true
+}
+
+// store caches key. Expired entries are swept at most once per TTL, and
+// the entries expiring first are evicted when the cache is full.
+func (v *Validator) store(key cacheKey) {
+	if v.cacheTTL <= 0 {
+		return
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	now := v.now()
+	if now.After(v.nextSweep) {
+		maps.DeleteFunc(v.cache, func(_ cacheKey, expires time.Time) bool { return now.After(expires) })
+		v.nextSweep = now.Add(v.cacheTTL)
+	}
+	for len(v.cache) >= maxCacheEntries {
+		var oldest cacheKey
+		var oldestExpires time.Time
+		for k, expires := range v.cache {
+			if oldestExpires.IsZero() || expires.Before(oldestExpires) {
+				oldest, oldestExpires = k, expires
+			}
+		}
+		delete(v.cache, oldest)
+	}
+	v.cache[key] = now.Add(v.cacheTTL)
+}
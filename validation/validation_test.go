@@ -0,0 +1,413 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	oci "github.com/giantswarm/klaus-oci"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// fakeResolver resolves short names against fixed registry bases and knows
+// a fixed set of existing references.
+type fakeResolver struct {
+	latest map[string]string // repository -> latest tag
+	digest map[string]string // full ref -> digest
+
+	mu       sync.Mutex
+	resolves int
+}
+
+func (f *fakeResolver) resolve(ref, base string) (string, error) {
+	if !strings.Contains(ref, "/") {
+		ref = base + "/" + ref
+	}
+	if strings.Contains(ref, "@") {
+		return ref, nil
+	}
+	repo, tag := oci.SplitNameTag(ref)
+	if tag != "" && tag != "latest" {
+		return ref, nil
+	}
+	latest, ok := f.latest[repo]
+	if !ok {
+		return "", fmt.Errorf("no semver tags found for %s", repo)
+	}
+	return repo + ":" + latest, nil
+}
+
+func (f *fakeResolver) ResolvePersonalityRef(_ context.Context, ref string) (string, error) {
+	return f.resolve(ref, oci.DefaultPersonalityRegistry)
+}
+
+func (f *fakeResolver) ResolveToolchainRef(_ context.Context, ref string) (string, error) {
+	return f.resolve(ref, oci.DefaultToolchainRegistry)
+}
+
+func (f *fakeResolver) ResolvePluginRef(_ context.Context, ref string) (string, error) {
+	return f.resolve(ref, oci.DefaultPluginRegistry)
+}
+
+func (f *fakeResolver) Resolve(_ context.Context, ref string) (string, error) {
+	f.mu.Lock()
+	f.resolves++
+	f.mu.Unlock()
+	d, ok := f.digest[ref]
+	if !ok {
+		return "", fmt.Errorf("resolving %s: not found", ref)
+	}
+	return d, nil
+}
+
+func newFakeResolver() *fakeResolver {
+	return &fakeResolver{
+		latest: map[string]string{
+			oci.DefaultPersonalityRegistry + "/sre": "v1.0.0",
+			oci.DefaultPluginRegistry + "/gs-base":  "v0.2.0",
+		},
+		digest: map[string]string{
+			oci.DefaultPersonalityRegistry + "/sre:v1.0.0": "sha256:aaa",
+			oci.DefaultToolchainRegistry + "/go:v1.2.0":    "sha256:bbb",
+			oci.DefaultPluginRegistry + "/gs-base:v0.2.0":  "sha256:ccc",
+			"evil.example.com/plugins/miner:v1.0.0":        "sha256:ddd",
+		},
+	}
+}
+
+func TestValidate_Allowed(t *testing.T) {
+	v := New(newFakeResolver())
+
+	resp := v.Validate(t.Context(), Request{
+		Personality: "sre",
+		Toolchain:   "go:v1.2.0",
+		Plugins:     []string{"gs-base"},
+	})
+	if !resp.Allowed {
+		t.Fatalf("Allowed = false, violations = %v", resp.Violations)
+	}
+	if resp.Message() != "" {
+		t.Errorf("Message() = %q, want empty", resp.Message())
+	}
+	if len(resp.Resolved) != 3 {
+		t.Fatalf("Resolved = %+v, want 3 entries", resp.Resolved)
+	}
+	if got := resp.Resolved[0]; got.Kind != oci.KindPersonality || got.Digest != "sha256:aaa" {
+		t.Errorf("Resolved[0] = %+v, want personality sha256:aaa", got)
+	}
+	if len(resp.Warnings) != 2 {
+		t.Errorf("Warnings = %v, want 2 for the unpinned refs", resp.Warnings)
+	}
+}
+
+func TestValidate_AggregatesViolations(t *testing.T) {
+	v := New(newFakeResolver(),
+		WithPolicy(AllowedRegistries("gsoci.azurecr.io/giantswarm")),
+	)
+
+	resp := v.Validate(t.Context(), Request{
+		Personality: "unknown",
+		Toolchain:   "go:v9.9.9",
+		Plugins: []string{
+			"gs-base",
+			"evil.example.com/plugins/miner:v1.0.0",
+		},
+	})
+	if resp.Allowed {
+		t.Fatal("Allowed = true, want false")
+	}
+
+	fields := map[string]string{}
+	for _, viol := range resp.Violations {
+		fields[viol.Field] = viol.Message
+	}
+	for _, want := range []string{"spec.personality", "spec.toolchain", "spec.plugins[1]"} {
+		if _, ok := fields[want]; !ok {
+			t.Errorf("missing violation for %s in %v", want, resp.Violations)
+		}
+	}
+	if _, ok := fields["spec.plugins[0]"]; ok {
+		t.Errorf("unexpected violation for spec.plugins[0]: %v", fields["spec.plugins[0]"])
+	}
+	if !strings.Contains(fields["spec.toolchain"], "not found") {
+		t.Errorf("toolchain violation = %q, want not found", fields["spec.toolchain"])
+	}
+	if !strings.Contains(fields["spec.plugins[1]"], "not allowed") {
+		t.Errorf("plugin violation = %q, want not allowed", fields["spec.plugins[1]"])
+	}
+	if !strings.Contains(resp.Message(), "spec.plugins[1]") {
+		t.Errorf("Message() = %q, want it to mention spec.plugins[1]", resp.Message())
+	}
+}
+
+type fakeVerifier struct {
+	trusted map[string]bool
+}
+
+func (f fakeVerifier) Verify(_ context.Context, _, digest string) error {
+	if !f.trusted[digest] {
+		return errors.New("no trusted signature")
+	}
+	return nil
+}
+
+func TestValidate_SignatureVerification(t *testing.T) {
+	v := New(newFakeResolver(), WithSignatureVerifier(fakeVerifier{
+		trusted: map[string]bool{"sha256:aaa": true},
+	}))
+
+	resp := v.Validate(t.Context(), Request{
+		Personality: "sre:v1.0.0",
+		Plugins:     []string{"gs-base:v0.2.0"},
+	})
+	if resp.Allowed {
+		t.Fatal("Allowed = true, want false")
+	}
+	if len(resp.Violations) != 1 || resp.Violations[0].Field != "spec.plugins[0]" {
+		t.Fatalf("Violations = %v, want one for spec.plugins[0]", resp.Violations)
+	}
+	if !strings.Contains(resp.Violations[0].Message, "signature verification failed") {
+		t.Errorf("Message = %q", resp.Violations[0].Message)
+	}
+}
+
+// countingPolicy counts the references it checks.
+type countingPolicy struct {
+	mu     sync.Mutex
+	checks int
+}
+
+func (p *countingPolicy) Check(context.Context, oci.Kind, string, string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.checks++
+	return nil
+}
+
+func (p *countingPolicy) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.checks
+}
+
+func TestValidate_CachesSuccess(t *testing.T) {
+	r := newFakeResolver()
+	policy := &countingPolicy{}
+	v := New(r, WithPolicy(policy), WithCacheTTL(time.Minute))
+	now := time.Now()
+	v.now = func() time.Time { return now }
+
+	req := Request{Personality: "sre:v1.0.0", Toolchain: "go:v9.9.9"}
+	for range 3 {
+		v.Validate(t.Context(), req)
+	}
+	// References are resolved every time, but the personality is only
+	// checked on the first call; the missing toolchain is never checked.
+	if r.resolves != 6 {
+		t.Errorf("Resolve called %d times, want 6", r.resolves)
+	}
+	if n := policy.count(); n != 1 {
+		t.Errorf("policy checked %d references, want 1", n)
+	}
+
+	now = now.Add(2 * time.Minute)
+	v.Validate(t.Context(), req)
+	if n := policy.count(); n != 2 {
+		t.Errorf("policy checked %d references after expiry, want 2", n)
+	}
+}
+
+// namespaceResolver resolves short personality names in the namespace
+// of the context.
+type namespaceResolver struct{ *fakeResolver }
+
+func (r namespaceResolver) ResolvePersonalityRef(ctx context.Context, ref string) (string, error) {
+	namespace, _ := oci.ContextNamespace(ctx)
+	return "registry.example.com/" + namespace + "/klaus-personalities/" + ref, nil
+}
+
+func TestValidate_CacheKeyedByTenant(t *testing.T) {
+	fake := newFakeResolver()
+	fake.digest["registry.example.com/team-a/klaus-personalities/sre:v1.0.0"] = "sha256:aaa"
+	fake.digest["registry.example.com/team-b/klaus-personalities/sre:v1.0.0"] = "sha256:bbb"
+	policy := &countingPolicy{}
+	v := New(namespaceResolver{fake}, WithPolicy(policy))
+
+	req := Request{Personality: "sre:v1.0.0"}
+	teamA := oci.WithContextNamespace(t.Context(), "team-a")
+	teamB := oci.WithContextNamespace(t.Context(), "team-b")
+	v.Validate(teamA, req)
+	v.Validate(teamB, req)
+	if n := policy.count(); n != 2 {
+		t.Errorf("policy checked %d references for two namespaces, want 2", n)
+	}
+
+	alice := oci.WithContextCredentials(teamA, auth.Credential{Username: "alice", Password: "a"})
+	bob := oci.WithContextCredentials(teamA, auth.Credential{Username: "bob", Password: "b"})
+	v.Validate(alice, req)
+	v.Validate(bob, req)
+	v.Validate(alice, req)
+	if n := policy.count(); n != 4 {
+		t.Errorf("policy checked %d references, want one per namespace and credential (4)", n)
+	}
+	for key := range v.cache {
+		if strings.Contains(key.credential, "alice") || strings.Contains(key.credential, "bob") {
+			t.Errorf("cache key %+v reveals the credential", key)
+		}
+	}
+}
+
+func TestValidate_CacheBounded(t *testing.T) {
+	v := New(newFakeResolver())
+	now := time.Now()
+	v.now = func() time.Time { return now }
+
+	for i := range maxCacheEntries + 10 {
+		v.store(cacheKey{resolved: fmt.Sprint(i)})
+	}
+	if len(v.cache) != maxCacheEntries {
+		t.Errorf("cache holds %d entries, want %d", len(v.cache), maxCacheEntries)
+	}
+
+	now = now.Add(2 * DefaultCacheTTL)
+	v.store(cacheKey{resolved: "new"})
+	if len(v.cache) != 1 {
+		t.Errorf("cache holds %d entries after expiry, want the new one", len(v.cache))
+	}
+}
+
+func TestValidate_CacheDisabled(t *testing.T) {
+	r := newFakeResolver()
+	v := New(r, WithCacheTTL(0))
+
+	for range 2 {
+		v.Validate(t.Context(), Request{Personality: "sre:v1.0.0"})
+	}
+	if r.resolves != 2 {
+		t.Errorf("Resolve called %d times, want 2", r.resolves)
+	}
+}
+
+func TestAllowedRegistries(t *testing.T) {
+	p := AllowedRegistries("gsoci.azurecr.io/giantswarm/", "localhost:5000")
+
+	tests := []struct {
+		ref     string
+		allowed bool
+	}{
+		{"gsoci.azurecr.io/giantswarm/klaus-plugins/gs-base:v1.0.0", true},
+		{"localhost:5000/plugins/x@sha256:abc", true},
+		{"gsoci.azurecr.io/giantswarmer/x:v1", false},
+		{"docker.io/library/alpine:3", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			err := p.Check(t.Context(), oci.KindPlugin, tt.ref, "")
+			if (err == nil) != tt.allowed {
+				t.Errorf("Check(%q) = %v, want allowed=%v", tt.ref, err, tt.allowed)
+			}
+		})
+	}
+}
+
+// fakeEvaluator evaluates policies against fixed scan summaries by
+// reference.
+type fakeEvaluator struct {
+	scans map[string]*oci.ScanSummary
+
+	mu   sync.Mutex
+	refs []string
+}
+
+func (f *fakeEvaluator) EvaluateSecurityPolicy(_ context.Context, ref string, policy oci.SecurityPolicy) (*oci.PolicyResult, error) {
+	f.mu.Lock()
+	f.refs = append(f.refs, ref)
+	f.mu.Unlock()
+	scan, ok := f.scans[ref]
+	if !ok {
+		return nil, fmt.Errorf("%s: not found", ref)
+	}
+	return policy.Evaluate(scan, time.Now()), nil
+}
+
+func TestScanPolicy(t *testing.T) {
+	clean := &oci.ScanSummary{Timestamp: time.Now()}
+	vulnerable := &oci.ScanSummary{Timestamp: time.Now(), Vulnerabilities: []oci.Vulnerability{{ID: "CVE-2026-1", Severity: oci.VulnerabilityCritical}}}
+	e := &fakeEvaluator{scans: map[string]*oci.ScanSummary{
+		oci.DefaultPluginRegistry + "/gs-base@sha256:ccc":  clean,
+		oci.DefaultPersonalityRegistry + "/sre@sha256:aaa": vulnerable,
+		oci.DefaultToolchainRegistry + "/go@sha256:bbb":    nil,
+		"evil.example.com/plugins/miner@sha256:ddd":        clean,
+	}}
+	v := New(newFakeResolver(), WithPolicy(ScanPolicy(e, oci.SecurityPolicy{MaxSeverity: oci.VulnerabilityHigh})))
+
+	resp := v.Validate(t.Context(), Request{
+		Personality: "sre",
+		Toolchain:   "go:v1.2.0",
+		Plugins:     []string{"gs-base"},
+	})
+	if resp.Allowed {
+		t.Fatal("Allowed = true, want violations for the vulnerable and unscanned artifacts")
+	}
+	if len(resp.Violations) != 2 {
+		t.Fatalf("Violations = %v, want 2", resp.Violations)
+	}
+	for _, v := range resp.Violations {
+		switch v.Field {
+		case "spec.personality":
+			if !strings.Contains(v.Message, "CVE-2026-1") {
+				t.Errorf("personality violation = %q, want the CVE", v.Message)
+			}
+		case "spec.toolchain":
+			if !strings.Contains(v.Message, "no scan summary") {
+				t.Errorf("toolchain violation = %q, want missing scan", v.Message)
+			}
+		default:
+			t.Errorf("unexpected violation %v", v)
+		}
+	}
+	if len(e.refs) != 3 {
+		t.Errorf("evaluated %v, want the 3 digest-pinned refs", e.refs)
+	}
+}
+
+// fakeTrustVerifier passes references with a digest in passed.
+type fakeTrustVerifier struct {
+	passed map[string]bool
+}
+
+func (f fakeTrustVerifier) VerifyTrust(_ context.Context, ref string) (*oci.TrustResult, error) {
+	result := &oci.TrustResult{Ref: ref, Passed: f.passed[ref]}
+	if !result.Passed {
+		result.Reasons = []string{"no provenance attestation attached"}
+	}
+	return result, nil
+}
+
+func TestTrustPolicy(t *testing.T) {
+	f := fakeTrustVerifier{passed: map[string]bool{
+		oci.DefaultPersonalityRegistry + "/sre@sha256:aaa": true,
+		oci.DefaultToolchainRegistry + "/go@sha256:bbb":    true,
+	}}
+	v := New(newFakeResolver(), WithPolicy(TrustPolicy(f)))
+
+	resp := v.Validate(t.Context(), Request{
+		Personality: "sre",
+		Toolchain:   "go:v1.2.0",
+		Plugins:     []string{"gs-base"},
+	})
+	if resp.Allowed || len(resp.Violations) != 1 {
+		t.Fatalf("Violations = %v, want one for the plugin", resp.Violations)
+	}
+	if v := resp.Violations[0]; v.Field != "spec.plugins[0]" || !strings.Contains(v.Message, "trust policy violated") {
+		t.Errorf("violation = %v", v)
+	}
+}
+
+var _ Resolver = (*oci.Client)(nil)
+var _ SecurityEvaluator = (*oci.Client)(nil)
+var _ TrustVerifier = (*oci.Client)(nil)
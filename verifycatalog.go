@@ -0,0 +1,270 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// CatalogCheck names a check performed by VerifyCatalog.
+type CatalogCheck string
+
+const (
+	// CheckTags fails for repositories without semver tags.
+	CheckTags CatalogCheck = "tags"
+	// CheckManifest fails for manifests or config blobs that cannot be
+	// fetched or parsed.
+	CheckManifest CatalogCheck = "manifest"
+	// CheckMediaType fails for artifacts of an unknown kind or of a kind
+	// other than their namespace holds.
+	CheckMediaType CatalogCheck = "mediaType"
+	// CheckAnnotations fails for plugins and personalities without the
+	// Klaus name and type annotations.
+	CheckAnnotations CatalogCheck = "annotations"
+	// CheckReferences fails for personalities referencing plugins or
+	// toolchains that do not exist.
+	CheckReferences CatalogCheck = "references"
+)
+
+// Severity grades a catalog issue.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// CatalogIssue is a problem found by VerifyCatalog.
+type CatalogIssue struct {
+	// Ref is the affected repository, or reference for issues of a
+	// single tag.
+	Ref      string       `json:"ref"`
+	Check    CatalogCheck `json:"check"`
+	Severity Severity     `json:"severity"`
+	Message  string       `json:"message"`
+}
+
+// CatalogReport is the result of VerifyCatalog. It is meant to be
+// serialized as JSON, e.g. as the output of a nightly CI job.
+type CatalogReport struct {
+	// Repositories is the number of repositories checked.
+	Repositories int `json:"repositories"`
+	// Artifacts is the number of tags checked.
+	Artifacts int `json:"artifacts"`
+	// Issues lists the problems found, sorted by reference and check.
+	Issues []CatalogIssue `json:"issues"`
+}
+
+// OK reports whether the catalog has no error-level issues.
+func (r *CatalogReport) OK() bool {
+	return !slices.ContainsFunc(r.Issues, func(i CatalogIssue) bool { return i.Severity == SeverityError })
+}
+
+// VerifyCatalog checks every tag of every repository under bases (e.g.
+// DefaultPluginRegistry) for consistency: manifests and config blobs
+// parse, each artifact's kind matches its namespace, plugins and
+// personalities carry the Klaus name and type annotations, and the
+// plugins and toolchains referenced by personalities exist. Repositories
+// without semver tags are reported too.
+//
+// The expected kind of a namespace is derived from the last segment of its
+// base ("klaus-plugins", "klaus-personalities", "klaus-toolchains"); bases
+// named otherwise only have their artifacts' kinds checked for being
+// known. Problems with individual artifacts are reported as issues; an
+// error is only returned when a base cannot be listed.
+func (c *Client) VerifyCatalog(ctx context.Context, bases []string) (*CatalogReport, error) {
+	v := &catalogVerifier{client: c, report: &CatalogReport{Issues: []CatalogIssue{}}}
+
+	// target is a repository or tagged reference and the kind its
+	// namespace holds.
+	type target struct {
+		name string
+		kind Kind
+	}
+	var targets []target
+	for _, base := range bases {
+		repos, err := c.listRepositories(ctx, base)
+		if err != nil {
+			return nil, err
+		}
+		kind := namespaceKind(base)
+		for _, repo := range repos {
+			targets = append(targets, target{name: repo, kind: kind})
+		}
+	}
+	v.report.Repositories = len(targets)
+
+	var (
+		mu   sync.Mutex
+		refs []target
+	)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+	for _, t := range targets {
+		g.Go(func() error {
+			tags, err := c.List(gctx, t.name)
+			if err != nil {
+				v.issue(t.name, CheckTags, SeverityError, "listing tags: %v", err)
+				return nil
+			}
+			if len(sortedSemverTags(tags)) == 0 {
+				v.issue(t.name, CheckTags, SeverityError, "no semver tags")
+			}
+			mu.Lock()
+			for _, tag := range tags {
+				refs = append(refs, target{name: t.name + ":" + tag, kind: t.kind})
+			}
+			mu.Unlock()
+			return nil
+		})
+	}
+	_ = g.Wait()
+	v.report.Artifacts = len(refs)
+
+	g, gctx = errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+	for _, r := range refs {
+		g.Go(func() error {
+			v.verifyArtifact(gctx, r.name, r.kind)
+			return nil
+		})
+	}
+	_ = g.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(v.report.Issues, func(a, b CatalogIssue) int {
+		if c := strings.Compare(a.Ref, b.Ref); c != 0 {
+			return c
+		}
+		if c := strings.Compare(string(a.Check), string(b.Check)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Message, b.Message)
+	})
+	return v.report, nil
+}
+
+// namespaceKind derives the artifact kind a registry base holds from its
+// last path segment, or "" when it does not name one.
+func namespaceKind(base string) Kind {
+	name := ShortName(strings.TrimSuffix(base, "/"))
+	switch {
+	case strings.Contains(name, "plugin"):
+		return KindPlugin
+	case strings.Contains(name, "personalit"):
+		return KindPersonality
+	case strings.Contains(name, "toolchain"):
+		return KindToolchain
+	}
+	return ""
+}
+
+// catalogVerifier collects the issues of a VerifyCatalog run.
+type catalogVerifier struct {
+	client *Client
+
+	mu     sync.Mutex
+	report *CatalogReport
+	// exists caches reference existence checks shared by personalities.
+	exists sync.Map
+}
+
+func (v *catalogVerifier) issue(ref string, check CatalogCheck, severity Severity, format string, args ...any) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.report.Issues = append(v.report.Issues, CatalogIssue{
+		Ref:      ref,
+		Check:    check,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// verifyArtifact checks the artifact at ref, expected to be of kind
+// unless kind is empty.
+func (v *catalogVerifier) verifyArtifact(ctx context.Context, ref string, kind Kind) {
+	fm, err := v.client.fetchManifest(ctx, ref)
+	if err != nil {
+		v.issue(ref, CheckManifest, SeverityError, "%v", err)
+		return
+	}
+	got, ok := kindOfManifest(fm.mediaType, fm.manifest.ArtifactType, fm.manifest.Config.MediaType)
+	switch {
+	case !ok:
+		v.issue(ref, CheckMediaType, SeverityError, "unrecognized artifact type (config media type %q)", fm.manifest.Config.MediaType)
+		return
+	case kind != "" && got != kind:
+		v.issue(ref, CheckMediaType, SeverityError, "%s in a %s namespace", got, kind)
+	}
+	if got == KindToolchain {
+		return
+	}
+
+	annotations := fm.manifest.Annotations
+	if annotations[AnnotationName] == "" {
+		v.issue(ref, CheckAnnotations, SeverityError, "missing %s annotation", AnnotationName)
+	}
+	switch annotated := KindFromAnnotations(annotations); {
+	case annotated == "":
+		v.issue(ref, CheckAnnotations, SeverityWarning, "missing %s annotation", AnnotationType)
+	case annotated != got:
+		v.issue(ref, CheckAnnotations, SeverityError, "%s annotation says %s, media types say %s", AnnotationType, annotated, got)
+	}
+
+	configJSON, err := fetchConfigBlob(ctx, fm.repo, ref, fm.manifest.Config)
+	if err != nil {
+		v.issue(ref, CheckManifest, SeverityError, "%v", err)
+		return
+	}
+	if got == KindPlugin {
+		var blob pluginConfigBlob
+		if err := json.Unmarshal(configJSON, &blob); err != nil {
+			v.issue(ref, CheckManifest, SeverityError, "parsing plugin config: %v", err)
+		}
+		return
+	}
+
+	var blob personalityConfigBlob
+	if err := json.Unmarshal(configJSON, &blob); err != nil {
+		v.issue(ref, CheckManifest, SeverityError, "parsing personality config: %v", err)
+		return
+	}
+	if blob.Toolchain.Repository != "" {
+		if err := v.referenceExists(ctx, blob.Toolchain.Ref()); err != nil {
+			v.issue(ref, CheckReferences, SeverityError, "toolchain %s: %v", blob.Toolchain.Ref(), err)
+		}
+	}
+	for _, p := range blob.Plugins {
+		if err := v.referenceExists(ctx, p.Ref()); err != nil {
+			v.issue(ref, CheckReferences, SeverityError, "plugin %s: %v", p.Ref(), err)
+		}
+	}
+}
+
+// referenceExists checks that ref resolves; references without a tag or
+// digest must have a semver tag. Results are cached per reference.
+func (v *catalogVerifier) referenceExists(ctx context.Context, ref string) error {
+	if cached, ok := v.exists.Load(ref); ok {
+		if cached == nil {
+			return nil
+		}
+		return cached.(error)
+	}
+	var err error
+	if hasTagOrDigest(ref) {
+		_, err = v.client.Resolve(ctx, ref)
+	} else {
+		_, err = v.client.ResolveLatestVersion(ctx, ref)
+	}
+	if ctx.Err() == nil {
+		v.exists.Store(ref, err)
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+package oci
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifyCatalog(t *testing.T) {
+	reg := newCacheRegistry()
+	host := newPullTestRegistry(t, reg)
+	client := NewClient(WithPlainHTTP(true))
+	plugins := host + "/klaus-plugins"
+	personalities := host + "/klaus-personalities"
+
+	pluginSrc := t.TempDir()
+	writeFile(t, filepath.Join(pluginSrc, ".claude-plugin", "plugin.json"), `{"name":"gs-base"}`)
+	if _, err := client.PushPlugin(t.Context(), pluginSrc, plugins+"/gs-base:v1.0.0", Plugin{Name: "gs-base"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.PushPlugin(t.Context(), pluginSrc, plugins+"/gs-dev:main", Plugin{Name: "gs-dev"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A plugin pushed by a tool that sets no annotations.
+	built, err := BuildPlugin(pluginSrc, Plugin{Name: "gs-bare"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	built.Manifest.Annotations = nil
+	bare, err := json.Marshal(built.Manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg.addBlob(built.Config)
+	reg.addBlob(built.Layer)
+	reg.addManifest("klaus-plugins/gs-bare", "v1.0.0", bare)
+
+	soulSrc := t.TempDir()
+	writeFile(t, filepath.Join(soulSrc, "SOUL.md"), "Be calm.")
+	sre := Personality{
+		Name: "sre",
+		Plugins: []PluginReference{
+			{Repository: plugins + "/gs-base", Tag: "v1.0.0"},
+			{Repository: plugins + "/gs-gone", Tag: "v1.0.0"},
+		},
+	}
+	if _, err := client.PushPersonality(t.Context(), soulSrc, personalities+"/sre:v1.0.0", sre); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.PushPersonality(t.Context(), soulSrc, plugins+"/misplaced:v1.0.0", Personality{Name: "misplaced"}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := client.VerifyCatalog(t.Context(), []string{plugins, personalities})
+	if err != nil {
+		t.Fatalf("VerifyCatalog() error = %v", err)
+	}
+	if report.Repositories != 5 || report.Artifacts != 5 {
+		t.Errorf("checked %d repositories and %d artifacts, want 5 and 5", report.Repositories, report.Artifacts)
+	}
+	if report.OK() {
+		t.Error("OK() = true for a catalog with errors")
+	}
+
+	want := []CatalogIssue{
+		{Ref: personalities + "/sre:v1.0.0", Check: CheckReferences, Severity: SeverityError},
+		{Ref: plugins + "/gs-bare:v1.0.0", Check: CheckAnnotations, Severity: SeverityError},
+		{Ref: plugins + "/gs-bare:v1.0.0", Check: CheckAnnotations, Severity: SeverityWarning},
+		{Ref: plugins + "/gs-dev", Check: CheckTags, Severity: SeverityError},
+		{Ref: plugins + "/misplaced:v1.0.0", Check: CheckMediaType, Severity: SeverityError},
+	}
+	if len(report.Issues) != len(want) {
+		t.Fatalf("Issues = %+v, want %d issues", report.Issues, len(want))
+	}
+	for i, w := range want {
+		got := report.Issues[i]
+		if got.Ref != w.Ref || got.Check != w.Check || got.Severity != w.Severity {
+			t.Errorf("Issues[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+	if msg := report.Issues[0].Message; !strings.Contains(msg, "gs-gone") {
+		t.Errorf("reference issue = %q, want gs-gone", msg)
+	}
+}
+
+func TestNamespaceKind(t *testing.T) {
+	tests := map[string]Kind{
+		DefaultPluginRegistry:             KindPlugin,
+		DefaultPersonalityRegistry:        KindPersonality,
+		DefaultToolchainRegistry:          KindToolchain,
+		"ghcr.io/acme/klaus-plugins/":     KindPlugin,
+		"registry.example.com/team/stuff": "",
+	}
+	for base, want := range tests {
+		if got := namespaceKind(base); got != want {
+			t.Errorf("namespaceKind(%s) = %q, want %q", base, got, want)
+		}
+	}
+}
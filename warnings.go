@@ -0,0 +1,129 @@
+package oci
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RegistryWarning is a warning a registry sent in a Warning response
+// header, e.g. announcing a deprecation or upcoming throttling. The OCI
+// distribution spec has registries send them with code 299.
+type RegistryWarning struct {
+	// Host is the registry host that sent the warning.
+	Host string
+	// Code is the warn-code, usually 299.
+	Code int
+	// Agent is the warn-agent, usually "-".
+	Agent string
+	// Text is the warning message.
+	Text string
+}
+
+func (w RegistryWarning) String() string {
+	return w.Host + ": " + w.Text
+}
+
+// WithRegistryWarningHandler calls fn for each distinct warning registries
+// send, once per client, so users learn about registry-side deprecations
+// before they break. fn is called from the goroutine making the request,
+// one call at a time, and must not block. Client.RegistryWarnings lists
+// the warnings regardless. Warnings are only captured by clients whose
+// HTTP transport was configured by NewClient.
+func WithRegistryWarningHandler(fn func(RegistryWarning)) ClientOption {
+	return func(c *Client) { c.warnings.handler = fn }
+}
+
+// RegistryWarnings returns the distinct warnings registries have sent to
+// the client so far, in the order they were first received.
+func (c *Client) RegistryWarnings() []RegistryWarning {
+	c.warnings.mu.Lock()
+	defer c.warnings.mu.Unlock()
+	return slices.Clone(c.warnings.seen)
+}
+
+// registryWarnings collects the warnings of a client.
+type registryWarnings struct {
+	handler func(RegistryWarning)
+
+	mu   sync.Mutex
+	seen []RegistryWarning
+}
+
+// add records w and reports it to the handler unless it was seen before.
+func (r *registryWarnings) add(w RegistryWarning) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if slices.Contains(r.seen, w) {
+		return
+	}
+	r.seen = append(r.seen, w)
+	if r.handler != nil {
+		r.handler(w)
+	}
+}
+
+// warningTransport captures the Warning headers of registry responses.
+type warningTransport struct {
+	base     http.RoundTripper
+	warnings *registryWarnings
+}
+
+func (t *warningTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	for _, v := range resp.Header.Values("Warning") {
+		w := parseWarningHeader(v)
+		w.Host = req.URL.Host
+		t.warnings.add(w)
+	}
+	return resp, nil
+}
+
+// parseWarningHeader parses a Warning header value of the form
+// `299 - "text"`, optionally followed by a date. Values that do not
+// follow the format are kept whole as the text.
+func parseWarningHeader(v string) RegistryWarning {
+	raw := strings.TrimSpace(v)
+	codeStr, rest, ok := strings.Cut(raw, " ")
+	code, err := strconv.Atoi(codeStr)
+	if !ok || err != nil {
+		return RegistryWarning{Text: raw}
+	}
+	agent, rest, ok := strings.Cut(strings.TrimLeft(rest, " "), " ")
+	if !ok {
+		return RegistryWarning{Text: raw}
+	}
+	text, ok := unquoteWarnText(strings.TrimLeft(rest, " "))
+	if !ok {
+		return RegistryWarning{Text: raw}
+	}
+	return RegistryWarning{Code: code, Agent: agent, Text: text}
+}
+
+// unquoteWarnText returns the quoted string at the start of s, with
+// quoted-pair escapes resolved, ignoring anything after it.
+func unquoteWarnText(s string) (string, bool) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", false
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return b.String(), true
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", false
+}
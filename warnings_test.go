@@ -0,0 +1,56 @@
+package oci
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWarnings(t *testing.T) {
+	reg := newCacheRegistry()
+	handler := reg.handler()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Warning", `299 - "this endpoint is deprecated, use \"v3\""`)
+		w.Header().Add("Warning", `299 - "rate limits apply from 2026-11-01" "Sat, 01 Nov 2026 00:00:00 GMT"`)
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	host := strings.TrimPrefix(ts.URL, "http://")
+
+	var handled []RegistryWarning
+	client := NewClient(WithPlainHTTP(true), WithRegistryWarningHandler(func(w RegistryWarning) {
+		handled = append(handled, w)
+	}))
+	pushVersions(t, client, host+"/klaus/sre", "v1.0.0", "v1.1.0")
+
+	want := []RegistryWarning{
+		{Host: host, Code: 299, Agent: "-", Text: `this endpoint is deprecated, use "v3"`},
+		{Host: host, Code: 299, Agent: "-", Text: "rate limits apply from 2026-11-01"},
+	}
+	got := client.RegistryWarnings()
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("RegistryWarnings() = %+v, want %+v", got, want)
+	}
+	if len(handled) != len(want) {
+		t.Errorf("handler called with %+v, want each warning once", handled)
+	}
+}
+
+func TestParseWarningHeader(t *testing.T) {
+	tests := []struct {
+		header string
+		want   RegistryWarning
+	}{
+		{`299 - "deprecated"`, RegistryWarning{Code: 299, Agent: "-", Text: "deprecated"}},
+		{`199 registry.example.com "a \"quoted\" word" "Sat, 01 Nov 2026 00:00:00 GMT"`, RegistryWarning{Code: 199, Agent: "registry.example.com", Text: `a "quoted" word`}},
+		{`deprecated endpoint`, RegistryWarning{Text: "deprecated endpoint"}},
+		{`299 - unquoted`, RegistryWarning{Text: "299 - unquoted"}},
+		{`299 - "unterminated`, RegistryWarning{Text: `299 - "unterminated`}},
+	}
+	for _, tt := range tests {
+		if got := parseWarningHeader(tt.header); got != tt.want {
+			t.Errorf("parseWarningHeader(%q) = %+v, want %+v", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package oci
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WritePersonalityToDir writes p to personality.yaml in dir, the inverse
+// of ReadPersonalityFromDir. When the file exists, it is updated in place:
+// comments, key order, and keys the Personality type does not know are
+// kept, and list entries are matched by their repository so comments
+// stay with the right plugin. SOUL.md is not touched.
+func WritePersonalityToDir(dir string, p Personality) error {
+	if p.Name == "" {
+		return fmt.Errorf("personality name is required")
+	}
+	var updated yaml.Node
+	if err := updated.Encode(p); err != nil {
+		return fmt.Errorf("encoding personality.yaml: %w", err)
+	}
+
+	path := filepath.Join(dir, "personality.yaml")
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("reading personality.yaml: %w", err)
+	default:
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("parsing personality.yaml: %w", err)
+		}
+		if len(doc.Content) == 1 && doc.Content[0].Kind == yaml.MappingNode {
+			mergeYAMLMapping(doc.Content[0], &updated, yamlKeys(reflect.TypeFor[Personality]()))
+			updated = doc
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&updated); err != nil {
+		return fmt.Errorf("encoding personality.yaml: %w", err)
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// yamlKeys returns the YAML keys of the fields of struct type t.
+func yamlKeys(t reflect.Type) map[string]bool {
+	keys := make(map[string]bool, t.NumField())
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name != "-" && name != "" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
+
+// mergeYAMLMapping updates the mapping dst to hold the values of src,
+// keeping the comments and key order of dst. Keys missing from src are
+// removed when owned reports them or owned is nil.
+func mergeYAMLMapping(dst, src *yaml.Node, owned map[string]bool) {
+	values := make(map[string]*yaml.Node, len(src.Content)/2)
+	var order []string
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		values[src.Content[i].Value] = src.Content[i+1]
+		order = append(order, src.Content[i].Value)
+	}
+
+	var content []*yaml.Node
+	seen := map[string]bool{}
+	for i := 0; i+1 < len(dst.Content); i += 2 {
+		key, value := dst.Content[i], dst.Content[i+1]
+		v, ok := values[key.Value]
+		if !ok && (owned == nil || owned[key.Value]) {
+			continue
+		}
+		if ok {
+			mergeYAMLNode(value, v)
+			seen[key.Value] = true
+		}
+		content = append(content, key, value)
+	}
+	for _, k := range order {
+		if !seen[k] {
+			content = append(content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}, values[k])
+		}
+	}
+	dst.Content = content
+}
+
+// mergeYAMLNode updates dst to hold the value of src, keeping the
+// comments of dst and of the entries that remain.
+func mergeYAMLNode(dst, src *yaml.Node) {
+	switch {
+	case dst.Kind != src.Kind:
+	case dst.Kind == yaml.MappingNode:
+		mergeYAMLMapping(dst, src, nil)
+		return
+	case dst.Kind == yaml.SequenceNode:
+		mergeYAMLSequence(dst, src)
+		return
+	case dst.Kind == yaml.ScalarNode && dst.Tag == src.Tag:
+		dst.Value = src.Value
+		return
+	}
+	head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+	*dst = *src
+	dst.HeadComment, dst.LineComment, dst.FootComment = head, line, foot
+}
+
+// mergeYAMLSequence updates the sequence dst to hold the items of src.
+// Items are matched by their identity (see yamlIdentity), so the comments
+// of kept items survive reordering, insertion, and removal.
+func mergeYAMLSequence(dst, src *yaml.Node) {
+	old := map[string]*yaml.Node{}
+	for _, item := range dst.Content {
+		if id := yamlIdentity(item); id != "" {
+			old[id] = item
+		}
+	}
+	content := make([]*yaml.Node, 0, len(src.Content))
+	for _, item := range src.Content {
+		if prev, ok := old[yamlIdentity(item)]; ok {
+			delete(old, yamlIdentity(item))
+			mergeYAMLNode(prev, item)
+			item = prev
+		}
+		content = append(content, item)
+	}
+	dst.Content = content
+}
+
+// yamlIdentity identifies a sequence item: a scalar by its value, a
+// mapping by its repository. Other items have no identity.
+func yamlIdentity(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return "scalar:" + n.Value
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == "repository" {
+				return "repository:" + n.Content[i+1].Value
+			}
+		}
+	}
+	return ""
+}
+
+// WritePluginManifest writes the manifest metadata of p to
+// .claude-plugin/plugin.json in dir, and its Klaus extensions to
+// .claude-plugin/klaus.json, the inverse of ReadPluginFromDir. Existing
+// files are updated in place, keeping key order and the fields Plugin
+// does not manage, such as version, hooks, or component path overrides.
+// Discovered components are not written, since ReadPluginFromDir derives
+// them from the directory. klaus.json is only created when p has
+// extensions.
+func WritePluginManifest(dir string, p Plugin) error {
+	if p.Name == "" {
+		return fmt.Errorf("plugin name is required")
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".claude-plugin"), 0o755); err != nil {
+		return err
+	}
+
+	manifest := map[string]any{
+		"name":        p.Name,
+		"description": nonZero(p.Description),
+		"author":      p.Author,
+		"homepage":    nonZero(p.Homepage),
+		"repository":  nonZero(p.SourceRepo),
+		"license":     nonZero(p.License),
+		"keywords":    p.Keywords,
+	}
+	manifestOrder := []string{"name", "description", "author", "homepage", "repository", "license", "keywords"}
+	if err := updateJSONFile(filepath.Join(dir, ".claude-plugin", "plugin.json"), manifestOrder, manifest, true); err != nil {
+		return err
+	}
+
+	ext := map[string]any{
+		"dependencies": p.Dependencies,
+		"secrets":      p.Secrets,
+		"permissions":  p.Permissions,
+		"private":      nonZero(p.Private),
+	}
+	return updateJSONFile(filepath.Join(dir, klausExtensionFile), []string{"dependencies", "secrets", "permissions", "private"}, ext, false)
+}
+
+// updateJSONFile sets the fields of the JSON object in path to values,
+// removing fields whose value is nil or encodes to null, so that empty
+// but set values such as "permissions": {} are kept. order is the order
+// of new fields; existing fields keep their position and fields not in
+// values are kept. A missing file is created, unless create is false and
+// all values are nil.
+func updateJSONFile(path string, order []string, values map[string]any, create bool) error {
+	name := filepath.Base(path)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("reading %s: %w", name, err)
+	}
+	exists := err == nil
+
+	var keys []string
+	fields := map[string]json.RawMessage{}
+	if exists {
+		if keys, fields, err = readJSONObject(data); err != nil {
+			return fmt.Errorf("parsing %s: %w", name, err)
+		}
+	}
+
+	empty := true
+	for _, k := range order {
+		raw, err := marshalJSON(values[k])
+		if err != nil {
+			return fmt.Errorf("encoding %s: %w", name, err)
+		}
+		if string(raw) == "null" {
+			delete(fields, k)
+			continue
+		}
+		empty = false
+		if _, ok := fields[k]; !ok {
+			keys = append(keys, k)
+		}
+		fields[k] = raw
+	}
+	if !exists && !create && empty {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for _, k := range keys {
+		raw, ok := fields[k]
+		if !ok {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		key, _ := marshalJSON(k)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(raw)
+	}
+	buf.WriteByte('}')
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return fmt.Errorf("encoding %s: %w", name, err)
+	}
+	out.WriteByte('\n')
+	return os.WriteFile(path, out.Bytes(), 0o644)
+}
+
+// readJSONObject decodes a JSON object into its fields, returning the keys
+// in document order.
+func readJSONObject(data []byte) ([]string, map[string]json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil {
+		return nil, nil, err
+	} else if tok != json.Delim('{') {
+		return nil, nil, fmt.Errorf("not a JSON object")
+	}
+	var keys []string
+	fields := map[string]json.RawMessage{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+		if _, dup := fields[key]; !dup {
+			keys = append(keys, key)
+		}
+		fields[key] = raw
+	}
+	return keys, fields, nil
+}
+
+// marshalJSON encodes v like json.Marshal, but without escaping HTML
+// characters, so values such as "A & B <a@b>" are written as they are.
+func marshalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// nonZero returns v, or nil if v is the zero value of its type, so that
+// updateJSONFile removes unset fields.
+func nonZero[T comparable](v T) any {
+	var zero T
+	if v == zero {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,176 @@
+package oci
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWritePersonalityToDir_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "personality.yaml"), `# The SRE personality.
+name: sre
+description: Site reliability # keep me
+custom: value
+plugins:
+  # Base tooling.
+  - repository: example.com/klaus-plugins/gs-base
+    tag: v1.0.0
+  # Flux support.
+  - repository: example.com/klaus-plugins/gs-flux
+    tag: v1.0.0
+`)
+
+	p, err := ReadPersonalityFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Description = "Keeps things running"
+	p.Plugins = append(p.Plugins, PluginReference{Repository: "example.com/klaus-plugins/gs-helm", Tag: "v2.0.0"})
+	p.Plugins[0].Tag = "v1.1.0"
+	p.Plugins = slices.Delete(p.Plugins, 1, 2)
+	if err := WritePersonalityToDir(dir, *p); err != nil {
+		t.Fatalf("WritePersonalityToDir() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "personality.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, s := range []string{"# The SRE personality.", "description: Keeps things running # keep me", "custom: value", "# Base tooling.", "tag: v1.1.0", "gs-helm"} {
+		if !strings.Contains(got, s) {
+			t.Errorf("personality.yaml missing %q:\n%s", s, got)
+		}
+	}
+	if strings.Contains(got, "gs-flux") || strings.Contains(got, "Flux support") {
+		t.Errorf("personality.yaml still has the removed plugin:\n%s", got)
+	}
+
+	back, err := ReadPersonalityFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.Description != p.Description || !slices.Equal(back.Plugins, p.Plugins) {
+		t.Errorf("read back %+v, want %+v", back, p)
+	}
+}
+
+func TestWritePersonalityToDir_New(t *testing.T) {
+	dir := t.TempDir()
+	want := Personality{Name: "sre", Toolchain: ToolchainReference{Repository: "example.com/klaus-toolchains/go", Tag: "v1.0.0"}}
+	if err := WritePersonalityToDir(dir, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadPersonalityFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != want.Name || got.Toolchain != want.Toolchain {
+		t.Errorf("read back %+v, want %+v", got, want)
+	}
+	if err := WritePersonalityToDir(dir, Personality{}); err == nil {
+		t.Error("WritePersonalityToDir() without a name succeeded")
+	}
+}
+
+func TestWritePluginManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".claude-plugin", "plugin.json"), `{
+  "name": "gs-base",
+  "version": "1.0.0",
+  "description": "Old",
+  "hooks": "./config/hooks.json",
+  "license": "MIT"
+}`)
+	writeFile(t, filepath.Join(dir, "skills", "k8s", "SKILL.md"), "# k8s")
+
+	p, err := ReadPluginFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Description = "Base tools"
+	p.License = ""
+	p.Keywords = []string{"kubernetes"}
+	p.Dependencies = []PluginReference{{Repository: "example.com/klaus-plugins/gs-core", Tag: "v1.0.0"}}
+	if err := WritePluginManifest(dir, *p); err != nil {
+		t.Fatalf("WritePluginManifest() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, ".claude-plugin", "plugin.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "name": "gs-base",
+  "version": "1.0.0",
+  "description": "Base tools",
+  "hooks": "./config/hooks.json",
+  "keywords": [
+    "kubernetes"
+  ]
+}
+`
+	if string(data) != want {
+		t.Errorf("plugin.json =\n%s\nwant\n%s", data, want)
+	}
+
+	back, err := ReadPluginFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.Description != "Base tools" || !slices.Equal(back.Dependencies, p.Dependencies) || !slices.Equal(back.Skills, []string{"k8s"}) {
+		t.Errorf("read back %+v", back)
+	}
+}
+
+func TestWritePluginManifest_NoExtensions(t *testing.T) {
+	dir := t.TempDir()
+	if err := WritePluginManifest(dir, Plugin{Name: "gs-base", Skills: []string{"ignored"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, klausExtensionFile)); !os.IsNotExist(err) {
+		t.Errorf("klaus.json created without extensions: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ".claude-plugin", "plugin.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "{\n  \"name\": \"gs-base\"\n}\n" {
+		t.Errorf("plugin.json = %s", data)
+	}
+}
+
+func TestWritePluginManifest_EmptyAndSpecialValues(t *testing.T) {
+	dir := t.TempDir()
+	p := Plugin{
+		Name:        "gs-base",
+		Description: "Helm & Kubernetes <tools>",
+		Author:      &Author{Name: "A & B", Email: "a@b"},
+		Permissions: &Permissions{},
+	}
+	if err := WritePluginManifest(dir, p); err != nil {
+		t.Fatalf("WritePluginManifest() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, ".claude-plugin", "plugin.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"Helm & Kubernetes <tools>"`) || !strings.Contains(string(data), `"A & B"`) {
+		t.Errorf("plugin.json escapes HTML characters:\n%s", data)
+	}
+
+	back, err := ReadPluginFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.Permissions == nil {
+		t.Error("empty declared permissions read back as nil")
+	}
+	if back.Description != p.Description || back.Author == nil || back.Author.Name != "A & B" {
+		t.Errorf("read back %+v", back)
+	}
+}